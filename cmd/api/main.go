@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,6 +16,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	_ "github.com/lib/pq" // PostgreSQL driver for the AOCS session store
 	"github.com/ocx/backend/internal/catalog"
 	"github.com/ocx/backend/internal/config"
 	"github.com/ocx/backend/internal/database"
@@ -32,10 +36,15 @@ import (
 	"github.com/ocx/backend/internal/middleware"
 	"github.com/ocx/backend/internal/multitenancy"
 	"github.com/ocx/backend/internal/plan"
+	"github.com/ocx/backend/internal/protocol"
 	"github.com/ocx/backend/internal/reputation"
 	"github.com/ocx/backend/internal/security"
 	"github.com/ocx/backend/internal/webhooks"
+	pb "github.com/ocx/backend/pb"
 	"github.com/ocx/backend/pkg/plugins"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
@@ -85,12 +94,39 @@ func main() {
 
 	// Initialize Patent Components — all values from config
 	federationRegistry := federation.NewFederationRegistry()
+	revocations := federation.NewRevocationRegistry(federation.OCXInstanceID(cfg.Federation.InstanceID))
+	revocations.SetRefreshInterval(time.Duration(cfg.Federation.TrustBundlePollSec) * time.Second)
+	federationRegistry.SetRevocations(revocations)
+	federationRegistry.SetDiscovery(federation.NewFederationDiscovery(federation.DiscoveryConfig{
+		CacheTTL:   time.Duration(cfg.Federation.DiscoveryRefreshSec) * time.Second,
+		RequireDNS: cfg.Federation.DiscoveryRequireDNS,
+	}))
 	trustLedger := federation.NewPersistentTrustLedger()
+	trustLedger.SetRetention(federation.TrustRetention{
+		HistoryPoints:  cfg.Federation.TrustHistoryPoints,
+		HistoryMaxAge:  time.Duration(cfg.Federation.TrustHistoryDays) * 24 * time.Hour,
+		AttestationAge: time.Duration(cfg.Federation.AttestationRetentionDays) * 24 * time.Hour,
+	})
+	if cfg.Federation.TrustStore == "postgres" {
+		db, err := sql.Open("postgres", cfg.Federation.TrustDatabaseURL)
+		if err == nil {
+			err = db.Ping()
+		}
+		if err == nil {
+			defer db.Close()
+			err = trustLedger.SetStore(context.Background(), federation.NewPostgresTrustLedgerStore(db))
+		}
+		if err != nil {
+			slog.Warn("Federation trust store Postgres unavailable; trust resets on restart", "error", err)
+		}
+	}
 
 	// SupabaseHandshakeStore — durable federation handshake sessions
+	var handshakeStore federation.HandshakeSessionStore = federation.NewInMemoryHandshakeStore()
 	if cfg.GetSupabaseURL() != "" && cfg.GetSupabaseKey() != "" {
-		handshakeStore := federation.NewSupabaseHandshakeStore(cfg.GetSupabaseURL(), cfg.GetSupabaseKey())
-		federationRegistry.SetHandshakeStore(handshakeStore)
+		supabaseHandshakes := federation.NewSupabaseHandshakeStore(cfg.GetSupabaseURL(), cfg.GetSupabaseKey())
+		federationRegistry.SetHandshakeStore(supabaseHandshakes)
+		handshakeStore = supabaseHandshakes
 		slog.Info("SupabaseHandshakeStore wired into FederationRegistry")
 	} else {
 		slog.Warn("Supabase not configured, federation handshakes use in-memory store")
@@ -126,6 +162,14 @@ func main() {
 	}
 	escrowGate := escrow.NewEscrowGate(juryClient, escrow.NewEntropyMonitorLive(cfg.Escrow.EntropyThreshold))
 	toolClassifier := escrow.NewToolClassifier()
+
+	// MCP tool list analysis — rug-pull and tool-poisoning risk feeds classification
+	var toolFingerprints security.ToolFingerprintStore
+	if redisAdapter != nil {
+		toolFingerprints = security.NewRedisToolFingerprintStore(redisAdapter, "ocx:")
+	}
+	toolListAnalyzer := security.NewToolListAnalyzer(toolFingerprints)
+	toolClassifier.SetToolRiskSource(toolListAnalyzer)
 	repWallet := reputation.NewReputationWallet(supabaseClient)
 
 	// =====================================================================
@@ -203,6 +247,183 @@ func main() {
 		slog.Info("SPIFFEVerifier wired into TriFactorGate", "socket", spiffeSocket)
 	}
 
+	// gRPC spoke transport — same Hub routing as /ws, authenticated by SPIFFE SVIDs
+	// Spoke tenant, trust and entitlements come from the agent directory,
+	// reputation wallet and JIT grants, never from the spoke itself
+	hub.SetSpokeAuthority(fabric.NewRegistrySpokeAuthority(supabaseClient, repWallet, jitEntitlements))
+
+	if cfg.Fabric.SpokeGRPCPort != "" {
+		// Refused unless SPIFFE mTLS is available or plaintext explicitly allowed
+		if spokeTLS, ok := listenerTLS("gRPC spoke transport", spiffeVerifier, cfg.Fabric.AllowInsecureSpokeTransport); ok {
+			if lis, err := net.Listen("tcp", ":"+cfg.Fabric.SpokeGRPCPort); err != nil {
+				slog.Warn("gRPC spoke transport listen failed", "port", cfg.Fabric.SpokeGRPCPort, "error", err)
+			} else {
+				spokeServer := fabric.NewSpokeGRPCServer(hub, spokeTLS, cfg.Fabric.AllowInsecureSpokeTransport)
+				go func() {
+					if err := spokeServer.Serve(lis); err != nil {
+						slog.Warn("gRPC spoke transport stopped", "error", err)
+					}
+				}()
+				defer spokeServer.GracefulStop()
+				slog.Info("gRPC spoke transport listening", "port", cfg.Fabric.SpokeGRPCPort, "mtls", spokeTLS != nil)
+			}
+		}
+	}
+
+	// External signing keys — federation and evidence keys held by a
+	// KMS-style key service, so no private key lives in this process
+	var externalKeys []*federation.ExternalKeyProvider
+	var federationSigner federation.CryptoProvider
+	if ks := cfg.Federation.KeyService; ks.URL != "" {
+		keyService := federation.NewHTTPKeyService(ks.URL, ks.Token, nil)
+		timeout := time.Duration(ks.TimeoutSec) * time.Second
+		signer, err := federation.NewExternalKeyProvider(context.Background(), keyService, ks.FederationKey, timeout)
+		if err != nil {
+			log.Fatalf("Failed to load federation key from key service: %v", err)
+		}
+		federationSigner = signer
+		externalKeys = append(externalKeys, signer)
+		slog.Info("Federation signing key held by key service", "key_id", ks.FederationKey,
+			"version", signer.Version().Version, "algorithm", signer.Algorithm())
+
+		if ks.EvidenceKey != "" {
+			evidenceSigner, err := federation.NewExternalKeyProvider(context.Background(), keyService, ks.EvidenceKey, timeout)
+			if err != nil {
+				log.Fatalf("Failed to load evidence key from key service: %v", err)
+			}
+			onFailure := evidence.ParseSignFailurePolicy(ks.EvidenceSignFailure)
+			evidenceVault.SetSigner(evidenceSigner, onFailure)
+			externalKeys = append(externalKeys, evidenceSigner)
+			slog.Info("Evidence records signed by key service", "key_id", ks.EvidenceKey, "on_failure", onFailure)
+		}
+	}
+
+	// Inter-OCX message transport — signed messages from federated peers are
+	// routed into the Hub, taxed by peer trust and recorded as evidence
+	federationManager, err := federation.NewFederationManager(federation.FederationConfig{
+		InstanceID:       federation.OCXInstanceID(cfg.Federation.InstanceID),
+		Region:           cfg.Federation.Region,
+		MaxPeers:         cfg.Federation.MaxPeers,
+		TrustTaxBaseRate: cfg.Federation.TrustTaxBaseRate,
+		MaxClockSkew:     time.Duration(cfg.Federation.MessageClockSkewSec) * time.Second,
+		CryptoAlgorithm:  federation.CryptoAlgorithm(cfg.Handshake.DefaultCryptoAlgorithm),
+		Signer:           federationSigner,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create federation manager: %v", err)
+	}
+	federationManager.SetTrustLedger(trustLedger)
+	federationManager.SetEvidenceVault(evidenceVault)
+	federationManager.SetLocalDelivery(hub)
+	revocations.SetSigner(federationManager.Crypto())
+	federationManager.SetRevocations(revocations)
+	federationRegistry.OnDiscovered(federationManager.PinPeer)
+	defer federationManager.Close()
+	// Peers' message endpoints are dialed with the same SPIFFE mTLS their
+	// servers require; without it outbound federation stays off
+	if dialCreds, ok := dialerTLS("Outbound federated messages", spiffeVerifier, cfg.Federation.AllowInsecureMessageTransport); ok {
+		federationManager.SetDialOptions(grpc.WithTransportCredentials(dialCreds))
+	}
+
+	// Inbound federated message IDs are remembered across replicas, so a
+	// replayed envelope is delivered once
+	if redisAdapter != nil {
+		federationManager.SetMessageReplayStore(
+			federation.NewRedisNonceStore(redisAdapter, "ocx:federated-message:", 10*time.Minute), 10*time.Minute)
+	}
+
+	// Imported reputation credentials and their challenges are single use
+	// across replicas
+	credentialWindow := time.Duration(cfg.Federation.Reputation.ReplayWindowSec) * time.Second
+	if redisAdapter != nil {
+		federationManager.SetCredentialReplayStore(
+			federation.NewRedisNonceStore(redisAdapter, "ocx:reputation-credential:", credentialWindow), credentialWindow)
+	} else {
+		federationManager.SetCredentialReplayStore(federation.NewInMemoryNonceStore(credentialWindow), credentialWindow)
+	}
+
+	// Inter-OCX handshake service — sessions persist to handshakeStore and
+	// challenge nonces are shared across replicas through Redis
+	handshakeService := federation.NewHandshakeServiceServer(&federation.OCXInstance{
+		InstanceID:   cfg.Federation.InstanceID,
+		TrustDomain:  cfg.Federation.TrustDomain,
+		Region:       cfg.Federation.Region,
+		Organization: cfg.Federation.Organization,
+	}, federation.NewTrustAttestationLedgerWithID(cfg.Federation.InstanceID))
+	handshakeService.SetSessionStore(handshakeStore)
+	handshakeService.SetRevocationChecker(revocations)
+	acceptedAlgorithms := make([]federation.CryptoAlgorithm, 0, len(cfg.Handshake.AcceptedCryptoAlgorithms))
+	for _, alg := range cfg.Handshake.AcceptedCryptoAlgorithms {
+		acceptedAlgorithms = append(acceptedAlgorithms, federation.CryptoAlgorithm(alg))
+	}
+	handshakeAlgorithms := federation.AlgorithmPreference(
+		federation.CryptoAlgorithm(cfg.Handshake.DefaultCryptoAlgorithm), acceptedAlgorithms)
+	if len(handshakeAlgorithms) == 0 {
+		log.Fatalf("No accepted_crypto_algorithms %v are supported by this build", cfg.Handshake.AcceptedCryptoAlgorithms)
+	}
+	handshakeService.SetAlgorithmPreference(handshakeAlgorithms...)
+	if redisAdapter != nil {
+		handshakeService.SetNonceStore(federation.NewRedisNonceStore(redisAdapter, "ocx:handshake-nonce:", 5*time.Minute))
+		slog.Info("RedisNonceStore wired into handshake service for cross-pod replay protection")
+	} else {
+		handshakeService.SetNonceStore(federation.NewInMemoryNonceStore(5 * time.Minute))
+	}
+
+	// Binary AOCS frame transport — 110-byte frames over TCP, TLS when SPIFFE is available
+	if cfg.Fabric.FrameListenPort != "" {
+		// Session store — lets a dropped client resume its session on any replica
+		var sessionStore protocol.SessionStore
+		switch cfg.Fabric.SessionStore {
+		case "redis":
+			if redisAdapter != nil {
+				sessionStore = protocol.NewRedisSessionStore(redisAdapter, "ocx:aocs:")
+			} else {
+				slog.Warn("AOCS session store set to redis but Redis is unavailable; sessions are process-local")
+			}
+		case "postgres":
+			db, err := sql.Open("postgres", cfg.Fabric.SessionDatabaseURL)
+			if err == nil {
+				err = db.Ping()
+			}
+			if err != nil {
+				slog.Warn("AOCS session store Postgres connection failed; sessions are process-local", "error", err)
+			} else {
+				defer db.Close()
+				sessionStore = protocol.NewPostgresSessionStore(db)
+			}
+		}
+
+		frameSessions := protocol.NewSessionManager(protocol.SessionManagerConfig{
+			MaxSessionsPerTenant: 1000,
+			MaxTotalSessions:     100000,
+			CleanupInterval:      time.Minute,
+			Store:                sessionStore,
+			ResumptionSecret:     []byte(cfg.Fabric.SessionResumeSecret),
+		})
+		defer frameSessions.Stop()
+
+		// Refused unless SPIFFE mTLS is available or plaintext explicitly
+		// allowed; the codec key comes from TLS, so plaintext is unencrypted
+		if frameTLS, ok := listenerTLS("Frame transport", spiffeVerifier, cfg.Fabric.AllowInsecureFrameTransport); ok {
+			if lis, err := net.Listen("tcp", ":"+cfg.Fabric.FrameListenPort); err != nil {
+				slog.Warn("Frame transport listen failed", "port", cfg.Fabric.FrameListenPort, "error", err)
+			} else {
+				if frameTLS != nil {
+					lis = tls.NewListener(lis, frameTLS)
+				}
+				frameCtx, frameCancel := context.WithCancel(context.Background())
+				defer frameCancel()
+				frameServer := fabric.NewFrameServer(hub, frameSessions, cfg.Fabric.AllowInsecureFrameTransport)
+				go func() {
+					if err := frameServer.Serve(frameCtx, lis); err != nil {
+						slog.Warn("Frame transport stopped", "error", err)
+					}
+				}()
+				slog.Info("Frame transport listening", "port", cfg.Fabric.FrameListenPort, "mtls", frameTLS != nil)
+			}
+		}
+	}
+
 	// §13 Claim 13 (G3 fix): SOP Graph Manager — drift computation
 	sopManager := plan.NewSOPGraphManager()
 	slog.Info("SOPGraphManager initialized", "claim", 13)
@@ -319,9 +540,82 @@ func main() {
 
 	slog.Info("Connectors initialized", "webhooks", 0, "plugins", pluginRegistry.Count(), "tools", toolCatalog.Count())
 
+	// Federation integrity — attest what this instance runs, check peers
+	policyVersions := catalog.NewPolicyVersionStore()
+	toolCatalog.SetPolicyVersions(policyVersions)
+	federationManager.SetManifestSource(federation.NewManifestSource(federation.ManifestInputs{
+		GovernanceHashes: govConfigCache.Hashes,
+		PolicyVersions:   policyVersions.ActiveVersions,
+		Features:         integrityFeatures(cfg),
+	}))
+	integrityPolicy := federation.NewIntegrityPolicy(cfg.Federation.IntegrityPenalty)
+	for _, p := range cfg.Federation.IntegrityPeers {
+		integrityPolicy.Allow(federation.OCXInstanceID(p.InstanceID), federation.IntegrityAllowList{
+			BinaryDigests:     p.BinaryDigests,
+			GovernanceHashes:  p.GovernanceHashes,
+			PolicyVersions:    p.PolicyVersions,
+			RequiredFeatures:  p.RequiredFeatures,
+			ForbiddenFeatures: p.ForbiddenFeatures,
+			Enforce:           p.Enforce,
+		})
+	}
+	federationManager.SetIntegrityPolicy(integrityPolicy)
+	federationManager.SetAlertEmitter(eventEmitter)
+
+	// Resume or expire handshakes interrupted by the last shutdown. This must
+	// finish before the handshake service accepts calls, so a peer cannot
+	// act on a session that recovery would have expired.
+	handshakeService.SetEventEmitter(eventEmitter)
+	if _, err := handshakeService.Recover(context.Background()); err != nil {
+		log.Fatalf("Handshake session recovery failed: %v", err)
+	}
+
+	if cfg.Federation.MessageGRPCPort != "" {
+		// Refused unless SPIFFE mTLS is available or plaintext explicitly allowed
+		if federationTLS, ok := listenerTLS("Federated message transport", spiffeVerifier, cfg.Federation.AllowInsecureMessageTransport); ok {
+			if lis, err := net.Listen("tcp", ":"+cfg.Federation.MessageGRPCPort); err != nil {
+				slog.Warn("Federated message transport listen failed", "port", cfg.Federation.MessageGRPCPort, "error", err)
+			} else {
+				federationServer := federation.NewFederationGRPCServer(federationManager, federationTLS, cfg.Federation.AllowInsecureMessageTransport)
+				pb.RegisterInterOCXHandshakeServiceServer(federationServer, handshakeService)
+				go func() {
+					if err := federationServer.Serve(lis); err != nil {
+						slog.Warn("Federated message transport stopped", "error", err)
+					}
+				}()
+				defer federationServer.GracefulStop()
+				slog.Info("Federated message transport listening", "port", cfg.Federation.MessageGRPCPort, "mtls", federationTLS != nil)
+			}
+		}
+	}
+
 	// Session Audit Logger — security forensics
 	sessionAuditor := security.NewSessionAuditor(supabaseClient)
 
+	// Response Inspector — tool results and retrievals before they reach the model
+	riCfg := cfg.Security.ResponseInspection
+	responseInspector := security.NewResponseInspector(security.ResponseInspectorConfig{
+		MaxResultBytes:           riCfg.MaxResultBytes,
+		PIIAction:                security.ParseResponseAction(riCfg.PIIAction),
+		SecretAction:             security.ParseResponseAction(riCfg.SecretAction),
+		InjectionAction:          security.ParseResponseAction(riCfg.InjectionAction),
+		RetrievalInjectionAction: security.ParseResponseAction(riCfg.RetrievalInjectionAction),
+		OversizeAction:           security.ParseResponseAction(riCfg.OversizeAction),
+	}, evidenceVault)
+
+	// Agent Cards — A2A peers' declared skills checked in /govern and hub routing
+	acCfg := cfg.Security.AgentCards
+	agentCards := security.NewAgentCardRegistry(security.AgentCardConfig{
+		CacheTTL:               time.Duration(acCfg.CacheTTLSec) * time.Second,
+		FetchTimeout:           time.Duration(acCfg.FetchTimeoutMs) * time.Millisecond,
+		UnsignedAction:         security.ParseAgentCardAction(acCfg.UnsignedAction),
+		InvalidSignatureAction: security.ParseAgentCardAction(acCfg.InvalidSignatureAction),
+		MismatchAction:         security.ParseAgentCardAction(acCfg.MismatchAction),
+		TrustedJWKS:            acCfg.TrustedJWKS,
+		AllowPrivateNetworks:   acCfg.AllowPrivateNetworks,
+	})
+	hub.SetRouteInspector(agentCards)
+
 	// =========================================================================
 	// Router Setup
 	// =========================================================================
@@ -385,14 +679,80 @@ func main() {
 
 	// Marketplace API
 	marketplaceMux := http.NewServeMux()
-	marketplace.SetupMarketplace(marketplaceMux)
+	marketplaceHandler := marketplace.SetupMarketplace(marketplaceMux)
 	router.PathPrefix("/api/v1/marketplace/").Handler(marketplaceMux)
 
+	// Out-of-process connector plugins, verified with the marketplace's
+	// publisher keys and listed under /api/v1/plugins
+	if cfg.Plugins.Dir != "" {
+		sigVerifier := marketplaceHandler.Service().SignatureVerifier()
+		for publisherID, keyPath := range cfg.Plugins.PublisherKeys {
+			pemBytes, err := os.ReadFile(keyPath)
+			if err == nil {
+				err = sigVerifier.RegisterPublisherKeyPEM(publisherID, pemBytes)
+			}
+			if err != nil {
+				slog.Warn("Plugin publisher key not loaded", "publisher", publisherID, "error", err)
+			}
+		}
+		pluginHost := plugins.NewHost(plugins.HostConfig{
+			Dir:           cfg.Plugins.Dir,
+			CallTimeout:   time.Duration(cfg.Plugins.CallTimeoutMs) * time.Millisecond,
+			StartTimeout:  time.Duration(cfg.Plugins.StartTimeoutMs) * time.Millisecond,
+			MaxRestarts:   cfg.Plugins.MaxRestarts,
+			AllowUnsigned: cfg.Plugins.AllowUnsigned,
+		}, sigVerifier, pluginRegistry)
+		defer pluginHost.Shutdown()
+		n, err := pluginHost.LoadDir()
+		if err != nil {
+			slog.Warn("Some connector plugins failed to load", "error", err)
+		}
+		slog.Info("Connector plugins loaded", "dir", cfg.Plugins.Dir, "loaded", n)
+	}
+
+	// Sandboxed WASM parser/policy plugins, hot-reloaded from wasm_dir
+	if cfg.Plugins.WASMDir != "" {
+		wasmCtx, wasmCancel := context.WithCancel(context.Background())
+		defer wasmCancel()
+		wasmLoader, err := plugins.NewWASMLoader(wasmCtx, plugins.WASMConfig{
+			Dir:            cfg.Plugins.WASMDir,
+			CallTimeout:    time.Duration(cfg.Plugins.WASMCallTimeoutMs) * time.Millisecond,
+			MaxMemoryPages: uint32(cfg.Plugins.WASMMaxMemoryMB) * 16, // 64 KiB pages
+		}, pluginRegistry)
+		if err != nil {
+			slog.Warn("WASM plugin runtime unavailable", "error", err)
+		} else {
+			defer wasmLoader.Close(context.Background())
+			n, err := wasmLoader.Reload(wasmCtx)
+			if err != nil {
+				slog.Warn("Some WASM plugins failed to load", "error", err)
+			}
+			go wasmLoader.Watch(wasmCtx, time.Duration(cfg.Plugins.WASMReloadIntervalSec)*time.Second)
+			slog.Info("WASM plugins loaded", "dir", cfg.Plugins.WASMDir, "loaded", n)
+		}
+	}
+
 	// Federation (§5)
 	api.HandleFunc("/federation/handshake", handlers.HandleFederationHandshake(cfg, federationRegistry, trustLedger)).Methods("POST")
 	api.HandleFunc("/federation/trust", handlers.HandleFederationTrust(trustLedger)).Methods("GET")
 	api.HandleFunc("/federation/trust/{instanceId}", handlers.HandleFederationInstanceTrust(trustLedger)).Methods("GET")
 	api.HandleFunc("/federation/attestations", handlers.HandleFederationAttestations(trustLedger)).Methods("GET")
+	api.HandleFunc("/federation/revocations", handlers.HandleListRevocations(revocations)).Methods("GET")
+	api.HandleFunc("/federation/revocations", handlers.RequireFederationOperator(cfg.Federation.OperatorTenants,
+		handlers.HandleRevoke(federationRegistry))).Methods("POST")
+	api.HandleFunc("/federation/discover", handlers.RequireFederationOperator(cfg.Federation.OperatorTenants,
+		handlers.HandleFederationDiscover(federationRegistry))).Methods("POST")
+	api.HandleFunc("/federation/discover", handlers.HandleListDiscovered(federationRegistry)).Methods("GET")
+	api.HandleFunc("/federation/integrity/manifest", handlers.HandleFederationIntegrityManifest(federationManager)).Methods("GET")
+	api.HandleFunc("/federation/reputation/credentials", handlers.HandleIssueReputationCredential(federationManager, repWallet, repManager,
+		time.Duration(cfg.Federation.Reputation.CredentialTTLSec)*time.Second)).Methods("POST")
+	api.HandleFunc("/federation/reputation/challenge", handlers.HandleReputationChallenge(federationManager)).Methods("POST")
+	api.HandleFunc("/federation/reputation/import", handlers.HandleImportReputationCredential(federationManager, repWallet, repManager,
+		federation.ReputationImportPolicy{
+			MinIssuerTrust:          cfg.Federation.Reputation.MinIssuerTrust,
+			MaxImportedScore:        cfg.Federation.Reputation.MaxImportedScore,
+			MaxImportedInteractions: cfg.Federation.Reputation.MaxImportedInteractions,
+		})).Methods("POST")
 
 	// Escrow (§4)
 	api.HandleFunc("/escrow/items", handlers.HandleEscrowItems(escrowGate)).Methods("GET")
@@ -415,9 +775,20 @@ func main() {
 		jitEntitlements, evidenceVault, repWallet, toolCatalog,
 		webhookEmitter, eventEmitter, compensationStack,
 		tokenBroker, continuousEval, sandboxExecutor, ghostEngine,
-		sopManager, sessionAuditor,
+		sopManager, sessionAuditor, pluginRegistry, agentCards,
 	)).Methods("POST")
 
+	// Response-side governance: inspect tool results before they reach the model
+	api.HandleFunc("/govern/response", handlers.HandleGovernResponse(responseInspector)).Methods("POST")
+
+	// MCP tool list analysis (tool poisoning / rug-pull detection)
+	api.HandleFunc("/mcp/tools/analyze", handlers.HandleAnalyzeMCPTools(toolListAnalyzer)).Methods("POST")
+	api.HandleFunc("/mcp/servers/{serverId}/accept", handlers.HandleAcceptMCPTools(toolListAnalyzer)).Methods("POST")
+
+	// A2A agent cards (peer discovery, signature verification, declared skills)
+	api.HandleFunc("/a2a/agents", handlers.HandleRegisterAgentCard(agentCards)).Methods("POST")
+	api.HandleFunc("/a2a/agents/{agentId}/card", handlers.HandleGetAgentCard(agentCards)).Methods("GET")
+
 	// Bail-Out API (Patent Claims 6 + 14)
 	api.HandleFunc("/bail-out", handlers.HandleBailOut(
 		repWallet, billingEngine, evidenceVault, tokenBroker,
@@ -481,6 +852,21 @@ func main() {
 	// Agent Card — service discovery
 	router.HandleFunc("/.well-known/ocx-governance.json", handlers.HandleAgentCard()).Methods("GET")
 
+	// Federation trust bundle — signed roots and revocations, polled by peers
+	router.HandleFunc("/.well-known/ocx-trust-bundle.json", handlers.HandleTrustBundle(revocations)).Methods("GET")
+
+	// Federation metadata — partners discover this instance by domain
+	router.HandleFunc(federation.WellKnownFederationPath, handlers.HandleFederationMetadata(federationManager, federation.MetadataPublication{
+		Domain:       cfg.Federation.Domain,
+		TrustDomain:  cfg.Federation.TrustDomain,
+		Organization: cfg.Federation.Organization,
+		Endpoints: federation.FederationEndpoints{
+			Handshake: cfg.Federation.AdvertiseHandshakeAddr,
+			Messages:  cfg.Federation.AdvertiseMessageAddr,
+		},
+		TTL: time.Duration(cfg.Federation.MetadataTTLSec) * time.Second,
+	})).Methods("GET")
+
 	// =========================================================================
 	// Global Middleware
 	// =========================================================================
@@ -506,6 +892,38 @@ func main() {
 	// L4 FIX: Create a shared shutdown context for background goroutines
 	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
 
+	// Keep this replica's spoke ownership alive in the shared hub store
+	go hub.RunStoreHeartbeat(shutdownCtx)
+
+	// Trust ledger retention (attestation and trust history pruning)
+	go trustLedger.RunRetention(shutdownCtx, time.Hour)
+
+	// Peer trust bundles — pick up new federation roots and revocations
+	var bundleSources []federation.TrustBundleSource
+	for _, src := range cfg.Federation.TrustBundleSources {
+		pinnedPEM := src.PublicKeyPEM
+		bundleSources = append(bundleSources, federation.TrustBundleSource{
+			URL: src.URL,
+			IssuerKey: func(issuer federation.OCXInstanceID) ([]byte, error) {
+				if pinnedPEM != "" {
+					return federation.PublicKeyFromPEM(pinnedPEM)
+				}
+				return federationManager.PeerPublicKey(issuer)
+			},
+		})
+	}
+	go revocations.RunTrustBundlePoller(shutdownCtx, &http.Client{Timeout: 10 * time.Second}, bundleSources,
+		time.Duration(cfg.Federation.TrustBundlePollSec)*time.Second)
+
+	// Federation discovery — resolve configured partner domains and keep them fresh
+	go federationRegistry.RunDiscoveryRefresh(shutdownCtx, cfg.Federation.PeerDomains,
+		time.Duration(cfg.Federation.DiscoveryRefreshSec)*time.Second)
+
+	// Pick up signing keys rotated in the key service
+	for _, key := range externalKeys {
+		go key.RunRefresh(shutdownCtx, time.Duration(cfg.Federation.KeyService.RefreshSec)*time.Second)
+	}
+
 	// Graceful shutdown (Cloud Run sends SIGTERM)
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -527,7 +945,6 @@ func main() {
 
 	// Suppress unused variable warnings
 	_ = tenantManager
-	_ = shutdownCtx
 	_ = billingEngine
 
 	// Start server
@@ -540,6 +957,48 @@ func main() {
 	slog.Info("Server stopped")
 }
 
+// listenerTLS returns the SPIFFE mTLS config for a transport listener. Without
+// a SPIRE agent the listener runs in plaintext only when allowInsecure is set
+// (development only); ok is false when it must not start.
+func listenerTLS(name string, verifier *identity.SPIFFEVerifier, allowInsecure bool) (*tls.Config, bool) {
+	if verifier == nil {
+		if !allowInsecure {
+			slog.Error(name + " disabled: SPIFFE mTLS unavailable and plaintext not allowed")
+			return nil, false
+		}
+		slog.Warn(name + " running without SPIFFE mTLS (development only)")
+		return nil, true
+	}
+	tlsConf, err := verifier.GetServerTLSConfig()
+	if err != nil || tlsConf == nil {
+		// Never fall back to plaintext when SPIFFE is configured
+		slog.Error(name+" disabled: SPIFFE server TLS unavailable", "error", err)
+		return nil, false
+	}
+	return tlsConf, true
+}
+
+// dialerTLS is the client side of listenerTLS: SPIFFE mTLS credentials
+// when a SPIRE agent is available, plaintext only if allowInsecure, and
+// false when the named client must not dial at all.
+func dialerTLS(name string, verifier *identity.SPIFFEVerifier, allowInsecure bool) (credentials.TransportCredentials, bool) {
+	if verifier == nil {
+		if !allowInsecure {
+			slog.Error(name + " disabled: SPIFFE mTLS unavailable and plaintext not allowed")
+			return nil, false
+		}
+		slog.Warn(name + " running without SPIFFE mTLS (development only)")
+		return insecure.NewCredentials(), true
+	}
+	tlsConf, err := verifier.GetTLSConfig()
+	if err != nil || tlsConf == nil {
+		// Never fall back to plaintext when SPIFFE is configured
+		slog.Error(name+" disabled: SPIFFE client TLS unavailable", "error", err)
+		return nil, false
+	}
+	return credentials.NewTLS(tlsConf), true
+}
+
 // getEnvOrDefault returns the env var value or a default.
 func getEnvOrDefault(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
@@ -547,3 +1006,29 @@ func getEnvOrDefault(key, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+// integrityFeatures lists the features this instance advertises in its
+// federation integrity manifest: the configured ones plus those derived
+// from the config.
+func integrityFeatures(cfg *config.Config) []string {
+	features := append([]string{"crypto:" + cfg.Handshake.DefaultCryptoAlgorithm}, cfg.Federation.IntegrityFeatures...)
+	if cfg.Federation.MessageGRPCPort != "" {
+		features = append(features, "federation-messages")
+	}
+	if cfg.Federation.Domain != "" {
+		features = append(features, "discovery")
+	}
+	if cfg.Federation.KeyService.URL != "" {
+		features = append(features, "key-service")
+	}
+	if cfg.Federation.KeyService.EvidenceKey != "" {
+		features = append(features, "evidence-signing")
+	}
+	if cfg.Plugins.Dir != "" {
+		features = append(features, "plugins")
+	}
+	if cfg.Plugins.AllowUnsigned {
+		features = append(features, "unsigned-plugins")
+	}
+	return features
+}
@@ -0,0 +1,67 @@
+// Command connector-plugin-example is a minimal out-of-process connector
+// plugin. It parses an in-house agent framework's action envelope:
+//
+//	{"agent_action": {"tool": "search_records", "params": {...}, "agent": "a1"}}
+//
+// Build it, sign the binary and drop it with a manifest into the gateway's
+// plugin directory:
+//
+//	go build -o plugins/inhouse ./cmd/connector-plugin-example
+//	cat > plugins/inhouse.plugin.json <<EOF
+//	{"name":"inhouse-actions","executable":"inhouse","publisher_id":"acme","signature":"<hex>"}
+//	EOF
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ocx/backend/internal/protocol"
+	"github.com/ocx/backend/pkg/plugins"
+)
+
+type actionEnvelope struct {
+	Action *struct {
+		Tool   string                 `json:"tool"`
+		Params map[string]interface{} `json:"params"`
+		Agent  string                 `json:"agent"`
+		ID     string                 `json:"id"`
+	} `json:"agent_action"`
+}
+
+type inhouseParser struct{}
+
+func (inhouseParser) Name() string        { return "inhouse-actions" }
+func (inhouseParser) Version() string     { return "1.0.0" }
+func (inhouseParser) Protocols() []string { return []string{"inhouse"} }
+func (inhouseParser) Priority() int       { return 40 }
+
+func (inhouseParser) CanHandle(payload []byte) bool {
+	var env actionEnvelope
+	return json.Unmarshal(payload, &env) == nil && env.Action != nil && env.Action.Tool != ""
+}
+
+func (inhouseParser) Parse(payload []byte) (*protocol.AIPayload, error) {
+	var env actionEnvelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil, err
+	}
+	if env.Action == nil || env.Action.Tool == "" {
+		return nil, fmt.Errorf("no agent_action.tool")
+	}
+	return &protocol.AIPayload{
+		Protocol:    protocol.ProtoCustom,
+		ToolName:    env.Action.Tool,
+		AgentID:     env.Action.Agent,
+		Arguments:   env.Action.Params,
+		MessageType: "tool_call",
+		Direction:   "request",
+		Confidence:  0.9,
+		CallID:      env.Action.ID,
+		Metadata:    map[string]interface{}{"framework": "inhouse"},
+	}, nil
+}
+
+func main() {
+	plugins.Serve(inhouseParser{})
+}
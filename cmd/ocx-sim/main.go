@@ -0,0 +1,131 @@
+// ocx-sim runs federation simulation scenarios: N in-process OCX instances
+// exchanging handshakes and signed messages with injected faults, checked
+// for delivery and trust convergence.
+//
+//	ocx-sim                        # run every built-in scenario
+//	ocx-sim -run forged-signer     # run named built-ins
+//	ocx-sim -scenario sim.yaml     # run a scenario file
+//	ocx-sim -list                  # list built-ins
+//
+// Exits 1 when any scenario's expectations fail.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/ocx/backend/internal/fedsim"
+)
+
+func main() {
+	scenarioFile := flag.String("scenario", "", "Scenario file (YAML or JSON)")
+	run := flag.String("run", "", "Comma-separated built-in scenarios to run (default: all)")
+	list := flag.Bool("list", false, "List built-in scenarios and exit")
+	asJSON := flag.Bool("json", false, "Print results as JSON")
+	verbose := flag.Bool("v", false, "Show federation and hub logs")
+	flag.Parse()
+
+	if *list {
+		for _, s := range fedsim.BuiltinScenarios() {
+			fmt.Printf("%-16s %s\n", s.Name, s.Description)
+		}
+		return
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+
+	scenarios, err := selectScenarios(*scenarioFile, *run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var results []*fedsim.Result
+	failed := false
+	for _, s := range scenarios {
+		result, err := fedsim.Run(ctx, s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "scenario %s: %v\n", s.Name, err)
+			os.Exit(2)
+		}
+		results = append(results, result)
+		failed = failed || !result.Passed()
+		if !*asJSON {
+			printResult(result)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func selectScenarios(file, names string) ([]*fedsim.Scenario, error) {
+	if file != "" {
+		s, err := fedsim.LoadScenario(file)
+		if err != nil {
+			return nil, err
+		}
+		return []*fedsim.Scenario{s}, nil
+	}
+	if names == "" {
+		return fedsim.BuiltinScenarios(), nil
+	}
+	var scenarios []*fedsim.Scenario
+	for _, name := range strings.Split(names, ",") {
+		s, err := fedsim.BuiltinScenario(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, s)
+	}
+	return scenarios, nil
+}
+
+func printResult(r *fedsim.Result) {
+	verdict := "\033[32mPASS\033[0m"
+	if !r.Passed() {
+		verdict = "\033[31mFAIL\033[0m"
+	}
+	fmt.Printf("%s %s (%d rounds, %s)\n", verdict, r.Scenario, r.Rounds, r.Duration.Round(1e6))
+	fmt.Printf("  handshakes: %d ok, %d failed\n", r.Handshakes, r.HandshakeFailures)
+	m := r.Messages
+	fmt.Printf("  messages:   %d sent, %d delivered, %d dropped, %d rejected, %d unreachable\n",
+		m.Sent, m.Delivered, m.Dropped, m.Rejected, m.Unreachable)
+	fmt.Printf("  healthy:    %s (delivery %.1f%%)\n", strings.Join(r.Healthy, ", "), r.HealthyMessages.DeliveryRate()*100)
+
+	subjects := make([]string, 0, len(r.TrustRange))
+	for subject := range r.TrustRange {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	fmt.Println("  trust held by healthy observers:")
+	for _, subject := range subjects {
+		span := r.TrustRange[subject]
+		fmt.Printf("    %-8s %.3f – %.3f\n", subject, span[0], span[1])
+	}
+	for _, f := range r.Failures {
+		fmt.Printf("  \033[31m✗\033[0m %s\n", f)
+	}
+	fmt.Println()
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/ocx/backend/internal/ledger"
 	"github.com/ocx/backend/internal/plan"
 	"github.com/ocx/backend/internal/probe"
+	"github.com/ocx/backend/internal/protocol"
 	"github.com/ocx/backend/internal/reputation"
 	"github.com/ocx/backend/internal/revert"
 	"github.com/ocx/backend/internal/snapshot" // Phase 4
@@ -372,6 +373,9 @@ type WorkerGroup struct {
 
 	// Phase 8: Economic Barrier
 	escrowGate *escrow.EscrowGate
+
+	// Per-call governance of AI tool calls in captured payloads
+	aiParser *protocol.UniversalAIParser
 }
 
 func NewWorkerGroup(conn interface{}, s *socketio.Server, vu *probe.VerdictUpdater, eg *escrow.EscrowGate) *WorkerGroup {
@@ -387,6 +391,8 @@ func NewWorkerGroup(conn interface{}, s *socketio.Server, vu *probe.VerdictUpdat
 		auditLogger: ledger.NewAuditLogger(&pb.MockLedgerClient{}), // Use Mock
 
 		escrowGate: eg, // Phase 8
+
+		aiParser: protocol.NewUniversalAIParser(),
 	}
 }
 
@@ -421,6 +427,14 @@ func (wg *WorkerGroup) worker(ctx context.Context) {
 			// 2. Plan Validation
 			allowed, sessionPlan := wg.planStore.Validate(ev.PID, "sys_read")
 
+			// 2b. Every tool call in the payload is checked on its own;
+			// one disallowed call blocks the whole turn
+			payloadLen := min(int(ev.Size), len(ev.Payload))
+			callsAllowed, toolCalls := EvaluateToolCalls(wg.planStore, wg.aiParser, ev.PID, ev.Payload[:payloadLen])
+			if !callsAllowed {
+				allowed = false
+			}
+
 			action := "ALLOW"
 			if !allowed {
 				action = "BLOCK_VIOLATION"
@@ -500,6 +514,9 @@ func (wg *WorkerGroup) worker(ctx context.Context) {
 				"action":        action,
 				"manual_review": manualReq, // Trigger for UI Modal
 			}
+			if len(toolCalls) > 0 {
+				uiEvent["tool_calls"] = toolCalls
+			}
 
 			wg.socket.BroadcastToNamespace("/", "traffic_event", uiEvent)
 
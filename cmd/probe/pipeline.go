@@ -3,6 +3,7 @@ package main
 import (
 	"log/slog"
 	"github.com/ocx/backend/internal/plan"
+	"github.com/ocx/backend/internal/protocol"
 
 	socketio "github.com/googollee/go-socket.io"
 )
@@ -62,3 +63,30 @@ func (gn *GovernanceNode) ProcessAgentTurn(agentID string, payload []byte) {
 		}
 	}()
 }
+
+// ToolCallVerdict is the plan decision for one tool call in a captured payload
+type ToolCallVerdict struct {
+	CallID   string `json:"call_id"`
+	ToolName string `json:"tool_name"`
+	Allowed  bool   `json:"allowed"`
+}
+
+// EvaluateToolCalls checks every tool call carried by payload against the
+// process's plan independently, so a model turn with parallel calls can't
+// smuggle a disallowed call behind an allowed first one. The turn is allowed
+// only if every call is.
+func EvaluateToolCalls(store *plan.PlanStore, parser *protocol.UniversalAIParser, pid uint32, payload []byte) (bool, []ToolCallVerdict) {
+	allowed := true
+	var verdicts []ToolCallVerdict
+	for _, call := range parser.ParseAll(payload) {
+		if call.MessageType != "tool_call" {
+			continue
+		}
+		ok, _ := store.Validate(pid, call.ToolName)
+		if !ok {
+			allowed = false
+		}
+		verdicts = append(verdicts, ToolCallVerdict{CallID: call.CallID, ToolName: call.ToolName, Allowed: ok})
+	}
+	return allowed, verdicts
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/hex"
 	"testing"
 
+	"github.com/ocx/backend/internal/plan"
+	"github.com/ocx/backend/internal/protocol"
 	"github.com/ocx/backend/internal/snapshot"
+	"github.com/ocx/backend/pb"
 )
 
 func TestSnapshot_Integrity(t *testing.T) {
@@ -30,3 +33,28 @@ func TestSnapshot_Integrity(t *testing.T) {
 		t.Errorf("CompareAndVerify PASSED mismatching hash. Expected error!")
 	}
 }
+
+func TestEvaluateToolCalls_EachCallChecked(t *testing.T) {
+	store := plan.NewPlanStore()
+	store.RegisterPlan(42, &pb.ExecutionPlan{AgentId: "agent-1", AllowedCalls: []string{"get_balance", "send_email"}})
+	parser := protocol.NewUniversalAIParser()
+
+	// The disallowed call is second: governing only the first would allow it
+	payload := []byte(`{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","tool_calls":[
+		{"id":"call_1","type":"function","function":{"name":"get_balance","arguments":"{}"}},
+		{"id":"call_2","type":"function","function":{"name":"transfer_funds","arguments":"{\"amount\":5000}"}}]},
+		"finish_reason":"tool_calls"}]}`)
+
+	allowed, verdicts := EvaluateToolCalls(store, parser, 42, payload)
+	if allowed {
+		t.Fatal("turn with a disallowed call was allowed")
+	}
+	if len(verdicts) != 2 || !verdicts[0].Allowed || verdicts[1].Allowed || verdicts[1].CallID != "call_2" {
+		t.Errorf("unexpected per-call verdicts: %+v", verdicts)
+	}
+
+	// Payloads without tool calls leave the decision to the existing checks
+	if allowed, verdicts := EvaluateToolCalls(store, parser, 42, []byte("GET / HTTP/1.1\r\n\r\n")); !allowed || len(verdicts) != 0 {
+		t.Errorf("non-AI payload: allowed=%v verdicts=%v", allowed, verdicts)
+	}
+}
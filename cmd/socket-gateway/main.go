@@ -26,6 +26,7 @@ import (
 	"github.com/ocx/backend/internal/protocol"
 	"github.com/ocx/backend/internal/reputation"
 	"github.com/ocx/backend/internal/revert"
+	"github.com/ocx/backend/internal/security"
 )
 
 // Global components for speculative execution
@@ -42,6 +43,8 @@ var (
 	jitterInjector     *escrow.TemporalJitterInjector // §3.3 Temporal Jitter
 	evidenceVault      *evidence.EvidenceVault        // §6 Evidence Vault
 	aiParser           *protocol.UniversalAIParser    // Universal AI protocol parser
+	streamTracker      *protocol.StreamTracker        // SSE/chunked reassembly per connection
+	toolListAnalyzer   *security.ToolListAnalyzer     // MCP tool list fingerprinting
 	reputationWallet   *reputation.ReputationWallet   // Trust score lookup
 	ghostPool          *ghostpool.PoolManager         // Pre-warmed sandbox container pool
 )
@@ -130,6 +133,8 @@ func main() {
 	slog.Info("SocketMeter initialized (§4.1 real-time metering)")
 	// 6. Tool Classifier — §2 deterministic Class A/B classification
 	toolClassifier = escrow.NewToolClassifier()
+	toolListAnalyzer = security.NewToolListAnalyzer(nil)
+	toolClassifier.SetToolRiskSource(toolListAnalyzer)
 	slog.Info("ToolClassifier initialized (§2 Class A/B registry)")
 	// 7. Tri-Factor Gate — §2 Identity + Signal + Cognitive validation
 	triFactorGate = escrow.NewTriFactorGate(toolClassifier, juryClient, entropyMonitor, escrow.TriFactorGateConfig{
@@ -157,6 +162,7 @@ func main() {
 	// 12. Universal AI Protocol Parser — MCP, OpenAI, A2A, LangChain, CrewAI, AutoGen, RAG
 	aiParser = protocol.NewUniversalAIParser()
 	slog.Info("UniversalAIParser initialized (MCP/OpenAI/A2A/LangChain/RAG/GenericAI)")
+	streamTracker = protocol.NewStreamTracker(aiParser, 2*time.Minute, 10000)
 	// 13. Reputation Wallet — agent trust score lookup
 	// Socket-gateway uses in-memory mode; the API server uses Supabase-backed.
 	reputationWallet = reputation.NewReputationWallet(nil)
@@ -271,13 +277,32 @@ func main() {
 				continue
 			}
 
+			// Reassemble streamed payloads here, in capture order: the
+			// per-event goroutines below may run out of order
+			streamed := reassembleStream(&event)
+
 			// Process event (initiate speculative audit)
-			go processSocketEvent(&event)
+			go processSocketEvent(&event, streamed)
 		}
 	}
 }
 
-func processSocketEvent(event *SocketEvent) {
+// reassembleStream feeds the event into its connection's stream and returns
+// AI payloads (e.g. SSE tool calls) completed by this segment.
+func reassembleStream(event *SocketEvent) []*protocol.AIPayload {
+	if streamTracker == nil {
+		return nil
+	}
+	n := min(event.PayloadLen, uint32(len(event.Payload)))
+	key := fmt.Sprintf("%s:%d>%s:%d", ipToString(event.SrcIP), event.SrcPort, ipToString(event.DstIP), event.DstPort)
+	payloads, err := streamTracker.Feed(key, event.Payload[:n])
+	if err != nil {
+		slog.Warn("Stream reassembly reset", "stream", key, "error", err)
+	}
+	return payloads
+}
+
+func processSocketEvent(event *SocketEvent, streamed []*protocol.AIPayload) {
 	// Pipeline-wide timeout — prevents goroutine leak if any step hangs
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
@@ -376,9 +401,27 @@ func processSocketEvent(event *SocketEvent) {
 	// =========================================================================
 	toolID := "network_call" // Default fallback
 	agentTrustScore := 0.3   // Conservative default for unknown agents (matches wallet.go newAgentDefaultScore)
+	mcpServer := ""          // MCP server a tool call is sent to, for descriptor risk
 
+	// Every tool call in the segment is governed: one message can carry
+	// several, and a harmless first call must not let a dangerous one through
+	var calls []*protocol.AIPayload
 	if aiParser != nil {
 		aiPayload := aiParser.Parse(payload)
+		// Prefer payloads reassembled from the stream: a single segment of
+		// an SSE response only holds a fragment of the tool call
+		calls = protocol.ToolCalls(streamed)
+		if len(calls) == 0 {
+			calls = protocol.ToolCalls(aiParser.ParseAll(payload))
+		}
+		if len(calls) > 0 {
+			aiPayload = calls[0]
+		} else if aiPayload.Protocol == protocol.ProtoRaw && len(streamed) > 0 {
+			aiPayload = streamed[0]
+		}
+		if len(calls) > 1 {
+			slog.Info("Multiple tool calls in segment", "count", len(calls))
+		}
 		if aiPayload.Protocol != protocol.ProtoRaw {
 			slog.Info("AI Protocol Detected: (confidence= tool= type=)", "protocol", aiPayload.Protocol, "confidence", aiPayload.Confidence, "tool_name", aiPayload.ToolName, "message_type", aiPayload.MessageType)
 			// Use parsed tool name for classification
@@ -393,6 +436,17 @@ func processSocketEvent(event *SocketEvent) {
 			if aiPayload.TenantID != "" {
 				tenantID = aiPayload.TenantID
 			}
+			// An MCP request flows client -> server: the destination is the MCP server
+			if aiPayload.Protocol == protocol.ProtoMCP && aiPayload.Direction == "request" {
+				mcpServer = fmt.Sprintf("%s:%d", ipToString(event.DstIP), event.DstPort)
+			}
+			// A tools/list result flows server -> client: the source is the MCP server
+			if toolListAnalyzer != nil && aiPayload.Protocol == protocol.ProtoMCP && aiPayload.RawMethod == "tools/list" && aiPayload.Direction == "response" {
+				serverID := fmt.Sprintf("%s:%d", ipToString(event.SrcIP), event.SrcPort)
+				if _, err := toolListAnalyzer.AnalyzePayload(ctx, tenantID, serverID, payload); err != nil {
+					slog.Warn("MCP tool list analysis failed", "server", serverID, "error", err)
+				}
+			}
 		} else {
 			slog.Info("📡 Raw payload (no AI protocol detected) — using defaults")
 		}
@@ -413,20 +467,20 @@ func processSocketEvent(event *SocketEvent) {
 			"agent_id", agentID, "default", agentTrustScore)
 	}
 
-	var classification *escrow.ClassificationResult
-	if toolClassifier != nil {
-		classification, err = toolClassifier.Classify(escrow.ClassificationRequest{
-			ToolID:          toolID,
+	classify := func(tool string) *escrow.ClassificationResult {
+		classification, err := toolClassifier.Classify(escrow.ClassificationRequest{
+			ToolID:          tool,
 			AgentID:         agentID,
 			TenantID:        tenantID,
+			ServerID:        mcpServer,
 			Args:            map[string]interface{}{"payload_len": event.PayloadLen},
 			AgentTrustScore: agentTrustScore,
 			Entitlements:    []string{}, // Would come from JIT manager
 		})
 		if err != nil {
-			slog.Warn("Classification failed: (defaulting to CLASS_B fail-secure)", "error", err)
+			slog.Warn("Classification failed: (defaulting to CLASS_B fail-secure)", "tool_id", tool, "error", err)
 			// Fail-secure: default to CLASS_B (stricter) when classification fails
-			classification = &escrow.ClassificationResult{
+			return &escrow.ClassificationResult{
 				Classification: escrow.ToolClassification{
 					ActionClass:              escrow.CLASS_B,
 					GovernanceTaxCoefficient: 1.5,
@@ -434,13 +488,52 @@ func processSocketEvent(event *SocketEvent) {
 				FinalVerdict:   "HOLD",
 				EscrowDecision: escrow.ATOMIC_HOLD,
 			}
-		} else {
-			slog.Info("Classified",
-				"tool_id", toolID,
-				"action_class", classification.Classification.ActionClass,
-				"verdict", classification.FinalVerdict,
-				"escrow_decision", classification.EscrowDecision)
 		}
+		slog.Info("Classified",
+			"tool_id", tool,
+			"action_class", classification.Classification.ActionClass,
+			"verdict", classification.FinalVerdict,
+			"escrow_decision", classification.EscrowDecision)
+		return classification
+	}
+
+	// The most restrictive call governs the segment
+	var classification *escrow.ClassificationResult
+	if toolClassifier != nil {
+		if len(calls) == 0 {
+			classification = classify(toolID)
+		}
+		for _, call := range calls {
+			c := classify(call.ToolName)
+			if classification == nil || verdictRank(c) > verdictRank(classification) {
+				classification, toolID = c, call.ToolName
+			}
+		}
+	}
+
+	// One blocked call blocks the whole segment: nothing is sequestered,
+	// held, charged or granted for any of its calls
+	if classification != nil && classification.FinalVerdict == "BLOCK" {
+		slog.Info("Segment blocked", "tx_i_d", txID, "tool_id", toolID, "reason", classification.Reasoning, "calls", len(calls))
+		if evidenceVault != nil {
+			_, recErr := evidenceVault.RecordTransaction(
+				ctx, tenantID, agentID, txID,
+				toolID, classification.Classification.ActionClass.String(),
+				evidence.OutcomeBlock, agentTrustScore,
+				fmt.Sprintf("Socket-gateway segment blocked: %s", classification.Reasoning),
+				map[string]interface{}{
+					"src_ip":      ipToString(event.SrcIP),
+					"dst_ip":      ipToString(event.DstIP),
+					"payload_len": event.PayloadLen,
+					"tool_calls":  len(calls),
+				},
+			)
+			if recErr != nil {
+				slog.Warn("Evidence recording failed", "rec_err", recErr)
+			}
+		}
+		compStack.Compensate(ctx)
+		return
 	}
 
 	// =========================================================================
@@ -657,6 +750,21 @@ func processSocketEvent(event *SocketEvent) {
 	slog.Info("Pipeline complete: tx", "tx_i_d", txID)
 }
 
+// verdictRank orders classifications from least to most restrictive, so the
+// most restrictive tool call in a segment governs it.
+func verdictRank(c *escrow.ClassificationResult) int {
+	switch {
+	case c.FinalVerdict == "BLOCK":
+		return 3
+	case c.FinalVerdict == "ESCALATE" || c.FinalVerdict == "HOLD":
+		return 2
+	case c.Classification.ActionClass == escrow.CLASS_B:
+		return 1
+	default:
+		return 0
+	}
+}
+
 func reportStats(statsMap *ebpf.Map) {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
@@ -231,6 +231,46 @@ CREATE INDEX IF NOT EXISTS idx_federation_hs_initiator ON federation_handshakes(
 CREATE INDEX IF NOT EXISTS idx_federation_hs_incomplete ON federation_handshakes(state)
     WHERE state NOT IN ('COMPLETED', 'REJECTED', 'EXPIRED');
 
+-- Federation Trust Ledger (used by PostgresTrustLedgerStore; survives restarts)
+CREATE TABLE IF NOT EXISTS federation_trust_instances (
+    remote_instance_id TEXT PRIMARY KEY,
+    current_trust FLOAT NOT NULL,
+    last_handshake_at TIMESTAMPTZ,
+    data JSONB NOT NULL,             -- InstanceTrustRecord incl. trust history
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS federation_trust_attestations (
+    event_id TEXT PRIMARY KEY,
+    local_instance_id TEXT NOT NULL,
+    remote_instance_id TEXT NOT NULL,
+    agent_id TEXT NOT NULL,
+    local_trust FLOAT NOT NULL,
+    remote_trust FLOAT NOT NULL,
+    agreed_trust FLOAT NOT NULL,
+    attestation_hash TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fed_trust_att_created ON federation_trust_attestations(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fed_trust_att_remote ON federation_trust_attestations(remote_instance_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fed_trust_att_agent ON federation_trust_attestations(agent_id);
+
+-- AOCS Sessions (used by PostgresSessionStore for suspend/resume across replicas)
+CREATE TABLE IF NOT EXISTS aocs_sessions (
+    session_id TEXT PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,   -- protocol.IdentifierHash of the tenant
+    state TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    data JSONB NOT NULL,
+    resume_hash TEXT,            -- SHA-256 of the outstanding resumption token
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_aocs_sessions_tenant ON aocs_sessions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_aocs_sessions_expires ON aocs_sessions(expires_at);
+
 -- =============================================================================
 -- SECTION 6: AGENT IDENTITIES (PID Mapping)
 -- =============================================================================
@@ -1236,9 +1276,10 @@ CREATE POLICY "Tenant members read own audit log"
 -- =============================================================================
 -- MIGRATION COMPLETE!
 -- =============================================================================
--- Total Tables: 54 (52 + tenant_governance_config, governance_audit_log)
+-- Total Tables: 57 (52 + tenant_governance_config, governance_audit_log,
+--                  federation_trust_instances, federation_trust_attestations, aocs_sessions)
 -- Views: 2 (activity_execution_stats, pending_approvals)
--- Indexes: 48
+-- Indexes: 53
 -- RLS Policies: 8
 -- =============================================================================
 
@@ -107,6 +107,18 @@ func (pvs *PolicyVersionStore) GetActive(toolName string) *PolicyVersion {
 	return versions[activeVer-1]
 }
 
+// ActiveVersions returns the active policy version number of every tool.
+func (pvs *PolicyVersionStore) ActiveVersions() map[string]int {
+	pvs.mu.RLock()
+	defer pvs.mu.RUnlock()
+
+	out := make(map[string]int, len(pvs.active))
+	for tool, v := range pvs.active {
+		out[tool] = v
+	}
+	return out
+}
+
 // GetHistory returns all versions for a tool.
 func (pvs *PolicyVersionStore) GetHistory(toolName string) []*PolicyVersion {
 	pvs.mu.RLock()
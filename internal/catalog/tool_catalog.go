@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"reflect"
 	"sync"
 	"time"
 )
@@ -43,9 +44,10 @@ type ToolDefinition struct {
 // Instead of hardcoding tools in the ToolClassifier, organizations register
 // their tools via API and define governance policies dynamically.
 type ToolCatalog struct {
-	mu     sync.RWMutex
-	tools  map[string]*ToolDefinition // name -> def
-	logger *log.Logger
+	mu       sync.RWMutex
+	tools    map[string]*ToolDefinition // name -> def
+	versions *PolicyVersionStore        // optional policy history
+	logger   *log.Logger
 }
 
 // NewToolCatalog creates a new tool catalog
@@ -124,6 +126,39 @@ func (tc *ToolCatalog) registerDefaults() {
 	}
 }
 
+// SetPolicyVersions records every tool's governance policy in pvs, now and
+// on each later Register that changes it.
+func (tc *ToolCatalog) SetPolicyVersions(pvs *PolicyVersionStore) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tc.versions = pvs
+	for _, tool := range tc.tools {
+		tc.recordPolicyVersion(tool)
+	}
+}
+
+// recordPolicyVersion pushes the tool's policy when it differs from the
+// active version (must be called with lock).
+func (tc *ToolCatalog) recordPolicyVersion(tool *ToolDefinition) {
+	if tc.versions == nil {
+		return
+	}
+	data, err := json.Marshal(tool.GovernancePolicy)
+	if err != nil {
+		return
+	}
+	var policy map[string]interface{}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return
+	}
+	if active := tc.versions.GetActive(tool.Name); active != nil &&
+		active.ActionClass == string(tool.ActionClass) && reflect.DeepEqual(active.Policy, policy) {
+		return
+	}
+	tc.versions.Push(tool.Name, policy, string(tool.ActionClass), tool.RegisteredBy, "registered")
+}
+
 // Register adds or updates a tool in the catalog
 func (tc *ToolCatalog) Register(tool *ToolDefinition) error {
 	tc.mu.Lock()
@@ -145,6 +180,7 @@ func (tc *ToolCatalog) Register(tool *ToolDefinition) error {
 	tool.UpdatedAt = now
 
 	tc.tools[tool.Name] = tool
+	tc.recordPolicyVersion(tool)
 	tc.logger.Printf("📦 Registered tool: %s (%s, min_trust=%.2f)",
 		tool.Name, tool.ActionClass, tool.GovernancePolicy.MinTrustScore)
 	return nil
@@ -36,8 +36,10 @@ type Config struct {
 	Security   SecurityConfig   `yaml:"security"`
 	Sovereign  SovereignConfig  `yaml:"sovereign"`
 	Redis      RedisConfig      `yaml:"redis"`
+	Fabric     FabricConfig     `yaml:"fabric"`
 	TriFactor  TriFactorConfig  `yaml:"tri_factor"`
 	HITL       HITLConfig       `yaml:"hitl"`
+	Plugins    PluginsConfig    `yaml:"plugins"`
 }
 
 type ServerConfig struct {
@@ -102,10 +104,12 @@ type TrustSecrets struct {
 }
 
 type HandshakeConfig struct {
-	SessionExpiryMinutes int      `yaml:"session_expiry_minutes"`
-	SupportedVersions    []string `yaml:"supported_versions"`
-	MinTrustLevel        float64  `yaml:"min_trust_level"`
-	BaseTaxRate          float64  `yaml:"base_tax_rate"`
+	SessionExpiryMinutes     int      `yaml:"session_expiry_minutes"`
+	SupportedVersions        []string `yaml:"supported_versions"`
+	MinTrustLevel            float64  `yaml:"min_trust_level"`
+	BaseTaxRate              float64  `yaml:"base_tax_rate"`
+	DefaultCryptoAlgorithm   string   `yaml:"default_crypto_algorithm"`   // Signing algorithm for this instance's federation key
+	AcceptedCryptoAlgorithms []string `yaml:"accepted_crypto_algorithms"` // Peer algorithms accepted in HELLO, in preference order (empty = all supported)
 }
 
 type TriFactorConfig struct {
@@ -158,6 +162,98 @@ type FederationConfig struct {
 	TrustDomain  string `yaml:"trust_domain"`
 	Region       string `yaml:"region"`
 	Organization string `yaml:"organization"`
+
+	// Trust ledger persistence and retention
+	TrustStore               string `yaml:"trust_store"`        // memory | postgres
+	TrustDatabaseURL         string `yaml:"trust_database_url"` // Postgres DSN when trust_store=postgres
+	TrustHistoryPoints       int    `yaml:"trust_history_points"`
+	TrustHistoryDays         int    `yaml:"trust_history_days"`
+	AttestationRetentionDays int    `yaml:"attestation_retention_days"`
+
+	// Inter-OCX message transport
+	MessageGRPCPort     string  `yaml:"message_grpc_port"`      // empty disables the federated message service
+	MaxPeers            int     `yaml:"max_peers"`              // connected OCX peers allowed at once
+	TrustTaxBaseRate    float64 `yaml:"trust_tax_base_rate"`    // tax charged on inbound messages at zero trust
+	MessageClockSkewSec int     `yaml:"message_clock_skew_sec"` // tolerated sender clock drift
+
+	// Plaintext, inbound and outbound, without a SPIRE agent; refused unless
+	// set (development only)
+	AllowInsecureMessageTransport bool `yaml:"allow_insecure_message_transport"`
+
+	// Tenants allowed to revoke peers and force discovery; these act on
+	// every tenant of the deployment, so empty leaves them to nobody
+	OperatorTenants []string `yaml:"operator_tenants"`
+
+	// Revocation — peers' trust bundles polled for new roots and revocations
+	TrustBundleSources []TrustBundleSourceConfig `yaml:"trust_bundle_sources"`
+	TrustBundlePollSec int                       `yaml:"trust_bundle_poll_sec"`
+
+	// Discovery — /.well-known/ocx-federation.json plus optional DNS records
+	Domain                 string   `yaml:"domain"`                   // published domain; empty disables the metadata document
+	AdvertiseHandshakeAddr string   `yaml:"advertise_handshake_addr"` // host:port peers dial for handshakes
+	AdvertiseMessageAddr   string   `yaml:"advertise_message_addr"`   // host:port of the message transport
+	MetadataTTLSec         int      `yaml:"metadata_ttl_sec"`
+	PeerDomains            []string `yaml:"peer_domains"` // discovered at boot and refreshed
+	DiscoveryRefreshSec    int      `yaml:"discovery_refresh_sec"`
+	DiscoveryRequireDNS    bool     `yaml:"discovery_require_dns"` // peers must publish an _ocx-federation TXT pin
+
+	// Signing keys held by an external key service instead of in-process
+	KeyService KeyServiceConfig `yaml:"key_service"`
+
+	// Integrity — signed software manifest in attestations, checked
+	// against a per-peer allow-list
+	IntegrityFeatures []string                   `yaml:"integrity_features"` // advertised in addition to the derived ones
+	IntegrityPenalty  float64                    `yaml:"integrity_penalty"`  // peer trust multiplier on mismatch
+	IntegrityPeers    []IntegrityAllowListConfig `yaml:"integrity_peers"`
+
+	// Portable agent reputation credentials
+	Reputation ReputationPortabilityConfig `yaml:"reputation"`
+}
+
+// ReputationPortabilityConfig controls signed reputation credentials that
+// agents carry between federated instances. Imported scores are multiplied
+// by the issuer's trust in the ledger.
+type ReputationPortabilityConfig struct {
+	CredentialTTLSec        int     `yaml:"credential_ttl_sec"`        // lifetime of issued credentials
+	ReplayWindowSec         int     `yaml:"replay_window_sec"`         // imported credential IDs are remembered this long
+	MinIssuerTrust          float64 `yaml:"min_issuer_trust"`          // issuers below this are refused
+	MaxImportedScore        float64 `yaml:"max_imported_score"`        // cap on the discounted score
+	MaxImportedInteractions int64   `yaml:"max_imported_interactions"` // issuer history counted locally
+}
+
+// IntegrityAllowListConfig is what a peer's integrity manifest must match.
+// InstanceID "*" applies to peers without their own entry; empty fields
+// are not checked.
+type IntegrityAllowListConfig struct {
+	InstanceID        string              `yaml:"instance_id"`
+	BinaryDigests     []string            `yaml:"binary_digests"`
+	GovernanceHashes  map[string][]string `yaml:"governance_hashes"` // tenant ID → allowed hashes
+	PolicyVersions    map[string][]int    `yaml:"policy_versions"`   // tool → allowed active versions
+	RequiredFeatures  []string            `yaml:"required_features"`
+	ForbiddenFeatures []string            `yaml:"forbidden_features"`
+	Enforce           bool                `yaml:"enforce"` // reject the handshake instead of lowering trust
+}
+
+// KeyServiceConfig points federation and evidence signing at a KMS-style
+// key service. An empty URL keeps generating the federation key in-process.
+type KeyServiceConfig struct {
+	URL           string `yaml:"url"`
+	Token         string `yaml:"token"`          // bearer token
+	FederationKey string `yaml:"federation_key"` // key ID for handshake, message and bundle signatures
+	EvidenceKey   string `yaml:"evidence_key"`   // key ID for evidence records; empty leaves them unsigned
+	// What to do with an evidence record the key service fails to sign:
+	// keep_unsigned (chained with signature_error set) or reject
+	EvidenceSignFailure string `yaml:"evidence_sign_failure"`
+	TimeoutSec          int    `yaml:"timeout_sec"` // per signing call
+	RefreshSec          int    `yaml:"refresh_sec"` // how often a rotated key version is picked up
+}
+
+// TrustBundleSourceConfig is a peer trust bundle endpoint. Without a pinned
+// public key the bundle must be signed with the key the peer presented in
+// its handshake.
+type TrustBundleSourceConfig struct {
+	URL          string `yaml:"url"`
+	PublicKeyPEM string `yaml:"public_key_pem"`
 }
 
 // WebhookConfig for webhook dispatcher
@@ -195,6 +291,31 @@ type SecurityConfig struct {
 	DriftThreshold      float64 `yaml:"drift_threshold"`
 	TrustDropLimit      float64 `yaml:"trust_drop_limit"`
 	AnomalyThreshold    int     `yaml:"anomaly_threshold"`
+
+	ResponseInspection ResponseInspectionConfig `yaml:"response_inspection"`
+	AgentCards         AgentCardsConfig         `yaml:"agent_cards"`
+}
+
+// ResponseInspectionConfig for tool results and retrievals before they reach
+// the model. Actions are allow | redact | block.
+type ResponseInspectionConfig struct {
+	MaxResultBytes           int    `yaml:"max_result_bytes"`
+	PIIAction                string `yaml:"pii_action"`
+	SecretAction             string `yaml:"secret_action"`
+	InjectionAction          string `yaml:"injection_action"`
+	RetrievalInjectionAction string `yaml:"retrieval_injection_action"`
+	OversizeAction           string `yaml:"oversize_action"`
+}
+
+// AgentCardsConfig for A2A peer agent cards. Actions are flag | escrow | block.
+type AgentCardsConfig struct {
+	CacheTTLSec            int               `yaml:"cache_ttl_sec"`
+	FetchTimeoutMs         int               `yaml:"fetch_timeout_ms"`
+	UnsignedAction         string            `yaml:"unsigned_action"`
+	InvalidSignatureAction string            `yaml:"invalid_signature_action"`
+	MismatchAction         string            `yaml:"mismatch_action"`
+	TrustedJWKS            map[string]string `yaml:"trusted_jwks"` // card host -> JWKS URL
+	AllowPrivateNetworks   bool              `yaml:"allow_private_networks"`
 }
 
 // SovereignConfig for Sovereign Mode (Claim 12)
@@ -212,6 +333,37 @@ type RedisConfig struct {
 	Enabled  bool   `yaml:"enabled"`
 }
 
+// FabricConfig for additional Hub spoke transports
+type FabricConfig struct {
+	SpokeGRPCPort   string `yaml:"spoke_grpc_port"`   // empty disables the gRPC spoke transport
+	FrameListenPort string `yaml:"frame_listen_port"` // empty disables the binary frame transport
+
+	// Plaintext without a SPIRE agent; refused unless set (development only)
+	AllowInsecureSpokeTransport bool `yaml:"allow_insecure_spoke_transport"`
+	AllowInsecureFrameTransport bool `yaml:"allow_insecure_frame_transport"`
+
+	// AOCS session persistence for resumption across restarts and replicas
+	SessionStore        string `yaml:"session_store"`         // memory | redis | postgres
+	SessionDatabaseURL  string `yaml:"session_database_url"`  // Postgres DSN when session_store=postgres
+	SessionResumeSecret string `yaml:"session_resume_secret"` // HMAC key shared by all replicas
+}
+
+// PluginsConfig for out-of-process connector plugins
+type PluginsConfig struct {
+	Dir            string            `yaml:"dir"` // empty disables external plugins
+	CallTimeoutMs  int               `yaml:"call_timeout_ms"`
+	StartTimeoutMs int               `yaml:"start_timeout_ms"`
+	MaxRestarts    int               `yaml:"max_restarts"`
+	AllowUnsigned  bool              `yaml:"allow_unsigned"` // development only
+	PublisherKeys  map[string]string `yaml:"publisher_keys"` // publisher ID -> PEM public key file
+
+	// Sandboxed WebAssembly parser and policy modules, reloaded on change
+	WASMDir               string `yaml:"wasm_dir"` // empty disables WASM plugins
+	WASMCallTimeoutMs     int    `yaml:"wasm_call_timeout_ms"`
+	WASMMaxMemoryMB       int    `yaml:"wasm_max_memory_mb"`
+	WASMReloadIntervalSec int    `yaml:"wasm_reload_interval_sec"`
+}
+
 // ServicesConfig contains URLs for Python services
 type ServicesConfig struct {
 	TrustRegistryURL    string `yaml:"trust_registry_url"`
@@ -332,6 +484,16 @@ func (c *Config) applyEnvOverrides() {
 	c.Federation.TrustDomain = getEnv("OCX_TRUST_DOMAIN", c.Federation.TrustDomain)
 	c.Federation.Region = getEnv("OCX_REGION", c.Federation.Region)
 	c.Federation.Organization = getEnv("OCX_ORG", c.Federation.Organization)
+	c.Federation.TrustStore = getEnv("OCX_FEDERATION_TRUST_STORE", c.Federation.TrustStore)
+	c.Federation.TrustDatabaseURL = getEnv("OCX_FEDERATION_TRUST_DATABASE_URL", c.Federation.TrustDatabaseURL)
+	c.Federation.MessageGRPCPort = getEnv("OCX_FEDERATION_GRPC_PORT", c.Federation.MessageGRPCPort)
+	c.Federation.AllowInsecureMessageTransport = getEnvBool("OCX_ALLOW_INSECURE_MESSAGE_TRANSPORT", c.Federation.AllowInsecureMessageTransport)
+	if operators := getEnv("OCX_FEDERATION_OPERATOR_TENANTS", ""); operators != "" {
+		c.Federation.OperatorTenants = splitCSV(operators)
+	}
+	c.Federation.Domain = getEnv("OCX_FEDERATION_DOMAIN", c.Federation.Domain)
+	c.Federation.KeyService.URL = getEnv("OCX_KEY_SERVICE_URL", c.Federation.KeyService.URL)
+	c.Federation.KeyService.Token = getEnv("OCX_KEY_SERVICE_TOKEN", c.Federation.KeyService.Token)
 
 	// Tri-Factor Gate
 	if v := getEnvFloat("TRI_FACTOR_IDENTITY_THRESHOLD", 0); v > 0 {
@@ -391,6 +553,18 @@ func (c *Config) applyEnvOverrides() {
 	if v := getEnvFloat("OCX_MIN_TRUST_FOR_TOKEN", 0); v > 0 {
 		c.Security.MinTrustForToken = v
 	}
+	ri := &c.Security.ResponseInspection
+	if v := getEnvInt("OCX_RESPONSE_MAX_BYTES", 0); v > 0 {
+		ri.MaxResultBytes = v
+	}
+	ri.PIIAction = getEnv("OCX_RESPONSE_PII_ACTION", ri.PIIAction)
+	ri.SecretAction = getEnv("OCX_RESPONSE_SECRET_ACTION", ri.SecretAction)
+	ri.InjectionAction = getEnv("OCX_RESPONSE_INJECTION_ACTION", ri.InjectionAction)
+	ri.RetrievalInjectionAction = getEnv("OCX_RESPONSE_RETRIEVAL_INJECTION_ACTION", ri.RetrievalInjectionAction)
+	ac := &c.Security.AgentCards
+	ac.UnsignedAction = getEnv("OCX_AGENT_CARD_UNSIGNED_ACTION", ac.UnsignedAction)
+	ac.InvalidSignatureAction = getEnv("OCX_AGENT_CARD_INVALID_SIGNATURE_ACTION", ac.InvalidSignatureAction)
+	ac.MismatchAction = getEnv("OCX_AGENT_CARD_MISMATCH_ACTION", ac.MismatchAction)
 
 	// Sovereign Mode (Claim 12)
 	c.Sovereign.Enabled = getEnvBool("OCX_SOVEREIGN_MODE", c.Sovereign.Enabled)
@@ -405,6 +579,20 @@ func (c *Config) applyEnvOverrides() {
 	}
 	c.Redis.Enabled = getEnvBool("OCX_REDIS_ENABLED", c.Redis.Enabled)
 
+	// Fabric
+	c.Fabric.SpokeGRPCPort = getEnv("OCX_SPOKE_GRPC_PORT", c.Fabric.SpokeGRPCPort)
+	c.Fabric.FrameListenPort = getEnv("OCX_FRAME_PORT", c.Fabric.FrameListenPort)
+	c.Fabric.AllowInsecureSpokeTransport = getEnvBool("OCX_ALLOW_INSECURE_SPOKE_TRANSPORT", c.Fabric.AllowInsecureSpokeTransport)
+	c.Fabric.AllowInsecureFrameTransport = getEnvBool("OCX_ALLOW_INSECURE_FRAME_TRANSPORT", c.Fabric.AllowInsecureFrameTransport)
+	c.Fabric.SessionStore = getEnv("OCX_SESSION_STORE", c.Fabric.SessionStore)
+	c.Fabric.SessionDatabaseURL = getEnv("OCX_SESSION_DATABASE_URL", c.Fabric.SessionDatabaseURL)
+	c.Fabric.SessionResumeSecret = getEnv("OCX_SESSION_RESUME_SECRET", c.Fabric.SessionResumeSecret)
+
+	// Plugins
+	c.Plugins.Dir = getEnv("OCX_PLUGIN_DIR", c.Plugins.Dir)
+	c.Plugins.AllowUnsigned = getEnvBool("OCX_PLUGIN_ALLOW_UNSIGNED", c.Plugins.AllowUnsigned)
+	c.Plugins.WASMDir = getEnv("OCX_WASM_PLUGIN_DIR", c.Plugins.WASMDir)
+
 	// Apply defaults for zero values
 	c.ApplyDefaults()
 }
@@ -444,6 +632,54 @@ func (c *Config) ApplyDefaults() {
 	if c.Federation.TrustDomain == "" {
 		c.Federation.TrustDomain = "spiffe://ocx-local"
 	}
+	if c.Federation.TrustStore == "" {
+		c.Federation.TrustStore = "memory"
+	}
+	if c.Federation.TrustHistoryPoints == 0 {
+		c.Federation.TrustHistoryPoints = 100
+	}
+	if c.Federation.MaxPeers == 0 {
+		c.Federation.MaxPeers = 64
+	}
+	if c.Federation.TrustBundlePollSec == 0 {
+		c.Federation.TrustBundlePollSec = 300
+	}
+	if c.Federation.MetadataTTLSec == 0 {
+		c.Federation.MetadataTTLSec = 86400
+	}
+	if c.Federation.DiscoveryRefreshSec == 0 {
+		c.Federation.DiscoveryRefreshSec = 900
+	}
+	if c.Federation.IntegrityPenalty == 0 {
+		c.Federation.IntegrityPenalty = 0.5
+	}
+	if c.Federation.Reputation.CredentialTTLSec == 0 {
+		c.Federation.Reputation.CredentialTTLSec = 604800
+	}
+	if c.Federation.Reputation.ReplayWindowSec == 0 {
+		c.Federation.Reputation.ReplayWindowSec = 604800
+	}
+	if c.Federation.Reputation.MinIssuerTrust == 0 {
+		c.Federation.Reputation.MinIssuerTrust = 0.5
+	}
+	if c.Federation.Reputation.MaxImportedScore == 0 {
+		c.Federation.Reputation.MaxImportedScore = 0.8
+	}
+	if c.Federation.Reputation.MaxImportedInteractions == 0 {
+		c.Federation.Reputation.MaxImportedInteractions = 100
+	}
+	if c.Federation.KeyService.TimeoutSec == 0 {
+		c.Federation.KeyService.TimeoutSec = 5
+	}
+	if c.Federation.KeyService.RefreshSec == 0 {
+		c.Federation.KeyService.RefreshSec = 300
+	}
+	if c.Federation.TrustTaxBaseRate == 0 {
+		c.Federation.TrustTaxBaseRate = 0.10
+	}
+	if c.Federation.MessageClockSkewSec == 0 {
+		c.Federation.MessageClockSkewSec = 30
+	}
 
 	// Tri-Factor Gate defaults
 	if c.TriFactor.IdentityThreshold == 0 {
@@ -466,6 +702,9 @@ func (c *Config) ApplyDefaults() {
 	if c.Handshake.BaseTaxRate == 0 {
 		c.Handshake.BaseTaxRate = 0.10
 	}
+	if c.Handshake.DefaultCryptoAlgorithm == "" {
+		c.Handshake.DefaultCryptoAlgorithm = "ed25519"
+	}
 
 	// HITL defaults
 	if c.HITL.DefaultCostMultiplier == 0 {
@@ -486,6 +725,25 @@ func (c *Config) ApplyDefaults() {
 	if c.CloudTasks.QueueID == "" {
 		c.CloudTasks.QueueID = "ocx-webhooks"
 	}
+	// Plugin defaults
+	if c.Plugins.CallTimeoutMs == 0 {
+		c.Plugins.CallTimeoutMs = 2000
+	}
+	if c.Plugins.StartTimeoutMs == 0 {
+		c.Plugins.StartTimeoutMs = 10000
+	}
+	if c.Plugins.MaxRestarts == 0 {
+		c.Plugins.MaxRestarts = 3
+	}
+	if c.Plugins.WASMCallTimeoutMs == 0 {
+		c.Plugins.WASMCallTimeoutMs = 50
+	}
+	if c.Plugins.WASMMaxMemoryMB == 0 {
+		c.Plugins.WASMMaxMemoryMB = 64
+	}
+	if c.Plugins.WASMReloadIntervalSec == 0 {
+		c.Plugins.WASMReloadIntervalSec = 10
+	}
 	// Security defaults
 	if c.Security.TokenTTLSec == 0 {
 		c.Security.TokenTTLSec = 300 // 5 minutes
@@ -508,6 +766,39 @@ func (c *Config) ApplyDefaults() {
 	if c.Security.AnomalyThreshold == 0 {
 		c.Security.AnomalyThreshold = 5
 	}
+	if c.Security.ResponseInspection.MaxResultBytes == 0 {
+		c.Security.ResponseInspection.MaxResultBytes = 1 << 20 // 1 MiB
+	}
+	if c.Security.ResponseInspection.PIIAction == "" {
+		c.Security.ResponseInspection.PIIAction = "redact"
+	}
+	if c.Security.ResponseInspection.SecretAction == "" {
+		c.Security.ResponseInspection.SecretAction = "redact"
+	}
+	if c.Security.ResponseInspection.InjectionAction == "" {
+		c.Security.ResponseInspection.InjectionAction = "redact"
+	}
+	if c.Security.ResponseInspection.RetrievalInjectionAction == "" {
+		c.Security.ResponseInspection.RetrievalInjectionAction = "block"
+	}
+	if c.Security.ResponseInspection.OversizeAction == "" {
+		c.Security.ResponseInspection.OversizeAction = "block"
+	}
+	if c.Security.AgentCards.CacheTTLSec == 0 {
+		c.Security.AgentCards.CacheTTLSec = 3600
+	}
+	if c.Security.AgentCards.FetchTimeoutMs == 0 {
+		c.Security.AgentCards.FetchTimeoutMs = 5000
+	}
+	if c.Security.AgentCards.UnsignedAction == "" {
+		c.Security.AgentCards.UnsignedAction = "flag"
+	}
+	if c.Security.AgentCards.InvalidSignatureAction == "" {
+		c.Security.AgentCards.InvalidSignatureAction = "block"
+	}
+	if c.Security.AgentCards.MismatchAction == "" {
+		c.Security.AgentCards.MismatchAction = "escrow"
+	}
 	// Redis defaults
 	if c.Redis.Addr == "" {
 		c.Redis.Addr = "localhost:6379"
@@ -90,6 +90,7 @@ type ClassificationRequest struct {
 	ToolID          string                 `json:"tool_id"`
 	AgentID         string                 `json:"agent_id"`
 	TenantID        string                 `json:"tenant_id"`
+	ServerID        string                 `json:"server_id,omitempty"` // MCP server the tool is called on, when known
 	Args            map[string]interface{} `json:"args"`
 	AgentTrustScore float64                `json:"agent_trust_score"`
 	Entitlements    []string               `json:"entitlements"`
@@ -103,10 +104,27 @@ type ClassificationResult struct {
 	EntitlementCheck EntitlementResult  `json:"entitlement_check"`
 	TrustCheck       TrustCheckResult   `json:"trust_check"`
 	DynamicOverrides []DynamicOverride  `json:"dynamic_overrides"`
+	ToolRisk         float64            `json:"tool_risk,omitempty"`
+	ToolRiskReasons  []string           `json:"tool_risk_reasons,omitempty"`
 	FinalVerdict     string             `json:"final_verdict"` // PROCEED, BLOCK, ESCALATE
 	Reasoning        string             `json:"reasoning"`
 }
 
+// ToolRiskSource reports risk in how a tool is advertised, independent of
+// any one call: e.g. MCP tool descriptions carrying injected instructions or
+// changed since they were accepted. Risk is scoped to the tenant, and to
+// serverID when it is not empty. Scores run from 0 (clean) to 1.
+type ToolRiskSource interface {
+	ToolRisk(tenantID, serverID, toolID string) (score float64, reasons []string)
+}
+
+const (
+	// ToolRiskHoldThreshold forces ATOMIC_HOLD and human review
+	ToolRiskHoldThreshold = 0.5
+	// ToolRiskBlockThreshold blocks the call outright
+	ToolRiskBlockThreshold = 0.8
+)
+
 // EntitlementResult captures JIT entitlement validation
 type EntitlementResult struct {
 	Required []string `json:"required"`
@@ -137,6 +155,9 @@ type ToolClassifier struct {
 
 	// Governance config — tenant-specific unknown tool thresholds
 	govConfig *governance.GovernanceConfigCache
+
+	// Descriptor-level tool risk (e.g. MCP tool list analysis)
+	riskSource ToolRiskSource
 }
 
 // NewToolClassifier creates a new classifier with default tool registry
@@ -263,6 +284,12 @@ func (tc *ToolClassifier) loadDefaultRegistry() {
 	})
 }
 
+// SetToolRiskSource attaches a descriptor risk source. Risky tools are held
+// for review or blocked regardless of their registered class.
+func (tc *ToolClassifier) SetToolRiskSource(src ToolRiskSource) {
+	tc.riskSource = src
+}
+
 // RegisterTool adds or updates a tool classification in the registry
 func (tc *ToolClassifier) RegisterTool(classification *ToolClassification) {
 	tc.mu.Lock()
@@ -330,6 +357,10 @@ func (tc *ToolClassifier) Classify(req ClassificationRequest) (*ClassificationRe
 		req.AgentTrustScore,
 	)
 
+	if tc.riskSource != nil {
+		result.ToolRisk, result.ToolRiskReasons = tc.riskSource.ToolRisk(req.TenantID, req.ServerID, req.ToolID)
+	}
+
 	// Apply dynamic overrides based on context
 	result.DynamicOverrides = tc.applyDynamicOverrides(req, classification, result)
 
@@ -411,6 +442,16 @@ func (tc *ToolClassifier) applyDynamicOverrides(
 		result.EscrowDecision = ATOMIC_HOLD
 	}
 
+	// Override 4: Suspicious tool descriptors escalate to ATOMIC_HOLD
+	if result.ToolRisk >= ToolRiskHoldThreshold && result.EscrowDecision == GHOST_TURN {
+		overrides = append(overrides, DynamicOverride{
+			Reason:    fmt.Sprintf("Tool descriptor risk %.2f: %v", result.ToolRisk, result.ToolRiskReasons),
+			OldPolicy: GHOST_TURN,
+			NewPolicy: ATOMIC_HOLD,
+		})
+		result.EscrowDecision = ATOMIC_HOLD
+	}
+
 	return overrides
 }
 
@@ -434,6 +475,22 @@ func (tc *ToolClassifier) determineVerdict(result *ClassificationResult) (string
 		)
 	}
 
+	// Block tools whose advertised descriptor looks poisoned
+	if result.ToolRisk >= ToolRiskBlockThreshold {
+		return "BLOCK", fmt.Sprintf(
+			"Tool descriptor risk %.2f at or above %.2f: %v",
+			result.ToolRisk, ToolRiskBlockThreshold, result.ToolRiskReasons,
+		)
+	}
+
+	// Escalate suspicious descriptors to human review
+	if result.ToolRisk >= ToolRiskHoldThreshold {
+		return "ESCALATE", fmt.Sprintf(
+			"Tool descriptor risk %.2f requires HITL approval: %v",
+			result.ToolRisk, result.ToolRiskReasons,
+		)
+	}
+
 	// Escalate CLASS_B to human review
 	if result.Classification.ActionClass == CLASS_B {
 		return "ESCALATE", "CLASS_B action requires Tri-Factor Gate validation and HITL approval"
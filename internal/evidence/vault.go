@@ -31,6 +31,7 @@ const (
 	EvidenceCorrection    EvidenceType = "CORRECTION"    // Human correction
 	EvidenceFederation    EvidenceType = "FEDERATION"    // Cross-OCX event
 	EvidenceDispute       EvidenceType = "DISPUTE"       // Dispute record
+	EvidenceResponse      EvidenceType = "RESPONSE"      // Tool result / retrieval inspection
 )
 
 // VerdictOutcome represents the outcome of a decision
@@ -42,6 +43,7 @@ const (
 	OutcomeHold     VerdictOutcome = "HOLD"
 	OutcomeEscalate VerdictOutcome = "ESCALATE"
 	OutcomeOverride VerdictOutcome = "OVERRIDE"
+	OutcomeRedact   VerdictOutcome = "REDACT"
 )
 
 // ============================================================================
@@ -89,6 +91,12 @@ type EvidenceRecord struct {
 	PreviousHash string `json:"previous_hash"`
 	Signature    []byte `json:"signature,omitempty"`
 
+	// Key that produced Signature, or why the record is unsigned. Like the
+	// signature they are not part of the hash.
+	SigningKeyID      string `json:"signing_key_id,omitempty"`
+	SigningKeyVersion string `json:"signing_key_version,omitempty"`
+	SignatureError    string `json:"signature_error,omitempty"`
+
 	// Metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
@@ -107,6 +115,9 @@ func (e *EvidenceRecord) ComputeHash() string {
 	copy := *e
 	copy.Hash = ""
 	copy.Signature = nil
+	copy.SigningKeyID = ""
+	copy.SigningKeyVersion = ""
+	copy.SignatureError = ""
 
 	data, err := json.Marshal(copy)
 	if err != nil {
@@ -137,6 +148,11 @@ type EvidenceChain struct {
 	RecordCount int64
 
 	mu sync.RWMutex
+
+	// appendMu orders appends. It is held from linking a record to the
+	// previous hash until the record is in the chain, which may include a
+	// signing call, so readers only wait on mu.
+	appendMu sync.Mutex
 }
 
 // NewEvidenceChain creates a new evidence chain for a tenant
@@ -171,22 +187,30 @@ func NewEvidenceChain(tenantID string) *EvidenceChain {
 
 // Append adds a new record to the chain
 func (ec *EvidenceChain) Append(record *EvidenceRecord) error {
-	ec.mu.Lock()
-	defer ec.mu.Unlock()
+	ec.appendMu.Lock()
+	defer ec.appendMu.Unlock()
+	ec.link(record)
+	ec.commit(record)
+	return nil
+}
 
-	// Link to previous record
+// link sets the record's previous hash and hash. The caller holds appendMu
+// until commit.
+func (ec *EvidenceChain) link(record *EvidenceRecord) {
+	ec.mu.RLock()
 	record.PreviousHash = ec.LastHash
-
-	// Compute hash
+	ec.mu.RUnlock()
 	record.Hash = record.ComputeHash()
+}
 
-	// Add to chain
+// commit adds a linked record to the chain.
+func (ec *EvidenceChain) commit(record *EvidenceRecord) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
 	ec.Records = append(ec.Records, record)
 	ec.LastHash = record.Hash
 	ec.RecordCount++
 	ec.UpdatedAt = time.Now()
-
-	return nil
 }
 
 // Validate validates the entire chain integrity
@@ -256,6 +280,11 @@ type EvidenceVault struct {
 	// Storage backend (for production)
 	store EvidenceStore
 
+	// Optional record signer (e.g. a key held by an external key service)
+	signer        RecordSigner
+	signFailure   SignFailurePolicy
+	unsignedCount int64
+
 	mu     sync.RWMutex
 	logger *log.Logger
 }
@@ -268,6 +297,39 @@ type EvidenceStore interface {
 	QueryRecords(ctx context.Context, query RecordQuery) ([]*EvidenceRecord, error)
 }
 
+// RecordSigner signs the hash of each appended record and names the key
+// version it used. Implementations may call out to an external key service,
+// so the private key never has to live in this process.
+type RecordSigner interface {
+	SignRecord(ctx context.Context, data []byte) (sig []byte, keyID, keyVersion string, err error)
+}
+
+// SignFailurePolicy decides what happens to a record the signer fails on.
+type SignFailurePolicy string
+
+const (
+	// SignFailureKeepUnsigned chains the record without a signature and
+	// sets its SignatureError, so a gap in signing is visible in the record.
+	SignFailureKeepUnsigned SignFailurePolicy = "keep_unsigned"
+
+	// SignFailureReject leaves the record out of the chain and returns the
+	// error to the caller.
+	SignFailureReject SignFailurePolicy = "reject"
+)
+
+// ErrRecordNotSigned is returned under SignFailureReject when the signer
+// fails.
+var ErrRecordNotSigned = errors.New("evidence record could not be signed")
+
+// ParseSignFailurePolicy maps a config value to a policy. Unknown values
+// keep records unsigned.
+func ParseSignFailurePolicy(s string) SignFailurePolicy {
+	if SignFailurePolicy(s) == SignFailureReject {
+		return SignFailureReject
+	}
+	return SignFailureKeepUnsigned
+}
+
 // RecordQuery defines a query for evidence records
 type RecordQuery struct {
 	TenantID      string
@@ -299,6 +361,16 @@ func NewEvidenceVault(cfg VaultConfig) *EvidenceVault {
 	}
 }
 
+// SetSigner makes the vault sign the hash of every record it appends.
+// onFailure decides whether a record the signer fails on is kept unsigned
+// or rejected.
+func (ev *EvidenceVault) SetSigner(s RecordSigner, onFailure SignFailurePolicy) {
+	ev.mu.Lock()
+	ev.signer = s
+	ev.signFailure = onFailure
+	ev.mu.Unlock()
+}
+
 // RecordTransaction records a transaction in the vault
 func (ev *EvidenceVault) RecordTransaction(
 	ctx context.Context,
@@ -403,23 +475,101 @@ func (ev *EvidenceVault) RecordCorrection(
 	return ev.appendRecord(ctx, record)
 }
 
-func (ev *EvidenceVault) appendRecord(ctx context.Context, record *EvidenceRecord) (*EvidenceRecord, error) {
-	ev.mu.Lock()
-	defer ev.mu.Unlock()
+// RecordResponseInspection records findings from inspecting a tool result or
+// retrieval before it reached the model. txID is the transaction of the call
+// that produced the result, so the findings sit alongside its verdict.
+func (ev *EvidenceVault) RecordResponseInspection(
+	ctx context.Context,
+	tenantID, agentID, txID string,
+	toolID string,
+	verdict VerdictOutcome,
+	reasoning string,
+	findings map[string]interface{},
+) (*EvidenceRecord, error) {
+	record := &EvidenceRecord{
+		ID:            fmt.Sprintf("resp-%s-%d", txID, time.Now().UnixNano()),
+		Type:          EvidenceResponse,
+		TransactionID: txID,
+		TenantID:      tenantID,
+		AgentID:       agentID,
+		ToolID:        toolID,
+		Verdict:       verdict,
+		Reasoning:     reasoning,
+		Payload:       findings,
+		Timestamp:     time.Now(),
+		ProcessedAt:   time.Now(),
+	}
+
+	return ev.appendRecord(ctx, record)
+}
+
+// RecordFederation records a message exchanged with another OCX instance.
+// direction is "outbound" on the sender and "inbound" on the receiver;
+// txID is the federated message ID, so both sides' records correlate.
+func (ev *EvidenceVault) RecordFederation(
+	ctx context.Context,
+	tenantID, agentID, txID string,
+	peerInstanceID, direction string,
+	verdict VerdictOutcome,
+	trustScore float64,
+	reasoning string,
+	details map[string]interface{},
+) (*EvidenceRecord, error) {
+	record := &EvidenceRecord{
+		ID:            fmt.Sprintf("fed-%s-%d", txID, time.Now().UnixNano()),
+		Type:          EvidenceFederation,
+		TransactionID: txID,
+		TenantID:      tenantID,
+		AgentID:       agentID,
+		Verdict:       verdict,
+		TrustScore:    trustScore,
+		Reasoning:     reasoning,
+		Payload:       details,
+		Timestamp:     time.Now(),
+		ProcessedAt:   time.Now(),
+		Metadata: map[string]interface{}{
+			"peer_instance_id": peerInstanceID,
+			"direction":        direction,
+		},
+	}
 
+	return ev.appendRecord(ctx, record)
+}
+
+func (ev *EvidenceVault) appendRecord(ctx context.Context, record *EvidenceRecord) (*EvidenceRecord, error) {
 	// Get or create chain for tenant
+	ev.mu.Lock()
 	chain, exists := ev.chains[record.TenantID]
 	if !exists {
 		chain = NewEvidenceChain(record.TenantID)
 		ev.chains[record.TenantID] = chain
 	}
-
-	// Append to chain
-	if err := chain.Append(record); err != nil {
-		return nil, err
+	signer, onFailure := ev.signer, ev.signFailure
+	ev.mu.Unlock()
+
+	// Link, sign and append. Only appends to this tenant's chain wait on
+	// the signer; the record is not visible until it is complete.
+	chain.appendMu.Lock()
+	chain.link(record)
+	if signer != nil {
+		sig, keyID, version, err := signer.SignRecord(ctx, []byte(record.Hash))
+		if err != nil {
+			if onFailure == SignFailureReject {
+				chain.appendMu.Unlock()
+				ev.logger.Printf("Rejected record %s: signing failed: %v", record.ID, err)
+				return nil, fmt.Errorf("%w: %s: %v", ErrRecordNotSigned, record.ID, err)
+			}
+			record.SignatureError = err.Error()
+			ev.logger.Printf("Recording %s unsigned: signing failed: %v", record.ID, err)
+		} else {
+			record.Signature, record.SigningKeyID, record.SigningKeyVersion = sig, keyID, version
+		}
 	}
+	chain.commit(record)
+	chain.appendMu.Unlock()
 
 	// Update indexes
+	ev.mu.Lock()
 	ev.txIndex[record.TransactionID] = append(
 		ev.txIndex[record.TransactionID],
 		record.ID,
@@ -428,6 +578,10 @@ func (ev *EvidenceVault) appendRecord(ctx context.Context, record *EvidenceRecor
 		ev.agentIndex[record.AgentID],
 		record.ID,
 	)
+	if record.SignatureError != "" {
+		ev.unsignedCount++
+	}
+	ev.mu.Unlock()
 
 	// Persist to store if available
 	if ev.store != nil {
@@ -714,6 +868,7 @@ func (ev *EvidenceVault) Stats() map[string]interface{} {
 		"transaction_index": len(ev.txIndex),
 		"agent_index":       len(ev.agentIndex),
 		"retention_days":    ev.retentionDays,
+		"unsigned_records":  ev.unsignedCount,
 	}
 }
 
@@ -0,0 +1,46 @@
+// Package fabric — Inbound federated messages.
+//
+// Messages from other OCX instances arrive over the federation message
+// transport (federation.FederatedMessageServer). Once the federation layer
+// has verified the sender's signature and the message lifetime, the Hub
+// routes the message to the destination tenant/agent like any other
+// message. The source address names the remote instance, so a reply can be
+// told apart from local traffic.
+package fabric
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ocx/backend/internal/federation"
+)
+
+// federatedHops is the fabric TTL given to inbound federated messages.
+const federatedHops = 5
+
+// DeliverFederated routes a verified message from a federated OCX instance
+// to DestTenant/DestAgent on this hub. Implements federation.LocalDelivery.
+func (h *Hub) DeliverFederated(ctx context.Context, msg *federation.FederatedMessage, trustTax float64) error {
+	if msg.DestTenant == "" || msg.DestAgent == "" {
+		return fmt.Errorf("federated message %s has no destination agent", msg.ID)
+	}
+
+	_, err := h.Route(ctx, &Message{
+		ID:          msg.ID,
+		Type:        msg.MessageType,
+		Source:      VirtualAddress(fmt.Sprintf("ocx://%s/%s/%s", msg.SourceOCX, msg.SourceTenant, msg.SourceAgent)),
+		Destination: h.assignVirtualAddress(msg.DestTenant, msg.DestAgent),
+		TenantID:    msg.DestTenant,
+		Payload:     msg.Payload,
+		Headers: map[string]string{
+			"x-ocx-federation-source": string(msg.SourceOCX),
+			"x-ocx-trace-id":          msg.TraceID,
+			"x-ocx-trust-tax":         strconv.FormatFloat(trustTax, 'f', 4, 64),
+		},
+		Timestamp: time.Now(),
+		TTL:       federatedHops,
+	})
+	return err
+}
@@ -0,0 +1,625 @@
+// Package fabric — Binary AOCS frame transport.
+//
+// High-volume agents can skip JSON entirely and speak the 110-byte
+// protocol.Frame format over a raw TCP (or TLS) connection. Each connection
+// is bound to a protocol.Session and registered as a Hub spoke, so framed
+// agents share routing, forwarding and metrics with WebSocket and gRPC spokes.
+//
+// Wire rules:
+//   - The first frame must be FrameTypeHandshake with SequenceNum 0 and a JSON
+//     FrameHandshake payload. The hub answers with a handshake frame carrying
+//     the assigned SessionID, which every later frame must echo, and a
+//     single-use resume_token.
+//   - If the connection drops, the session is suspended. A new connection (to
+//     any replica sharing the session store) may present resume_token in its
+//     handshake, whose SequenceNum must then continue the old sequence.
+//     Disconnect frames and protocol violations end the session instead.
+//   - Inbound sequence numbers increase by one per frame (wrapping at 2^16);
+//     gaps, replays and bad CRC-16 checksums terminate the connection.
+//   - FrameTypeMessage payloads are protocol.EncodeAddressedPayload(dest, body);
+//     DestAddr, when set, must equal protocol.AddressDigest(dest).
+//   - The handshake may offer compression and encryption (protocol.CodecOffer);
+//     the negotiated protocol.CodecParams apply to every frame after the
+//     handshake in both directions. Encryption is negotiated only when the
+//     handshake carries key_share, an ephemeral X25519 public key; the ack
+//     returns the hub's. Both sides compute
+//     secret = federation.DeriveSessionKey(X25519(shares), SessionID, frameKeyShareInfo || EKM)
+//     where EKM is 32 bytes of TLS keying material exported with label
+//     frameKeyExporterLabel and no context, or empty on plaintext
+//     connections. The keys are protocol.DeriveFrameKeys(secret, SessionID):
+//     the client seals with the client->server key and the hub with the
+//     server->client key. On plaintext connections (development only) the
+//     exchange is unauthenticated, so it protects against passive capture
+//     but not an active man-in-the-middle.
+package fabric
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ocx/backend/internal/federation"
+	"github.com/ocx/backend/internal/protocol"
+)
+
+const (
+	frameIdleTimeout = 2 * time.Minute // Connection dropped after this long without a frame
+	frameSessionTTL  = 24 * time.Hour
+
+	frameKeyExporterLabel = "EXPORTER-ocx-aocs-frame"
+	frameKeyShareInfo     = "ocx-aocs-frame-v1 key share"
+)
+
+// FrameHandshake is the JSON payload of the client's handshake frame.
+type FrameHandshake struct {
+	TenantID     string   `json:"tenant_id"`
+	AgentID      string   `json:"agent_id"`
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Ignored by the hub, which resolves trust and entitlements itself
+	// (SpokeAuthority); kept for wire compatibility.
+	TrustScore   float64  `json:"trust_score"`
+	Entitlements []string `json:"entitlements,omitempty"`
+
+	Codec protocol.CodecOffer `json:"codec,omitempty"`
+
+	// KeyShare is the client's ephemeral X25519 public key. Encryption is
+	// only negotiated when it is present.
+	KeyShare []byte `json:"key_share,omitempty"`
+
+	// ResumeToken reattaches a suspended session instead of creating one
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// FrameHandshakeAck is the JSON payload of the hub's handshake reply.
+type FrameHandshakeAck struct {
+	SessionID   string `json:"session_id"`
+	SpokeID     string `json:"spoke_id"`
+	VirtualAddr string `json:"virtual_addr"`
+	HubID       string `json:"hub_id"`
+
+	Codec    protocol.CodecParams `json:"codec"`
+	KeyShare []byte               `json:"key_share,omitempty"` // Hub's X25519 public key when encrypting
+
+	Resumed     bool   `json:"resumed"`
+	TurnCount   int32  `json:"turn_count"`
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// FrameServer accepts framed connections and binds them to hub spokes.
+type FrameServer struct {
+	hub      *Hub
+	sessions *protocol.SessionManager
+
+	// allowInsecure accepts plaintext connections with a self-asserted
+	// agent_id. Only enabled for local development without a SPIRE agent.
+	allowInsecure bool
+}
+
+// NewFrameServer creates a frame transport for a hub. Connections must be
+// TLS with an SVID unless allowInsecure is set (development only).
+func NewFrameServer(hub *Hub, sessions *protocol.SessionManager, allowInsecure bool) *FrameServer {
+	return &FrameServer{hub: hub, sessions: sessions, allowInsecure: allowInsecure}
+}
+
+// Serve accepts connections until the listener is closed or ctx is cancelled.
+// Wrap lis with tls.NewListener (SPIFFE server config) to require SVIDs.
+func (s *FrameServer) Serve(ctx context.Context, lis net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		lis.Close()
+	}()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(ctx, conn)
+	}
+}
+
+// frameConn is a framed connection bound to a session and spoke.
+// It implements SpokeSink; only writeLoop writes to the socket.
+type frameConn struct {
+	conn    net.Conn
+	session *protocol.Session
+	spoke   *SpokeInfo
+	out     chan *protocol.Frame
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (fc *frameConn) close() {
+	fc.once.Do(func() {
+		close(fc.done)
+		fc.conn.Close()
+	})
+}
+
+// Deliver encodes a routed message as a FrameTypeMessage. Implements SpokeSink.
+func (fc *frameConn) Deliver(msg *Message) error {
+	payload, err := protocol.EncodeAddressedPayload(string(msg.Source), msg.Payload)
+	if err != nil {
+		return err
+	}
+	frame := fc.newFrame(protocol.FrameTypeMessage, payload)
+	frame.Header.SourceAddr = protocol.AddressDigest(string(msg.Source))
+	frame.Header.DestAddr = protocol.AddressDigest(string(fc.spoke.VirtualAddr))
+	frame.Header.TransactionID = transactionIDFromMessageID(msg.ID)
+
+	select {
+	case fc.out <- frame:
+		return nil
+	case <-fc.done:
+		return fmt.Errorf("frame connection closed")
+	default:
+		return fmt.Errorf("send buffer full")
+	}
+}
+
+// newFrame builds an outbound frame stamped with the session's identifiers.
+// Sequence numbers are assigned in writeLoop so they match wire order.
+func (fc *frameConn) newFrame(frameType protocol.FrameType, payload []byte) *protocol.Frame {
+	frame := protocol.NewFrame(frameType, payload)
+	frame.Header.SessionID = fc.session.ID
+	frame.Header.TenantID = fc.session.TenantID
+	frame.Header.AgentID = fc.session.AgentID
+	return frame
+}
+
+func (fc *frameConn) writeLoop() {
+	defer fc.close()
+	for {
+		select {
+		case frame := <-fc.out:
+			frame.Header.SequenceNum = fc.session.NextSequence()
+			if codec := fc.session.Codec(); codec != nil && frame.Header.FrameType != protocol.FrameTypeHandshake {
+				if err := codec.Encode(frame); err != nil {
+					slog.Warn("Frame encode failed", "spoke_id", fc.spoke.ID, "error", err)
+					continue
+				}
+			}
+			if err := frame.Seal(); err != nil {
+				slog.Warn("Frame seal failed", "spoke_id", fc.spoke.ID, "error", err)
+				continue
+			}
+			fc.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := protocol.WriteFrame(fc.conn, frame); err != nil {
+				slog.Warn("Frame write failed", "spoke_id", fc.spoke.ID, "error", err)
+				return
+			}
+			fc.session.RecordMessage(true, len(frame.Payload))
+		case <-fc.done:
+			return
+		}
+	}
+}
+
+// reply queues a frame without blocking the read loop.
+func (fc *frameConn) reply(frame *protocol.Frame) {
+	select {
+	case fc.out <- frame:
+	default:
+		slog.Warn("Frame send buffer full, dropping reply", "spoke_id", fc.spoke.ID, "type", frame.Header.FrameType)
+	}
+}
+
+func (fc *frameConn) replyError(txID [32]byte, err error) {
+	frame := fc.newFrame(protocol.FrameTypeError, []byte(err.Error()))
+	frame.Header.TransactionID = txID
+	fc.reply(frame)
+}
+
+func (s *FrameServer) handleConn(ctx context.Context, conn net.Conn) {
+	remote := conn.RemoteAddr().String()
+
+	svidAgent, err := frameConnAgent(conn)
+	if err == nil && svidAgent == "" && !s.allowInsecure {
+		err = fmt.Errorf("SPIFFE SVID required")
+	}
+	if err != nil {
+		slog.Warn("Framed connection rejected", "remote", remote, "error", err)
+		conn.Close()
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(frameIdleTimeout))
+	first, err := readVerifiedFrame(conn)
+	if err != nil {
+		slog.Warn("Framed handshake read failed", "remote", remote, "error", err)
+		conn.Close()
+		return
+	}
+	if first.Header.FrameType != protocol.FrameTypeHandshake {
+		slog.Warn("Framed connection must open with a handshake", "remote", remote,
+			"type", first.Header.FrameType)
+		conn.Close()
+		return
+	}
+
+	var hs FrameHandshake
+	if err := json.Unmarshal(first.Payload, &hs); err != nil {
+		slog.Warn("Invalid framed handshake payload", "remote", remote, "error", err)
+		conn.Close()
+		return
+	}
+	if svidAgent != "" {
+		if hs.AgentID != "" && hs.AgentID != svidAgent {
+			slog.Warn("Framed handshake agent does not match SVID", "remote", remote,
+				"agent_id", hs.AgentID, "svid_agent", svidAgent)
+			conn.Close()
+			return
+		}
+		hs.AgentID = svidAgent
+	}
+	if hs.AgentID == "" {
+		slog.Warn("Framed handshake missing agent_id", "remote", remote)
+		conn.Close()
+		return
+	}
+
+	// Only the agent identity comes from the peer; tenant membership, trust
+	// and entitlements are resolved here and replace whatever it claimed.
+	grant, err := s.hub.resolveSpoke(ctx, hs.TenantID, hs.AgentID)
+	if err != nil {
+		slog.Warn("Framed spoke not authorized", "remote", remote, "agent_id", hs.AgentID,
+			"tenant_id", hs.TenantID, "error", err)
+		conn.Close()
+		return
+	}
+	hs.TenantID = grant.TenantID
+	hs.TrustScore = grant.TrustScore
+	hs.Entitlements = grant.Entitlements
+
+	caps := make([]Capability, len(hs.Capabilities))
+	for i, c := range hs.Capabilities {
+		caps[i] = Capability(c)
+	}
+	spoke, err := s.hub.RegisterSpoke(hs.TenantID, hs.AgentID, caps, hs.TrustScore, hs.Entitlements)
+	if err != nil {
+		slog.Warn("Framed spoke registration failed", "remote", remote, "error", err)
+		conn.Close()
+		return
+	}
+
+	session, resumed, err := s.openSession(ctx, &hs, first.Header.SequenceNum, spoke)
+	if err != nil {
+		slog.Warn("Framed session setup failed", "remote", remote, "resume", hs.ResumeToken != "", "error", err)
+		s.hub.UnregisterSpoke(spoke.ID)
+		conn.Close()
+		return
+	}
+
+	tlsConn, _ := conn.(*tls.Conn)
+	params := protocol.NegotiateCodec(hs.Codec, len(hs.KeyShare) > 0)
+	hubShare, err := installFrameCodec(session, params, hs.KeyShare, tlsConn)
+	if err != nil {
+		slog.Warn("Framed codec setup failed", "remote", remote, "error", err)
+		s.hub.UnregisterSpoke(spoke.ID)
+		s.sessions.Remove(session.ID)
+		conn.Close()
+		return
+	}
+
+	fc := &frameConn{
+		conn:    conn,
+		session: session,
+		spoke:   spoke,
+		out:     make(chan *protocol.Frame, sendBuffer),
+		done:    make(chan struct{}),
+	}
+	resumable := false
+	defer func() {
+		fc.close()
+		s.hub.UnregisterSpoke(spoke.ID)
+		if resumable {
+			suspendCtx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			err := s.sessions.Suspend(suspendCtx, session.ID)
+			cancel()
+			if err == nil {
+				slog.Info("Framed spoke disconnected, session suspended", "spoke_id", spoke.ID, "session_id", session.IDString())
+				return
+			}
+			slog.Warn("Failed to suspend framed session", "session_id", session.IDString(), "error", err)
+		}
+		session.Terminate()
+		s.sessions.Remove(session.ID)
+		slog.Info("Framed spoke disconnected", "spoke_id", spoke.ID, "session_id", session.IDString())
+	}()
+
+	if err := s.hub.AttachSink(spoke.ID, fc); err != nil {
+		slog.Warn("Failed to attach frame sink", "spoke_id", spoke.ID, "error", err)
+		return
+	}
+
+	resumeToken, err := s.sessions.IssueResumptionToken(ctx, session)
+	if err != nil {
+		slog.Warn("Failed to issue resumption token", "session_id", session.IDString(), "error", err)
+	}
+
+	ack, _ := json.Marshal(FrameHandshakeAck{
+		SessionID:   session.IDString(),
+		SpokeID:     string(spoke.ID),
+		VirtualAddr: string(spoke.VirtualAddr),
+		HubID:       string(s.hub.ID),
+		Codec:       params,
+		KeyShare:    hubShare,
+		Resumed:     resumed,
+		TurnCount:   session.Turn(),
+		ResumeToken: resumeToken,
+	})
+	fc.reply(fc.newFrame(protocol.FrameTypeHandshake, ack))
+	go fc.writeLoop()
+
+	slog.Info("Framed spoke connected", "spoke_id", spoke.ID, "session_id", session.IDString(),
+		"tenant_id", hs.TenantID, "agent_id", hs.AgentID, "remote", remote,
+		"compression", params.Compression, "encryption", params.Encryption, "resumed", resumed)
+
+	resumable = s.readLoop(ctx, fc)
+}
+
+// openSession creates a session for a new handshake, or resumes one when the
+// handshake carries a resumption token. seq is the handshake's SequenceNum.
+func (s *FrameServer) openSession(ctx context.Context, hs *FrameHandshake, seq uint16, spoke *SpokeInfo) (*protocol.Session, bool, error) {
+	tenantHash := protocol.IdentifierHash(hs.TenantID)
+	agentHash := protocol.IdentifierHash(hs.AgentID)
+
+	if hs.ResumeToken != "" {
+		session, err := s.sessions.Resume(ctx, hs.ResumeToken)
+		if err != nil {
+			return nil, false, err
+		}
+		if session.TenantID != tenantHash || session.AgentID != agentHash {
+			// A token presented by another identity is treated as compromised
+			session.Terminate()
+			s.sessions.Remove(session.ID)
+			return nil, false, fmt.Errorf("resumption token does not belong to %s/%s", hs.TenantID, hs.AgentID)
+		}
+		if err := session.AcceptSequence(seq); err != nil {
+			session.Terminate()
+			s.sessions.Remove(session.ID)
+			return nil, false, err
+		}
+		return session, true, nil
+	}
+
+	if seq != 0 {
+		return nil, false, fmt.Errorf("new session handshake must use seq=0, got %d", seq)
+	}
+	session, err := s.sessions.Create(ctx, protocol.SessionConfig{
+		TenantID:    tenantHash,
+		AgentID:     agentHash,
+		LocalAddr:   protocol.AddressDigest(string(s.hub.ID)),
+		RemoteAddr:  protocol.AddressDigest(string(spoke.VirtualAddr)),
+		TrustLevel:  hs.TrustScore,
+		IdleTimeout: frameIdleTimeout,
+		TTL:         frameSessionTTL,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	session.Activate()
+	session.AcceptSequence(seq)
+	return session, false, nil
+}
+
+// readLoop processes inbound frames until the connection ends. It reports
+// whether the session may be resumed: true when the connection was lost,
+// false after a Disconnect frame or a protocol violation.
+func (s *FrameServer) readLoop(ctx context.Context, fc *frameConn) bool {
+	for {
+		fc.conn.SetReadDeadline(time.Now().Add(frameIdleTimeout))
+		frame, err := readVerifiedFrame(fc.conn)
+		if err != nil {
+			select {
+			case <-fc.done:
+			default:
+				slog.Info("Framed connection closed", "spoke_id", fc.spoke.ID, "error", err)
+			}
+			return true
+		}
+
+		if frame.Header.SessionID != fc.session.ID {
+			fc.session.RecordError(fmt.Errorf("session ID mismatch"))
+			slog.Warn("Frame session ID mismatch", "spoke_id", fc.spoke.ID)
+			return false
+		}
+		if codec := fc.session.Codec(); codec != nil {
+			if err := codec.Decode(frame); err != nil {
+				fc.session.RecordError(err)
+				slog.Warn("Frame decode failed", "spoke_id", fc.spoke.ID, "error", err)
+				return false
+			}
+		}
+		if err := fc.session.AcceptSequence(frame.Header.SequenceNum); err != nil {
+			fc.session.RecordError(err)
+			slog.Warn("Frame sequence violation", "spoke_id", fc.spoke.ID, "error", err)
+			return false
+		}
+		fc.session.RecordMessage(false, len(frame.Payload))
+		fc.spoke.Touch(int64(len(frame.Payload)))
+
+		switch frame.Header.FrameType {
+		case protocol.FrameTypeMessage:
+			s.routeFrame(ctx, fc, frame)
+		case protocol.FrameTypeHeartbeat:
+			fc.reply(fc.newFrame(protocol.FrameTypeHeartbeat, nil))
+		case protocol.FrameTypeDisconnect:
+			return false
+		default:
+			fc.replyError(frame.Header.TransactionID,
+				fmt.Errorf("unsupported frame type %s", frame.Header.FrameType))
+		}
+	}
+}
+
+func (s *FrameServer) routeFrame(ctx context.Context, fc *frameConn, frame *protocol.Frame) {
+	txID := frame.Header.TransactionID
+
+	dest, body, err := protocol.DecodeAddressedPayload(frame.Payload)
+	if err != nil {
+		fc.replyError(txID, err)
+		return
+	}
+	if frame.Header.DestAddr != ([16]byte{}) && frame.Header.DestAddr != protocol.AddressDigest(dest) {
+		fc.replyError(txID, fmt.Errorf("destination digest does not match %s", dest))
+		return
+	}
+
+	msgID := messageIDFromTransactionID(txID)
+	msg := &Message{
+		ID:          msgID,
+		Type:        frame.Header.FrameType.String(),
+		Source:      fc.spoke.VirtualAddr,
+		Destination: VirtualAddress(dest),
+		TenantID:    fc.spoke.TenantID,
+		Payload:     body,
+		Headers: map[string]string{
+			"action_class": fmt.Sprintf("%d", frame.Header.ActionClass),
+			"session_id":   fc.session.IDString(),
+		},
+		Timestamp: time.Unix(int64(frame.Header.Timestamp), 0),
+		TTL:       5,
+	}
+
+	result, err := s.hub.Route(ctx, msg)
+	if err != nil {
+		fc.replyError(txID, err)
+		return
+	}
+
+	resp, _ := json.Marshal(map[string]interface{}{
+		"id":           msgID,
+		"status":       "routed",
+		"destinations": result.Destinations,
+		"hops":         result.HopsUsed,
+	})
+	reply := fc.newFrame(protocol.FrameTypeResponse, resp)
+	reply.Header.TransactionID = txID
+	fc.reply(reply)
+}
+
+// readVerifiedFrame reads one frame and checks its CRC-16.
+func readVerifiedFrame(conn net.Conn) (*protocol.Frame, error) {
+	frame, err := protocol.ReadFrame(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := frame.VerifyChecksum(); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// frameConnAgent returns the SVID agent ID for TLS connections, completing
+// the TLS handshake first. Plain TCP connections yield "".
+func frameConnAgent(conn net.Conn) (string, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return "", nil
+	}
+	tlsConn.SetDeadline(time.Now().Add(writeWait))
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	tlsConn.SetDeadline(time.Time{})
+
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", fmt.Errorf("SPIFFE SVID required")
+	}
+	return svidAgentID(certs[0])
+}
+
+// installFrameCodec builds the negotiated codec and attaches it to the session.
+// When encrypting it answers the client's key share with the returned hub
+// share; the keys are bound to that exchange, the session ID and, over TLS,
+// the connection, with a separate key per direction.
+func installFrameCodec(session *protocol.Session, params protocol.CodecParams, clientShare []byte, tlsConn *tls.Conn) ([]byte, error) {
+	if params.Compression == protocol.CompressionNone && params.Encryption == protocol.CipherNone {
+		return nil, nil
+	}
+
+	var sendKey, recvKey, hubShare []byte
+	keyID := ""
+	if params.Encryption != protocol.CipherNone {
+		peer, err := ecdh.X25519().NewPublicKey(clientShare)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key share: %w", err)
+		}
+		priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate key share: %w", err)
+		}
+		shared, err := priv.ECDH(peer)
+		if err != nil {
+			return nil, fmt.Errorf("key exchange: %w", err)
+		}
+		var ekm []byte
+		if tlsConn != nil {
+			state := tlsConn.ConnectionState()
+			if ekm, err = state.ExportKeyingMaterial(frameKeyExporterLabel, nil, 32); err != nil {
+				return nil, fmt.Errorf("export TLS keying material: %w", err)
+			}
+		}
+		secret, err := frameSessionSecret(shared, session.ID, ekm)
+		if err != nil {
+			return nil, err
+		}
+		if recvKey, sendKey, err = protocol.DeriveFrameKeys(secret, session.ID[:]); err != nil {
+			return nil, err
+		}
+		hubShare = priv.PublicKey().Bytes()
+		keyID = fmt.Sprintf("%s/%s", params.Encryption, session.IDString())
+	}
+
+	codec, err := protocol.NewFrameCodec(params, sendKey, recvKey)
+	if err != nil {
+		return nil, err
+	}
+	session.SetCodec(codec, keyID)
+	return hubShare, nil
+}
+
+// frameSessionSecret derives the secret DeriveFrameKeys expands from the
+// handshake's X25519 shared secret, mixing in TLS keying material when
+// the connection has it.
+func frameSessionSecret(shared []byte, sessionID [16]byte, ekm []byte) ([]byte, error) {
+	info := append([]byte(frameKeyShareInfo), ekm...)
+	return federation.DeriveSessionKey(shared, sessionID[:], info)
+}
+
+// messageIDFromTransactionID renders a frame transaction ID as a hub message
+// ID, generating one when the client left it zero.
+func messageIDFromTransactionID(txID [32]byte) string {
+	if txID == ([32]byte{}) {
+		return uuid.New().String()
+	}
+	return hex.EncodeToString(txID[:])
+}
+
+// transactionIDFromMessageID is the inverse of messageIDFromTransactionID for
+// IDs that round-trip; other IDs are copied (truncated to 32 bytes).
+func transactionIDFromMessageID(id string) [32]byte {
+	var txID [32]byte
+	if b, err := hex.DecodeString(id); err == nil && len(b) == len(txID) {
+		copy(txID[:], b)
+		return txID
+	}
+	copy(txID[:], id)
+	return txID
+}
@@ -0,0 +1,126 @@
+package fabric
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ocx/backend/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// FRAME TRANSPORT TESTS
+// ============================================================================
+
+func TestFrameServerRefusesPlaintextByDefault(t *testing.T) {
+	sessions := protocol.NewSessionManager(protocol.SessionManagerConfig{})
+	t.Cleanup(sessions.Stop)
+	srv := NewFrameServer(NewHub("hub-1", "test", "ocx"), sessions, false)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go srv.handleConn(context.Background(), server)
+
+	// The hub closes a plaintext connection before reading its handshake
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	_, err := client.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+// newPlaintextFrameServer returns a development-mode frame server that
+// accepts plaintext connections.
+func newPlaintextFrameServer(t *testing.T) *FrameServer {
+	sessions := protocol.NewSessionManager(protocol.SessionManagerConfig{
+		MaxSessionsPerTenant: 10,
+		MaxTotalSessions:     10,
+	})
+	t.Cleanup(sessions.Stop)
+	return NewFrameServer(NewHub("hub-1", "test", "ocx"), sessions, true)
+}
+
+func TestFrameServerEncryptsPlaintextWithKeyShare(t *testing.T) {
+	srv := newPlaintextFrameServer(t)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go srv.handleConn(context.Background(), server)
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hs, _ := json.Marshal(FrameHandshake{
+		AgentID:  "agent-1",
+		Codec:    protocol.CodecOffer{Encryption: []protocol.CipherSuite{protocol.CipherChaCha20Poly1305}},
+		KeyShare: priv.PublicKey().Bytes(),
+	})
+	first := protocol.NewFrame(protocol.FrameTypeHandshake, hs)
+	require.NoError(t, first.Seal())
+	require.NoError(t, protocol.WriteFrame(client, first))
+
+	reply, err := protocol.ReadFrame(client)
+	require.NoError(t, err)
+	var ack FrameHandshakeAck
+	require.NoError(t, json.Unmarshal(reply.Payload, &ack))
+	require.Equal(t, protocol.CipherChaCha20Poly1305, ack.Codec.Encryption)
+
+	// Derive the client's keys from the hub's share, as a client would
+	hubShare, err := ecdh.X25519().NewPublicKey(ack.KeyShare)
+	require.NoError(t, err)
+	shared, err := priv.ECDH(hubShare)
+	require.NoError(t, err)
+	var sessionID [16]byte
+	b, _ := hex.DecodeString(ack.SessionID)
+	copy(sessionID[:], b)
+	secret, err := frameSessionSecret(shared, sessionID, nil)
+	require.NoError(t, err)
+	c2s, s2c, err := protocol.DeriveFrameKeys(secret, sessionID[:])
+	require.NoError(t, err)
+	codec, err := protocol.NewFrameCodec(ack.Codec, c2s, s2c)
+	require.NoError(t, err)
+
+	heartbeat := protocol.NewFrame(protocol.FrameTypeHeartbeat, []byte("ping"))
+	heartbeat.Header.SessionID = sessionID
+	heartbeat.Header.SequenceNum = 1
+	require.NoError(t, codec.Encode(heartbeat))
+	require.NoError(t, heartbeat.Seal())
+	require.NoError(t, protocol.WriteFrame(client, heartbeat))
+
+	// The hub only answers if it opened the frame with the same keys
+	pong, err := protocol.ReadFrame(client)
+	require.NoError(t, err)
+	assert.True(t, pong.Header.Flags&protocol.FlagEncrypted != 0, "reply must be encrypted")
+	require.NoError(t, codec.Decode(pong))
+	assert.Equal(t, protocol.FrameTypeHeartbeat, pong.Header.FrameType)
+}
+
+func TestFrameServerRefusesEncryptionWithoutKeyShare(t *testing.T) {
+	srv := newPlaintextFrameServer(t)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go srv.handleConn(context.Background(), server)
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	hs, _ := json.Marshal(FrameHandshake{
+		AgentID: "agent-1",
+		Codec:   protocol.CodecOffer{Encryption: []protocol.CipherSuite{protocol.CipherChaCha20Poly1305}},
+	})
+	first := protocol.NewFrame(protocol.FrameTypeHandshake, hs)
+	require.NoError(t, first.Seal())
+	require.NoError(t, protocol.WriteFrame(client, first))
+
+	reply, err := protocol.ReadFrame(client)
+	require.NoError(t, err)
+	var ack FrameHandshakeAck
+	require.NoError(t, json.Unmarshal(reply.Payload, &ack))
+	assert.Equal(t, protocol.CipherNone, ack.Codec.Encryption)
+	assert.Empty(t, ack.KeyShare)
+}
@@ -0,0 +1,388 @@
+// Package fabric — gRPC streaming spoke transport.
+//
+// Go and Python agent runtimes can connect to the Hub over a bidirectional
+// gRPC stream (pb.SpokeTransportService/Connect) instead of the JSON
+// WebSocket endpoint. Both transports register spokes in the same Hub, so
+// routing, cross-replica forwarding and metrics are shared. Callers are
+// authenticated by the SPIFFE SVID presented during the mTLS handshake.
+package fabric
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/ocx/backend/pb"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcAckTimeout      = 30 * time.Second // Unacked deliveries are redelivered after this long
+	grpcMaxRedeliveries = 3                // Then dropped as undeliverable
+	grpcMaxPending      = 4 * sendBuffer   // Deliver refuses new messages beyond this many unacked
+)
+
+// GRPCSpokeServer implements pb.SpokeTransportServiceServer on top of a Hub.
+type GRPCSpokeServer struct {
+	pb.UnimplementedSpokeTransportServiceServer
+
+	hub *Hub
+
+	// requireSVID rejects streams whose peer did not present a SPIFFE SVID.
+	// Only disabled for local development without a SPIRE agent.
+	requireSVID bool
+}
+
+// NewGRPCSpokeServer creates the gRPC spoke transport for a hub.
+func NewGRPCSpokeServer(hub *Hub, requireSVID bool) *GRPCSpokeServer {
+	return &GRPCSpokeServer{hub: hub, requireSVID: requireSVID}
+}
+
+// NewSpokeGRPCServer builds a grpc.Server with the spoke transport registered.
+// tlsConf should come from identity.SPIFFEVerifier.GetServerTLSConfig and
+// makes SVIDs mandatory. Only with a nil tlsConf and allowInsecure does the
+// server accept streams without an SVID (development only); a nil tlsConf
+// without allowInsecure rejects every stream.
+func NewSpokeGRPCServer(hub *Hub, tlsConf *tls.Config, allowInsecure bool) *grpc.Server {
+	var opts []grpc.ServerOption
+	if tlsConf != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+	srv := grpc.NewServer(opts...)
+	pb.RegisterSpokeTransportServiceServer(srv, NewGRPCSpokeServer(hub, tlsConf != nil || !allowInsecure))
+	return srv
+}
+
+// grpcSpoke is the per-stream state of a connected gRPC spoke. It implements
+// SpokeSink; all stream.Send calls happen on the sendLoop goroutine.
+type grpcSpoke struct {
+	spoke *SpokeInfo
+	send  chan *pb.HubFrame
+	done  chan struct{}
+	once  sync.Once
+
+	mu      sync.Mutex
+	pending map[string]*pendingDelivery // delivered message ID → delivery, until acked
+}
+
+// pendingDelivery is a delivery awaiting the spoke's ack.
+type pendingDelivery struct {
+	frame    *pb.HubFrame
+	sentAt   time.Time
+	attempts int // redeliveries so far
+}
+
+// Deliver queues a routed message for the spoke. Implements SpokeSink.
+func (g *grpcSpoke) Deliver(msg *Message) error {
+	id := msg.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	frame := &pb.HubFrame{Frame: &pb.HubFrame_Delivery{Delivery: &pb.Delivery{
+		Id:        id,
+		Type:      msg.Type,
+		Source:    string(msg.Source),
+		Payload:   msg.Payload,
+		Headers:   msg.Headers,
+		Timestamp: msg.Timestamp.UnixMilli(),
+	}}}
+
+	// Record the delivery before queuing it so an ack that races the
+	// send loop finds it; drop it again if the frame is never queued. A
+	// spoke that stops acking gets backpressure rather than an ever
+	// growing pending set.
+	g.mu.Lock()
+	if len(g.pending) >= grpcMaxPending {
+		g.mu.Unlock()
+		return fmt.Errorf("too many unacknowledged deliveries")
+	}
+	g.pending[id] = &pendingDelivery{frame: frame, sentAt: time.Now()}
+	g.mu.Unlock()
+
+	select {
+	case g.send <- frame:
+		return nil
+	case <-g.done:
+		g.ack(id)
+		return fmt.Errorf("spoke stream closed")
+	default:
+		g.ack(id)
+		return fmt.Errorf("send buffer full")
+	}
+}
+
+func (g *grpcSpoke) close() {
+	g.once.Do(func() { close(g.done) })
+}
+
+// ack removes a delivery from the pending set, reporting whether it was known.
+func (g *grpcSpoke) ack(messageID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.pending[messageID]; !ok {
+		return false
+	}
+	delete(g.pending, messageID)
+	return true
+}
+
+// redeliveries returns the deliveries unacked for grpcAckTimeout as of now,
+// marking them sent again. Those already redelivered grpcMaxRedeliveries
+// times are dropped instead.
+func (g *grpcSpoke) redeliveries(now time.Time) []*pb.HubFrame {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var frames []*pb.HubFrame
+	dropped := 0
+	for id, p := range g.pending {
+		if now.Sub(p.sentAt) < grpcAckTimeout {
+			continue
+		}
+		if p.attempts >= grpcMaxRedeliveries {
+			delete(g.pending, id)
+			dropped++
+			continue
+		}
+		p.attempts++
+		p.sentAt = now
+		frames = append(frames, p.frame)
+	}
+	if dropped > 0 {
+		slog.Warn("Dropping unacknowledged gRPC deliveries", "spoke_id", g.spoke.ID,
+			"dropped", dropped, "attempts", grpcMaxRedeliveries+1)
+	}
+	return frames
+}
+
+// Connect serves a single spoke for the lifetime of the stream.
+func (s *GRPCSpokeServer) Connect(stream pb.SpokeTransportService_ConnectServer) error {
+	ctx := stream.Context()
+
+	svidAgent, err := s.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	reg := first.GetRegister()
+	if reg == nil {
+		return status.Error(codes.InvalidArgument, "first frame must be a SpokeRegister")
+	}
+
+	agentID := reg.AgentId
+	if svidAgent != "" {
+		if agentID != "" && agentID != svidAgent {
+			return status.Errorf(codes.PermissionDenied,
+				"agent_id %q does not match SVID agent %q", agentID, svidAgent)
+		}
+		agentID = svidAgent
+	}
+	if agentID == "" {
+		return status.Error(codes.InvalidArgument, "agent_id is required")
+	}
+
+	// The spoke's own trust_score and entitlements are never trusted; the
+	// tenant it asks for is checked against the agent directory.
+	grant, err := s.hub.resolveSpoke(ctx, reg.TenantId, agentID)
+	if err != nil {
+		if errors.Is(err, ErrSpokeNotAuthorized) {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+		return status.Errorf(codes.Unavailable, "resolve spoke: %v", err)
+	}
+	tenantID := grant.TenantID
+
+	caps := make([]Capability, len(reg.Capabilities))
+	for i, c := range reg.Capabilities {
+		caps[i] = Capability(c)
+	}
+
+	spoke, err := s.hub.RegisterSpoke(tenantID, agentID, caps, grant.TrustScore, grant.Entitlements)
+	if err != nil {
+		return status.Errorf(codes.Internal, "register spoke: %v", err)
+	}
+
+	gs := &grpcSpoke{
+		spoke:   spoke,
+		send:    make(chan *pb.HubFrame, sendBuffer),
+		done:    make(chan struct{}),
+		pending: make(map[string]*pendingDelivery),
+	}
+	defer func() {
+		gs.close()
+		s.hub.UnregisterSpoke(spoke.ID)
+		gs.mu.Lock()
+		unacked := len(gs.pending)
+		gs.mu.Unlock()
+		slog.Info("gRPC spoke disconnected", "spoke_id", spoke.ID, "unacked", unacked)
+	}()
+
+	// Queue Registered while nothing else can write to the fresh channel, so
+	// it is the first frame sent and never waits on deliveries; then start
+	// sending before deliveries can arrive.
+	gs.send <- &pb.HubFrame{Frame: &pb.HubFrame_Registered{Registered: &pb.SpokeRegistered{
+		SpokeId:     string(spoke.ID),
+		VirtualAddr: string(spoke.VirtualAddr),
+		HubId:       string(s.hub.ID),
+		ReplicaId:   s.hub.ReplicaID(),
+	}}}
+	sendErr := make(chan error, 1)
+	go func() { sendErr <- gs.sendLoop(stream) }()
+
+	if err := s.hub.AttachSink(spoke.ID, gs); err != nil {
+		return status.Errorf(codes.Internal, "attach spoke: %v", err)
+	}
+
+	slog.Info("gRPC spoke connected", "spoke_id", spoke.ID, "tenant_id", tenantID, "agent_id", agentID)
+
+	recvErr := make(chan error, 1)
+	go func() { recvErr <- s.recvLoop(ctx, stream, gs) }()
+
+	select {
+	case err := <-recvErr:
+		return err
+	case err := <-sendErr:
+		return err
+	}
+}
+
+// sendLoop is the only goroutine that writes to the stream. It also
+// redelivers deliveries the spoke has not acked in time.
+func (g *grpcSpoke) sendLoop(stream pb.SpokeTransportService_ConnectServer) error {
+	ticker := time.NewTicker(grpcAckTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case frame := <-g.send:
+			if err := stream.Send(frame); err != nil {
+				return err
+			}
+		case now := <-ticker.C:
+			for _, frame := range g.redeliveries(now) {
+				if err := stream.Send(frame); err != nil {
+					return err
+				}
+			}
+		case <-g.done:
+			return nil
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// recvLoop reads SpokeSend/SpokeAck frames and routes them through the hub.
+func (s *GRPCSpokeServer) recvLoop(ctx context.Context, stream pb.SpokeTransportService_ConnectServer, gs *grpcSpoke) error {
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		switch f := frame.Frame.(type) {
+		case *pb.SpokeFrame_Send:
+			gs.spoke.Touch(int64(len(f.Send.Payload)))
+			s.route(ctx, gs, f.Send)
+
+		case *pb.SpokeFrame_Ack:
+			if gs.ack(f.Ack.MessageId) {
+				s.hub.metrics.MessagesAcked.Add(1)
+			}
+
+		case *pb.SpokeFrame_Register:
+			return status.Error(codes.FailedPrecondition, "spoke already registered on this stream")
+
+		default:
+			return status.Error(codes.InvalidArgument, "empty spoke frame")
+		}
+	}
+}
+
+func (s *GRPCSpokeServer) route(ctx context.Context, gs *grpcSpoke, send *pb.SpokeSend) {
+	ttl := int(send.Ttl)
+	if ttl <= 0 {
+		ttl = 5
+	}
+
+	msg := &Message{
+		ID:          send.Id,
+		Type:        send.Type,
+		Source:      gs.spoke.VirtualAddr,
+		Destination: VirtualAddress(send.Destination),
+		TenantID:    gs.spoke.TenantID,
+		Payload:     send.Payload,
+		Headers:     send.Headers,
+		Timestamp:   time.Now(),
+		TTL:         ttl,
+	}
+
+	result := &pb.SendResult{Id: send.Id}
+	routed, err := s.hub.Route(ctx, msg)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	} else {
+		result.Status = "routed"
+		result.Hops = int32(routed.HopsUsed)
+		for _, d := range routed.Destinations {
+			result.Destinations = append(result.Destinations, string(d))
+		}
+	}
+
+	select {
+	case gs.send <- &pb.HubFrame{Frame: &pb.HubFrame_SendResult{SendResult: result}}:
+	default:
+		slog.Warn("Send buffer full for gRPC spoke, dropping send result", "spoke_id", gs.spoke.ID)
+	}
+}
+
+// authenticate extracts the agent ID from the caller's SPIFFE SVID.
+// SVIDs follow identity.GenerateSPIFFEID: spiffe://<trust-domain>/agent/<id>.
+// Returns "" without error when no SVID is required and none was presented.
+func (s *GRPCSpokeServer) authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+			agentID, err := svidAgentID(tlsInfo.State.PeerCertificates[0])
+			if err != nil {
+				return "", status.Error(codes.Unauthenticated, err.Error())
+			}
+			return agentID, nil
+		}
+	}
+
+	if s.requireSVID {
+		return "", status.Error(codes.Unauthenticated, "SPIFFE SVID required")
+	}
+	return "", nil
+}
+
+// svidAgentID returns the agent ID encoded in an X.509 SVID's SPIFFE ID.
+func svidAgentID(cert *x509.Certificate) (string, error) {
+	id, err := x509svid.IDFromCert(cert)
+	if err != nil {
+		return "", fmt.Errorf("invalid SVID: %w", err)
+	}
+	agentID := strings.TrimPrefix(id.Path(), "/agent/")
+	if agentID == id.Path() || agentID == "" {
+		return "", fmt.Errorf("SVID %s is not an agent identity", id)
+	}
+	return agentID, nil
+}
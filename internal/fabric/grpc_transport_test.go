@@ -0,0 +1,103 @@
+package fabric
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ocx/backend/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// ============================================================================
+// gRPC SPOKE TRANSPORT TESTS
+// ============================================================================
+
+// connectPlaintext opens a Connect stream to srv over bufconn without TLS
+// and returns the first error the hub reports after a SpokeRegister.
+func connectPlaintext(t *testing.T, srv *grpc.Server) error {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///spoke",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	stream, err := pb.NewSpokeTransportServiceClient(conn).Connect(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.SpokeFrame{Frame: &pb.SpokeFrame_Register{Register: &pb.SpokeRegister{
+		TenantId: "tenant-a", AgentId: "agent-1",
+	}}}))
+	_, err = stream.Recv()
+	return err
+}
+
+func TestSpokeGRPCServerRefusesPlaintextByDefault(t *testing.T) {
+	hub := NewHub("hub-1", "test", "ocx")
+
+	// Without TLS and without the insecure opt-in, a self-asserted agent_id
+	// is never accepted
+	err := connectPlaintext(t, NewSpokeGRPCServer(hub, nil, false))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "got %v", err)
+
+	// The development opt-in registers it
+	err = connectPlaintext(t, NewSpokeGRPCServer(hub, nil, true))
+	assert.NotEqual(t, codes.Unauthenticated, status.Code(err), "got %v", err)
+}
+
+// newTestGRPCSpoke returns a grpcSpoke with no stream attached, so
+// deliveries stay queued.
+func newTestGRPCSpoke() *grpcSpoke {
+	return &grpcSpoke{
+		spoke:   &SpokeInfo{ID: "spoke-1"},
+		send:    make(chan *pb.HubFrame, grpcMaxPending+1),
+		done:    make(chan struct{}),
+		pending: make(map[string]*pendingDelivery),
+	}
+}
+
+func TestGRPCSpokeCapsUnackedDeliveries(t *testing.T) {
+	gs := newTestGRPCSpoke()
+	for i := 0; i < grpcMaxPending; i++ {
+		require.NoError(t, gs.Deliver(&Message{ID: fmt.Sprintf("msg-%d", i)}))
+	}
+	assert.Error(t, gs.Deliver(&Message{ID: "one-too-many"}))
+
+	// An ack makes room again
+	require.True(t, gs.ack("msg-0"))
+	assert.NoError(t, gs.Deliver(&Message{ID: "after-ack"}))
+}
+
+func TestGRPCSpokeRedeliversThenDropsUnacked(t *testing.T) {
+	gs := newTestGRPCSpoke()
+	require.NoError(t, gs.Deliver(&Message{ID: "msg-1"}))
+	require.NoError(t, gs.Deliver(&Message{ID: "msg-2"}))
+	require.True(t, gs.ack("msg-2"))
+
+	now := time.Now()
+	assert.Empty(t, gs.redeliveries(now), "nothing is due before the ack timeout")
+
+	for i := 0; i < grpcMaxRedeliveries; i++ {
+		now = now.Add(grpcAckTimeout)
+		frames := gs.redeliveries(now)
+		require.Len(t, frames, 1)
+		assert.Equal(t, "msg-1", frames[0].GetDelivery().Id)
+	}
+
+	now = now.Add(grpcAckTimeout)
+	assert.Empty(t, gs.redeliveries(now))
+	assert.False(t, gs.ack("msg-1"), "delivery is dropped after its last redelivery")
+}
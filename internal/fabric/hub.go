@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -60,6 +61,7 @@ type SpokeInfo struct {
 	TrustScore   float64
 	Entitlements []string
 	ConnectedAt  time.Time
+	Owner        string       // Replica holding the spoke's live connection
 	LastSeen     atomic.Value // time.Time — P0 FIX: atomic for concurrent access
 	MessageCount atomic.Int64 // P0 FIX: atomic to prevent data races
 	BytesSent    atomic.Int64 // P0 FIX: atomic to prevent data races
@@ -90,19 +92,22 @@ type RoutingEntry struct {
 
 // Hub is the central routing point for AOCS messages.
 //
-// P1 FIX #7 — SCALING LIMITATION:
-// All spoke registrations, routing tables, capability indexes, and tenant indexes
-// are in-memory maps. A second Hub instance on another pod has zero awareness of
-// spokes connected to pod 1. For horizontal scaling, back the Hub routing table
-// with Redis (pub/sub for broadcast, hash for spoke registry) or use sticky
-// sessions with a shared discovery service.
-//
-// TODO(scale): Implement HubStore interface backed by Redis for multi-pod routing.
+// P1 FIX #7 — HORIZONTAL SCALING:
+// The in-memory maps below hold only the spokes connected to this replica.
+// When a RedisHubStore is injected, every registration is also written to
+// Redis tagged with this replica's ID, and Route falls back to the shared
+// store when no local route exists. Messages for remote spokes are forwarded
+// to the owning replica over the RedisEventBus (see remote_routing.go).
+// Ownership expires with the store's spokeTTL unless refreshed by
+// RunStoreHeartbeat, so a crashed replica's spokes stop receiving traffic.
 type Hub struct {
 	ID        HubID
 	Region    string
 	Namespace string
 
+	// replicaID distinguishes API replicas sharing the same logical HubID.
+	replicaID string
+
 	mu sync.RWMutex
 
 	// Spoke registry: SpokeID -> SpokeInfo
@@ -117,6 +122,9 @@ type Hub struct {
 	// Tenant index: TenantID -> []SpokeID
 	tenantIndex map[string][]SpokeID
 
+	// Delivery sinks for spokes with a live transport (WebSocket, gRPC)
+	sinks map[SpokeID]SpokeSink
+
 	// Peer hubs for federation
 	peers map[HubID]*PeerHub
 
@@ -132,6 +140,15 @@ type Hub struct {
 	// Optional Redis-backed event bus for cross-pod event distribution
 	fabricEventBus *RedisEventBus
 
+	// Unsubscribes this replica's forwarded-message channel
+	forwardUnsub func()
+
+	// Optional pre-routing governance check (agent card skills, ...)
+	inspector RouteInspector
+
+	// Optional server-side resolver for spoke tenant, trust and entitlements
+	authority SpokeAuthority
+
 	logger *log.Logger
 }
 
@@ -152,6 +169,8 @@ type PeerHub struct {
 type HubMetrics struct {
 	MessagesRouted    atomic.Int64
 	MessagesFailed    atomic.Int64
+	MessagesForwarded atomic.Int64 // Sent to another replica via the event bus
+	MessagesAcked     atomic.Int64 // Deliveries acknowledged by spokes
 	SpokesConnected   atomic.Int32
 	PeersConnected    atomic.Int32
 	AvgRoutingLatency atomic.Int64 // stored as nanoseconds
@@ -160,16 +179,24 @@ type HubMetrics struct {
 // MessageHandler processes messages for a specific type
 type MessageHandler func(ctx context.Context, msg *Message) (*Message, error)
 
+// SpokeSink pushes routed messages onto a spoke's transport connection.
+// Deliver is called with the hub read lock held and must not block.
+type SpokeSink interface {
+	Deliver(msg *Message) error
+}
+
 // NewHub creates a new AOCS Hub
 func NewHub(id HubID, region, namespace string) *Hub {
 	return &Hub{
 		ID:              id,
 		Region:          region,
 		Namespace:       namespace,
+		replicaID:       newReplicaID(),
 		spokes:          make(map[SpokeID]*SpokeInfo),
 		routes:          make(map[VirtualAddress][]RoutingEntry),
 		capabilityIndex: make(map[Capability][]SpokeID),
 		tenantIndex:     make(map[string][]SpokeID),
+		sinks:           make(map[SpokeID]SpokeSink),
 		peers:           make(map[HubID]*PeerHub),
 		handlers:        make(map[string]MessageHandler),
 		metrics:         &HubMetrics{},
@@ -186,10 +213,32 @@ func (h *Hub) SetStore(s *RedisHubStore) {
 }
 
 // SetFabricEventBus injects a Redis-backed event bus for cross-pod event distribution.
+// The hub subscribes to its replica-specific forwarding channel so that other
+// replicas can hand it messages for spokes connected here.
 func (h *Hub) SetFabricEventBus(bus *RedisEventBus) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	if h.forwardUnsub != nil {
+		h.forwardUnsub()
+		h.forwardUnsub = nil
+	}
 	h.fabricEventBus = bus
+	if bus != nil {
+		h.forwardUnsub = bus.Subscribe(forwardEventType(h.replicaID), h.handleForwardedEvent)
+	}
+}
+
+// SetRouteInspector installs a check that runs on every message before it
+// is routed. Nil removes it.
+func (h *Hub) SetRouteInspector(ri RouteInspector) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inspector = ri
+}
+
+// ReplicaID returns the identifier of this Hub replica.
+func (h *Hub) ReplicaID() string {
+	return h.replicaID
 }
 
 // ============================================================================
@@ -204,7 +253,6 @@ func (h *Hub) RegisterSpoke(
 	entitlements []string,
 ) (*SpokeInfo, error) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	// Generate spoke ID and virtual address
 	spokeID := h.generateSpokeID(tenantID, agentID)
@@ -219,6 +267,7 @@ func (h *Hub) RegisterSpoke(
 		TrustScore:   trustScore,
 		Entitlements: entitlements,
 		ConnectedAt:  time.Now(),
+		Owner:        h.replicaID,
 	}
 	spoke.LastSeen.Store(time.Now())
 
@@ -248,21 +297,36 @@ func (h *Hub) RegisterSpoke(
 	h.logger.Printf("Registered spoke: %s (tenant=%s, agent=%s, addr=%s)",
 		spokeID, tenantID, agentID, virtualAddr)
 
+	store := h.store
+	h.mu.Unlock()
+
+	// Persist outside the lock so a slow Redis doesn't stall local routing.
+	// A failed write only costs cross-replica reachability until the next
+	// heartbeat re-asserts the registration.
+	if store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		if err := store.SaveSpoke(ctx, spoke); err != nil {
+			h.logger.Printf("Failed to persist spoke %s to hub store: %v", spokeID, err)
+		}
+	}
+
 	return spoke, nil
 }
 
 // UnregisterSpoke removes a spoke from the hub
 func (h *Hub) UnregisterSpoke(spokeID SpokeID) error {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	spoke, exists := h.spokes[spokeID]
 	if !exists {
+		h.mu.Unlock()
 		return fmt.Errorf("spoke %s not found", spokeID)
 	}
 
 	// Remove from spoke map
 	delete(h.spokes, spokeID)
+	delete(h.sinks, spokeID)
 
 	// Remove from routing table
 	delete(h.routes, spoke.VirtualAddr)
@@ -279,6 +343,31 @@ func (h *Hub) UnregisterSpoke(spokeID SpokeID) error {
 
 	h.logger.Printf("Unregistered spoke: %s", spokeID)
 
+	store := h.store
+	h.mu.Unlock()
+
+	if store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		if err := store.DeleteSpoke(ctx, spoke); err != nil {
+			h.logger.Printf("Failed to delete spoke %s from hub store: %v", spokeID, err)
+		}
+	}
+
+	return nil
+}
+
+// AttachSink binds a transport connection to a registered spoke so that
+// messages routed to it are actually pushed to the agent. The sink is
+// dropped automatically when the spoke is unregistered.
+func (h *Hub) AttachSink(spokeID SpokeID, sink SpokeSink) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, exists := h.spokes[spokeID]; !exists {
+		return fmt.Errorf("spoke %s not found", spokeID)
+	}
+	h.sinks[spokeID] = sink
 	return nil
 }
 
@@ -309,6 +398,22 @@ type Message struct {
 	Priority    int
 }
 
+// RouteSignal is a governance finding raised about a message before it is
+// routed. Signals are recorded in the message's x-ocx-signals header;
+// a rejecting signal stops delivery.
+type RouteSignal struct {
+	Kind   string
+	Detail string
+	Reject bool
+}
+
+// RouteInspector examines a message before routing. src is the sending
+// spoke when it is connected to this hub, nil otherwise. It runs under the
+// hub's read lock, so it must not block or call back into the Hub.
+type RouteInspector interface {
+	InspectRoute(ctx context.Context, src *SpokeInfo, msg *Message) []RouteSignal
+}
+
 // Route routes a message to its destination
 func (h *Hub) Route(ctx context.Context, msg *Message) (*RouteResult, error) {
 	start := time.Now()
@@ -316,38 +421,126 @@ func (h *Hub) Route(ctx context.Context, msg *Message) (*RouteResult, error) {
 		h.metrics.MessagesRouted.Add(1)
 	}()
 
+	// Local routing runs under the read lock. Store lookups and forwards to
+	// other replicas run after it is released, so Redis latency never holds
+	// up spoke registration or the forwarded-message handler.
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	local := h.routeLocal(ctx, msg, start)
+	links := replicaLinks{store: h.store, bus: h.fabricEventBus}
+	h.mu.RUnlock()
+
+	switch {
+	case local.done:
+		return local.result, local.err
+	case local.cap != "":
+		if local.err != nil && links.store != nil {
+			return h.routeRemoteCapability(ctx, links, msg, local.cap, start)
+		}
+		return local.result, local.err
+	case local.broadcast:
+		if links.store != nil {
+			return h.extendBroadcastRemote(ctx, links, msg, local.result, local.err, start)
+		}
+		return local.result, local.err
+	}
+
+	// Try spokes owned by other replicas of this hub
+	if links.store != nil {
+		if result, err := h.routeRemoteDirect(ctx, links, msg, start); err == nil {
+			return result, nil
+		}
+	}
 
+	// Try federated routing
+	h.mu.RLock()
+	result, err := h.routeFederated(ctx, msg, start)
+	h.mu.RUnlock()
+	if err == nil {
+		return result, nil
+	}
+
+	h.metrics.MessagesFailed.Add(1)
+	return nil, fmt.Errorf("no route to %s", msg.Destination)
+}
+
+// localRoute is what Route decided from this replica's own state, and
+// which cross-replica step, if any, is left to try.
+type localRoute struct {
+	result    *RouteResult
+	err       error
+	done      bool       // final; no remote step applies
+	cap       Capability // capability destination; retry on other replicas if err is set
+	broadcast bool       // tenant broadcast; extend to other replicas
+}
+
+// routeLocal checks the TTL, runs the inspector and routes to spokes
+// connected to this replica. Callers hold h.mu.
+func (h *Hub) routeLocal(ctx context.Context, msg *Message, start time.Time) localRoute {
 	// Check TTL
 	if msg.TTL <= 0 {
 		h.metrics.MessagesFailed.Add(1)
-		return nil, fmt.Errorf("message TTL expired")
+		return localRoute{err: fmt.Errorf("message TTL expired"), done: true}
 	}
 	msg.TTL--
 
+	if h.inspector != nil {
+		if err := h.inspect(ctx, msg); err != nil {
+			h.metrics.MessagesFailed.Add(1)
+			return localRoute{err: err, done: true}
+		}
+	}
+
 	// Try direct routing first
 	if entries, exists := h.routes[msg.Destination]; exists && len(entries) > 0 {
-		return h.routeDirect(ctx, msg, entries, start)
+		result, err := h.routeDirect(ctx, msg, entries, start)
+		return localRoute{result: result, err: err, done: true}
 	}
 
 	// Try capability-based routing (if destination is a capability)
 	if cap, ok := h.parseCapability(msg.Destination); ok {
-		return h.routeByCapability(ctx, msg, cap, start)
+		result, err := h.routeByCapability(ctx, msg, cap, start)
+		return localRoute{result: result, err: err, cap: cap}
 	}
 
-	// Try tenant broadcast
+	// Try tenant broadcast (local spokes plus every replica owning tenant spokes)
 	if h.isTenantBroadcast(msg.Destination) {
-		return h.routeTenantBroadcast(ctx, msg, start)
+		result, err := h.routeTenantBroadcast(ctx, msg, start)
+		return localRoute{result: result, err: err, broadcast: true}
 	}
 
-	// Try federated routing
-	if result, err := h.routeFederated(ctx, msg, start); err == nil {
-		return result, nil
+	return localRoute{}
+}
+
+// inspect runs the route inspector and records its signals on msg.
+// Callers hold h.mu.
+func (h *Hub) inspect(ctx context.Context, msg *Message) error {
+	var src *SpokeInfo
+	if entries := h.routes[msg.Source]; len(entries) > 0 {
+		src = entries[0].Spoke
+	}
+	signals := h.inspector.InspectRoute(ctx, src, msg)
+	if len(signals) == 0 {
+		return nil
 	}
 
-	h.metrics.MessagesFailed.Add(1)
-	return nil, fmt.Errorf("no route to %s", msg.Destination)
+	kinds := make([]string, len(signals))
+	var rejected *RouteSignal
+	for i := range signals {
+		kinds[i] = signals[i].Kind
+		if signals[i].Reject && rejected == nil {
+			rejected = &signals[i]
+		}
+	}
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers["x-ocx-signals"] = strings.Join(kinds, ",")
+	h.logger.Printf("Route signals for %s from %s: %s", msg.ID, msg.Source, msg.Headers["x-ocx-signals"])
+
+	if rejected != nil {
+		return fmt.Errorf("rejected by route inspector: %s: %s", rejected.Kind, rejected.Detail)
+	}
+	return nil
 }
 
 // RouteResult contains the result of a routing decision
@@ -511,6 +704,13 @@ func (h *Hub) deliverToSpoke(_ context.Context, msg *Message, spoke *SpokeInfo)
 	spoke.BytesRecv.Add(int64(len(msg.Payload)))
 	spoke.LastSeen.Store(time.Now())
 
+	if sink := h.sinks[spoke.ID]; sink != nil {
+		if err := sink.Deliver(msg); err != nil {
+			return fmt.Errorf("deliver to spoke %s: %w", spoke.ID, err)
+		}
+		spoke.BytesSent.Add(int64(len(msg.Payload)))
+	}
+
 	h.logger.Printf("Delivered message %s to spoke %s", msg.ID, spoke.ID)
 	return nil
 }
@@ -9,11 +9,17 @@ package fabric
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 )
 
+// ErrKeyNotFound must be wrapped by RedisClient.Get when the key does not
+// exist (or has expired). The store uses it to tell an expired spoke apart
+// from a transient Redis failure before pruning index entries.
+var ErrKeyNotFound = errors.New("redis key not found")
+
 // RedisClient is a minimal interface that any Redis library (go-redis, redigo)
 // can satisfy. The Hub doesn't import a specific driver — code in cmd/api/main
 // creates the concrete client and injects it.
@@ -60,6 +66,7 @@ type spokeJSON struct {
 	TrustScore   float64  `json:"trust_score"`
 	Entitlements []string `json:"entitlements"`
 	ConnectedAt  string   `json:"connected_at"`
+	Owner        string   `json:"owner"`
 }
 
 func spokeToJSON(s *SpokeInfo) *spokeJSON {
@@ -76,11 +83,33 @@ func spokeToJSON(s *SpokeInfo) *spokeJSON {
 		TrustScore:   s.TrustScore,
 		Entitlements: s.Entitlements,
 		ConnectedAt:  s.ConnectedAt.Format(time.RFC3339),
+		Owner:        s.Owner,
 	}
 }
 
+// SpokeTTL returns how long a spoke registration lives in Redis without a
+// refresh. Owning replicas must call RefreshSpoke well within this window.
+func (rs *RedisHubStore) SpokeTTL() time.Duration {
+	return rs.spokeTTL
+}
+
 // SaveSpoke persists a spoke registration to Redis.
 func (rs *RedisHubStore) SaveSpoke(ctx context.Context, spoke *SpokeInfo) error {
+	if err := rs.writeSpoke(ctx, spoke); err != nil {
+		return err
+	}
+	slog.Info("[RedisHubStore] Saved spoke", "spoke_id", spoke.ID, "owner", spoke.Owner)
+	return nil
+}
+
+// RefreshSpoke extends the TTL of a live spoke and re-asserts its index
+// entries. Called from the owning replica's heartbeat; if the owner dies the
+// spoke key expires and other replicas stop routing to it.
+func (rs *RedisHubStore) RefreshSpoke(ctx context.Context, spoke *SpokeInfo) error {
+	return rs.writeSpoke(ctx, spoke)
+}
+
+func (rs *RedisHubStore) writeSpoke(ctx context.Context, spoke *SpokeInfo) error {
 	data, err := json.Marshal(spokeToJSON(spoke))
 	if err != nil {
 		return fmt.Errorf("marshal spoke: %w", err)
@@ -112,7 +141,6 @@ func (rs *RedisHubStore) SaveSpoke(ctx context.Context, spoke *SpokeInfo) error
 		slog.Warn("[RedisHubStore] Failed to index tenant", "tenant", spoke.TenantID, "error", err)
 	}
 
-	slog.Info("[RedisHubStore] Saved spoke", "spoke_id", spoke.ID)
 	return nil
 }
 
@@ -121,7 +149,7 @@ func (rs *RedisHubStore) LoadSpoke(ctx context.Context, spokeID SpokeID) (*Spoke
 	spokeKey := rs.keyPrefix + "spoke:" + string(spokeID)
 	data, err := rs.client.Get(ctx, spokeKey)
 	if err != nil {
-		return nil, fmt.Errorf("redis GET spoke: %w", err)
+		return nil, fmt.Errorf("redis GET spoke %s: %w", spokeID, err)
 	}
 
 	var sj spokeJSON
@@ -144,6 +172,7 @@ func (rs *RedisHubStore) LoadSpoke(ctx context.Context, spokeID SpokeID) (*Spoke
 		TrustScore:   sj.TrustScore,
 		Entitlements: sj.Entitlements,
 		ConnectedAt:  connectedAt,
+		Owner:        sj.Owner,
 	}
 	spoke.LastSeen.Store(time.Now())
 	return spoke, nil
@@ -194,3 +223,44 @@ func (rs *RedisHubStore) GetSpokesByTenant(ctx context.Context, tenantID string)
 	}
 	return ids, nil
 }
+
+// LoadSpokesByRoute returns the live spokes registered under a virtual address.
+func (rs *RedisHubStore) LoadSpokesByRoute(ctx context.Context, addr VirtualAddress) ([]*SpokeInfo, error) {
+	return rs.loadIndex(ctx, rs.keyPrefix+"route:"+string(addr))
+}
+
+// LoadSpokesByCapability returns the live spokes advertising a capability.
+func (rs *RedisHubStore) LoadSpokesByCapability(ctx context.Context, cap Capability) ([]*SpokeInfo, error) {
+	return rs.loadIndex(ctx, rs.keyPrefix+"cap:"+string(cap))
+}
+
+// LoadSpokesByTenant returns the live spokes belonging to a tenant.
+func (rs *RedisHubStore) LoadSpokesByTenant(ctx context.Context, tenantID string) ([]*SpokeInfo, error) {
+	return rs.loadIndex(ctx, rs.keyPrefix+"tenant:"+tenantID)
+}
+
+// loadIndex resolves every member of an index set to its spoke record.
+// Members whose spoke key has expired are pruned from the set so that stale
+// ownership left behind by a crashed replica does not accumulate.
+func (rs *RedisHubStore) loadIndex(ctx context.Context, indexKey string) ([]*SpokeInfo, error) {
+	members, err := rs.client.SMembers(ctx, indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("redis SMEMBERS %s: %w", indexKey, err)
+	}
+
+	spokes := make([]*SpokeInfo, 0, len(members))
+	for _, m := range members {
+		spoke, err := rs.LoadSpoke(ctx, SpokeID(m))
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				_ = rs.client.SRem(ctx, indexKey, m)
+				slog.Info("[RedisHubStore] Pruned expired spoke from index", "spoke_id", m, "index", indexKey)
+				continue
+			}
+			slog.Warn("[RedisHubStore] Failed to load spoke", "spoke_id", m, "error", err)
+			continue
+		}
+		spokes = append(spokes, spoke)
+	}
+	return spokes, nil
+}
@@ -0,0 +1,284 @@
+// Package fabric — Cross-replica routing for horizontally scaled Hubs.
+//
+// Behind a load balancer each API replica runs its own Hub and only holds the
+// WebSocket connections of the spokes that landed on it. RedisHubStore records
+// which replica owns each spoke; when Route finds no local match it consults
+// the store and forwards the message to the owning replica over a
+// replica-specific RedisEventBus channel. The owner delivers it locally.
+package fabric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventMessageForward is the base event type for cross-replica message
+// forwarding. Each replica subscribes to "<EventMessageForward>.<replicaID>".
+const EventMessageForward EventType = "fabric.message.forward"
+
+// storeTimeout bounds every Hub → RedisHubStore call so a slow Redis
+// degrades cross-replica routing without stalling local delivery.
+const storeTimeout = 2 * time.Second
+
+// forwardEnvelope is the payload carried in an EventMessageForward event.
+type forwardEnvelope struct {
+	TargetSpoke   SpokeID  `json:"target_spoke,omitempty"` // empty for tenant broadcasts
+	OriginReplica string   `json:"origin_replica"`
+	Message       *Message `json:"message"`
+}
+
+// newReplicaID returns OCX_REPLICA_ID if set, otherwise hostname plus a random
+// suffix so that restarted pods never inherit a dead replica's ownership.
+func newReplicaID() string {
+	if id := os.Getenv("OCX_REPLICA_ID"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "hub"
+	}
+	return host + "-" + uuid.New().String()[:8]
+}
+
+func forwardEventType(replicaID string) EventType {
+	return EventType(string(EventMessageForward) + "." + replicaID)
+}
+
+// replicaLinks is the cross-replica state Route reads under h.mu; the
+// remote routing steps use it after the lock is released.
+type replicaLinks struct {
+	store *RedisHubStore
+	bus   *RedisEventBus
+}
+
+// routeRemoteDirect resolves a virtual address through the shared store and
+// forwards to the first live spoke owned by another replica.
+func (h *Hub) routeRemoteDirect(ctx context.Context, links replicaLinks, msg *Message, start time.Time) (*RouteResult, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	spokes, err := links.store.LoadSpokesByRoute(lookupCtx, msg.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spoke := range spokes {
+		if spoke.Owner == "" || spoke.Owner == h.replicaID {
+			continue
+		}
+		if err := h.forwardToReplica(ctx, links.bus, spoke.Owner, spoke.ID, msg); err != nil {
+			h.logger.Printf("Forward to replica %s failed: %v", spoke.Owner, err)
+			continue
+		}
+		return &RouteResult{
+			Decision:     RouteForward,
+			Destinations: []VirtualAddress{spoke.VirtualAddr},
+			RoutingTime:  time.Since(start),
+			HopsUsed:     2,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no remote route to %s", msg.Destination)
+}
+
+// routeRemoteCapability picks the highest-trust remote spoke with the
+// capability (honouring the tenant filter) and forwards to its owner.
+func (h *Hub) routeRemoteCapability(
+	ctx context.Context,
+	links replicaLinks,
+	msg *Message,
+	cap Capability,
+	start time.Time,
+) (*RouteResult, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	spokes, err := links.store.LoadSpokesByCapability(lookupCtx, cap)
+	if err != nil {
+		h.metrics.MessagesFailed.Add(1)
+		return nil, fmt.Errorf("no spokes with capability %s: %w", cap, err)
+	}
+
+	var best *SpokeInfo
+	for _, spoke := range spokes {
+		if spoke.Owner == "" || spoke.Owner == h.replicaID {
+			continue
+		}
+		if msg.TenantID != "" && spoke.TenantID != msg.TenantID {
+			continue
+		}
+		if best == nil || spoke.TrustScore > best.TrustScore {
+			best = spoke
+		}
+	}
+
+	if best == nil {
+		h.metrics.MessagesFailed.Add(1)
+		return nil, fmt.Errorf("no matching spokes for capability %s in tenant %s", cap, msg.TenantID)
+	}
+
+	if err := h.forwardToReplica(ctx, links.bus, best.Owner, best.ID, msg); err != nil {
+		h.metrics.MessagesFailed.Add(1)
+		return nil, err
+	}
+
+	return &RouteResult{
+		Decision:     RouteForward,
+		Destinations: []VirtualAddress{best.VirtualAddr},
+		RoutingTime:  time.Since(start),
+		HopsUsed:     2,
+	}, nil
+}
+
+// extendBroadcastRemote forwards a tenant broadcast once to every other
+// replica owning spokes in the tenant and merges their addresses into the
+// local result. The local error is returned only when nothing was reached.
+func (h *Hub) extendBroadcastRemote(
+	ctx context.Context,
+	links replicaLinks,
+	msg *Message,
+	local *RouteResult,
+	localErr error,
+	start time.Time,
+) (*RouteResult, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	spokes, err := links.store.LoadSpokesByTenant(lookupCtx, msg.TenantID)
+	if err != nil {
+		h.logger.Printf("Remote tenant lookup failed for %s: %v", msg.TenantID, err)
+		return local, localErr
+	}
+
+	byOwner := make(map[string][]VirtualAddress)
+	for _, spoke := range spokes {
+		if spoke.Owner == "" || spoke.Owner == h.replicaID {
+			continue
+		}
+		byOwner[spoke.Owner] = append(byOwner[spoke.Owner], spoke.VirtualAddr)
+	}
+
+	var remote []VirtualAddress
+	for owner, addrs := range byOwner {
+		if err := h.forwardToReplica(ctx, links.bus, owner, "", msg); err != nil {
+			h.logger.Printf("Broadcast forward to replica %s failed: %v", owner, err)
+			continue
+		}
+		remote = append(remote, addrs...)
+	}
+
+	if len(remote) == 0 {
+		return local, localErr
+	}
+
+	result := &RouteResult{Decision: RouteBroadcast, HopsUsed: 2}
+	if local != nil {
+		result.Destinations = append(result.Destinations, local.Destinations...)
+	}
+	result.Destinations = append(result.Destinations, remote...)
+	result.RoutingTime = time.Since(start)
+	return result, nil
+}
+
+// forwardToReplica publishes the message on the owning replica's channel.
+func (h *Hub) forwardToReplica(ctx context.Context, bus *RedisEventBus, owner string, target SpokeID, msg *Message) error {
+	if bus == nil {
+		return fmt.Errorf("no fabric event bus configured for cross-replica forwarding")
+	}
+
+	data, err := json.Marshal(&forwardEnvelope{
+		TargetSpoke:   target,
+		OriginReplica: h.replicaID,
+		Message:       msg,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal forward envelope: %w", err)
+	}
+
+	if err := bus.Publish(ctx, &Event{
+		Type:     forwardEventType(owner),
+		Source:   h.replicaID,
+		TenantID: msg.TenantID,
+		Payload:  map[string]interface{}{"envelope": string(data)},
+	}); err != nil {
+		return err
+	}
+
+	h.metrics.MessagesForwarded.Add(1)
+	h.logger.Printf("Forwarded message %s to replica %s", msg.ID, owner)
+	return nil
+}
+
+// handleForwardedEvent delivers a message forwarded by another replica to the
+// locally connected spoke(s). It never re-forwards, so a spoke that moved or
+// disconnected in the meantime results in a dropped message, not a loop.
+func (h *Hub) handleForwardedEvent(ctx context.Context, event *Event) error {
+	raw, ok := event.Payload["envelope"].(string)
+	if !ok {
+		return fmt.Errorf("forward event %s missing envelope", event.ID)
+	}
+
+	var env forwardEnvelope
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		return fmt.Errorf("unmarshal forward envelope: %w", err)
+	}
+	if env.Message == nil {
+		return fmt.Errorf("forward event %s has no message", event.ID)
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if env.TargetSpoke == "" {
+		for _, id := range h.tenantIndex[env.Message.TenantID] {
+			if spoke := h.spokes[id]; spoke != nil {
+				_ = h.deliverToSpoke(ctx, env.Message, spoke)
+			}
+		}
+		return nil
+	}
+
+	spoke, exists := h.spokes[env.TargetSpoke]
+	if !exists {
+		h.metrics.MessagesFailed.Add(1)
+		return fmt.Errorf("forwarded spoke %s no longer connected to replica %s", env.TargetSpoke, h.replicaID)
+	}
+	return h.deliverToSpoke(ctx, env.Message, spoke)
+}
+
+// RunStoreHeartbeat refreshes this replica's spoke registrations in the hub
+// store every third of the spoke TTL until ctx is cancelled. Returns
+// immediately when no store is configured.
+func (h *Hub) RunStoreHeartbeat(ctx context.Context) {
+	h.mu.RLock()
+	store := h.store
+	h.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	ticker := time.NewTicker(store.SpokeTTL() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			spokes := h.GetSpokes()
+			refreshCtx, cancel := context.WithTimeout(ctx, storeTimeout)
+			for _, spoke := range spokes {
+				if err := store.RefreshSpoke(refreshCtx, spoke); err != nil {
+					slog.Warn("[Hub] Spoke heartbeat failed", "spoke_id", spoke.ID, "error", err)
+				}
+			}
+			cancel()
+		}
+	}
+}
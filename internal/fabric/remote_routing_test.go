@@ -0,0 +1,238 @@
+package fabric
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// CROSS-REPLICA ROUTING TESTS
+// ============================================================================
+
+// memRedis is an in-process RedisClient and RedisPubSubClient shared by the
+// replicas of a test. While block is set, SMembers waits on it, which holds
+// a Route in its store lookup.
+type memRedis struct {
+	mu    sync.Mutex
+	kv    map[string][]byte
+	sets  map[string]map[string]bool
+	subs  map[string][]func([]byte)
+	block chan struct{}
+	inIO  chan struct{}
+}
+
+func newMemRedis() *memRedis {
+	return &memRedis{
+		kv:   make(map[string][]byte),
+		sets: make(map[string]map[string]bool),
+		subs: make(map[string][]func([]byte)),
+	}
+}
+
+func (m *memRedis) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kv[key] = value
+	return nil
+}
+
+func (m *memRedis) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.kv[key]
+	if !ok {
+		return nil, fmt.Errorf("get %s: %w", key, ErrKeyNotFound)
+	}
+	return v, nil
+}
+
+func (m *memRedis) Del(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		delete(m.kv, k)
+	}
+	return nil
+}
+
+func (m *memRedis) SAdd(_ context.Context, key string, members ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sets[key] == nil {
+		m.sets[key] = make(map[string]bool)
+	}
+	for _, v := range members {
+		m.sets[key][v] = true
+	}
+	return nil
+}
+
+func (m *memRedis) SRem(_ context.Context, key string, members ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range members {
+		delete(m.sets[key], v)
+	}
+	return nil
+}
+
+func (m *memRedis) SMembers(_ context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	block, inIO := m.block, m.inIO
+	m.mu.Unlock()
+	if block != nil {
+		inIO <- struct{}{}
+		<-block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for v := range m.sets[key] {
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (m *memRedis) Publish(_ context.Context, channel string, message []byte) error {
+	m.mu.Lock()
+	handlers := append([]func([]byte){}, m.subs[channel]...)
+	m.mu.Unlock()
+	for _, h := range handlers {
+		h(message)
+	}
+	return nil
+}
+
+func (m *memRedis) Subscribe(_ context.Context, channel string, handler func([]byte)) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[channel] = append(m.subs[channel], handler)
+	return func() {}, nil
+}
+
+// chanSink collects deliveries to one spoke.
+type chanSink chan *Message
+
+func (s chanSink) Deliver(msg *Message) error {
+	select {
+	case s <- msg:
+		return nil
+	default:
+		return fmt.Errorf("sink full")
+	}
+}
+
+func (s chanSink) wait(t *testing.T) *Message {
+	t.Helper()
+	select {
+	case msg := <-s:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no delivery")
+		return nil
+	}
+}
+
+// newReplica returns a Hub sharing redis with every other replica.
+func newReplica(t *testing.T, redis *memRedis) *Hub {
+	h := NewHub("hub-1", "test", "ocx")
+	h.SetStore(NewRedisHubStore(redis, "", time.Minute))
+	bus := NewRedisEventBus(redis, "")
+	h.SetFabricEventBus(bus)
+	t.Cleanup(func() { bus.Close() })
+	return h
+}
+
+func connectSpoke(t *testing.T, h *Hub, tenantID, agentID string, caps ...Capability) (*SpokeInfo, chanSink) {
+	spoke, err := h.RegisterSpoke(tenantID, agentID, caps, 0.8, nil)
+	require.NoError(t, err)
+	sink := make(chanSink, 8)
+	require.NoError(t, h.AttachSink(spoke.ID, sink))
+	return spoke, sink
+}
+
+func TestRouteForwardsToOtherReplicas(t *testing.T) {
+	ctx := context.Background()
+	redis := newMemRedis()
+	a, b := newReplica(t, redis), newReplica(t, redis)
+	require.NotEqual(t, a.ReplicaID(), b.ReplicaID())
+
+	local, localSink := connectSpoke(t, a, "tenant-1", "planner")
+	remote, remoteSink := connectSpoke(t, b, "tenant-1", "fx", "fx-rates")
+	_, otherTenantSink := connectSpoke(t, b, "tenant-2", "fx", "fx-rates")
+
+	// Direct address owned by the other replica
+	result, err := a.Route(ctx, &Message{ID: "m-1", Source: local.VirtualAddr, Destination: remote.VirtualAddr, TenantID: "tenant-1", TTL: 5})
+	require.NoError(t, err)
+	assert.Equal(t, RouteForward, result.Decision)
+	assert.Equal(t, []VirtualAddress{remote.VirtualAddr}, result.Destinations)
+	assert.Equal(t, "m-1", remoteSink.wait(t).ID)
+
+	// Capability only the other replica serves, filtered by tenant
+	result, err = a.Route(ctx, &Message{ID: "m-2", Source: local.VirtualAddr, Destination: "cap://fx-rates", TenantID: "tenant-1", TTL: 5})
+	require.NoError(t, err)
+	assert.Equal(t, RouteForward, result.Decision)
+	assert.Equal(t, "m-2", remoteSink.wait(t).ID)
+
+	// Tenant broadcast reaches spokes on both replicas, not other tenants
+	result, err = a.Route(ctx, &Message{ID: "m-3", Source: local.VirtualAddr, Destination: "broadcast://tenant-1", TenantID: "tenant-1", TTL: 5})
+	require.NoError(t, err)
+	assert.Equal(t, RouteBroadcast, result.Decision)
+	assert.ElementsMatch(t, []VirtualAddress{local.VirtualAddr, remote.VirtualAddr}, result.Destinations)
+	assert.Equal(t, "m-3", localSink.wait(t).ID)
+	assert.Equal(t, "m-3", remoteSink.wait(t).ID)
+	assert.Empty(t, otherTenantSink)
+
+	// Nothing anywhere
+	_, err = a.Route(ctx, &Message{ID: "m-4", Destination: "ocx://hub-1/tenant-1/nobody", TenantID: "tenant-1", TTL: 5})
+	assert.Error(t, err)
+}
+
+func TestRouteReleasesLockDuringStoreLookup(t *testing.T) {
+	redis := newMemRedis()
+	a, b := newReplica(t, redis), newReplica(t, redis)
+	remote, remoteSink := connectSpoke(t, b, "tenant-1", "fx")
+
+	redis.mu.Lock()
+	redis.block, redis.inIO = make(chan struct{}), make(chan struct{}, 1)
+	block := redis.block
+	redis.mu.Unlock()
+
+	routed := make(chan error, 1)
+	go func() {
+		_, err := a.Route(context.Background(), &Message{ID: "m-1", Destination: remote.VirtualAddr, TenantID: "tenant-1", TTL: 5})
+		routed <- err
+	}()
+	select {
+	case <-redis.inIO:
+	case <-time.After(2 * time.Second):
+		t.Fatal("route never reached the store")
+	}
+
+	// Registration needs the write lock; it must not wait for Redis
+	registered := make(chan error, 1)
+	go func() {
+		_, err := a.RegisterSpoke("tenant-1", "late", nil, 0.8, nil)
+		registered <- err
+	}()
+	select {
+	case err := <-registered:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RegisterSpoke blocked behind a store lookup in Route")
+	}
+
+	redis.mu.Lock()
+	redis.block = nil
+	redis.mu.Unlock()
+	close(block)
+	require.NoError(t, <-routed)
+	assert.Equal(t, "m-1", remoteSink.wait(t).ID)
+}
@@ -0,0 +1,120 @@
+// Package fabric — server-side spoke attributes.
+//
+// A spoke authenticates as an agent (SPIFFE SVID on gRPC and TLS frame
+// connections) but everything else it says about itself is a claim. The
+// tenant, trust score and entitlements the Hub routes and governs with are
+// resolved here from the agent directory, the reputation wallet and the JIT
+// entitlement manager; the values a spoke sends at registration are ignored.
+package fabric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ocx/backend/internal/database"
+	"github.com/ocx/backend/internal/escrow"
+)
+
+// defaultSpokeTrust is the trust given to spokes when no SpokeAuthority is
+// configured, matching the WebSocket transport.
+const defaultSpokeTrust = 0.5
+
+// ErrSpokeNotAuthorized is returned when an agent may not register in the
+// tenant it asked for.
+var ErrSpokeNotAuthorized = errors.New("agent is not authorized for tenant")
+
+// SpokeGrant holds the server-resolved attributes of a registering spoke.
+type SpokeGrant struct {
+	TenantID     string
+	TrustScore   float64
+	Entitlements []string
+}
+
+// SpokeAuthority resolves what an authenticated agent is allowed to be when
+// it registers as a spoke. requestedTenant is the tenant the spoke asked
+// for ("default" when it sent none).
+type SpokeAuthority interface {
+	ResolveSpoke(ctx context.Context, requestedTenant, agentID string) (*SpokeGrant, error)
+}
+
+// AgentDirectory looks up registered agents (database.SupabaseClient).
+type AgentDirectory interface {
+	GetAgent(ctx context.Context, tenantID, agentID string) (*database.Agent, error)
+}
+
+// TrustSource returns an agent's current trust score (reputation.ReputationWallet).
+type TrustSource interface {
+	GetTrustScore(ctx context.Context, agentID, tenantID string) (float64, error)
+}
+
+// EntitlementSource lists an agent's live JIT grants (escrow.JITEntitlementManager).
+type EntitlementSource interface {
+	GetActiveEntitlements(agentID string) []*escrow.Entitlement
+}
+
+// RegistrySpokeAuthority admits an agent only into a tenant it is registered
+// in, and takes its trust from the wallet and its entitlements from the
+// active JIT grants. Entitlements may be nil.
+type RegistrySpokeAuthority struct {
+	agents       AgentDirectory
+	trust        TrustSource
+	entitlements EntitlementSource
+}
+
+// NewRegistrySpokeAuthority creates a SpokeAuthority backed by the agent
+// directory and reputation wallet.
+func NewRegistrySpokeAuthority(agents AgentDirectory, trust TrustSource, entitlements EntitlementSource) *RegistrySpokeAuthority {
+	return &RegistrySpokeAuthority{agents: agents, trust: trust, entitlements: entitlements}
+}
+
+// ResolveSpoke implements SpokeAuthority.
+func (a *RegistrySpokeAuthority) ResolveSpoke(ctx context.Context, requestedTenant, agentID string) (*SpokeGrant, error) {
+	agent, err := a.agents.GetAgent(ctx, requestedTenant, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("look up agent %s: %w", agentID, err)
+	}
+	if agent == nil {
+		return nil, fmt.Errorf("%w: %s is not registered in %s", ErrSpokeNotAuthorized, agentID, requestedTenant)
+	}
+	if agent.IsFrozen || agent.Blacklisted {
+		return nil, fmt.Errorf("%w: %s is frozen or blacklisted in %s", ErrSpokeNotAuthorized, agentID, requestedTenant)
+	}
+
+	score, err := a.trust.GetTrustScore(ctx, agentID, agent.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("trust score for %s: %w", agentID, err)
+	}
+
+	grant := &SpokeGrant{TenantID: agent.TenantID, TrustScore: score}
+	if a.entitlements != nil {
+		for _, e := range a.entitlements.GetActiveEntitlements(agentID) {
+			grant.Entitlements = append(grant.Entitlements, e.Permission)
+		}
+	}
+	return grant, nil
+}
+
+// SetSpokeAuthority installs the resolver used by the gRPC and frame
+// transports. Without one, spokes join the tenant they ask for with
+// defaultSpokeTrust and no entitlements (development only).
+func (h *Hub) SetSpokeAuthority(a SpokeAuthority) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authority = a
+}
+
+// resolveSpoke returns the attributes agentID registers with.
+func (h *Hub) resolveSpoke(ctx context.Context, requestedTenant, agentID string) (*SpokeGrant, error) {
+	if requestedTenant == "" {
+		requestedTenant = "default"
+	}
+	h.mu.RLock()
+	authority := h.authority
+	h.mu.RUnlock()
+
+	if authority == nil {
+		return &SpokeGrant{TenantID: requestedTenant, TrustScore: defaultSpokeTrust}, nil
+	}
+	return authority.ResolveSpoke(ctx, requestedTenant, agentID)
+}
@@ -4,6 +4,7 @@ package fabric
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -108,6 +109,9 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		Send:  make(chan []byte, sendBuffer),
 		done:  make(chan struct{}),
 	}
+	if err := h.AttachSink(spoke.ID, ws); err != nil {
+		slog.Warn("Failed to attach WebSocket sink", "spoke_id", spoke.ID, "error", err)
+	}
 
 	slog.Info("WebSocket spoke connected: (tenant=)", "i_d", spoke.ID, "tenant_i_d", tenantID)
 	// P0 FIX: Two goroutines with clear ownership:
@@ -250,6 +254,28 @@ func (ws *WebSocketSpoke) readPump() {
 	}
 }
 
+// Deliver queues a message routed to this spoke onto the write pump.
+// Implements SpokeSink; never blocks.
+func (ws *WebSocketSpoke) Deliver(msg *Message) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":      msg.ID,
+		"type":    msg.Type,
+		"source":  msg.Source,
+		"payload": msg.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	select {
+	case ws.Send <- data:
+		return nil
+	case <-ws.done:
+		return fmt.Errorf("spoke connection closed")
+	default:
+		return fmt.Errorf("send buffer full")
+	}
+}
+
 // WSMessage represents a WebSocket message from a spoke
 type WSMessage struct {
 	ID          string `json:"id"`
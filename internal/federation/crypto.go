@@ -80,12 +80,23 @@ func GenerateProof(challenge []byte, privateKey *ecdsa.PrivateKey) ([]byte, erro
 }
 
 // VerifyProof verifies an ECDSA signature against a challenge
-// Returns true if the signature is valid
-func VerifyProof(proof, challenge []byte, publicKey *ecdsa.PublicKey) (bool, error) {
+// Returns true if the signature is valid. A key revoked in revocations
+// fails verification with an ErrRevoked error; revocations may be nil.
+func VerifyProof(proof, challenge []byte, publicKey *ecdsa.PublicKey, revocations RevocationChecker) (bool, error) {
 	if publicKey == nil {
 		return false, errors.New("public key cannot be nil")
 	}
 
+	if revocations != nil {
+		fp, err := PublicKeyFingerprint(publicKey)
+		if err != nil {
+			return false, err
+		}
+		if e := revocations.Revoked(RevokeKindKey, fp); e != nil {
+			return false, fmt.Errorf("proof key of %s %w: %s", e.InstanceID, ErrRevoked, e.Reason)
+		}
+	}
+
 	// Hash the challenge
 	hash := sha256.Sum256(challenge)
 
@@ -142,8 +153,11 @@ func EncodePublicKeyPEM(publicKey *ecdsa.PublicKey) (string, error) {
 	return string(pem.EncodeToMemory(pemBlock)), nil
 }
 
-// VerifyCertificateChain verifies a certificate chain against a root CA
-func VerifyCertificateChain(certChain []string, rootCA *x509.Certificate) error {
+// VerifyCertificateChain verifies a certificate chain against a root CA.
+// Any certificate in the chain, or key it certifies, that is revoked in
+// revocations fails verification with an ErrRevoked error; revocations
+// may be nil.
+func VerifyCertificateChain(certChain []string, rootCA *x509.Certificate, revocations RevocationChecker) error {
 	if len(certChain) == 0 {
 		return errors.New("certificate chain is empty")
 	}
@@ -151,14 +165,12 @@ func VerifyCertificateChain(certChain []string, rootCA *x509.Certificate) error
 	// Parse all certificates in the chain
 	certs := make([]*x509.Certificate, 0, len(certChain))
 	for i, certPEM := range certChain {
-		block, _ := pem.Decode([]byte(certPEM))
-		if block == nil {
-			return fmt.Errorf("failed to decode certificate %d", i)
-		}
-
-		cert, err := x509.ParseCertificate(block.Bytes)
+		cert, err := parseChainCertificate(certPEM)
 		if err != nil {
-			return fmt.Errorf("failed to parse certificate %d: %w", i, err)
+			return fmt.Errorf("certificate %d: %w", i, err)
+		}
+		if err := checkCertificateRevoked(revocations, cert); err != nil {
+			return fmt.Errorf("certificate %d: %w", i, err)
 		}
 
 		certs = append(certs, cert)
@@ -188,6 +200,20 @@ func VerifyCertificateChain(certChain []string, rootCA *x509.Certificate) error
 	return nil
 }
 
+// parseChainCertificate parses a PEM certificate, or raw DER as carried in
+// HandshakeProof.CertificateChain.
+func parseChainCertificate(data string) (*x509.Certificate, error) {
+	der := []byte(data)
+	if block, _ := pem.Decode(der); block != nil {
+		der = block.Bytes
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
 // IsNonceFresh checks if a nonce was created within the acceptable time window
 // This prevents replay attacks using old nonces
 func IsNonceFresh(nonceTimestamp int64, maxAge time.Duration) bool {
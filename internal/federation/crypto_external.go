@@ -0,0 +1,323 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// EXTERNAL KEY PROVIDER — signing delegated to a key service
+//
+// ExternalKeyProvider is a CryptoProvider whose private key lives in an
+// external key service (a cloud KMS, an HSM behind PKCS#11, or the
+// SoftwareKeyService stand-in), so it never enters the API process. The
+// provider pins one key version; rotating the key in the service and
+// letting Refresh pick it up replaces the signing key without a redeploy.
+// Verification only needs public keys and stays local.
+// ============================================================================
+
+// ErrKeyNotFound is returned by a KeyService for an unknown or disabled key
+// version.
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// ErrExternalSignatureInvalid is returned when a signature from the key
+// service does not verify against the pinned public key, e.g. after a
+// misconfigured key ID or a rotation the provider has not picked up yet.
+var ErrExternalSignatureInvalid = errors.New("external signature does not verify against pinned key")
+
+const defaultKeyServiceTimeout = 5 * time.Second
+
+// KeyVersion is one version of a key held by a KeyService.
+type KeyVersion struct {
+	KeyID     string          `json:"key_id"`
+	Version   string          `json:"version"`
+	Algorithm CryptoAlgorithm `json:"algorithm"`
+	PublicKey []byte          `json:"public_key"` // wire format, as returned by PublicKeyBytes
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// KeyService is the surface an external signer has to offer. A PKCS#11
+// module or a cloud KMS is plugged in by implementing these two calls.
+type KeyService interface {
+	// CurrentVersion returns the version new signatures should use.
+	CurrentVersion(ctx context.Context, keyID string) (*KeyVersion, error)
+
+	// Sign signs data with the given version of keyID.
+	Sign(ctx context.Context, keyID, version string, data []byte) ([]byte, error)
+}
+
+// ExternalKeyProvider implements CryptoProvider on top of a KeyService.
+type ExternalKeyProvider struct {
+	service KeyService
+	keyID   string
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	current  *KeyVersion
+	onRotate []func(old, current KeyVersion)
+}
+
+// NewExternalKeyProvider loads the current version of keyID. timeout bounds
+// every call to the service (default 5s).
+func NewExternalKeyProvider(ctx context.Context, service KeyService, keyID string, timeout time.Duration) (*ExternalKeyProvider, error) {
+	if timeout <= 0 {
+		timeout = defaultKeyServiceTimeout
+	}
+	p := &ExternalKeyProvider{service: service, keyID: keyID, timeout: timeout}
+	if _, err := p.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *ExternalKeyProvider) Algorithm() CryptoAlgorithm {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current.Algorithm
+}
+
+func (p *ExternalKeyProvider) PublicKeyBytes() []byte {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current.PublicKey
+}
+
+// Sign asks the key service to sign with the pinned key version. The
+// CryptoProvider interface carries no context, so the call runs under
+// context.Background bounded by the provider timeout; callers that need
+// cancellation use SignRecord.
+func (p *ExternalKeyProvider) Sign(data []byte) ([]byte, error) {
+	sig, _, err := p.sign(context.Background(), data)
+	return sig, err
+}
+
+// SignRecord signs like Sign and reports the key version used, for
+// evidence.RecordSigner.
+func (p *ExternalKeyProvider) SignRecord(ctx context.Context, data []byte) ([]byte, string, string, error) {
+	sig, version, err := p.sign(ctx, data)
+	return sig, p.keyID, version, err
+}
+
+func (p *ExternalKeyProvider) sign(ctx context.Context, data []byte) ([]byte, string, error) {
+	version := p.Version()
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	sig, err := p.service.Sign(ctx, p.keyID, version.Version, data)
+	if err != nil {
+		return nil, "", fmt.Errorf("external signer %s/%s: %w", p.keyID, version.Version, err)
+	}
+
+	// Never hand out a signature peers cannot verify against the key we
+	// advertise.
+	verifier, err := verifierFor(version.Algorithm)
+	if err != nil {
+		return nil, "", err
+	}
+	if ok, err := verifier.Verify(version.PublicKey, data, sig); err != nil || !ok {
+		return nil, "", fmt.Errorf("external signer %s/%s: %w", p.keyID, version.Version, ErrExternalSignatureInvalid)
+	}
+	return sig, version.Version, nil
+}
+
+func (p *ExternalKeyProvider) Verify(publicKey, data, signature []byte) (bool, error) {
+	verifier, err := verifierFor(p.Algorithm())
+	if err != nil {
+		return false, err
+	}
+	return verifier.Verify(publicKey, data, signature)
+}
+
+func (p *ExternalKeyProvider) EncodePublicKeyPEM() (string, error) {
+	return PublicKeyPEM(p.PublicKeyBytes())
+}
+
+// Version returns the key version currently used for signing.
+func (p *ExternalKeyProvider) Version() KeyVersion {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return *p.current
+}
+
+// OnRotate registers a callback run after Refresh switches to a new key
+// version.
+func (p *ExternalKeyProvider) OnRotate(fn func(old, current KeyVersion)) {
+	p.mu.Lock()
+	p.onRotate = append(p.onRotate, fn)
+	p.mu.Unlock()
+}
+
+// Refresh re-reads the current key version from the service and reports
+// whether it changed.
+func (p *ExternalKeyProvider) Refresh(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	v, err := p.service.CurrentVersion(ctx, p.keyID)
+	if err != nil {
+		return false, fmt.Errorf("external signer %s: %w", p.keyID, err)
+	}
+	if !IsSupportedCryptoAlgorithm(v.Algorithm) {
+		return false, fmt.Errorf("external signer %s: unsupported crypto algorithm: %s", p.keyID, v.Algorithm)
+	}
+	if DetectKeyAlgorithm(v.PublicKey) != v.Algorithm {
+		return false, fmt.Errorf("external signer %s: %s key does not match algorithm %s",
+			p.keyID, DetectKeyAlgorithm(v.PublicKey), v.Algorithm)
+	}
+
+	p.mu.Lock()
+	old := p.current
+	if old != nil && old.Version == v.Version && bytes.Equal(old.PublicKey, v.PublicKey) {
+		p.mu.Unlock()
+		return false, nil
+	}
+	p.current = v
+	listeners := append([]func(old, current KeyVersion){}, p.onRotate...)
+	p.mu.Unlock()
+
+	if old != nil {
+		for _, fn := range listeners {
+			fn(*old, *v)
+		}
+	}
+	return old != nil, nil
+}
+
+// RunRefresh calls Refresh every interval until ctx is cancelled, so a key
+// rotated in the service is picked up without a restart.
+func (p *ExternalKeyProvider) RunRefresh(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rotated, err := p.Refresh(ctx)
+			if err != nil {
+				slog.Warn("[KeyService] Refresh failed, keeping current key version", "key_id", p.keyID, "error", err)
+				continue
+			}
+			if rotated {
+				slog.Info("[KeyService] Signing key rotated", "key_id", p.keyID, "version", p.Version().Version)
+			}
+		}
+	}
+}
+
+// signingKeyRotated disconnects every peer: they hold the attestation key
+// of the previous version and must handshake again to learn the new one.
+func (fm *FederationManager) signingKeyRotated(old, current KeyVersion) {
+	fm.logger.Printf("Signing key %s rotated from version %s to %s; peers must re-handshake",
+		current.KeyID, old.Version, current.Version)
+	for _, p := range fm.ListPeers() {
+		fm.DisconnectPeer(p.ID)
+	}
+}
+
+// ============================================================================
+// HTTP KEY SERVICE CLIENT
+//
+// A KMS-style JSON API:
+//
+//	GET  {base}/keys/{key_id}                          → KeyVersion
+//	POST {base}/keys/{key_id}/versions/{version}/sign  {"data"} → {"signature"}
+//
+// Binary fields are base64. Requests carry an optional bearer token.
+// SoftwareKeyService.Handler serves the same API.
+// ============================================================================
+
+// HTTPKeyService is a KeyService reached over HTTP.
+type HTTPKeyService struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewHTTPKeyService creates a client for the key service at baseURL. A nil
+// client uses one with a 10s timeout.
+func NewHTTPKeyService(baseURL, token string, client *http.Client) *HTTPKeyService {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPKeyService{baseURL: strings.TrimRight(baseURL, "/"), token: token, client: client}
+}
+
+type keySignRequest struct {
+	Data []byte `json:"data"`
+}
+
+type keySignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+func (s *HTTPKeyService) CurrentVersion(ctx context.Context, keyID string) (*KeyVersion, error) {
+	var v KeyVersion
+	if err := s.do(ctx, http.MethodGet, "/keys/"+url.PathEscape(keyID), nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (s *HTTPKeyService) Sign(ctx context.Context, keyID, version string, data []byte) ([]byte, error) {
+	var resp keySignResponse
+	path := "/keys/" + url.PathEscape(keyID) + "/versions/" + url.PathEscape(version) + "/sign"
+	if err := s.do(ctx, http.MethodPost, path, keySignRequest{Data: data}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Signature) == 0 {
+		return nil, errors.New("key service returned an empty signature")
+	}
+	return resp.Signature, nil
+}
+
+func (s *HTTPKeyService) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("key service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrKeyNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("key service returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid key service response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ocx/backend/internal/evidence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// EXTERNAL KEY PROVIDER TESTS
+// ============================================================================
+
+func TestExternalKeyProviderOverHTTP(t *testing.T) {
+	ctx := context.Background()
+	keys := NewSoftwareKeyService()
+	created, err := keys.CreateKey("ocx-a-federation", AlgorithmEd25519)
+	require.NoError(t, err)
+	srv := httptest.NewServer(keys.Handler("s3cret"))
+	t.Cleanup(srv.Close)
+
+	signer, err := NewExternalKeyProvider(ctx, NewHTTPKeyService(srv.URL, "s3cret", nil), "ocx-a-federation", 0)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmEd25519, signer.Algorithm())
+	assert.Equal(t, created.PublicKey, signer.PublicKeyBytes())
+
+	data := []byte("federation handshake challenge data")
+	sig, err := signer.Sign(data)
+	require.NoError(t, err)
+	local, err := NewCryptoProvider(AlgorithmEd25519)
+	require.NoError(t, err)
+	valid, err := local.Verify(signer.PublicKeyBytes(), data, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	pemKey, err := signer.EncodePublicKeyPEM()
+	require.NoError(t, err)
+	key, err := PublicKeyFromPEM(pemKey)
+	require.NoError(t, err)
+	assert.Equal(t, signer.PublicKeyBytes(), key)
+
+	// Wrong token and unknown keys are refused
+	_, err = NewExternalKeyProvider(ctx, NewHTTPKeyService(srv.URL, "wrong", nil), "ocx-a-federation", 0)
+	assert.Error(t, err)
+	_, err = NewExternalKeyProvider(ctx, NewHTTPKeyService(srv.URL, "s3cret", nil), "missing", 0)
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "got %v", err)
+
+	// Evidence records are signed over their chained hash
+	vault := evidence.NewEvidenceVault(evidence.VaultConfig{})
+	vault.SetSigner(signer, evidence.SignFailureReject)
+	record, err := vault.RecordTransaction(ctx, "tenant-a", "agent-1", "tx-1", "tool", "A",
+		evidence.OutcomeAllow, 0.9, "ok", nil)
+	require.NoError(t, err)
+	valid, err = signer.Verify(signer.PublicKeyBytes(), []byte(record.Hash), record.Signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, "ocx-a-federation", record.SigningKeyID)
+	assert.Equal(t, created.Version, record.SigningKeyVersion)
+}
+
+func TestEvidenceSignFailurePolicy(t *testing.T) {
+	ctx := context.Background()
+	keys := NewSoftwareKeyService()
+	created, err := keys.CreateKey("ocx-evidence", AlgorithmEd25519)
+	require.NoError(t, err)
+	signer, err := NewExternalKeyProvider(ctx, keys, "ocx-evidence", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, keys.DisableVersion("ocx-evidence", created.Version))
+
+	record := func(vault *evidence.EvidenceVault, txID string) (*evidence.EvidenceRecord, error) {
+		return vault.RecordTransaction(ctx, "tenant-a", "agent-1", txID, "tool", "A",
+			evidence.OutcomeAllow, 0.9, "ok", nil)
+	}
+
+	// Kept unsigned: chained, and the record says why it has no signature
+	kept := evidence.NewEvidenceVault(evidence.VaultConfig{})
+	kept.SetSigner(signer, evidence.SignFailureKeepUnsigned)
+	r, err := record(kept, "tx-1")
+	require.NoError(t, err)
+	assert.Nil(t, r.Signature)
+	assert.Empty(t, r.SigningKeyID)
+	assert.Contains(t, r.SignatureError, "ocx-evidence")
+	valid, _, err := kept.ValidateChain("tenant-a")
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.EqualValues(t, 1, kept.Stats()["unsigned_records"])
+
+	// Rejected: the caller gets the error and the chain is not extended
+	rejecting := evidence.NewEvidenceVault(evidence.VaultConfig{})
+	rejecting.SetSigner(signer, evidence.SignFailureReject)
+	_, err = record(rejecting, "tx-1")
+	assert.True(t, errors.Is(err, evidence.ErrRecordNotSigned), "got %v", err)
+	history, err := rejecting.GetTransactionHistory(ctx, "tx-1")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	// A later record still links to the last chained one
+	next, err := keys.RotateKey("ocx-evidence")
+	require.NoError(t, err)
+	_, err = signer.Refresh(ctx)
+	require.NoError(t, err)
+	r, err = record(rejecting, "tx-2")
+	require.NoError(t, err)
+	assert.Equal(t, next.Version, r.SigningKeyVersion)
+	valid, _, err = rejecting.ValidateChain("tenant-a")
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestExternalKeyRotationReplacesFederationKey(t *testing.T) {
+	ctx := context.Background()
+	keys := NewSoftwareKeyService()
+	_, err := keys.CreateKey("ocx-a-federation", AlgorithmEd25519)
+	require.NoError(t, err)
+	signer, err := NewExternalKeyProvider(ctx, keys, "ocx-a-federation", time.Second)
+	require.NoError(t, err)
+
+	a, err := NewFederationManager(FederationConfig{InstanceID: "ocx-a", MaxPeers: 4, Signer: signer})
+	require.NoError(t, err)
+	b, err := NewFederationManager(FederationConfig{InstanceID: "ocx-b", MaxPeers: 4})
+	require.NoError(t, err)
+
+	// The handshake runs on the service-held key
+	attestation, err := b.CreateAttestation(0, 0)
+	require.NoError(t, err)
+	challenge, err := a.ProcessHandshakeMessage(&HandshakeMessage{
+		Type: HandshakeHello, InstanceID: "ocx-b", Nonce: []byte("n"), Attestation: attestation, Timestamp: time.Now(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, signer.PublicKeyBytes(), challenge.Attestation.PublicKey)
+	response, err := b.ProcessHandshakeMessage(challenge)
+	require.NoError(t, err)
+	_, err = a.ProcessHandshakeMessage(response)
+	require.NoError(t, err)
+	_, err = a.GetPeer("ocx-b")
+	require.NoError(t, err)
+
+	// Nothing changes until the key is rotated in the service
+	rotated, err := signer.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	oldKey := signer.PublicKeyBytes()
+	next, err := keys.RotateKey("ocx-a-federation")
+	require.NoError(t, err)
+	rotated, err = signer.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	assert.Equal(t, next.Version, signer.Version().Version)
+	assert.Equal(t, next.PublicKey, signer.PublicKeyBytes())
+	assert.NotEqual(t, oldKey, signer.PublicKeyBytes())
+
+	// Peers knew the old key and must re-handshake
+	_, err = a.GetPeer("ocx-b")
+	assert.Error(t, err)
+	fresh, err := a.CreateAttestation(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, next.PublicKey, fresh.PublicKey)
+
+	// A disabled version no longer signs
+	require.NoError(t, keys.DisableVersion("ocx-a-federation", next.Version))
+	_, err = signer.Sign([]byte("data"))
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "got %v", err)
+}
+
+// mismatchedKeyService advertises one key and signs with another, like a
+// KMS pointed at the wrong key.
+type mismatchedKeyService struct {
+	advertised, signing *SoftwareKeyService
+}
+
+func (s *mismatchedKeyService) CurrentVersion(ctx context.Context, keyID string) (*KeyVersion, error) {
+	return s.advertised.CurrentVersion(ctx, keyID)
+}
+
+func (s *mismatchedKeyService) Sign(ctx context.Context, keyID, version string, data []byte) ([]byte, error) {
+	return s.signing.Sign(ctx, keyID, version, data)
+}
+
+func TestExternalKeyProviderRejectsUnverifiableSignature(t *testing.T) {
+	ctx := context.Background()
+	advertised, signing := NewSoftwareKeyService(), NewSoftwareKeyService()
+	_, err := advertised.CreateKey("ocx-a-federation", AlgorithmEd25519)
+	require.NoError(t, err)
+	_, err = signing.CreateKey("ocx-a-federation", AlgorithmEd25519)
+	require.NoError(t, err)
+
+	signer, err := NewExternalKeyProvider(ctx, &mismatchedKeyService{advertised: advertised, signing: signing}, "ocx-a-federation", 0)
+	require.NoError(t, err)
+	_, err = signer.Sign([]byte("data"))
+	assert.True(t, errors.Is(err, ErrExternalSignatureInvalid), "got %v", err)
+	_, _, _, err = signer.SignRecord(ctx, []byte("data"))
+	assert.True(t, errors.Is(err, ErrExternalSignatureInvalid), "got %v", err)
+}
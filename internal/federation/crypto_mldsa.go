@@ -0,0 +1,102 @@
+//go:build go1.27
+
+package federation
+
+import (
+	"crypto/mldsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// ============================================================================
+// ML-DSA-65 PROVIDER (FIPS 204)
+// ============================================================================
+
+// postQuantumAvailable reports whether this build can sign and verify
+// ML-DSA (crypto/mldsa, Go 1.27+).
+const postQuantumAvailable = true
+
+// MLDSAProvider implements CryptoProvider using ML-DSA-65 (FIPS 204).
+// Public keys travel in their raw FIPS 204 encoding.
+type MLDSAProvider struct {
+	privateKey *mldsa.PrivateKey
+}
+
+func newMLDSAProvider() (*MLDSAProvider, error) {
+	priv, err := mldsa.GenerateKey(mldsa.MLDSA65())
+	if err != nil {
+		return nil, fmt.Errorf("ml-dsa key generation failed: %w", err)
+	}
+	return &MLDSAProvider{privateKey: priv}, nil
+}
+
+// NewMLDSAProviderFromSeed restores an ML-DSA-65 key from its 32-byte seed.
+func NewMLDSAProviderFromSeed(seed []byte) (*MLDSAProvider, error) {
+	priv, err := mldsa.NewPrivateKey(mldsa.MLDSA65(), seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ML-DSA seed: %w", err)
+	}
+	return &MLDSAProvider{privateKey: priv}, nil
+}
+
+func (p *MLDSAProvider) Algorithm() CryptoAlgorithm {
+	return AlgorithmMLDSA65
+}
+
+func (p *MLDSAProvider) PublicKeyBytes() []byte {
+	return p.privateKey.PublicKey().Bytes()
+}
+
+func (p *MLDSAProvider) Sign(data []byte) ([]byte, error) {
+	return p.signContext(data, "")
+}
+
+func (p *MLDSAProvider) Verify(publicKey, data, signature []byte) (bool, error) {
+	return p.verifyContext(publicKey, data, signature, "")
+}
+
+func (p *MLDSAProvider) EncodePublicKeyPEM() (string, error) {
+	derBytes, err := x509.MarshalPKIXPublicKey(p.privateKey.PublicKey())
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ML-DSA public key: %w", err)
+	}
+	pemBlock := &pem.Block{Type: "PUBLIC KEY", Bytes: derBytes}
+	return string(pem.EncodeToMemory(pemBlock)), nil
+}
+
+// signContext signs with an ML-DSA context string, which keeps signatures
+// made for one purpose (such as half of a hybrid signature) from
+// verifying in another.
+func (p *MLDSAProvider) signContext(data []byte, context string) ([]byte, error) {
+	return p.privateKey.Sign(nil, data, &mldsa.Options{Context: context})
+}
+
+func (p *MLDSAProvider) verifyContext(publicKey, data, signature []byte, context string) (bool, error) {
+	if len(publicKey) != mldsa65PublicKeySize {
+		return false, fmt.Errorf("invalid ML-DSA-65 public key size: got %d, want %d",
+			len(publicKey), mldsa65PublicKeySize)
+	}
+	pub, err := mldsa.NewPublicKey(mldsa.MLDSA65(), publicKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse ML-DSA public key: %w", err)
+	}
+	return mldsa.Verify(pub, data, signature, &mldsa.Options{Context: context}) == nil, nil
+}
+
+// mldsaPublicKeyDER converts a raw ML-DSA-65 public key to PKIX DER.
+func mldsaPublicKeyDER(publicKey []byte) ([]byte, error) {
+	pub, err := mldsa.NewPublicKey(mldsa.MLDSA65(), publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.MarshalPKIXPublicKey(pub)
+}
+
+// mldsaRawPublicKey returns the raw encoding of a parsed ML-DSA key.
+func mldsaRawPublicKey(pub interface{}) ([]byte, bool) {
+	if k, ok := pub.(*mldsa.PublicKey); ok {
+		return k.Bytes(), true
+	}
+	return nil, false
+}
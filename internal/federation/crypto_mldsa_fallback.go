@@ -0,0 +1,53 @@
+//go:build !go1.27
+
+package federation
+
+import "errors"
+
+// ============================================================================
+// ML-DSA-65 PROVIDER — UNAVAILABLE
+//
+// crypto/mldsa ships with Go 1.27. Older toolchains build without
+// post-quantum support: ML-DSA and hybrid providers cannot be created and
+// their signatures never verify.
+// ============================================================================
+
+const postQuantumAvailable = false
+
+var errPostQuantumUnavailable = errors.New("ML-DSA requires a Go 1.27+ build")
+
+// MLDSAProvider implements CryptoProvider using ML-DSA-65 (FIPS 204).
+type MLDSAProvider struct{}
+
+func newMLDSAProvider() (*MLDSAProvider, error) {
+	return nil, errPostQuantumUnavailable
+}
+
+// NewMLDSAProviderFromSeed restores an ML-DSA-65 key from its 32-byte seed.
+func NewMLDSAProviderFromSeed(seed []byte) (*MLDSAProvider, error) {
+	return nil, errPostQuantumUnavailable
+}
+
+func (p *MLDSAProvider) Algorithm() CryptoAlgorithm { return AlgorithmMLDSA65 }
+
+func (p *MLDSAProvider) PublicKeyBytes() []byte { return nil }
+
+func (p *MLDSAProvider) Sign(data []byte) ([]byte, error) { return nil, errPostQuantumUnavailable }
+
+func (p *MLDSAProvider) Verify(publicKey, data, signature []byte) (bool, error) {
+	return false, errPostQuantumUnavailable
+}
+
+func (p *MLDSAProvider) EncodePublicKeyPEM() (string, error) { return "", errPostQuantumUnavailable }
+
+func (p *MLDSAProvider) signContext(data []byte, context string) ([]byte, error) {
+	return nil, errPostQuantumUnavailable
+}
+
+func (p *MLDSAProvider) verifyContext(publicKey, data, signature []byte, context string) (bool, error) {
+	return false, errPostQuantumUnavailable
+}
+
+func mldsaPublicKeyDER(publicKey []byte) ([]byte, error) { return nil, errPostQuantumUnavailable }
+
+func mldsaRawPublicKey(pub interface{}) ([]byte, bool) { return nil, false }
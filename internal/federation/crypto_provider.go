@@ -13,7 +13,7 @@ import (
 )
 
 // ============================================================================
-// DUAL CRYPTO PROVIDER — ECDSA P-256 / Ed25519
+// CRYPTO PROVIDERS — Ed25519 / ECDSA P-256 / ML-DSA-65 / Ed25519+ML-DSA-65
 // Tenant-configurable signing algorithm for Inter-OCX Federation.
 // ============================================================================
 
@@ -28,8 +28,29 @@ const (
 	// AlgorithmECDSA uses ECDSA with the NIST P-256 curve. FIPS 140-2/3
 	// compliant. Required for regulated financial tenants.
 	AlgorithmECDSA CryptoAlgorithm = "ecdsa-p256"
+
+	// AlgorithmMLDSA65 uses ML-DSA-65 (FIPS 204), a post-quantum lattice
+	// signature. 1952-byte public keys, 3309-byte signatures.
+	AlgorithmMLDSA65 CryptoAlgorithm = "ml-dsa-65"
+
+	// AlgorithmHybrid signs with Ed25519 and ML-DSA-65; both signatures
+	// must verify, so it stays secure while either scheme holds. For
+	// long-lived attestations of regulated tenants.
+	AlgorithmHybrid CryptoAlgorithm = "ed25519+ml-dsa-65"
+)
+
+// Wire sizes used to tell key formats apart.
+const (
+	mldsa65PublicKeySize = 1952
+	mldsa65SignatureSize = 3309
+	hybridPublicKeySize  = ed25519.PublicKeySize + mldsa65PublicKeySize
+	hybridSignatureSize  = ed25519.SignatureSize + mldsa65SignatureSize
 )
 
+// ErrAlgorithmNotAccepted is returned when a peer signs with an algorithm
+// the local policy does not accept, or no common algorithm exists.
+var ErrAlgorithmNotAccepted = errors.New("crypto algorithm not accepted")
+
 // CryptoProvider abstracts signing and verification so the federation layer
 // can operate algorithm-agnostically. Each tenant selects their preferred
 // algorithm via configuration.
@@ -61,9 +82,46 @@ func NewCryptoProvider(algorithm CryptoAlgorithm) (CryptoProvider, error) {
 		return newEd25519Provider()
 	case AlgorithmECDSA:
 		return newECDSAProvider()
+	case AlgorithmMLDSA65:
+		return newMLDSAProvider()
+	case AlgorithmHybrid:
+		return newHybridProvider()
+	default:
+		return nil, fmt.Errorf("unsupported crypto algorithm: %s (supported: %s, %s, %s, %s)",
+			algorithm, AlgorithmEd25519, AlgorithmECDSA, AlgorithmMLDSA65, AlgorithmHybrid)
+	}
+}
+
+// verifierFor returns a provider that can verify signatures of algorithm.
+// It holds no private key and must only be used for Verify.
+func verifierFor(algorithm CryptoAlgorithm) (CryptoProvider, error) {
+	switch algorithm {
+	case AlgorithmEd25519:
+		return &Ed25519Provider{}, nil
+	case AlgorithmECDSA:
+		return &ECDSAProvider{}, nil
+	case AlgorithmMLDSA65:
+		return &MLDSAProvider{}, nil
+	case AlgorithmHybrid:
+		return &HybridProvider{classical: &Ed25519Provider{}, pq: &MLDSAProvider{}}, nil
 	default:
-		return nil, fmt.Errorf("unsupported crypto algorithm: %s (supported: %s, %s)",
-			algorithm, AlgorithmEd25519, AlgorithmECDSA)
+		return nil, fmt.Errorf("unsupported crypto algorithm: %s", algorithm)
+	}
+}
+
+// DetectKeyAlgorithm infers the algorithm of a wire-format public key from
+// its length: 32 bytes Ed25519, 1952 ML-DSA-65, 1984 hybrid, otherwise
+// PKIX DER ECDSA.
+func DetectKeyAlgorithm(publicKey []byte) CryptoAlgorithm {
+	switch len(publicKey) {
+	case ed25519.PublicKeySize:
+		return AlgorithmEd25519
+	case mldsa65PublicKeySize:
+		return AlgorithmMLDSA65
+	case hybridPublicKeySize:
+		return AlgorithmHybrid
+	default:
+		return AlgorithmECDSA
 	}
 }
 
@@ -191,6 +249,92 @@ func (p *ECDSAProvider) EncodePublicKeyPEM() (string, error) {
 	return string(pem.EncodeToMemory(pemBlock)), nil
 }
 
+// ============================================================================
+// HYBRID Ed25519 + ML-DSA-65 PROVIDER
+// ============================================================================
+
+// hybridPEMType labels a hybrid public key (Ed25519 ‖ ML-DSA-65, raw) in a
+// HELLO; there is no standard PKIX encoding for the pair.
+const hybridPEMType = "OCX HYBRID PUBLIC KEY"
+
+// hybridDomain separates the halves of a hybrid signature from standalone
+// Ed25519 and ML-DSA signatures, so neither half can be stripped off and
+// presented on its own.
+const hybridDomain = "ocx-hybrid-ed25519-ml-dsa-65"
+
+// HybridProvider implements CryptoProvider by signing with both Ed25519 and
+// ML-DSA-65. Public keys and signatures are the Ed25519 part followed by the
+// ML-DSA part; a signature is valid only if both halves verify.
+type HybridProvider struct {
+	classical *Ed25519Provider
+	pq        *MLDSAProvider
+}
+
+func newHybridProvider() (*HybridProvider, error) {
+	classical, err := newEd25519Provider()
+	if err != nil {
+		return nil, err
+	}
+	pq, err := newMLDSAProvider()
+	if err != nil {
+		return nil, err
+	}
+	return &HybridProvider{classical: classical, pq: pq}, nil
+}
+
+// NewHybridProvider combines existing Ed25519 and ML-DSA-65 keys.
+func NewHybridProvider(classical *Ed25519Provider, pq *MLDSAProvider) *HybridProvider {
+	return &HybridProvider{classical: classical, pq: pq}
+}
+
+func (p *HybridProvider) Algorithm() CryptoAlgorithm {
+	return AlgorithmHybrid
+}
+
+func (p *HybridProvider) PublicKeyBytes() []byte {
+	key := make([]byte, 0, hybridPublicKeySize)
+	key = append(key, p.classical.PublicKeyBytes()...)
+	return append(key, p.pq.PublicKeyBytes()...)
+}
+
+func (p *HybridProvider) Sign(data []byte) ([]byte, error) {
+	classicalSig, err := p.classical.Sign(append([]byte(hybridDomain), data...))
+	if err != nil {
+		return nil, err
+	}
+	pqSig, err := p.pq.signContext(data, hybridDomain)
+	if err != nil {
+		return nil, fmt.Errorf("ml-dsa half of hybrid signature: %w", err)
+	}
+	return append(classicalSig, pqSig...), nil
+}
+
+func (p *HybridProvider) Verify(publicKey, data, signature []byte) (bool, error) {
+	if len(publicKey) != hybridPublicKeySize {
+		return false, fmt.Errorf("invalid hybrid public key size: got %d, want %d",
+			len(publicKey), hybridPublicKeySize)
+	}
+	if len(signature) != hybridSignatureSize {
+		return false, nil
+	}
+	classicalOK, err := p.classical.Verify(publicKey[:ed25519.PublicKeySize],
+		append([]byte(hybridDomain), data...), signature[:ed25519.SignatureSize])
+	if err != nil {
+		return false, err
+	}
+	pqOK, err := p.pq.verifyContext(publicKey[ed25519.PublicKeySize:], data,
+		signature[ed25519.SignatureSize:], hybridDomain)
+	if err != nil {
+		return false, err
+	}
+	return classicalOK && pqOK, nil
+}
+
+func (p *HybridProvider) EncodePublicKeyPEM() (string, error) {
+	pemBlock := &pem.Block{Type: hybridPEMType, Bytes: p.PublicKeyBytes()}
+	return string(pem.EncodeToMemory(pemBlock)), nil
+}
+
 // ============================================================================
 // TENANT CONFIGURATION HELPER
 // ============================================================================
@@ -198,12 +342,74 @@ func (p *ECDSAProvider) EncodePublicKeyPEM() (string, error) {
 // DefaultCryptoAlgorithm is used when no tenant-level preference is configured.
 const DefaultCryptoAlgorithm = AlgorithmEd25519
 
+// SupportedCryptoAlgorithms lists the algorithms this build can verify, as
+// advertised in federation metadata and HELLO messages.
+func SupportedCryptoAlgorithms() []CryptoAlgorithm {
+	algs := []CryptoAlgorithm{AlgorithmEd25519, AlgorithmECDSA}
+	if postQuantumAvailable {
+		algs = append(algs, AlgorithmHybrid, AlgorithmMLDSA65)
+	}
+	return algs
+}
+
+// IsSupportedCryptoAlgorithm reports whether this build can use alg.
+func IsSupportedCryptoAlgorithm(alg CryptoAlgorithm) bool {
+	for _, a := range SupportedCryptoAlgorithms() {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// AlgorithmPreference orders preferred first, then the accepted algorithms
+// (all supported ones when accepted is empty). The result is both the
+// negotiation order and the set a handshake accepts from peers, so
+// preferred is only included when accepted lists it: an accepted list of
+// ["ed25519+ml-dsa-65"] requires hybrid peers whatever the default is.
+func AlgorithmPreference(preferred CryptoAlgorithm, accepted []CryptoAlgorithm) []CryptoAlgorithm {
+	if len(accepted) == 0 {
+		accepted = SupportedCryptoAlgorithms()
+	}
+	allowed := map[CryptoAlgorithm]bool{}
+	for _, a := range accepted {
+		allowed[a] = true
+	}
+	out := make([]CryptoAlgorithm, 0, len(accepted))
+	seen := map[CryptoAlgorithm]bool{}
+	for _, a := range append([]CryptoAlgorithm{preferred}, accepted...) {
+		if allowed[a] && !seen[a] && IsSupportedCryptoAlgorithm(a) {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// NegotiateCryptoAlgorithm picks the first local preference the remote
+// supports. An unknown remote (empty list) gets the first preference.
+func NegotiateCryptoAlgorithm(local, remote []CryptoAlgorithm) (CryptoAlgorithm, error) {
+	if len(local) == 0 {
+		local = []CryptoAlgorithm{DefaultCryptoAlgorithm}
+	}
+	if len(remote) == 0 {
+		return local[0], nil
+	}
+	for _, l := range local {
+		for _, r := range remote {
+			if l == r {
+				return l, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: local %v, remote %v", ErrAlgorithmNotAccepted, local, remote)
+}
+
 // ResolveCryptoAlgorithm returns the algorithm for a tenant, or the default.
 // It reads from a tenant config map (tenant_id → algorithm string).
 func ResolveCryptoAlgorithm(tenantID string, tenantConfig map[string]string, globalDefault CryptoAlgorithm) CryptoAlgorithm {
 	if alg, ok := tenantConfig[tenantID]; ok {
-		switch CryptoAlgorithm(alg) {
-		case AlgorithmEd25519, AlgorithmECDSA:
+		if IsSupportedCryptoAlgorithm(CryptoAlgorithm(alg)) {
 			return CryptoAlgorithm(alg)
 		}
 	}
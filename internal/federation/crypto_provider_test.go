@@ -1,14 +1,19 @@
 package federation
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"errors"
 	"testing"
 
+	pb "github.com/ocx/backend/pb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // ============================================================================
@@ -92,6 +97,225 @@ func TestCryptoProvider_CrossAlgorithmRejection(t *testing.T) {
 	if err == nil {
 		assert.False(t, valid, "ECDSA signature must NOT verify with Ed25519 provider")
 	}
+
+	if !postQuantumAvailable {
+		return
+	}
+	mldsaProv, err := NewCryptoProvider(AlgorithmMLDSA65)
+	require.NoError(t, err)
+	hybridProv, err := NewCryptoProvider(AlgorithmHybrid)
+	require.NoError(t, err)
+
+	// ML-DSA signatures do not verify as Ed25519, and vice versa
+	pqSig, err := mldsaProv.Sign(data)
+	require.NoError(t, err)
+	valid, err = ed25519Prov.Verify(ed25519Prov.PublicKeyBytes(), data, pqSig)
+	if err == nil {
+		assert.False(t, valid, "ML-DSA signature must NOT verify with Ed25519 provider")
+	}
+	valid, err = mldsaProv.Verify(mldsaProv.PublicKeyBytes(), data, edSig)
+	if err == nil {
+		assert.False(t, valid, "Ed25519 signature must NOT verify with ML-DSA provider")
+	}
+
+	// A hybrid signature needs both halves, and neither half stands alone
+	hybridSig, err := hybridProv.Sign(data)
+	require.NoError(t, err)
+	hybridKey := hybridProv.PublicKeyBytes()
+	edKey, pqKey := hybridKey[:ed25519.PublicKeySize], hybridKey[ed25519.PublicKeySize:]
+	edHalf, pqHalf := hybridSig[:ed25519.SignatureSize], hybridSig[ed25519.SignatureSize:]
+
+	valid, err = ed25519Prov.Verify(edKey, data, edHalf)
+	require.NoError(t, err)
+	assert.False(t, valid, "classical half of a hybrid signature must NOT verify alone")
+	valid, err = mldsaProv.Verify(pqKey, data, pqHalf)
+	require.NoError(t, err)
+	assert.False(t, valid, "ML-DSA half of a hybrid signature must NOT verify alone")
+
+	for name, sig := range map[string][]byte{
+		"ed25519 only":     append(append([]byte{}, edHalf...), make([]byte, len(pqHalf))...),
+		"ml-dsa only":      append(make([]byte, ed25519.SignatureSize), pqHalf...),
+		"plain ed25519":    edSig,
+		"plain ml-dsa":     pqSig,
+		"truncated":        hybridSig[:len(hybridSig)-1],
+		"other hybrid key": hybridSig,
+	} {
+		key := hybridKey
+		if name == "other hybrid key" {
+			other, err := NewCryptoProvider(AlgorithmHybrid)
+			require.NoError(t, err)
+			key = other.PublicKeyBytes()
+		}
+		valid, err := hybridProv.Verify(key, data, sig)
+		if err == nil {
+			assert.False(t, valid, "hybrid verification must fail: %s", name)
+		}
+	}
+
+	// Hybrid signatures are rejected by every single-algorithm provider
+	valid, err = ecdsaProv.Verify(ecdsaProv.PublicKeyBytes(), data, hybridSig)
+	if err == nil {
+		assert.False(t, valid, "hybrid signature must NOT verify with ECDSA provider")
+	}
+}
+
+func TestHybridProvider_SignVerify(t *testing.T) {
+	if !postQuantumAvailable {
+		t.Skip("ML-DSA not available in this build")
+	}
+	provider, err := NewCryptoProvider(AlgorithmHybrid)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmHybrid, provider.Algorithm())
+	assert.Len(t, provider.PublicKeyBytes(), hybridPublicKeySize)
+	assert.Equal(t, AlgorithmHybrid, DetectKeyAlgorithm(provider.PublicKeyBytes()))
+
+	data := []byte("federation handshake challenge data")
+	sig, err := provider.Sign(data)
+	require.NoError(t, err)
+	assert.Len(t, sig, hybridSignatureSize)
+
+	valid, err := provider.Verify(provider.PublicKeyBytes(), data, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = provider.Verify(provider.PublicKeyBytes(), []byte("tampered data"), sig)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	// The PEM key round-trips to the same wire key and fingerprint
+	pemKey, err := provider.EncodePublicKeyPEM()
+	require.NoError(t, err)
+	key, err := PublicKeyFromPEM(pemKey)
+	require.NoError(t, err)
+	assert.Equal(t, provider.PublicKeyBytes(), key)
+	fp, err := KeyFingerprintPEM(pemKey)
+	require.NoError(t, err)
+	assert.Equal(t, KeyFingerprint(provider.PublicKeyBytes()), fp)
+}
+
+func TestNegotiateCryptoAlgorithm(t *testing.T) {
+	alg, err := NegotiateCryptoAlgorithm(
+		[]CryptoAlgorithm{AlgorithmHybrid, AlgorithmEd25519},
+		[]CryptoAlgorithm{AlgorithmECDSA, AlgorithmEd25519})
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmEd25519, alg)
+
+	// Unknown remote support → first local preference
+	alg, err = NegotiateCryptoAlgorithm([]CryptoAlgorithm{AlgorithmECDSA}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmECDSA, alg)
+
+	_, err = NegotiateCryptoAlgorithm([]CryptoAlgorithm{AlgorithmHybrid}, []CryptoAlgorithm{AlgorithmEd25519})
+	assert.True(t, errors.Is(err, ErrAlgorithmNotAccepted), "got %v", err)
+
+	// The preferred algorithm leads; unsupported names are dropped
+	prefs := AlgorithmPreference(AlgorithmECDSA, []CryptoAlgorithm{AlgorithmEd25519, "rsa-4096", AlgorithmECDSA})
+	assert.Equal(t, []CryptoAlgorithm{AlgorithmECDSA, AlgorithmEd25519}, prefs)
+	assert.Equal(t, prefs, ParseAlgorithms(joinAlgorithms(prefs)))
+
+	// A default outside the accepted list is not smuggled back in
+	assert.Equal(t, []CryptoAlgorithm{AlgorithmECDSA},
+		AlgorithmPreference(AlgorithmEd25519, []CryptoAlgorithm{AlgorithmECDSA}))
+}
+
+func TestHandshakeService_HybridOnlyRefusesEd25519(t *testing.T) {
+	if !postQuantumAvailable {
+		t.Skip("ML-DSA not available in this build")
+	}
+	ctx := context.Background()
+	responder := &OCXInstance{InstanceID: "ocx-b", Organization: "Acme Corp"}
+	server := NewHandshakeServiceServer(responder, NewTrustAttestationLedgerWithID("ocx-b"))
+	server.SetAlgorithmPreference(AlgorithmPreference(AlgorithmEd25519, []CryptoAlgorithm{AlgorithmHybrid})...)
+
+	newHello := func() *pb.HandshakeHello {
+		initiator := NewHandshakeSession(&OCXInstance{InstanceID: "ocx-a", Organization: "Acme Corp"}, responder, NewMockTrustAttestationLedger())
+		initiator.SetAlgorithmPreference(AlgorithmEd25519)
+		hello, err := initiator.SendHello(ctx)
+		require.NoError(t, err)
+		return hello
+	}
+
+	// An Ed25519 key in the HELLO is refused
+	_, err := server.InitiateHandshake(ctx, newHello())
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "got %v", err)
+
+	// So is a keyless HELLO announcing Ed25519
+	keyless := newHello()
+	keyless.PublicKey = ""
+	_, err = server.InitiateHandshake(ctx, keyless)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "got %v", err)
+
+	// A keyless HELLO that announces nothing is held to its PROOF key
+	session := NewHandshakeSession(responder, &OCXInstance{InstanceID: "ocx-a"}, NewMockTrustAttestationLedger(), &Ed25519Provider{})
+	session.SetAlgorithmPreference(AlgorithmHybrid)
+	silent := newHello()
+	silent.PublicKey = ""
+	delete(silent.Metadata, "algorithm")
+	require.NoError(t, session.ReceiveHello(ctx, silent))
+	_, err = session.SendChallenge(ctx, silent)
+	require.NoError(t, err)
+	err = session.ReceiveProof(ctx, &pb.HandshakeProof{Proof: []byte("sig")})
+	assert.True(t, errors.Is(err, ErrAlgorithmNotAccepted), "got %v", err)
+}
+
+func TestHandshakeSession_HybridNegotiation(t *testing.T) {
+	if !postQuantumAvailable {
+		t.Skip("ML-DSA not available in this build")
+	}
+	ctx := context.Background()
+	local := &OCXInstance{InstanceID: "ocx-a", Organization: "Acme Corp"}
+	remote := &OCXInstance{InstanceID: "ocx-b", Organization: "Acme Corp",
+		Algorithms: []CryptoAlgorithm{AlgorithmHybrid, AlgorithmEd25519}}
+
+	// Both sides prefer hybrid: HELLO negotiates it and the PROOF needs
+	// both signatures
+	initiator := NewHandshakeSession(local, remote, NewMockTrustAttestationLedger())
+	initiator.SetAlgorithmPreference(AlgorithmHybrid, AlgorithmEd25519)
+	responder := NewHandshakeSession(remote, local, NewMockTrustAttestationLedger())
+	responder.SetAlgorithmPreference(AlgorithmHybrid)
+
+	hello, err := initiator.SendHello(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, string(AlgorithmHybrid), hello.Metadata["algorithm"])
+	require.NoError(t, responder.ReceiveHello(ctx, hello))
+	assert.Equal(t, AlgorithmHybrid, responder.RemoteAlgorithm())
+
+	challenge, err := responder.SendChallenge(ctx, hello)
+	require.NoError(t, err)
+	require.NoError(t, initiator.ReceiveChallenge(ctx, challenge))
+	proof, err := initiator.GenerateProof(ctx, "agent")
+	require.NoError(t, err)
+	assert.Equal(t, "Ed25519+ML-DSA-65", proof.ProofType)
+
+	// Only the classical half of the proof is refused
+	stripped := &pb.HandshakeProof{Proof: proof.Proof[:ed25519.SignatureSize], ProofType: proof.ProofType}
+	check := NewHandshakeSession(remote, local, NewMockTrustAttestationLedger())
+	check.SetAlgorithmPreference(AlgorithmHybrid)
+	require.NoError(t, check.ReceiveHello(ctx, hello))
+	_, err = check.SendChallenge(ctx, hello)
+	require.NoError(t, err)
+	check.challenge = responder.challenge
+	assert.Error(t, check.ReceiveProof(ctx, stripped))
+
+	require.NoError(t, responder.ReceiveProof(ctx, proof))
+
+	// A hybrid-only responder refuses a classical peer at HELLO
+	classical := NewHandshakeSession(local, &OCXInstance{InstanceID: "ocx-b"}, NewMockTrustAttestationLedger())
+	classical.SetAlgorithmPreference(AlgorithmEd25519)
+	hello, err = classical.SendHello(ctx)
+	require.NoError(t, err)
+	strict := NewHandshakeSession(remote, local, NewMockTrustAttestationLedger())
+	strict.SetAlgorithmPreference(AlgorithmHybrid)
+	err = strict.ReceiveHello(ctx, hello)
+	assert.True(t, errors.Is(err, ErrAlgorithmNotAccepted), "got %v", err)
+
+	// A peer announcing hybrid support only cannot be sent a classical key
+	fixed, err := NewCryptoProvider(AlgorithmEd25519)
+	require.NoError(t, err)
+	pinned := NewHandshakeSession(local, &OCXInstance{InstanceID: "ocx-b", Algorithms: []CryptoAlgorithm{AlgorithmHybrid}},
+		NewMockTrustAttestationLedger(), fixed)
+	_, err = pinned.SendHello(ctx)
+	assert.True(t, errors.Is(err, ErrAlgorithmNotAccepted), "got %v", err)
 }
 
 func TestNewCryptoProvider_InvalidAlgorithm(t *testing.T) {
@@ -107,10 +331,14 @@ func TestCryptoProvider_PublicKeyPEM(t *testing.T) {
 	}{
 		{"Ed25519", AlgorithmEd25519},
 		{"ECDSA-P256", AlgorithmECDSA},
+		{"ML-DSA-65", AlgorithmMLDSA65},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if !IsSupportedCryptoAlgorithm(tt.alg) {
+				t.Skip("algorithm not available in this build")
+			}
 			provider, err := NewCryptoProvider(tt.alg)
 			require.NoError(t, err)
 
@@ -0,0 +1,738 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// FEDERATION DISCOVERY
+//
+// Each OCX instance publishes signed FederationMetadata at
+// https://<domain>/.well-known/ocx-federation.json: its instance ID,
+// endpoints, public keys, supported algorithms and capabilities. Operators
+// may also publish DNS records:
+//
+//	_ocx-federation.<domain>      TXT  "v=ocx1; id=<instance id>; fp=<key fingerprint>"
+//	_ocx-handshake._tcp.<domain>  SRV  handshake gRPC endpoint
+//	_ocx-messages._tcp.<domain>   SRV  FederatedMessageService endpoint
+//
+// The TXT record pins the instance ID and signing key independently of the
+// web server; SRV records supply endpoints the document leaves out.
+// FederationDiscovery resolves, verifies and caches documents by domain and
+// FederationRegistry.Discover registers the result, so partners federate by
+// exchanging a domain name.
+// ============================================================================
+
+// WellKnownFederationPath is where an instance serves its metadata.
+const WellKnownFederationPath = "/.well-known/ocx-federation.json"
+
+const (
+	federationTXTPrefix  = "_ocx-federation."
+	federationTXTVersion = "ocx1"
+	maxMetadataBytes     = 1 << 20
+)
+
+var (
+	// ErrInvalidMetadata is returned when a discovery document or its DNS
+	// records fail verification.
+	ErrInvalidMetadata = errors.New("invalid federation metadata")
+
+	// ErrPeerKeyMismatch is returned when a peer presents a key other than
+	// the ones its discovery document lists.
+	ErrPeerKeyMismatch = errors.New("peer key does not match discovered metadata")
+
+	// ErrInstanceConflict is returned when a discovered document claims an
+	// instance ID already bound to another domain or key.
+	ErrInstanceConflict = errors.New("instance ID is bound to another domain or key")
+)
+
+// FederationEndpoints are the network endpoints an instance advertises.
+type FederationEndpoints struct {
+	Handshake   string `json:"handshake,omitempty"`    // host:port of the handshake gRPC service
+	Messages    string `json:"messages,omitempty"`     // host:port of FederatedMessageService
+	TrustBundle string `json:"trust_bundle,omitempty"` // URL of the signed trust bundle
+}
+
+// MetadataKey is a public key in attestation wire format.
+type MetadataKey struct {
+	Algorithm   CryptoAlgorithm `json:"algorithm"`
+	PublicKey   []byte          `json:"public_key"`
+	Fingerprint string          `json:"fingerprint"` // KeyFingerprint(PublicKey)
+}
+
+// FederationMetadata is the signed document served at
+// WellKnownFederationPath. PublicKeys[0] signs it and is the key the
+// instance presents in handshake attestations.
+type FederationMetadata struct {
+	InstanceID   OCXInstanceID       `json:"instance_id"`
+	Domain       string              `json:"domain"`
+	TrustDomain  string              `json:"trust_domain,omitempty"`
+	Organization string              `json:"organization,omitempty"`
+	Region       string              `json:"region,omitempty"`
+	Endpoints    FederationEndpoints `json:"endpoints"`
+	PublicKeys   []MetadataKey       `json:"public_keys"`
+	Algorithms   []CryptoAlgorithm   `json:"algorithms"`
+	Capabilities []string            `json:"capabilities,omitempty"`
+	IssuedAt     time.Time           `json:"issued_at"`
+	ExpiresAt    time.Time           `json:"expires_at"`
+	Signature    []byte              `json:"signature"`
+}
+
+func (m *FederationMetadata) canonicalBytes() ([]byte, error) {
+	copy := *m
+	copy.Signature = nil
+	copy.IssuedAt = m.IssuedAt.UTC()
+	copy.ExpiresAt = m.ExpiresAt.UTC()
+	return json.Marshal(copy)
+}
+
+// Verify checks the document is complete, current and signed by its first
+// key. It does not show the key belongs to the domain; the DNS pin and the
+// key seen on earlier fetches do that.
+func (m *FederationMetadata) Verify(now time.Time) error {
+	if m.InstanceID == "" || m.Domain == "" {
+		return fmt.Errorf("%w: instance_id and domain are required", ErrInvalidMetadata)
+	}
+	if len(m.PublicKeys) == 0 {
+		return fmt.Errorf("%w: no public keys", ErrInvalidMetadata)
+	}
+	for i, k := range m.PublicKeys {
+		if KeyFingerprint(k.PublicKey) != k.Fingerprint {
+			return fmt.Errorf("%w: key %d fingerprint does not match", ErrInvalidMetadata, i)
+		}
+	}
+	if !m.ExpiresAt.IsZero() && now.After(m.ExpiresAt) {
+		return fmt.Errorf("%w: expired at %s", ErrInvalidMetadata, m.ExpiresAt.Format(time.RFC3339))
+	}
+	data, err := m.canonicalBytes()
+	if err != nil {
+		return err
+	}
+	if !verifyDetectedKey(m.PublicKeys[0].PublicKey, data, m.Signature) {
+		return fmt.Errorf("%w: bad signature from %s", ErrInvalidMetadata, m.InstanceID)
+	}
+	return nil
+}
+
+// SigningKey returns the key that signs the document.
+func (m *FederationMetadata) SigningKey() MetadataKey {
+	if len(m.PublicKeys) == 0 {
+		return MetadataKey{}
+	}
+	return m.PublicKeys[0]
+}
+
+// ============================================================================
+// PUBLISHING
+// ============================================================================
+
+// MetadataPublication is what an instance advertises beyond the identity
+// its FederationManager already holds.
+type MetadataPublication struct {
+	Domain       string
+	TrustDomain  string
+	Organization string
+	Endpoints    FederationEndpoints
+	TTL          time.Duration // document lifetime (default 24h)
+}
+
+// SignedMetadata returns this instance's discovery document, signed with
+// its attestation key. Without an explicit trust bundle URL the bundle on
+// the published domain is advertised.
+func (fm *FederationManager) SignedMetadata(pub MetadataPublication) (*FederationMetadata, error) {
+	if pub.Domain == "" {
+		return nil, errors.New("federation domain not configured")
+	}
+	ttl := pub.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	endpoints := pub.Endpoints
+	if endpoints.TrustBundle == "" {
+		endpoints.TrustBundle = "https://" + pub.Domain + "/.well-known/ocx-trust-bundle.json"
+	}
+
+	key := fm.crypto.PublicKeyBytes()
+	now := time.Now().UTC()
+	m := &FederationMetadata{
+		InstanceID:   fm.instanceID,
+		Domain:       pub.Domain,
+		TrustDomain:  pub.TrustDomain,
+		Organization: pub.Organization,
+		Region:       fm.region,
+		Endpoints:    endpoints,
+		PublicKeys:   []MetadataKey{{Algorithm: fm.crypto.Algorithm(), PublicKey: key, Fingerprint: KeyFingerprint(key)}},
+		Algorithms:   SupportedCryptoAlgorithms(),
+		Capabilities: fm.capabilities,
+		IssuedAt:     now,
+		ExpiresAt:    now.Add(ttl),
+	}
+	data, err := m.canonicalBytes()
+	if err != nil {
+		return nil, err
+	}
+	if m.Signature, err = fm.crypto.Sign(data); err != nil {
+		return nil, fmt.Errorf("sign federation metadata: %w", err)
+	}
+	return m, nil
+}
+
+// ============================================================================
+// DNS RECORDS
+// ============================================================================
+
+// DNSResolver is the part of *net.Resolver discovery uses.
+type DNSResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// dnsPin is what a domain's _ocx-federation TXT records assert.
+type dnsPin struct {
+	instanceID   OCXInstanceID
+	fingerprints map[string]bool
+}
+
+// parseFederationTXT reads "v=ocx1; id=...; fp=..." records. Records of
+// other versions are ignored; it returns nil when none apply. Several
+// records may list several fingerprints (key rotation) but must agree on
+// the instance ID.
+func parseFederationTXT(records []string) (*dnsPin, error) {
+	var pin *dnsPin
+	for _, rec := range records {
+		fields := map[string][]string{}
+		for _, part := range strings.Split(rec, ";") {
+			k, v, ok := strings.Cut(part, "=")
+			if ok {
+				k = strings.ToLower(strings.TrimSpace(k))
+				fields[k] = append(fields[k], strings.TrimSpace(v))
+			}
+		}
+		if len(fields["v"]) == 0 || fields["v"][0] != federationTXTVersion {
+			continue
+		}
+		if pin == nil {
+			pin = &dnsPin{fingerprints: map[string]bool{}}
+		}
+		for _, id := range fields["id"] {
+			if pin.instanceID != "" && pin.instanceID != OCXInstanceID(id) {
+				return nil, fmt.Errorf("%w: TXT records name both %s and %s", ErrInvalidMetadata, pin.instanceID, id)
+			}
+			pin.instanceID = OCXInstanceID(id)
+		}
+		for _, fp := range fields["fp"] {
+			pin.fingerprints[strings.ToLower(fp)] = true
+		}
+	}
+	if pin != nil && (pin.instanceID == "" || len(pin.fingerprints) == 0) {
+		return nil, fmt.Errorf("%w: TXT record needs id and fp", ErrInvalidMetadata)
+	}
+	return pin, nil
+}
+
+// FederationTXTRecord returns the TXT record value an operator publishes at
+// _ocx-federation.<domain> to pin this metadata's instance and signing key.
+func FederationTXTRecord(m *FederationMetadata) string {
+	return fmt.Sprintf("v=%s; id=%s; fp=%s", federationTXTVersion, m.InstanceID, m.SigningKey().Fingerprint)
+}
+
+// ============================================================================
+// DISCOVERY
+// ============================================================================
+
+// DiscoveryConfig configures FederationDiscovery.
+type DiscoveryConfig struct {
+	CacheTTL     time.Duration // default 15m; never beyond the document's expires_at
+	FetchTimeout time.Duration // default 5s
+	RequireDNS   bool          // reject domains without an _ocx-federation TXT pin
+	Scheme       string        // default https
+	HTTPClient   *http.Client
+	Resolver     DNSResolver // default net.DefaultResolver
+}
+
+// DiscoveredInstance is a verified discovery document and the endpoints
+// resolved for it.
+type DiscoveredInstance struct {
+	Domain        string              `json:"domain"`
+	Metadata      *FederationMetadata `json:"metadata"`
+	HandshakeAddr string              `json:"handshake_addr,omitempty"`
+	MessageAddr   string              `json:"message_addr,omitempty"`
+	DNSPinned     bool                `json:"dns_pinned"`
+	FetchedAt     time.Time           `json:"fetched_at"`
+	ExpiresAt     time.Time           `json:"expires_at"`
+}
+
+// Instance returns the registry entry for the discovered instance.
+func (d *DiscoveredInstance) Instance() *OCXInstance {
+	return &OCXInstance{
+		InstanceID:   string(d.Metadata.InstanceID),
+		TrustDomain:  d.Metadata.TrustDomain,
+		Region:       d.Metadata.Region,
+		Organization: d.Metadata.Organization,
+		GRPCAddr:     d.HandshakeAddr,
+		Domain:       d.Domain,
+		MessageAddr:  d.MessageAddr,
+		PublicKey:    d.Metadata.SigningKey().PublicKey,
+		Algorithms:   d.Metadata.Algorithms,
+	}
+}
+
+// FederationDiscovery resolves, verifies and caches peers' discovery
+// documents by domain.
+type FederationDiscovery struct {
+	cfg         DiscoveryConfig
+	client      *http.Client
+	resolver    DNSResolver
+	revocations RevocationChecker
+
+	mu    sync.RWMutex
+	cache map[string]*DiscoveredInstance // domain → last verified result
+}
+
+// NewFederationDiscovery creates a discovery client. Zero values take the
+// defaults: 15m cache, 5s fetch, https, the system resolver, DNS optional.
+func NewFederationDiscovery(cfg DiscoveryConfig) *FederationDiscovery {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 15 * time.Minute
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = 5 * time.Second
+	}
+	if cfg.Scheme == "" {
+		cfg.Scheme = "https"
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: cfg.FetchTimeout}
+	}
+	resolver := cfg.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &FederationDiscovery{
+		cfg:      cfg,
+		client:   client,
+		resolver: resolver,
+		cache:    make(map[string]*DiscoveredInstance),
+	}
+}
+
+// SetRevocationChecker rejects documents naming a revoked instance or key.
+func (d *FederationDiscovery) SetRevocationChecker(rc RevocationChecker) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.revocations = rc
+}
+
+// Resolve returns the verified instance for domain, refetching once the
+// cache has expired. A failed refetch keeps serving the previous result
+// until its document expires.
+func (d *FederationDiscovery) Resolve(ctx context.Context, domain string) (*DiscoveredInstance, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.RLock()
+	cached := d.cache[domain]
+	d.mu.RUnlock()
+	now := time.Now()
+	if cached != nil && now.Before(cached.ExpiresAt) {
+		return cached, nil
+	}
+
+	di, err := d.discover(ctx, domain, cached)
+	if err != nil {
+		if cached != nil && !errors.Is(err, ErrRevoked) && now.Before(cached.Metadata.ExpiresAt) {
+			slog.Warn("[Discovery] refresh failed, serving cached metadata", "domain", domain, "error", err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	d.mu.Lock()
+	d.cache[domain] = di
+	d.mu.Unlock()
+	return di, nil
+}
+
+// Cached returns every cached result, sorted by domain.
+func (d *FederationDiscovery) Cached() []*DiscoveredInstance {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]*DiscoveredInstance, 0, len(d.cache))
+	for _, di := range d.cache {
+		out = append(out, di)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+// Forget drops domain from the cache.
+func (d *FederationDiscovery) Forget(domain string) {
+	if domain, err := normalizeDomain(domain); err == nil {
+		d.mu.Lock()
+		delete(d.cache, domain)
+		d.mu.Unlock()
+	}
+}
+
+func (d *FederationDiscovery) discover(ctx context.Context, domain string, previous *DiscoveredInstance) (*DiscoveredInstance, error) {
+	pin, err := d.lookupPin(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	m, err := d.fetch(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := m.Verify(now); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(m.Domain, domain) {
+		return nil, fmt.Errorf("%w: document is for %s, fetched from %s", ErrInvalidMetadata, m.Domain, domain)
+	}
+	signer := m.SigningKey()
+	if pin != nil {
+		if pin.instanceID != m.InstanceID {
+			return nil, fmt.Errorf("%w: DNS pins %s, document names %s", ErrInvalidMetadata, pin.instanceID, m.InstanceID)
+		}
+		if !pin.fingerprints[signer.Fingerprint] {
+			return nil, fmt.Errorf("%w: signing key %s is not pinned in DNS", ErrInvalidMetadata, signer.Fingerprint)
+		}
+	}
+	if previous != nil {
+		// Without DNS the first key seen is trusted; a later document may
+		// not swap the instance or rotate keys behind our back.
+		if previous.Metadata.InstanceID != m.InstanceID {
+			return nil, fmt.Errorf("%w: %s now claims instance %s (was %s)", ErrInvalidMetadata, domain, m.InstanceID, previous.Metadata.InstanceID)
+		}
+		if pin == nil && previous.Metadata.SigningKey().Fingerprint != signer.Fingerprint {
+			return nil, fmt.Errorf("%w: signing key of %s changed without a DNS pin", ErrInvalidMetadata, domain)
+		}
+	}
+
+	d.mu.RLock()
+	rc := d.revocations
+	d.mu.RUnlock()
+	if err := checkInstanceRevoked(rc, string(m.InstanceID)); err != nil {
+		return nil, err
+	}
+	for _, k := range m.PublicKeys {
+		if err := checkKeyRevoked(rc, k.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	di := &DiscoveredInstance{
+		Domain:        domain,
+		Metadata:      m,
+		HandshakeAddr: m.Endpoints.Handshake,
+		MessageAddr:   m.Endpoints.Messages,
+		DNSPinned:     pin != nil,
+		FetchedAt:     now,
+		ExpiresAt:     now.Add(d.cfg.CacheTTL),
+	}
+	if di.HandshakeAddr == "" {
+		di.HandshakeAddr = d.lookupSRV(ctx, "ocx-handshake", domain)
+	}
+	if di.MessageAddr == "" {
+		di.MessageAddr = d.lookupSRV(ctx, "ocx-messages", domain)
+	}
+	if !m.ExpiresAt.IsZero() && m.ExpiresAt.Before(di.ExpiresAt) {
+		di.ExpiresAt = m.ExpiresAt
+	}
+	slog.Info("[Discovery] verified federation metadata", "domain", domain, "instance_id", m.InstanceID,
+		"dns_pinned", di.DNSPinned, "handshake", di.HandshakeAddr, "messages", di.MessageAddr)
+	return di, nil
+}
+
+// lookupPin reads the domain's TXT pin. A missing record is only an error
+// when RequireDNS is set.
+func (d *FederationDiscovery) lookupPin(ctx context.Context, domain string) (*dnsPin, error) {
+	records, err := d.resolver.LookupTXT(ctx, federationTXTPrefix+dnsName(domain))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			if d.cfg.RequireDNS {
+				return nil, fmt.Errorf("look up %s%s: %w", federationTXTPrefix, domain, err)
+			}
+			slog.Warn("[Discovery] TXT lookup failed, continuing without DNS pin", "domain", domain, "error", err)
+		}
+		records = nil
+	}
+	pin, err := parseFederationTXT(records)
+	if err != nil {
+		return nil, err
+	}
+	if pin == nil && d.cfg.RequireDNS {
+		return nil, fmt.Errorf("%w: no %s%s TXT record", ErrInvalidMetadata, federationTXTPrefix, domain)
+	}
+	return pin, nil
+}
+
+// lookupSRV returns host:port of the preferred SRV target, or "".
+func (d *FederationDiscovery) lookupSRV(ctx context.Context, service, domain string) string {
+	_, addrs, err := d.resolver.LookupSRV(ctx, service, "tcp", dnsName(domain))
+	if err != nil || len(addrs) == 0 {
+		return ""
+	}
+	return net.JoinHostPort(strings.TrimSuffix(addrs[0].Target, "."), fmt.Sprint(addrs[0].Port))
+}
+
+func (d *FederationDiscovery) fetch(ctx context.Context, domain string) (*FederationMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.FetchTimeout)
+	defer cancel()
+	rawURL := d.cfg.Scheme + "://" + domain + WellKnownFederationPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: status %d", rawURL, resp.StatusCode)
+	}
+	var m FederationMetadata
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxMetadataBytes)).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", rawURL, err)
+	}
+	return &m, nil
+}
+
+// normalizeDomain lower-cases a domain (optionally host:port) and rejects
+// URLs.
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if domain == "" || strings.ContainsAny(domain, "/?#@ ") {
+		return "", fmt.Errorf("invalid federation domain %q", domain)
+	}
+	return domain, nil
+}
+
+// dnsName strips a port from a domain for DNS lookups.
+func dnsName(domain string) string {
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		return host
+	}
+	return domain
+}
+
+// ============================================================================
+// REGISTRY AND MANAGER INTEGRATION
+// ============================================================================
+
+// SetDiscovery enables Discover. Discovered documents are checked against
+// the registry's revocation list.
+func (fr *FederationRegistry) SetDiscovery(d *FederationDiscovery) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.discovery = d
+	if fr.revocations != nil {
+		d.SetRevocationChecker(fr.revocations)
+	}
+}
+
+// OnDiscovered registers a callback run before Discover registers an
+// instance. An error from any callback aborts the registration.
+func (fr *FederationRegistry) OnDiscovered(fn func(*DiscoveredInstance) error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.discoveryListeners = append(fr.discoveryListeners, fn)
+}
+
+// Discover resolves and verifies the instance published at domain and
+// registers it.
+func (fr *FederationRegistry) Discover(ctx context.Context, domain string) (*DiscoveredInstance, error) {
+	fr.mu.RLock()
+	d := fr.discovery
+	listeners := append([]func(*DiscoveredInstance) error{}, fr.discoveryListeners...)
+	fr.mu.RUnlock()
+	if d == nil {
+		return nil, errors.New("federation discovery not configured")
+	}
+
+	di, err := d.Resolve(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	fr.mu.RLock()
+	err = fr.checkDiscoveredIdentity(di)
+	fr.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range listeners {
+		if err := fn(di); err != nil {
+			return nil, err
+		}
+	}
+
+	instance := di.Instance()
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if err := fr.checkDiscoveredIdentity(di); err != nil {
+		return nil, err
+	}
+	if fr.revocations != nil {
+		if err := checkInstanceRevoked(fr.revocations, instance.InstanceID); err != nil {
+			return nil, err
+		}
+	}
+	fr.instances[instance.InstanceID] = instance
+	slog.Info("[Discovery] registered instance", "instance_id", instance.InstanceID, "domain", di.Domain)
+	return di, nil
+}
+
+// checkDiscoveredIdentity refuses a document whose instance ID is already
+// registered from another domain, or was configured with a key the document
+// does not list. A domain rediscovering its own instance may rotate keys;
+// FederationDiscovery already holds it to its DNS pin or first key. The
+// caller holds fr.mu.
+func (fr *FederationRegistry) checkDiscoveredIdentity(di *DiscoveredInstance) error {
+	existing := fr.instances[string(di.Metadata.InstanceID)]
+	if existing == nil {
+		return nil
+	}
+	if existing.Domain != "" {
+		if existing.Domain != di.Domain {
+			return fmt.Errorf("%w: %s is registered from %s, %s claims it", ErrInstanceConflict,
+				di.Metadata.InstanceID, existing.Domain, di.Domain)
+		}
+		return nil
+	}
+	if len(existing.PublicKey) > 0 && !di.Metadata.listsKey(existing.PublicKey) {
+		return fmt.Errorf("%w: %s is configured with key %s, which %s does not list", ErrInstanceConflict,
+			di.Metadata.InstanceID, KeyFingerprint(existing.PublicKey), di.Domain)
+	}
+	return nil
+}
+
+// listsKey reports whether key is one of the document's public keys.
+func (m *FederationMetadata) listsKey(key []byte) bool {
+	fp := KeyFingerprint(key)
+	for _, k := range m.PublicKeys {
+		if k.Fingerprint == fp {
+			return true
+		}
+	}
+	return false
+}
+
+// Discovered returns the cached discovery results.
+func (fr *FederationRegistry) Discovered() []*DiscoveredInstance {
+	fr.mu.RLock()
+	d := fr.discovery
+	fr.mu.RUnlock()
+	if d == nil {
+		return nil
+	}
+	return d.Cached()
+}
+
+// RunDiscoveryRefresh discovers domains now and then re-resolves them and
+// every other cached domain each interval until ctx ends.
+func (fr *FederationRegistry) RunDiscoveryRefresh(ctx context.Context, domains []string, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	refresh := func() {
+		seen := map[string]bool{}
+		for _, di := range fr.Discovered() {
+			seen[di.Domain] = true
+		}
+		for _, domain := range domains {
+			seen[domain] = true
+		}
+		for domain := range seen {
+			if _, err := fr.Discover(ctx, domain); err != nil {
+				slog.Warn("[Discovery] refresh failed", "domain", domain, "error", err)
+			}
+		}
+	}
+
+	refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// PinPeer binds a discovered instance to the manager: its HELLO and
+// CHALLENGE must present one of the listed keys, and its advertised
+// message endpoint is used once it connects. An instance already pinned
+// from another domain, or connected with a key the document does not list,
+// is refused rather than re-pinned.
+func (fm *FederationManager) PinPeer(di *DiscoveredInstance) error {
+	id := di.Metadata.InstanceID
+	fm.mu.Lock()
+	if prev := fm.pinned[id]; prev != nil && prev.Domain != di.Domain {
+		fm.mu.Unlock()
+		return fmt.Errorf("%w: %s is pinned to %s, %s claims it", ErrInstanceConflict, id, prev.Domain, di.Domain)
+	}
+	peer := fm.peers[id]
+	var attestation *Attestation
+	if peer != nil {
+		peer.mu.RLock()
+		attestation = peer.Attestation
+		peer.mu.RUnlock()
+	}
+	if attestation != nil && !di.Metadata.listsKey(attestation.PublicKey) {
+		fm.mu.Unlock()
+		return fmt.Errorf("%w: %s is connected with a key %s does not list", ErrInstanceConflict, id, di.Domain)
+	}
+	fm.pinned[id] = di
+	fm.mu.Unlock()
+
+	if peer != nil && di.MessageAddr != "" {
+		peer.mu.Lock()
+		peer.Endpoint = di.MessageAddr
+		peer.mu.Unlock()
+	}
+	return nil
+}
+
+// checkPinnedKey rejects an attestation whose key the peer's discovery
+// document does not list. Peers that were not discovered are not pinned.
+func (fm *FederationManager) checkPinnedKey(id OCXInstanceID, att *Attestation) error {
+	fm.mu.RLock()
+	di := fm.pinned[id]
+	fm.mu.RUnlock()
+	if di == nil {
+		return nil
+	}
+	if di.Metadata.listsKey(att.PublicKey) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w (%s)", id, ErrPeerKeyMismatch, di.Domain)
+}
+
+// pinnedEndpoint returns the discovered message endpoint for id. The
+// caller holds fm.mu.
+func (fm *FederationManager) pinnedEndpoint(id OCXInstanceID) string {
+	if di := fm.pinned[id]; di != nil {
+		return di.MessageAddr
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// FEDERATION DISCOVERY TESTS
+// ============================================================================
+
+type fakeResolver struct {
+	txt map[string][]string
+	srv map[string][]*net.SRV // "_service._proto.name"
+}
+
+func (r *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if recs, ok := r.txt[name]; ok {
+		return recs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	key := "_" + service + "._" + proto + "." + name
+	if recs, ok := r.srv[key]; ok {
+		return key, recs, nil
+	}
+	return "", nil, &net.DNSError{Err: "no such host", Name: key, IsNotFound: true}
+}
+
+// metadataServer serves whatever document publish returns at the
+// well-known path. The domain is the server's host:port.
+type metadataServer struct {
+	mu      sync.Mutex
+	publish func(domain string) *FederationMetadata
+	srv     *httptest.Server
+}
+
+func newMetadataServer(t *testing.T, publish func(domain string) *FederationMetadata) (*metadataServer, string) {
+	ms := &metadataServer{publish: publish}
+	ms.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != WellKnownFederationPath {
+			http.NotFound(w, r)
+			return
+		}
+		ms.mu.Lock()
+		m := ms.publish(r.Host)
+		ms.mu.Unlock()
+		json.NewEncoder(w).Encode(m)
+	}))
+	t.Cleanup(ms.srv.Close)
+	return ms, strings.TrimPrefix(ms.srv.URL, "http://")
+}
+
+func (ms *metadataServer) set(publish func(domain string) *FederationMetadata) {
+	ms.mu.Lock()
+	ms.publish = publish
+	ms.mu.Unlock()
+}
+
+func publishFrom(t *testing.T, fm *FederationManager) func(string) *FederationMetadata {
+	return func(domain string) *FederationMetadata {
+		m, err := fm.SignedMetadata(MetadataPublication{Domain: domain, Organization: "Partner Corp"})
+		require.NoError(t, err)
+		return m
+	}
+}
+
+func TestDiscoveryResolvesAndPinsPeer(t *testing.T) {
+	a, err := NewFederationManager(FederationConfig{InstanceID: "ocx-a", MaxPeers: 4})
+	require.NoError(t, err)
+	b, err := NewFederationManager(FederationConfig{InstanceID: "ocx-b", Region: "eu-west-1", MaxPeers: 4})
+	require.NoError(t, err)
+
+	_, domain := newMetadataServer(t, publishFrom(t, b))
+	published, err := b.SignedMetadata(MetadataPublication{Domain: domain})
+	require.NoError(t, err)
+	resolver := &fakeResolver{
+		txt: map[string][]string{"_ocx-federation.127.0.0.1": {"unrelated", FederationTXTRecord(published)}},
+		srv: map[string][]*net.SRV{"_ocx-messages._tcp.127.0.0.1": {{Target: "msg.partner.example.", Port: 7443}}},
+	}
+
+	registry := NewFederationRegistry()
+	registry.SetDiscovery(NewFederationDiscovery(DiscoveryConfig{Scheme: "http", Resolver: resolver, RequireDNS: true}))
+	registry.OnDiscovered(a.PinPeer)
+
+	di, err := registry.Discover(context.Background(), domain)
+	require.NoError(t, err)
+	assert.True(t, di.DNSPinned)
+	assert.Equal(t, "msg.partner.example:7443", di.MessageAddr)
+	assert.Equal(t, "Partner Corp", di.Metadata.Organization)
+	assert.ElementsMatch(t, SupportedCryptoAlgorithms(), di.Metadata.Algorithms)
+
+	instance, err := registry.Lookup("ocx-b")
+	require.NoError(t, err)
+	assert.Equal(t, domain, instance.Domain)
+	assert.Equal(t, "eu-west-1", instance.Region)
+	assert.Equal(t, b.crypto.PublicKeyBytes(), instance.PublicKey)
+	require.Len(t, registry.Discovered(), 1)
+
+	// An impostor claiming ocx-b with another key is refused by the
+	// manager that pinned the discovered key...
+	impostor, err := NewFederationManager(FederationConfig{InstanceID: "ocx-b", MaxPeers: 4})
+	require.NoError(t, err)
+	forged, err := impostor.CreateAttestation(0, 0)
+	require.NoError(t, err)
+	_, err = a.ProcessHandshakeMessage(&HandshakeMessage{
+		Type: HandshakeHello, InstanceID: "ocx-b", Nonce: []byte("n"), Attestation: forged, Timestamp: time.Now(),
+	})
+	assert.True(t, errors.Is(err, ErrPeerKeyMismatch), "got %v", err)
+
+	// ...while the real ocx-b connects and gets its discovered endpoint.
+	genuine, err := b.CreateAttestation(0, 0)
+	require.NoError(t, err)
+	challenge, err := a.ProcessHandshakeMessage(&HandshakeMessage{
+		Type: HandshakeHello, InstanceID: "ocx-b", Nonce: []byte("n"), Attestation: genuine, Timestamp: time.Now(),
+	})
+	require.NoError(t, err)
+	response, err := b.ProcessHandshakeMessage(challenge)
+	require.NoError(t, err)
+	_, err = a.ProcessHandshakeMessage(response)
+	require.NoError(t, err)
+	peer, err := a.GetPeer("ocx-b")
+	require.NoError(t, err)
+	assert.Equal(t, "msg.partner.example:7443", peer.Endpoint)
+}
+
+func TestDiscoveryRejectsUnverifiableMetadata(t *testing.T) {
+	ctx := context.Background()
+	b, err := NewFederationManager(FederationConfig{InstanceID: "ocx-b", MaxPeers: 4})
+	require.NoError(t, err)
+	other, err := NewFederationManager(FederationConfig{InstanceID: "ocx-b", MaxPeers: 4})
+	require.NoError(t, err)
+	server, domain := newMetadataServer(t, publishFrom(t, b))
+	noDNS := &fakeResolver{}
+
+	newDiscovery := func(cfg DiscoveryConfig) *FederationDiscovery {
+		cfg.Scheme = "http"
+		if cfg.Resolver == nil {
+			cfg.Resolver = noDNS
+		}
+		return NewFederationDiscovery(cfg)
+	}
+
+	// DNS pins a key the document is not signed with
+	otherMeta, err := other.SignedMetadata(MetadataPublication{Domain: domain})
+	require.NoError(t, err)
+	wrongPin := &fakeResolver{txt: map[string][]string{"_ocx-federation.127.0.0.1": {FederationTXTRecord(otherMeta)}}}
+	_, err = newDiscovery(DiscoveryConfig{Resolver: wrongPin}).Resolve(ctx, domain)
+	assert.True(t, errors.Is(err, ErrInvalidMetadata), "got %v", err)
+
+	// DNS required but absent
+	_, err = newDiscovery(DiscoveryConfig{RequireDNS: true}).Resolve(ctx, domain)
+	assert.True(t, errors.Is(err, ErrInvalidMetadata), "got %v", err)
+
+	// Altered after signing
+	server.set(func(d string) *FederationMetadata {
+		m := publishFrom(t, b)(d)
+		m.Endpoints.Messages = "attacker.example:443"
+		return m
+	})
+	_, err = newDiscovery(DiscoveryConfig{}).Resolve(ctx, domain)
+	assert.True(t, errors.Is(err, ErrInvalidMetadata), "got %v", err)
+
+	// Signed for another domain
+	server.set(func(string) *FederationMetadata { return publishFrom(t, b)("ocx.elsewhere.example") })
+	_, err = newDiscovery(DiscoveryConfig{}).Resolve(ctx, domain)
+	assert.True(t, errors.Is(err, ErrInvalidMetadata), "got %v", err)
+
+	// Without DNS the first key is kept: a swapped key is refused and the
+	// cached document keeps being served until it expires.
+	server.set(publishFrom(t, b))
+	d := newDiscovery(DiscoveryConfig{CacheTTL: time.Nanosecond})
+	first, err := d.Resolve(ctx, domain)
+	require.NoError(t, err)
+	server.set(publishFrom(t, other))
+	_, err = d.discover(ctx, domain, first)
+	assert.True(t, errors.Is(err, ErrInvalidMetadata), "got %v", err)
+	again, err := d.Resolve(ctx, domain)
+	require.NoError(t, err)
+	assert.Equal(t, first.Metadata.SigningKey().Fingerprint, again.Metadata.SigningKey().Fingerprint)
+
+	// Revoked instances are not registered
+	server.set(publishFrom(t, b))
+	revocations := NewRevocationRegistry("ocx-a")
+	revocations.RevokeInstance("ocx-b", "contract ended")
+	registry := NewFederationRegistry()
+	registry.SetRevocations(revocations)
+	registry.SetDiscovery(newDiscovery(DiscoveryConfig{}))
+	_, err = registry.Discover(ctx, domain)
+	assert.True(t, errors.Is(err, ErrRevoked), "got %v", err)
+}
+
+func TestDiscoveryRejectsInstanceIDClaimedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	a, err := NewFederationManager(FederationConfig{InstanceID: "ocx-a", MaxPeers: 4})
+	require.NoError(t, err)
+	b, err := NewFederationManager(FederationConfig{InstanceID: "ocx-b", MaxPeers: 4})
+	require.NoError(t, err)
+	impostor, err := NewFederationManager(FederationConfig{InstanceID: "ocx-b", MaxPeers: 4})
+	require.NoError(t, err)
+	c, err := NewFederationManager(FederationConfig{InstanceID: "ocx-c", MaxPeers: 4})
+	require.NoError(t, err)
+	impostorC, err := NewFederationManager(FederationConfig{InstanceID: "ocx-c", MaxPeers: 4})
+	require.NoError(t, err)
+
+	_, genuineDomain := newMetadataServer(t, publishFrom(t, b))
+	_, impostorDomain := newMetadataServer(t, publishFrom(t, impostor))
+
+	registry := NewFederationRegistry()
+	registry.SetDiscovery(NewFederationDiscovery(DiscoveryConfig{Scheme: "http", Resolver: &fakeResolver{}}))
+	registry.OnDiscovered(a.PinPeer)
+
+	_, err = registry.Discover(ctx, genuineDomain)
+	require.NoError(t, err)
+
+	// Another domain publishing a valid document for ocx-b neither
+	// re-registers nor re-pins it
+	_, err = registry.Discover(ctx, impostorDomain)
+	assert.True(t, errors.Is(err, ErrInstanceConflict), "got %v", err)
+	instance, err := registry.Lookup("ocx-b")
+	require.NoError(t, err)
+	assert.Equal(t, genuineDomain, instance.Domain)
+	assert.Equal(t, b.crypto.PublicKeyBytes(), instance.PublicKey)
+
+	forged, err := impostor.CreateAttestation(0, 0)
+	require.NoError(t, err)
+	_, err = a.ProcessHandshakeMessage(&HandshakeMessage{
+		Type: HandshakeHello, InstanceID: "ocx-b", Nonce: []byte("n"), Attestation: forged, Timestamp: time.Now(),
+	})
+	assert.True(t, errors.Is(err, ErrPeerKeyMismatch), "got %v", err)
+
+	// The manager refuses the re-pin on its own as well
+	resolved, err := NewFederationDiscovery(DiscoveryConfig{Scheme: "http", Resolver: &fakeResolver{}}).Resolve(ctx, impostorDomain)
+	require.NoError(t, err)
+	assert.True(t, errors.Is(a.PinPeer(resolved), ErrInstanceConflict))
+
+	// A statically configured instance keeps its key
+	require.NoError(t, registry.Register(&OCXInstance{InstanceID: "ocx-c", PublicKey: c.crypto.PublicKeyBytes()}))
+	_, impostorCDomain := newMetadataServer(t, publishFrom(t, impostorC))
+	_, err = registry.Discover(ctx, impostorCDomain)
+	assert.True(t, errors.Is(err, ErrInstanceConflict), "got %v", err)
+
+	_, cDomain := newMetadataServer(t, publishFrom(t, c))
+	_, err = registry.Discover(ctx, cDomain)
+	require.NoError(t, err)
+}
+
+func TestParseFederationTXT(t *testing.T) {
+	pin, err := parseFederationTXT([]string{"v=ocx1; id=ocx-b; fp=AA", "v=ocx1;fp=bb", "v=spf1 -all"})
+	require.NoError(t, err)
+	require.NotNil(t, pin)
+	assert.Equal(t, OCXInstanceID("ocx-b"), pin.instanceID)
+	assert.True(t, pin.fingerprints["aa"])
+	assert.True(t, pin.fingerprints["bb"])
+
+	pin, err = parseFederationTXT([]string{"v=spf1 -all"})
+	require.NoError(t, err)
+	assert.Nil(t, pin)
+
+	_, err = parseFederationTXT([]string{"v=ocx1; id=ocx-b; fp=aa", "v=ocx1; id=ocx-c; fp=bb"})
+	assert.Error(t, err)
+}
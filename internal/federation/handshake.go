@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	pb "github.com/ocx/backend/pb"
@@ -23,6 +24,12 @@ type OCXInstance struct {
 	// P3 FIX: GRPCAddr is the remote gRPC endpoint for federation handshake.
 	// If set, NegotiateV2 uses real gRPC transport. If empty, falls back to in-memory.
 	GRPCAddr string
+
+	// Set for instances found through discovery (discovery.go).
+	Domain      string
+	MessageAddr string
+	PublicKey   []byte
+	Algorithms  []CryptoAlgorithm // algorithms the instance verifies; empty = unknown
 }
 
 // TrustAttestation represents proof of audit completion
@@ -44,6 +51,9 @@ type InterOCXHandshake struct {
 	remoteOCX  *OCXInstance
 	trustLevel float64
 	ledger     *TrustAttestationLedger
+
+	revocations RevocationChecker
+	algorithms  []CryptoAlgorithm
 }
 
 // NewInterOCXHandshake creates a new handshake session
@@ -55,6 +65,17 @@ func NewInterOCXHandshake(local, remote *OCXInstance, ledger *TrustAttestationLe
 	}
 }
 
+// SetRevocationChecker rejects a revoked remote instance, key or certificate.
+func (h *InterOCXHandshake) SetRevocationChecker(rc RevocationChecker) {
+	h.revocations = rc
+}
+
+// SetAlgorithmPreference sets the signing algorithms to negotiate, most
+// preferred first; see HandshakeSession.SetAlgorithmPreference.
+func (h *InterOCXHandshake) SetAlgorithmPreference(algs ...CryptoAlgorithm) {
+	h.algorithms = algs
+}
+
 // NegotiateV2 performs the full 6-step Inter-OCX handshake.
 // P3 FIX: When the remote OCX has a GRPCAddr, this delegates to
 // HandshakeClient.PerformFullHandshake() for real gRPC transport.
@@ -70,6 +91,8 @@ func (h *InterOCXHandshake) NegotiateV2(ctx context.Context, agentID string) (*p
 			// Fall through to in-memory simulation below
 		} else {
 			defer client.Close()
+			client.SetRevocationChecker(h.revocations)
+			client.SetAlgorithmPreference(h.algorithms, h.remoteOCX.Algorithms)
 
 			result, err := client.PerformFullHandshake(ctx, h.remoteOCX.InstanceID, agentID)
 			if err != nil {
@@ -87,6 +110,8 @@ func (h *InterOCXHandshake) NegotiateV2(ctx context.Context, agentID string) (*p
 
 	// In-memory simulation (dev/test fallback)
 	session := NewHandshakeSession(h.localOCX, h.remoteOCX, h.ledger)
+	session.SetRevocationChecker(h.revocations)
+	session.SetAlgorithmPreference(h.algorithms...)
 
 	// Step 1: HELLO
 	hello, err := session.SendHello(ctx)
@@ -159,8 +184,13 @@ func (o *OCXInstance) GetAuditHash(agentID string) (string, error) {
 
 // FederationRegistry maintains directory of trusted OCX instances
 type FederationRegistry struct {
+	mu             sync.RWMutex
 	instances      map[string]*OCXInstance
 	handshakeStore *SupabaseHandshakeStore // optional durable store
+	revocations    *RevocationRegistry     // optional revocation list
+
+	discovery          *FederationDiscovery // discovery.go
+	discoveryListeners []func(*DiscoveredInstance) error
 }
 
 // NewFederationRegistry creates a new registry
@@ -175,19 +205,70 @@ func (fr *FederationRegistry) SetHandshakeStore(s *SupabaseHandshakeStore) {
 	fr.handshakeStore = s
 }
 
+// SetRevocations makes Register and Lookup refuse revoked instances.
+func (fr *FederationRegistry) SetRevocations(r *RevocationRegistry) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.revocations = r
+	if fr.discovery != nil {
+		fr.discovery.SetRevocationChecker(r)
+	}
+}
+
+// Revocations returns the registry's revocation list, or nil.
+func (fr *FederationRegistry) Revocations() *RevocationRegistry {
+	fr.mu.RLock()
+	defer fr.mu.RUnlock()
+	return fr.revocations
+}
+
 // Register adds an OCX instance to the federation
 func (fr *FederationRegistry) Register(instance *OCXInstance) error {
 	if instance.InstanceID == "" {
 		return errors.New("instance ID required")
 	}
 
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if fr.revocations != nil {
+		if err := checkInstanceRevoked(fr.revocations, instance.InstanceID); err != nil {
+			return err
+		}
+	}
+
 	fr.instances[instance.InstanceID] = instance
 	slog.Info("Registered OCX instance:", "instance_i_d", instance.InstanceID, "organization", instance.Organization)
 	return nil
 }
 
+// Revoke revokes an instance and removes it from the directory. Peers
+// connected to a FederationManager sharing the revocation list are torn
+// down.
+func (fr *FederationRegistry) Revoke(instanceID, reason string) (RevocationEntry, error) {
+	fr.mu.Lock()
+	r := fr.revocations
+	if r != nil {
+		delete(fr.instances, instanceID)
+	}
+	fr.mu.Unlock()
+
+	if r == nil {
+		return RevocationEntry{}, errors.New("no revocation list configured")
+	}
+	return r.RevokeInstance(OCXInstanceID(instanceID), reason), nil
+}
+
 // Lookup retrieves an OCX instance by ID
 func (fr *FederationRegistry) Lookup(instanceID string) (*OCXInstance, error) {
+	fr.mu.RLock()
+	defer fr.mu.RUnlock()
+
+	if fr.revocations != nil {
+		if err := checkInstanceRevoked(fr.revocations, instanceID); err != nil {
+			return nil, err
+		}
+	}
+
 	instance, ok := fr.instances[instanceID]
 	if !ok {
 		return nil, fmt.Errorf("instance not found: %s", instanceID)
@@ -198,6 +279,8 @@ func (fr *FederationRegistry) Lookup(instanceID string) (*OCXInstance, error) {
 
 // ListInstances returns all registered instances
 func (fr *FederationRegistry) ListInstances() []*OCXInstance {
+	fr.mu.RLock()
+	defer fr.mu.RUnlock()
 	instances := make([]*OCXInstance, 0, len(fr.instances))
 	for _, instance := range fr.instances {
 		instances = append(instances, instance)
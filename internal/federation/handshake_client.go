@@ -20,8 +20,11 @@ type HandshakeClient struct {
 	conn   *grpc.ClientConn
 	client pb.InterOCXHandshakeServiceClient
 
-	localAgent *OCXInstance
-	ledger     *TrustAttestationLedger
+	localAgent  *OCXInstance
+	ledger      *TrustAttestationLedger
+	revocations RevocationChecker
+	algorithms  []CryptoAlgorithm
+	remoteAlgs  []CryptoAlgorithm
 }
 
 // NewHandshakeClient creates a new handshake client
@@ -40,6 +43,19 @@ func NewHandshakeClient(serverAddr string, localAgent *OCXInstance, ledger *Trus
 	}, nil
 }
 
+// SetRevocationChecker rejects a revoked remote instance, key or certificate.
+func (c *HandshakeClient) SetRevocationChecker(rc RevocationChecker) {
+	c.revocations = rc
+}
+
+// SetAlgorithmPreference sets the local algorithm preference and, when
+// known (e.g. from discovery), the algorithms the remote supports, so
+// SendHello can negotiate the signing algorithm.
+func (c *HandshakeClient) SetAlgorithmPreference(local, remote []CryptoAlgorithm) {
+	c.algorithms = local
+	c.remoteAlgs = remote
+}
+
 // Close closes the gRPC connection
 func (c *HandshakeClient) Close() error {
 	return c.conn.Close()
@@ -51,10 +67,13 @@ func (c *HandshakeClient) PerformFullHandshake(ctx context.Context, remoteAgentI
 	// Create remote agent placeholder
 	remoteAgent := &OCXInstance{
 		InstanceID: remoteAgentID,
+		Algorithms: c.remoteAlgs,
 	}
 
 	// Create handshake session
 	session := NewHandshakeSession(c.localAgent, remoteAgent, c.ledger)
+	session.SetRevocationChecker(c.revocations)
+	session.SetAlgorithmPreference(c.algorithms...)
 
 	// ========================================================================
 	// STEP 1: Send HELLO
@@ -135,8 +154,10 @@ func (c *HandshakeClient) PerformStreamingHandshake(ctx context.Context, remoteA
 	}
 
 	// Create session
-	remoteAgent := &OCXInstance{InstanceID: remoteAgentID}
+	remoteAgent := &OCXInstance{InstanceID: remoteAgentID, Algorithms: c.remoteAlgs}
 	session := NewHandshakeSession(c.localAgent, remoteAgent, c.ledger)
+	session.SetRevocationChecker(c.revocations)
+	session.SetAlgorithmPreference(c.algorithms...)
 
 	// Step 1: Send HELLO
 	hello, err := session.SendHello(ctx)
@@ -0,0 +1,253 @@
+package federation
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	pb "github.com/ocx/backend/pb"
+)
+
+// ============================================================================
+// HANDSHAKE RECOVERY
+//
+// HandshakeServiceServer saves each responder session to its
+// HandshakeSessionStore after every step. On startup Recover rebuilds the
+// sessions a crashed process left behind: those still within their timeout
+// and waiting for the peer's next message (PROOF or ATTESTATION) are
+// resumed, everything else is expired with an audit event. Challenge nonces
+// go through a shared NonceStore, so a PROOF replayed after a restart, or
+// to another replica that resumed the same session, is rejected.
+// ============================================================================
+
+// HandshakeExpiredEvent is the CloudEvent type emitted for a stored
+// handshake expired at recovery.
+const HandshakeExpiredEvent = "ocx.federation.handshake_expired"
+
+// resumableStates are the responder states in which the peer's next
+// message can still arrive. Any other incomplete state was interrupted
+// mid-step and the peer already saw an error.
+var resumableStates = map[HandshakeState]bool{
+	StateChallengeSent: true, // awaiting PROOF
+	StateVerified:      true, // awaiting ATTESTATION
+}
+
+// Snapshot returns the session's persistable state.
+func (hs *HandshakeSession) Snapshot() *HandshakeSessionState {
+	state := hs.stateMachine.GetCurrentState()
+	snapshot := &HandshakeSessionState{
+		SessionID:       hs.sessionID,
+		LocalOCXID:      hs.localOCX.InstanceID,
+		RemoteOCXID:     hs.remoteOCX.InstanceID,
+		CurrentState:    int(state),
+		Nonce:           hs.nonce,
+		Challenge:       hex.EncodeToString(hs.challenge),
+		TrustLevel:      hs.trustLevel,
+		StartedAt:       hs.stateMachine.GetStartTime(),
+		LastStepAt:      hs.stateMachine.GetLastUpdateTime(),
+		StepCompleted:   stepCompleted(state),
+		Verdict:         verdictFor(state),
+		RemoteAlgorithm: string(hs.remoteAlgorithm),
+	}
+	if len(hs.remotePublicKey) > 0 {
+		if key, err := PublicKeyPEM(hs.remotePublicKey); err == nil {
+			snapshot.RemotePublicKey = key
+		}
+	}
+	return snapshot
+}
+
+// RestoreHandshakeSession rebuilds a session and its state machine from a
+// stored snapshot.
+func RestoreHandshakeSession(state *HandshakeSessionState, local *OCXInstance, ledger *TrustAttestationLedger) (*HandshakeSession, error) {
+	current := HandshakeState(state.CurrentState)
+	if current < StateInit || current > StateError {
+		return nil, fmt.Errorf("session %s: unknown handshake state %d", state.SessionID, state.CurrentState)
+	}
+	challenge, err := hex.DecodeString(state.Challenge)
+	if err != nil {
+		return nil, fmt.Errorf("session %s: invalid challenge: %w", state.SessionID, err)
+	}
+
+	session := NewHandshakeSession(local, &OCXInstance{InstanceID: state.RemoteOCXID}, ledger)
+	if state.RemotePublicKey != "" {
+		key, err := PublicKeyFromPEM(state.RemotePublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("session %s: invalid remote key: %w", state.SessionID, err)
+		}
+		alg := CryptoAlgorithm(state.RemoteAlgorithm)
+		if DetectKeyAlgorithm(key) != alg {
+			return nil, fmt.Errorf("session %s: remote key is %s, not %s", state.SessionID, DetectKeyAlgorithm(key), alg)
+		}
+		session.remoteAlgorithm = alg
+		session.remotePublicKey = key
+	}
+
+	session.stateMachine.cancel()
+	session.sessionID = state.SessionID
+	session.stateMachine = RestoreHandshakeStateMachine(state.SessionID, current, state.StartedAt, state.LastStepAt)
+	session.nonce = state.Nonce
+	session.challenge = challenge
+	session.trustLevel = state.TrustLevel
+	session.verdict = state.Verdict
+	return session, nil
+}
+
+// stepCompleted maps a state to the last of the six steps it completed.
+func stepCompleted(s HandshakeState) int {
+	switch s {
+	case StateInit:
+		return 0
+	case StateHelloSent, StateHelloReceived:
+		return 1
+	case StateChallengeSent, StateChallengeReceived:
+		return 2
+	case StateProofSent, StateProofReceived:
+		return 3
+	case StateVerified:
+		return 4
+	case StateAttestationSent, StateAttestationReceived:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// verdictFor is the stored verdict of a state; "" while incomplete.
+func verdictFor(s HandshakeState) string {
+	switch s {
+	case StateAccepted:
+		return "ACCEPTED"
+	case StateRejected:
+		return "REJECTED"
+	case StateTimeout:
+		return "EXPIRED"
+	case StateError:
+		return "ERROR"
+	default:
+		return ""
+	}
+}
+
+// HandshakeRecovery reports what Recover did with stored sessions.
+type HandshakeRecovery struct {
+	Resumed []string // session IDs
+	Expired []string
+}
+
+// Recover loads incomplete sessions of this instance from the store,
+// resumes those that can still complete and expires the rest.
+func (s *HandshakeServiceServer) Recover(ctx context.Context) (*HandshakeRecovery, error) {
+	report := &HandshakeRecovery{}
+	if s.store == nil {
+		return report, nil
+	}
+	states, err := s.store.ListIncomplete(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list incomplete handshakes: %w", err)
+	}
+
+	for _, state := range states {
+		if state.LocalOCXID != "" && state.LocalOCXID != s.localAgent.InstanceID {
+			continue // another instance sharing the store
+		}
+
+		session, err := RestoreHandshakeSession(state, s.localAgent, s.ledger)
+		var reason string
+		switch {
+		case err != nil:
+			reason = err.Error()
+		case session.stateMachine.CheckTimeout():
+			reason = "timed out"
+		case !resumableStates[HandshakeState(state.CurrentState)]:
+			reason = "interrupted in " + HandshakeState(state.CurrentState).String()
+		default:
+			if err := checkInstanceRevoked(s.revocations, state.RemoteOCXID); err != nil {
+				reason = err.Error()
+			}
+		}
+		if reason != "" {
+			s.expire(ctx, state, reason)
+			report.Expired = append(report.Expired, state.SessionID)
+			continue
+		}
+
+		session.SetRevocationChecker(s.revocations)
+		session.SetAlgorithmPreference(s.algorithms...)
+		session.SetNonceStore(s.nonces)
+		s.mu.Lock()
+		if _, exists := s.sessions[state.SessionID]; !exists {
+			s.sessions[state.SessionID] = session
+		}
+		s.mu.Unlock()
+		report.Resumed = append(report.Resumed, state.SessionID)
+		slog.Info("[HandshakeStore] Resumed handshake", "session_id", state.SessionID,
+			"remote_instance_id", state.RemoteOCXID, "state", HandshakeState(state.CurrentState).String())
+	}
+
+	slog.Info("[HandshakeStore] Recovered handshake sessions", "resumed", len(report.Resumed), "expired", len(report.Expired))
+	return report, nil
+}
+
+// expire marks a stored session EXPIRED and emits an audit event.
+func (s *HandshakeServiceServer) expire(ctx context.Context, state *HandshakeSessionState, reason string) {
+	interrupted := HandshakeState(state.CurrentState).String()
+	state.CurrentState = int(StateTimeout)
+	state.Verdict = verdictFor(StateTimeout)
+	if err := s.store.Save(ctx, state); err != nil {
+		slog.Warn("[HandshakeStore] Failed to mark handshake expired", "session_id", state.SessionID, "error", err)
+	}
+
+	slog.Warn("[HandshakeStore] Expired interrupted handshake", "session_id", state.SessionID,
+		"remote_instance_id", state.RemoteOCXID, "state", interrupted, "reason", reason)
+	if s.alerts != nil {
+		s.alerts.Emit(HandshakeExpiredEvent, "/federation/"+s.localAgent.InstanceID, state.SessionID, map[string]interface{}{
+			"remote_instance_id": state.RemoteOCXID,
+			"state":              interrupted,
+			"step_completed":     state.StepCompleted,
+			"started_at":         state.StartedAt.Format(time.RFC3339),
+			"reason":             reason,
+		})
+	}
+}
+
+// persist saves a session after a step, whether it succeeded or failed.
+func (s *HandshakeServiceServer) persist(ctx context.Context, session *HandshakeSession) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Save(context.WithoutCancel(ctx), session.Snapshot()); err != nil {
+		slog.Warn("[HandshakeStore] Save failed; session will not survive a restart",
+			"session_id", session.sessionID, "error", err)
+	}
+}
+
+// proofSession finds the session a PROOF answers: the one awaiting a PROOF
+// whose HELLO key verifies it over its challenge. Sessions without a HELLO
+// key are matched as before, by state alone.
+func (s *HandshakeServiceServer) proofSession(proof *pb.HandshakeProof) *HandshakeSession {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var fallback *HandshakeSession
+	for _, sess := range s.sessions {
+		if sess.stateMachine.GetCurrentState() != StateChallengeSent {
+			continue
+		}
+		if sess.remotePublicKey == nil {
+			if fallback == nil {
+				fallback = sess
+			}
+			continue
+		}
+		verifier, err := verifierFor(sess.remoteAlgorithm)
+		if err != nil {
+			continue
+		}
+		if ok, err := verifier.Verify(sess.remotePublicKey, sess.challenge, proof.Proof); err == nil && ok {
+			return sess
+		}
+	}
+	return fallback
+}
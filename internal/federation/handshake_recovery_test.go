@@ -0,0 +1,170 @@
+package federation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/ocx/backend/pb"
+)
+
+// ============================================================================
+// HANDSHAKE RECOVERY TESTS
+// ============================================================================
+
+var (
+	recoveryResponder = &OCXInstance{InstanceID: "ocx-responder", Organization: "Responder Org"}
+	recoveryInitiator = &OCXInstance{InstanceID: "ocx-initiator", Organization: "Initiator Org"}
+)
+
+// recoveryServer returns a responder sharing store and nonces with its
+// other replicas.
+func recoveryServer(store HandshakeSessionStore, nonces NonceStore, alerts *recordingEmitter) *HandshakeServiceServer {
+	s := NewHandshakeServiceServer(recoveryResponder, NewTrustAttestationLedgerWithID("ocx-responder"))
+	s.SetSessionStore(store)
+	s.SetNonceStore(nonces)
+	if alerts != nil {
+		s.SetEventEmitter(alerts)
+	}
+	return s
+}
+
+// challengedSession runs HELLO and CHALLENGE against s and returns the
+// initiator's PROOF and the responder's session ID.
+func challengedSession(t *testing.T, s *HandshakeServiceServer) (*pb.HandshakeProof, string) {
+	t.Helper()
+	ctx := context.Background()
+	initiator := NewHandshakeSession(recoveryInitiator, recoveryResponder, NewMockTrustAttestationLedger())
+	hello, err := initiator.SendHello(ctx)
+	require.NoError(t, err)
+	challenge, err := s.InitiateHandshake(ctx, hello)
+	require.NoError(t, err)
+	require.NoError(t, initiator.ReceiveChallenge(ctx, challenge))
+	proof, err := initiator.GenerateProof(ctx, "agent-1")
+	require.NoError(t, err)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	require.Len(t, s.sessions, 1)
+	for id := range s.sessions {
+		return proof, id
+	}
+	return nil, ""
+}
+
+func TestHandshakeRecoveryResumesSession(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryHandshakeStore()
+	nonces := NewInMemoryNonceStore(time.Minute)
+
+	proof, sessionID := challengedSession(t, recoveryServer(store, nonces, nil))
+
+	// The process restarts; two replicas recover the same session
+	b := recoveryServer(store, nonces, nil)
+	report, err := b.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{sessionID}, report.Resumed)
+	assert.Empty(t, report.Expired)
+	c := recoveryServer(store, nonces, nil)
+	_, err = c.Recover(ctx)
+	require.NoError(t, err)
+
+	verify, err := b.RespondToChallenge(ctx, proof)
+	require.NoError(t, err)
+	assert.True(t, verify.Verified)
+
+	// The same PROOF replayed to the other replica
+	_, err = c.RespondToChallenge(ctx, proof)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err), "got %v", err)
+
+	// The resumed session completes
+	result, err := b.ExchangeAttestation(ctx, &pb.HandshakeAttestation{
+		TrustLevel: verify.TrustLevel,
+		Metadata:   map[string]string{"session_id": sessionID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, sessionID, result.SessionId)
+
+	incomplete, err := store.ListIncomplete(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, incomplete)
+}
+
+func TestHandshakeRecoveryResumesVerifiedSession(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryHandshakeStore()
+	nonces := NewInMemoryNonceStore(time.Minute)
+
+	a := recoveryServer(store, nonces, nil)
+	proof, sessionID := challengedSession(t, a)
+	verify, err := a.RespondToChallenge(ctx, proof)
+	require.NoError(t, err)
+
+	b := recoveryServer(store, nonces, nil)
+	report, err := b.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{sessionID}, report.Resumed)
+
+	_, err = b.ExchangeAttestation(ctx, &pb.HandshakeAttestation{
+		TrustLevel: verify.TrustLevel,
+		Metadata:   map[string]string{"session_id": sessionID},
+	})
+	require.NoError(t, err)
+}
+
+func TestHandshakeRecoveryExpiresSessions(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryHandshakeStore()
+	now := time.Now()
+	require.NoError(t, store.Save(ctx, &HandshakeSessionState{
+		SessionID: "timed-out", LocalOCXID: "ocx-responder", RemoteOCXID: "ocx-initiator",
+		CurrentState: int(StateChallengeSent), StepCompleted: 2,
+		StartedAt: now.Add(-10 * time.Minute), LastStepAt: now.Add(-10 * time.Minute),
+	}))
+	require.NoError(t, store.Save(ctx, &HandshakeSessionState{
+		SessionID: "interrupted", LocalOCXID: "ocx-responder", RemoteOCXID: "ocx-initiator",
+		CurrentState: int(StateHelloReceived), StepCompleted: 1,
+		StartedAt: now, LastStepAt: now,
+	}))
+	require.NoError(t, store.Save(ctx, &HandshakeSessionState{
+		SessionID: "other-instance", LocalOCXID: "ocx-other", RemoteOCXID: "ocx-initiator",
+		CurrentState: int(StateChallengeSent), StartedAt: now, LastStepAt: now,
+	}))
+
+	alerts := &recordingEmitter{}
+	report, err := recoveryServer(store, NewInMemoryNonceStore(time.Minute), alerts).Recover(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, report.Resumed)
+	assert.ElementsMatch(t, []string{"timed-out", "interrupted"}, report.Expired)
+
+	for _, id := range report.Expired {
+		state, err := store.Load(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "EXPIRED", state.Verdict)
+		assert.Equal(t, int(StateTimeout), state.CurrentState)
+	}
+	events := alerts.recorded()
+	require.Len(t, events, 2)
+	for _, e := range events {
+		assert.Equal(t, HandshakeExpiredEvent, e.Type)
+		assert.Equal(t, "ocx-initiator", e.Data["remote_instance_id"])
+	}
+
+	// Another instance's session is left alone
+	other, err := store.Load(ctx, "other-instance")
+	require.NoError(t, err)
+	assert.Empty(t, other.Verdict)
+}
+
+func TestNonceStoreRejectsReuse(t *testing.T) {
+	nonces := NewInMemoryNonceStore(time.Minute)
+	assert.True(t, nonces.MarkUsed("nonce-1"))
+	assert.False(t, nonces.MarkUsed("nonce-1"))
+	assert.True(t, nonces.IsUsed("nonce-1"))
+	assert.False(t, nonces.IsUsed("nonce-2"))
+}
@@ -2,11 +2,13 @@ package federation
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ocx/backend/internal/events"
 	pb "github.com/ocx/backend/pb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -30,6 +32,18 @@ type HandshakeServiceServer struct {
 	// Ledger for attestation
 	ledger *TrustAttestationLedger
 
+	// Revoked instances, keys and certificates (nil = none)
+	revocations RevocationChecker
+
+	// Signing algorithms accepted from initiators (nil = all supported)
+	algorithms []CryptoAlgorithm
+
+	// Crash recovery (handshake_recovery.go): sessions saved after each
+	// step, challenge nonces shared across replicas, expiry audit events
+	store  HandshakeSessionStore
+	nonces NonceStore
+	alerts events.EventEmitter
+
 	// Configuration
 	minTrustLevel float64
 	sessionTTL    time.Duration
@@ -46,6 +60,35 @@ func NewHandshakeServiceServer(localAgent *OCXInstance, ledger *TrustAttestation
 	}
 }
 
+// SetRevocationChecker rejects revoked remote instances, keys and
+// certificates in new sessions.
+func (s *HandshakeServiceServer) SetRevocationChecker(rc RevocationChecker) {
+	s.revocations = rc
+}
+
+// SetAlgorithmPreference limits the algorithms initiators may sign with,
+// e.g. to hybrid only for tenants that require post-quantum signatures.
+func (s *HandshakeServiceServer) SetAlgorithmPreference(algs ...CryptoAlgorithm) {
+	s.algorithms = algs
+}
+
+// SetSessionStore saves sessions after each step so Recover can resume
+// them after a restart.
+func (s *HandshakeServiceServer) SetSessionStore(store HandshakeSessionStore) {
+	s.store = store
+}
+
+// SetNonceStore consumes each challenge nonce once across restarts and
+// replicas sharing the store.
+func (s *HandshakeServiceServer) SetNonceStore(ns NonceStore) {
+	s.nonces = ns
+}
+
+// SetEventEmitter publishes sessions expired at recovery as CloudEvents.
+func (s *HandshakeServiceServer) SetEventEmitter(e events.EventEmitter) {
+	s.alerts = e
+}
+
 // InitiateHandshake handles Step 1 (HELLO) and responds with Step 2 (CHALLENGE)
 func (s *HandshakeServiceServer) InitiateHandshake(ctx context.Context, hello *pb.HandshakeHello) (*pb.HandshakeChallenge, error) {
 	slog.Info("Received HELLO from", "instance_id", hello.InstanceId, "organization", hello.Organization)
@@ -59,6 +102,9 @@ func (s *HandshakeServiceServer) InitiateHandshake(ctx context.Context, hello *p
 
 	// Create new handshake session
 	session := NewHandshakeSession(s.localAgent, remoteAgent, s.ledger)
+	session.SetRevocationChecker(s.revocations)
+	session.SetAlgorithmPreference(s.algorithms...)
+	session.SetNonceStore(s.nonces)
 
 	// Store session
 	s.mu.Lock()
@@ -67,11 +113,19 @@ func (s *HandshakeServiceServer) InitiateHandshake(ctx context.Context, hello *p
 
 	// Process HELLO
 	if err := session.ReceiveHello(ctx, hello); err != nil {
+		s.persist(ctx, session)
+		if errors.Is(err, ErrRevoked) {
+			return nil, status.Errorf(codes.PermissionDenied, "HELLO rejected: %v", err)
+		}
+		if errors.Is(err, ErrAlgorithmNotAccepted) {
+			return nil, status.Errorf(codes.FailedPrecondition, "HELLO rejected: %v", err)
+		}
 		return nil, status.Errorf(codes.InvalidArgument, "HELLO validation failed: %v", err)
 	}
 
 	// Generate and send CHALLENGE
 	challenge, err := session.SendChallenge(ctx, hello)
+	s.persist(ctx, session)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to generate challenge: %v", err)
 	}
@@ -82,18 +136,8 @@ func (s *HandshakeServiceServer) InitiateHandshake(ctx context.Context, hello *p
 
 // RespondToChallenge handles Step 3 (PROOF) and responds with Step 4 (VERIFY)
 func (s *HandshakeServiceServer) RespondToChallenge(ctx context.Context, proof *pb.HandshakeProof) (*pb.HandshakeVerify, error) {
-	// Find session by proof metadata (would need to add session_id to proof)
-	// For now, find the most recent session
-	s.mu.RLock()
-	var session *HandshakeSession
-	for _, sess := range s.sessions {
-		if sess.stateMachine.GetCurrentState() == StateChallengeSent {
-			session = sess
-			break
-		}
-	}
-	s.mu.RUnlock()
-
+	// The proof carries no session ID; match it to the challenge it signs
+	session := s.proofSession(proof)
 	if session == nil {
 		return nil, status.Errorf(codes.NotFound, "no active handshake session found")
 	}
@@ -101,11 +145,19 @@ func (s *HandshakeServiceServer) RespondToChallenge(ctx context.Context, proof *
 	slog.Info("Received PROOF (session=)", "session_i_d", session.sessionID)
 	// Verify proof
 	if err := session.ReceiveProof(ctx, proof); err != nil {
+		s.persist(ctx, session)
+		if errors.Is(err, ErrNonceReplayed) {
+			return nil, status.Errorf(codes.AlreadyExists, "proof rejected: %v", err)
+		}
+		if errors.Is(err, ErrAlgorithmNotAccepted) {
+			return nil, status.Errorf(codes.FailedPrecondition, "proof rejected: %v", err)
+		}
 		return nil, status.Errorf(codes.Unauthenticated, "proof verification failed: %v", err)
 	}
 
 	// Perform verification and calculate trust
 	verify, err := session.PerformVerification(ctx, proof, "default-agent")
+	s.persist(ctx, session)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "verification failed: %v", err)
 	}
@@ -129,11 +181,13 @@ func (s *HandshakeServiceServer) ExchangeAttestation(ctx context.Context, attest
 	slog.Info("Received ATTESTATION (session=)", "session_i_d", sessionID)
 	// Receive attestation
 	if err := session.ReceiveAttestation(ctx, attestation); err != nil {
+		s.persist(ctx, session)
 		return nil, status.Errorf(codes.InvalidArgument, "attestation invalid: %v", err)
 	}
 
 	// Finalize handshake
 	result, err := session.FinalizeHandshake(ctx, attestation)
+	s.persist(ctx, session)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to finalize handshake: %v", err)
 	}
@@ -242,5 +296,10 @@ func (s *HandshakeServiceServer) cleanupSession(sessionID string, delay time.Dur
 	s.mu.Lock()
 	delete(s.sessions, sessionID)
 	s.mu.Unlock()
+	if s.store != nil {
+		if err := s.store.Delete(context.Background(), sessionID); err != nil {
+			slog.Warn("[HandshakeStore] Delete failed", "session_id", sessionID, "error", err)
+		}
+	}
 	slog.Info("Cleaned up session", "session_i_d", sessionID)
 }
@@ -2,6 +2,7 @@ package federation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -30,17 +31,18 @@ type HandshakeSessionState struct {
 	StartedAt     time.Time `json:"started_at"`
 	LastStepAt    time.Time `json:"last_step_at"`
 	StepCompleted int       `json:"step_completed"` // 0-6
-	Verdict       string    `json:"verdict"`        // "", "ACCEPTED", "REJECTED"
+	Verdict       string    `json:"verdict"`        // "", "ACCEPTED", "REJECTED", "EXPIRED", "ERROR"
+
+	// Key and algorithm accepted from the peer's HELLO, needed to verify
+	// its PROOF after a restart
+	RemoteAlgorithm string `json:"remote_algorithm,omitempty"`
+	RemotePublicKey string `json:"remote_public_key,omitempty"` // PEM
 }
 
 // HandshakeSessionStore persists handshake session state for crash recovery.
 //
-// When implemented, the handshake flow should:
-// 1. Save state after each step transition (SendHello, ReceiveChallenge, etc.)
-// 2. On startup, load incomplete sessions and resume or expire them
-// 3. Use idempotency keys so steps can be retried without side effects
-//
-// TODO(durability): Implement SupabaseHandshakeStore for production persistence.
+// HandshakeServiceServer saves state after each step and, on startup,
+// loads incomplete sessions and resumes or expires them (Recover).
 type HandshakeSessionStore interface {
 	// Save persists the current handshake session state.
 	Save(ctx context.Context, state *HandshakeSessionState) error
@@ -115,7 +117,7 @@ func (s *InMemoryHandshakeStore) ListIncomplete(ctx context.Context) ([]*Handsha
 //
 // In-memory nonce store with automatic expiration. Prevents replay attacks
 // by ensuring each nonce can only be used once within its TTL window.
-// For production, implement a Redis-backed NonceStore.
+// RedisNonceStore shares the window across restarts and replicas.
 // ============================================================================
 
 // NonceStore tracks used nonces to prevent replay attacks.
@@ -126,6 +128,9 @@ type NonceStore interface {
 	IsUsed(nonce string) bool
 }
 
+// ErrNonceReplayed is returned when a nonce is presented a second time.
+var ErrNonceReplayed = errors.New("nonce already used")
+
 // InMemoryNonceStore provides TTL-based in-memory nonce tracking.
 type InMemoryNonceStore struct {
 	mu     sync.Mutex
@@ -193,3 +198,54 @@ func (ns *InMemoryNonceStore) cleanup() {
 		}
 	}
 }
+
+// NonceRedisClient is the subset of Redis operations RedisNonceStore needs.
+// infra.GoRedisAdapter satisfies it.
+type NonceRedisClient interface {
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// RedisNonceStore is a NonceStore shared by every replica using the same
+// Redis. Nonces expire with the key TTL. Redis errors fail closed: the
+// nonce is treated as used.
+type RedisNonceStore struct {
+	client    NonceRedisClient
+	keyPrefix string // e.g. "ocx:nonce:"
+	maxAge    time.Duration
+	timeout   time.Duration
+}
+
+// NewRedisNonceStore creates a Redis-backed nonce store with the given TTL
+// (default 5 minutes).
+func NewRedisNonceStore(client NonceRedisClient, keyPrefix string, maxAge time.Duration) *RedisNonceStore {
+	if maxAge == 0 {
+		maxAge = 5 * time.Minute
+	}
+	return &RedisNonceStore{client: client, keyPrefix: keyPrefix, maxAge: maxAge, timeout: 2 * time.Second}
+}
+
+// MarkUsed atomically records a nonce with SET NX. Returns false if it was
+// already used or Redis is unavailable.
+func (r *RedisNonceStore) MarkUsed(nonce string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	ok, err := r.client.SetNX(ctx, r.keyPrefix+nonce, []byte("1"), r.maxAge)
+	if err != nil {
+		slog.Warn("[NonceStore] Redis unavailable, rejecting nonce", "error", err)
+		return false
+	}
+	return ok
+}
+
+// IsUsed checks if a nonce has been used within its TTL.
+func (r *RedisNonceStore) IsUsed(nonce string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	used, err := r.client.Exists(ctx, r.keyPrefix+nonce)
+	if err != nil {
+		slog.Warn("[NonceStore] Redis unavailable, treating nonce as used", "error", err)
+		return true
+	}
+	return used
+}
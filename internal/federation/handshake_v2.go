@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,9 +32,15 @@ type HandshakeSession struct {
 	nonce     string
 	challenge []byte
 
-	// Dual-algorithm crypto provider (Ed25519 or ECDSA P-256)
+	// Crypto provider (Ed25519, ECDSA P-256, ML-DSA-65 or hybrid)
 	cryptoProvider CryptoProvider
 
+	// Algorithm negotiation: local preference order (also the set accepted
+	// from the peer), and the algorithm and key the peer's HELLO presented
+	algorithms      []CryptoAlgorithm
+	remoteAlgorithm CryptoAlgorithm
+	remotePublicKey []byte
+
 	// Ledger for attestation
 	ledger *TrustAttestationLedger
 
@@ -46,6 +53,12 @@ type HandshakeSession struct {
 
 	// Dev/test fallback key (when no SPIFFE agent) - kept for backward compat
 	devKey *ecdsa.PrivateKey
+
+	// Revoked keys, certificates and instances (nil = none)
+	revocations RevocationChecker
+
+	// Consumed challenge nonces, shared across restarts and replicas (nil = none)
+	nonces NonceStore
 }
 
 // NewHandshakeSession creates a new 6-step handshake session.
@@ -80,6 +93,39 @@ func (hs *HandshakeSession) SetThresholds(minTrust, baseTax float64) {
 	}
 }
 
+// SetRevocationChecker rejects revoked remote instances, keys and
+// certificates during the handshake.
+func (hs *HandshakeSession) SetRevocationChecker(rc RevocationChecker) {
+	hs.revocations = rc
+}
+
+// SetNonceStore rejects a PROOF for a challenge nonce already consumed, by
+// this process or any other sharing the store.
+func (hs *HandshakeSession) SetNonceStore(ns NonceStore) {
+	hs.nonces = ns
+}
+
+// SetAlgorithmPreference sets the algorithms this side signs with, most
+// preferred first. SendHello negotiates the first one the remote supports;
+// ReceiveHello rejects peers signing with anything else. Empty means the
+// default algorithm is preferred and every supported one accepted.
+func (hs *HandshakeSession) SetAlgorithmPreference(algs ...CryptoAlgorithm) {
+	hs.algorithms = algs
+}
+
+// RemoteAlgorithm returns the algorithm the peer's HELLO negotiated.
+func (hs *HandshakeSession) RemoteAlgorithm() CryptoAlgorithm {
+	return hs.remoteAlgorithm
+}
+
+// acceptedAlgorithms is the local preference, or every supported algorithm.
+func (hs *HandshakeSession) acceptedAlgorithms() []CryptoAlgorithm {
+	if len(hs.algorithms) > 0 {
+		return hs.algorithms
+	}
+	return AlgorithmPreference(DefaultCryptoAlgorithm, nil)
+}
+
 // SetGovernanceConfig loads trust thresholds from the tenant governance config.
 // tenantID is the local tenant initiating the handshake.
 func (hs *HandshakeSession) SetGovernanceConfig(cache *governance.GovernanceConfigCache, tenantID string) {
@@ -123,10 +169,15 @@ func (hs *HandshakeSession) SendHello(ctx context.Context) (*pb.HandshakeHello,
 		}
 		spiffeID = svid.ID.String()
 	} else {
-		// Dev/test fallback: use the session's crypto provider
+		// Dev/test fallback: use the session's crypto provider, or create
+		// one for the best algorithm both sides support
 		if hs.cryptoProvider == nil {
-			// Default to Ed25519 if none was provided
-			provider, err := NewCryptoProvider(AlgorithmEd25519)
+			alg, err := NegotiateCryptoAlgorithm(hs.acceptedAlgorithms(), hs.remoteOCX.Algorithms)
+			if err != nil {
+				hs.stateMachine.SetError(err)
+				return nil, err
+			}
+			provider, err := NewCryptoProvider(alg)
 			if err != nil {
 				hs.stateMachine.SetError(err)
 				return nil, fmt.Errorf("failed to create dev crypto provider: %w", err)
@@ -139,6 +190,12 @@ func (hs *HandshakeSession) SendHello(ctx context.Context) (*pb.HandshakeHello,
 		spiffeID = fmt.Sprintf("spiffe://dev/%s", hs.localOCX.InstanceID)
 	}
 
+	// A fixed key must still be one the remote can verify
+	if _, err := NegotiateCryptoAlgorithm([]CryptoAlgorithm{hs.cryptoProvider.Algorithm()}, hs.remoteOCX.Algorithms); err != nil {
+		hs.stateMachine.SetError(err)
+		return nil, err
+	}
+
 	// Encode public key from the provider
 	var err error
 	publicKeyPEM, err = hs.cryptoProvider.EncodePublicKeyPEM()
@@ -164,6 +221,7 @@ func (hs *HandshakeSession) SendHello(ctx context.Context) (*pb.HandshakeHello,
 			"region":       hs.localOCX.Region,
 			"trust_domain": hs.localOCX.TrustDomain,
 			"algorithm":    string(hs.cryptoProvider.Algorithm()),
+			"algorithms":   joinAlgorithms(hs.acceptedAlgorithms()),
 		},
 	}
 
@@ -193,10 +251,100 @@ func (hs *HandshakeSession) ReceiveHello(ctx context.Context, hello *pb.Handshak
 		return err
 	}
 
-	slog.Info("[STEP 1/6] HELLO received from", "instance_id", hello.InstanceId)
+	// Reject revoked instances and keys
+	if err := checkInstanceRevoked(hs.revocations, hello.InstanceId); err != nil {
+		hs.stateMachine.SetError(err)
+		return err
+	}
+
+	// The peer's signing algorithm must be one we accept, and its key must
+	// be of that algorithm. A HELLO without a key is held to the algorithm
+	// it announces here and to its PROOF key in ReceiveProof.
+	if hello.PublicKey != "" {
+		if err := hs.acceptRemoteKey(hello); err != nil {
+			hs.stateMachine.SetError(err)
+			return err
+		}
+	} else if alg := CryptoAlgorithm(hello.Metadata["algorithm"]); alg != "" {
+		if err := hs.checkAlgorithmAccepted(hello.InstanceId, alg); err != nil {
+			hs.stateMachine.SetError(err)
+			return err
+		}
+		hs.remoteAlgorithm = alg
+	}
+	if hs.revocations != nil && hello.PublicKey != "" {
+		fp, err := KeyFingerprintPEM(hello.PublicKey)
+		if err != nil {
+			err = fmt.Errorf("invalid HELLO public key: %w", err)
+			hs.stateMachine.SetError(err)
+			return err
+		}
+		if e := hs.revocations.Revoked(RevokeKindKey, fp); e != nil {
+			err := fmt.Errorf("HELLO key of %s %w: %s", hello.InstanceId, ErrRevoked, e.Reason)
+			hs.stateMachine.SetError(err)
+			return err
+		}
+	}
+
+	slog.Info("[STEP 1/6] HELLO received from", "instance_id", hello.InstanceId, "algorithm", hs.remoteAlgorithm)
 	return nil
 }
 
+// acceptRemoteKey checks the algorithm a HELLO announces against local
+// policy and the key it carries, and keeps the key to verify the PROOF.
+func (hs *HandshakeSession) acceptRemoteKey(hello *pb.HandshakeHello) error {
+	key, err := PublicKeyFromPEM(hello.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid HELLO public key: %w", err)
+	}
+	alg := CryptoAlgorithm(hello.Metadata["algorithm"])
+	if alg == "" {
+		alg = DetectKeyAlgorithm(key)
+	}
+	if DetectKeyAlgorithm(key) != alg {
+		return fmt.Errorf("HELLO announces %s but carries a %s key", alg, DetectKeyAlgorithm(key))
+	}
+	if err := hs.checkAlgorithmAccepted(hello.InstanceId, alg); err != nil {
+		return err
+	}
+	hs.remoteAlgorithm = alg
+	hs.remotePublicKey = key
+	return nil
+}
+
+// checkAlgorithmAccepted refuses a peer signing with an algorithm outside
+// the local preference.
+func (hs *HandshakeSession) checkAlgorithmAccepted(instanceID string, alg CryptoAlgorithm) error {
+	for _, a := range hs.acceptedAlgorithms() {
+		if a == alg {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s signs with %s, policy requires one of %v",
+		ErrAlgorithmNotAccepted, instanceID, alg, hs.acceptedAlgorithms())
+}
+
+// joinAlgorithms renders a preference list for HELLO metadata.
+func joinAlgorithms(algs []CryptoAlgorithm) string {
+	names := make([]string, len(algs))
+	for i, a := range algs {
+		names[i] = string(a)
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseAlgorithms reads a comma-separated algorithm list, as sent in HELLO
+// metadata.
+func ParseAlgorithms(list string) []CryptoAlgorithm {
+	var algs []CryptoAlgorithm
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			algs = append(algs, CryptoAlgorithm(name))
+		}
+	}
+	return algs
+}
+
 // ============================================================================
 // STEP 2: CHALLENGE - Cryptographic challenge with nonce
 // ============================================================================
@@ -325,8 +473,13 @@ func (hs *HandshakeSession) GenerateProof(ctx context.Context, agentID string) (
 
 	// Set proof type based on provider algorithm
 	proofType := "ECDSA-SHA256"
-	if hs.cryptoProvider.Algorithm() == AlgorithmEd25519 {
+	switch hs.cryptoProvider.Algorithm() {
+	case AlgorithmEd25519:
 		proofType = "Ed25519"
+	case AlgorithmMLDSA65:
+		proofType = "ML-DSA-65"
+	case AlgorithmHybrid:
+		proofType = "Ed25519+ML-DSA-65"
 	}
 
 	proofMsg := &pb.HandshakeProof{
@@ -349,8 +502,9 @@ func (hs *HandshakeSession) ReceiveProof(ctx context.Context, proof *pb.Handshak
 		return err
 	}
 
-	// Determine the remote's crypto provider
+	// Determine the remote's crypto provider and key
 	var remoteProvider CryptoProvider
+	var remoteKey []byte
 
 	if hs.remoteOCX.SPIFFESource != nil {
 		// Extract key from SPIFFE SVID
@@ -368,6 +522,15 @@ func (hs *HandshakeSession) ReceiveProof(ctx context.Context, proof *pb.Handshak
 			hs.stateMachine.SetError(err)
 			return err
 		}
+	} else if hs.remotePublicKey != nil {
+		// The key and algorithm accepted from the peer's HELLO
+		verifier, err := verifierFor(hs.remoteAlgorithm)
+		if err != nil {
+			hs.stateMachine.SetError(err)
+			return err
+		}
+		remoteProvider = verifier
+		remoteKey = hs.remotePublicKey
 	} else if hs.cryptoProvider != nil {
 		// In dev/test, use the same provider type
 		remoteProvider = hs.cryptoProvider
@@ -377,9 +540,37 @@ func (hs *HandshakeSession) ReceiveProof(ctx context.Context, proof *pb.Handshak
 		return err
 	}
 
+	if remoteKey == nil {
+		remoteKey = remoteProvider.PublicKeyBytes()
+	}
+
+	// Keyless HELLOs were not checked against policy; their PROOF key is
+	if err := hs.checkAlgorithmAccepted(hs.remoteOCX.InstanceID, remoteProvider.Algorithm()); err != nil {
+		hs.stateMachine.SetError(err)
+		return err
+	}
+
+	// Reject a revoked proof key or certificate
+	if err := checkKeyRevoked(hs.revocations, remoteKey); err != nil {
+		hs.stateMachine.SetError(err)
+		return err
+	}
+	for i, c := range proof.CertificateChain {
+		cert, err := parseChainCertificate(c)
+		if err != nil {
+			err = fmt.Errorf("proof certificate %d: %w", i, err)
+			hs.stateMachine.SetError(err)
+			return err
+		}
+		if err := checkCertificateRevoked(hs.revocations, cert); err != nil {
+			hs.stateMachine.SetError(err)
+			return err
+		}
+	}
+
 	// Verify proof using the provider
 	valid, err := remoteProvider.Verify(
-		remoteProvider.PublicKeyBytes(),
+		remoteKey,
 		hs.challenge,
 		proof.Proof,
 	)
@@ -394,6 +585,13 @@ func (hs *HandshakeSession) ReceiveProof(ctx context.Context, proof *pb.Handshak
 		return err
 	}
 
+	// Each challenge answers once, even if the session was resumed elsewhere
+	if hs.nonces != nil && !hs.nonces.MarkUsed(hs.nonce) {
+		err := fmt.Errorf("%w: challenge nonce of session %s", ErrNonceReplayed, hs.sessionID)
+		hs.stateMachine.SetError(err)
+		return err
+	}
+
 	slog.Info("✅ [STEP 3/6] PROOF verified successfully", "algorithm", remoteProvider.Algorithm())
 	return nil
 }
@@ -486,10 +684,15 @@ func (hs *HandshakeSession) ExchangeAttestation(ctx context.Context, verify *pb.
 	return attestationMsg, nil
 }
 
-// ReceiveAttestation processes an incoming ATTESTATION message
+// ReceiveAttestation processes an incoming ATTESTATION message, either
+// after this side sent its own or, on the responder, straight after VERIFY
 func (hs *HandshakeSession) ReceiveAttestation(ctx context.Context, attestation *pb.HandshakeAttestation) error {
 	// Transition state
-	if err := hs.stateMachine.Transition(StateAttestationSent, StateAttestationReceived); err != nil {
+	from := StateAttestationSent
+	if hs.stateMachine.GetCurrentState() == StateVerified {
+		from = StateVerified
+	}
+	if err := hs.stateMachine.Transition(from, StateAttestationReceived); err != nil {
 		return err
 	}
 
@@ -0,0 +1,55 @@
+package federation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// SHARED TEST FIXTURES
+// ============================================================================
+
+// newTestManagers creates "ocx-a" and "ocx-b" with the given regions.
+func newTestManagers(t *testing.T, regionA, regionB string) (a, b *FederationManager) {
+	t.Helper()
+	var err error
+	a, err = NewFederationManager(FederationConfig{InstanceID: "ocx-a", Region: regionA, MaxPeers: 4})
+	require.NoError(t, err)
+	b, err = NewFederationManager(FederationConfig{InstanceID: "ocx-b", Region: regionB, MaxPeers: 4})
+	require.NoError(t, err)
+	return a, b
+}
+
+// runHandshake drives HELLO → CHALLENGE → RESPONSE → CONFIRM from initiator
+// to responder and returns the first error either side reports.
+func runHandshake(t *testing.T, initiator, responder *FederationManager) error {
+	t.Helper()
+	attestation, err := initiator.CreateAttestation(1, 1)
+	require.NoError(t, err)
+	challenge, err := responder.ProcessHandshakeMessage(&HandshakeMessage{
+		Type: HandshakeHello, InstanceID: initiator.instanceID, Nonce: []byte("nonce"), Attestation: attestation, Timestamp: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	response, err := initiator.ProcessHandshakeMessage(challenge)
+	if err != nil {
+		return err
+	}
+	confirm, err := responder.ProcessHandshakeMessage(response)
+	if err != nil {
+		return err
+	}
+	_, err = initiator.ProcessHandshakeMessage(confirm)
+	return err
+}
+
+// handshakePair connects "ocx-a" and "ocx-b" in both directions.
+func handshakePair(t *testing.T) (a, b *FederationManager) {
+	t.Helper()
+	a, b = newTestManagers(t, "", "")
+	require.NoError(t, runHandshake(t, a, b))
+	return a, b
+}
@@ -0,0 +1,353 @@
+package federation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ocx/backend/internal/events"
+)
+
+// ============================================================================
+// SOFTWARE INTEGRITY ATTESTATION
+//
+// An attestation can carry an IntegrityManifest describing what the instance
+// runs: the digest of its binary, the governance config hash of each tenant,
+// the active policy version of each tool and its enabled features. The
+// manifest is covered by the attestation signature. The receiving side
+// checks it against the allow-list it keeps for that peer; a mismatch costs
+// the peer trust in the PersistentTrustLedger, keeps it PROVISIONAL and
+// raises an alert. Enforcing allow-lists reject the handshake instead.
+// ============================================================================
+
+// ErrIntegrityMismatch is returned when an enforcing allow-list rejects a
+// peer's manifest.
+var ErrIntegrityMismatch = errors.New("integrity manifest not allowed")
+
+// IntegrityAlertEvent is the CloudEvent type emitted on a mismatch.
+const IntegrityAlertEvent = "ocx.federation.integrity_mismatch"
+
+const defaultIntegrityPenalty = 0.5
+
+// IntegrityManifest describes the code and configuration an instance runs.
+type IntegrityManifest struct {
+	BinaryDigest     string            `json:"binary_digest"`               // "sha256:<hex>" of the executable
+	GovernanceHashes map[string]string `json:"governance_hashes,omitempty"` // tenant ID → governance config hash
+	PolicyVersions   map[string]int    `json:"policy_versions,omitempty"`   // tool → active policy version
+	Features         []string          `json:"features,omitempty"`
+	GeneratedAt      time.Time         `json:"generated_at"`
+}
+
+// ManifestSource builds the manifest attached to each attestation.
+type ManifestSource func() (*IntegrityManifest, error)
+
+// ManifestInputs are the parts NewManifestSource assembles.
+type ManifestInputs struct {
+	BinaryDigest     string                   // default: ExecutableDigest
+	GovernanceHashes func() map[string]string // e.g. GovernanceConfigCache.Hashes
+	PolicyVersions   func() map[string]int    // e.g. PolicyVersionStore.ActiveVersions
+	Features         []string
+}
+
+// NewManifestSource returns a ManifestSource reading the current governance
+// hashes and policy versions each time an attestation is created.
+func NewManifestSource(in ManifestInputs) ManifestSource {
+	features := append([]string(nil), in.Features...)
+	sort.Strings(features)
+	return func() (*IntegrityManifest, error) {
+		digest := in.BinaryDigest
+		if digest == "" {
+			var err error
+			if digest, err = ExecutableDigest(); err != nil {
+				return nil, err
+			}
+		}
+		m := &IntegrityManifest{BinaryDigest: digest, Features: features, GeneratedAt: time.Now().UTC()}
+		if in.GovernanceHashes != nil {
+			m.GovernanceHashes = in.GovernanceHashes()
+		}
+		if in.PolicyVersions != nil {
+			m.PolicyVersions = in.PolicyVersions()
+		}
+		return m, nil
+	}
+}
+
+var executableDigest struct {
+	once   sync.Once
+	digest string
+	err    error
+}
+
+// ExecutableDigest returns the SHA-256 of the running executable as
+// "sha256:<hex>". It is computed once per process.
+func ExecutableDigest() (string, error) {
+	executableDigest.once.Do(func() {
+		path, err := os.Executable()
+		if err != nil {
+			executableDigest.err = fmt.Errorf("locate executable: %w", err)
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			executableDigest.err = fmt.Errorf("open executable: %w", err)
+			return
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			executableDigest.err = fmt.Errorf("hash executable: %w", err)
+			return
+		}
+		executableDigest.digest = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	})
+	return executableDigest.digest, executableDigest.err
+}
+
+// IntegrityMismatch is one way a manifest differs from an allow-list.
+type IntegrityMismatch struct {
+	Field   string `json:"field"`             // manifest, binary_digest, governance_hash, policy_version, feature
+	Subject string `json:"subject,omitempty"` // tenant, tool or feature
+	Got     string `json:"got"`
+	Want    string `json:"want"`
+}
+
+func (m IntegrityMismatch) String() string {
+	if m.Subject != "" {
+		return fmt.Sprintf("%s[%s]: got %s, want %s", m.Field, m.Subject, m.Got, m.Want)
+	}
+	return fmt.Sprintf("%s: got %s, want %s", m.Field, m.Got, m.Want)
+}
+
+// IntegrityAllowList is what a peer's manifest must match. Empty fields
+// are not checked.
+type IntegrityAllowList struct {
+	BinaryDigests     []string            `json:"binary_digests,omitempty"`
+	GovernanceHashes  map[string][]string `json:"governance_hashes,omitempty"` // tenant ID → allowed hashes
+	PolicyVersions    map[string][]int    `json:"policy_versions,omitempty"`   // tool → allowed active versions
+	RequiredFeatures  []string            `json:"required_features,omitempty"`
+	ForbiddenFeatures []string            `json:"forbidden_features,omitempty"`
+	Enforce           bool                `json:"enforce"` // reject the handshake instead of lowering trust
+}
+
+// Check returns every mismatch between m and the allow-list, in a stable
+// order. A missing manifest is a mismatch.
+func (l *IntegrityAllowList) Check(m *IntegrityManifest) []IntegrityMismatch {
+	if m == nil {
+		return []IntegrityMismatch{{Field: "manifest", Got: "none", Want: "signed manifest"}}
+	}
+	var out []IntegrityMismatch
+
+	if len(l.BinaryDigests) > 0 && !containsString(l.BinaryDigests, m.BinaryDigest) {
+		out = append(out, IntegrityMismatch{Field: "binary_digest", Got: orMissing(m.BinaryDigest),
+			Want: strings.Join(l.BinaryDigests, " | ")})
+	}
+	for _, tenant := range sortedKeys(l.GovernanceHashes) {
+		allowed := l.GovernanceHashes[tenant]
+		if got := m.GovernanceHashes[tenant]; !containsString(allowed, got) {
+			out = append(out, IntegrityMismatch{Field: "governance_hash", Subject: tenant, Got: orMissing(got),
+				Want: strings.Join(allowed, " | ")})
+		}
+	}
+	for _, tool := range sortedKeys(l.PolicyVersions) {
+		allowed := l.PolicyVersions[tool]
+		got, ok := m.PolicyVersions[tool]
+		if !ok || !containsInt(allowed, got) {
+			gotStr := "missing"
+			if ok {
+				gotStr = strconv.Itoa(got)
+			}
+			want := make([]string, len(allowed))
+			for i, v := range allowed {
+				want[i] = strconv.Itoa(v)
+			}
+			out = append(out, IntegrityMismatch{Field: "policy_version", Subject: tool, Got: gotStr,
+				Want: strings.Join(want, " | ")})
+		}
+	}
+	for _, f := range l.RequiredFeatures {
+		if !containsString(m.Features, f) {
+			out = append(out, IntegrityMismatch{Field: "feature", Subject: f, Got: "disabled", Want: "enabled"})
+		}
+	}
+	for _, f := range l.ForbiddenFeatures {
+		if containsString(m.Features, f) {
+			out = append(out, IntegrityMismatch{Field: "feature", Subject: f, Got: "enabled", Want: "disabled"})
+		}
+	}
+	return out
+}
+
+// IntegrityPolicy holds the allow-list for each peer. The "*" entry applies
+// to peers without their own; peers with neither are not checked.
+type IntegrityPolicy struct {
+	mu      sync.RWMutex
+	lists   map[OCXInstanceID]*IntegrityAllowList
+	penalty float64
+}
+
+// NewIntegrityPolicy creates an empty policy. penalty is the factor a
+// peer's ledger trust is multiplied by on a mismatch (default 0.5).
+func NewIntegrityPolicy(penalty float64) *IntegrityPolicy {
+	if penalty <= 0 || penalty > 1 {
+		penalty = defaultIntegrityPenalty
+	}
+	return &IntegrityPolicy{lists: make(map[OCXInstanceID]*IntegrityAllowList), penalty: penalty}
+}
+
+// Allow sets the allow-list for peer ("*" for every other peer).
+func (p *IntegrityPolicy) Allow(peer OCXInstanceID, list IntegrityAllowList) {
+	p.mu.Lock()
+	p.lists[peer] = &list
+	p.mu.Unlock()
+}
+
+// AllowListFor returns the allow-list that applies to peer, or nil.
+func (p *IntegrityPolicy) AllowListFor(peer OCXInstanceID) *IntegrityAllowList {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if l, ok := p.lists[peer]; ok {
+		return l
+	}
+	return p.lists["*"]
+}
+
+// integrityState is the manager's integrity configuration. It has its own
+// lock because attestations are created while fm.mu is held.
+type integrityState struct {
+	mu     sync.RWMutex
+	source ManifestSource
+	policy *IntegrityPolicy
+	alerts events.EventEmitter
+}
+
+// SetManifestSource attaches a manifest from src to every attestation this
+// manager creates.
+func (fm *FederationManager) SetManifestSource(src ManifestSource) {
+	fm.integrity.mu.Lock()
+	fm.integrity.source = src
+	fm.integrity.mu.Unlock()
+}
+
+// SetIntegrityPolicy checks peers' manifests during handshakes.
+func (fm *FederationManager) SetIntegrityPolicy(p *IntegrityPolicy) {
+	fm.integrity.mu.Lock()
+	fm.integrity.policy = p
+	fm.integrity.mu.Unlock()
+}
+
+// SetAlertEmitter publishes integrity mismatches as CloudEvents.
+func (fm *FederationManager) SetAlertEmitter(e events.EventEmitter) {
+	fm.integrity.mu.Lock()
+	fm.integrity.alerts = e
+	fm.integrity.mu.Unlock()
+}
+
+// CurrentManifest returns the manifest attached to new attestations, or nil
+// without a manifest source. Operators copy its values into peers'
+// allow-lists.
+func (fm *FederationManager) CurrentManifest() (*IntegrityManifest, error) {
+	fm.integrity.mu.RLock()
+	src := fm.integrity.source
+	fm.integrity.mu.RUnlock()
+	if src == nil {
+		return nil, nil
+	}
+	m, err := src()
+	if err != nil {
+		return nil, fmt.Errorf("build integrity manifest: %w", err)
+	}
+	return m, nil
+}
+
+// checkIntegrity compares a peer's manifest with its allow-list. Mismatches
+// are penalised and alerted; they are returned so the peer is kept
+// PROVISIONAL, and fail the handshake when the allow-list enforces.
+func (fm *FederationManager) checkIntegrity(id OCXInstanceID, att *Attestation) ([]IntegrityMismatch, error) {
+	fm.integrity.mu.RLock()
+	policy, alerts := fm.integrity.policy, fm.integrity.alerts
+	fm.integrity.mu.RUnlock()
+	if policy == nil {
+		return nil, nil
+	}
+	list := policy.AllowListFor(id)
+	if list == nil {
+		return nil, nil
+	}
+	mismatches := list.Check(att.Manifest)
+	if len(mismatches) == 0 {
+		return nil, nil
+	}
+
+	summary := make([]string, len(mismatches))
+	for i, m := range mismatches {
+		summary[i] = m.String()
+	}
+	fm.logger.Printf("Integrity mismatch for %s (enforce=%t): %s", id, list.Enforce, strings.Join(summary, "; "))
+
+	fm.mu.RLock()
+	ledger := fm.trustLedger
+	fm.mu.RUnlock()
+	if ledger != nil {
+		ledger.RecordPenalty(context.Background(), string(fm.instanceID), string(id), "integrity_mismatch", policy.penalty)
+	}
+	if alerts != nil {
+		data := map[string]interface{}{
+			"instance_id": string(id),
+			"mismatches":  summary,
+			"enforced":    list.Enforce,
+		}
+		if att.Manifest != nil {
+			data["binary_digest"] = att.Manifest.BinaryDigest
+		}
+		alerts.Emit(IntegrityAlertEvent, "/federation/"+string(fm.instanceID), string(id), data)
+	}
+
+	if list.Enforce {
+		return mismatches, fmt.Errorf("%s: %w: %s", id, ErrIntegrityMismatch, strings.Join(summary, "; "))
+	}
+	return mismatches, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func orMissing(s string) string {
+	if s == "" {
+		return "missing"
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
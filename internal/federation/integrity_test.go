@@ -0,0 +1,162 @@
+package federation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// INTEGRITY ATTESTATION TESTS
+// ============================================================================
+
+type recordedEvent struct {
+	Type    string
+	Subject string
+	Data    map[string]interface{}
+}
+
+type recordingEmitter struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+func (e *recordingEmitter) Emit(eventType, source, subject string, data map[string]interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, recordedEvent{Type: eventType, Subject: subject, Data: data})
+}
+
+func (e *recordingEmitter) recorded() []recordedEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]recordedEvent(nil), e.events...)
+}
+
+// integrityPair returns a verifier "ocx-a" holding list for "ocx-b", and
+// "ocx-b" reporting the given binary digest.
+func integrityPair(t *testing.T, list IntegrityAllowList, digest string) (a, b *FederationManager, ledger *PersistentTrustLedger, alerts *recordingEmitter) {
+	t.Helper()
+	a, b = newTestManagers(t, "", "")
+
+	ledger = NewPersistentTrustLedger()
+	alerts = &recordingEmitter{}
+	policy := NewIntegrityPolicy(0.5)
+	policy.Allow("ocx-b", list)
+	a.SetTrustLedger(ledger)
+	a.SetIntegrityPolicy(policy)
+	a.SetAlertEmitter(alerts)
+
+	b.SetManifestSource(NewManifestSource(ManifestInputs{
+		BinaryDigest:     digest,
+		GovernanceHashes: func() map[string]string { return map[string]string{"tenant-1": "gov-1"} },
+		PolicyVersions:   func() map[string]int { return map[string]int{"payments.transfer": 3} },
+		Features:         []string{"federation-messages", "crypto:ed25519"},
+	}))
+	return a, b, ledger, alerts
+}
+
+func TestIntegrityMatchingManifestVerifiesPeer(t *testing.T) {
+	a, b, ledger, alerts := integrityPair(t, IntegrityAllowList{
+		BinaryDigests:    []string{"sha256:good"},
+		GovernanceHashes: map[string][]string{"tenant-1": {"gov-1"}},
+		PolicyVersions:   map[string][]int{"payments.transfer": {2, 3}},
+		RequiredFeatures: []string{"federation-messages"},
+	}, "sha256:good")
+
+	require.NoError(t, runHandshake(t, b, a))
+	peer, err := a.GetPeer("ocx-b")
+	require.NoError(t, err)
+	assert.Equal(t, TrustVerified, peer.TrustLevel)
+	assert.Empty(t, peer.IntegrityMismatches)
+	assert.Empty(t, alerts.recorded())
+	assert.Nil(t, ledger.GetInstanceRecord("ocx-b"))
+}
+
+func TestIntegrityMismatchLowersTrustAndAlerts(t *testing.T) {
+	a, b, ledger, alerts := integrityPair(t, IntegrityAllowList{
+		BinaryDigests: []string{"sha256:good"},
+	}, "sha256:tampered")
+
+	require.NoError(t, runHandshake(t, b, a))
+	peer, err := a.GetPeer("ocx-b")
+	require.NoError(t, err)
+	assert.Equal(t, TrustProvisional, peer.TrustLevel)
+	require.Len(t, peer.IntegrityMismatches, 1)
+	assert.Equal(t, "binary_digest", peer.IntegrityMismatches[0].Field)
+	assert.Equal(t, "sha256:tampered", peer.IntegrityMismatches[0].Got)
+
+	assert.InDelta(t, 0.25, ledger.GetInstanceTrust("ocx-b"), 0.01)
+	log := ledger.GetAttestationLog(10)
+	require.NotEmpty(t, log)
+	assert.Equal(t, "penalty", log[len(log)-1].Outcome)
+
+	events := alerts.recorded()
+	require.Len(t, events, 1)
+	assert.Equal(t, IntegrityAlertEvent, events[0].Type)
+	assert.Equal(t, "ocx-b", events[0].Subject)
+	assert.Equal(t, "sha256:tampered", events[0].Data["binary_digest"])
+}
+
+func TestIntegrityEnforcedAllowListRejectsHandshake(t *testing.T) {
+	a, b, _, alerts := integrityPair(t, IntegrityAllowList{
+		PolicyVersions: map[string][]int{"payments.transfer": {4}},
+		Enforce:        true,
+	}, "sha256:good")
+
+	err := runHandshake(t, b, a)
+	assert.True(t, errors.Is(err, ErrIntegrityMismatch), "got %v", err)
+	_, err = a.GetPeer("ocx-b")
+	assert.Error(t, err)
+	assert.Len(t, alerts.recorded(), 1)
+}
+
+func TestIntegrityManifestIsSigned(t *testing.T) {
+	_, b, _, _ := integrityPair(t, IntegrityAllowList{}, "sha256:good")
+	attestation, err := b.CreateAttestation(1, 1)
+	require.NoError(t, err)
+	require.NotNil(t, attestation.Manifest)
+	assert.True(t, attestation.Verify())
+
+	attestation.Manifest.BinaryDigest = "sha256:other"
+	assert.False(t, attestation.Verify())
+}
+
+func TestIntegrityAllowListCheck(t *testing.T) {
+	manifest := &IntegrityManifest{
+		BinaryDigest:     "sha256:good",
+		GovernanceHashes: map[string]string{"tenant-1": "gov-1"},
+		PolicyVersions:   map[string]int{"payments.transfer": 3},
+		Features:         []string{"plugins"},
+	}
+
+	tests := []struct {
+		name   string
+		list   IntegrityAllowList
+		fields []string
+	}{
+		{"empty list allows anything", IntegrityAllowList{}, nil},
+		{"governance hash", IntegrityAllowList{GovernanceHashes: map[string][]string{"tenant-1": {"gov-2"}, "tenant-2": {"gov-9"}}},
+			[]string{"governance_hash", "governance_hash"}},
+		{"policy version", IntegrityAllowList{PolicyVersions: map[string][]int{"payments.transfer": {1, 2}}},
+			[]string{"policy_version"}},
+		{"features", IntegrityAllowList{RequiredFeatures: []string{"federation-messages"}, ForbiddenFeatures: []string{"plugins"}},
+			[]string{"feature", "feature"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, m := range tt.list.Check(manifest) {
+				fields = append(fields, m.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+
+	missing := (&IntegrityAllowList{}).Check(nil)
+	require.Len(t, missing, 1)
+	assert.Equal(t, "manifest", missing[0].Field)
+}
@@ -0,0 +1,166 @@
+package federation
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// SOFTWARE KEY SERVICE — local stand-in for a KMS or HSM
+//
+// SoftwareKeyService keeps versioned keys in memory and implements
+// KeyService directly or over HTTP (Handler). It is meant for tests and
+// development sidecars: run it in a separate process and the API process
+// still never holds a private key.
+// ============================================================================
+
+// SoftwareKeyService is an in-memory KeyService with versioned keys.
+type SoftwareKeyService struct {
+	mu   sync.RWMutex
+	keys map[string][]*softwareKeyVersion // key ID → versions, oldest first
+}
+
+type softwareKeyVersion struct {
+	KeyVersion
+	signer   CryptoProvider
+	disabled bool
+}
+
+// NewSoftwareKeyService creates an empty key service.
+func NewSoftwareKeyService() *SoftwareKeyService {
+	return &SoftwareKeyService{keys: make(map[string][]*softwareKeyVersion)}
+}
+
+// CreateKey generates version 1 of a new key.
+func (s *SoftwareKeyService) CreateKey(keyID string, algorithm CryptoAlgorithm) (*KeyVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.keys[keyID]; exists {
+		return nil, fmt.Errorf("key %s already exists", keyID)
+	}
+	return s.addVersion(keyID, algorithm)
+}
+
+// RotateKey generates a new current version of keyID with the same
+// algorithm. Earlier versions keep signing until disabled.
+func (s *SoftwareKeyService) RotateKey(keyID string) (*KeyVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, ok := s.keys[keyID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return s.addVersion(keyID, versions[len(versions)-1].Algorithm)
+}
+
+// DisableVersion stops a key version from signing.
+func (s *SoftwareKeyService) DisableVersion(keyID, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.version(keyID, version)
+	if v == nil {
+		return ErrKeyNotFound
+	}
+	v.disabled = true
+	return nil
+}
+
+// addVersion appends a freshly generated version. Caller holds s.mu.
+func (s *SoftwareKeyService) addVersion(keyID string, algorithm CryptoAlgorithm) (*KeyVersion, error) {
+	signer, err := NewCryptoProvider(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	v := &softwareKeyVersion{
+		KeyVersion: KeyVersion{
+			KeyID:     keyID,
+			Version:   strconv.Itoa(len(s.keys[keyID]) + 1),
+			Algorithm: algorithm,
+			PublicKey: signer.PublicKeyBytes(),
+			CreatedAt: time.Now().UTC(),
+		},
+		signer: signer,
+	}
+	s.keys[keyID] = append(s.keys[keyID], v)
+	kv := v.KeyVersion
+	return &kv, nil
+}
+
+// version returns a key version, or nil. Caller holds s.mu.
+func (s *SoftwareKeyService) version(keyID, version string) *softwareKeyVersion {
+	for _, v := range s.keys[keyID] {
+		if v.Version == version {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *SoftwareKeyService) CurrentVersion(ctx context.Context, keyID string) (*KeyVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := s.keys[keyID]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !versions[i].disabled {
+			kv := versions[i].KeyVersion
+			return &kv, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+func (s *SoftwareKeyService) Sign(ctx context.Context, keyID, version string, data []byte) ([]byte, error) {
+	s.mu.RLock()
+	v := s.version(keyID, version)
+	s.mu.RUnlock()
+	if v == nil || v.disabled {
+		return nil, ErrKeyNotFound
+	}
+	return v.signer.Sign(data)
+}
+
+// Handler serves the HTTP key service API (see HTTPKeyService). A
+// non-empty token is required as a bearer token on every request.
+func (s *SoftwareKeyService) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /keys/{key_id}", func(w http.ResponseWriter, r *http.Request) {
+		v, err := s.CurrentVersion(r.Context(), r.PathValue("key_id"))
+		writeKeyServiceResponse(w, v, err)
+	})
+	mux.HandleFunc("POST /keys/{key_id}/versions/{version}/sign", func(w http.ResponseWriter, r *http.Request) {
+		var req keySignRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		sig, err := s.Sign(r.Context(), r.PathValue("key_id"), r.PathValue("version"), req.Data)
+		writeKeyServiceResponse(w, keySignResponse{Signature: sig}, err)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func writeKeyServiceResponse(w http.ResponseWriter, body interface{}, err error) {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		http.Error(w, `{"error":"key not found"}`, http.StatusNotFound)
+	case err != nil:
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}
+}
@@ -0,0 +1,571 @@
+package federation
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ocx/backend/internal/evidence"
+	pb "github.com/ocx/backend/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// ============================================================================
+// FEDERATED MESSAGE TRANSPORT
+//
+// SendMessage signs a FederatedMessage and delivers it to the destination
+// peer over pb.FederatedMessageService. On the receiving instance,
+// ReceiveMessage checks that the sender is a connected peer, verifies the
+// signature against the public key from its handshake attestation, rejects
+// expired messages, charges the federation trust tax and hands the message
+// to the local fabric hub. Both sides record an EvidenceFederation record
+// keyed by the message ID, so the two halves of an exchange correlate.
+// ============================================================================
+
+// DefaultMessageTTL is the lifetime, in seconds, given to messages sent
+// without one.
+const DefaultMessageTTL = 60
+
+// defaultMessageReplayWindow is how long received message IDs are
+// remembered unless SetMessageReplayStore says otherwise.
+const defaultMessageReplayWindow = 10 * time.Minute
+
+var (
+	ErrPeerNotConnected        = errors.New("peer not connected")
+	ErrInvalidMessageSignature = errors.New("invalid message signature")
+	ErrMessageExpired          = errors.New("message expired")
+	ErrMessageReplayed         = errors.New("message already received")
+	ErrMisaddressed            = errors.New("message addressed to another instance")
+	ErrUndeliverable           = errors.New("destination not reachable")
+	ErrNoTransportCredentials  = errors.New("no transport credentials for peer message endpoints")
+)
+
+// LocalDelivery hands an inbound federated message to the local fabric.
+// fabric.Hub implements it.
+type LocalDelivery interface {
+	// DeliverFederated routes msg to DestTenant/DestAgent. trustTax is the
+	// federation trust tax charged for the message.
+	DeliverFederated(ctx context.Context, msg *FederatedMessage, trustTax float64) error
+}
+
+// MessageReceipt is the receiver's acknowledgement of a delivered message.
+type MessageReceipt struct {
+	MessageID   string        `json:"message_id"`
+	ReceiverOCX OCXInstanceID `json:"receiver_ocx"`
+	TrustTax    float64       `json:"trust_tax"`
+	EvidenceID  string        `json:"evidence_id,omitempty"`
+	ReceivedAt  time.Time     `json:"received_at"`
+}
+
+// ExpiresAt returns the end of the message's lifetime.
+func (m *FederatedMessage) ExpiresAt() time.Time {
+	return m.Timestamp.Add(time.Duration(m.TTL) * time.Second)
+}
+
+// canonicalBytes is the signed form of the message: the signature is
+// excluded and the timestamp normalised to UTC so it survives the
+// nanosecond round trip through the wire envelope.
+func (m *FederatedMessage) canonicalBytes() ([]byte, error) {
+	copy := *m
+	copy.Signature = nil
+	copy.Timestamp = m.Timestamp.UTC().Round(0)
+	return json.Marshal(copy)
+}
+
+// messageReplayState remembers received message IDs for their lifetime.
+type messageReplayState struct {
+	seen   NonceStore
+	window time.Duration // longest message lifetime the store covers
+}
+
+// SetMessageReplayStore records received message IDs in store, which must
+// remember them for window. A RedisNonceStore shares them across replicas.
+// Messages whose lifetime, plus clock skew, ends after window are refused,
+// since their ID could be forgotten while they are still valid.
+func (fm *FederationManager) SetMessageReplayStore(store NonceStore, window time.Duration) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.messageReplay = messageReplayState{seen: store, window: window}
+}
+
+// messageReplayStore returns the replay store, creating an in-memory one on
+// first use.
+func (fm *FederationManager) messageReplayStore() messageReplayState {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if fm.messageReplay.seen == nil {
+		fm.messageReplay = messageReplayState{
+			seen:   NewInMemoryNonceStore(defaultMessageReplayWindow),
+			window: defaultMessageReplayWindow,
+		}
+	}
+	return fm.messageReplay
+}
+
+// SetLocalDelivery sets where inbound messages are routed. Without it,
+// inbound messages are rejected as undeliverable.
+func (fm *FederationManager) SetLocalDelivery(d LocalDelivery) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.delivery = d
+}
+
+// SetTrustLedger makes the trust tax follow each peer's earned trust score
+// instead of its handshake trust level.
+func (fm *FederationManager) SetTrustLedger(l *PersistentTrustLedger) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.trustLedger = l
+}
+
+// SetEvidenceVault enables EvidenceFederation records for sent and received
+// messages.
+func (fm *FederationManager) SetEvidenceVault(v *evidence.EvidenceVault) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.evidence = v
+}
+
+// SetDialOptions sets the options used to dial peer message endpoints,
+// including their transport credentials. Until it is called, SendMessage
+// refuses to dial.
+func (fm *FederationManager) SetDialOptions(opts ...grpc.DialOption) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.dialOptions = opts
+}
+
+// SetPeerEndpoint records the FederatedMessageService address of a
+// connected peer.
+func (fm *FederationManager) SetPeerEndpoint(id OCXInstanceID, endpoint string) error {
+	peer, err := fm.GetPeer(id)
+	if err != nil {
+		return err
+	}
+	peer.mu.Lock()
+	peer.Endpoint = endpoint
+	peer.mu.Unlock()
+	return nil
+}
+
+// Close closes connections to peer message endpoints.
+func (fm *FederationManager) Close() error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	var firstErr error
+	for endpoint, conn := range fm.clients {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(fm.clients, endpoint)
+	}
+	return firstErr
+}
+
+// SendMessage signs msg and delivers it to the peer named by DestOCX.
+// ID, SourceOCX, Timestamp and TTL are filled in when empty.
+func (fm *FederationManager) SendMessage(ctx context.Context, msg *FederatedMessage) (*MessageReceipt, error) {
+	fm.mu.RLock()
+	peer, exists := fm.peers[msg.DestOCX]
+	fm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("peer %s: %w", msg.DestOCX, ErrPeerNotConnected)
+	}
+
+	if !peer.IsHealthy() {
+		return nil, fmt.Errorf("peer %s is unhealthy", msg.DestOCX)
+	}
+
+	peer.mu.RLock()
+	endpoint := peer.Endpoint
+	peer.mu.RUnlock()
+	if endpoint == "" {
+		return nil, fmt.Errorf("peer %s has no message endpoint", msg.DestOCX)
+	}
+
+	if msg.ID == "" {
+		msg.ID = uuid.New().String()
+	}
+	msg.SourceOCX = fm.instanceID
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now().UTC()
+	}
+	if msg.TTL <= 0 {
+		msg.TTL = DefaultMessageTTL
+	}
+
+	// Sign the message
+	msgBytes, err := msg.canonicalBytes()
+	if err != nil {
+		return nil, fmt.Errorf("encode message %s: %w", msg.ID, err)
+	}
+	msg.Signature, err = fm.crypto.Sign(msgBytes)
+	if err != nil {
+		return nil, fmt.Errorf("sign message %s: %w", msg.ID, err)
+	}
+
+	client, err := fm.messageClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	trust := fm.peerTrust(peer)
+	resp, err := client.Deliver(ctx, envelopeFromMessage(msg))
+	if err != nil {
+		peer.mu.Lock()
+		peer.ErrorCount++
+		peer.mu.Unlock()
+		fm.recordEvidence(ctx, msg.SourceTenant, msg.SourceAgent, msg, msg.DestOCX, "outbound",
+			evidence.OutcomeBlock, trust, status.Convert(err).Message(), 0)
+		return nil, fmt.Errorf("deliver message %s to %s: %w", msg.ID, msg.DestOCX, err)
+	}
+
+	peer.mu.Lock()
+	peer.MessagesSent++
+	peer.BytesSent += int64(len(msgBytes))
+	peer.LastActivity = time.Now()
+	peer.mu.Unlock()
+
+	receipt := &MessageReceipt{
+		MessageID:   resp.MessageId,
+		ReceiverOCX: OCXInstanceID(resp.ReceiverOcx),
+		TrustTax:    resp.TrustTax,
+		EvidenceID:  resp.EvidenceId,
+		ReceivedAt:  time.Unix(0, resp.ReceivedAt).UTC(),
+	}
+	fm.recordEvidence(ctx, msg.SourceTenant, msg.SourceAgent, msg, msg.DestOCX, "outbound",
+		evidence.OutcomeAllow, trust, "delivered", receipt.TrustTax)
+
+	fm.logger.Printf("Sent message %s to %s (trust_tax=%.4f)", msg.ID, msg.DestOCX, receipt.TrustTax)
+
+	return receipt, nil
+}
+
+// ReceiveMessage accepts a message from a connected peer and routes it to
+// the local fabric. It is the server side of SendMessage.
+func (fm *FederationManager) ReceiveMessage(ctx context.Context, msg *FederatedMessage) (*MessageReceipt, error) {
+	if msg.DestOCX != fm.instanceID {
+		return nil, fmt.Errorf("%w: %s", ErrMisaddressed, msg.DestOCX)
+	}
+
+	fm.mu.RLock()
+	peer, exists := fm.peers[msg.SourceOCX]
+	delivery := fm.delivery
+	fm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("peer %s: %w", msg.SourceOCX, ErrPeerNotConnected)
+	}
+
+	peer.mu.RLock()
+	state, level, attestation := peer.State, peer.TrustLevel, peer.Attestation
+	peer.mu.RUnlock()
+	if state != StateConnected || level == TrustRevoked || attestation == nil {
+		return nil, fmt.Errorf("peer %s (state=%s, trust=%s): %w", msg.SourceOCX, state, level, ErrPeerNotConnected)
+	}
+	if err := fm.checkAttestationRevoked(msg.SourceOCX, attestation); err != nil {
+		return nil, fmt.Errorf("peer %s: %w: %v", msg.SourceOCX, ErrPeerNotConnected, err)
+	}
+
+	trust := fm.peerTrust(peer)
+	reject := func(err error) (*MessageReceipt, error) {
+		peer.mu.Lock()
+		peer.ErrorCount++
+		peer.mu.Unlock()
+		fm.recordEvidence(ctx, msg.DestTenant, msg.DestAgent, msg, msg.SourceOCX, "inbound",
+			evidence.OutcomeBlock, trust, err.Error(), 0)
+		fm.logger.Printf("Rejected message %s from %s: %v", msg.ID, msg.SourceOCX, err)
+		return nil, err
+	}
+
+	// Verify the signature against the key the peer attested to
+	msgBytes, err := msg.canonicalBytes()
+	if err != nil {
+		return reject(fmt.Errorf("%w: %v", ErrInvalidMessageSignature, err))
+	}
+	valid, err := fm.crypto.Verify(attestation.PublicKey, msgBytes, msg.Signature)
+	if err != nil || !valid {
+		return reject(ErrInvalidMessageSignature)
+	}
+
+	// Check the lifetime, allowing for clock drift between instances
+	now := time.Now()
+	if msg.TTL <= 0 || now.After(msg.ExpiresAt().Add(fm.maxClockSkew)) {
+		return reject(fmt.Errorf("%w: sent %s with ttl %ds", ErrMessageExpired, msg.Timestamp.Format(time.RFC3339), msg.TTL))
+	}
+	if msg.Timestamp.After(now.Add(fm.maxClockSkew)) {
+		return reject(fmt.Errorf("%w: timestamp %s is in the future", ErrMessageExpired, msg.Timestamp.Format(time.RFC3339)))
+	}
+
+	tax := fm.trustTax(trust)
+	if delivery == nil {
+		return reject(fmt.Errorf("%w: no local delivery configured", ErrUndeliverable))
+	}
+
+	// Deliver each message once: its ID is consumed before delivery, so a
+	// captured envelope replayed within its lifetime is refused, and a
+	// retry after a failed delivery needs a new ID
+	replay := fm.messageReplayStore()
+	if msg.ExpiresAt().Add(fm.maxClockSkew).After(now.Add(replay.window)) {
+		return reject(fmt.Errorf("%w: ttl %ds outlives the %s replay window", ErrMessageExpired, msg.TTL, replay.window))
+	}
+	if msg.ID == "" {
+		return reject(fmt.Errorf("%w: message has no ID", ErrInvalidMessageSignature))
+	}
+	if !replay.seen.MarkUsed(string(msg.SourceOCX) + ":" + msg.ID) {
+		// The first delivery already has its evidence record
+		fm.logger.Printf("Dropped replayed message %s from %s", msg.ID, msg.SourceOCX)
+		return nil, fmt.Errorf("%w: %s", ErrMessageReplayed, msg.ID)
+	}
+	if err := delivery.DeliverFederated(ctx, msg, tax); err != nil {
+		return reject(fmt.Errorf("%w: %v", ErrUndeliverable, err))
+	}
+
+	peer.mu.Lock()
+	peer.MessagesReceived++
+	peer.BytesReceived += int64(len(msgBytes))
+	peer.TrustTaxCharged += tax
+	peer.LastActivity = now
+	peer.LastHeartbeat = now
+	peer.mu.Unlock()
+
+	receipt := &MessageReceipt{
+		MessageID:   msg.ID,
+		ReceiverOCX: fm.instanceID,
+		TrustTax:    tax,
+		EvidenceID: fm.recordEvidence(ctx, msg.DestTenant, msg.DestAgent, msg, msg.SourceOCX, "inbound",
+			evidence.OutcomeAllow, trust, "delivered", tax),
+		ReceivedAt: now.UTC(),
+	}
+
+	fm.logger.Printf("Received message %s from %s for %s/%s (trust_tax=%.4f)",
+		msg.ID, msg.SourceOCX, msg.DestTenant, msg.DestAgent, tax)
+
+	if fm.onMessageReceived != nil {
+		fm.onMessageReceived(msg.SourceOCX, msg.Payload)
+	}
+
+	return receipt, nil
+}
+
+// peerTrust returns the peer's trust score: its ledger score when a trust
+// ledger is set, otherwise a score for its handshake trust level.
+func (fm *FederationManager) peerTrust(peer *PeerConnection) float64 {
+	fm.mu.RLock()
+	ledger := fm.trustLedger
+	fm.mu.RUnlock()
+	if ledger != nil {
+		return ledger.GetInstanceTrust(string(peer.ID))
+	}
+
+	peer.mu.RLock()
+	defer peer.mu.RUnlock()
+	switch peer.TrustLevel {
+	case TrustMutual:
+		return 0.9
+	case TrustVerified:
+		return 0.7
+	case TrustProvisional:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// trustTax applies the federation trust tax: (1 - trust) * base rate,
+// capped at the base rate. Higher trust = lower tax.
+func (fm *FederationManager) trustTax(trust float64) float64 {
+	tax := (1.0 - trust) * fm.taxBaseRate
+	if tax > fm.taxBaseRate {
+		tax = fm.taxBaseRate
+	}
+	if tax < 0 {
+		tax = 0
+	}
+	return tax
+}
+
+// recordEvidence writes an EvidenceFederation record and returns its ID,
+// or "" when no vault is set or the write fails.
+func (fm *FederationManager) recordEvidence(
+	ctx context.Context,
+	tenantID, agentID string,
+	msg *FederatedMessage,
+	peerID OCXInstanceID,
+	direction string,
+	verdict evidence.VerdictOutcome,
+	trust float64,
+	reasoning string,
+	trustTax float64,
+) string {
+	fm.mu.RLock()
+	vault := fm.evidence
+	fm.mu.RUnlock()
+	if vault == nil {
+		return ""
+	}
+
+	payloadHash := sha256.Sum256(msg.Payload)
+	record, err := vault.RecordFederation(ctx, tenantID, agentID, msg.ID, string(peerID), direction,
+		verdict, trust, reasoning, map[string]interface{}{
+			"source_ocx":     string(msg.SourceOCX),
+			"dest_ocx":       string(msg.DestOCX),
+			"source_tenant":  msg.SourceTenant,
+			"source_agent":   msg.SourceAgent,
+			"dest_tenant":    msg.DestTenant,
+			"dest_agent":     msg.DestAgent,
+			"message_type":   msg.MessageType,
+			"trace_id":       msg.TraceID,
+			"payload_sha256": hex.EncodeToString(payloadHash[:]),
+			"trust_tax":      trustTax,
+		})
+	if err != nil {
+		fm.logger.Printf("Failed to record federation evidence for %s: %v", msg.ID, err)
+		return ""
+	}
+	return record.ID
+}
+
+// messageClient returns a client for a peer message endpoint, dialing it
+// on first use.
+func (fm *FederationManager) messageClient(endpoint string) (pb.FederatedMessageServiceClient, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	conn, ok := fm.clients[endpoint]
+	if !ok {
+		if len(fm.dialOptions) == 0 {
+			return nil, fmt.Errorf("dial %s: %w", endpoint, ErrNoTransportCredentials)
+		}
+		var err error
+		conn, err = grpc.NewClient(endpoint, fm.dialOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+		}
+		fm.clients[endpoint] = conn
+	}
+	return pb.NewFederatedMessageServiceClient(conn), nil
+}
+
+func envelopeFromMessage(msg *FederatedMessage) *pb.FederatedEnvelope {
+	return &pb.FederatedEnvelope{
+		Id:           msg.ID,
+		SourceOcx:    string(msg.SourceOCX),
+		DestOcx:      string(msg.DestOCX),
+		SourceTenant: msg.SourceTenant,
+		DestTenant:   msg.DestTenant,
+		SourceAgent:  msg.SourceAgent,
+		DestAgent:    msg.DestAgent,
+		MessageType:  msg.MessageType,
+		Payload:      msg.Payload,
+		Timestamp:    msg.Timestamp.UnixNano(),
+		Ttl:          int32(msg.TTL),
+		TraceId:      msg.TraceID,
+		Signature:    msg.Signature,
+	}
+}
+
+func messageFromEnvelope(env *pb.FederatedEnvelope) *FederatedMessage {
+	return &FederatedMessage{
+		ID:           env.Id,
+		SourceOCX:    OCXInstanceID(env.SourceOcx),
+		DestOCX:      OCXInstanceID(env.DestOcx),
+		SourceTenant: env.SourceTenant,
+		DestTenant:   env.DestTenant,
+		SourceAgent:  env.SourceAgent,
+		DestAgent:    env.DestAgent,
+		MessageType:  env.MessageType,
+		Payload:      env.Payload,
+		Timestamp:    time.Unix(0, env.Timestamp).UTC(),
+		TTL:          int(env.Ttl),
+		TraceID:      env.TraceId,
+		Signature:    env.Signature,
+	}
+}
+
+// ============================================================================
+// gRPC SERVICE IMPLEMENTATION
+// ============================================================================
+
+// FederatedMessageServer implements pb.FederatedMessageServiceServer on top
+// of a FederationManager.
+type FederatedMessageServer struct {
+	pb.UnimplementedFederatedMessageServiceServer
+
+	manager *FederationManager
+}
+
+// NewFederatedMessageServer creates the message service for a manager.
+func NewFederatedMessageServer(fm *FederationManager) *FederatedMessageServer {
+	return &FederatedMessageServer{manager: fm}
+}
+
+// NewFederationGRPCServer builds a grpc.Server with the message service
+// registered. tlsConf should come from the SPIFFE server config. Only with
+// a nil tlsConf and allowInsecure does the server run without transport
+// security (development only; messages are still signature-checked); a nil
+// tlsConf without allowInsecure rejects every call.
+func NewFederationGRPCServer(fm *FederationManager, tlsConf *tls.Config, allowInsecure bool) *grpc.Server {
+	var opts []grpc.ServerOption
+	switch {
+	case tlsConf != nil:
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	case !allowInsecure:
+		refuse := status.Error(codes.Unauthenticated, "federation transport requires mTLS")
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(context.Context, interface{}, *grpc.UnaryServerInfo, grpc.UnaryHandler) (interface{}, error) {
+				return nil, refuse
+			}),
+			grpc.StreamInterceptor(func(interface{}, grpc.ServerStream, *grpc.StreamServerInfo, grpc.StreamHandler) error {
+				return refuse
+			}),
+		)
+	}
+	srv := grpc.NewServer(opts...)
+	pb.RegisterFederatedMessageServiceServer(srv, NewFederatedMessageServer(fm))
+	return srv
+}
+
+// Deliver receives one envelope from a peer.
+func (s *FederatedMessageServer) Deliver(ctx context.Context, env *pb.FederatedEnvelope) (*pb.DeliveryReceipt, error) {
+	receipt, err := s.manager.ReceiveMessage(ctx, messageFromEnvelope(env))
+	if err != nil {
+		return nil, status.Error(deliveryCode(err), err.Error())
+	}
+	return &pb.DeliveryReceipt{
+		MessageId:   receipt.MessageID,
+		ReceiverOcx: string(receipt.ReceiverOCX),
+		TrustTax:    receipt.TrustTax,
+		EvidenceId:  receipt.EvidenceID,
+		ReceivedAt:  receipt.ReceivedAt.UnixNano(),
+	}, nil
+}
+
+func deliveryCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, ErrInvalidMessageSignature):
+		return codes.Unauthenticated
+	case errors.Is(err, ErrPeerNotConnected):
+		return codes.PermissionDenied
+	case errors.Is(err, ErrMessageExpired):
+		return codes.FailedPrecondition
+	case errors.Is(err, ErrMessageReplayed):
+		return codes.AlreadyExists
+	case errors.Is(err, ErrMisaddressed):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrUndeliverable):
+		return codes.NotFound
+	default:
+		return codes.Internal
+	}
+}
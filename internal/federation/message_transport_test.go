@@ -0,0 +1,232 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ocx/backend/internal/evidence"
+	pb "github.com/ocx/backend/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// ============================================================================
+// FEDERATED MESSAGE TRANSPORT TESTS
+// ============================================================================
+
+type recordingDelivery struct {
+	mu       sync.Mutex
+	messages []*FederatedMessage
+	taxes    []float64
+}
+
+func (d *recordingDelivery) DeliverFederated(ctx context.Context, msg *FederatedMessage, trustTax float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = append(d.messages, msg)
+	d.taxes = append(d.taxes, trustTax)
+	return nil
+}
+
+// federatedPair connects two managers through the handshake and serves the
+// message service of "ocx-b" over bufconn.
+func federatedPair(t *testing.T) (a, b *FederationManager, delivery *recordingDelivery, vaultA, vaultB *evidence.EvidenceVault) {
+	t.Helper()
+	a, b = newTestManagers(t, "us-east-1", "eu-west-1")
+	require.NoError(t, runHandshake(t, a, b))
+
+	delivery = &recordingDelivery{}
+	vaultA = evidence.NewEvidenceVault(evidence.VaultConfig{})
+	vaultB = evidence.NewEvidenceVault(evidence.VaultConfig{})
+	a.SetEvidenceVault(vaultA)
+	b.SetEvidenceVault(vaultB)
+	b.SetLocalDelivery(delivery)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewFederationGRPCServer(b, nil, true)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	a.SetDialOptions(
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, a.SetPeerEndpoint("ocx-b", "passthrough:///ocx-b"))
+	t.Cleanup(func() { a.Close() })
+	return a, b, delivery, vaultA, vaultB
+}
+
+func TestFederatedMessageDelivery(t *testing.T) {
+	a, b, delivery, vaultA, vaultB := federatedPair(t)
+	ctx := context.Background()
+
+	receipt, err := a.SendMessage(ctx, &FederatedMessage{
+		DestOCX:      "ocx-b",
+		SourceTenant: "tenant-a",
+		SourceAgent:  "planner",
+		DestTenant:   "tenant-b",
+		DestAgent:    "booking-agent",
+		MessageType:  "task",
+		Payload:      []byte(`{"task":"reserve"}`),
+		TraceID:      "trace-1",
+	})
+	require.NoError(t, err)
+
+	// Verified peer without a ledger → trust 0.7 → tax (1-0.7)*0.10
+	assert.Equal(t, OCXInstanceID("ocx-b"), receipt.ReceiverOCX)
+	assert.InDelta(t, 0.03, receipt.TrustTax, 1e-9)
+	assert.NotEmpty(t, receipt.EvidenceID)
+
+	require.Len(t, delivery.messages, 1)
+	got := delivery.messages[0]
+	assert.Equal(t, OCXInstanceID("ocx-a"), got.SourceOCX)
+	assert.Equal(t, "tenant-b", got.DestTenant)
+	assert.Equal(t, "booking-agent", got.DestAgent)
+	assert.Equal(t, `{"task":"reserve"}`, string(got.Payload))
+
+	peerA, err := b.GetPeer("ocx-a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), peerA.MessagesReceived)
+	assert.InDelta(t, 0.03, peerA.TrustTaxCharged, 1e-9)
+
+	// Both sides hold an EvidenceFederation record for the same message
+	for side, vault := range map[string]*evidence.EvidenceVault{"sender": vaultA, "receiver": vaultB} {
+		records, err := vault.GetTransactionHistory(ctx, receipt.MessageID)
+		require.NoError(t, err, side)
+		require.Len(t, records, 1, side)
+		assert.Equal(t, evidence.EvidenceFederation, records[0].Type, side)
+		assert.Equal(t, evidence.OutcomeAllow, records[0].Verdict, side)
+	}
+}
+
+func TestFederatedMessageRejections(t *testing.T) {
+	a, b, delivery, _, vaultB := federatedPair(t)
+	ctx := context.Background()
+
+	n := 0
+	signed := func(mutate func(*FederatedMessage)) *FederatedMessage {
+		n++
+		msg := &FederatedMessage{
+			ID: fmt.Sprintf("msg-%d", n), SourceOCX: "ocx-a", DestOCX: "ocx-b",
+			DestTenant: "tenant-b", DestAgent: "booking-agent", Payload: []byte("hello"),
+			Timestamp: time.Now().UTC(), TTL: 60,
+		}
+		mutate(msg)
+		data, err := msg.canonicalBytes()
+		require.NoError(t, err)
+		msg.Signature, err = a.crypto.Sign(data)
+		require.NoError(t, err)
+		return msg
+	}
+
+	// Tampered after signing
+	tampered := signed(func(*FederatedMessage) {})
+	tampered.Payload = []byte("transfer everything")
+	_, err := b.ReceiveMessage(ctx, tampered)
+	assert.True(t, errors.Is(err, ErrInvalidMessageSignature), "got %v", err)
+
+	// Signed, but its lifetime ended ten minutes ago
+	expired := signed(func(m *FederatedMessage) { m.Timestamp = time.Now().Add(-10 * time.Minute).UTC() })
+	_, err = b.ReceiveMessage(ctx, expired)
+	assert.True(t, errors.Is(err, ErrMessageExpired), "got %v", err)
+
+	// Unknown sender
+	stranger := signed(func(m *FederatedMessage) { m.SourceOCX = "ocx-unknown" })
+	_, err = b.ReceiveMessage(ctx, stranger)
+	assert.True(t, errors.Is(err, ErrPeerNotConnected), "got %v", err)
+
+	assert.Empty(t, delivery.messages)
+
+	// Rejections are recorded on the receiver and surface as gRPC codes on
+	// the sender.
+	records, err := vaultB.GetTransactionHistory(ctx, tampered.ID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, evidence.OutcomeBlock, records[0].Verdict)
+
+	b.SetLocalDelivery(nil)
+	_, err = a.SendMessage(ctx, &FederatedMessage{DestOCX: "ocx-b", DestTenant: "tenant-b", DestAgent: "missing"})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(errors.Unwrap(err)))
+}
+
+func TestFederatedMessageReplayRefused(t *testing.T) {
+	a, b, delivery, _, vaultB := federatedPair(t)
+	ctx := context.Background()
+
+	msg := &FederatedMessage{
+		ID: "msg-replay", SourceOCX: "ocx-a", DestOCX: "ocx-b",
+		DestTenant: "tenant-b", DestAgent: "booking-agent", Payload: []byte("hello"),
+		Timestamp: time.Now().UTC(), TTL: 60,
+	}
+	data, err := msg.canonicalBytes()
+	require.NoError(t, err)
+	msg.Signature, err = a.crypto.Sign(data)
+	require.NoError(t, err)
+
+	_, err = b.ReceiveMessage(ctx, msg)
+	require.NoError(t, err)
+
+	// A captured envelope replayed within its lifetime is neither delivered,
+	// taxed nor recorded again
+	_, err = b.ReceiveMessage(ctx, msg)
+	assert.True(t, errors.Is(err, ErrMessageReplayed), "got %v", err)
+	assert.Equal(t, codes.AlreadyExists, deliveryCode(err))
+	assert.Len(t, delivery.messages, 1)
+	peerA, err := b.GetPeer("ocx-a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), peerA.MessagesReceived)
+	records, err := vaultB.GetTransactionHistory(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	// A lifetime longer than the replay window could outlast the seen ID
+	b.SetMessageReplayStore(NewInMemoryNonceStore(time.Minute), time.Minute)
+	long := &FederatedMessage{
+		ID: "msg-long", SourceOCX: "ocx-a", DestOCX: "ocx-b",
+		DestTenant: "tenant-b", DestAgent: "booking-agent", Timestamp: time.Now().UTC(), TTL: 3600,
+	}
+	data, err = long.canonicalBytes()
+	require.NoError(t, err)
+	long.Signature, err = a.crypto.Sign(data)
+	require.NoError(t, err)
+	_, err = b.ReceiveMessage(ctx, long)
+	assert.True(t, errors.Is(err, ErrMessageExpired), "got %v", err)
+}
+
+func TestFederationGRPCServerRefusesPlaintextByDefault(t *testing.T) {
+	_, b := newTestManagers(t, "", "")
+	lis := bufconn.Listen(1 << 20)
+	srv := NewFederationGRPCServer(b, nil, false)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///ocx-b",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	_, err = pb.NewFederatedMessageServiceClient(conn).Deliver(context.Background(), &pb.FederatedEnvelope{Id: "msg-1", SourceOcx: "ocx-a", DestOcx: "ocx-b"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "got %v", err)
+}
+
+func TestSendMessageRefusesWithoutDialCredentials(t *testing.T) {
+	a, b := newTestManagers(t, "", "")
+	require.NoError(t, runHandshake(t, a, b))
+	require.NoError(t, a.SetPeerEndpoint("ocx-b", "passthrough:///ocx-b"))
+
+	_, err := a.SendMessage(context.Background(), &FederatedMessage{DestOCX: "ocx-b", DestTenant: "tenant-b", DestAgent: "agent-2", Payload: []byte("hi")})
+	assert.True(t, errors.Is(err, ErrNoTransportCredentials), "got %v", err)
+}
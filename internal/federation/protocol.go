@@ -13,6 +13,9 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/ocx/backend/internal/evidence"
+	"google.golang.org/grpc"
 )
 
 // ============================================================================
@@ -61,6 +64,7 @@ type Attestation struct {
 	ValidUntil     time.Time              `json:"valid_until"`
 	Signature      []byte                 `json:"signature"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	Manifest       *IntegrityManifest     `json:"manifest,omitempty"` // integrity.go
 }
 
 // Sign signs the attestation using the given CryptoProvider.
@@ -102,18 +106,7 @@ func (a *Attestation) Verify() bool {
 	if err != nil {
 		return false
 	}
-
-	// Try Ed25519 first (fixed 32-byte public key)
-	if len(a.PublicKey) == 32 {
-		provider := &Ed25519Provider{}
-		valid, err := provider.Verify(a.PublicKey, data, a.Signature)
-		return err == nil && valid
-	}
-
-	// Try ECDSA (DER-encoded public key)
-	provider := &ECDSAProvider{}
-	valid, err := provider.Verify(a.PublicKey, data, a.Signature)
-	return err == nil && valid
+	return verifyDetectedKey(a.PublicKey, data, a.Signature)
 }
 
 func (a *Attestation) canonicalBytes() ([]byte, error) {
@@ -187,6 +180,10 @@ type PeerConnection struct {
 	BytesSent        int64
 	BytesReceived    int64
 	ErrorCount       int64
+	TrustTaxCharged  float64 // federation trust tax charged on inbound messages
+
+	// Integrity manifest checks that failed at handshake (integrity.go)
+	IntegrityMismatches []IntegrityMismatch
 
 	mu sync.RWMutex
 }
@@ -232,6 +229,21 @@ type FederationManager struct {
 	onPeerDisconnected func(peerID OCXInstanceID)
 	onMessageReceived  func(peerID OCXInstanceID, msg []byte)
 
+	// Message transport (message_transport.go)
+	clients       map[string]*grpc.ClientConn // peer endpoint → connection
+	dialOptions   []grpc.DialOption
+	delivery      LocalDelivery
+	trustLedger   *PersistentTrustLedger
+	evidence      *evidence.EvidenceVault
+	taxBaseRate   float64
+	maxClockSkew  time.Duration
+	messageReplay messageReplayState
+
+	revocations *RevocationRegistry                   // revocation.go
+	pinned      map[OCXInstanceID]*DiscoveredInstance // discovery.go
+	integrity   integrityState                        // integrity.go
+	reputation  reputationState                       // reputation_credential.go
+
 	mu     sync.RWMutex
 	logger *log.Logger
 }
@@ -243,6 +255,16 @@ type PendingHandshake struct {
 	Challenge   []byte
 	StartedAt   time.Time
 	Attestation *Attestation
+	Integrity   []IntegrityMismatch
+}
+
+// trustLevel is the trust a completed handshake grants: peers whose
+// integrity manifest did not match stay provisional.
+func (p *PendingHandshake) trustLevel() TrustLevel {
+	if len(p.Integrity) > 0 {
+		return TrustProvisional
+	}
+	return TrustVerified
 }
 
 // FederationConfig holds federation manager configuration
@@ -253,7 +275,11 @@ type FederationConfig struct {
 	GovernanceHash  string
 	Capabilities    []string
 	MaxPeers        int
-	CryptoAlgorithm CryptoAlgorithm // ed25519 (default) or ecdsa-p256
+	CryptoAlgorithm CryptoAlgorithm // ed25519 (default), ecdsa-p256, ml-dsa-65 or ed25519+ml-dsa-65
+	Signer          CryptoProvider  // existing signing key, e.g. an ExternalKeyProvider; overrides CryptoAlgorithm
+
+	TrustTaxBaseRate float64       // federation trust tax at zero trust (default 0.10)
+	MaxClockSkew     time.Duration // tolerated sender clock drift (default 30s)
 }
 
 // NewFederationManager creates a new federation manager
@@ -264,12 +290,25 @@ func NewFederationManager(cfg FederationConfig) (*FederationManager, error) {
 		alg = DefaultCryptoAlgorithm
 	}
 
-	provider, err := NewCryptoProvider(alg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create crypto provider: %w", err)
+	provider := cfg.Signer
+	if provider == nil {
+		var err error
+		provider, err = NewCryptoProvider(alg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create crypto provider: %w", err)
+		}
 	}
 
-	return &FederationManager{
+	taxBaseRate := cfg.TrustTaxBaseRate
+	if taxBaseRate <= 0 {
+		taxBaseRate = 0.10
+	}
+	maxClockSkew := cfg.MaxClockSkew
+	if maxClockSkew <= 0 {
+		maxClockSkew = 30 * time.Second
+	}
+
+	fm := &FederationManager{
 		instanceID:     cfg.InstanceID,
 		region:         cfg.Region,
 		version:        cfg.Version,
@@ -279,8 +318,16 @@ func NewFederationManager(cfg FederationConfig) (*FederationManager, error) {
 		governanceHash: cfg.GovernanceHash,
 		capabilities:   cfg.Capabilities,
 		maxPeers:       cfg.MaxPeers,
+		clients:        make(map[string]*grpc.ClientConn),
+		pinned:         make(map[OCXInstanceID]*DiscoveredInstance),
+		taxBaseRate:    taxBaseRate,
+		maxClockSkew:   maxClockSkew,
 		logger:         log.New(log.Writer(), fmt.Sprintf("[Federation:%s] ", cfg.InstanceID), log.LstdFlags),
-	}, nil
+	}
+	if ext, ok := provider.(*ExternalKeyProvider); ok {
+		ext.OnRotate(fm.signingKeyRotated)
+	}
+	return fm, nil
 }
 
 // CreateAttestation creates a signed attestation for this instance
@@ -297,6 +344,11 @@ func (fm *FederationManager) CreateAttestation(tenantCount, agentCount int) (*At
 		Timestamp:      time.Now(),
 		ValidUntil:     time.Now().Add(24 * time.Hour),
 	}
+	manifest, err := fm.CurrentManifest()
+	if err != nil {
+		return nil, err
+	}
+	attestation.Manifest = manifest
 
 	if err := attestation.Sign(fm.crypto); err != nil {
 		return nil, err
@@ -376,6 +428,28 @@ func (fm *FederationManager) handleHello(msg *HandshakeMessage) (*HandshakeMessa
 			Timestamp:  time.Now(),
 		}, errors.New("invalid attestation")
 	}
+	if err := fm.checkAttestationRevoked(msg.InstanceID, msg.Attestation); err != nil {
+		return &HandshakeMessage{
+			Type:       HandshakeReject,
+			InstanceID: fm.instanceID,
+			Timestamp:  time.Now(),
+		}, err
+	}
+	if err := fm.checkPinnedKey(msg.InstanceID, msg.Attestation); err != nil {
+		return &HandshakeMessage{
+			Type:       HandshakeReject,
+			InstanceID: fm.instanceID,
+			Timestamp:  time.Now(),
+		}, err
+	}
+	integrity, err := fm.checkIntegrity(msg.InstanceID, msg.Attestation)
+	if err != nil {
+		return &HandshakeMessage{
+			Type:       HandshakeReject,
+			InstanceID: fm.instanceID,
+			Timestamp:  time.Now(),
+		}, err
+	}
 
 	// Generate challenge
 	challenge := make([]byte, 32)
@@ -391,6 +465,7 @@ func (fm *FederationManager) handleHello(msg *HandshakeMessage) (*HandshakeMessa
 		Challenge:   challenge,
 		StartedAt:   time.Now(),
 		Attestation: msg.Attestation,
+		Integrity:   integrity,
 	}
 	fm.mu.Unlock()
 
@@ -411,6 +486,48 @@ func (fm *FederationManager) handleHello(msg *HandshakeMessage) (*HandshakeMessa
 }
 
 func (fm *FederationManager) handleChallenge(msg *HandshakeMessage) (*HandshakeMessage, error) {
+	// The challenger attests too; keep its attestation until it confirms so
+	// both sides end the handshake holding the other's public key.
+	if msg.Attestation == nil || !msg.Attestation.Verify() {
+		return &HandshakeMessage{
+			Type:       HandshakeReject,
+			InstanceID: fm.instanceID,
+			Timestamp:  time.Now(),
+		}, errors.New("invalid attestation")
+	}
+	if err := fm.checkAttestationRevoked(msg.InstanceID, msg.Attestation); err != nil {
+		return &HandshakeMessage{
+			Type:       HandshakeReject,
+			InstanceID: fm.instanceID,
+			Timestamp:  time.Now(),
+		}, err
+	}
+	if err := fm.checkPinnedKey(msg.InstanceID, msg.Attestation); err != nil {
+		return &HandshakeMessage{
+			Type:       HandshakeReject,
+			InstanceID: fm.instanceID,
+			Timestamp:  time.Now(),
+		}, err
+	}
+	integrity, err := fm.checkIntegrity(msg.InstanceID, msg.Attestation)
+	if err != nil {
+		return &HandshakeMessage{
+			Type:       HandshakeReject,
+			InstanceID: fm.instanceID,
+			Timestamp:  time.Now(),
+		}, err
+	}
+	fm.mu.Lock()
+	fm.pendingPeers[msg.InstanceID] = &PendingHandshake{
+		PeerID:      msg.InstanceID,
+		Nonce:       msg.Nonce,
+		Challenge:   msg.Challenge,
+		StartedAt:   time.Now(),
+		Attestation: msg.Attestation,
+		Integrity:   integrity,
+	}
+	fm.mu.Unlock()
+
 	// Sign the challenge with our crypto provider
 	response, err := fm.crypto.Sign(msg.Challenge)
 	if err != nil {
@@ -449,20 +566,22 @@ func (fm *FederationManager) handleResponse(msg *HandshakeMessage) (*HandshakeMe
 
 	// Create peer connection
 	conn := &PeerConnection{
-		ID:            msg.InstanceID,
-		State:         StateConnected,
-		TrustLevel:    TrustVerified,
-		Attestation:   pending.Attestation,
-		ConnectedAt:   time.Now(),
-		LastHeartbeat: time.Now(),
-		LastActivity:  time.Now(),
+		ID:                  msg.InstanceID,
+		State:               StateConnected,
+		TrustLevel:          pending.trustLevel(),
+		Attestation:         pending.Attestation,
+		Endpoint:            fm.pinnedEndpoint(msg.InstanceID),
+		ConnectedAt:         time.Now(),
+		LastHeartbeat:       time.Now(),
+		LastActivity:        time.Now(),
+		IntegrityMismatches: pending.Integrity,
 	}
 
 	fm.peers[msg.InstanceID] = conn
 	delete(fm.pendingPeers, msg.InstanceID)
 	fm.mu.Unlock()
 
-	fm.logger.Printf("Handshake complete with %s (trust=%s)", msg.InstanceID, TrustVerified)
+	fm.logger.Printf("Handshake complete with %s (trust=%s)", msg.InstanceID, conn.TrustLevel)
 
 	if fm.onPeerConnected != nil {
 		fm.onPeerConnected(msg.InstanceID)
@@ -476,7 +595,34 @@ func (fm *FederationManager) handleResponse(msg *HandshakeMessage) (*HandshakeMe
 }
 
 func (fm *FederationManager) handleConfirm(msg *HandshakeMessage) (*HandshakeMessage, error) {
-	fm.logger.Printf("Handshake confirmed by %s", msg.InstanceID)
+	fm.mu.Lock()
+	pending, exists := fm.pendingPeers[msg.InstanceID]
+	if !exists {
+		fm.mu.Unlock()
+		fm.logger.Printf("Handshake confirmed by %s", msg.InstanceID)
+		return nil, nil // No response needed
+	}
+	delete(fm.pendingPeers, msg.InstanceID)
+	if _, connected := fm.peers[msg.InstanceID]; !connected {
+		fm.peers[msg.InstanceID] = &PeerConnection{
+			ID:                  msg.InstanceID,
+			State:               StateConnected,
+			TrustLevel:          pending.trustLevel(),
+			Attestation:         pending.Attestation,
+			Endpoint:            fm.pinnedEndpoint(msg.InstanceID),
+			ConnectedAt:         time.Now(),
+			LastHeartbeat:       time.Now(),
+			LastActivity:        time.Now(),
+			IntegrityMismatches: pending.Integrity,
+		}
+	}
+	fm.mu.Unlock()
+
+	fm.logger.Printf("Handshake confirmed by %s (trust=%s)", msg.InstanceID, pending.trustLevel())
+
+	if fm.onPeerConnected != nil {
+		fm.onPeerConnected(msg.InstanceID)
+	}
 	return nil, nil // No response needed
 }
 
@@ -558,35 +704,6 @@ type FederatedMessage struct {
 	Signature    []byte        `json:"signature"`
 }
 
-// SendMessage sends a message to a federated peer
-func (fm *FederationManager) SendMessage(ctx context.Context, msg *FederatedMessage) error {
-	fm.mu.RLock()
-	peer, exists := fm.peers[msg.DestOCX]
-	fm.mu.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("peer %s not connected", msg.DestOCX)
-	}
-
-	if !peer.IsHealthy() {
-		return fmt.Errorf("peer %s is unhealthy", msg.DestOCX)
-	}
-
-	// Sign the message
-	msgBytes, _ := json.Marshal(msg)
-	msg.Signature, _ = fm.crypto.Sign(msgBytes)
-
-	peer.mu.Lock()
-	peer.MessagesSent++
-	peer.BytesSent += int64(len(msgBytes))
-	peer.LastActivity = time.Now()
-	peer.mu.Unlock()
-
-	fm.logger.Printf("Sent message %s to %s", msg.ID, msg.DestOCX)
-
-	return nil
-}
-
 // Stats returns federation statistics
 func (fm *FederationManager) Stats() map[string]interface{} {
 	fm.mu.RLock()
@@ -595,6 +712,7 @@ func (fm *FederationManager) Stats() map[string]interface{} {
 	connected := 0
 	totalSent := int64(0)
 	totalRecv := int64(0)
+	totalTax := 0.0
 
 	for _, p := range fm.peers {
 		if p.State == StateConnected {
@@ -602,6 +720,7 @@ func (fm *FederationManager) Stats() map[string]interface{} {
 		}
 		totalSent += p.MessagesSent
 		totalRecv += p.MessagesReceived
+		totalTax += p.TrustTaxCharged
 	}
 
 	return map[string]interface{}{
@@ -612,5 +731,6 @@ func (fm *FederationManager) Stats() map[string]interface{} {
 		"pending_handshakes": len(fm.pendingPeers),
 		"messages_sent":      totalSent,
 		"messages_received":  totalRecv,
+		"trust_tax_charged":  totalTax,
 	}
 }
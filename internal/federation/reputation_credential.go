@@ -0,0 +1,376 @@
+package federation
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ============================================================================
+// PORTABLE REPUTATION CREDENTIALS
+//
+// An instance issues a ReputationCredential for one of its agents, signed
+// with its attestation key and bound to the agent's own public key. When
+// the agent moves, the instance it moves to hands out a CredentialChallenge,
+// the agent signs it with that key, and the importing instance verifies the
+// credential against the key the issuer presented in its handshake and
+// seeds the agent's reputation with the issued score discounted by its
+// trust in the issuer (PersistentTrustLedger). A low-trust issuer can
+// therefore not launder reputation: its credentials are worth little, and
+// below MinIssuerTrust nothing at all. A credential is imported at most
+// once; a copy is worthless without the agent's key.
+// ============================================================================
+
+// credentialChallengeTTL bounds how long a challenge can be answered.
+const credentialChallengeTTL = 5 * time.Minute
+
+// defaultCredentialReplayWindow is how long consumed credential IDs are
+// remembered unless SetCredentialReplayStore says otherwise.
+const defaultCredentialReplayWindow = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidCredential is returned for a malformed credential or a bad
+	// signature.
+	ErrInvalidCredential = errors.New("invalid reputation credential")
+
+	// ErrCredentialExpired is returned for a credential past its expiry.
+	ErrCredentialExpired = errors.New("reputation credential expired")
+
+	// ErrUnknownIssuer is returned when the issuer is not a connected peer or
+	// signed with a key other than the one it attested to.
+	ErrUnknownIssuer = errors.New("reputation credential issuer unknown")
+
+	// ErrIssuerNotTrusted is returned when the issuer's trust is below the
+	// import policy's minimum.
+	ErrIssuerNotTrusted = errors.New("reputation credential issuer not trusted")
+
+	// ErrInvalidPresentation is returned when the challenge is not one this
+	// instance issued, has expired or was already answered, or the proof is
+	// not signed with the credential's subject key.
+	ErrInvalidPresentation = errors.New("invalid reputation credential presentation")
+
+	// ErrCredentialReplayed is returned for a credential already imported.
+	ErrCredentialReplayed = errors.New("reputation credential already imported")
+)
+
+// ReputationHistory summarises the interactions behind an issued score.
+type ReputationHistory struct {
+	TotalInteractions      int64     `json:"total_interactions"`
+	SuccessfulInteractions int64     `json:"successful_interactions"`
+	FailedInteractions     int64     `json:"failed_interactions"`
+	FirstSeen              time.Time `json:"first_seen"`
+	LastActive             time.Time `json:"last_active"`
+}
+
+// ReputationCredential is a signed statement of an agent's reputation on
+// the issuing instance.
+type ReputationCredential struct {
+	CredentialID     string            `json:"credential_id"`
+	AgentID          string            `json:"agent_id"`
+	TenantID         string            `json:"tenant_id"`          // tenant on the issuing instance
+	SubjectPublicKey []byte            `json:"subject_public_key"` // agent key that must sign the presentation
+	Score            float64           `json:"score"`
+	History          ReputationHistory `json:"history"`
+	Issuer           OCXInstanceID     `json:"issuer"`
+	IssuedAt         time.Time         `json:"issued_at"`
+	ExpiresAt        time.Time         `json:"expires_at"`
+	PublicKey        []byte            `json:"public_key"` // issuer attestation key
+	Signature        []byte            `json:"signature"`
+}
+
+// CredentialChallenge is a nonce the importing instance signs and hands to
+// the presenter for one import into TenantID.
+type CredentialChallenge struct {
+	Nonce     string        `json:"nonce"`
+	Audience  OCXInstanceID `json:"audience"`  // the importing instance
+	TenantID  string        `json:"tenant_id"` // the importing tenant
+	ExpiresAt time.Time     `json:"expires_at"`
+	Signature []byte        `json:"signature"` // by the audience
+}
+
+func (ch *CredentialChallenge) canonicalBytes() ([]byte, error) {
+	copy := *ch
+	copy.Signature = nil
+	return json.Marshal(copy)
+}
+
+// CredentialPresentation is a credential together with the subject's
+// signature over a challenge from the importing instance.
+type CredentialPresentation struct {
+	Credential *ReputationCredential `json:"credential"`
+	Challenge  *CredentialChallenge  `json:"challenge"`
+	Proof      []byte                `json:"proof"` // by the credential's subject key over ProofBytes
+}
+
+// ProofBytes is what the subject signs: the challenge bound to the
+// credential it is presenting.
+func (p *CredentialPresentation) ProofBytes() ([]byte, error) {
+	if p.Credential == nil || p.Challenge == nil {
+		return nil, fmt.Errorf("%w: credential and challenge are required", ErrInvalidPresentation)
+	}
+	return json.Marshal(struct {
+		CredentialID string        `json:"credential_id"`
+		Nonce        string        `json:"nonce"`
+		Audience     OCXInstanceID `json:"audience"`
+		TenantID     string        `json:"tenant_id"`
+	}{p.Credential.CredentialID, p.Challenge.Nonce, p.Challenge.Audience, p.Challenge.TenantID})
+}
+
+// reputationState is the replay protection for imported credentials.
+type reputationState struct {
+	consumed NonceStore    // credential IDs and answered challenge nonces
+	window   time.Duration // longest credential lifetime the store covers
+}
+
+// SetCredentialReplayStore records consumed credential IDs and challenge
+// nonces in store, which must remember them for window. A RedisNonceStore
+// shares them across replicas. Credentials valid for longer than window are
+// refused, since their ID could be forgotten before they expire.
+func (fm *FederationManager) SetCredentialReplayStore(store NonceStore, window time.Duration) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.reputation = reputationState{consumed: store, window: window}
+}
+
+// credentialReplay returns the replay store, creating an in-memory one on
+// first use.
+func (fm *FederationManager) credentialReplay() reputationState {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if fm.reputation.consumed == nil {
+		fm.reputation = reputationState{
+			consumed: NewInMemoryNonceStore(defaultCredentialReplayWindow),
+			window:   defaultCredentialReplayWindow,
+		}
+	}
+	return fm.reputation
+}
+
+func (c *ReputationCredential) canonicalBytes() ([]byte, error) {
+	copy := *c
+	copy.Signature = nil
+	return json.Marshal(copy)
+}
+
+// IssueReputationCredential signs a credential stating agentID's score and
+// history in tenantID, valid for ttl and presentable only with the private
+// key of subjectKey.
+func (fm *FederationManager) IssueReputationCredential(tenantID, agentID string, subjectKey []byte, score float64, history ReputationHistory, ttl time.Duration) (*ReputationCredential, error) {
+	if agentID == "" {
+		return nil, fmt.Errorf("%w: agent ID is required", ErrInvalidCredential)
+	}
+	if len(subjectKey) == 0 {
+		return nil, fmt.Errorf("%w: subject public key is required", ErrInvalidCredential)
+	}
+	if math.IsNaN(score) || score < 0 || score > 1 {
+		return nil, fmt.Errorf("%w: score %.2f outside [0, 1]", ErrInvalidCredential, score)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w: ttl must be positive", ErrInvalidCredential)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	c := &ReputationCredential{
+		CredentialID:     "repcred-" + hex.EncodeToString(id),
+		AgentID:          agentID,
+		TenantID:         tenantID,
+		SubjectPublicKey: subjectKey,
+		Score:            score,
+		History:          history,
+		Issuer:           fm.instanceID,
+		IssuedAt:         now,
+		ExpiresAt:        now.Add(ttl),
+		PublicKey:        fm.crypto.PublicKeyBytes(),
+	}
+	data, err := c.canonicalBytes()
+	if err != nil {
+		return nil, err
+	}
+	if c.Signature, err = fm.crypto.Sign(data); err != nil {
+		return nil, fmt.Errorf("reputation credential sign failed: %w", err)
+	}
+	return c, nil
+}
+
+// VerifyReputationCredential checks a credential's issuer, key, signature
+// and lifetime, and returns the issuer's trust score.
+func (fm *FederationManager) VerifyReputationCredential(c *ReputationCredential) (float64, error) {
+	if c == nil || c.AgentID == "" || len(c.SubjectPublicKey) == 0 || len(c.Signature) == 0 {
+		return 0, fmt.Errorf("%w: agent ID, subject key and signature are required", ErrInvalidCredential)
+	}
+	if math.IsNaN(c.Score) || c.Score < 0 || c.Score > 1 {
+		return 0, fmt.Errorf("%w: score %.2f outside [0, 1]", ErrInvalidCredential, c.Score)
+	}
+
+	fm.mu.RLock()
+	peer, exists := fm.peers[c.Issuer]
+	fm.mu.RUnlock()
+	if !exists {
+		return 0, fmt.Errorf("%w: %s is not a connected peer", ErrUnknownIssuer, c.Issuer)
+	}
+	peer.mu.RLock()
+	level, attestation := peer.TrustLevel, peer.Attestation
+	peer.mu.RUnlock()
+	if level == TrustRevoked || attestation == nil || !bytes.Equal(attestation.PublicKey, c.PublicKey) {
+		return 0, fmt.Errorf("%w: %s did not attest to the signing key", ErrUnknownIssuer, c.Issuer)
+	}
+	if err := fm.checkAttestationRevoked(c.Issuer, attestation); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownIssuer, err)
+	}
+
+	data, err := c.canonicalBytes()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+	if !verifyDetectedKey(c.PublicKey, data, c.Signature) {
+		return 0, fmt.Errorf("%w: bad signature", ErrInvalidCredential)
+	}
+
+	now := time.Now()
+	if now.After(c.ExpiresAt.Add(fm.maxClockSkew)) {
+		return 0, fmt.Errorf("%w: expired %s", ErrCredentialExpired, c.ExpiresAt.Format(time.RFC3339))
+	}
+	if c.IssuedAt.After(now.Add(fm.maxClockSkew)) {
+		return 0, fmt.Errorf("%w: issued %s is in the future", ErrInvalidCredential, c.IssuedAt.Format(time.RFC3339))
+	}
+	return fm.peerTrust(peer), nil
+}
+
+// ReputationImportPolicy bounds what an imported credential is worth.
+type ReputationImportPolicy struct {
+	MinIssuerTrust          float64 // issuers below this are refused
+	MaxImportedScore        float64 // cap on the discounted score
+	MaxImportedInteractions int64   // cap on how much issuer history counts locally
+}
+
+// DefaultReputationImportPolicy refuses issuers below neutral trust, caps
+// imported scores at 0.8 and counts at most 100 issuer interactions.
+func DefaultReputationImportPolicy() ReputationImportPolicy {
+	return ReputationImportPolicy{MinIssuerTrust: 0.5, MaxImportedScore: 0.8, MaxImportedInteractions: 100}
+}
+
+// ImportedReputation is the local value of a verified credential.
+type ImportedReputation struct {
+	CredentialID string        `json:"credential_id"`
+	AgentID      string        `json:"agent_id"`
+	Issuer       OCXInstanceID `json:"issuer"`
+	IssuedScore  float64       `json:"issued_score"`
+	IssuerTrust  float64       `json:"issuer_trust"`
+	Score        float64       `json:"score"`  // issued score × issuer trust, capped
+	Weight       float64       `json:"weight"` // interactions the imported score counts as
+}
+
+// NewCredentialChallenge signs a fresh challenge for presenting a
+// credential to tenantID on this instance.
+func (fm *FederationManager) NewCredentialChallenge(tenantID string) (*CredentialChallenge, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ch := &CredentialChallenge{
+		Nonce:     hex.EncodeToString(nonce),
+		Audience:  fm.instanceID,
+		TenantID:  tenantID,
+		ExpiresAt: time.Now().UTC().Add(credentialChallengeTTL),
+	}
+	data, err := ch.canonicalBytes()
+	if err != nil {
+		return nil, err
+	}
+	if ch.Signature, err = fm.crypto.Sign(data); err != nil {
+		return nil, fmt.Errorf("credential challenge sign failed: %w", err)
+	}
+	return ch, nil
+}
+
+// verifyPresentation checks that p answers a live challenge this instance
+// issued for tenantID, signed with the credential's subject key.
+func (fm *FederationManager) verifyPresentation(p *CredentialPresentation, tenantID string) error {
+	ch := p.Challenge
+	if ch == nil || len(p.Proof) == 0 {
+		return fmt.Errorf("%w: challenge and proof are required", ErrInvalidPresentation)
+	}
+	if ch.Audience != fm.instanceID || ch.TenantID != tenantID {
+		return fmt.Errorf("%w: challenge issued for %s/%s", ErrInvalidPresentation, ch.Audience, ch.TenantID)
+	}
+	data, err := ch.canonicalBytes()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPresentation, err)
+	}
+	if !verifyDetectedKey(fm.crypto.PublicKeyBytes(), data, ch.Signature) {
+		return fmt.Errorf("%w: challenge not issued by this instance", ErrInvalidPresentation)
+	}
+	if time.Now().After(ch.ExpiresAt) {
+		return fmt.Errorf("%w: challenge expired %s", ErrInvalidPresentation, ch.ExpiresAt.Format(time.RFC3339))
+	}
+	proof, err := p.ProofBytes()
+	if err != nil {
+		return err
+	}
+	if !verifyDetectedKey(p.Credential.SubjectPublicKey, proof, p.Proof) {
+		return fmt.Errorf("%w: proof not signed with the subject key", ErrInvalidPresentation)
+	}
+	return nil
+}
+
+// ImportReputationCredential verifies a presented credential for tenantID,
+// consumes it and its challenge, and discounts it by the issuer's trust
+// under policy.
+func (fm *FederationManager) ImportReputationCredential(p *CredentialPresentation, tenantID string, policy ReputationImportPolicy) (*ImportedReputation, error) {
+	if p == nil || p.Credential == nil {
+		return nil, fmt.Errorf("%w: credential is required", ErrInvalidCredential)
+	}
+	c := p.Credential
+	trust, err := fm.VerifyReputationCredential(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := fm.verifyPresentation(p, tenantID); err != nil {
+		return nil, err
+	}
+	replay := fm.credentialReplay()
+	if c.ExpiresAt.After(time.Now().Add(replay.window)) {
+		return nil, fmt.Errorf("%w: valid until %s, beyond the %s replay window",
+			ErrInvalidCredential, c.ExpiresAt.Format(time.RFC3339), replay.window)
+	}
+	if trust < policy.MinIssuerTrust {
+		return nil, fmt.Errorf("%w: %s trust %.2f below %.2f", ErrIssuerNotTrusted, c.Issuer, trust, policy.MinIssuerTrust)
+	}
+	if !replay.consumed.MarkUsed("challenge:" + p.Challenge.Nonce) {
+		return nil, fmt.Errorf("%w: challenge already answered", ErrInvalidPresentation)
+	}
+	if !replay.consumed.MarkUsed("credential:" + string(c.Issuer) + ":" + c.CredentialID) {
+		return nil, fmt.Errorf("%w: %s", ErrCredentialReplayed, c.CredentialID)
+	}
+
+	score := c.Score * trust
+	if policy.MaxImportedScore > 0 && score > policy.MaxImportedScore {
+		score = policy.MaxImportedScore
+	}
+	interactions := c.History.TotalInteractions
+	if policy.MaxImportedInteractions > 0 && interactions > policy.MaxImportedInteractions {
+		interactions = policy.MaxImportedInteractions
+	}
+
+	imported := &ImportedReputation{
+		CredentialID: c.CredentialID,
+		AgentID:      c.AgentID,
+		Issuer:       c.Issuer,
+		IssuedScore:  c.Score,
+		IssuerTrust:  trust,
+		Score:        score,
+		Weight:       float64(interactions) * trust,
+	}
+	fm.logger.Printf("Imported reputation of %s from %s: %.2f × trust %.2f = %.2f",
+		c.AgentID, c.Issuer, c.Score, trust, score)
+	return imported, nil
+}
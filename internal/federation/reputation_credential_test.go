@@ -0,0 +1,179 @@
+package federation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// REPUTATION CREDENTIAL TESTS
+// ============================================================================
+
+// agentKey generates the key an agent binds its credentials to.
+func agentKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return pub, priv
+}
+
+// present answers a fresh challenge from importer for tenantID with key.
+func present(t *testing.T, importer *FederationManager, tenantID string, c *ReputationCredential, key ed25519.PrivateKey) *CredentialPresentation {
+	t.Helper()
+	challenge, err := importer.NewCredentialChallenge(tenantID)
+	require.NoError(t, err)
+	p := &CredentialPresentation{Credential: c, Challenge: challenge}
+	data, err := p.ProofBytes()
+	require.NoError(t, err)
+	p.Proof = ed25519.Sign(key, data)
+	return p
+}
+
+func TestReputationCredentialDiscountedByIssuerTrust(t *testing.T) {
+	a, b := handshakePair(t)
+	ledger := NewPersistentTrustLedger()
+	b.SetTrustLedger(ledger)
+	pub, priv := agentKey(t)
+
+	issue := func() *ReputationCredential {
+		c, err := a.IssueReputationCredential("tenant-a", "agent-1", pub, 0.9, ReputationHistory{
+			TotalInteractions: 500, SuccessfulInteractions: 450, FailedInteractions: 50,
+		}, time.Hour)
+		require.NoError(t, err)
+		return c
+	}
+	credential := issue()
+	assert.Equal(t, OCXInstanceID("ocx-a"), credential.Issuer)
+
+	// Unknown issuer → neutral 0.5 ledger trust
+	imported, err := b.ImportReputationCredential(present(t, b, "tenant-a", credential, priv), "tenant-a", DefaultReputationImportPolicy())
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, imported.IssuerTrust, 0.001)
+	assert.InDelta(t, 0.45, imported.Score, 0.001)
+	assert.InDelta(t, 50, imported.Weight, 0.001) // 100 interactions × 0.5
+
+	// Highly trusted issuer, capped at MaxImportedScore
+	_, err = ledger.RecordHandshake(t.Context(), "ocx-b", "ocx-a", "ocx-a.example", "Org A", "agent-1", 1.0, 1.0, true)
+	require.NoError(t, err)
+	imported, err = b.ImportReputationCredential(present(t, b, "tenant-a", issue(), priv), "tenant-a", DefaultReputationImportPolicy())
+	require.NoError(t, err)
+	assert.Greater(t, imported.IssuerTrust, 0.5)
+	assert.LessOrEqual(t, imported.Score, 0.8)
+
+	// A penalised issuer cannot launder reputation
+	_, err = ledger.RecordPenalty(t.Context(), "ocx-b", "ocx-a", "test", 0.2)
+	require.NoError(t, err)
+	_, err = b.ImportReputationCredential(present(t, b, "tenant-a", issue(), priv), "tenant-a", DefaultReputationImportPolicy())
+	assert.True(t, errors.Is(err, ErrIssuerNotTrusted), "got %v", err)
+}
+
+func TestReputationCredentialRejected(t *testing.T) {
+	a, b := handshakePair(t)
+	policy := DefaultReputationImportPolicy()
+	pub, priv := agentKey(t)
+
+	issue := func() *ReputationCredential {
+		c, err := a.IssueReputationCredential("tenant-a", "agent-1", pub, 0.9, ReputationHistory{TotalInteractions: 10}, time.Hour)
+		require.NoError(t, err)
+		return c
+	}
+	importAs := func(c *ReputationCredential) error {
+		_, err := b.ImportReputationCredential(present(t, b, "tenant-a", c, priv), "tenant-a", policy)
+		return err
+	}
+
+	tampered := issue()
+	tampered.Score = 1.0
+	err := importAs(tampered)
+	assert.True(t, errors.Is(err, ErrInvalidCredential), "got %v", err)
+
+	b.maxClockSkew = 0
+	expired, err := a.IssueReputationCredential("tenant-a", "agent-1", pub, 0.9, ReputationHistory{}, time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	err = importAs(expired)
+	assert.True(t, errors.Is(err, ErrCredentialExpired), "got %v", err)
+
+	// Signed by an instance b never handshook with
+	c, err := NewFederationManager(FederationConfig{InstanceID: "ocx-c", MaxPeers: 4})
+	require.NoError(t, err)
+	foreign, err := c.IssueReputationCredential("tenant-c", "agent-1", pub, 0.9, ReputationHistory{}, time.Hour)
+	require.NoError(t, err)
+	err = importAs(foreign)
+	assert.True(t, errors.Is(err, ErrUnknownIssuer), "got %v", err)
+
+	// Claiming to be a connected peer with a different key
+	foreign.Issuer = "ocx-a"
+	err = importAs(foreign)
+	assert.True(t, errors.Is(err, ErrUnknownIssuer), "got %v", err)
+
+	// Valid for longer than b remembers consumed credentials
+	b.SetCredentialReplayStore(NewInMemoryNonceStore(time.Minute), time.Minute)
+	err = importAs(issue())
+	assert.True(t, errors.Is(err, ErrInvalidCredential), "got %v", err)
+
+	_, err = a.IssueReputationCredential("tenant-a", "agent-1", pub, 1.5, ReputationHistory{}, time.Hour)
+	assert.True(t, errors.Is(err, ErrInvalidCredential), "got %v", err)
+	_, err = a.IssueReputationCredential("tenant-a", "agent-1", nil, 0.9, ReputationHistory{}, time.Hour)
+	assert.True(t, errors.Is(err, ErrInvalidCredential), "got %v", err)
+}
+
+func TestReputationCredentialRequiresHolderKey(t *testing.T) {
+	a, b := handshakePair(t)
+	policy := DefaultReputationImportPolicy()
+	pub, priv := agentKey(t)
+	_, thief := agentKey(t)
+
+	credential, err := a.IssueReputationCredential("tenant-a", "agent-1", pub, 0.9, ReputationHistory{}, time.Hour)
+	require.NoError(t, err)
+
+	// A copied credential is worthless without the subject's key
+	_, err = b.ImportReputationCredential(present(t, b, "tenant-a", credential, thief), "tenant-a", policy)
+	assert.True(t, errors.Is(err, ErrInvalidPresentation), "got %v", err)
+
+	// The subject key swapped for the thief's breaks the issuer signature
+	swapped := *credential
+	swapped.SubjectPublicKey = thief.Public().(ed25519.PublicKey)
+	_, err = b.ImportReputationCredential(present(t, b, "tenant-a", &swapped, thief), "tenant-a", policy)
+	assert.True(t, errors.Is(err, ErrInvalidCredential), "got %v", err)
+
+	// A challenge for another tenant, or from another instance, is refused
+	_, err = b.ImportReputationCredential(present(t, b, "tenant-b", credential, priv), "tenant-a", policy)
+	assert.True(t, errors.Is(err, ErrInvalidPresentation), "got %v", err)
+	_, err = b.ImportReputationCredential(present(t, a, "tenant-a", credential, priv), "tenant-a", policy)
+	assert.True(t, errors.Is(err, ErrInvalidPresentation), "got %v", err)
+
+	// A self-made challenge is not signed by b
+	forged := present(t, b, "tenant-a", credential, priv)
+	forged.Challenge.Nonce = "chosen"
+	data, err := forged.ProofBytes()
+	require.NoError(t, err)
+	forged.Proof = ed25519.Sign(priv, data)
+	_, err = b.ImportReputationCredential(forged, "tenant-a", policy)
+	assert.True(t, errors.Is(err, ErrInvalidPresentation), "got %v", err)
+}
+
+func TestReputationCredentialImportedOnce(t *testing.T) {
+	a, b := handshakePair(t)
+	policy := DefaultReputationImportPolicy()
+	pub, priv := agentKey(t)
+
+	credential, err := a.IssueReputationCredential("tenant-a", "agent-1", pub, 0.9, ReputationHistory{}, time.Hour)
+	require.NoError(t, err)
+
+	presentation := present(t, b, "tenant-a", credential, priv)
+	_, err = b.ImportReputationCredential(presentation, "tenant-a", policy)
+	require.NoError(t, err)
+
+	// The same presentation, then a fresh challenge for the same credential
+	_, err = b.ImportReputationCredential(presentation, "tenant-a", policy)
+	assert.True(t, errors.Is(err, ErrInvalidPresentation), "got %v", err)
+	_, err = b.ImportReputationCredential(present(t, b, "tenant-a", credential, priv), "tenant-a", policy)
+	assert.True(t, errors.Is(err, ErrCredentialReplayed), "got %v", err)
+}
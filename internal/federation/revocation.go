@@ -0,0 +1,680 @@
+package federation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// REVOCATION AND TRUST BUNDLES
+//
+// Each OCX instance keeps a RevocationRegistry of revoked instance keys,
+// certificates and instance IDs. The registry publishes a signed TrustBundle
+// (its federation roots plus its own revocations) that peers poll; entries
+// learned from a peer's bundle are merged locally. Revocations are checked
+// by VerifyProof, VerifyCertificateChain, both handshakes and the message
+// transport, and a FederationManager tears down any PeerConnection whose
+// key or instance is revoked the moment the entry arrives.
+// ============================================================================
+
+// ErrRevoked is returned when a key, certificate or instance is revoked.
+var ErrRevoked = errors.New("revoked")
+
+// RevocationKind is what a RevocationEntry revokes.
+type RevocationKind string
+
+const (
+	RevokeKindKey         RevocationKind = "key"         // subject: KeyFingerprint
+	RevokeKindCertificate RevocationKind = "certificate" // subject: CertificateFingerprint
+	RevokeKindInstance    RevocationKind = "instance"    // subject: OCX instance ID
+)
+
+// RevocationEntry revokes one key, certificate or instance.
+type RevocationEntry struct {
+	Kind       RevocationKind `json:"kind"`
+	Subject    string         `json:"subject"`
+	InstanceID OCXInstanceID  `json:"instance_id,omitempty"` // instance the key or certificate belonged to
+	Reason     string         `json:"reason,omitempty"`
+	RevokedAt  time.Time      `json:"revoked_at"`
+	IssuedBy   OCXInstanceID  `json:"issued_by"`
+}
+
+func (e RevocationEntry) key() string {
+	return string(e.Kind) + "|" + e.Subject
+}
+
+// RevocationChecker reports whether a subject is revoked.
+type RevocationChecker interface {
+	// Revoked returns the entry revoking subject, or nil.
+	Revoked(kind RevocationKind, subject string) *RevocationEntry
+}
+
+// KeyFingerprint identifies a federation public key in the wire format
+// carried by attestations: raw Ed25519, ML-DSA-65 or hybrid bytes, or a
+// PKIX DER key. The fingerprint is the SHA-256 of the PKIX DER encoding
+// (of the raw bytes for hybrid keys, which have none), so it matches
+// PublicKeyFingerprint and KeyFingerprintPEM for the same key.
+func KeyFingerprint(publicKey []byte) string {
+	der := publicKey
+	switch DetectKeyAlgorithm(publicKey) {
+	case AlgorithmEd25519:
+		if d, err := x509.MarshalPKIXPublicKey(ed25519.PublicKey(publicKey)); err == nil {
+			der = d
+		}
+	case AlgorithmMLDSA65:
+		if d, err := mldsaPublicKeyDER(publicKey); err == nil {
+			der = d
+		}
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// PublicKeyFingerprint is KeyFingerprint for a parsed public key.
+func PublicKeyFingerprint(pub interface{}) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshal public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// KeyFingerprintPEM is KeyFingerprint for a PEM-encoded PKIX public key,
+// as carried in a v2 HELLO.
+func KeyFingerprintPEM(publicKeyPEM string) (string, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return "", errors.New("failed to decode PEM block")
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CertificateFingerprint is the SHA-256 of the certificate's DER encoding.
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkKeyRevoked returns an ErrRevoked error when rc revokes the key.
+// A nil checker revokes nothing.
+func checkKeyRevoked(rc RevocationChecker, publicKey []byte) error {
+	if rc == nil || len(publicKey) == 0 {
+		return nil
+	}
+	if e := rc.Revoked(RevokeKindKey, KeyFingerprint(publicKey)); e != nil {
+		return fmt.Errorf("key of %s %w: %s", e.InstanceID, ErrRevoked, e.Reason)
+	}
+	return nil
+}
+
+// checkInstanceRevoked returns an ErrRevoked error when rc revokes the instance.
+func checkInstanceRevoked(rc RevocationChecker, instanceID string) error {
+	if rc == nil || instanceID == "" {
+		return nil
+	}
+	if e := rc.Revoked(RevokeKindInstance, instanceID); e != nil {
+		return fmt.Errorf("instance %s %w: %s", instanceID, ErrRevoked, e.Reason)
+	}
+	return nil
+}
+
+// checkCertificateRevoked returns an ErrRevoked error when rc revokes the
+// certificate itself or the key it certifies.
+func checkCertificateRevoked(rc RevocationChecker, cert *x509.Certificate) error {
+	if rc == nil {
+		return nil
+	}
+	if e := rc.Revoked(RevokeKindCertificate, CertificateFingerprint(cert)); e != nil {
+		return fmt.Errorf("certificate %s %w: %s", cert.Subject, ErrRevoked, e.Reason)
+	}
+	if fp, err := PublicKeyFingerprint(cert.PublicKey); err == nil {
+		if e := rc.Revoked(RevokeKindKey, fp); e != nil {
+			return fmt.Errorf("key of certificate %s %w: %s", cert.Subject, ErrRevoked, e.Reason)
+		}
+	}
+	return nil
+}
+
+// verifyDetectedKey verifies a signature, picking the algorithm from the
+// public key (DetectKeyAlgorithm).
+func verifyDetectedKey(publicKey, data, signature []byte) bool {
+	provider, err := verifierFor(DetectKeyAlgorithm(publicKey))
+	if err != nil {
+		return false
+	}
+	valid, err := provider.Verify(publicKey, data, signature)
+	return err == nil && valid
+}
+
+// ============================================================================
+// TRUST BUNDLE
+// ============================================================================
+
+// TrustBundle is the signed set of federation roots and revocations an
+// instance publishes for its peers. Version increases with every change.
+type TrustBundle struct {
+	Issuer      OCXInstanceID     `json:"issuer"`
+	Version     int64             `json:"version"`
+	IssuedAt    time.Time         `json:"issued_at"`
+	NextUpdate  time.Time         `json:"next_update"`
+	Roots       []string          `json:"roots"` // PEM certificates
+	Revocations []RevocationEntry `json:"revocations"`
+	Algorithm   CryptoAlgorithm   `json:"algorithm"`
+	PublicKey   []byte            `json:"public_key"`
+	Signature   []byte            `json:"signature"`
+}
+
+func (b *TrustBundle) canonicalBytes() ([]byte, error) {
+	copy := *b
+	copy.Signature = nil
+	copy.IssuedAt = b.IssuedAt.UTC()
+	copy.NextUpdate = b.NextUpdate.UTC()
+	return json.Marshal(copy)
+}
+
+// Verify checks the bundle signature against issuerKey, the key the
+// verifier already trusts for the issuer (not the key in the bundle).
+func (b *TrustBundle) Verify(issuerKey []byte) error {
+	if len(issuerKey) == 0 {
+		return errors.New("no trusted key for trust bundle issuer")
+	}
+	if KeyFingerprint(b.PublicKey) != KeyFingerprint(issuerKey) {
+		return fmt.Errorf("trust bundle from %s signed with an unexpected key", b.Issuer)
+	}
+	data, err := b.canonicalBytes()
+	if err != nil {
+		return err
+	}
+	if !verifyDetectedKey(issuerKey, data, b.Signature) {
+		return fmt.Errorf("trust bundle from %s has an invalid signature", b.Issuer)
+	}
+	return nil
+}
+
+// ============================================================================
+// REVOCATION REGISTRY
+// ============================================================================
+
+// RevocationRegistry holds this instance's revocations and roots plus those
+// learned from peers' trust bundles.
+type RevocationRegistry struct {
+	mu        sync.RWMutex
+	issuer    OCXInstanceID
+	signer    CryptoProvider
+	entries   map[string]RevocationEntry
+	version   int64
+	roots     map[string]*x509.Certificate // fingerprint → root, own
+	peerRoots map[OCXInstanceID][]*x509.Certificate
+	applied   map[OCXInstanceID]int64 // highest bundle version applied per issuer
+	listeners []func(RevocationEntry)
+	refresh   time.Duration
+}
+
+// NewRevocationRegistry creates an empty registry for the given instance.
+func NewRevocationRegistry(issuer OCXInstanceID) *RevocationRegistry {
+	return &RevocationRegistry{
+		issuer:    issuer,
+		entries:   make(map[string]RevocationEntry),
+		version:   time.Now().UnixNano(),
+		roots:     make(map[string]*x509.Certificate),
+		peerRoots: make(map[OCXInstanceID][]*x509.Certificate),
+		applied:   make(map[OCXInstanceID]int64),
+		refresh:   5 * time.Minute,
+	}
+}
+
+// SetSigner sets the key trust bundles are signed with, normally the
+// FederationManager's attestation key.
+func (r *RevocationRegistry) SetSigner(signer CryptoProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.signer = signer
+}
+
+// SetRefreshInterval sets the NextUpdate hint published in trust bundles.
+func (r *RevocationRegistry) SetRefreshInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh = d
+}
+
+// OnRevoke registers fn to run for every new entry, local or learned.
+// fn runs without the registry lock held.
+func (r *RevocationRegistry) OnRevoke(fn func(RevocationEntry)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Revoked implements RevocationChecker.
+func (r *RevocationRegistry) Revoked(kind RevocationKind, subject string) *RevocationEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[string(kind)+"|"+subject]
+	if !ok {
+		return nil
+	}
+	return &e
+}
+
+// Revoke adds an entry issued by this instance.
+func (r *RevocationRegistry) Revoke(entry RevocationEntry) RevocationEntry {
+	if entry.RevokedAt.IsZero() {
+		entry.RevokedAt = time.Now().UTC()
+	}
+	entry.IssuedBy = r.issuer
+	r.add([]RevocationEntry{entry})
+	slog.Warn("[Revocation] Revoked", "kind", entry.Kind, "subject", entry.Subject, "instance_id", entry.InstanceID, "reason", entry.Reason)
+	return entry
+}
+
+// RevokeKey revokes an instance public key (attestation wire format).
+func (r *RevocationRegistry) RevokeKey(publicKey []byte, instanceID OCXInstanceID, reason string) RevocationEntry {
+	return r.Revoke(RevocationEntry{Kind: RevokeKindKey, Subject: KeyFingerprint(publicKey), InstanceID: instanceID, Reason: reason})
+}
+
+// RevokeCertificate revokes a certificate.
+func (r *RevocationRegistry) RevokeCertificate(cert *x509.Certificate, instanceID OCXInstanceID, reason string) RevocationEntry {
+	return r.Revoke(RevocationEntry{Kind: RevokeKindCertificate, Subject: CertificateFingerprint(cert), InstanceID: instanceID, Reason: reason})
+}
+
+// RevokeInstance revokes every key and connection of an instance.
+func (r *RevocationRegistry) RevokeInstance(instanceID OCXInstanceID, reason string) RevocationEntry {
+	return r.Revoke(RevocationEntry{Kind: RevokeKindInstance, Subject: string(instanceID), InstanceID: instanceID, Reason: reason})
+}
+
+// add stores new entries, bumps the version and notifies listeners.
+func (r *RevocationRegistry) add(entries []RevocationEntry) int {
+	r.mu.Lock()
+	var added []RevocationEntry
+	for _, e := range entries {
+		if _, exists := r.entries[e.key()]; exists {
+			continue
+		}
+		r.entries[e.key()] = e
+		added = append(added, e)
+	}
+	if len(added) > 0 {
+		r.bumpVersion()
+	}
+	listeners := append([]func(RevocationEntry){}, r.listeners...)
+	r.mu.Unlock()
+
+	for _, e := range added {
+		for _, fn := range listeners {
+			fn(e)
+		}
+	}
+	return len(added)
+}
+
+// bumpVersion keeps versions increasing across restarts. Callers hold r.mu.
+func (r *RevocationRegistry) bumpVersion() {
+	v := time.Now().UnixNano()
+	if v <= r.version {
+		v = r.version + 1
+	}
+	r.version = v
+}
+
+// List returns all entries, newest first.
+func (r *RevocationRegistry) List() []RevocationEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]RevocationEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RevokedAt.After(out[j].RevokedAt) })
+	return out
+}
+
+// AddRoot adds a PEM certificate to this instance's federation roots.
+func (r *RevocationRegistry) AddRoot(certPEM string) error {
+	cert, err := parseChainCertificate(certPEM)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.roots[CertificateFingerprint(cert)] = cert
+	r.bumpVersion()
+	return nil
+}
+
+// Roots returns a pool of this instance's roots and those learned from
+// peers, excluding revoked certificates.
+func (r *RevocationRegistry) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	r.mu.RLock()
+	certs := make([]*x509.Certificate, 0, len(r.roots))
+	for _, c := range r.roots {
+		certs = append(certs, c)
+	}
+	for _, rs := range r.peerRoots {
+		certs = append(certs, rs...)
+	}
+	r.mu.RUnlock()
+
+	for _, c := range certs {
+		if checkCertificateRevoked(r, c) == nil {
+			pool.AddCert(c)
+		}
+	}
+	return pool
+}
+
+// TrustBundle returns this instance's signed trust bundle: its own roots
+// and the revocations it issued. Entries learned from peers are not
+// re-published.
+func (r *RevocationRegistry) TrustBundle() (*TrustBundle, error) {
+	r.mu.RLock()
+	signer := r.signer
+	bundle := &TrustBundle{
+		Issuer:      r.issuer,
+		Version:     r.version,
+		IssuedAt:    time.Now().UTC(),
+		NextUpdate:  time.Now().UTC().Add(r.refresh),
+		Roots:       make([]string, 0, len(r.roots)),
+		Revocations: make([]RevocationEntry, 0),
+	}
+	for _, c := range r.roots {
+		bundle.Roots = append(bundle.Roots, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})))
+	}
+	for _, e := range r.entries {
+		if e.IssuedBy == r.issuer {
+			bundle.Revocations = append(bundle.Revocations, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	if signer == nil {
+		return nil, errors.New("revocation registry has no signer")
+	}
+	sort.Strings(bundle.Roots)
+	sort.Slice(bundle.Revocations, func(i, j int) bool { return bundle.Revocations[i].key() < bundle.Revocations[j].key() })
+
+	bundle.Algorithm = signer.Algorithm()
+	bundle.PublicKey = signer.PublicKeyBytes()
+	data, err := bundle.canonicalBytes()
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Signature, err = signer.Sign(data); err != nil {
+		return nil, fmt.Errorf("sign trust bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+// ApplyTrustBundle verifies a peer's bundle against issuerKey and merges
+// its roots and revocations. Bundles no newer than the last one applied
+// from the same issuer are ignored. It returns the number of new
+// revocations.
+func (r *RevocationRegistry) ApplyTrustBundle(b *TrustBundle, issuerKey []byte) (int, error) {
+	if b.Issuer == r.issuer {
+		return 0, errors.New("refusing to apply own trust bundle")
+	}
+	if err := b.Verify(issuerKey); err != nil {
+		return 0, err
+	}
+
+	roots := make([]*x509.Certificate, 0, len(b.Roots))
+	for i, p := range b.Roots {
+		cert, err := parseChainCertificate(p)
+		if err != nil {
+			return 0, fmt.Errorf("trust bundle root %d: %w", i, err)
+		}
+		roots = append(roots, cert)
+	}
+
+	r.mu.Lock()
+	if b.Version <= r.applied[b.Issuer] {
+		r.mu.Unlock()
+		return 0, nil
+	}
+	r.applied[b.Issuer] = b.Version
+	r.peerRoots[b.Issuer] = roots
+	r.mu.Unlock()
+
+	// A peer may only speak for itself: entries keep their issuer.
+	entries := make([]RevocationEntry, 0, len(b.Revocations))
+	for _, e := range b.Revocations {
+		e.IssuedBy = b.Issuer
+		entries = append(entries, e)
+	}
+	added := r.add(entries)
+	if added > 0 {
+		slog.Warn("[Revocation] Applied trust bundle", "issuer", b.Issuer, "version", b.Version, "new_revocations", added)
+	}
+	return added, nil
+}
+
+// ============================================================================
+// TRUST BUNDLE POLLING
+// ============================================================================
+
+// TrustBundleSource is a peer trust bundle endpoint.
+type TrustBundleSource struct {
+	URL string
+	// IssuerKey returns the key trusted for the bundle's issuer: a pinned
+	// key, or the key the peer presented during the handshake.
+	IssuerKey func(issuer OCXInstanceID) ([]byte, error)
+}
+
+// FetchTrustBundle downloads and applies one peer trust bundle.
+func (r *RevocationRegistry) FetchTrustBundle(ctx context.Context, client *http.Client, src TrustBundleSource) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("fetch trust bundle %s: %w", src.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("fetch trust bundle %s: status %d", src.URL, resp.StatusCode)
+	}
+
+	var bundle TrustBundle
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&bundle); err != nil {
+		return 0, fmt.Errorf("decode trust bundle %s: %w", src.URL, err)
+	}
+	key, err := src.IssuerKey(bundle.Issuer)
+	if err != nil {
+		return 0, fmt.Errorf("trust bundle issuer %s: %w", bundle.Issuer, err)
+	}
+	return r.ApplyTrustBundle(&bundle, key)
+}
+
+// RunTrustBundlePoller fetches every source each interval until ctx ends.
+func (r *RevocationRegistry) RunTrustBundlePoller(ctx context.Context, client *http.Client, sources []TrustBundleSource, interval time.Duration) {
+	if len(sources) == 0 || interval <= 0 {
+		return
+	}
+	poll := func() {
+		for _, src := range sources {
+			if _, err := r.FetchTrustBundle(ctx, client, src); err != nil {
+				slog.Warn("[Revocation] Trust bundle poll failed", "url", src.URL, "error", err)
+			}
+		}
+	}
+
+	poll()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
+
+// ============================================================================
+// PEER TEARDOWN
+// ============================================================================
+
+// SetRevocations makes the manager reject revoked keys and instances in
+// handshakes and message delivery, and disconnect peers as soon as their
+// key or instance is revoked. Peers already revoked are disconnected now.
+func (fm *FederationManager) SetRevocations(r *RevocationRegistry) {
+	fm.mu.Lock()
+	fm.revocations = r
+	fm.mu.Unlock()
+
+	r.OnRevoke(fm.teardownRevoked)
+	for _, e := range r.List() {
+		fm.teardownRevoked(e)
+	}
+}
+
+// Crypto returns the manager's signing provider, used to sign trust bundles
+// with the same key peers know from its attestation.
+func (fm *FederationManager) Crypto() CryptoProvider {
+	return fm.crypto
+}
+
+// checkAttestationRevoked rejects an instance or attestation key that is
+// revoked.
+func (fm *FederationManager) checkAttestationRevoked(id OCXInstanceID, att *Attestation) error {
+	fm.mu.RLock()
+	r := fm.revocations
+	fm.mu.RUnlock()
+	if r == nil {
+		return nil
+	}
+	if err := checkInstanceRevoked(r, string(id)); err != nil {
+		return err
+	}
+	if att != nil {
+		return checkKeyRevoked(r, att.PublicKey)
+	}
+	return nil
+}
+
+// teardownRevoked disconnects every peer the entry revokes and drops its
+// message connection.
+func (fm *FederationManager) teardownRevoked(e RevocationEntry) {
+	if e.Kind == RevokeKindCertificate {
+		return // peers are keyed by attestation key; certificates are checked at handshake
+	}
+
+	fm.mu.RLock()
+	var revoked []*PeerConnection
+	for _, p := range fm.peers {
+		p.mu.RLock()
+		match := (e.Kind == RevokeKindInstance && string(p.ID) == e.Subject) ||
+			(e.Kind == RevokeKindKey && p.Attestation != nil && KeyFingerprint(p.Attestation.PublicKey) == e.Subject)
+		p.mu.RUnlock()
+		if match {
+			revoked = append(revoked, p)
+		}
+	}
+	fm.mu.RUnlock()
+
+	for _, p := range revoked {
+		p.mu.Lock()
+		p.TrustLevel = TrustRevoked
+		endpoint := p.Endpoint
+		p.mu.Unlock()
+
+		if err := fm.DisconnectPeer(p.ID); err != nil {
+			continue // already gone
+		}
+		if endpoint != "" {
+			fm.mu.Lock()
+			if conn, ok := fm.clients[endpoint]; ok {
+				conn.Close()
+				delete(fm.clients, endpoint)
+			}
+			fm.mu.Unlock()
+		}
+		fm.logger.Printf("Tore down peer %s: %s revoked (%s)", p.ID, e.Kind, e.Reason)
+	}
+}
+
+// PeerPublicKey returns the key a connected peer presented in its
+// handshake attestation.
+func (fm *FederationManager) PeerPublicKey(id OCXInstanceID) ([]byte, error) {
+	peer, err := fm.GetPeer(id)
+	if err != nil {
+		return nil, err
+	}
+	peer.mu.RLock()
+	defer peer.mu.RUnlock()
+	if peer.Attestation == nil || len(peer.Attestation.PublicKey) == 0 {
+		return nil, fmt.Errorf("peer %s has no attested key", id)
+	}
+	return peer.Attestation.PublicKey, nil
+}
+
+// PublicKeyFromPEM converts a PEM public key to the attestation wire
+// format: raw bytes for Ed25519, ML-DSA-65 and hybrid keys, PKIX DER
+// otherwise.
+func PublicKeyFromPEM(publicKeyPEM string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	if block.Type == hybridPEMType {
+		if len(block.Bytes) != hybridPublicKeySize {
+			return nil, fmt.Errorf("invalid hybrid public key size: %d", len(block.Bytes))
+		}
+		return block.Bytes, nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	if edPub, ok := pub.(ed25519.PublicKey); ok {
+		return []byte(edPub), nil
+	}
+	if raw, ok := mldsaRawPublicKey(pub); ok {
+		return raw, nil
+	}
+	return block.Bytes, nil
+}
+
+// PublicKeyPEM is the inverse of PublicKeyFromPEM: it encodes a wire-format
+// public key as PEM, as sent in a HELLO.
+func PublicKeyPEM(publicKey []byte) (string, error) {
+	var der []byte
+	switch DetectKeyAlgorithm(publicKey) {
+	case AlgorithmHybrid:
+		return string(pem.EncodeToMemory(&pem.Block{Type: hybridPEMType, Bytes: publicKey})), nil
+	case AlgorithmEd25519:
+		var err error
+		if der, err = x509.MarshalPKIXPublicKey(ed25519.PublicKey(publicKey)); err != nil {
+			return "", fmt.Errorf("failed to marshal Ed25519 public key: %w", err)
+		}
+	case AlgorithmMLDSA65:
+		var err error
+		if der, err = mldsaPublicKeyDER(publicKey); err != nil {
+			return "", fmt.Errorf("failed to marshal ML-DSA public key: %w", err)
+		}
+	default:
+		der = publicKey
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
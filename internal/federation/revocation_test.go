@@ -0,0 +1,151 @@
+package federation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// REVOCATION AND TRUST BUNDLE TESTS
+// ============================================================================
+
+func TestRevokedPeerKeyTearsDownConnection(t *testing.T) {
+	a, b, delivery, _, _ := federatedPair(t)
+	ctx := context.Background()
+
+	revocations := NewRevocationRegistry("ocx-b")
+	b.SetRevocations(revocations)
+
+	msg := &FederatedMessage{DestOCX: "ocx-b", DestTenant: "tenant-b", DestAgent: "booking-agent", Payload: []byte("one")}
+	_, err := a.SendMessage(ctx, msg)
+	require.NoError(t, err)
+
+	keyA, err := b.PeerPublicKey("ocx-a")
+	require.NoError(t, err)
+	entry := revocations.RevokeKey(keyA, "ocx-a", "key compromise")
+	assert.Equal(t, KeyFingerprint(keyA), entry.Subject)
+	assert.Equal(t, OCXInstanceID("ocx-b"), entry.IssuedBy)
+
+	// The peer is gone, and a message signed with the revoked key is refused
+	_, err = b.GetPeer("ocx-a")
+	assert.Error(t, err)
+	_, err = a.SendMessage(ctx, &FederatedMessage{DestOCX: "ocx-b", DestTenant: "tenant-b", DestAgent: "booking-agent", Payload: []byte("two")})
+	require.Error(t, err)
+	assert.Len(t, delivery.messages, 1)
+
+	// A fresh HELLO with the revoked key is rejected at handshake time
+	attestation, err := a.CreateAttestation(1, 1)
+	require.NoError(t, err)
+	_, err = b.ProcessHandshakeMessage(&HandshakeMessage{
+		Type: HandshakeHello, InstanceID: "ocx-a", Nonce: []byte("again"), Attestation: attestation, Timestamp: time.Now(),
+	})
+	assert.True(t, errors.Is(err, ErrRevoked), "got %v", err)
+}
+
+func TestTrustBundleDistribution(t *testing.T) {
+	issuerCrypto, err := NewCryptoProvider(AlgorithmEd25519)
+	require.NoError(t, err)
+	issuer := NewRevocationRegistry("ocx-a")
+	issuer.SetSigner(issuerCrypto)
+
+	rootPEM, _, _ := testCertificate(t, "federation-root")
+	require.NoError(t, issuer.AddRoot(rootPEM))
+	issuer.RevokeInstance("ocx-rogue", "operator request")
+
+	bundle, err := issuer.TrustBundle()
+	require.NoError(t, err)
+
+	receiver := NewRevocationRegistry("ocx-b")
+	added, err := receiver.ApplyTrustBundle(bundle, issuerCrypto.PublicKeyBytes())
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	entry := receiver.Revoked(RevokeKindInstance, "ocx-rogue")
+	require.NotNil(t, entry)
+	assert.Equal(t, OCXInstanceID("ocx-a"), entry.IssuedBy)
+
+	// Replaying the same version changes nothing
+	added, err = receiver.ApplyTrustBundle(bundle, issuerCrypto.PublicKeyBytes())
+	require.NoError(t, err)
+	assert.Zero(t, added)
+
+	// A tampered bundle or the wrong issuer key fails verification
+	issuer.RevokeInstance("ocx-other", "decommissioned")
+	next, err := issuer.TrustBundle()
+	require.NoError(t, err)
+	next.Revocations = next.Revocations[:1]
+	_, err = receiver.ApplyTrustBundle(next, issuerCrypto.PublicKeyBytes())
+	assert.Error(t, err)
+
+	other, err := NewCryptoProvider(AlgorithmEd25519)
+	require.NoError(t, err)
+	next, err = issuer.TrustBundle()
+	require.NoError(t, err)
+	_, err = receiver.ApplyTrustBundle(next, other.PublicKeyBytes())
+	assert.Error(t, err)
+
+	// The directory refuses instances revoked by a peer
+	registry := NewFederationRegistry()
+	registry.SetRevocations(receiver)
+	err = registry.Register(&OCXInstance{InstanceID: "ocx-rogue"})
+	assert.True(t, errors.Is(err, ErrRevoked), "got %v", err)
+	_, err = registry.Lookup("ocx-rogue")
+	assert.True(t, errors.Is(err, ErrRevoked), "got %v", err)
+}
+
+func TestVerifyRejectsRevokedCertificatesAndKeys(t *testing.T) {
+	certPEM, cert, key := testCertificate(t, "ocx-a")
+	revocations := NewRevocationRegistry("ocx-b")
+
+	require.NoError(t, VerifyCertificateChain([]string{certPEM}, cert, revocations))
+
+	challenge := []byte("challenge")
+	proof, err := GenerateProof(challenge, key)
+	require.NoError(t, err)
+	ok, err := VerifyProof(proof, challenge, &key.PublicKey, revocations)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	revocations.RevokeCertificate(cert, "ocx-a", "mis-issued")
+	err = VerifyCertificateChain([]string{certPEM}, cert, revocations)
+	assert.True(t, errors.Is(err, ErrRevoked), "got %v", err)
+
+	fp, err := PublicKeyFingerprint(&key.PublicKey)
+	require.NoError(t, err)
+	revocations.Revoke(RevocationEntry{Kind: RevokeKindKey, Subject: fp, InstanceID: "ocx-a", Reason: "key compromise"})
+	_, err = VerifyProof(proof, challenge, &key.PublicKey, revocations)
+	assert.True(t, errors.Is(err, ErrRevoked), "got %v", err)
+}
+
+// testCertificate returns a self-signed ECDSA certificate.
+func testCertificate(t *testing.T, cn string) (string, *x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), cert, key
+}
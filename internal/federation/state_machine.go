@@ -129,6 +129,20 @@ func NewHandshakeStateMachine(sessionID string) *HandshakeStateMachine {
 	}
 }
 
+// RestoreHandshakeStateMachine rebuilds a state machine persisted in a
+// HandshakeSessionStore. The total timeout still runs from startedAt, so a
+// restored handshake gets no extra time.
+func RestoreHandshakeStateMachine(sessionID string, state HandshakeState, startedAt, lastUpdatedAt time.Time) *HandshakeStateMachine {
+	sm := NewHandshakeStateMachine(sessionID)
+	sm.cancel()
+	sm.ctx, sm.cancel = context.WithDeadline(context.Background(), startedAt.Add(sm.totalTimeout))
+	sm.currentState = state
+	sm.startedAt = startedAt
+	sm.lastUpdatedAt = lastUpdatedAt
+	sm.timeoutAt = startedAt.Add(sm.totalTimeout)
+	return sm
+}
+
 // Transition attempts to transition from one state to another
 func (sm *HandshakeStateMachine) Transition(from, to HandshakeState) error {
 	sm.mu.Lock()
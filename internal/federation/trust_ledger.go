@@ -18,23 +18,36 @@ import (
 	"github.com/ocx/backend/internal/governance"
 )
 
+// trustStoreTimeout bounds each write-through or prune against the
+// TrustLedgerStore so a slow or hung database cannot stall callers.
+const trustStoreTimeout = 5 * time.Second
+
 // PersistentTrustLedger stores and retrieves trust scores for cross-OCX
 // handshakes. Implements §5.2: "Trust score exchange during handshakes"
 // with persistent trust history between OCX instances.
 type PersistentTrustLedger struct {
 	mu sync.RWMutex
 
-	// Per-instance trust records (remoteInstanceID → history)
+	// Per-instance trust records (remoteInstanceID → history), loaded from
+	// and written through to store
 	instanceTrust map[string]*InstanceTrustRecord
 
-	// Global trust attestation log
-	attestationLog []TrustAttestationEvent
+	// Durable instance records and attestation log
+	store     TrustLedgerStore
+	retention TrustRetention
 
 	// Decay parameters
 	decayHalfLifeHours float64 // Trust score decays over time
 	minTrustFloor      float64 // Minimum trust floor
 }
 
+// TrustRetention bounds how much trust history is kept.
+type TrustRetention struct {
+	HistoryPoints  int           // trust history points kept per instance (default 100)
+	HistoryMaxAge  time.Duration // older history points are dropped; 0 keeps them
+	AttestationAge time.Duration // older attestation events are pruned; 0 keeps them
+}
+
 // InstanceTrustRecord holds the persistent trust data for a remote OCX instance.
 type InstanceTrustRecord struct {
 	RemoteInstanceID string           `json:"remote_instance_id"`
@@ -68,20 +81,52 @@ type TrustAttestationEvent struct {
 	RemoteTrust      float64   `json:"remote_trust"`
 	AgreedTrust      float64   `json:"agreed_trust"`
 	AttestationHash  string    `json:"attestation_hash"`
-	Outcome          string    `json:"outcome"` // "success", "rejected", "timeout"
+	Outcome          string    `json:"outcome"` // "success", "rejected", "timeout", "penalty"
 	Timestamp        time.Time `json:"timestamp"`
 }
 
-// NewPersistentTrustLedger creates a new persistent trust ledger.
+// NewPersistentTrustLedger creates a trust ledger backed by an in-memory
+// store. Use SetStore for durable storage.
 func NewPersistentTrustLedger() *PersistentTrustLedger {
 	return &PersistentTrustLedger{
 		instanceTrust:      make(map[string]*InstanceTrustRecord),
-		attestationLog:     make([]TrustAttestationEvent, 0),
+		store:              NewMemoryTrustLedgerStore(0),
+		retention:          TrustRetention{HistoryPoints: 100},
 		decayHalfLifeHours: 168, // 1 week half-life
 		minTrustFloor:      0.1,
 	}
 }
 
+// SetStore switches the ledger to store and loads the instance records it
+// holds, replacing the in-memory working set.
+func (ptl *PersistentTrustLedger) SetStore(ctx context.Context, store TrustLedgerStore) error {
+	records, err := store.LoadInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("load trust ledger: %w", err)
+	}
+
+	ptl.mu.Lock()
+	defer ptl.mu.Unlock()
+	ptl.store = store
+	ptl.instanceTrust = make(map[string]*InstanceTrustRecord, len(records))
+	for _, r := range records {
+		ptl.instanceTrust[r.RemoteInstanceID] = r
+	}
+	slog.Info("Trust ledger loaded from store", "instances", len(records))
+	return nil
+}
+
+// SetRetention sets history retention. A non-positive HistoryPoints keeps
+// the default of 100.
+func (ptl *PersistentTrustLedger) SetRetention(r TrustRetention) {
+	if r.HistoryPoints <= 0 {
+		r.HistoryPoints = 100
+	}
+	ptl.mu.Lock()
+	defer ptl.mu.Unlock()
+	ptl.retention = r
+}
+
 // SetGovernanceConfig loads trust decay parameters from the tenant governance config.
 func (ptl *PersistentTrustLedger) SetGovernanceConfig(cache *governance.GovernanceConfigCache, tenantID string) {
 	if cache == nil {
@@ -104,7 +149,6 @@ func (ptl *PersistentTrustLedger) RecordHandshake(
 	success bool,
 ) (*TrustAttestationEvent, error) {
 	ptl.mu.Lock()
-	defer ptl.mu.Unlock()
 
 	// Get or create instance record
 	record, exists := ptl.instanceTrust[remoteInstanceID]
@@ -159,15 +203,12 @@ func (ptl *PersistentTrustLedger) RecordHandshake(
 		record.LowWaterMark = record.CurrentTrust
 	}
 
-	// Append to history (keep last 100 points)
-	record.TrustHistory = append(record.TrustHistory, TrustDataPoint{
+	// Append to history, trimmed to the retention window
+	record.TrustHistory = ptl.trimHistory(append(record.TrustHistory, TrustDataPoint{
 		Score:     record.CurrentTrust,
 		Source:    "handshake",
 		Timestamp: time.Now(),
-	})
-	if len(record.TrustHistory) > 100 {
-		record.TrustHistory = record.TrustHistory[len(record.TrustHistory)-100:]
-	}
+	}))
 
 	// Create attestation event
 	attestHash := computeAttestationHash(localInstanceID, remoteInstanceID, agentID, agreedTrust)
@@ -184,16 +225,94 @@ func (ptl *PersistentTrustLedger) RecordHandshake(
 		Timestamp:        time.Now(),
 	}
 
-	// Append to attestation log (keep last 5000)
-	ptl.attestationLog = append(ptl.attestationLog, event)
-	if len(ptl.attestationLog) > 5000 {
-		ptl.attestationLog = ptl.attestationLog[len(ptl.attestationLog)-5000:]
+	snapshot, store := cloneInstanceRecord(record), ptl.store
+	ptl.mu.Unlock()
+
+	ptl.writeThrough(ctx, store, snapshot, event)
+
+	slog.Info("Trust ledger: trust= (handshake #, outcome=)", "remote_instance_i_d", remoteInstanceID, "current_trust", snapshot.CurrentTrust, "handshake_count", snapshot.HandshakeCount, "outcome", outcome)
+	return &event, nil
+}
+
+// RecordPenalty multiplies a remote instance's trust by factor (0–1), e.g.
+// after a failed integrity check, and logs a "penalty" attestation event
+// whose local and remote trust are the scores before and after.
+func (ptl *PersistentTrustLedger) RecordPenalty(
+	ctx context.Context,
+	localInstanceID, remoteInstanceID, reason string,
+	factor float64,
+) (*TrustAttestationEvent, error) {
+	if factor < 0 || factor > 1 {
+		return nil, fmt.Errorf("penalty factor %.2f outside [0, 1]", factor)
+	}
+
+	ptl.mu.Lock()
+
+	record, exists := ptl.instanceTrust[remoteInstanceID]
+	if !exists {
+		record = &InstanceTrustRecord{
+			RemoteInstanceID: remoteInstanceID,
+			CurrentTrust:     0.5,
+			HighWaterMark:    0.5,
+			LowWaterMark:     0.5,
+			FirstSeenAt:      time.Now(),
+			TrustHistory:     make([]TrustDataPoint, 0),
+		}
+		ptl.instanceTrust[remoteInstanceID] = record
+	}
+
+	before := ptl.applyDecay(record.CurrentTrust, record.LastHandshakeAt)
+	record.CurrentTrust = before * factor
+	if record.CurrentTrust < ptl.minTrustFloor {
+		record.CurrentTrust = ptl.minTrustFloor
+	}
+	if record.CurrentTrust < record.LowWaterMark {
+		record.LowWaterMark = record.CurrentTrust
+	}
+	record.TrustHistory = ptl.trimHistory(append(record.TrustHistory, TrustDataPoint{
+		Score:     record.CurrentTrust,
+		Source:    "penalty",
+		Timestamp: time.Now(),
+	}))
+
+	event := TrustAttestationEvent{
+		EventID:          fmt.Sprintf("att-%d", time.Now().UnixNano()),
+		LocalInstanceID:  localInstanceID,
+		RemoteInstanceID: remoteInstanceID,
+		LocalTrust:       before,
+		RemoteTrust:      record.CurrentTrust,
+		AgreedTrust:      record.CurrentTrust,
+		AttestationHash:  computeAttestationHash(localInstanceID, remoteInstanceID, reason, record.CurrentTrust),
+		Outcome:          "penalty",
+		Timestamp:        time.Now(),
 	}
 
-	slog.Info("Trust ledger: trust= (handshake #, outcome=)", "remote_instance_i_d", remoteInstanceID, "current_trust", record.CurrentTrust, "handshake_count", record.HandshakeCount, "outcome", outcome)
+	snapshot, store := cloneInstanceRecord(record), ptl.store
+	ptl.mu.Unlock()
+
+	ptl.writeThrough(ctx, store, snapshot, event)
+
+	slog.Warn("Trust ledger: penalty applied", "remote_instance_id", remoteInstanceID,
+		"reason", reason, "from", before, "to", snapshot.CurrentTrust)
 	return &event, nil
 }
 
+// writeThrough persists a record snapshot and its attestation event. It runs
+// outside ptl.mu so readers and other handshakes never wait on the store,
+// and each call is bounded by trustStoreTimeout. A store failure is logged
+// rather than failing the caller: the in-memory record is current and the
+// next successful save carries it.
+func (ptl *PersistentTrustLedger) writeThrough(ctx context.Context, store TrustLedgerStore, record *InstanceTrustRecord, event TrustAttestationEvent) {
+	saveCtx, cancel := context.WithTimeout(ctx, trustStoreTimeout)
+	defer cancel()
+	if err := store.SaveInstance(saveCtx, record); err != nil {
+		slog.Warn("Trust ledger write-through failed", "remote_instance_id", record.RemoteInstanceID, "error", err)
+	}
+	if err := store.AppendAttestation(saveCtx, event); err != nil {
+		slog.Warn("Trust ledger attestation write failed", "event_id", event.EventID, "error", err)
+	}
+}
+
 // GetInstanceTrust returns the current trust score for a remote instance.
 func (ptl *PersistentTrustLedger) GetInstanceTrust(remoteInstanceID string) float64 {
 	ptl.mu.RLock()
@@ -242,23 +361,105 @@ func (ptl *PersistentTrustLedger) ListTrustedInstances(minTrust float64) []*Inst
 	return results
 }
 
-// GetAttestationLog returns recent attestation events.
+// GetAttestationLog returns up to limit recent attestation events, oldest
+// first.
 func (ptl *PersistentTrustLedger) GetAttestationLog(limit int) []TrustAttestationEvent {
+	page, err := ptl.QueryAttestations(context.Background(), AttestationQuery{Limit: limit})
+	if err != nil {
+		slog.Warn("Trust ledger attestation query failed", "error", err)
+		return nil
+	}
+	out := page.Attestations
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// QueryAttestations returns a page of attestation history, newest first.
+func (ptl *PersistentTrustLedger) QueryAttestations(ctx context.Context, q AttestationQuery) (*AttestationPage, error) {
 	ptl.mu.RLock()
-	defer ptl.mu.RUnlock()
+	store := ptl.store
+	ptl.mu.RUnlock()
+	return store.ListAttestations(ctx, q)
+}
 
-	if limit <= 0 || limit > len(ptl.attestationLog) {
-		limit = len(ptl.attestationLog)
+// PruneHistory applies retention: attestation events past AttestationAge
+// are deleted from the store and trust history points past HistoryMaxAge
+// are dropped from every instance record. Cutoffs and trimmed records are
+// computed under the lock; store deletes and saves run outside it, each
+// bounded by trustStoreTimeout.
+func (ptl *PersistentTrustLedger) PruneHistory(ctx context.Context) error {
+	ptl.mu.Lock()
+	store, retention := ptl.store, ptl.retention
+	var trimmed []*InstanceTrustRecord
+	if retention.HistoryMaxAge > 0 {
+		for _, record := range ptl.instanceTrust {
+			before := len(record.TrustHistory)
+			if record.TrustHistory = ptl.trimHistory(record.TrustHistory); len(record.TrustHistory) != before {
+				trimmed = append(trimmed, cloneInstanceRecord(record))
+			}
+		}
 	}
+	ptl.mu.Unlock()
+
+	if retention.AttestationAge > 0 {
+		pruneCtx, cancel := context.WithTimeout(ctx, trustStoreTimeout)
+		n, err := store.PruneAttestations(pruneCtx, time.Now().Add(-retention.AttestationAge))
+		cancel()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			slog.Info("Trust ledger pruned attestations", "deleted", n)
+		}
+	}
+	for _, record := range trimmed {
+		saveCtx, cancel := context.WithTimeout(ctx, trustStoreTimeout)
+		err := store.SaveInstance(saveCtx, record)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("prune trust history for %s: %w", record.RemoteInstanceID, err)
+		}
+	}
+	return nil
+}
 
-	start := len(ptl.attestationLog) - limit
-	out := make([]TrustAttestationEvent, limit)
-	copy(out, ptl.attestationLog[start:])
-	return out
+// RunRetention prunes history every interval until ctx is cancelled.
+func (ptl *PersistentTrustLedger) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ptl.PruneHistory(ctx); err != nil {
+				slog.Warn("Trust ledger retention failed", "error", err)
+			}
+		}
+	}
 }
 
 // --- Internal helpers ---
 
+// trimHistory keeps the newest HistoryPoints points no older than
+// HistoryMaxAge. Callers hold ptl.mu.
+func (ptl *PersistentTrustLedger) trimHistory(history []TrustDataPoint) []TrustDataPoint {
+	if ptl.retention.HistoryMaxAge > 0 {
+		cutoff := time.Now().Add(-ptl.retention.HistoryMaxAge)
+		keep := 0
+		for keep < len(history) && history[keep].Timestamp.Before(cutoff) {
+			keep++
+		}
+		history = history[keep:]
+	}
+	if n := ptl.retention.HistoryPoints; n > 0 && len(history) > n {
+		history = history[len(history)-n:]
+	}
+	return history
+}
+
 // applyDecay computes trust decay since last handshake.
 // Trust decays exponentially toward the floor.
 func (ptl *PersistentTrustLedger) applyDecay(currentTrust float64, lastUpdate time.Time) float64 {
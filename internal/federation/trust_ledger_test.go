@@ -0,0 +1,155 @@
+package federation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordHandshakes(t *testing.T, ledger *PersistentTrustLedger, remote string, n int, success bool) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, err := ledger.RecordHandshake(context.Background(), "ocx-local", remote, remote+".example", "Org", "agent-1", 0.9, 0.9, success)
+		require.NoError(t, err)
+	}
+}
+
+func TestTrustLedgerSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTrustLedgerStore(0)
+
+	before := NewPersistentTrustLedger()
+	require.NoError(t, before.SetStore(ctx, store))
+	recordHandshakes(t, before, "ocx-remote-a", 4, true)
+	recordHandshakes(t, before, "ocx-remote-b", 2, false)
+
+	after := NewPersistentTrustLedger()
+	assert.Equal(t, 0.5, after.GetInstanceTrust("ocx-remote-a"), "a fresh ledger without a store starts neutral")
+	require.NoError(t, after.SetStore(ctx, store))
+
+	assert.InDelta(t, before.GetInstanceTrust("ocx-remote-a"), after.GetInstanceTrust("ocx-remote-a"), 1e-9)
+	assert.Greater(t, after.GetInstanceTrust("ocx-remote-a"), 0.5)
+	assert.Less(t, after.GetInstanceTrust("ocx-remote-b"), 0.5)
+
+	rec := after.GetInstanceRecord("ocx-remote-a")
+	require.NotNil(t, rec)
+	assert.Equal(t, 4, rec.HandshakeCount)
+	assert.Len(t, rec.TrustHistory, 4)
+	assert.Len(t, after.GetAttestationLog(0), 6)
+
+	// The reloaded ledger keeps writing through
+	recordHandshakes(t, after, "ocx-remote-a", 1, true)
+	records, err := store.LoadInstances(ctx)
+	require.NoError(t, err)
+	for _, r := range records {
+		if r.RemoteInstanceID == "ocx-remote-a" {
+			assert.Equal(t, 5, r.HandshakeCount)
+		}
+	}
+}
+
+func TestTrustLedgerAttestationPagination(t *testing.T) {
+	ctx := context.Background()
+	ledger := NewPersistentTrustLedger()
+	recordHandshakes(t, ledger, "ocx-remote-a", 5, true)
+	recordHandshakes(t, ledger, "ocx-remote-b", 2, false)
+
+	page, err := ledger.QueryAttestations(ctx, AttestationQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 7, page.Total)
+	assert.Equal(t, 3, page.NextOffset)
+	require.Len(t, page.Attestations, 3)
+	assert.Equal(t, "ocx-remote-b", page.Attestations[0].RemoteInstanceID, "newest first")
+
+	last, err := ledger.QueryAttestations(ctx, AttestationQuery{Limit: 3, Offset: 6})
+	require.NoError(t, err)
+	assert.Len(t, last.Attestations, 1)
+	assert.Zero(t, last.NextOffset)
+
+	filtered, err := ledger.QueryAttestations(ctx, AttestationQuery{RemoteInstanceID: "ocx-remote-b", Outcome: "rejected"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, filtered.Total)
+
+	log := ledger.GetAttestationLog(2)
+	require.Len(t, log, 2)
+	assert.False(t, log[1].Timestamp.Before(log[0].Timestamp), "GetAttestationLog stays oldest first")
+}
+
+func TestTrustLedgerRetention(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTrustLedgerStore(0)
+	ledger := NewPersistentTrustLedger()
+	require.NoError(t, ledger.SetStore(ctx, store))
+	ledger.SetRetention(TrustRetention{HistoryPoints: 3, AttestationAge: 24 * time.Hour})
+
+	recordHandshakes(t, ledger, "ocx-remote-a", 5, true)
+	assert.Len(t, ledger.GetInstanceRecord("ocx-remote-a").TrustHistory, 3, "history capped at configured points")
+
+	old := TrustAttestationEvent{EventID: "att-old", RemoteInstanceID: "ocx-remote-a", Outcome: "success", Timestamp: time.Now().Add(-48 * time.Hour)}
+	store.attestations = append([]TrustAttestationEvent{old}, store.attestations...)
+
+	require.NoError(t, ledger.PruneHistory(ctx))
+	page, err := ledger.QueryAttestations(ctx, AttestationQuery{Limit: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 5, page.Total)
+	for _, e := range page.Attestations {
+		assert.NotEqual(t, "att-old", e.EventID)
+	}
+}
+
+// blockingTrustStore parks SaveInstance until release is closed or the
+// caller's context ends.
+type blockingTrustStore struct {
+	*MemoryTrustLedgerStore
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingTrustStore) SaveInstance(ctx context.Context, record *InstanceTrustRecord) error {
+	s.entered <- struct{}{}
+	select {
+	case <-s.release:
+		return s.MemoryTrustLedgerStore.SaveInstance(ctx, record)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestTrustLedgerStoreDoesNotHoldLock(t *testing.T) {
+	ctx := context.Background()
+	store := &blockingTrustStore{
+		MemoryTrustLedgerStore: NewMemoryTrustLedgerStore(0),
+		entered:                make(chan struct{}, 1),
+		release:                make(chan struct{}),
+	}
+	ledger := NewPersistentTrustLedger()
+	require.NoError(t, ledger.SetStore(ctx, store))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = ledger.RecordHandshake(ctx, "ocx-local", "ocx-remote-a", "a.example", "Org", "agent-1", 0.9, 0.9, true)
+	}()
+	<-store.entered
+
+	// The write is parked in the store; readers still see the new record.
+	read := make(chan float64, 1)
+	go func() { read <- ledger.GetInstanceTrust("ocx-remote-a") }()
+	select {
+	case trust := <-read:
+		assert.Greater(t, trust, 0.5)
+	case <-time.After(time.Second):
+		t.Fatal("GetInstanceTrust blocked on a pending store write")
+	}
+	assert.Equal(t, 1, ledger.GetInstanceRecord("ocx-remote-a").HandshakeCount)
+
+	close(store.release)
+	<-done
+	records, err := store.LoadInstances(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, 1, records[0].HandshakeCount)
+}
@@ -0,0 +1,324 @@
+package federation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// TRUST LEDGER PERSISTENCE
+//
+// PersistentTrustLedger keeps its working set in memory for lookups on the
+// handshake path and writes every change through to a TrustLedgerStore.
+// On boot the ledger reloads instance records from the store, so remote
+// OCX instances keep their earned trust across restarts instead of falling
+// back to neutral 0.5.
+// ============================================================================
+
+// AttestationQuery selects attestation events, newest first. Empty fields
+// do not filter.
+type AttestationQuery struct {
+	RemoteInstanceID string
+	AgentID          string
+	Outcome          string
+	Since            time.Time
+	Until            time.Time
+	Limit            int
+	Offset           int
+}
+
+// AttestationPage is one page of attestation history.
+type AttestationPage struct {
+	Attestations []TrustAttestationEvent `json:"attestations"`
+	Total        int                     `json:"total"`
+	Limit        int                     `json:"limit"`
+	Offset       int                     `json:"offset"`
+	NextOffset   int                     `json:"next_offset,omitempty"` // 0 on the last page
+}
+
+// TrustLedgerStore persists trust records and attestation history.
+type TrustLedgerStore interface {
+	// LoadInstances returns every stored instance record.
+	LoadInstances(ctx context.Context) ([]*InstanceTrustRecord, error)
+
+	// SaveInstance upserts one instance record, trust history included.
+	SaveInstance(ctx context.Context, record *InstanceTrustRecord) error
+
+	// AppendAttestation records one attestation event.
+	AppendAttestation(ctx context.Context, event TrustAttestationEvent) error
+
+	// ListAttestations returns a page of events matching q, newest first.
+	ListAttestations(ctx context.Context, q AttestationQuery) (*AttestationPage, error)
+
+	// PruneAttestations deletes events older than before.
+	PruneAttestations(ctx context.Context, before time.Time) (int64, error)
+}
+
+// normalizeQuery clamps the page size to 1–500 (default 50).
+func normalizeQuery(q AttestationQuery) AttestationQuery {
+	if q.Limit <= 0 {
+		q.Limit = 50
+	}
+	if q.Limit > 500 {
+		q.Limit = 500
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+func (q AttestationQuery) matches(e *TrustAttestationEvent) bool {
+	return (q.RemoteInstanceID == "" || e.RemoteInstanceID == q.RemoteInstanceID) &&
+		(q.AgentID == "" || e.AgentID == q.AgentID) &&
+		(q.Outcome == "" || e.Outcome == q.Outcome) &&
+		(q.Since.IsZero() || !e.Timestamp.Before(q.Since)) &&
+		(q.Until.IsZero() || e.Timestamp.Before(q.Until))
+}
+
+func newAttestationPage(events []TrustAttestationEvent, total int, q AttestationQuery) *AttestationPage {
+	page := &AttestationPage{Attestations: events, Total: total, Limit: q.Limit, Offset: q.Offset}
+	if page.Attestations == nil {
+		page.Attestations = []TrustAttestationEvent{}
+	}
+	if next := q.Offset + len(events); len(events) > 0 && next < total {
+		page.NextOffset = next
+	}
+	return page
+}
+
+func cloneInstanceRecord(r *InstanceTrustRecord) *InstanceTrustRecord {
+	cp := *r
+	cp.TrustHistory = append([]TrustDataPoint(nil), r.TrustHistory...)
+	return &cp
+}
+
+// ============================================================================
+// IN-MEMORY IMPLEMENTATION (for dev/test)
+// ============================================================================
+
+// MemoryTrustLedgerStore keeps the ledger in process memory. The
+// attestation log is capped at maxEvents, oldest dropped first.
+type MemoryTrustLedgerStore struct {
+	mu           sync.RWMutex
+	instances    map[string]*InstanceTrustRecord
+	attestations []TrustAttestationEvent // oldest first
+	maxEvents    int
+}
+
+// NewMemoryTrustLedgerStore creates an in-memory store. maxEvents <= 0
+// keeps 5000 attestation events.
+func NewMemoryTrustLedgerStore(maxEvents int) *MemoryTrustLedgerStore {
+	if maxEvents <= 0 {
+		maxEvents = 5000
+	}
+	return &MemoryTrustLedgerStore{
+		instances: make(map[string]*InstanceTrustRecord),
+		maxEvents: maxEvents,
+	}
+}
+
+func (s *MemoryTrustLedgerStore) LoadInstances(ctx context.Context) ([]*InstanceTrustRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*InstanceTrustRecord, 0, len(s.instances))
+	for _, r := range s.instances {
+		out = append(out, cloneInstanceRecord(r))
+	}
+	return out, nil
+}
+
+func (s *MemoryTrustLedgerStore) SaveInstance(ctx context.Context, record *InstanceTrustRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.instances[record.RemoteInstanceID] = cloneInstanceRecord(record)
+	return nil
+}
+
+func (s *MemoryTrustLedgerStore) AppendAttestation(ctx context.Context, event TrustAttestationEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attestations = append(s.attestations, event)
+	if len(s.attestations) > s.maxEvents {
+		s.attestations = s.attestations[len(s.attestations)-s.maxEvents:]
+	}
+	return nil
+}
+
+func (s *MemoryTrustLedgerStore) ListAttestations(ctx context.Context, q AttestationQuery) (*AttestationPage, error) {
+	q = normalizeQuery(q)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []TrustAttestationEvent
+	total := 0
+	for i := len(s.attestations) - 1; i >= 0; i-- {
+		e := &s.attestations[i]
+		if !q.matches(e) {
+			continue
+		}
+		if total >= q.Offset && len(events) < q.Limit {
+			events = append(events, *e)
+		}
+		total++
+	}
+	return newAttestationPage(events, total, q), nil
+}
+
+func (s *MemoryTrustLedgerStore) PruneAttestations(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keep := sort.Search(len(s.attestations), func(i int) bool {
+		return !s.attestations[i].Timestamp.Before(before)
+	})
+	s.attestations = append(s.attestations[:0], s.attestations[keep:]...)
+	return int64(keep), nil
+}
+
+// ============================================================================
+// POSTGRES IMPLEMENTATION (Supabase or any Postgres)
+// ============================================================================
+
+// PostgresTrustLedgerStore persists the ledger in the federation_trust_instances
+// and federation_trust_attestations tables (db/master_schema.sql). Supabase
+// projects use their Postgres connection string.
+type PostgresTrustLedgerStore struct {
+	db *sql.DB
+}
+
+// NewPostgresTrustLedgerStore creates a Postgres-backed trust ledger store.
+func NewPostgresTrustLedgerStore(db *sql.DB) *PostgresTrustLedgerStore {
+	return &PostgresTrustLedgerStore{db: db}
+}
+
+func (p *PostgresTrustLedgerStore) LoadInstances(ctx context.Context) ([]*InstanceTrustRecord, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT data FROM federation_trust_instances`)
+	if err != nil {
+		return nil, fmt.Errorf("load trust instances: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*InstanceTrustRecord
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scan trust instance: %w", err)
+		}
+		record := &InstanceTrustRecord{}
+		if err := json.Unmarshal(data, record); err != nil {
+			slog.Warn("[TrustLedgerStore] Skipping unreadable instance record", "error", err)
+			continue
+		}
+		out = append(out, record)
+	}
+	return out, rows.Err()
+}
+
+func (p *PostgresTrustLedgerStore) SaveInstance(ctx context.Context, record *InstanceTrustRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal trust record: %w", err)
+	}
+	_, err = p.db.ExecContext(ctx, `
+		INSERT INTO federation_trust_instances (remote_instance_id, current_trust, last_handshake_at, data, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (remote_instance_id) DO UPDATE SET
+			current_trust = EXCLUDED.current_trust,
+			last_handshake_at = EXCLUDED.last_handshake_at,
+			data = EXCLUDED.data,
+			updated_at = NOW()`,
+		record.RemoteInstanceID, record.CurrentTrust, record.LastHandshakeAt, data)
+	if err != nil {
+		return fmt.Errorf("save trust record %s: %w", record.RemoteInstanceID, err)
+	}
+	return nil
+}
+
+func (p *PostgresTrustLedgerStore) AppendAttestation(ctx context.Context, e TrustAttestationEvent) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO federation_trust_attestations (event_id, local_instance_id, remote_instance_id, agent_id,
+			local_trust, remote_trust, agreed_trust, attestation_hash, outcome, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (event_id) DO NOTHING`,
+		e.EventID, e.LocalInstanceID, e.RemoteInstanceID, e.AgentID,
+		e.LocalTrust, e.RemoteTrust, e.AgreedTrust, e.AttestationHash, e.Outcome, e.Timestamp)
+	if err != nil {
+		return fmt.Errorf("append attestation %s: %w", e.EventID, err)
+	}
+	return nil
+}
+
+func (p *PostgresTrustLedgerStore) ListAttestations(ctx context.Context, q AttestationQuery) (*AttestationPage, error) {
+	q = normalizeQuery(q)
+	var where []string
+	var args []interface{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if q.RemoteInstanceID != "" {
+		add("remote_instance_id = $%d", q.RemoteInstanceID)
+	}
+	if q.AgentID != "" {
+		add("agent_id = $%d", q.AgentID)
+	}
+	if q.Outcome != "" {
+		add("outcome = $%d", q.Outcome)
+	}
+	if !q.Since.IsZero() {
+		add("created_at >= $%d", q.Since)
+	}
+	if !q.Until.IsZero() {
+		add("created_at < $%d", q.Until)
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := p.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM federation_trust_attestations`+filter, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count attestations: %w", err)
+	}
+
+	args = append(args, q.Limit, q.Offset)
+	rows, err := p.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT event_id, local_instance_id, remote_instance_id, agent_id,
+			local_trust, remote_trust, agreed_trust, attestation_hash, outcome, created_at
+		FROM federation_trust_attestations%s
+		ORDER BY created_at DESC, event_id DESC
+		LIMIT $%d OFFSET $%d`, filter, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list attestations: %w", err)
+	}
+	defer rows.Close()
+
+	var events []TrustAttestationEvent
+	for rows.Next() {
+		var e TrustAttestationEvent
+		if err := rows.Scan(&e.EventID, &e.LocalInstanceID, &e.RemoteInstanceID, &e.AgentID,
+			&e.LocalTrust, &e.RemoteTrust, &e.AgreedTrust, &e.AttestationHash, &e.Outcome, &e.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan attestation: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list attestations: %w", err)
+	}
+	return newAttestationPage(events, total, q), nil
+}
+
+func (p *PostgresTrustLedgerStore) PruneAttestations(ctx context.Context, before time.Time) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM federation_trust_attestations WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune attestations: %w", err)
+	}
+	return res.RowsAffected()
+}
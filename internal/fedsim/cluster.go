@@ -0,0 +1,647 @@
+// Package fedsim runs federation simulations: N in-process OCX instances,
+// each with its own FederationManager, FederationRegistry, trust ledger,
+// revocation list and fabric Hub, exchanging signed messages over gRPC on
+// in-memory bufconn listeners. Faults — latency, dropped messages, forged
+// signatures, clock skew and key revocation — are injected per instance,
+// and the cluster reports delivery and the trust every instance has
+// earned with the others, so integration tests and the ocx-sim scenario
+// runner can assert that trust converges and messages get through.
+package fedsim
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	mrand "math/rand/v2"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ocx/backend/internal/evidence"
+	"github.com/ocx/backend/internal/fabric"
+	"github.com/ocx/backend/internal/federation"
+	pb "github.com/ocx/backend/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// ============================================================================
+// CLUSTER
+// ============================================================================
+
+// ErrDropped is returned by Send for a message lost to an injected drop.
+var ErrDropped = errors.New("message dropped by fault injection")
+
+const (
+	// ForgedSignaturePenalty is the trust factor a receiver applies to the
+	// sender of a message whose signature does not verify.
+	ForgedSignaturePenalty = 0.5
+
+	// ExpiredMessagePenalty is the trust factor a receiver applies to the
+	// sender of a message outside its lifetime, e.g. from a skewed clock.
+	ExpiredMessagePenalty = 0.9
+)
+
+// Config sizes a cluster.
+type Config struct {
+	Instances      int           // number of OCX instances (default 3)
+	Tenant         string        // tenant hosting the agents on every hub (default "tenant-1")
+	Agents         []string      // agents registered on every hub (default ["agent-1"])
+	HandshakeTrust float64       // trust both sides claim in a successful handshake (default 0.9)
+	MaxClockSkew   time.Duration // sender clock drift each manager tolerates (default 30s)
+	Seed           uint64        // seeds dropped-message decisions
+}
+
+// Faults are the faults injected on an instance's outbound traffic.
+type Faults struct {
+	Latency         time.Duration // added to every handshake step and message
+	DropRate        float64       // fraction of outbound messages lost (0–1)
+	ForgeSignatures bool          // corrupt signatures on handshakes and messages
+	ClockSkew       time.Duration // offset of the instance's clock
+}
+
+// IsZero reports whether no fault is set.
+func (f Faults) IsZero() bool {
+	return f == Faults{}
+}
+
+// Link is an ordered pair of instances.
+type Link struct {
+	From federation.OCXInstanceID
+	To   federation.OCXInstanceID
+}
+
+// LinkStats counts the outcomes of messages sent over a link.
+type LinkStats struct {
+	Sent        int `json:"sent" yaml:"sent"`
+	Delivered   int `json:"delivered" yaml:"delivered"`
+	Dropped     int `json:"dropped" yaml:"dropped"`
+	Rejected    int `json:"rejected" yaml:"rejected"`       // refused by the receiver
+	Unreachable int `json:"unreachable" yaml:"unreachable"` // not connected, e.g. after revocation
+}
+
+func (s *LinkStats) add(o LinkStats) {
+	s.Sent += o.Sent
+	s.Delivered += o.Delivered
+	s.Dropped += o.Dropped
+	s.Rejected += o.Rejected
+	s.Unreachable += o.Unreachable
+}
+
+// DeliveryRate is Delivered/Sent, or 1 when nothing was sent.
+func (s LinkStats) DeliveryRate() float64 {
+	if s.Sent == 0 {
+		return 1
+	}
+	return float64(s.Delivered) / float64(s.Sent)
+}
+
+// Instance is one simulated OCX instance.
+type Instance struct {
+	ID          federation.OCXInstanceID
+	Manager     *federation.FederationManager
+	Registry    *federation.FederationRegistry
+	Ledger      *federation.PersistentTrustLedger
+	Revocations *federation.RevocationRegistry
+	Vault       *evidence.EvidenceVault
+	Hub         *fabric.Hub
+
+	cluster  *Cluster
+	listener *bufconn.Listener
+	server   *grpc.Server
+	inbox    *inbox
+}
+
+// Cluster is a set of simulated instances that can reach each other.
+type Cluster struct {
+	cfg       Config
+	instances map[federation.OCXInstanceID]*Instance
+	order     []federation.OCXInstanceID
+
+	mu         sync.Mutex
+	faults     map[federation.OCXInstanceID]Faults
+	revoked    map[federation.OCXInstanceID]bool
+	stats      map[Link]*LinkStats
+	handshakes int
+	failures   int
+	rng        *mrand.Rand
+}
+
+// InstanceName returns the ID of the i-th instance (1-based).
+func InstanceName(i int) federation.OCXInstanceID {
+	return federation.OCXInstanceID(fmt.Sprintf("ocx-%d", i))
+}
+
+// NewCluster starts cfg.Instances instances. Instances are not connected
+// until they handshake; see ConnectAll.
+func NewCluster(cfg Config) (*Cluster, error) {
+	if cfg.Instances <= 0 {
+		cfg.Instances = 3
+	}
+	if cfg.Tenant == "" {
+		cfg.Tenant = "tenant-1"
+	}
+	if len(cfg.Agents) == 0 {
+		cfg.Agents = []string{"agent-1"}
+	}
+	if cfg.HandshakeTrust <= 0 {
+		cfg.HandshakeTrust = 0.9
+	}
+
+	c := &Cluster{
+		cfg:       cfg,
+		instances: make(map[federation.OCXInstanceID]*Instance),
+		faults:    make(map[federation.OCXInstanceID]Faults),
+		revoked:   make(map[federation.OCXInstanceID]bool),
+		stats:     make(map[Link]*LinkStats),
+		rng:       mrand.New(mrand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15)),
+	}
+	for i := 1; i <= cfg.Instances; i++ {
+		inst, err := c.newInstance(InstanceName(i))
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("start %s: %w", InstanceName(i), err)
+		}
+		c.instances[inst.ID] = inst
+		c.order = append(c.order, inst.ID)
+	}
+	return c, nil
+}
+
+func (c *Cluster) newInstance(id federation.OCXInstanceID) (*Instance, error) {
+	fm, err := federation.NewFederationManager(federation.FederationConfig{
+		InstanceID:   id,
+		Region:       "sim",
+		MaxPeers:     c.cfg.Instances,
+		MaxClockSkew: c.cfg.MaxClockSkew,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inst := &Instance{
+		ID:          id,
+		Manager:     fm,
+		Registry:    federation.NewFederationRegistry(),
+		Ledger:      federation.NewPersistentTrustLedger(),
+		Revocations: federation.NewRevocationRegistry(id),
+		Vault:       evidence.NewEvidenceVault(evidence.VaultConfig{}),
+		Hub:         fabric.NewHub(fabric.HubID(id), "sim", "simulation"),
+		cluster:     c,
+		listener:    bufconn.Listen(1 << 20),
+		inbox:       &inbox{},
+	}
+	inst.Revocations.SetSigner(fm.Crypto())
+	inst.Registry.SetRevocations(inst.Revocations)
+	fm.SetRevocations(inst.Revocations)
+	fm.SetTrustLedger(inst.Ledger)
+	fm.SetEvidenceVault(inst.Vault)
+	fm.SetLocalDelivery(inst.Hub)
+	fm.SetDialOptions(
+		grpc.WithContextDialer(c.dial),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(inst.injectFaults),
+	)
+
+	for _, agent := range c.cfg.Agents {
+		spoke, err := inst.Hub.RegisterSpoke(c.cfg.Tenant, agent, nil, 1.0, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := inst.Hub.AttachSink(spoke.ID, inst.inbox); err != nil {
+			return nil, err
+		}
+	}
+
+	inst.server = grpc.NewServer(grpc.UnaryInterceptor(inst.penalizeRejected))
+	pb.RegisterFederatedMessageServiceServer(inst.server, federation.NewFederatedMessageServer(fm))
+	go inst.server.Serve(inst.listener)
+	return inst, nil
+}
+
+// dial connects to the bufconn listener of the instance named by addr
+// ("passthrough:///ocx-2" dials "ocx-2").
+func (c *Cluster) dial(ctx context.Context, addr string) (net.Conn, error) {
+	inst, ok := c.instances[federation.OCXInstanceID(addr)]
+	if !ok {
+		return nil, fmt.Errorf("no simulated instance %s", addr)
+	}
+	return inst.listener.DialContext(ctx)
+}
+
+// Close stops every instance.
+func (c *Cluster) Close() {
+	for _, inst := range c.instances {
+		inst.server.Stop()
+		inst.Manager.Close()
+		inst.listener.Close()
+	}
+}
+
+// Instance returns the instance with the given ID, or nil.
+func (c *Cluster) Instance(id federation.OCXInstanceID) *Instance {
+	return c.instances[id]
+}
+
+// IDs returns the instance IDs in creation order.
+func (c *Cluster) IDs() []federation.OCXInstanceID {
+	return append([]federation.OCXInstanceID(nil), c.order...)
+}
+
+// Config returns the cluster's configuration with defaults applied.
+func (c *Cluster) Config() Config {
+	return c.cfg
+}
+
+// ============================================================================
+// FAULT INJECTION
+// ============================================================================
+
+// SetFaults replaces the faults injected on id's outbound traffic.
+func (c *Cluster) SetFaults(id federation.OCXInstanceID, f Faults) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f.IsZero() {
+		delete(c.faults, id)
+		return
+	}
+	c.faults[id] = f
+}
+
+// Faults returns the faults injected on id.
+func (c *Cluster) Faults(id federation.OCXInstanceID) Faults {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.faults[id]
+}
+
+// RevokeKey revokes id's attestation key on every other instance, as if a
+// trust bundle listing it had reached them all. Their connections to id
+// are torn down and later handshakes from it are refused.
+func (c *Cluster) RevokeKey(id federation.OCXInstanceID, reason string) error {
+	subject := c.instances[id]
+	if subject == nil {
+		return fmt.Errorf("no simulated instance %s", id)
+	}
+	key := subject.Manager.Crypto().PublicKeyBytes()
+	for _, inst := range c.instances {
+		if inst.ID != id {
+			inst.Revocations.RevokeKey(key, id, reason)
+		}
+	}
+	c.mu.Lock()
+	c.revoked[id] = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Healthy returns the instances with no faults that have not been revoked.
+func (c *Cluster) Healthy() []federation.OCXInstanceID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var healthy []federation.OCXInstanceID
+	for _, id := range c.order {
+		if _, faulty := c.faults[id]; !faulty && !c.revoked[id] {
+			healthy = append(healthy, id)
+		}
+	}
+	return healthy
+}
+
+// now is the instance's clock, including injected skew.
+func (inst *Instance) now() time.Time {
+	return time.Now().Add(inst.cluster.Faults(inst.ID).ClockSkew).UTC()
+}
+
+// injectFaults is the client interceptor on the instance's outbound
+// message connections.
+func (inst *Instance) injectFaults(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	f := inst.cluster.Faults(inst.ID)
+	if err := sleep(ctx, f.Latency); err != nil {
+		return err
+	}
+	if f.DropRate > 0 && inst.cluster.chance() < f.DropRate {
+		return ErrDropped
+	}
+	if env, ok := req.(*pb.FederatedEnvelope); ok && f.ForgeSignatures {
+		env.Signature = corrupt(env.Signature)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// penalizeRejected is the server interceptor on the instance's message
+// service: a sender whose message fails verification loses trust in this
+// instance's ledger.
+func (inst *Instance) penalizeRejected(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	env, ok := req.(*pb.FederatedEnvelope)
+	if err == nil || !ok {
+		return resp, err
+	}
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		inst.Ledger.RecordPenalty(ctx, string(inst.ID), env.SourceOcx, "invalid_message_signature", ForgedSignaturePenalty)
+	case codes.FailedPrecondition:
+		inst.Ledger.RecordPenalty(ctx, string(inst.ID), env.SourceOcx, "message_expired", ExpiredMessagePenalty)
+	}
+	return resp, err
+}
+
+func (c *Cluster) chance() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// corrupt returns a copy of sig with its first byte flipped.
+func corrupt(sig []byte) []byte {
+	if len(sig) == 0 {
+		return []byte{0xff}
+	}
+	forged := append([]byte(nil), sig...)
+	forged[0] ^= 0xff
+	return forged
+}
+
+// ============================================================================
+// HANDSHAKES
+// ============================================================================
+
+// Handshake runs HELLO, CHALLENGE, RESPONSE and CONFIRM between two
+// instances, applying each sender's faults to the steps it sends. On
+// success both record the handshake in their trust ledgers and registries
+// and learn each other's message endpoint; on failure the instance that
+// refused a step records a failed handshake with the other.
+func (c *Cluster) Handshake(ctx context.Context, initiator, responder federation.OCXInstanceID) error {
+	a, b := c.instances[initiator], c.instances[responder]
+	if a == nil || b == nil {
+		return fmt.Errorf("no simulated instance %s or %s", initiator, responder)
+	}
+
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	attestation, err := a.Manager.CreateAttestation(1, len(c.cfg.Agents))
+	if err != nil {
+		return err
+	}
+
+	msg := &federation.HandshakeMessage{Type: federation.HandshakeHello, InstanceID: a.ID, Nonce: nonce, Attestation: attestation}
+	from, to := a, b
+	for msg != nil {
+		reply, err := c.relay(ctx, from, to, msg)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.recordHandshake(to, from, false)
+			}
+			return fmt.Errorf("%s %s → %s: %w", msg.Type, from.ID, to.ID, err)
+		}
+		msg, from, to = reply, to, from
+	}
+
+	endpoints := map[*Instance]*Instance{a: b, b: a}
+	for local, remote := range endpoints {
+		if err := local.Manager.SetPeerEndpoint(remote.ID, "passthrough:///"+string(remote.ID)); err != nil {
+			return err
+		}
+		if err := local.Registry.Register(&federation.OCXInstance{
+			InstanceID:  string(remote.ID),
+			Region:      "sim",
+			MessageAddr: string(remote.ID),
+			PublicKey:   remote.Manager.Crypto().PublicKeyBytes(),
+		}); err != nil {
+			return err
+		}
+		c.recordHandshake(local, remote, true)
+	}
+	return nil
+}
+
+// relay delivers one handshake step from one instance to another.
+func (c *Cluster) relay(ctx context.Context, from, to *Instance, msg *federation.HandshakeMessage) (*federation.HandshakeMessage, error) {
+	f := c.Faults(from.ID)
+	if err := sleep(ctx, f.Latency); err != nil {
+		return nil, err
+	}
+	sent := *msg
+	sent.Timestamp = from.now()
+	if f.ForgeSignatures {
+		if sent.Attestation != nil {
+			attestation := *sent.Attestation
+			attestation.Signature = corrupt(attestation.Signature)
+			sent.Attestation = &attestation
+		}
+		if len(sent.Response) > 0 {
+			sent.Response = corrupt(sent.Response)
+		}
+	}
+	return to.Manager.ProcessHandshakeMessage(&sent)
+}
+
+func (c *Cluster) recordHandshake(observer, subject *Instance, success bool) {
+	trust := c.cfg.HandshakeTrust
+	observer.Ledger.RecordHandshake(context.Background(),
+		string(observer.ID), string(subject.ID), "", "", "fedsim",
+		trust, trust, success)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if success {
+		c.handshakes++
+	} else {
+		c.failures++
+	}
+}
+
+// ConnectAll handshakes every pair of instances once and returns the
+// failures joined.
+func (c *Cluster) ConnectAll(ctx context.Context) error {
+	var errs []error
+	for i, a := range c.order {
+		for _, b := range c.order[i+1:] {
+			if err := c.Handshake(ctx, a, b); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HandshakeCounts returns the successful and failed handshakes observed so
+// far; a successful handshake counts once per side.
+func (c *Cluster) HandshakeCounts() (succeeded, failed int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.handshakes, c.failures
+}
+
+// ============================================================================
+// MESSAGES
+// ============================================================================
+
+// Send delivers payload from one instance to agent on another over the
+// federated message transport, stamped with the sender's (possibly
+// skewed) clock, and records the outcome.
+func (c *Cluster) Send(ctx context.Context, from, to federation.OCXInstanceID, agent string, payload []byte) (*federation.MessageReceipt, error) {
+	sender := c.instances[from]
+	if sender == nil {
+		return nil, fmt.Errorf("no simulated instance %s", from)
+	}
+	receipt, err := sender.Manager.SendMessage(ctx, &federation.FederatedMessage{
+		DestOCX:      to,
+		SourceTenant: c.cfg.Tenant,
+		SourceAgent:  "fedsim",
+		DestTenant:   c.cfg.Tenant,
+		DestAgent:    agent,
+		MessageType:  "simulation",
+		Payload:      payload,
+		Timestamp:    sender.now(),
+	})
+
+	outcome := LinkStats{Sent: 1}
+	switch {
+	case err == nil:
+		outcome.Delivered = 1
+	case errors.Is(err, ErrDropped):
+		outcome.Dropped = 1
+	case refused(err):
+		outcome.Rejected = 1
+	default:
+		outcome.Unreachable = 1
+	}
+	c.mu.Lock()
+	stats, ok := c.stats[Link{from, to}]
+	if !ok {
+		stats = &LinkStats{}
+		c.stats[Link{from, to}] = stats
+	}
+	stats.add(outcome)
+	c.mu.Unlock()
+	return receipt, err
+}
+
+// refused reports whether a send failed because the receiver refused the
+// message, rather than because the sender could not reach it.
+func refused(err error) bool {
+	st, ok := status.FromError(errors.Unwrap(err))
+	return ok && st.Code() != codes.OK && st.Code() != codes.Unavailable
+}
+
+// Stats returns message outcomes per link.
+func (c *Cluster) Stats() map[Link]LinkStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[Link]LinkStats, len(c.stats))
+	for link, s := range c.stats {
+		out[link] = *s
+	}
+	return out
+}
+
+// Totals sums the outcomes of links between the given instances, or of all
+// links when ids is empty.
+func (c *Cluster) Totals(ids ...federation.OCXInstanceID) LinkStats {
+	in := make(map[federation.OCXInstanceID]bool, len(ids))
+	for _, id := range ids {
+		in[id] = true
+	}
+	var total LinkStats
+	for link, s := range c.Stats() {
+		if len(ids) == 0 || (in[link.From] && in[link.To]) {
+			total.add(s)
+		}
+	}
+	return total
+}
+
+// Received returns the messages the instance's hub delivered to its agents.
+func (inst *Instance) Received() []*fabric.Message {
+	return inst.inbox.messages()
+}
+
+// inbox is the spoke sink of every simulated agent.
+type inbox struct {
+	mu       sync.Mutex
+	received []*fabric.Message
+}
+
+func (b *inbox) Deliver(msg *fabric.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.received = append(b.received, msg)
+	return nil
+}
+
+func (b *inbox) messages() []*fabric.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*fabric.Message(nil), b.received...)
+}
+
+// ============================================================================
+// TRUST
+// ============================================================================
+
+// Trust returns the trust observer's ledger holds for subject.
+func (c *Cluster) Trust(observer, subject federation.OCXInstanceID) float64 {
+	inst := c.instances[observer]
+	if inst == nil {
+		return 0
+	}
+	return inst.Ledger.GetInstanceTrust(string(subject))
+}
+
+// TrustMatrix returns every instance's trust in every other instance,
+// keyed observer → subject.
+func (c *Cluster) TrustMatrix() map[federation.OCXInstanceID]map[federation.OCXInstanceID]float64 {
+	matrix := make(map[federation.OCXInstanceID]map[federation.OCXInstanceID]float64, len(c.order))
+	for _, observer := range c.order {
+		row := make(map[federation.OCXInstanceID]float64, len(c.order)-1)
+		for _, subject := range c.order {
+			if subject != observer {
+				row[subject] = c.Trust(observer, subject)
+			}
+		}
+		matrix[observer] = row
+	}
+	return matrix
+}
+
+// TrustRange returns the lowest and highest trust the observers hold for
+// subject, ignoring subject's view of itself. Trust has converged on
+// subject when the two are close.
+func (c *Cluster) TrustRange(subject federation.OCXInstanceID, observers []federation.OCXInstanceID) (lowest, highest float64) {
+	var scores []float64
+	for _, observer := range observers {
+		if observer != subject {
+			scores = append(scores, c.Trust(observer, subject))
+		}
+	}
+	if len(scores) == 0 {
+		return 0, 0
+	}
+	sort.Float64s(scores)
+	return scores[0], scores[len(scores)-1]
+}
@@ -0,0 +1,112 @@
+package fedsim
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ocx/backend/internal/federation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ============================================================================
+// FEDERATION SIMULATION TESTS
+// ============================================================================
+
+func connectedCluster(t *testing.T, n int) *Cluster {
+	t.Helper()
+	c, err := NewCluster(Config{Instances: n, Seed: 1})
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	require.NoError(t, c.ConnectAll(context.Background()))
+	return c
+}
+
+func TestClusterDeliversOverGRPC(t *testing.T) {
+	c := connectedCluster(t, 3)
+	ctx := context.Background()
+
+	receipt, err := c.Send(ctx, "ocx-1", "ocx-3", "agent-1", []byte(`{"task":"reserve"}`))
+	require.NoError(t, err)
+	assert.Equal(t, federation.OCXInstanceID("ocx-3"), receipt.ReceiverOCX)
+
+	received := c.Instance("ocx-3").Received()
+	require.Len(t, received, 1)
+	assert.Equal(t, `{"task":"reserve"}`, string(received[0].Payload))
+	assert.Equal(t, "ocx-1", received[0].Headers["x-ocx-federation-source"])
+
+	assert.Equal(t, LinkStats{Sent: 1, Delivered: 1}, c.Stats()[Link{"ocx-1", "ocx-3"}])
+	assert.Greater(t, c.Trust("ocx-3", "ocx-1"), 0.5)
+}
+
+func TestClusterFaults(t *testing.T) {
+	c := connectedCluster(t, 3)
+	ctx := context.Background()
+	before := c.Trust("ocx-2", "ocx-1")
+
+	c.SetFaults("ocx-1", Faults{ForgeSignatures: true})
+	_, err := c.Send(ctx, "ocx-1", "ocx-2", "agent-1", []byte("forged"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(errors.Unwrap(err)), "got %v", err)
+	assert.InDelta(t, before*ForgedSignaturePenalty, c.Trust("ocx-2", "ocx-1"), 0.001)
+	assert.Error(t, c.Handshake(ctx, "ocx-1", "ocx-3"))
+
+	c.SetFaults("ocx-1", Faults{ClockSkew: 5 * time.Minute})
+	_, err = c.Send(ctx, "ocx-1", "ocx-2", "agent-1", []byte("from the future"))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(errors.Unwrap(err)), "got %v", err)
+
+	c.SetFaults("ocx-1", Faults{DropRate: 1})
+	_, err = c.Send(ctx, "ocx-1", "ocx-2", "agent-1", []byte("lost"))
+	assert.True(t, errors.Is(err, ErrDropped), "got %v", err)
+
+	c.SetFaults("ocx-1", Faults{})
+	require.NoError(t, c.RevokeKey("ocx-1", "test"))
+	_, err = c.Send(ctx, "ocx-1", "ocx-2", "agent-1", []byte("revoked"))
+	assert.Error(t, err)
+	assert.Error(t, c.Handshake(ctx, "ocx-2", "ocx-1"))
+
+	assert.Empty(t, c.Instance("ocx-2").Received())
+	assert.Equal(t, LinkStats{Sent: 4, Dropped: 1, Rejected: 3}, c.Stats()[Link{"ocx-1", "ocx-2"}])
+	assert.Equal(t, []federation.OCXInstanceID{"ocx-2", "ocx-3"}, c.Healthy())
+}
+
+func TestBuiltinScenariosPass(t *testing.T) {
+	for _, s := range BuiltinScenarios() {
+		t.Run(s.Name, func(t *testing.T) {
+			result, err := Run(context.Background(), s)
+			require.NoError(t, err)
+			assert.True(t, result.Passed(), "failures: %v", result.Failures)
+		})
+	}
+}
+
+func TestScenarioExpectationsFail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+name: forger-trusted
+instances: 3
+rounds: 3
+faults:
+  - instance: ocx-3
+    forge_signatures: true
+expect:
+  trusted: [ocx-3]
+  min_trust: 0.5
+`), 0o644))
+
+	s, err := LoadScenario(path)
+	require.NoError(t, err)
+	result, err := Run(context.Background(), s)
+	require.NoError(t, err)
+	assert.False(t, result.Passed())
+	require.Len(t, result.Failures, 1)
+	assert.Contains(t, result.Failures[0], "ocx-3")
+
+	s.Faults[0].Instance = "ocx-9"
+	assert.Error(t, s.Validate())
+}
@@ -0,0 +1,374 @@
+package fedsim
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ocx/backend/internal/federation"
+	"gopkg.in/yaml.v2"
+)
+
+// ============================================================================
+// SCENARIOS
+//
+// A Scenario drives a Cluster through rounds. Each round, faults and
+// revocations due that round are applied, every pair of instances
+// handshakes again (re-attestation, which is what moves trust in the
+// ledgers), and every connected instance sends MessagesPerRound messages
+// to each other instance. Expectations are then checked against the
+// healthy instances: those with no faults that were never revoked.
+// ============================================================================
+
+// Scenario describes a simulation run. It loads from YAML (or JSON).
+type Scenario struct {
+	Name             string           `yaml:"name" json:"name"`
+	Description      string           `yaml:"description" json:"description,omitempty"`
+	Instances        int              `yaml:"instances" json:"instances"`                   // default 3
+	Rounds           int              `yaml:"rounds" json:"rounds"`                         // default 5
+	MessagesPerRound int              `yaml:"messages_per_round" json:"messages_per_round"` // per ordered pair; default 2
+	Seed             uint64           `yaml:"seed" json:"seed"`
+	Faults           []FaultSpec      `yaml:"faults" json:"faults,omitempty"`
+	Revocations      []RevocationSpec `yaml:"revocations" json:"revocations,omitempty"`
+	Expect           Expectations     `yaml:"expect" json:"expect"`
+}
+
+// FaultSpec injects faults on one instance from a round onwards.
+type FaultSpec struct {
+	Instance        string  `yaml:"instance" json:"instance"`
+	FromRound       int     `yaml:"from_round" json:"from_round,omitempty"` // 1-based; 0 = from the start
+	LatencyMS       int     `yaml:"latency_ms" json:"latency_ms,omitempty"`
+	DropRate        float64 `yaml:"drop_rate" json:"drop_rate,omitempty"`
+	ForgeSignatures bool    `yaml:"forge_signatures" json:"forge_signatures,omitempty"`
+	ClockSkewSec    int     `yaml:"clock_skew_sec" json:"clock_skew_sec,omitempty"`
+}
+
+func (f FaultSpec) faults() Faults {
+	return Faults{
+		Latency:         time.Duration(f.LatencyMS) * time.Millisecond,
+		DropRate:        f.DropRate,
+		ForgeSignatures: f.ForgeSignatures,
+		ClockSkew:       time.Duration(f.ClockSkewSec) * time.Second,
+	}
+}
+
+// RevocationSpec revokes an instance's key on every other instance at the
+// start of a round.
+type RevocationSpec struct {
+	Instance string `yaml:"instance" json:"instance"`
+	Round    int    `yaml:"round" json:"round"`
+	Reason   string `yaml:"reason" json:"reason,omitempty"`
+}
+
+// Expectations are checked after the last round. Zero values are not
+// checked.
+type Expectations struct {
+	MinDeliveryRate float64  `yaml:"min_delivery_rate" json:"min_delivery_rate,omitempty"` // between healthy instances
+	MaxTrustSpread  float64  `yaml:"max_trust_spread" json:"max_trust_spread,omitempty"`   // per subject, across healthy observers
+	Trusted         []string `yaml:"trusted" json:"trusted,omitempty"`                     // trusted by every healthy observer…
+	MinTrust        float64  `yaml:"min_trust" json:"min_trust,omitempty"`                 // …at least this much
+	Distrusted      []string `yaml:"distrusted" json:"distrusted,omitempty"`               // trusted by every healthy observer…
+	MaxTrust        float64  `yaml:"max_trust" json:"max_trust,omitempty"`                 // …at most this much
+}
+
+// Result reports a scenario run.
+type Result struct {
+	Scenario          string                        `json:"scenario"`
+	Rounds            int                           `json:"rounds"`
+	Duration          time.Duration                 `json:"duration_ns"`
+	Handshakes        int                           `json:"handshakes"`
+	HandshakeFailures int                           `json:"handshake_failures"`
+	Messages          LinkStats                     `json:"messages"`
+	Healthy           []string                      `json:"healthy"`
+	HealthyMessages   LinkStats                     `json:"healthy_messages"`
+	Trust             map[string]map[string]float64 `json:"trust"`       // observer → subject
+	TrustRange        map[string][2]float64         `json:"trust_range"` // subject → [lowest, highest] across healthy observers
+	Failures          []string                      `json:"failures,omitempty"`
+}
+
+// Passed reports whether every expectation held.
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// LoadScenario reads a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	if err := yaml.UnmarshalStrict(data, &s); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if s.Name == "" {
+		s.Name = path
+	}
+	return &s, s.Validate()
+}
+
+// Validate applies defaults and checks instance names and rounds.
+func (s *Scenario) Validate() error {
+	if s.Instances <= 0 {
+		s.Instances = 3
+	}
+	if s.Rounds <= 0 {
+		s.Rounds = 5
+	}
+	if s.MessagesPerRound <= 0 {
+		s.MessagesPerRound = 2
+	}
+
+	known := make(map[string]bool, s.Instances)
+	for i := 1; i <= s.Instances; i++ {
+		known[string(InstanceName(i))] = true
+	}
+	check := func(field, id string) error {
+		if !known[id] {
+			return fmt.Errorf("scenario %s: %s names unknown instance %q (have ocx-1..ocx-%d)", s.Name, field, id, s.Instances)
+		}
+		return nil
+	}
+	for _, f := range s.Faults {
+		if err := check("fault", f.Instance); err != nil {
+			return err
+		}
+		if f.DropRate < 0 || f.DropRate > 1 {
+			return fmt.Errorf("scenario %s: drop_rate %.2f for %s outside [0, 1]", s.Name, f.DropRate, f.Instance)
+		}
+	}
+	for _, r := range s.Revocations {
+		if err := check("revocation", r.Instance); err != nil {
+			return err
+		}
+		if r.Round < 1 || r.Round > s.Rounds {
+			return fmt.Errorf("scenario %s: revocation of %s in round %d outside 1..%d", s.Name, r.Instance, r.Round, s.Rounds)
+		}
+	}
+	for _, id := range append(append([]string(nil), s.Expect.Trusted...), s.Expect.Distrusted...) {
+		if err := check("expectation", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run executes the scenario on a fresh cluster and checks its
+// expectations. The error is for a run that could not complete; unmet
+// expectations are reported in Result.Failures.
+func Run(ctx context.Context, s *Scenario) (*Result, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	cluster, err := NewCluster(Config{Instances: s.Instances, Seed: s.Seed})
+	if err != nil {
+		return nil, err
+	}
+	defer cluster.Close()
+
+	start := time.Now()
+	ids := cluster.IDs()
+	agents := cluster.Config().Agents
+	for round := 1; round <= s.Rounds; round++ {
+		for _, f := range s.Faults {
+			if max(f.FromRound, 1) == round {
+				cluster.SetFaults(federation.OCXInstanceID(f.Instance), f.faults())
+			}
+		}
+		for _, r := range s.Revocations {
+			if r.Round == round {
+				reason := r.Reason
+				if reason == "" {
+					reason = "key compromise (simulated)"
+				}
+				if err := cluster.RevokeKey(federation.OCXInstanceID(r.Instance), reason); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// Re-attest every pair, alternating which side initiates
+		for i, a := range ids {
+			for _, b := range ids[i+1:] {
+				initiator, responder := a, b
+				if round%2 == 0 {
+					initiator, responder = b, a
+				}
+				cluster.Handshake(ctx, initiator, responder)
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, from := range ids {
+			for _, to := range ids {
+				if from == to {
+					continue
+				}
+				for n := 0; n < s.MessagesPerRound; n++ {
+					payload := fmt.Sprintf(`{"round":%d,"seq":%d}`, round, n)
+					cluster.Send(ctx, from, to, agents[n%len(agents)], []byte(payload))
+					if err := ctx.Err(); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	result := cluster.result(s)
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// result summarises the cluster and checks s.Expect against it.
+func (c *Cluster) result(s *Scenario) *Result {
+	healthy := c.Healthy()
+	r := &Result{
+		Scenario:        s.Name,
+		Rounds:          s.Rounds,
+		Messages:        c.Totals(),
+		HealthyMessages: c.Totals(healthy...),
+		Trust:           make(map[string]map[string]float64),
+		TrustRange:      make(map[string][2]float64),
+	}
+	r.Handshakes, r.HandshakeFailures = c.HandshakeCounts()
+	for _, id := range healthy {
+		r.Healthy = append(r.Healthy, string(id))
+	}
+	for observer, row := range c.TrustMatrix() {
+		r.Trust[string(observer)] = make(map[string]float64, len(row))
+		for subject, trust := range row {
+			r.Trust[string(observer)][string(subject)] = trust
+		}
+	}
+	for _, subject := range c.IDs() {
+		lowest, highest := c.TrustRange(subject, healthy)
+		r.TrustRange[string(subject)] = [2]float64{lowest, highest}
+	}
+
+	fail := func(format string, args ...interface{}) {
+		r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+	}
+
+	// Every message the receivers accepted reached an agent on their hub
+	accepted := make(map[federation.OCXInstanceID]int)
+	for link, stats := range c.Stats() {
+		accepted[link.To] += stats.Delivered
+	}
+	for _, id := range c.IDs() {
+		if got := len(c.Instance(id).Received()); got != accepted[id] {
+			fail("%s: hub delivered %d of %d accepted messages", id, got, accepted[id])
+		}
+	}
+
+	e := s.Expect
+	if e.MinDeliveryRate > 0 {
+		if rate := r.HealthyMessages.DeliveryRate(); rate < e.MinDeliveryRate {
+			fail("delivery between healthy instances %.2f below %.2f", rate, e.MinDeliveryRate)
+		}
+	}
+	if e.MaxTrustSpread > 0 {
+		for _, subject := range sortedKeys(r.TrustRange) {
+			span := r.TrustRange[subject]
+			if spread := span[1] - span[0]; spread > e.MaxTrustSpread {
+				fail("trust in %s has not converged: healthy observers range %.3f–%.3f", subject, span[0], span[1])
+			}
+		}
+	}
+	for _, subject := range e.Trusted {
+		if lowest := r.TrustRange[subject][0]; lowest < e.MinTrust {
+			fail("%s trusted %.3f by some healthy observer, want ≥ %.3f", subject, lowest, e.MinTrust)
+		}
+	}
+	for _, subject := range e.Distrusted {
+		if highest := r.TrustRange[subject][1]; highest > e.MaxTrust {
+			fail("%s trusted %.3f by some healthy observer, want ≤ %.3f", subject, highest, e.MaxTrust)
+		}
+	}
+	return r
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ============================================================================
+// BUILT-IN SCENARIOS
+// ============================================================================
+
+// BuiltinScenarios returns the scenarios shipped with the runner, one per
+// fault.
+func BuiltinScenarios() []*Scenario {
+	return []*Scenario{
+		{
+			Name:        "baseline",
+			Description: "Healthy federation: everything is delivered and trust converges upwards",
+			Instances:   4, Rounds: 6, MessagesPerRound: 2, Seed: 1,
+			Expect: Expectations{
+				MinDeliveryRate: 1, MaxTrustSpread: 0.01,
+				Trusted: []string{"ocx-1", "ocx-2", "ocx-3", "ocx-4"}, MinTrust: 0.8,
+			},
+		},
+		{
+			Name:        "lossy-network",
+			Description: "ocx-2 drops a quarter of its messages and adds latency; healthy links are unaffected",
+			Instances:   4, Rounds: 5, MessagesPerRound: 4, Seed: 7,
+			Faults: []FaultSpec{{Instance: "ocx-2", LatencyMS: 2, DropRate: 0.25}},
+			Expect: Expectations{
+				MinDeliveryRate: 1, MaxTrustSpread: 0.01,
+				Trusted: []string{"ocx-1", "ocx-2"}, MinTrust: 0.8,
+			},
+		},
+		{
+			Name:        "forged-signer",
+			Description: "ocx-3 starts forging signatures in round 3 and loses the federation's trust",
+			Instances:   5, Rounds: 6, MessagesPerRound: 2, Seed: 3,
+			Faults: []FaultSpec{{Instance: "ocx-3", FromRound: 3, ForgeSignatures: true}},
+			Expect: Expectations{
+				MinDeliveryRate: 1, MaxTrustSpread: 0.01,
+				Trusted: []string{"ocx-1", "ocx-2", "ocx-4", "ocx-5"}, MinTrust: 0.8,
+				Distrusted: []string{"ocx-3"}, MaxTrust: 0.2,
+			},
+		},
+		{
+			Name:        "clock-skew",
+			Description: "ocx-4's clock runs two minutes fast; its messages are refused as out of lifetime",
+			Instances:   4, Rounds: 5, MessagesPerRound: 2, Seed: 4,
+			Faults: []FaultSpec{{Instance: "ocx-4", ClockSkewSec: 120}},
+			Expect: Expectations{
+				MinDeliveryRate: 1, MaxTrustSpread: 0.01,
+				Distrusted: []string{"ocx-4"}, MaxTrust: 0.6,
+			},
+		},
+		{
+			Name:        "key-revocation",
+			Description: "ocx-2's key is revoked in round 3; it is disconnected and its re-attestations refused",
+			Instances:   4, Rounds: 6, MessagesPerRound: 2, Seed: 5,
+			Revocations: []RevocationSpec{{Instance: "ocx-2", Round: 3, Reason: "key compromise (simulated)"}},
+			Expect: Expectations{
+				MinDeliveryRate: 1, MaxTrustSpread: 0.01,
+				Trusted: []string{"ocx-1", "ocx-3", "ocx-4"}, MinTrust: 0.8,
+				Distrusted: []string{"ocx-2"}, MaxTrust: 0.5,
+			},
+		},
+	}
+}
+
+// BuiltinScenario returns the built-in scenario with the given name.
+func BuiltinScenario(name string) (*Scenario, error) {
+	for _, s := range BuiltinScenarios() {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown scenario %q", name)
+}
@@ -9,6 +9,8 @@
 package governance
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -272,6 +274,34 @@ func (c *GovernanceConfigCache) GetConfig(tenantID string) *TenantGovernanceConf
 	return cfg
 }
 
+// Hash returns a SHA-256 over the governance parameters, ignoring row
+// identity and timestamps, so two instances running the same parameters
+// for a tenant report the same hash.
+func (c *TenantGovernanceConfig) Hash() string {
+	params := *c
+	params.ConfigID = ""
+	params.CreatedAt = time.Time{}
+	params.UpdatedAt = time.Time{}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Hashes returns the config hash of every cached tenant.
+func (c *GovernanceConfigCache) Hashes() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make(map[string]string, len(c.configs))
+	for tenantID, cfg := range c.configs {
+		out[tenantID] = cfg.Hash()
+	}
+	return out
+}
+
 // Invalidate removes a tenant's config from the cache, forcing a reload on
 // the next GetConfig call. Call this after a config update via the API.
 func (c *GovernanceConfigCache) Invalidate(tenantID string) {
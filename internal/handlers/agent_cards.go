@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ocx/backend/internal/multitenancy"
+	"github.com/ocx/backend/internal/security"
+)
+
+// HandleRegisterAgentCard binds an A2A agent to its peer URL within the
+// caller's tenant, fetching and verifying the agent card. Later /govern calls
+// and hub routing in that tenant check the agent's invocations against the
+// skills the card declares.
+func HandleRegisterAgentCard(cards *security.AgentCardRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			AgentID string `json:"agent_id"`
+			URL     string `json:"url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if req.AgentID == "" || req.URL == "" {
+			http.Error(w, `{"error":"agent_id and url are required"}`, http.StatusBadRequest)
+			return
+		}
+
+		card, err := cards.Register(r.Context(), tenantID, req.AgentID, req.URL)
+		if err != nil {
+			slog.Warn("/a2a/agents register failed", "tenant_id", tenantID, "agent_id", req.AgentID, "url", req.URL, "error", err)
+			if errors.Is(err, security.ErrNonPublicAddress) {
+				http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(card)
+	}
+}
+
+// HandleGetAgentCard returns the card the caller's tenant registered for an
+// agent, its verification result and how often the agent has acted outside
+// its declared skills.
+func HandleGetAgentCard(cards *security.AgentCardRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		agentID := mux.Vars(r)["agentId"]
+		card, ok := cards.Get(tenantID, agentID)
+		if !ok {
+			http.Error(w, `{"error":"no agent card registered for agent"}`, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(card)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/ocx/backend/internal/catalog"
 	"github.com/ocx/backend/internal/events"
+	"github.com/ocx/backend/internal/multitenancy"
 	"github.com/ocx/backend/internal/webhooks"
 	"github.com/ocx/backend/pkg/plugins"
 )
@@ -127,10 +128,12 @@ func HandleDeleteWebhook(reg *webhooks.Registry) http.HandlerFunc {
 	}
 }
 
-// HandleListPlugins lists all registered plugins.
+// HandleListPlugins lists the platform plugins and the caller's own tenant
+// plugins.
 func HandleListPlugins(reg *plugins.Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		pluginList := reg.List()
+		tenantID, _ := multitenancy.GetTenantID(r.Context())
+		pluginList := reg.ListForTenant(tenantID)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"plugins": pluginList,
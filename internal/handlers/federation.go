@@ -1,13 +1,21 @@
 package handlers
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/ocx/backend/internal/config"
 	"github.com/ocx/backend/internal/federation"
+	"github.com/ocx/backend/internal/multitenancy"
+	"github.com/ocx/backend/internal/reputation"
 )
 
 // HandleFederationHandshake initiates an Inter-OCX handshake (§5).
@@ -15,6 +23,7 @@ func HandleFederationHandshake(cfg *config.Config, registry *federation.Federati
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			RemoteInstanceID string `json:"remote_instance_id"`
+			RemoteDomain     string `json:"remote_domain"`
 			AgentID          string `json:"agent_id"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -22,8 +31,28 @@ func HandleFederationHandshake(cfg *config.Config, registry *federation.Federati
 			return
 		}
 
-		// Look up remote instance
+		// Resolve a partner by domain, or look up a registered instance
+		if req.RemoteDomain != "" {
+			discovered, err := registry.Discover(r.Context(), req.RemoteDomain)
+			if errors.Is(err, federation.ErrRevoked) {
+				http.Error(w, "Remote instance revoked: "+err.Error(), http.StatusForbidden)
+				return
+			}
+			if errors.Is(err, federation.ErrInstanceConflict) {
+				http.Error(w, "Remote instance conflict: "+err.Error(), http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Remote instance discovery failed: "+err.Error(), http.StatusBadGateway)
+				return
+			}
+			req.RemoteInstanceID = string(discovered.Metadata.InstanceID)
+		}
 		remote, err := registry.Lookup(req.RemoteInstanceID)
+		if errors.Is(err, federation.ErrRevoked) {
+			http.Error(w, "Remote instance revoked: "+err.Error(), http.StatusForbidden)
+			return
+		}
 		if err != nil {
 			http.Error(w, "Remote instance not found in registry: "+err.Error(), http.StatusNotFound)
 			return
@@ -40,6 +69,10 @@ func HandleFederationHandshake(cfg *config.Config, registry *federation.Federati
 		// §5.2 Fix: Use real TrustAttestationLedger backed by PersistentTrustLedger
 		tal := federation.NewTrustAttestationLedgerWithID(cfg.Federation.InstanceID)
 		handshake := federation.NewInterOCXHandshake(local, remote, tal)
+		if revocations := registry.Revocations(); revocations != nil {
+			handshake.SetRevocationChecker(revocations)
+		}
+		handshake.SetAlgorithmPreference(handshakeAlgorithms(cfg)...)
 		result, err := handshake.NegotiateV2(r.Context(), req.AgentID)
 		if err != nil {
 			slog.Warn("Federation handshake failed", "error", err)
@@ -62,6 +95,16 @@ func HandleFederationHandshake(cfg *config.Config, registry *federation.Federati
 	}
 }
 
+// handshakeAlgorithms returns the configured HELLO algorithm preference,
+// with the instance's default algorithm first when it is accepted.
+func handshakeAlgorithms(cfg *config.Config) []federation.CryptoAlgorithm {
+	accepted := make([]federation.CryptoAlgorithm, 0, len(cfg.Handshake.AcceptedCryptoAlgorithms))
+	for _, alg := range cfg.Handshake.AcceptedCryptoAlgorithms {
+		accepted = append(accepted, federation.CryptoAlgorithm(alg))
+	}
+	return federation.AlgorithmPreference(federation.CryptoAlgorithm(cfg.Handshake.DefaultCryptoAlgorithm), accepted)
+}
+
 // HandleFederationTrust lists trusted OCX instances (§5.2).
 func HandleFederationTrust(ledger *federation.PersistentTrustLedger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -98,17 +141,377 @@ func HandleFederationInstanceTrust(ledger *federation.PersistentTrustLedger) htt
 	}
 }
 
-// HandleFederationAttestations lists recent attestation events.
+// HandleFederationAttestations pages through attestation history, newest
+// first. Query parameters: limit (1–500, default 50), offset,
+// remote_instance_id, agent_id, outcome, since and until (RFC 3339).
 func HandleFederationAttestations(ledger *federation.PersistentTrustLedger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		events := ledger.GetAttestationLog(50)
-		if events == nil {
-			events = []federation.TrustAttestationEvent{}
+		qs := r.URL.Query()
+		q := federation.AttestationQuery{
+			RemoteInstanceID: qs.Get("remote_instance_id"),
+			AgentID:          qs.Get("agent_id"),
+			Outcome:          qs.Get("outcome"),
+		}
+		var err error
+		if v := qs.Get("limit"); v != "" {
+			if q.Limit, err = strconv.Atoi(v); err != nil {
+				http.Error(w, `{"error":"limit must be an integer"}`, http.StatusBadRequest)
+				return
+			}
+		}
+		if v := qs.Get("offset"); v != "" {
+			if q.Offset, err = strconv.Atoi(v); err != nil || q.Offset < 0 {
+				http.Error(w, `{"error":"offset must be a non-negative integer"}`, http.StatusBadRequest)
+				return
+			}
+		}
+		for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+			if v := qs.Get(name); v != "" {
+				if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+					http.Error(w, fmt.Sprintf(`{"error":"%s must be RFC 3339"}`, name), http.StatusBadRequest)
+					return
+				}
+			}
+		}
+
+		page, err := ledger.QueryAttestations(r.Context(), q)
+		if err != nil {
+			slog.Warn("Attestation history query failed", "error", err)
+			http.Error(w, `{"error":"attestation history unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+// HandleTrustBundle serves this instance's signed trust bundle (federation
+// roots and revocations). Peers poll it, so it is served without tenant
+// authentication; the signature is what they trust.
+func HandleTrustBundle(revocations *federation.RevocationRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bundle, err := revocations.TrustBundle()
+		if err != nil {
+			slog.Warn("Trust bundle unavailable", "error", err)
+			http.Error(w, `{"error":"trust bundle unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=60")
+		json.NewEncoder(w).Encode(bundle)
+	}
+}
+
+// HandleListRevocations lists revoked keys, certificates and instances,
+// including those learned from peers' trust bundles.
+func HandleListRevocations(revocations *federation.RevocationRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"revocations": revocations.List(),
+		})
+	}
+}
+
+// RequireFederationOperator admits only requests from one of the operator
+// tenants. Revocations and forced discovery change federation for every
+// tenant on the deployment, so an ordinary tenant's API key gets 403.
+func RequireFederationOperator(operators []string, next http.HandlerFunc) http.HandlerFunc {
+	allowed := make(map[string]bool, len(operators))
+	for _, id := range operators {
+		allowed[id] = true
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil || !allowed[tenantID] {
+			slog.Warn("Federation operator request refused", "tenant_id", tenantID, "path", r.URL.Path)
+			http.Error(w, `{"error":"federation operator access required"}`, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// HandleRevoke revokes an OCX instance, instance key or certificate. Peer
+// connections using the revoked instance or key are torn down immediately
+// and the entry is published in the next trust bundle.
+func HandleRevoke(registry *federation.FederationRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Kind           federation.RevocationKind `json:"kind"`
+			InstanceID     string                    `json:"instance_id"`
+			PublicKeyPEM   string                    `json:"public_key_pem"`
+			CertificatePEM string                    `json:"certificate_pem"`
+			Fingerprint    string                    `json:"fingerprint"`
+			Reason         string                    `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		revocations := registry.Revocations()
+		if revocations == nil {
+			http.Error(w, `{"error":"revocation list not configured"}`, http.StatusServiceUnavailable)
+			return
+		}
+
+		var entry federation.RevocationEntry
+		switch req.Kind {
+		case federation.RevokeKindInstance:
+			if req.InstanceID == "" {
+				http.Error(w, `{"error":"instance_id is required"}`, http.StatusBadRequest)
+				return
+			}
+			entry, _ = registry.Revoke(req.InstanceID, req.Reason)
+
+		case federation.RevokeKindKey:
+			subject := req.Fingerprint
+			if req.PublicKeyPEM != "" {
+				fp, err := federation.KeyFingerprintPEM(req.PublicKeyPEM)
+				if err != nil {
+					http.Error(w, `{"error":"public_key_pem is not a PEM public key"}`, http.StatusBadRequest)
+					return
+				}
+				subject = fp
+			}
+			if subject == "" {
+				http.Error(w, `{"error":"public_key_pem or fingerprint is required"}`, http.StatusBadRequest)
+				return
+			}
+			entry = revocations.Revoke(federation.RevocationEntry{
+				Kind: req.Kind, Subject: subject, InstanceID: federation.OCXInstanceID(req.InstanceID), Reason: req.Reason,
+			})
+
+		case federation.RevokeKindCertificate:
+			if req.CertificatePEM == "" {
+				http.Error(w, `{"error":"certificate_pem is required"}`, http.StatusBadRequest)
+				return
+			}
+			block, _ := pem.Decode([]byte(req.CertificatePEM))
+			if block == nil {
+				http.Error(w, `{"error":"certificate_pem is not a PEM certificate"}`, http.StatusBadRequest)
+				return
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				http.Error(w, `{"error":"certificate_pem is not a valid certificate"}`, http.StatusBadRequest)
+				return
+			}
+			entry = revocations.RevokeCertificate(cert, federation.OCXInstanceID(req.InstanceID), req.Reason)
+
+		default:
+			http.Error(w, `{"error":"kind must be instance, key or certificate"}`, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(entry)
+	}
+}
+
+// HandleFederationMetadata serves this instance's signed discovery document
+// (/.well-known/ocx-federation.json). Like the trust bundle it is public;
+// peers verify the signature and, when published, the DNS pin.
+func HandleFederationMetadata(fm *federation.FederationManager, pub federation.MetadataPublication) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metadata, err := fm.SignedMetadata(pub)
+		if err != nil {
+			slog.Warn("Federation metadata unavailable", "error", err)
+			http.Error(w, `{"error":"federation metadata unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=300")
+		json.NewEncoder(w).Encode(metadata)
+	}
+}
+
+// HandleFederationIntegrityManifest returns the integrity manifest this
+// instance attaches to its attestations, for building peers' allow-lists.
+func HandleFederationIntegrityManifest(fm *federation.FederationManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		manifest, err := fm.CurrentManifest()
+		if err != nil {
+			slog.Warn("Integrity manifest unavailable", "error", err)
+			http.Error(w, `{"error":"integrity manifest unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		if manifest == nil {
+			http.Error(w, `{"error":"integrity manifest not configured"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(manifest)
+	}
+}
+
+// HandleFederationDiscover resolves a partner by domain, verifies its
+// metadata and registers it. Body: {"domain": "ocx.partner.example"}.
+func HandleFederationDiscover(registry *federation.FederationRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Domain string `json:"domain"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Domain == "" {
+			http.Error(w, `{"error":"domain is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		discovered, err := registry.Discover(r.Context(), req.Domain)
+		switch {
+		case errors.Is(err, federation.ErrRevoked):
+			http.Error(w, `{"error":"instance revoked"}`, http.StatusForbidden)
+			return
+		case errors.Is(err, federation.ErrInvalidMetadata):
+			slog.Warn("Federation metadata rejected", "domain", req.Domain, "error", err)
+			http.Error(w, `{"error":"federation metadata failed verification"}`, http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, federation.ErrInstanceConflict):
+			slog.Warn("Federation instance ID conflict", "domain", req.Domain, "error", err)
+			http.Error(w, `{"error":"instance ID is already bound to another domain or key"}`, http.StatusConflict)
+			return
+		case err != nil:
+			slog.Warn("Federation discovery failed", "domain", req.Domain, "error", err)
+			http.Error(w, `{"error":"federation metadata unavailable"}`, http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(discovered)
+	}
+}
+
+// HandleListDiscovered lists the partners resolved through discovery.
+func HandleListDiscovered(registry *federation.FederationRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"instances": registry.Discovered(),
+		})
+	}
+}
+
+// HandleIssueReputationCredential signs a reputation credential for one of
+// this tenant's agents to present to another instance, bound to the agent's
+// public key. Body: {"agent_id": "agent-1", "public_key": "<base64>"}.
+func HandleIssueReputationCredential(fm *federation.FederationManager, wallet *reputation.ReputationWallet, manager *reputation.ReputationManager, ttl time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			AgentID   string `json:"agent_id"`
+			PublicKey []byte `json:"public_key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AgentID == "" || len(req.PublicKey) == 0 {
+			http.Error(w, `{"error":"agent_id and public_key are required"}`, http.StatusBadRequest)
+			return
+		}
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil || tenantID == "" {
+			http.Error(w, `{"error":"tenant is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		var history federation.ReputationHistory
+		if rep, err := manager.GetAgentReputation(tenantID, req.AgentID); err == nil {
+			if rep.Blacklisted {
+				http.Error(w, `{"error":"agent is blacklisted"}`, http.StatusConflict)
+				return
+			}
+			history = federation.ReputationHistory{
+				TotalInteractions:      rep.TotalInteractions,
+				SuccessfulInteractions: rep.SuccessfulInteractions,
+				FailedInteractions:     rep.FailedInteractions,
+				FirstSeen:              rep.FirstSeen,
+				LastActive:             rep.LastUpdated,
+			}
+		}
+		score, _ := wallet.GetTrustScore(r.Context(), req.AgentID, tenantID)
+
+		credential, err := fm.IssueReputationCredential(tenantID, req.AgentID, req.PublicKey, score, history, ttl)
+		if err != nil {
+			slog.Warn("Reputation credential not issued", "agent_id", req.AgentID, "error", err)
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(credential)
+	}
+}
+
+// HandleReputationChallenge hands out a challenge an agent signs with its
+// credential's subject key to import the credential into this tenant.
+func HandleReputationChallenge(fm *federation.FederationManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil || tenantID == "" {
+			http.Error(w, `{"error":"tenant is required"}`, http.StatusBadRequest)
+			return
+		}
+		challenge, err := fm.NewCredentialChallenge(tenantID)
+		if err != nil {
+			slog.Warn("Reputation credential challenge not issued", "error", err)
+			http.Error(w, `{"error":"challenge unavailable"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(challenge)
+	}
+}
+
+// HandleImportReputationCredential verifies a credential issued by a
+// federated peer and seeds the agent's reputation in this tenant with the
+// score discounted by the issuer's trust. Body: a CredentialPresentation —
+// the credential, a challenge from HandleReputationChallenge and the
+// agent's signature over both.
+func HandleImportReputationCredential(fm *federation.FederationManager, wallet *reputation.ReputationWallet, manager *reputation.ReputationManager, policy federation.ReputationImportPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var presentation federation.CredentialPresentation
+		if err := json.NewDecoder(r.Body).Decode(&presentation); err != nil || presentation.Credential == nil {
+			http.Error(w, `{"error":"invalid credential"}`, http.StatusBadRequest)
+			return
 		}
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil || tenantID == "" {
+			http.Error(w, `{"error":"tenant is required"}`, http.StatusBadRequest)
+			return
+		}
+		if presentation.Credential.TenantID != tenantID {
+			http.Error(w, `{"error":"credential was issued to another tenant"}`, http.StatusForbidden)
+			return
+		}
+
+		imported, err := fm.ImportReputationCredential(&presentation, tenantID, policy)
+		switch {
+		case errors.Is(err, federation.ErrUnknownIssuer), errors.Is(err, federation.ErrIssuerNotTrusted),
+			errors.Is(err, federation.ErrInvalidPresentation):
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusForbidden)
+			return
+		case errors.Is(err, federation.ErrCredentialReplayed):
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusUnprocessableEntity)
+			return
+		}
+
+		source := string(imported.Issuer)
+		err = manager.ImportReputation(tenantID, imported.AgentID, imported.Score, imported.Weight, source)
+		if errors.Is(err, reputation.ErrLocalHistory) {
+			http.Error(w, `{"error":"agent already has local reputation"}`, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+		applied, err := wallet.ImportScore(r.Context(), imported.AgentID, tenantID, imported.Score, source)
+		if err != nil {
+			slog.Warn("Imported trust score not stored", "agent_id", imported.AgentID, "error", err)
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"attestations": events,
-			"total":        len(events),
+			"imported":     imported,
+			"trust_seeded": applied,
 		})
 	}
 }
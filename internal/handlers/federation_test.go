@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ocx/backend/internal/federation"
+	"github.com/ocx/backend/internal/multitenancy"
+	"github.com/stretchr/testify/assert"
+)
+
+// ============================================================================
+// FEDERATION OPERATOR TESTS
+// ============================================================================
+
+// operatorRequest runs a POST from tenantID through RequireFederationOperator
+// and reports the status and whether the wrapped handler ran.
+func operatorRequest(t *testing.T, operators []string, tenantID string) (int, bool) {
+	t.Helper()
+	reached := false
+	h := RequireFederationOperator(operators, func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusCreated)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/federation/revocations",
+		strings.NewReader(`{"kind":"instance","instance_id":"ocx-b"}`))
+	if tenantID != "" {
+		req = req.WithContext(multitenancy.WithTenant(req.Context(), tenantID))
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec.Code, reached
+}
+
+func TestRequireFederationOperatorRefusesTenant(t *testing.T) {
+	code, reached := operatorRequest(t, []string{"ops"}, "tenant-a")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.False(t, reached)
+}
+
+func TestRequireFederationOperatorRefusesWithoutOperators(t *testing.T) {
+	code, reached := operatorRequest(t, nil, "tenant-a")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.False(t, reached)
+
+	code, reached = operatorRequest(t, nil, "")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.False(t, reached)
+}
+
+func TestRequireFederationOperatorAdmitsOperator(t *testing.T) {
+	code, reached := operatorRequest(t, []string{"ops"}, "ops")
+	assert.Equal(t, http.StatusCreated, code)
+	assert.True(t, reached)
+}
+
+// ============================================================================
+// REPUTATION CREDENTIAL IMPORT TESTS
+// ============================================================================
+
+func TestImportReputationCredentialRefusesOtherTenant(t *testing.T) {
+	h := HandleImportReputationCredential(nil, nil, nil, federation.DefaultReputationImportPolicy())
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/federation/reputation/import",
+		strings.NewReader(`{"credential":{"credential_id":"repcred-1","agent_id":"agent-1","tenant_id":"tenant-b"}}`))
+	req = req.WithContext(multitenancy.WithTenant(req.Context(), "tenant-a"))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
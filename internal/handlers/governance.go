@@ -16,10 +16,13 @@ import (
 	"github.com/ocx/backend/internal/evidence"
 	"github.com/ocx/backend/internal/governance"
 	"github.com/ocx/backend/internal/gvisor"
+	"github.com/ocx/backend/internal/multitenancy"
 	"github.com/ocx/backend/internal/plan"
+	"github.com/ocx/backend/internal/protocol"
 	"github.com/ocx/backend/internal/reputation"
 	"github.com/ocx/backend/internal/security"
 	"github.com/ocx/backend/internal/webhooks"
+	"github.com/ocx/backend/pkg/plugins"
 )
 
 // HandleGovern is the main governance endpoint that classifies, escrows,
@@ -44,18 +47,23 @@ func HandleGovern(
 	ghostEngine *governance.GhostStateEngine,
 	sopManager *plan.SOPGraphManager,
 	auditor *security.SessionAuditor,
+	pluginReg *plugins.Registry,
+	agentCards *security.AgentCardRegistry,
 ) http.HandlerFunc {
 	// Configurable timeout — defaults to 60 seconds if not set in config
 	timeoutSec := cfg.Contracts.RuntimeTimeoutMs / 1000
 	if timeoutSec <= 0 {
 		timeoutSec = 60
 	}
+	aiParser := protocol.NewUniversalAIParser()
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeoutSec)*time.Second)
 		defer cancel()
 
-		// Parse SDK request
+		// Parse SDK request. A single call uses tool_name/arguments; a model
+		// turn with several calls sends tool_calls, or the raw protocol
+		// message in payload for the gateway to extract every call itself.
 		var req struct {
 			ToolName  string                 `json:"tool_name"`
 			AgentID   string                 `json:"agent_id"`
@@ -64,6 +72,8 @@ func HandleGovern(
 			Model     string                 `json:"model"`
 			SessionID string                 `json:"session_id"`
 			Protocol  string                 `json:"protocol"`
+			ToolCalls []governToolCall       `json:"tool_calls,omitempty"`
+			Payload   json.RawMessage        `json:"payload,omitempty"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
@@ -75,7 +85,20 @@ func HandleGovern(
 			txID = "gov-" + time.Now().Format("20060102-150405.000")
 		}
 
-		slog.Info("/govern: tool= agent= tenant= protocol", "tool_name", req.ToolName, "agent_i_d", req.AgentID, "tenant_i_d", req.TenantID, "protocol", req.Protocol)
+		// Tenant plugins and agent cards apply to the authenticated tenant,
+		// not the one the request body names
+		callerTenant := req.TenantID
+		if id, err := multitenancy.GetTenantID(r.Context()); err == nil {
+			callerTenant = id
+		}
+
+		calls, err := governCalls(aiParser, pluginReg, callerTenant, req.ToolName, req.Arguments, req.ToolCalls, req.Payload)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		slog.Info("/govern: tool= agent= tenant= protocol", "tool_name", calls[0].ToolName, "agent_i_d", req.AgentID, "tenant_i_d", req.TenantID, "protocol", req.Protocol, "calls", len(calls))
 		// Step 1: Get agent trust score from Supabase via ReputationWallet
 		// No hardcoded default — the wallet queries the agents table directly.
 		var trustScore float64
@@ -92,402 +115,704 @@ func HandleGovern(
 			return
 		}
 
-		// Step 1b: Check Tool Catalog policy (if tool is registered)
-		var verdict, actionClass, reason, escrowID, entitlementID, evidenceHash string
-		var govTax float64
-		var policyBlocked bool
-		var speculativeHash string
-		var tokenResponse *security.JITToken
-		var ghostSideEffects []governance.SideEffect
-		var sopDriftReport *plan.DriftReport
-
-		if tc != nil {
-			if tool, ok := tc.Get(req.ToolName); ok {
-				// Enforce min trust score (Claim 3)
-				if tool.GovernancePolicy.MinTrustScore > 0 && trustScore < tool.GovernancePolicy.MinTrustScore {
-					verdict = "BLOCK"
-					reason = fmt.Sprintf("Trust score %.2f below tool minimum %.2f", trustScore, tool.GovernancePolicy.MinTrustScore)
-					actionClass = string(tool.ActionClass)
-					policyBlocked = true
+		// Every call of the turn is classified against the JIT entitlements
+		// the agent held when the request arrived; a grant made for one call
+		// must not decide its siblings.
+		var agentEntitlements []string
+		if jit != nil {
+			for _, ent := range jit.GetActiveEntitlements(req.AgentID) {
+				agentEntitlements = append(agentEntitlements, ent.Permission)
+			}
+		}
+
+		// evaluate runs steps 1b–5 for one tool call. Each call of a
+		// multi-call turn gets its own transaction ID, so escrow holds,
+		// entitlements, compensation and evidence stay per call.
+		evaluate := func(call governToolCall, txID string) *governOutcome {
+			// Step 1b: Check Tool Catalog policy (if tool is registered)
+			var verdict, actionClass, reason, entitlementID string
+			var govTax float64
+			var policyBlocked bool
+			var heldClassification *escrow.ClassificationResult
+			var speculativeHash string
+			var tokenResponse *security.JITToken
+			var ghostSideEffects []governance.SideEffect
+			var sopDriftReport *plan.DriftReport
+			var cardSignals []security.AgentCardSignal
+
+			if tc != nil {
+				if tool, ok := tc.Get(call.ToolName); ok {
+					// Enforce min trust score (Claim 3)
+					if tool.GovernancePolicy.MinTrustScore > 0 && trustScore < tool.GovernancePolicy.MinTrustScore {
+						verdict = "BLOCK"
+						reason = fmt.Sprintf("Trust score %.2f below tool minimum %.2f", trustScore, tool.GovernancePolicy.MinTrustScore)
+						actionClass = string(tool.ActionClass)
+						policyBlocked = true
+					}
+					// Enforce human review requirement
+					if !policyBlocked && tool.GovernancePolicy.RequireHumanReview {
+						verdict = "ESCROW"
+						reason = "Tool requires human review per catalog policy"
+						actionClass = string(tool.ActionClass)
+						policyBlocked = true
+					}
 				}
-				// Enforce human review requirement
-				if !policyBlocked && tool.GovernancePolicy.RequireHumanReview {
+			}
+
+			// Step 1c: Policy plugins (tenant WASM modules); strictest wins
+			if !policyBlocked && pluginReg != nil {
+				if d := pluginReg.EvaluatePolicies(ctx, callerTenant, &protocol.AIPayload{
+					ToolName:    call.ToolName,
+					AgentID:     req.AgentID,
+					TenantID:    req.TenantID,
+					TaskID:      req.SessionID,
+					Arguments:   call.Arguments,
+					Model:       req.Model,
+					CallID:      call.ID,
+					MessageType: "tool_call",
+					Direction:   "request",
+				}); d != nil {
 					verdict = "ESCROW"
-					reason = "Tool requires human review per catalog policy"
-					actionClass = string(tool.ActionClass)
+					if d.Verdict == plugins.PolicyBlock {
+						verdict = "BLOCK"
+					}
+					reason = fmt.Sprintf("Policy plugin %s: %s", d.Plugin, d.Reason)
 					policyBlocked = true
 				}
 			}
-		}
 
-		// Step 2: Classify the tool call (if not already blocked by policy)
-		if !policyBlocked {
-			// Fetch agent's active JIT entitlements (Claim 7)
-			var agentEntitlements []string
-			if jit != nil {
-				activeEnts := jit.GetActiveEntitlements(req.AgentID)
-				for _, ent := range activeEnts {
-					agentEntitlements = append(agentEntitlements, ent.Permission)
+			// Step 1d: A2A agent card — unverified cards and tools outside the
+			// agent's declared skills
+			if !policyBlocked && agentCards != nil {
+				cardSignals = agentCards.CheckInvocation(ctx, callerTenant, req.AgentID, call.ToolName)
+				if s := security.StrictestCardSignal(cardSignals); s != nil && s.Action != security.CardActionFlag {
+					verdict = string(s.Action)
+					reason = "Agent card: " + s.Detail
+					policyBlocked = true
 				}
 			}
 
-			classification, err := classifier.Classify(escrow.ClassificationRequest{
-				ToolID:          req.ToolName,
-				AgentID:         req.AgentID,
-				TenantID:        req.TenantID,
-				Args:            req.Arguments,
-				AgentTrustScore: trustScore,
-				Entitlements:    agentEntitlements,
-			})
-			if err != nil {
-				// Fail-secure: treat unknown tools as CLASS_B
-				verdict = "ESCROW"
-				actionClass = "CLASS_B"
-				reason = "Classification failed — tool held for review"
-				govTax = cfg.Escrow.FailureTaxRate
-			} else {
-				actionClass = classification.Classification.ActionClass.String()
-				govTax = classification.Classification.GovernanceTaxCoefficient
-
-				switch classification.FinalVerdict {
-				case "ALLOW":
-					verdict = "ALLOW"
-					reason = "Tool call approved by governance"
-				case "BLOCK":
-					verdict = "BLOCK"
-					reason = "Tool call blocked by policy"
-				case "HOLD":
+			// Step 2: Classify the tool call (if not already blocked by policy)
+			if !policyBlocked {
+				// Agent's JIT entitlements (Claim 7), as of the request
+				classification, err := classifier.Classify(escrow.ClassificationRequest{
+					ToolID:          call.ToolName,
+					AgentID:         req.AgentID,
+					TenantID:        req.TenantID,
+					Args:            call.Arguments,
+					AgentTrustScore: trustScore,
+					Entitlements:    agentEntitlements,
+				})
+				if err != nil {
+					// Fail-secure: treat unknown tools as CLASS_B
 					verdict = "ESCROW"
-					reason = "Tool call held for tri-factor review"
-
-					// ===========================================================
-					// CLAIM 1 + 2: Speculative Execution + Tri-Factor Barrier
-					// Patent: "intercepting a request, executing speculatively
-					// in a sandbox, generating a revert function, auditing
-					// asynchronously, and gating release until audit completion"
-					// ===========================================================
-
-					if classification.Classification.ActionClass == escrow.CLASS_B {
-						// Step 3a (Claim 9): Create ghost state snapshot for
-						// business-state sandbox
-						if ghostEngine != nil {
-							ghost := ghostEngine.Snapshot(txID,
-								map[string]interface{}{
-									req.AgentID: map[string]interface{}{
-										"trust_score": trustScore,
-										"tool":        req.ToolName,
+					actionClass = "CLASS_B"
+					reason = "Classification failed — tool held for review"
+					govTax = cfg.Escrow.FailureTaxRate
+				} else {
+					actionClass = classification.Classification.ActionClass.String()
+					govTax = classification.Classification.GovernanceTaxCoefficient
+
+					switch classification.FinalVerdict {
+					case "ALLOW":
+						verdict = "ALLOW"
+						reason = "Tool call approved by governance"
+					case "BLOCK":
+						verdict = "BLOCK"
+						reason = "Tool call blocked by policy"
+					case "HOLD":
+						verdict = "ESCROW"
+						reason = "Tool call held for tri-factor review"
+
+						// ===========================================================
+						// CLAIM 1 + 2: Speculative Execution + Tri-Factor Barrier
+						// Patent: "intercepting a request, executing speculatively
+						// in a sandbox, generating a revert function, auditing
+						// asynchronously, and gating release until audit completion"
+						// ===========================================================
+
+						if classification.Classification.ActionClass == escrow.CLASS_B {
+							// Step 3a (Claim 9): Create ghost state snapshot for
+							// business-state sandbox
+							if ghostEngine != nil {
+								ghost := ghostEngine.Snapshot(txID,
+									map[string]interface{}{
+										req.AgentID: map[string]interface{}{
+											"trust_score": trustScore,
+											"tool":        call.ToolName,
+										},
 									},
-								},
-								map[string]float64{req.AgentID: trustScore},
-								map[string][]string{req.AgentID: {req.ToolName + ":execute"}},
-							)
-							// Simulate tool on ghost state
-							simResult, simErr := ghostEngine.SimulateOnGhost(
-								txID, req.ToolName, req.AgentID, req.Arguments,
-							)
-							if simErr == nil && simResult != nil {
-								speculativeHash = simResult.StateHash
-								ghostSideEffects = simResult.SideEffects
-								if !simResult.PolicyPassed {
-									verdict = "BLOCK"
-									reason = fmt.Sprintf("Ghost simulation policy violation: %v",
-										simResult.Violations)
+									map[string]float64{req.AgentID: trustScore},
+									map[string][]string{req.AgentID: {call.ToolName + ":execute"}},
+								)
+								// Simulate tool on ghost state
+								simResult, simErr := ghostEngine.SimulateOnGhost(
+									txID, call.ToolName, req.AgentID, call.Arguments,
+								)
+								if simErr == nil && simResult != nil {
+									speculativeHash = simResult.StateHash
+									ghostSideEffects = simResult.SideEffects
+									if !simResult.PolicyPassed {
+										verdict = "BLOCK"
+										reason = fmt.Sprintf("Ghost simulation policy violation: %v",
+											simResult.Violations)
+									}
 								}
+								_ = ghost // used via txID reference
 							}
-							_ = ghost // used via txID reference
-						}
 
-						// Step 3b (Claim 1): Speculative execution in gVisor sandbox
-						if sandbox != nil && verdict != "BLOCK" {
-							specPayload := &gvisor.ToolCallPayload{
-								TransactionID: txID,
-								AgentID:       req.AgentID,
-								ToolName:      req.ToolName,
-								Parameters:    req.Arguments,
-								Context:       map[string]interface{}{"tenant_id": req.TenantID},
-							}
-							specResult, specErr := sandbox.ExecuteSpeculative(ctx, specPayload)
-							if specErr == nil && specResult != nil {
-								speculativeHash = specResult.RevertToken
-								// §9: Register compensation — revert on failure
-								if compStack != nil {
-									capturedToken := specResult.RevertToken
-									compStack.Push(txID, "revert speculative execution "+req.ToolName, func() error {
-										slog.Info("Reverting speculative execution", "captured_token", capturedToken)
-										return nil // Revert handled by state cloner via revert token
-									})
+							// Step 3b (Claim 1): Speculative execution in gVisor sandbox
+							if sandbox != nil && verdict != "BLOCK" {
+								specPayload := &gvisor.ToolCallPayload{
+									TransactionID: txID,
+									AgentID:       req.AgentID,
+									ToolName:      call.ToolName,
+									Parameters:    call.Arguments,
+									Context:       map[string]interface{}{"tenant_id": req.TenantID},
+								}
+								specResult, specErr := sandbox.ExecuteSpeculative(ctx, specPayload)
+								if specErr == nil && specResult != nil {
+									speculativeHash = specResult.RevertToken
+									// §9: Register compensation — revert on failure
+									if compStack != nil {
+										capturedToken := specResult.RevertToken
+										compStack.Push(txID, "revert speculative execution "+call.ToolName, func() error {
+											slog.Info("Reverting speculative execution", "captured_token", capturedToken)
+											return nil // Revert handled by state cloner via revert token
+										})
+									}
 								}
 							}
-						}
 
-						// Step 3c (Claim 2): Tri-Factor Gate sequestration
-						if triGate != nil {
-							payload, _ := json.Marshal(req.Arguments)
-							pendingItem, seqErr := triGate.Sequester(
-								ctx, txID, req.TenantID, payload,
-								classification,
-							)
-							if seqErr == nil && pendingItem != nil {
-								escrowID = txID
-							}
-						} else {
-							// Fallback to basic EscrowGate
-							holdErr := gate.Hold(txID, req.TenantID, []byte(req.ToolName))
-							if holdErr == nil {
-								escrowID = txID
-							}
+							// Step 3c (Claim 2): Tri-Factor Gate sequestration
+							// waits for the turn's verdict (see settle)
+							heldClassification = classification
 						}
+					default:
+						verdict = "ALLOW"
+						reason = "Default allow"
 					}
-				default:
-					verdict = "ALLOW"
-					reason = "Default allow"
 				}
 			}
-		}
 
-		// ===========================================================
-		// CLAIM 13: SOP Drift Detection
-		// "drift computed as divergence from a machine-readable SOP
-		//  graph [...] used to adjust governance tax"
-		// ===========================================================
-		if sopManager != nil && !policyBlocked && req.SessionID != "" {
-			// Look for a registered SOP graph matching this session
-			graphID := "sop-" + req.SessionID
-			if _, ok := sopManager.GetGraph(graphID); !ok {
-				graphID = "sop-default"
-			}
-			if _, ok := sopManager.GetGraph(graphID); ok {
-				observed := &plan.ExecutionPath{
-					AgentID:   req.AgentID,
-					TenantID:  req.TenantID,
-					SessionID: req.SessionID,
-					Steps: []plan.ExecutionStep{{
-						ToolName:    req.ToolName,
-						ActionClass: actionClass,
-						Timestamp:   time.Now().Unix(),
-						Success:     verdict != "BLOCK",
-						TrustScore:  trustScore,
-					}},
+			// ===========================================================
+			// CLAIM 13: SOP Drift Detection
+			// "drift computed as divergence from a machine-readable SOP
+			//  graph [...] used to adjust governance tax"
+			// ===========================================================
+			if sopManager != nil && !policyBlocked && req.SessionID != "" {
+				// Look for a registered SOP graph matching this session
+				graphID := "sop-" + req.SessionID
+				if _, ok := sopManager.GetGraph(graphID); !ok {
+					graphID = "sop-default"
 				}
-				if drift, dErr := sopManager.ComputeDrift(graphID, observed); dErr == nil && drift != nil {
-					sopDriftReport = drift
-					if drift.GovernanceTaxAdjustment > 1.0 {
-						govTax = govTax * drift.GovernanceTaxAdjustment
-						slog.Info("SOP drift detected",
-							"edit_distance", drift.PathEditDistance,
-							"violations", drift.PolicyViolationCount,
-							"tax_adjustment", drift.GovernanceTaxAdjustment,
-							"graph_id", graphID)
+				if _, ok := sopManager.GetGraph(graphID); ok {
+					observed := &plan.ExecutionPath{
+						AgentID:   req.AgentID,
+						TenantID:  req.TenantID,
+						SessionID: req.SessionID,
+						Steps: []plan.ExecutionStep{{
+							ToolName:    call.ToolName,
+							ActionClass: actionClass,
+							Timestamp:   time.Now().Unix(),
+							Success:     verdict != "BLOCK",
+							TrustScore:  trustScore,
+						}},
 					}
-					if drift.NormalizedEditDistance > 0.8 && verdict == "ALLOW" {
-						verdict = "ESCROW"
-						reason = fmt.Sprintf("SOP drift too high (%.2f) — held for review", drift.NormalizedEditDistance)
+					if drift, dErr := sopManager.ComputeDrift(graphID, observed); dErr == nil && drift != nil {
+						sopDriftReport = drift
+						if drift.GovernanceTaxAdjustment > 1.0 {
+							govTax = govTax * drift.GovernanceTaxAdjustment
+							slog.Info("SOP drift detected",
+								"edit_distance", drift.PathEditDistance,
+								"violations", drift.PolicyViolationCount,
+								"tax_adjustment", drift.GovernanceTaxAdjustment,
+								"graph_id", graphID)
+						}
+						if drift.NormalizedEditDistance > 0.8 && verdict == "ALLOW" {
+							verdict = "ESCROW"
+							reason = fmt.Sprintf("SOP drift too high (%.2f) — held for review", drift.NormalizedEditDistance)
+						}
 					}
 				}
 			}
-		}
 
-		// Step 4: Micropayment hold + compensation registration
-		if mp != nil && verdict == "ALLOW" {
-			mp.HoldFunds(txID, req.TenantID, req.AgentID, req.ToolName, actionClass, govTax, 1.0)
-			// §9: Register compensation — refund on rollback
-			if compStack != nil {
-				compStack.Push(txID, "refund micropayment for "+req.ToolName, func() error {
-					return mp.RefundFunds(txID)
-				})
-			}
-		}
-
-		// Step 5: JIT Entitlement — TTL from config + compensation
-		jitTTL := time.Duration(cfg.Escrow.JITEntitlementTTL) * time.Second
-		if jit != nil && verdict == "ALLOW" {
-			ent, entErr := jit.GrantEphemeral(
-				req.AgentID, req.ToolName+":execute",
-				jitTTL,
-				"ocx-governance", "SDK govern request",
-				map[string]interface{}{"tx_id": txID},
-			)
-			if entErr == nil && ent != nil {
-				entitlementID = ent.ID
-				// §9: Register compensation — revoke entitlement on rollback
+			// Step 4: Micropayment hold + compensation registration
+			if mp != nil && verdict == "ALLOW" {
+				mp.HoldFunds(txID, req.TenantID, req.AgentID, call.ToolName, actionClass, govTax, 1.0)
+				// §9: Register compensation — refund on rollback
 				if compStack != nil {
-					perm := req.ToolName + ":execute"
-					agent := req.AgentID
-					compStack.Push(txID, "revoke JIT entitlement "+perm, func() error {
-						return jit.RevokeEntitlement(agent, perm, "compensation rollback")
+					compStack.Push(txID, "refund micropayment for "+call.ToolName, func() error {
+						return mp.RefundFunds(txID)
 					})
 				}
 			}
-		}
 
-		// ===========================================================
-		// CLAIM 7: JIT Token Broker
-		// "token broker issuing JIT tokens upon sufficient trust and an
-		//  attribution header cryptographically bound to each token"
-		// ===========================================================
-		if tokenBroker != nil && verdict == "ALLOW" {
-			token, tokErr := tokenBroker.IssueToken(
-				req.AgentID, req.TenantID,
-				req.ToolName+":execute",
-				trustScore,
-			)
-			if tokErr == nil && token != nil {
-				tokenResponse = token
-				// Set attribution header on response
-				w.Header().Set("X-OCX-Attribution", token.Attribution)
-
-				// Register with CAE for continuous monitoring (Claim 8)
-				if cae != nil {
-					cae.RegisterSession(token.TokenID, req.AgentID, req.TenantID, trustScore)
+			// Step 5: JIT Entitlement — TTL from config + compensation
+			jitTTL := time.Duration(cfg.Escrow.JITEntitlementTTL) * time.Second
+			if jit != nil && verdict == "ALLOW" {
+				ent, entErr := jit.GrantEphemeral(
+					req.AgentID, call.ToolName+":execute",
+					jitTTL,
+					"ocx-governance", "SDK govern request",
+					map[string]interface{}{"tx_id": txID},
+				)
+				if entErr == nil && ent != nil {
+					entitlementID = ent.ID
+					// §9: Register compensation — revoke entitlement on rollback
+					if compStack != nil {
+						perm := call.ToolName + ":execute"
+						agent := req.AgentID
+						compStack.Push(txID, "revoke JIT entitlement "+perm, func() error {
+							return jit.RevokeEntitlement(agent, perm, "compensation rollback")
+						})
+					}
 				}
 			}
-		}
 
-		// Step 6: Evidence record
-		if vault != nil {
-			outcome := evidence.OutcomeAllow
-			if verdict == "BLOCK" {
-				outcome = evidence.OutcomeBlock
-			} else if verdict == "ESCROW" {
-				outcome = evidence.OutcomeHold
+			// ===========================================================
+			// CLAIM 7: JIT Token Broker
+			// "token broker issuing JIT tokens upon sufficient trust and an
+			//  attribution header cryptographically bound to each token"
+			// ===========================================================
+			if tokenBroker != nil && verdict == "ALLOW" {
+				token, tokErr := tokenBroker.IssueToken(
+					req.AgentID, req.TenantID,
+					call.ToolName+":execute",
+					trustScore,
+				)
+				if tokErr == nil && token != nil {
+					// Attribution headers are set once the turn is settled
+					tokenResponse = token
+
+					// Register with CAE for continuous monitoring (Claim 8)
+					if cae != nil {
+						cae.RegisterSession(token.TokenID, req.AgentID, req.TenantID, trustScore)
+					}
+				}
 			}
-			record, recErr := vault.RecordTransaction(
-				ctx, req.TenantID, req.AgentID, txID,
-				req.ToolName, actionClass, outcome,
-				trustScore, reason,
-				req.Arguments,
-			)
-			if recErr == nil && record != nil {
-				evidenceHash = record.Hash
+
+			return &governOutcome{
+				call:               call,
+				txID:               txID,
+				verdict:            verdict,
+				actionClass:        actionClass,
+				reason:             reason,
+				heldClassification: heldClassification,
+				entitlementID:      entitlementID,
+				speculativeHash:    speculativeHash,
+				govTax:             govTax,
+				tokenResponse:      tokenResponse,
+				ghostSideEffects:   ghostSideEffects,
+				sopDriftReport:     sopDriftReport,
+				cardSignals:        cardSignals,
 			}
 		}
 
-		// Step 7: Emit CloudEvent + dispatch webhooks
-		eventType := "ocx.verdict." + strings.ToLower(verdict)
-		eventData := map[string]interface{}{
-			"transaction_id": txID,
-			"tool_name":      req.ToolName,
-			"agent_id":       req.AgentID,
-			"tenant_id":      req.TenantID,
-			"verdict":        verdict,
-			"action_class":   actionClass,
-			"trust_score":    trustScore,
-		}
-		if bus != nil {
-			bus.Emit(eventType, "/api/v1/govern", txID, eventData)
-		}
-		if wd != nil {
-			wd.Emit(webhooks.EventType(eventType), req.TenantID, eventData)
-		}
+		// settle runs step 3c and steps 8–9 for one call under the turn's
+		// final verdict: one blocked call rolls back its allowed siblings and
+		// keeps its held ones out of escrow, one held call holds them. An
+		// allowed call in a turn that is not allowed gives up its payment
+		// hold and entitlement; it is governed again when the turn is
+		// resubmitted. Each hold and grant is undone exactly once: by the
+		// compensation stack when there is one, directly otherwise.
+		settle := func(o *governOutcome, verdict string) {
+			txID := o.txID
+			voided := o.verdict == "ALLOW" && verdict != "ALLOW"
+			compensated := false
 
-		// Step 7b: Session Audit Log — security forensics
-		if auditor != nil {
-			tokenID := ""
-			if tokenResponse != nil {
-				tokenID = tokenResponse.TokenID
+			// Step 3c (Claim 2): Sequester held calls of a held turn
+			sequesterHeld(ctx, gate, triGate, req.TenantID, o, verdict)
+
+			// Step 8 (§9): Compensation — execute or clear based on verdict
+			if compStack != nil {
+				if verdict == "BLOCK" {
+					// BLOCK → undo all side-effects (LIFO)
+					o.compensationResults = compStack.Execute(txID)
+					compensated = true
+					// Discard ghost state
+					if ghostEngine != nil {
+						ghostEngine.Discard(txID)
+					}
+					// Revoke any issued tokens (Claim 8)
+					if tokenBroker != nil {
+						tokenBroker.RevokeAllForAgent(req.AgentID)
+					}
+				} else if voided {
+					// Allowed call in a held turn → undo its hold and grant
+					o.compensationResults = compStack.Execute(txID)
+					compensated = true
+				} else {
+					// ALLOW or ESCROW → commit (clear the stack)
+					compStack.Clear(txID)
+					// Commit ghost state on ALLOW
+					if ghostEngine != nil && verdict == "ALLOW" {
+						ghostEngine.Commit(txID)
+					}
+				}
+			}
+
+			// Step 9 (§4): Micropayment settlement based on verdict
+			if mp != nil {
+				if verdict == "ALLOW" {
+					mp.ReleaseFunds(txID) // finalize charge
+				} else if (verdict == "BLOCK" || voided) && !compensated {
+					mp.RefundFunds(txID)
+				}
 			}
-			auditor.LogFromRequest(r, txID, req.TenantID, req.AgentID,
-				"GOVERN", verdict, trustScore,
-				map[string]interface{}{
-					"tool_name":    req.ToolName,
-					"action_class": actionClass,
-					"token_id":     tokenID,
-					"protocol":     req.Protocol,
-				},
-			)
-		}
 
-		// Step 8 (§9): Compensation — execute or clear based on verdict
-		var compensationResults []escrow.CompensationResult
-		if compStack != nil {
-			if verdict == "BLOCK" {
-				// BLOCK → undo all side-effects (LIFO)
-				compensationResults = compStack.Execute(txID)
-				// Discard ghost state
-				if ghostEngine != nil {
-					ghostEngine.Discard(txID)
+			// The entitlement of a call its turn did not allow is void too
+			if voided && o.entitlementID != "" {
+				if jit != nil && !compensated {
+					jit.RevokeEntitlement(req.AgentID, o.call.ToolName+":execute", "turn not allowed: "+verdict)
 				}
-				// Revoke any issued tokens (Claim 8)
+				o.entitlementID = ""
+			}
+
+			// A token issued to a call that its turn did not allow is void
+			if verdict != "ALLOW" && o.tokenResponse != nil {
 				if tokenBroker != nil {
-					tokenBroker.RevokeAllForAgent(req.AgentID)
+					tokenBroker.RevokeToken(o.tokenResponse.TokenID)
+				}
+				o.tokenResponse = nil
+			}
+
+			// ===========================================================
+			// CLAIM 12: Sovereign Mode enforcement
+			// "preventing transmission of speculative outputs beyond said
+			//  boundary until escrow release"
+			// ===========================================================
+			if cfg.Sovereign.Enabled && cfg.Sovereign.BoundaryEnforced {
+				if verdict == "ESCROW" {
+					// In sovereign mode, strip speculative output from response
+					o.speculativeHash = "[SOVEREIGN-SEALED]"
+					o.ghostSideEffects = nil
 				}
-			} else {
-				// ALLOW or ESCROW → commit (clear the stack)
-				compStack.Clear(txID)
-				// Commit ghost state on ALLOW
-				if ghostEngine != nil && verdict == "ALLOW" {
-					ghostEngine.Commit(txID)
+			}
+		}
+
+		// record runs steps 6–7b for one settled call. Evidence, events and
+		// the audit log carry the turn's verdict: a call allowed on its own
+		// is held or blocked with its turn.
+		record := func(o *governOutcome, verdict, reason string) {
+			call, txID, actionClass := o.call, o.txID, o.actionClass
+
+			// Step 6: Evidence record
+			if vault != nil {
+				outcome := evidence.OutcomeAllow
+				if verdict == "BLOCK" {
+					outcome = evidence.OutcomeBlock
+				} else if verdict == "ESCROW" {
+					outcome = evidence.OutcomeHold
 				}
+				record, recErr := vault.RecordTransaction(
+					ctx, req.TenantID, req.AgentID, txID,
+					call.ToolName, actionClass, outcome,
+					trustScore, reason,
+					call.Arguments,
+				)
+				if recErr == nil && record != nil {
+					o.evidenceHash = record.Hash
+				}
+			}
+
+			// Step 7: Emit CloudEvent + dispatch webhooks
+			eventType := "ocx.verdict." + strings.ToLower(verdict)
+			eventData := map[string]interface{}{
+				"transaction_id": txID,
+				"tool_name":      call.ToolName,
+				"agent_id":       req.AgentID,
+				"tenant_id":      req.TenantID,
+				"verdict":        verdict,
+				"action_class":   actionClass,
+				"trust_score":    trustScore,
+			}
+			if call.ID != "" {
+				eventData["call_id"] = call.ID
 			}
+			if bus != nil {
+				bus.Emit(eventType, "/api/v1/govern", txID, eventData)
+			}
+			if wd != nil {
+				wd.Emit(webhooks.EventType(eventType), req.TenantID, eventData)
+			}
+
+			// Step 7b: Session Audit Log — security forensics
+			if auditor != nil {
+				tokenID := ""
+				if o.tokenResponse != nil {
+					tokenID = o.tokenResponse.TokenID
+				}
+				auditor.LogFromRequest(r, txID, req.TenantID, req.AgentID,
+					"GOVERN", verdict, trustScore,
+					map[string]interface{}{
+						"tool_name":    call.ToolName,
+						"action_class": actionClass,
+						"token_id":     tokenID,
+						"protocol":     req.Protocol,
+					},
+				)
+			}
+		}
+
+		// Single call: the original request/response contract
+		if len(calls) == 1 && len(req.ToolCalls) == 0 && (len(req.Payload) == 0 || string(req.Payload) == "null") {
+			o := evaluate(calls[0], txID)
+			settle(o, o.verdict)
+			record(o, o.verdict, o.reason)
+			response := o.response()
+			response["trust_score"] = trustScore
+			setAttributionHeaders(w, []*governOutcome{o})
+			writeGovernStatus(w, o.verdict)
+			json.NewEncoder(w).Encode(response)
+			return
 		}
 
-		// Step 9 (§4): Micropayment settlement based on verdict
-		if mp != nil {
-			if verdict == "ALLOW" {
-				mp.ReleaseFunds(txID) // finalize charge
-			} else if verdict == "BLOCK" {
-				// Already handled by compensation stack, but ensure cleanup
-				mp.RefundFunds(txID)
+		// Multi-call turn: govern each call independently, then combine
+		outcomes := make([]*governOutcome, len(calls))
+		for i, call := range calls {
+			outcomes[i] = evaluate(call, txID+":"+call.ID)
+		}
+		verdict, reason := combineGovernVerdicts(outcomes)
+		for _, o := range outcomes {
+			settle(o, verdict)
+			callReason := o.reason
+			if o.verdict != verdict {
+				callReason = fmt.Sprintf("%s; turn %s: %s", o.reason, strings.ToLower(verdict), reason)
 			}
+			record(o, verdict, callReason)
+		}
+
+		results := make([]map[string]interface{}, len(outcomes))
+		var totalTax float64
+		for i, o := range outcomes {
+			results[i] = o.response()
+			totalTax += o.govTax
 		}
 
-		// ===========================================================
-		// CLAIM 12: Sovereign Mode enforcement
-		// "preventing transmission of speculative outputs beyond said
-		//  boundary until escrow release"
-		// ===========================================================
-		if cfg.Sovereign.Enabled && cfg.Sovereign.BoundaryEnforced {
-			if verdict == "ESCROW" {
-				// In sovereign mode, strip speculative output from response
-				speculativeHash = "[SOVEREIGN-SEALED]"
-				ghostSideEffects = nil
+		setAttributionHeaders(w, outcomes)
+		writeGovernStatus(w, verdict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"transaction_id": txID,
+			"verdict":        verdict,
+			"reason":         reason,
+			"trust_score":    trustScore,
+			"governance_tax": totalTax,
+			"call_count":     len(outcomes),
+			"tool_calls":     results,
+			"processed_at":   time.Now(),
+		})
+	}
+}
+
+// governToolCall is one tool invocation to govern.
+type governToolCall struct {
+	ID        string                 `json:"id"`
+	ToolName  string                 `json:"tool_name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// governOutcome is the governance result for one tool call.
+type governOutcome struct {
+	call                governToolCall
+	txID                string
+	verdict             string
+	actionClass         string
+	reason              string
+	escrowID            string
+	heldClassification  *escrow.ClassificationResult // CLASS_B HOLD awaiting sequestration
+	entitlementID       string
+	evidenceHash        string
+	speculativeHash     string
+	govTax              float64
+	tokenResponse       *security.JITToken
+	ghostSideEffects    []governance.SideEffect
+	sopDriftReport      *plan.DriftReport
+	compensationResults []escrow.CompensationResult
+	cardSignals         []security.AgentCardSignal
+}
+
+// governCalls resolves the calls a /govern request asks about: explicit
+// tool_calls, every call extracted from a raw protocol payload, or the
+// single legacy tool_name/arguments pair. Connector plugins (platform ones
+// and tenantID's own) get the payload first, so a tenant's own format is not
+// mistaken for a built-in one.
+func governCalls(parser *protocol.UniversalAIParser, pluginReg *plugins.Registry, tenantID, toolName string, args map[string]interface{},
+	toolCalls []governToolCall, payload json.RawMessage) ([]governToolCall, error) {
+
+	if len(toolCalls) > 0 {
+		calls := make([]governToolCall, len(toolCalls))
+		for i, c := range toolCalls {
+			if c.ToolName == "" {
+				return nil, fmt.Errorf("tool_calls[%d]: tool_name is required", i)
 			}
+			calls[i] = c
 		}
+		uniqueCallIDs(calls)
+		return calls, nil
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		if verdict == "BLOCK" {
-			w.WriteHeader(http.StatusForbidden)
-		} else if verdict == "ESCROW" {
-			w.WriteHeader(http.StatusAccepted)
+	if len(payload) > 0 && string(payload) != "null" {
+		// Accept the protocol message as JSON or as a JSON string (e.g. a
+		// captured HTTP body with headers)
+		raw := []byte(payload)
+		var s string
+		if json.Unmarshal(payload, &s) == nil {
+			raw = []byte(s)
 		}
 
-		response := map[string]interface{}{
-			"transaction_id":   txID,
-			"verdict":          verdict,
-			"action_class":     actionClass,
-			"reason":           reason,
-			"trust_score":      trustScore,
-			"governance_tax":   govTax,
-			"escrow_id":        escrowID,
-			"entitlement_id":   entitlementID,
-			"evidence_hash":    evidenceHash,
-			"speculative_hash": speculativeHash,
-			"processed_at":     time.Now(),
+		var parsed []*protocol.AIPayload
+		if pluginReg != nil {
+			parsed, _ = pluginReg.ParseAll(tenantID, raw)
 		}
-		if len(compensationResults) > 0 {
-			response["compensation"] = compensationResults
+		if len(parsed) == 0 {
+			parsed = parser.ParseAll(raw)
 		}
-		if tokenResponse != nil {
-			response["jit_token"] = map[string]interface{}{
-				"token_id":    tokenResponse.TokenID,
-				"token":       tokenResponse.Token,
-				"attribution": tokenResponse.Attribution,
-				"expires_at":  tokenResponse.ExpiresAt,
-			}
+
+		var calls []governToolCall
+		for _, p := range protocol.ToolCalls(parsed) {
+			calls = append(calls, governToolCall{ID: p.CallID, ToolName: p.ToolName, Arguments: p.Arguments})
 		}
-		if len(ghostSideEffects) > 0 {
-			response["ghost_side_effects"] = len(ghostSideEffects)
+		if len(calls) == 0 {
+			return nil, fmt.Errorf("payload contains no tool calls")
 		}
-		if sopDriftReport != nil {
-			response["sop_drift"] = map[string]interface{}{
-				"path_edit_distance":        sopDriftReport.PathEditDistance,
-				"normalized_edit_distance":  sopDriftReport.NormalizedEditDistance,
-				"policy_violations":         sopDriftReport.PolicyViolationCount,
-				"governance_tax_adjustment": sopDriftReport.GovernanceTaxAdjustment,
-				"missing_steps":             sopDriftReport.MissingSteps,
-				"extra_steps":               sopDriftReport.ExtraSteps,
+		uniqueCallIDs(calls)
+		return calls, nil
+	}
+
+	return []governToolCall{{ToolName: toolName, Arguments: args}}, nil
+}
+
+// uniqueCallIDs gives every call an ID unique within the turn, so
+// txID:callID names exactly one transaction. Missing and repeated IDs become
+// call-<index>, suffixed further if the caller or protocol already used it.
+func uniqueCallIDs(calls []governToolCall) {
+	taken := make(map[string]bool, len(calls))
+	for _, c := range calls {
+		taken[c.ID] = true
+	}
+	seen := make(map[string]bool, len(calls))
+	for i := range calls {
+		id := calls[i].ID
+		if id == "" || seen[id] {
+			id = fmt.Sprintf("call-%d", i)
+			for n := 2; taken[id] || seen[id]; n++ {
+				id = fmt.Sprintf("call-%d-%d", i, n)
 			}
 		}
-		json.NewEncoder(w).Encode(response)
+		seen[id] = true
+		calls[i].ID = id
+	}
+}
+
+// sequesterHeld runs step 3c for a call once its turn's verdict is known. A
+// CLASS_B call is sequestered with the Tri-Factor Gate, or the basic
+// EscrowGate without one, only when both the call and its turn are held: a
+// held call in a blocked turn leaves no hold and no escrow ID behind.
+func sequesterHeld(ctx context.Context, gate *escrow.EscrowGate, triGate *escrow.TriFactorGate, tenantID string, o *governOutcome, verdict string) {
+	if o.heldClassification == nil || o.verdict != "ESCROW" || verdict != "ESCROW" {
+		return
+	}
+	if triGate != nil {
+		payload, _ := json.Marshal(o.call.Arguments)
+		pendingItem, seqErr := triGate.Sequester(ctx, o.txID, tenantID, payload, o.heldClassification)
+		if seqErr == nil && pendingItem != nil {
+			o.escrowID = o.txID
+		}
+	} else {
+		// Fallback to basic EscrowGate
+		if holdErr := gate.Hold(o.txID, tenantID, []byte(o.call.ToolName)); holdErr == nil {
+			o.escrowID = o.txID
+		}
+	}
+}
+
+// combineGovernVerdicts folds per-call verdicts into the turn's verdict:
+// any BLOCK blocks the turn, otherwise any ESCROW holds it.
+func combineGovernVerdicts(outcomes []*governOutcome) (string, string) {
+	var blocked, held []string
+	for _, o := range outcomes {
+		switch o.verdict {
+		case "BLOCK":
+			blocked = append(blocked, o.call.ToolName)
+		case "ESCROW":
+			held = append(held, o.call.ToolName)
+		}
+	}
+	switch {
+	case len(blocked) > 0:
+		return "BLOCK", fmt.Sprintf("%d of %d tool calls blocked: %s", len(blocked), len(outcomes), strings.Join(blocked, ", "))
+	case len(held) > 0:
+		return "ESCROW", fmt.Sprintf("%d of %d tool calls held for review: %s", len(held), len(outcomes), strings.Join(held, ", "))
+	default:
+		return "ALLOW", fmt.Sprintf("All %d tool calls approved by governance", len(outcomes))
+	}
+}
+
+// setAttributionHeaders adds one X-OCX-Attribution header per call that
+// kept its JIT token, in call order. Each value is also returned with its
+// call as jit_token.attribution.
+func setAttributionHeaders(w http.ResponseWriter, outcomes []*governOutcome) {
+	for _, o := range outcomes {
+		if o.tokenResponse != nil {
+			w.Header().Add("X-OCX-Attribution", o.tokenResponse.Attribution)
+		}
+	}
+}
+
+func writeGovernStatus(w http.ResponseWriter, verdict string) {
+	w.Header().Set("Content-Type", "application/json")
+	if verdict == "BLOCK" {
+		w.WriteHeader(http.StatusForbidden)
+	} else if verdict == "ESCROW" {
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// response renders the outcome in the /govern response shape.
+func (o *governOutcome) response() map[string]interface{} {
+	response := map[string]interface{}{
+		"transaction_id":   o.txID,
+		"verdict":          o.verdict,
+		"action_class":     o.actionClass,
+		"reason":           o.reason,
+		"governance_tax":   o.govTax,
+		"escrow_id":        o.escrowID,
+		"entitlement_id":   o.entitlementID,
+		"evidence_hash":    o.evidenceHash,
+		"speculative_hash": o.speculativeHash,
+		"processed_at":     time.Now(),
+	}
+	if o.call.ID != "" {
+		response["call_id"] = o.call.ID
+		response["tool_name"] = o.call.ToolName
+	}
+	if len(o.compensationResults) > 0 {
+		response["compensation"] = o.compensationResults
+	}
+	if o.tokenResponse != nil {
+		response["jit_token"] = map[string]interface{}{
+			"token_id":    o.tokenResponse.TokenID,
+			"token":       o.tokenResponse.Token,
+			"attribution": o.tokenResponse.Attribution,
+			"expires_at":  o.tokenResponse.ExpiresAt,
+		}
+	}
+	if len(o.ghostSideEffects) > 0 {
+		response["ghost_side_effects"] = len(o.ghostSideEffects)
+	}
+	if len(o.cardSignals) > 0 {
+		response["agent_card_signals"] = o.cardSignals
+	}
+	if o.sopDriftReport != nil {
+		response["sop_drift"] = map[string]interface{}{
+			"path_edit_distance":        o.sopDriftReport.PathEditDistance,
+			"normalized_edit_distance":  o.sopDriftReport.NormalizedEditDistance,
+			"policy_violations":         o.sopDriftReport.PolicyViolationCount,
+			"governance_tax_adjustment": o.sopDriftReport.GovernanceTaxAdjustment,
+			"missing_steps":             o.sopDriftReport.MissingSteps,
+			"extra_steps":               o.sopDriftReport.ExtraSteps,
+		}
 	}
+	return response
 }
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ocx/backend/internal/protocol"
+	"github.com/ocx/backend/internal/security"
+)
+
+// HandleGovernResponse inspects a tool result or retrieval before the agent
+// hands it back to the model. Findings are recorded as evidence under the
+// transaction that governed the originating call.
+func HandleGovernResponse(inspector *security.ResponseInspector) http.HandlerFunc {
+	aiParser := protocol.NewUniversalAIParser()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// payload is the protocol message carrying the result (an MCP
+		// response, an OpenAI request with tool messages, a vector DB
+		// response) or the raw tool output; content is plain-text output.
+		var req struct {
+			TransactionID string          `json:"transaction_id"`
+			AgentID       string          `json:"agent_id"`
+			TenantID      string          `json:"tenant_id"`
+			ToolName      string          `json:"tool_name"`
+			CallID        string          `json:"call_id"`
+			Payload       json.RawMessage `json:"payload,omitempty"`
+			Content       string          `json:"content,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if req.TransactionID == "" {
+			req.TransactionID = r.Header.Get("X-Transaction-ID")
+		}
+		if req.TransactionID == "" {
+			http.Error(w, `{"error":"transaction_id is required to link findings"}`, http.StatusBadRequest)
+			return
+		}
+
+		body, isJSON := []byte(req.Content), false
+		if len(req.Payload) > 0 && string(req.Payload) != "null" {
+			var s string
+			if json.Unmarshal(req.Payload, &s) == nil {
+				body = []byte(s)
+			} else {
+				body, isJSON = req.Payload, true
+			}
+		}
+		if len(body) == 0 {
+			http.Error(w, `{"error":"payload or content is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		// Raw tool output does not parse as any protocol; it is still a result
+		p := aiParser.Parse(body)
+		if !security.InspectsPayload(p) {
+			p = &protocol.AIPayload{
+				Protocol:    p.Protocol,
+				ToolName:    p.ToolName,
+				MessageType: "tool_result",
+				Direction:   "response",
+			}
+		}
+		if req.ToolName != "" {
+			p.ToolName = req.ToolName
+		}
+		if req.CallID != "" {
+			p.CallID = req.CallID
+		}
+
+		res := inspector.Inspect(r.Context(), security.ResponseTarget{
+			TransactionID: req.TransactionID,
+			TenantID:      req.TenantID,
+			AgentID:       req.AgentID,
+		}, p, body)
+
+		slog.Info("/govern/response", "transaction_id", res.TransactionID, "tool_name", res.ToolName,
+			"protocol", res.Protocol, "action", res.Action, "findings", len(res.Findings))
+
+		response := map[string]interface{}{
+			"transaction_id": res.TransactionID,
+			"verdict":        res.Action,
+			"reason":         res.Reason,
+			"tool_name":      res.ToolName,
+			"call_id":        res.CallID,
+			"protocol":       res.Protocol,
+			"findings":       res.Findings,
+			"evidence_hash":  res.EvidenceHash,
+			"processed_at":   time.Now(),
+		}
+		if res.Action == security.ResponseRedact {
+			if isJSON && json.Valid(res.Content) {
+				response["payload"] = json.RawMessage(res.Content)
+			} else {
+				response["content"] = string(res.Content)
+			}
+		}
+
+		writeGovernStatus(w, string(res.Action))
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ocx/backend/internal/escrow"
+	"github.com/ocx/backend/internal/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// MULTI-CALL TURN TESTS
+// ============================================================================
+
+// outcome is a per-call governance result as evaluate would leave it; held
+// calls carry the CLASS_B classification that settle sequesters.
+func outcome(id, tool, verdict string) *governOutcome {
+	o := &governOutcome{
+		call:    governToolCall{ID: id, ToolName: tool},
+		txID:    "gov-1:" + id,
+		verdict: verdict,
+	}
+	if verdict == "ESCROW" {
+		o.heldClassification = &escrow.ClassificationResult{ToolID: tool, FinalVerdict: "HOLD"}
+	}
+	return o
+}
+
+func TestCombineGovernVerdicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		verdicts []string
+		want     string
+		reason   string
+	}{
+		{"all allowed", []string{"ALLOW", "ALLOW"}, "ALLOW", "All 2 tool calls approved by governance"},
+		{"held sibling holds the turn", []string{"ALLOW", "ESCROW"}, "ESCROW", "1 of 2 tool calls held for review: tool-1"},
+		{"block wins over hold", []string{"ALLOW", "ESCROW", "BLOCK"}, "BLOCK", "1 of 3 tool calls blocked: tool-2"},
+		{"every block is named", []string{"BLOCK", "ESCROW", "BLOCK"}, "BLOCK", "2 of 3 tool calls blocked: tool-0, tool-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcomes := make([]*governOutcome, len(tt.verdicts))
+			for i, v := range tt.verdicts {
+				outcomes[i] = outcome(fmt.Sprintf("call-%d", i), fmt.Sprintf("tool-%d", i), v)
+			}
+			verdict, reason := combineGovernVerdicts(outcomes)
+			assert.Equal(t, tt.want, verdict)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestUniqueCallIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{"distinct IDs are kept", []string{"a", "b"}, []string{"a", "b"}},
+		{"empty IDs get their index", []string{"", ""}, []string{"call-0", "call-1"}},
+		{"repeats after the first are renamed", []string{"x", "x", "x"}, []string{"x", "call-1", "call-2"}},
+		{"generated IDs avoid caller IDs", []string{"call-1", "", ""}, []string{"call-1", "call-1-2", "call-2"}},
+		{"generated IDs avoid later caller IDs", []string{"", "call-0"}, []string{"call-0-2", "call-0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make([]governToolCall, len(tt.ids))
+			for i, id := range tt.ids {
+				calls[i] = governToolCall{ID: id, ToolName: "tool"}
+			}
+			uniqueCallIDs(calls)
+			got := make([]string, len(calls))
+			for i, c := range calls {
+				got[i] = c.ID
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSequesterHeldOnlyInHeldTurn(t *testing.T) {
+	gate := escrow.NewEscrowGate(nil, nil)
+	outcomes := []*governOutcome{
+		outcome("a", "read_file", "ALLOW"),
+		outcome("b", "execute_payment", "ESCROW"),
+	}
+	verdict, _ := combineGovernVerdicts(outcomes)
+	require.Equal(t, "ESCROW", verdict)
+
+	for _, o := range outcomes {
+		sequesterHeld(context.Background(), gate, nil, "tenant-a", o, verdict)
+	}
+
+	assert.Empty(t, outcomes[0].escrowID)
+	assert.Equal(t, "gov-1:b", outcomes[1].escrowID)
+	held := gate.ListHeld()
+	require.Len(t, held, 1)
+	assert.Equal(t, "gov-1:b", held[0].ID)
+}
+
+func TestSequesterHeldSkipsBlockedTurn(t *testing.T) {
+	gate := escrow.NewEscrowGate(nil, nil)
+	outcomes := []*governOutcome{
+		outcome("a", "read_file", "ALLOW"),
+		outcome("b", "execute_payment", "ESCROW"),
+		outcome("c", "delete_data", "BLOCK"),
+	}
+	verdict, _ := combineGovernVerdicts(outcomes)
+	require.Equal(t, "BLOCK", verdict)
+
+	for _, o := range outcomes {
+		sequesterHeld(context.Background(), gate, nil, "tenant-a", o, verdict)
+	}
+
+	assert.Empty(t, gate.ListHeld(), "a held call in a blocked turn must not be sequestered")
+	for _, o := range outcomes {
+		assert.Empty(t, o.escrowID, o.call.ID)
+		assert.Equal(t, "", o.response()["escrow_id"], o.call.ID)
+	}
+}
+
+func TestSetAttributionHeadersPerCall(t *testing.T) {
+	outcomes := []*governOutcome{
+		outcome("a", "read_file", "ALLOW"),
+		outcome("b", "list_files", "ALLOW"),
+		outcome("c", "send_email", "ALLOW"),
+	}
+	outcomes[0].tokenResponse = &security.JITToken{TokenID: "tok-a", Attribution: "attr-a"}
+	outcomes[2].tokenResponse = &security.JITToken{TokenID: "tok-c", Attribution: "attr-c"}
+
+	rec := httptest.NewRecorder()
+	setAttributionHeaders(rec, outcomes)
+	assert.Equal(t, []string{"attr-a", "attr-c"}, rec.Header().Values("X-OCX-Attribution"))
+}
@@ -75,9 +75,11 @@ func GetHubMetrics(hub *fabric.Hub) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"hub_id":           hub.ID,
+			"replica_id":       hub.ReplicaID(),
 			"region":           hub.Region,
 			"connected_spokes": metrics.SpokesConnected.Load(),
 			"total_routed":     metrics.MessagesRouted.Load(),
+			"total_forwarded":  metrics.MessagesForwarded.Load(),
 			"peers_connected":  metrics.PeersConnected.Load(),
 		})
 	}
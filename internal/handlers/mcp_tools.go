@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ocx/backend/internal/multitenancy"
+	"github.com/ocx/backend/internal/security"
+)
+
+// HandleAnalyzeMCPTools fingerprints a server's tools/list result and
+// reports descriptor changes, injection-style instructions and hidden
+// Unicode. Tool risk feeds later classification of calls to those tools.
+// The baseline is the authenticated tenant's own view of server_id, so a
+// caller can neither seed nor change the baseline another tenant relies on.
+func HandleAnalyzeMCPTools(analyzer *security.ToolListAnalyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			ServerID string          `json:"server_id"`
+			Payload  json.RawMessage `json:"payload"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if req.ServerID == "" || len(req.Payload) == 0 {
+			http.Error(w, `{"error":"server_id and payload are required"}`, http.StatusBadRequest)
+			return
+		}
+
+		report, err := analyzer.AnalyzePayload(r.Context(), tenantID, req.ServerID, req.Payload)
+		if err != nil {
+			slog.Warn("/mcp/tools/analyze failed", "tenant_id", tenantID, "server_id", req.ServerID, "error", err)
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// HandleAcceptMCPTools adopts the latest tool list the authenticated tenant
+// saw from a server as its baseline after review.
+func HandleAcceptMCPTools(analyzer *security.ToolListAnalyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := multitenancy.GetTenantID(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		serverID := mux.Vars(r)["serverId"]
+		if err := analyzer.Accept(r.Context(), tenantID, serverID); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusNotFound)
+			return
+		}
+
+		slog.Info("MCP tool list accepted", "tenant_id", tenantID, "server_id", serverID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"server_id": serverID,
+			"accepted":  true,
+		})
+	}
+}
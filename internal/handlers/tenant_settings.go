@@ -15,8 +15,10 @@ type cryptoAlgorithmRequest struct {
 
 // validCryptoAlgorithms lists the accepted values.
 var validCryptoAlgorithms = map[string]bool{
-	"ed25519":    true,
-	"ecdsa-p256": true,
+	"ed25519":           true,
+	"ecdsa-p256":        true,
+	"ml-dsa-65":         true,
+	"ed25519+ml-dsa-65": true,
 }
 
 // HandleGetTenantSettings returns the current tenant settings from the database.
@@ -57,7 +59,7 @@ func HandleGetTenantSettings(client *database.SupabaseClient) http.HandlerFunc {
 
 // HandleUpdateTenantCryptoAlgorithm sets the federation crypto algorithm.
 // PUT /api/v1/tenant/settings/crypto
-// Body: {"algorithm": "ed25519"} or {"algorithm": "ecdsa-p256"}
+// Body: {"algorithm": "ed25519"}, "ecdsa-p256", "ml-dsa-65" or "ed25519+ml-dsa-65"
 func HandleUpdateTenantCryptoAlgorithm(client *database.SupabaseClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, err := multitenancy.GetTenantID(r.Context())
@@ -75,7 +77,7 @@ func HandleUpdateTenantCryptoAlgorithm(client *database.SupabaseClient) http.Han
 
 		// Validate algorithm
 		if !validCryptoAlgorithms[req.Algorithm] {
-			http.Error(w, "Invalid algorithm. Must be 'ed25519' or 'ecdsa-p256'", http.StatusBadRequest)
+			http.Error(w, "Invalid algorithm. Must be 'ed25519', 'ecdsa-p256', 'ml-dsa-65' or 'ed25519+ml-dsa-65'", http.StatusBadRequest)
 			return
 		}
 
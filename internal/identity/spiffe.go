@@ -92,6 +92,13 @@ func (sv *SPIFFEVerifier) GetTLSConfig() (*tls.Config, error) {
 	return tlsConf, nil
 }
 
+// GetServerTLSConfig returns TLS config for servers that require callers to
+// present a SPIFFE SVID (mTLS)
+func (sv *SPIFFEVerifier) GetServerTLSConfig() (*tls.Config, error) {
+	tlsConf := tlsconfig.MTLSServerConfig(sv.source, sv.source, tlsconfig.AuthorizeAny())
+	return tlsConf, nil
+}
+
 // Close cleanup
 func (sv *SPIFFEVerifier) Close() error {
 	return sv.source.Close()
@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/ocx/backend/internal/fabric"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -62,7 +63,7 @@ func (a *GoRedisAdapter) Set(ctx context.Context, key string, value []byte, ttl
 func (a *GoRedisAdapter) Get(ctx context.Context, key string) ([]byte, error) {
 	val, err := a.rdb.Get(ctx, key).Bytes()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("key not found: %s", key)
+		return nil, fmt.Errorf("%w: %s", fabric.ErrKeyNotFound, key)
 	}
 	return val, err
 }
@@ -71,6 +72,31 @@ func (a *GoRedisAdapter) Del(ctx context.Context, keys ...string) error {
 	return a.rdb.Del(ctx, keys...).Err()
 }
 
+// SetNX sets key only if it does not exist and reports whether it did.
+func (a *GoRedisAdapter) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return a.rdb.SetNX(ctx, key, value, ttl).Result()
+}
+
+// delIfEqualScript deletes KEYS[1] only while it still holds ARGV[1].
+var delIfEqualScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// DelIfEqual atomically deletes key if its value is value and reports
+// whether it did.
+func (a *GoRedisAdapter) DelIfEqual(ctx context.Context, key string, value []byte) (bool, error) {
+	n, err := delIfEqualScript.Run(ctx, a.rdb, []string{key}, value).Int()
+	return n == 1, err
+}
+
+// Exists reports whether key exists.
+func (a *GoRedisAdapter) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := a.rdb.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
 func (a *GoRedisAdapter) SAdd(ctx context.Context, key string, members ...string) error {
 	ifaces := make([]interface{}, len(members))
 	for i, m := range members {
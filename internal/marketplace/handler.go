@@ -388,6 +388,11 @@ func (h *Handler) revenueAnalytics(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, analytics)
 }
 
+// Service returns the marketplace service behind the handler.
+func (h *Handler) Service() *Service {
+	return h.svc
+}
+
 // SetupMarketplace initializes the marketplace and registers all HTTP routes.
 // Call this from your main() to wire everything together.
 func SetupMarketplace(mux *http.ServeMux) *Handler {
@@ -183,6 +183,12 @@ func NewService() *Service {
 	return svc
 }
 
+// SignatureVerifier returns the verifier used for marketplace items and
+// connector plugin binaries.
+func (s *Service) SignatureVerifier() *SignatureVerifier {
+	return s.sigVerifier
+}
+
 // ListConnectors returns connectors visible to a tenant, filtered by category.
 func (s *Service) ListConnectors(tenantID string, category string) []*Connector {
 	s.mu.RLock()
@@ -10,7 +10,9 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"log/slog"
@@ -73,6 +75,25 @@ func (sv *SignatureVerifier) RegisterPublisherKey(publisherID string, pubKey *ec
 	slog.Info("Registered publisher key", "publisher_i_d", publisherID)
 }
 
+// RegisterPublisherKeyPEM adds a trusted publisher's PEM-encoded (PKIX)
+// ECDSA public key.
+func (sv *SignatureVerifier) RegisterPublisherKeyPEM(publisherID string, pemBytes []byte) error {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return fmt.Errorf("no PEM block in key for publisher %s", publisherID)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parse key for publisher %s: %w", publisherID, err)
+	}
+	ecKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("key for publisher %s is not ECDSA", publisherID)
+	}
+	sv.RegisterPublisherKey(publisherID, ecKey)
+	return nil
+}
+
 // GetPlatformPublicKey returns the platform's public key for verification.
 func (sv *SignatureVerifier) GetPlatformPublicKey() *ecdsa.PublicKey {
 	return &sv.platformKey.PublicKey
@@ -247,6 +268,57 @@ func (sv *SignatureVerifier) ValidateTemplate(tmpl *Template) *SignatureResult {
 	return result
 }
 
+// ValidatePlugin validates a plugin executable before it is launched. The
+// signature is the hex ASN.1 ECDSA signature over the SHA-256 of the binary,
+// as produced by SignContent for platform-signed plugins.
+func (sv *SignatureVerifier) ValidatePlugin(name, publisherID string, binary []byte, signatureHex string) *SignatureResult {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	hash := sha256.Sum256(binary)
+	contentHash := hex.EncodeToString(hash[:])
+
+	if signatureHex == "" {
+		sv.logVerification(name, "plugin", publisherID, "no_signature", contentHash)
+		return &SignatureResult{Valid: false, Reason: "no signature provided", ContentHash: contentHash}
+	}
+
+	pubKey, exists := sv.trustedKeys[publisherID]
+	if publisherID == "ocx-platform" {
+		pubKey, exists = &sv.platformKey.PublicKey, true
+	}
+	if !exists {
+		sv.logVerification(name, "plugin", publisherID, "unknown_publisher", contentHash)
+		return &SignatureResult{
+			Valid:       false,
+			Reason:      fmt.Sprintf("unknown publisher: %s (no registered key)", publisherID),
+			ContentHash: contentHash,
+		}
+	}
+
+	sigBytes, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		sv.logVerification(name, "plugin", publisherID, "invalid", contentHash)
+		return &SignatureResult{Valid: false, Reason: "malformed signature encoding", ContentHash: contentHash}
+	}
+
+	valid := ecdsa.VerifyASN1(pubKey, hash[:], sigBytes)
+
+	resultStr := "valid"
+	reason := "signature verified"
+	if !valid {
+		resultStr = "invalid"
+		reason = "signature verification failed — binary may have been tampered with"
+	}
+	sv.logVerification(name, "plugin", publisherID, resultStr, contentHash)
+	return &SignatureResult{
+		Valid:       valid,
+		Reason:      reason,
+		ContentHash: contentHash,
+		SignerID:    publisherID,
+	}
+}
+
 // GetAuditLog returns recent verification audit entries.
 func (sv *SignatureVerifier) GetAuditLog() []VerificationAuditEntry {
 	sv.mu.RLock()
@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -29,41 +30,68 @@ type a2aRequest struct {
 type a2aTaskSendParams struct {
 	ID      string `json:"id,omitempty"`
 	Message struct {
-		Role  string `json:"role"`
-		Parts []struct {
-			Type     string `json:"type,omitempty"`
-			Text     string `json:"text,omitempty"`
-			MimeType string `json:"mimeType,omitempty"`
-		} `json:"parts"`
+		Role  string    `json:"role"`
+		Parts []a2aPart `json:"parts"`
 	} `json:"message"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// a2aAgentCard represents an Agent Card
-type a2aAgentCard struct {
+// a2aPart is one message part: text, file or structured data
+type a2aPart struct {
+	Type     string                 `json:"type,omitempty"`
+	Kind     string                 `json:"kind,omitempty"` // newer spec revisions
+	Text     string                 `json:"text,omitempty"`
+	MimeType string                 `json:"mimeType,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	File     *struct {
+		Name     string `json:"name,omitempty"`
+		MimeType string `json:"mimeType,omitempty"`
+		URI      string `json:"uri,omitempty"`
+	} `json:"file,omitempty"`
+}
+
+// AgentCard is an A2A agent's self-description, served at
+// /.well-known/agent-card.json (agent.json before spec v0.3). Signatures
+// are JWS over the card's canonical JSON without the signatures member.
+type AgentCard struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	URL         string `json:"url"`
+	Version     string `json:"version,omitempty"`
 	Provider    struct {
 		Organization string `json:"organization"`
+		URL          string `json:"url,omitempty"`
 	} `json:"provider"`
-	Skills []struct {
-		ID          string   `json:"id"`
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		Tags        []string `json:"tags,omitempty"`
-	} `json:"skills"`
+	Skills     []AgentSkill         `json:"skills"`
+	Signatures []AgentCardSignature `json:"signatures,omitempty"`
+}
+
+// AgentSkill is one capability an agent card declares.
+type AgentSkill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+// AgentCardSignature is a detached-payload JWS: base64url protected header
+// and signature, plus an optional unprotected header.
+type AgentCardSignature struct {
+	Protected string                 `json:"protected"`
+	Signature string                 `json:"signature"`
+	Header    map[string]interface{} `json:"header,omitempty"`
 }
 
 func (p *A2AParser) Name() AIProtocolType { return ProtoA2A }
 
 func (p *A2AParser) CanParse(payload []byte) bool {
-	s := string(payload)
-	return strings.Contains(s, `"jsonrpc"`) &&
-		(strings.Contains(s, `"tasks/`) ||
-			strings.Contains(s, `"agent/`) ||
-			strings.Contains(s, `"parts"`) ||
-			strings.Contains(s, `"skills"`))
+	s := payload
+	return containsField(s, `"jsonrpc"`) &&
+		(containsField(s, `"tasks/`) ||
+			containsField(s, `"agent/`) ||
+			containsField(s, `"parts"`) ||
+			containsField(s, `"skills"`))
 }
 
 func (p *A2AParser) Parse(payload []byte) (*AIPayload, error) {
@@ -146,3 +174,57 @@ func classifyA2AMethod(method string) string {
 		return "agent_message"
 	}
 }
+
+// ParseAll returns one agent_task payload per part of a tasks/send message,
+// so a task carrying text plus data or file parts is governed part by part.
+func (p *A2AParser) ParseAll(payload []byte) ([]*AIPayload, error) {
+	primary, err := p.Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	if primary.RawMethod != "tasks/send" {
+		return []*AIPayload{primary}, nil
+	}
+
+	var req a2aRequest
+	var params a2aTaskSendParams
+	if json.Unmarshal(payload[findJSONStart(payload):], &req) != nil ||
+		json.Unmarshal(req.Params, &params) != nil || len(params.Message.Parts) < 2 {
+		return []*AIPayload{primary}, nil
+	}
+
+	parts := make([]*AIPayload, 0, len(params.Message.Parts))
+	for i, part := range params.Message.Parts {
+		kind := part.Kind
+		if kind == "" {
+			kind = part.Type
+		}
+		args := map[string]interface{}{"role": params.Message.Role}
+		switch {
+		case part.Data != nil:
+			kind = "data"
+			args["data"] = part.Data
+		case part.File != nil:
+			kind = "file"
+			args["file_name"] = part.File.Name
+			args["mime_type"] = part.File.MimeType
+			args["uri"] = part.File.URI
+		default:
+			if kind == "" {
+				kind = "text"
+			}
+			args["text"] = part.Text
+		}
+
+		call := clonePayload(primary)
+		call.Arguments = args
+		if params.ID != "" {
+			call.CallID = params.ID + "#" + strconv.Itoa(i)
+		}
+		call.Metadata["part_index"] = i
+		call.Metadata["part_type"] = kind
+		call.Metadata["total_parts"] = len(params.Message.Parts)
+		parts = append(parts, call)
+	}
+	return parts, nil
+}
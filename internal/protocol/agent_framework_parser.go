@@ -2,7 +2,6 @@ package protocol
 
 import (
 	"encoding/json"
-	"strings"
 	"time"
 )
 
@@ -21,15 +20,15 @@ type AgentFrameworkParser struct{}
 func (p *AgentFrameworkParser) Name() AIProtocolType { return ProtoLangChain }
 
 func (p *AgentFrameworkParser) CanParse(payload []byte) bool {
-	s := string(payload)
-	return strings.Contains(s, `"agent"`) &&
-		(strings.Contains(s, `"task"`) ||
-			strings.Contains(s, `"input"`) ||
-			strings.Contains(s, `"callbacks"`) ||
-			strings.Contains(s, `"sender"`) ||
-			strings.Contains(s, `"crew"`) ||
-			strings.Contains(s, `"pipeline"`) ||
-			strings.Contains(s, `"skills"`))
+	s := payload
+	return containsField(s, `"agent"`) &&
+		(containsField(s, `"task"`) ||
+			containsField(s, `"input"`) ||
+			containsField(s, `"callbacks"`) ||
+			containsField(s, `"sender"`) ||
+			containsField(s, `"crew"`) ||
+			containsField(s, `"pipeline"`) ||
+			containsField(s, `"skills"`))
 }
 
 func (p *AgentFrameworkParser) Parse(payload []byte) (*AIPayload, error) {
@@ -0,0 +1,470 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Payload shapes below follow the public Anthropic Messages API and Gemini
+// generateContent wire formats, plus neighbouring protocols that must keep
+// routing to their own parsers.
+var aiParserCorpus = []struct {
+	name      string
+	payload   string
+	protocol  AIProtocolType
+	tool      string
+	msgType   string
+	direction string
+	minConf   float64
+	args      map[string]interface{}
+	metadata  map[string]interface{}
+}{
+	// ---------------------------------------------------------------- Anthropic
+	{
+		name: "anthropic/response tool_use",
+		payload: `{"id":"msg_01Aq9w938a90dw8q","type":"message","role":"assistant","model":"claude-sonnet-4-20250514",
+			"content":[{"type":"text","text":"I'll check the weather."},
+			{"type":"tool_use","id":"toolu_01A09q90qw90lq917835lq9","name":"get_weather","input":{"location":"San Francisco, CA","unit":"celsius"}}],
+			"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":472,"output_tokens":91}}`,
+		protocol: ProtoAnthropic, tool: "get_weather", msgType: "tool_call", direction: "response", minConf: 0.95,
+		args:     map[string]interface{}{"location": "San Francisco, CA", "unit": "celsius"},
+		metadata: map[string]interface{}{"tool_use_id": "toolu_01A09q90qw90lq917835lq9", "total_tool_calls": 1, "stop_reason": "tool_use"},
+	},
+	{
+		name: "anthropic/response parallel tool_use",
+		payload: `{"id":"msg_02","type":"message","role":"assistant","model":"claude-opus-4-1",
+			"content":[{"type":"tool_use","id":"toolu_a","name":"execute_payment","input":{"amount":250,"currency":"USD"}},
+			{"type":"tool_use","id":"toolu_b","name":"send_email","input":{"to":"cfo@example.com"}}],
+			"stop_reason":"tool_use"}`,
+		protocol: ProtoAnthropic, tool: "execute_payment", msgType: "tool_call", direction: "response", minConf: 0.95,
+		args:     map[string]interface{}{"amount": 250.0, "currency": "USD"},
+		metadata: map[string]interface{}{"total_tool_calls": 2, "tool_names": []string{"execute_payment", "send_email"}},
+	},
+	{
+		name: "anthropic/response text only",
+		payload: `{"id":"msg_03","type":"message","role":"assistant","model":"claude-3-5-haiku-20241022",
+			"content":[{"type":"text","text":"Hello!"}],"stop_reason":"end_turn"}`,
+		protocol: ProtoAnthropic, tool: "llm_completion", msgType: "generation", direction: "response", minConf: 0.9,
+	},
+	{
+		name: "anthropic/request tool_result resolves name from tool_use",
+		payload: "POST /v1/messages HTTP/1.1\r\nHost: api.anthropic.com\r\nanthropic-version: 2023-06-01\r\n\r\n" +
+			`{"model":"claude-sonnet-4-20250514","max_tokens":1024,
+			"tools":[{"name":"get_weather","description":"Get weather","input_schema":{"type":"object","properties":{"location":{"type":"string"}}}}],
+			"messages":[{"role":"user","content":"What's the weather in SF?"},
+			{"role":"assistant","content":[{"type":"tool_use","id":"toolu_01","name":"get_weather","input":{"location":"San Francisco"}}]},
+			{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_01","content":"15 degrees"}]}]}`,
+		protocol: ProtoAnthropic, tool: "get_weather", msgType: "tool_result", direction: "request", minConf: 0.95,
+		metadata: map[string]interface{}{"tool_use_id": "toolu_01", "is_error": false, "total_tool_results": 1, "available_tools": []string{"get_weather"}},
+	},
+	{
+		name: "anthropic/request parallel tool_result with error",
+		payload: `{"model":"claude-sonnet-4-20250514","max_tokens":512,"messages":[
+			{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"read_file","input":{"path":"/etc/hosts"}},
+			{"type":"tool_use","id":"t2","name":"delete_file","input":{"path":"/tmp/x"}}]},
+			{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":[{"type":"text","text":"permission denied"}]},
+			{"type":"tool_result","tool_use_id":"t1","content":"127.0.0.1 localhost"}]}]}`,
+		protocol: ProtoAnthropic, tool: "delete_file", msgType: "tool_result", direction: "request", minConf: 0.95,
+		metadata: map[string]interface{}{"is_error": true, "total_tool_results": 2, "tool_use_ids": []string{"t2", "t1"}},
+	},
+	{
+		name: "anthropic/request truncated history",
+		payload: `{"model":"claude-sonnet-4-20250514","max_tokens":512,"messages":[
+			{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_gone","content":"ok"}]}]}`,
+		protocol: ProtoAnthropic, tool: "unknown_tool", msgType: "tool_result", direction: "request", minConf: 0.8,
+	},
+	{
+		name: "anthropic/request with tools is generation",
+		payload: `{"model":"claude-sonnet-4-20250514","max_tokens":1024,"system":"You are a bank assistant",
+			"tools":[{"name":"transfer_funds","input_schema":{"type":"object"}},{"type":"web_search_20250305","name":"web_search"}],
+			"messages":[{"role":"user","content":"Move $50 to savings"}]}`,
+		protocol: ProtoAnthropic, tool: "llm_completion", msgType: "generation", direction: "request", minConf: 0.9,
+		metadata: map[string]interface{}{"tool_count": 2, "available_tools": []string{"transfer_funds", "web_search"}},
+	},
+	{
+		name: "anthropic/bedrock request",
+		payload: `{"anthropic_version":"bedrock-2023-05-31","max_tokens":256,
+			"messages":[{"role":"user","content":[{"type":"text","text":"Summarize"}]}]}`,
+		protocol: ProtoAnthropic, tool: "llm_completion", msgType: "generation", direction: "request", minConf: 0.9,
+		metadata: map[string]interface{}{"anthropic_version": "bedrock-2023-05-31"},
+	},
+
+	// ------------------------------------------------------------------- Gemini
+	{
+		name: "gemini/response functionCall",
+		payload: `{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"find_theaters","args":{"location":"Mountain View, CA","movie":"Barbie"}}}]},
+			"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":9,"totalTokenCount":20},"modelVersion":"gemini-1.5-pro-002"}`,
+		protocol: ProtoGemini, tool: "find_theaters", msgType: "tool_call", direction: "response", minConf: 0.95,
+		args:     map[string]interface{}{"location": "Mountain View, CA", "movie": "Barbie"},
+		metadata: map[string]interface{}{"total_tool_calls": 1, "finish_reason": "STOP"},
+	},
+	{
+		name: "gemini/response parallel functionCall",
+		payload: `{"candidates":[{"content":{"role":"model","parts":[
+			{"functionCall":{"id":"call-1","name":"power_disco_ball","args":{"power":true}}},
+			{"functionCall":{"id":"call-2","name":"start_music","args":{"energetic":true,"loud":true}}},
+			{"functionCall":{"id":"call-3","name":"dim_lights","args":{"brightness":0.5}}}]},"finishReason":"STOP"}],
+			"modelVersion":"gemini-2.0-flash"}`,
+		protocol: ProtoGemini, tool: "power_disco_ball", msgType: "tool_call", direction: "response", minConf: 0.95,
+		args:     map[string]interface{}{"power": true},
+		metadata: map[string]interface{}{"total_tool_calls": 3, "tool_call_id": "call-1", "tool_names": []string{"power_disco_ball", "start_music", "dim_lights"}},
+	},
+	{
+		name: "gemini/response text",
+		payload: `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi there"}]},"finishReason":"STOP"}],
+			"modelVersion":"gemini-1.5-flash"}`,
+		protocol: ProtoGemini, tool: "llm_completion", msgType: "generation", direction: "response", minConf: 0.9,
+	},
+	{
+		name: "gemini/request functionResponse with model from path",
+		payload: "POST /v1beta/models/gemini-1.5-pro:generateContent?key=x HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" +
+			`{"contents":[{"role":"user","parts":[{"text":"Which theaters in Mountain View show Barbie?"}]},
+			{"role":"model","parts":[{"functionCall":{"name":"find_theaters","args":{"location":"Mountain View, CA"}}}]},
+			{"role":"user","parts":[{"functionResponse":{"name":"find_theaters","response":{"theaters":[{"name":"AMC"}]}}}]}],
+			"tools":[{"functionDeclarations":[{"name":"find_theaters","description":"find theaters"},{"name":"get_showtimes"}]}]}`,
+		protocol: ProtoGemini, tool: "find_theaters", msgType: "tool_result", direction: "request", minConf: 0.95,
+		metadata: map[string]interface{}{"total_tool_results": 1, "tool_count": 2},
+	},
+	{
+		name: "gemini/request snake_case SDK form",
+		payload: `{"contents":[{"role":"function","parts":[{"function_response":{"name":"get_balance","response":{"balance":10}}},
+			{"function_response":{"name":"get_limits","response":{"daily":500}}}]}],
+			"tools":[{"function_declarations":[{"name":"get_balance"},{"name":"get_limits"}]}]}`,
+		protocol: ProtoGemini, tool: "get_balance", msgType: "tool_result", direction: "request", minConf: 0.95,
+		metadata: map[string]interface{}{"total_tool_results": 2, "tool_names": []string{"get_balance", "get_limits"}},
+	},
+	{
+		name: "gemini/request generation",
+		payload: `{"contents":[{"role":"user","parts":[{"text":"Write a haiku"}]}],
+			"generationConfig":{"temperature":0.9},"model":"models/gemini-1.5-flash"}`,
+		protocol: ProtoGemini, tool: "llm_completion", msgType: "generation", direction: "request", minConf: 0.85,
+	},
+
+	// ----------------------------------------------- neighbours keep their parser
+	{
+		name: "openai/response tool_calls",
+		payload: `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant",
+			"tool_calls":[{"id":"call_abc","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},
+			"finish_reason":"tool_calls"}]}`,
+		protocol: ProtoOpenAI, tool: "get_weather", msgType: "tool_call", direction: "response", minConf: 0.95,
+		args: map[string]interface{}{"city": "Paris"},
+	},
+	{
+		name:     "openai/request chat",
+		payload:  `{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}],"max_tokens":100}`,
+		protocol: ProtoOpenAI, tool: "llm_completion", msgType: "generation", direction: "request", minConf: 0.85,
+	},
+	{
+		name:     "openai/legacy function_call",
+		payload:  `{"model":"gpt-3.5-turbo","messages":[{"role":"assistant","function_call":{"name":"lookup","arguments":"{}"}}]}`,
+		protocol: ProtoOpenAI, tool: "llm_completion", msgType: "generation", direction: "request", minConf: 0.85,
+	},
+	{
+		name:     "mcp/tools call",
+		payload:  `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"query_db","arguments":{"sql":"select 1"}}}`,
+		protocol: ProtoMCP, tool: "query_db", msgType: "tool_call", direction: "request", minConf: 0.9,
+	},
+	{
+		name: "mcp/tools list result",
+		payload: `{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"get_weather","description":"Get current weather",
+			"inputSchema":{"type":"object","properties":{"location":{"type":"string"}}}}]}}`,
+		protocol: ProtoMCP, tool: "_list_tools", msgType: "discovery", direction: "response", minConf: 0.9,
+		metadata: map[string]interface{}{"tool_count": 1, "tool_names": []string{"get_weather"}},
+	},
+
+	// ---------------------------------------------------------- RAG retrievals
+	{
+		name:     "rag/pinecone query",
+		payload:  `{"vector":[0.1,0.2,0.3],"topK":3,"namespace":"kb","includeMetadata":true}`,
+		protocol: ProtoRAG, tool: "vector_search", msgType: "retrieval", direction: "request", minConf: 0.9,
+	},
+	{
+		name: "rag/pinecone matches",
+		payload: `{"matches":[{"id":"doc-1","score":0.91,"metadata":{"text":"Refund policy: 30 days"}},
+			{"id":"doc-7","score":0.84,"metadata":{"text":"Shipping is free over $50"}}],"namespace":"kb"}`,
+		protocol: ProtoRAG, tool: "vector_search", msgType: "retrieval", direction: "response", minConf: 0.85,
+		metadata: map[string]interface{}{"retrieved_count": 2, "namespace": "kb"},
+	},
+	{
+		name: "rag/chroma query result",
+		payload: `{"ids":[["a","b"]],"documents":[["first chunk","second chunk"]],
+			"metadatas":[[{"src":"wiki"},{"src":"wiki"}]],"distances":[[0.12,0.4]]}`,
+		protocol: ProtoRAG, tool: "collection_query", msgType: "retrieval", direction: "response", minConf: 0.85,
+		metadata: map[string]interface{}{"retrieved_count": 2},
+	},
+	{
+		name:     "rag/chroma add is not a result",
+		payload:  `{"ids":["a"],"documents":["first chunk"],"metadatas":[{"src":"wiki"}]}`,
+		protocol: ProtoRAG, tool: "collection_query", msgType: "retrieval", direction: "request", minConf: 0.8,
+	},
+}
+
+func TestUniversalAIParserCorpus(t *testing.T) {
+	parser := NewUniversalAIParser()
+
+	for _, tc := range aiParserCorpus {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parser.Parse([]byte(tc.payload))
+
+			if got.Protocol != tc.protocol {
+				t.Fatalf("Protocol = %s, want %s (tool=%q type=%q)", got.Protocol, tc.protocol, got.ToolName, got.MessageType)
+			}
+			if got.ToolName != tc.tool {
+				t.Errorf("ToolName = %q, want %q", got.ToolName, tc.tool)
+			}
+			if got.MessageType != tc.msgType {
+				t.Errorf("MessageType = %q, want %q", got.MessageType, tc.msgType)
+			}
+			if got.Direction != tc.direction {
+				t.Errorf("Direction = %q, want %q", got.Direction, tc.direction)
+			}
+			if got.Confidence < tc.minConf {
+				t.Errorf("Confidence = %.2f, want >= %.2f", got.Confidence, tc.minConf)
+			}
+			if tc.args != nil && !reflect.DeepEqual(got.Arguments, tc.args) {
+				t.Errorf("Arguments = %v, want %v", got.Arguments, tc.args)
+			}
+			for k, want := range tc.metadata {
+				if v := got.Metadata[k]; !reflect.DeepEqual(v, want) {
+					t.Errorf("Metadata[%q] = %#v, want %#v", k, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGeminiModelFromRequestPath(t *testing.T) {
+	payload := "POST /v1/projects/p/locations/us-central1/publishers/google/models/gemini-2.0-flash-001:streamGenerateContent HTTP/1.1\r\n\r\n" +
+		`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`
+
+	got, err := (&GeminiParser{}).Parse([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Model != "gemini-2.0-flash-001" || got.RawMethod != "streamGenerateContent" {
+		t.Errorf("Model=%q RawMethod=%q", got.Model, got.RawMethod)
+	}
+}
+
+func TestUniversalAIParserParseAll(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		tools   []string
+		callIDs []string // nil: only check IDs are present and distinct
+	}{
+		{
+			name: "openai/three parallel tool_calls",
+			payload: `{"id":"chatcmpl-7","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","tool_calls":[
+				{"id":"call_1","type":"function","function":{"name":"get_balance","arguments":"{\"account\":\"chk\"}"}},
+				{"id":"call_2","type":"function","function":{"name":"transfer_funds","arguments":"{\"amount\":900}"}},
+				{"id":"call_3","type":"function","function":{"name":"send_email","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`,
+			tools:   []string{"get_balance", "transfer_funds", "send_email"},
+			callIDs: []string{"call_1", "call_2", "call_3"},
+		},
+		{
+			name: "openai/trailing tool results",
+			payload: `{"model":"gpt-4o","messages":[{"role":"user","content":"go"},
+				{"role":"assistant","tool_calls":[{"id":"call_a","type":"function","function":{"name":"lookup","arguments":"{}"}},{"id":"call_b","type":"function","function":{"name":"charge","arguments":"{}"}}]},
+				{"role":"tool","tool_call_id":"call_a","content":"ok"},{"role":"tool","tool_call_id":"call_b","content":"ok"}]}`,
+			tools:   []string{"lookup", "charge"},
+			callIDs: []string{"call_a", "call_b"},
+		},
+		{
+			name: "anthropic/parallel tool_use",
+			payload: `{"id":"msg_1","type":"message","role":"assistant","content":[
+				{"type":"tool_use","id":"toolu_a","name":"read_file","input":{"path":"a"}},
+				{"type":"tool_use","id":"toolu_b","name":"delete_file","input":{"path":"b"}}],"stop_reason":"tool_use"}`,
+			tools:   []string{"read_file", "delete_file"},
+			callIDs: []string{"toolu_a", "toolu_b"},
+		},
+		{
+			name: "gemini/parallel functionCall without ids",
+			payload: `{"candidates":[{"content":{"role":"model","parts":[
+				{"functionCall":{"name":"dim_lights","args":{"level":1}}},
+				{"functionCall":{"name":"dim_lights","args":{"level":2}}}]}}]}`,
+			tools: []string{"dim_lights", "dim_lights"},
+		},
+		{
+			name: "a2a/multi-part task",
+			payload: `{"jsonrpc":"2.0","id":"r1","method":"tasks/send","params":{"id":"task-9","message":{"role":"user","parts":[
+				{"type":"text","text":"Reconcile these"},{"type":"data","data":{"invoice":"INV-1"}},{"type":"file","file":{"name":"a.csv","mimeType":"text/csv"}}]}}}`,
+			tools:   []string{"agent_task", "agent_task", "agent_task"},
+			callIDs: []string{"task-9#0", "task-9#1", "task-9#2"},
+		},
+		{
+			name: "mcp/batch",
+			payload: `[{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"query_db","arguments":{}}},
+				{"jsonrpc":"2.0","id":"b","method":"tools/call","params":{"name":"drop_table","arguments":{"t":"x"}}}]`,
+			tools:   []string{"query_db", "drop_table"},
+			callIDs: []string{"1", "b"},
+		},
+		{
+			name:    "single call keeps one payload",
+			payload: `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"query_db","arguments":{}}}`,
+			tools:   []string{"query_db"},
+			callIDs: []string{"5"},
+		},
+	}
+
+	parser := NewUniversalAIParser()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := parser.ParseAll([]byte(tc.payload))
+			if len(calls) != len(tc.tools) {
+				t.Fatalf("got %d calls, want %d", len(calls), len(tc.tools))
+			}
+
+			seen := make(map[string]bool)
+			for i, call := range calls {
+				if call.ToolName != tc.tools[i] {
+					t.Errorf("call %d ToolName = %q, want %q", i, call.ToolName, tc.tools[i])
+				}
+				if call.CallID == "" || seen[call.CallID] {
+					t.Errorf("call %d CallID %q is empty or duplicated", i, call.CallID)
+				}
+				seen[call.CallID] = true
+				if tc.callIDs != nil && call.CallID != tc.callIDs[i] {
+					t.Errorf("call %d CallID = %q, want %q", i, call.CallID, tc.callIDs[i])
+				}
+				if call.Metadata["call_index"] != i || call.Metadata["call_count"] != len(calls) {
+					t.Errorf("call %d position metadata = %v/%v", i, call.Metadata["call_index"], call.Metadata["call_count"])
+				}
+			}
+
+			// IDs must be reproducible across parses of the same message
+			again := parser.ParseAll([]byte(tc.payload))
+			for i := range calls {
+				if again[i].CallID != calls[i].CallID {
+					t.Errorf("call %d CallID not stable: %q vs %q", i, calls[i].CallID, again[i].CallID)
+				}
+			}
+		})
+	}
+}
+
+func TestToolCallsKeepsEveryInvocation(t *testing.T) {
+	// A harmless first call must not hide a dangerous second one
+	payloads := NewUniversalAIParser().ParseAll([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[
+		{"type":"text","text":"Checking first"},
+		{"type":"tool_use","id":"toolu_a","name":"read_file","input":{"path":"a"}},
+		{"type":"tool_use","id":"toolu_b","name":"delete_data","input":{"table":"users"}}],"stop_reason":"tool_use"}`))
+	calls := ToolCalls(append(payloads, rawPayload()))
+	if len(calls) != 2 || calls[0].ToolName != "read_file" || calls[1].ToolName != "delete_data" {
+		names := make([]string, len(calls))
+		for i, c := range calls {
+			names[i] = c.ToolName
+		}
+		t.Fatalf("ToolCalls = %v, want [read_file delete_data]", names)
+	}
+}
+
+func TestUniversalAIParserArbitration(t *testing.T) {
+	cases := []struct {
+		name     string
+		payload  string
+		protocol AIProtocolType
+		tool     string
+		agent    string
+		wrapper  AIProtocolType // "" when the payload is not unwrapped
+		selected AIProtocolType // parser expected to be marked selected
+		minCands int
+	}{
+		{
+			name: "langchain envelope around openai tool_calls",
+			payload: `{"agent":"support-bot","config":{"run_name":"support_chain"},"input":{"id":"chatcmpl-3","object":"chat.completion","model":"gpt-4o",
+				"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_9","type":"function","function":{"name":"refund_order","arguments":"{\"order\":\"A1\"}"}}]},"finish_reason":"tool_calls"}]}}`,
+			protocol: ProtoOpenAI, tool: "refund_order", wrapper: ProtoLangChain, selected: ProtoOpenAI, minCands: 3,
+		},
+		{
+			name:     "autogen message carrying mcp json string",
+			payload:  `{"agent":"planner","sender":"planner","receiver":"executor","content":"{\"jsonrpc\":\"2.0\",\"id\":4,\"method\":\"tools/call\",\"params\":{\"name\":\"drop_table\",\"arguments\":{\"t\":\"users\"}}}"}`,
+			protocol: ProtoMCP, tool: "drop_table", agent: "planner", wrapper: ProtoAutoGen, selected: ProtoMCP, minCands: 2,
+		},
+		{
+			name:     "langchain without a recognisable inner payload stays langchain",
+			payload:  `{"agent":"a","input":"what is the refund policy?","config":{"run_name":"faq_chain"}}`,
+			protocol: ProtoLangChain, tool: "faq_chain", selected: ProtoLangChain, minCands: 1,
+		},
+		{
+			name: "anthropic request wins over openai on the same envelope",
+			payload: `{"model":"claude-sonnet-4-20250514","max_tokens":512,"messages":[
+				{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_gone","content":"ok"}]}]}`,
+			protocol: ProtoAnthropic, tool: "unknown_tool", selected: ProtoAnthropic, minCands: 2,
+		},
+		{
+			name:     "generic detector loses to a specific parser",
+			payload:  `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0.2,"max_tokens":50,"top_p":1,"agent_id":"x"}`,
+			protocol: ProtoOpenAI, tool: "llm_completion", selected: ProtoOpenAI, minCands: 1,
+		},
+	}
+
+	parser := NewUniversalAIParser()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for mode, got := range map[string]*AIPayload{
+				"Parse":    parser.Parse([]byte(tc.payload)),
+				"ParseAll": parser.ParseAll([]byte(tc.payload))[0],
+			} {
+				if got.Protocol != tc.protocol || got.ToolName != tc.tool {
+					t.Fatalf("%s: got %s/%q, want %s/%q", mode, got.Protocol, got.ToolName, tc.protocol, tc.tool)
+				}
+				if got.AgentID != tc.agent {
+					t.Errorf("%s: AgentID = %q, want %q", mode, got.AgentID, tc.agent)
+				}
+				if w, _ := got.Metadata["wrapper_protocol"].(string); AIProtocolType(w) != tc.wrapper {
+					t.Errorf("%s: wrapper_protocol = %q, want %q", mode, w, tc.wrapper)
+				}
+
+				cands, _ := got.Metadata["parse_candidates"].([]ParseCandidate)
+				if len(cands) < tc.minCands {
+					t.Fatalf("%s: %d candidates, want >= %d: %+v", mode, len(cands), tc.minCands, cands)
+				}
+				selected := 0
+				for _, c := range cands {
+					if c.Selected {
+						selected++
+						if c.Protocol != tc.selected || c.ToolName != tc.tool {
+							t.Errorf("%s: selected candidate = %+v", mode, c)
+						}
+						if tc.wrapper != "" && (c.Depth != 1 || c.Field == "") {
+							t.Errorf("%s: unwrapped candidate missing depth/field: %+v", mode, c)
+						}
+					}
+				}
+				if selected != 1 {
+					t.Errorf("%s: %d selected candidates, want 1", mode, selected)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkUniversalAIParserParse parses the whole corpus once per op.
+// Every matching parser runs on each payload, so watch this when adding a
+// parser: the inline paths (socket gateway, probe) pay for it per message.
+func BenchmarkUniversalAIParserParse(b *testing.B) {
+	parser := NewUniversalAIParser()
+	payloads := make([][]byte, len(aiParserCorpus))
+	for i, tc := range aiParserCorpus {
+		payloads[i] = []byte(tc.payload)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range payloads {
+			parser.Parse(p)
+		}
+	}
+}
+
+func BenchmarkUniversalAIParserParseAllWrapped(b *testing.B) {
+	parser := NewUniversalAIParser()
+	payload := []byte(`{"agent":"support-bot","config":{"run_name":"support_chain"},"input":{"model":"gpt-4o","choices":[{"index":0,
+		"message":{"role":"assistant","tool_calls":[{"id":"call_9","type":"function","function":{"name":"refund_order","arguments":"{\"order\":\"A1\"}"}}]},"finish_reason":"tool_calls"}]}}`)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser.ParseAll(payload)
+	}
+}
@@ -1,6 +1,10 @@
 package protocol
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
 	"time"
 )
 
@@ -10,6 +14,8 @@ type AIProtocolType string
 const (
 	ProtoMCP       AIProtocolType = "MCP"          // Model Context Protocol (Anthropic)
 	ProtoOpenAI    AIProtocolType = "OPENAI"       // OpenAI function calling / tool_calls
+	ProtoAnthropic AIProtocolType = "ANTHROPIC"    // Anthropic Messages API tool_use / tool_result
+	ProtoGemini    AIProtocolType = "GEMINI"       // Google Gemini functionCall / functionResponse
 	ProtoA2A       AIProtocolType = "A2A"          // Google Agent-to-Agent
 	ProtoLangChain AIProtocolType = "LANGCHAIN"    // LangChain / LlamaIndex agent
 	ProtoRAG       AIProtocolType = "RAG"          // RAG retrieval + generation
@@ -58,6 +64,12 @@ type AIPayload struct {
 	// identified the protocol
 	Confidence float64 `json:"confidence"`
 
+	// CallID identifies this invocation within its message. It is the
+	// protocol's own ID where one exists (OpenAI tool_call id, Anthropic
+	// tool_use id, JSON-RPC id) and a stable content hash otherwise, so one
+	// message with several calls yields distinct, reproducible IDs.
+	CallID string `json:"call_id,omitempty"`
+
 	// RawMethod is the original method/action before normalization
 	// MCP: "tools/call", OpenAI: "chat.completions", A2A: "tasks/send"
 	RawMethod string `json:"raw_method,omitempty"`
@@ -82,8 +94,18 @@ type AIPayloadParser interface {
 	Parse(payload []byte) (*AIPayload, error)
 }
 
-// UniversalAIParser attempts all registered parsers in priority order
-// and returns the first successful parse result
+// MultiCallParser is implemented by parsers whose payloads can carry several
+// invocations: parallel OpenAI tool_calls, multiple Anthropic tool_use
+// blocks, Gemini functionCall parts, A2A message parts, JSON-RPC batches.
+// ParseAll returns one AIPayload per invocation in payload order; Parse
+// keeps returning only the primary (first) one.
+type MultiCallParser interface {
+	ParseAll(payload []byte) ([]*AIPayload, error)
+}
+
+// UniversalAIParser runs every registered parser that recognises a payload
+// and keeps the best-scoring result (see arbitrate); registration order
+// only breaks ties
 type UniversalAIParser struct {
 	parsers []AIPayloadParser
 }
@@ -93,6 +115,8 @@ func NewUniversalAIParser() *UniversalAIParser {
 	return &UniversalAIParser{
 		parsers: []AIPayloadParser{
 			&MCPParser{},
+			&AnthropicParser{}, // Before OpenAI: Messages API requests also carry "model" + "messages"
+			&GeminiParser{},
 			&OpenAIParser{},
 			&A2AParser{},
 			&AgentFrameworkParser{},
@@ -102,18 +126,45 @@ func NewUniversalAIParser() *UniversalAIParser {
 	}
 }
 
-// Parse tries all registered parsers and returns the best match
+// Parse arbitrates between all matching parsers and returns the best match.
+// Metadata["parse_candidates"] lists every parser that was tried.
 func (u *UniversalAIParser) Parse(payload []byte) *AIPayload {
-	for _, parser := range u.parsers {
-		if parser.CanParse(payload) {
-			result, err := parser.Parse(payload)
-			if err == nil && result != nil {
-				return result
-			}
+	a := u.arbitrate(payload, false, 0)
+	if a.results == nil {
+		// Fallback — raw bytes, no AI protocol detected
+		return rawPayload()
+	}
+	recordCandidates(a.results, a)
+	return a.results[0]
+}
+
+// ParseAll is like Parse but returns every invocation in the payload, each
+// with a CallID. Parsers without multi-call support yield one payload.
+func (u *UniversalAIParser) ParseAll(payload []byte) []*AIPayload {
+	a := u.arbitrate(payload, true, 0)
+	results := a.results
+	if results == nil {
+		results = []*AIPayload{rawPayload()}
+	} else {
+		recordCandidates(results, a)
+	}
+	AssignCallIDs(results)
+	return results
+}
+
+// ToolCalls returns the tool invocations among payloads, in order. Every one
+// of them needs governing: a message can carry several.
+func ToolCalls(payloads []*AIPayload) []*AIPayload {
+	var calls []*AIPayload
+	for _, p := range payloads {
+		if p.MessageType == "tool_call" {
+			calls = append(calls, p)
 		}
 	}
+	return calls
+}
 
-	// Fallback — raw bytes, no AI protocol detected
+func rawPayload() *AIPayload {
 	return &AIPayload{
 		Protocol:    ProtoRaw,
 		ToolName:    "network_call",
@@ -124,6 +175,32 @@ func (u *UniversalAIParser) Parse(payload []byte) *AIPayload {
 	}
 }
 
+// AssignCallIDs gives every payload without a protocol-native ID a stable
+// one and records its position in the message (call_index / call_count).
+func AssignCallIDs(payloads []*AIPayload) {
+	for i, p := range payloads {
+		if p.CallID == "" {
+			p.CallID = StableCallID(p, i)
+		}
+		if p.Metadata == nil {
+			p.Metadata = make(map[string]interface{})
+		}
+		p.Metadata["call_index"] = i
+		p.Metadata["call_count"] = len(payloads)
+	}
+}
+
+// StableCallID derives a call ID from the invocation's content and position,
+// so retries of the same message produce the same IDs.
+func StableCallID(p *AIPayload, index int) string {
+	h := sha256.New()
+	h.Write([]byte(string(p.Protocol) + "\x00" + p.TaskID + "\x00" + p.ToolName + "\x00" + strconv.Itoa(index) + "\x00"))
+	if args, err := json.Marshal(p.Arguments); err == nil { // map keys marshal sorted
+		h.Write(args)
+	}
+	return "call-" + hex.EncodeToString(h.Sum(nil)[:8])
+}
+
 // RegisterParser adds a custom parser (for custom agent frameworks)
 func (u *UniversalAIParser) RegisterParser(p AIPayloadParser) {
 	// Insert before GenericAIDetector (last resort)
@@ -0,0 +1,313 @@
+package protocol
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AnthropicParser handles Anthropic Messages API payloads:
+//   - Requests to POST /v1/messages with "tools" (input_schema) and
+//     tool_use / tool_result content blocks in the conversation
+//   - Responses with "type":"message", "stop_reason" and tool_use blocks,
+//     including several parallel tool_use blocks in one turn
+//   - The same schema proxied through Bedrock / Vertex ("anthropic_version")
+//
+// Unlike OpenAI tool messages, a tool_result block carries only the
+// tool_use_id; the tool name is recovered from the matching tool_use block
+// earlier in the conversation.
+type AnthropicParser struct{}
+
+type anthropicRequest struct {
+	Model            string             `json:"model,omitempty"`
+	AnthropicVersion string             `json:"anthropic_version,omitempty"`
+	System           json.RawMessage    `json:"system,omitempty"`
+	Messages         []anthropicMessage `json:"messages"`
+	Tools            []anthropicToolDef `json:"tools,omitempty"`
+	ToolChoice       json.RawMessage    `json:"tool_choice,omitempty"`
+	MaxTokens        int                `json:"max_tokens,omitempty"`
+	Stream           bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"` // string or []anthropicBlock
+}
+
+type anthropicBlock struct {
+	Type string `json:"type"`
+
+	// tool_use
+	ID    string                 `json:"id,omitempty"`
+	Name  string                 `json:"name,omitempty"`
+	Input map[string]interface{} `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+
+	// text
+	Text string `json:"text,omitempty"`
+}
+
+type anthropicToolDef struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema,omitempty"`
+	Type        string          `json:"type,omitempty"` // server tools, e.g. "web_search_20250305"
+}
+
+type anthropicResponse struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Role       string           `json:"role"`
+	Model      string           `json:"model"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+}
+
+func (p *AnthropicParser) Name() AIProtocolType { return ProtoAnthropic }
+
+func (p *AnthropicParser) CanParse(payload []byte) bool {
+	s := payload
+	return containsField(s, `"tool_use"`) ||
+		containsField(s, `"tool_result"`) ||
+		containsField(s, `"input_schema"`) ||
+		containsField(s, `"anthropic_version"`) ||
+		(containsField(s, `"stop_reason"`) && containsField(s, `"content"`))
+}
+
+func (p *AnthropicParser) Parse(payload []byte) (*AIPayload, error) {
+	jsonStart := findJSONStart(payload)
+	if jsonStart < 0 {
+		return nil, errNotJSON
+	}
+	data := payload[jsonStart:]
+
+	result := &AIPayload{
+		Protocol:   ProtoAnthropic,
+		Direction:  "request",
+		DetectedAt: time.Now(),
+		Metadata:   make(map[string]interface{}),
+	}
+
+	// Response: {"type":"message","role":"assistant","content":[...],"stop_reason":...}
+	var resp anthropicResponse
+	if err := json.Unmarshal(data, &resp); err == nil && resp.Type == "message" && resp.Role == "assistant" {
+		result.Direction = "response"
+		result.Model = resp.Model
+		result.TaskID = resp.ID
+		result.RawMethod = "messages"
+		result.Metadata["stop_reason"] = resp.StopReason
+
+		if uses := anthropicToolUses(resp.Content); len(uses) > 0 {
+			setAnthropicToolCall(result, uses)
+			result.Confidence = 0.97
+			return result, nil
+		}
+
+		result.ToolName = "llm_completion"
+		result.MessageType = "generation"
+		result.Confidence = 0.93
+		return result, nil
+	}
+
+	// Request: {"model":...,"messages":[...],"tools":[...]}
+	var req anthropicRequest
+	if err := json.Unmarshal(data, &req); err != nil || len(req.Messages) == 0 {
+		return nil, errNotJSON
+	}
+
+	// Decode content blocks once; string content has no blocks
+	blocks := make([][]anthropicBlock, len(req.Messages))
+	hasBlocks := false
+	for i, msg := range req.Messages {
+		blocks[i] = anthropicBlocks(msg.Content)
+		for _, b := range blocks[i] {
+			if b.Type == "tool_use" || b.Type == "tool_result" {
+				hasBlocks = true
+			}
+		}
+	}
+
+	hasSchemaTools := false
+	for _, t := range req.Tools {
+		if (len(t.InputSchema) > 0 && string(t.InputSchema) != "null") || t.Type != "" {
+			hasSchemaTools = true
+		}
+	}
+
+	// Only claim requests with Anthropic-specific structure; plain chat
+	// payloads are left to the OpenAI-compatible parser.
+	if !hasBlocks && !hasSchemaTools && req.AnthropicVersion == "" {
+		return nil, errNotJSON
+	}
+
+	result.Model = req.Model
+	result.RawMethod = "messages"
+	if req.AnthropicVersion != "" {
+		result.Metadata["anthropic_version"] = req.AnthropicVersion
+	}
+	if len(req.Tools) > 0 {
+		toolNames := make([]string, 0, len(req.Tools))
+		for _, t := range req.Tools {
+			toolNames = append(toolNames, t.Name)
+		}
+		result.Metadata["available_tools"] = toolNames
+		result.Metadata["tool_count"] = len(req.Tools)
+	}
+
+	// The latest message decides what this request is doing
+	last := len(req.Messages) - 1
+	if req.Messages[last].Role == "user" {
+		var results []anthropicBlock
+		for _, b := range blocks[last] {
+			if b.Type == "tool_result" {
+				results = append(results, b)
+			}
+		}
+		if len(results) > 0 {
+			names := anthropicToolNamesByID(blocks[:last])
+			first := results[0]
+
+			result.ToolName = names[first.ToolUseID]
+			result.MessageType = "tool_result"
+			result.Confidence = 0.95
+			result.Metadata["tool_use_id"] = first.ToolUseID
+			result.Metadata["is_error"] = first.IsError
+			result.Metadata["total_tool_results"] = len(results)
+			if len(results) > 1 {
+				ids := make([]string, len(results))
+				for i, r := range results {
+					ids[i] = r.ToolUseID
+				}
+				result.Metadata["tool_use_ids"] = ids
+			}
+			if result.ToolName == "" {
+				// Conversation was truncated before the tool_use block
+				result.ToolName = "unknown_tool"
+				result.Confidence = 0.85
+			}
+			return result, nil
+		}
+	}
+
+	result.ToolName = "llm_completion"
+	result.MessageType = "generation"
+	result.Confidence = 0.92
+	return result, nil
+}
+
+// anthropicBlocks decodes message content, which may be a plain string.
+func anthropicBlocks(content json.RawMessage) []anthropicBlock {
+	if len(content) == 0 || content[0] != '[' {
+		return nil
+	}
+	var blocks []anthropicBlock
+	if json.Unmarshal(content, &blocks) != nil {
+		return nil
+	}
+	return blocks
+}
+
+func anthropicToolUses(blocks []anthropicBlock) []anthropicBlock {
+	var uses []anthropicBlock
+	for _, b := range blocks {
+		if b.Type == "tool_use" || b.Type == "server_tool_use" {
+			uses = append(uses, b)
+		}
+	}
+	return uses
+}
+
+// anthropicToolNamesByID maps tool_use IDs to tool names across messages.
+func anthropicToolNamesByID(messages [][]anthropicBlock) map[string]string {
+	names := make(map[string]string)
+	for _, blocks := range messages {
+		for _, b := range anthropicToolUses(blocks) {
+			names[b.ID] = b.Name
+		}
+	}
+	return names
+}
+
+// setAnthropicToolCall fills result from one or more tool_use blocks; the
+// first is the primary call, like OpenAI's tool_calls[0].
+func setAnthropicToolCall(result *AIPayload, uses []anthropicBlock) {
+	primary := uses[0]
+	result.ToolName = primary.Name
+	result.MessageType = "tool_call"
+	result.Arguments = primary.Input
+	result.Metadata["tool_use_id"] = primary.ID
+	result.Metadata["total_tool_calls"] = len(uses)
+	if len(uses) > 1 {
+		names := make([]string, len(uses))
+		for i, u := range uses {
+			names[i] = u.Name
+		}
+		result.Metadata["tool_names"] = names
+	}
+}
+
+// ParseAll returns every tool_use block of a response, or every tool_result
+// block in the last user message of a request.
+func (p *AnthropicParser) ParseAll(payload []byte) ([]*AIPayload, error) {
+	primary, err := p.Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	data := payload[findJSONStart(payload):]
+
+	switch {
+	case primary.MessageType == "tool_call":
+		var resp anthropicResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, err
+		}
+		uses := anthropicToolUses(resp.Content)
+		calls := make([]*AIPayload, 0, len(uses))
+		for _, u := range uses {
+			call := clonePayload(primary)
+			call.ToolName = u.Name
+			call.Arguments = u.Input
+			call.CallID = u.ID
+			call.Metadata["tool_use_id"] = u.ID
+			delete(call.Metadata, "tool_names")
+			calls = append(calls, call)
+		}
+		return calls, nil
+
+	case primary.MessageType == "tool_result":
+		var req anthropicRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, err
+		}
+		blocks := make([][]anthropicBlock, len(req.Messages))
+		for i, msg := range req.Messages {
+			blocks[i] = anthropicBlocks(msg.Content)
+		}
+		last := len(blocks) - 1
+		names := anthropicToolNamesByID(blocks[:last])
+
+		var results []*AIPayload
+		for _, b := range blocks[last] {
+			if b.Type != "tool_result" {
+				continue
+			}
+			result := clonePayload(primary)
+			result.ToolName = names[b.ToolUseID]
+			result.Confidence = 0.95
+			if result.ToolName == "" {
+				result.ToolName = "unknown_tool"
+				result.Confidence = 0.85
+			}
+			result.CallID = b.ToolUseID
+			result.Metadata["tool_use_id"] = b.ToolUseID
+			result.Metadata["is_error"] = b.IsError
+			delete(result.Metadata, "tool_use_ids")
+			results = append(results, result)
+		}
+		return results, nil
+	}
+	return []*AIPayload{primary}, nil
+}
@@ -0,0 +1,267 @@
+package protocol
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+// ============================================================================
+// PROTOCOL ARBITRATION - score every candidate parser instead of first match
+// ============================================================================
+//
+// Parser order used to decide the protocol: the first parser whose CanParse
+// matched and whose Parse succeeded won, so a LangChain envelope carrying an
+// OpenAI request became "LANGCHAIN chain_invoke" and the tool call inside
+// was never governed. Arbitration runs every matching parser, scores each
+// result by Confidence plus field completeness, and unwraps framework
+// envelopes whose inner payload a protocol-specific parser recognises.
+// Registration order only breaks exact ties.
+
+// ParseCandidate is one parser's result during arbitration, recorded in the
+// winner's Metadata["parse_candidates"] for debugging misclassifications.
+type ParseCandidate struct {
+	Parser     AIProtocolType `json:"parser"`
+	Protocol   AIProtocolType `json:"protocol,omitempty"`
+	ToolName   string         `json:"tool_name,omitempty"`
+	Confidence float64        `json:"confidence"`
+	Score      float64        `json:"score"`
+	Field      string         `json:"field,omitempty"` // envelope field for unwrapped candidates
+	Depth      int            `json:"depth,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Selected   bool           `json:"selected,omitempty"`
+}
+
+const (
+	// completenessWeight is the score each populated field adds on top of
+	// Confidence. Six fields cap the bonus at 0.12, enough to separate
+	// parsers that agree on confidence but not to outvote a clear signal.
+	completenessWeight = 0.02
+
+	// maxUnwrapDepth bounds how many framework envelopes are peeled.
+	maxUnwrapDepth = 2
+)
+
+// placeholderToolNames are what parsers report when the payload names no
+// tool; they do not count towards completeness.
+var placeholderToolNames = map[string]bool{
+	"":               true,
+	"llm_completion": true,
+	"ai_operation":   true,
+	"agent_action":   true,
+	"agent_message":  true,
+	"chain_invoke":   true,
+	"pipeline_run":   true,
+	"unknown_tool":   true,
+	"network_call":   true,
+}
+
+// wrapperProtocols carry other protocols' payloads in an envelope field.
+var wrapperProtocols = map[AIProtocolType]bool{
+	ProtoLangChain: true,
+	ProtoCrewAI:    true,
+	ProtoAutoGen:   true,
+	ProtoCustom:    true,
+}
+
+// envelopeFields are where agent frameworks put the wrapped request.
+var envelopeFields = []string{"input", "inputs", "payload", "body", "request", "data", "message", "content", "kwargs"}
+
+// scorePayload ranks a parse result: Confidence plus a bonus per field the
+// parser managed to populate.
+func scorePayload(p *AIPayload) float64 {
+	score := p.Confidence
+	if !placeholderToolNames[p.ToolName] {
+		score += completenessWeight
+	}
+	if len(p.Arguments) > 0 {
+		score += completenessWeight
+	}
+	if p.AgentID != "" {
+		score += completenessWeight
+	}
+	if p.Model != "" {
+		score += completenessWeight
+	}
+	if p.CallID != "" || p.TaskID != "" {
+		score += completenessWeight
+	}
+	if p.MessageType != "" && p.MessageType != "unknown" {
+		score += completenessWeight
+	}
+	return score
+}
+
+// arbitration is the outcome of scoring every parser on one payload.
+type arbitration struct {
+	results    []*AIPayload // winner's payloads; nil when nothing parsed
+	score      float64
+	fallback   bool // winner is the last-resort detector
+	selected   int  // winner's index in candidates
+	candidates []ParseCandidate
+}
+
+// arbitrate runs every parser whose CanParse matches. With all set,
+// multi-call parsers return every invocation. The last-resort detector can
+// only win when no specific parser produced a result, so it is not run
+// otherwise (it is a third of the cost of a full pass).
+func (u *UniversalAIParser) arbitrate(payload []byte, all bool, depth int) arbitration {
+	best := arbitration{selected: -1}
+	for _, parser := range u.parsers {
+		_, fallback := parser.(*GenericAIDetector)
+		if (fallback && best.results != nil) || !parser.CanParse(payload) {
+			continue
+		}
+		var results []*AIPayload
+		var err error
+		if mp, ok := parser.(MultiCallParser); ok && all {
+			results, err = mp.ParseAll(payload)
+		} else {
+			var result *AIPayload
+			if result, err = parser.Parse(payload); result != nil {
+				results = []*AIPayload{result}
+			}
+		}
+
+		c := ParseCandidate{Parser: parser.Name(), Depth: depth}
+		if err != nil || len(results) == 0 {
+			if err != nil {
+				c.Error = err.Error()
+			}
+			best.candidates = append(best.candidates, c)
+			continue
+		}
+		primary := results[0]
+		c.Protocol, c.ToolName, c.Confidence = primary.Protocol, primary.ToolName, primary.Confidence
+		score := scorePayload(primary)
+		c.Score = math.Round(score*1000) / 1000
+		best.candidates = append(best.candidates, c)
+
+		switch {
+		case best.results == nil,
+			best.fallback && !fallback,
+			fallback == best.fallback && score > best.score:
+			best.results, best.score, best.fallback = results, score, fallback
+			best.selected = len(best.candidates) - 1
+		}
+	}
+
+	if best.results != nil && wrapperProtocols[best.results[0].Protocol] && depth < maxUnwrapDepth {
+		best = u.unwrap(payload, best, all, depth)
+	}
+	return best
+}
+
+// unwrap looks inside a framework envelope for a payload a specific parser
+// recognises. The inner result replaces the wrapper's, inheriting identity
+// fields the inner protocol does not carry.
+func (u *UniversalAIParser) unwrap(payload []byte, outer arbitration, all bool, depth int) arbitration {
+	start := findJSONStart(payload)
+	if start < 0 || payload[start] != '{' {
+		return outer
+	}
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal(payload[start:], &envelope) != nil {
+		return outer
+	}
+
+	for _, field := range envelopeFields {
+		raw, ok := envelope[field]
+		if !ok {
+			continue
+		}
+		inner := envelopeBody(raw)
+		if inner == nil {
+			continue
+		}
+		nested := u.arbitrate(inner, all, depth+1)
+		offset := len(outer.candidates)
+		for _, c := range nested.candidates {
+			c.Field = field
+			outer.candidates = append(outer.candidates, c)
+		}
+		if nested.results == nil || nested.fallback || wrapperProtocols[nested.results[0].Protocol] {
+			continue
+		}
+
+		wrapper := outer.results[0]
+		for _, r := range nested.results {
+			if r.Metadata == nil {
+				r.Metadata = make(map[string]interface{})
+			}
+			if _, ok := r.Metadata["wrapper_protocol"]; !ok {
+				r.Metadata["wrapper_protocol"] = string(wrapper.Protocol)
+				r.Metadata["wrapper_tool"] = wrapper.ToolName
+				r.Metadata["wrapper_field"] = field
+			}
+			if r.AgentID == "" {
+				r.AgentID = wrapper.AgentID
+			}
+			if r.TenantID == "" {
+				r.TenantID = wrapper.TenantID
+			}
+			if r.TaskID == "" {
+				r.TaskID = wrapper.TaskID
+			}
+		}
+		outer.results, outer.score, outer.fallback = nested.results, nested.score, false
+		outer.selected = offset + nested.selected
+		return outer
+	}
+	return outer
+}
+
+// envelopeBody returns an envelope field's value as a payload to parse:
+// objects and arrays as-is, strings only when they hold JSON.
+func envelopeBody(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	switch raw[0] {
+	case '{', '[':
+		return raw
+	case '"':
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return nil
+		}
+		s = strings.TrimSpace(s)
+		if len(s) > 1 && (s[0] == '{' || s[0] == '[') {
+			return []byte(s)
+		}
+	}
+	return nil
+}
+
+// recordCandidates attaches the arbitration trail to every result.
+func recordCandidates(results []*AIPayload, a arbitration) {
+	if a.selected >= 0 {
+		a.candidates[a.selected].Selected = true
+	}
+	for _, r := range results {
+		if r.Metadata == nil {
+			r.Metadata = make(map[string]interface{})
+		}
+		r.Metadata["parse_candidates"] = a.candidates
+	}
+
+	outcome := "single"
+	parsed, first := 0, -1
+	for i, c := range a.candidates {
+		if c.Depth == 0 && c.Protocol != "" {
+			parsed++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	switch {
+	case a.selected >= 0 && a.candidates[a.selected].Depth > 0:
+		outcome = "unwrapped"
+	case parsed > 1 && a.selected != first:
+		outcome = "rescored"
+	case parsed > 1:
+		outcome = "first_match"
+	}
+	parseArbitrations.WithLabelValues(outcome).Inc()
+}
@@ -0,0 +1,395 @@
+package protocol
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ============================================================================
+// FRAME PAYLOAD CODEC (compression + AEAD)
+// ============================================================================
+//
+// Frame.Marshal/Unmarshal treat the payload as opaque bytes. A FrameCodec
+// sits between the application payload and the wire:
+//
+//	Encode: plaintext → compress (FlagCompressed) → ChaCha20-Poly1305 (FlagEncrypted)
+//	Decode: reverse, after Frame.VerifyChecksum
+//
+// The algorithms are negotiated once per session (NegotiateCodec). The AEAD
+// keys come from DeriveFrameKeys over a secret both peers already share
+// without sending it — one key per direction, so a frame reflected back to
+// its sender never authenticates. The frame transport derives that secret
+// from an ephemeral key exchange in the session handshake (mixed with TLS
+// exported keying material when the connection has it), so payload
+// encryption is available on plaintext connections too.
+//
+// Encrypted payloads are laid out as
+// nonce(12) || ciphertext || tag(16), and the header bytes preceding the
+// checksum — including SessionID, SequenceNum, flags and addressing — are
+// authenticated as associated data. Because SequenceNum is authenticated, the
+// codec can reject replays with a sliding window over sequence numbers.
+
+// CompressionAlgo identifies a payload compression algorithm.
+type CompressionAlgo string
+
+const (
+	CompressionNone   CompressionAlgo = "none"
+	CompressionZstd   CompressionAlgo = "zstd"
+	CompressionSnappy CompressionAlgo = "snappy"
+)
+
+// CipherSuite identifies a payload AEAD.
+type CipherSuite string
+
+const (
+	CipherNone             CipherSuite = "none"
+	CipherChaCha20Poly1305 CipherSuite = "chacha20-poly1305"
+)
+
+const (
+	// compressMinSize skips compression for payloads too small to benefit
+	compressMinSize = 64
+
+	// MaxDecodedPayloadSize bounds decompressed payloads (decompression bombs)
+	MaxDecodedPayloadSize = 16 * MaxPayloadSize
+
+	// replayWindowSize is how far behind the highest sequence a frame may arrive
+	replayWindowSize = 64
+
+	// HKDF info labels for AOCS frame payload keys, one per direction
+	FrameKeyInfoClientToServer = "ocx-aocs-frame-v1 client->server"
+	FrameKeyInfoServerToClient = "ocx-aocs-frame-v1 server->client"
+
+	frameKeySize = chacha20poly1305.KeySize
+)
+
+// Errors returned by FrameCodec.Decode
+var (
+	ErrReplayedFrame   = errors.New("replayed or stale frame sequence")
+	ErrFrameNotSealed  = errors.New("frame is not encrypted but session requires encryption")
+	ErrUnexpectedFlags = errors.New("frame flags not negotiated for this session")
+)
+
+// Shared zstd coders; EncodeAll/DecodeAll are safe for concurrent use.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecodedPayloadSize))
+)
+
+// supportedCompression and supportedCiphers list what this build implements,
+// in server preference order.
+var (
+	supportedCompression = []CompressionAlgo{CompressionZstd, CompressionSnappy}
+	supportedCiphers     = []CipherSuite{CipherChaCha20Poly1305}
+)
+
+// CodecOffer is what a client advertises in its handshake, in preference order.
+type CodecOffer struct {
+	Compression []CompressionAlgo `json:"compression,omitempty"`
+	Encryption  []CipherSuite     `json:"encryption,omitempty"`
+}
+
+// CodecParams is the negotiated result, echoed back to the client.
+type CodecParams struct {
+	Compression CompressionAlgo `json:"compression"`
+	Encryption  CipherSuite     `json:"encryption"`
+}
+
+// NegotiateCodec picks the client's most preferred algorithms that this build
+// supports. Encryption is only selected when allowEncryption is true, i.e.
+// when the caller is able to derive a shared session key.
+func NegotiateCodec(offer CodecOffer, allowEncryption bool) CodecParams {
+	params := CodecParams{Compression: CompressionNone, Encryption: CipherNone}
+
+	for _, c := range offer.Compression {
+		if containsAlgo(supportedCompression, c) {
+			params.Compression = c
+			break
+		}
+	}
+	if allowEncryption {
+		for _, c := range offer.Encryption {
+			if containsAlgo(supportedCiphers, c) {
+				params.Encryption = c
+				break
+			}
+		}
+	}
+	return params
+}
+
+func containsAlgo[T comparable](list []T, v T) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// DeriveFrameKeys expands secret with HKDF-SHA256, salted with the session
+// ID, into one 32-byte key per direction. The client seals with
+// clientToServer and opens with serverToClient; the server the reverse.
+func DeriveFrameKeys(secret, sessionID []byte) (clientToServer, serverToClient []byte, err error) {
+	if len(secret) == 0 {
+		return nil, nil, errors.New("frame key secret cannot be empty")
+	}
+	expand := func(info string) ([]byte, error) {
+		key := make([]byte, frameKeySize)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, secret, sessionID, []byte(info)), key); err != nil {
+			return nil, fmt.Errorf("derive %s key: %w", info, err)
+		}
+		return key, nil
+	}
+	if clientToServer, err = expand(FrameKeyInfoClientToServer); err != nil {
+		return nil, nil, err
+	}
+	if serverToClient, err = expand(FrameKeyInfoServerToClient); err != nil {
+		return nil, nil, err
+	}
+	return clientToServer, serverToClient, nil
+}
+
+// FrameCodec applies a session's negotiated compression and encryption.
+// Encode and Decode may be called from different goroutines.
+type FrameCodec struct {
+	params CodecParams
+	sealer cipher.AEAD // outbound frames
+	opener cipher.AEAD // inbound frames
+
+	mu         sync.Mutex
+	replaySeen bool
+	replayTop  uint16 // highest sequence accepted
+	replayMask uint64 // bit i set: replayTop-i accepted
+}
+
+// NewFrameCodec builds a codec for negotiated params. sendKey seals outbound
+// frames and recvKey opens inbound ones; both are required when
+// params.Encryption is not CipherNone and must be 32 bytes, as returned by
+// DeriveFrameKeys.
+func NewFrameCodec(params CodecParams, sendKey, recvKey []byte) (*FrameCodec, error) {
+	if params.Compression == "" {
+		params.Compression = CompressionNone
+	}
+	if params.Encryption == "" {
+		params.Encryption = CipherNone
+	}
+
+	c := &FrameCodec{params: params}
+
+	switch params.Compression {
+	case CompressionNone, CompressionZstd, CompressionSnappy:
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", params.Compression)
+	}
+
+	switch params.Encryption {
+	case CipherNone:
+	case CipherChaCha20Poly1305:
+		sealer, err := chacha20poly1305.New(sendKey)
+		if err != nil {
+			return nil, fmt.Errorf("init %s send key: %w", params.Encryption, err)
+		}
+		opener, err := chacha20poly1305.New(recvKey)
+		if err != nil {
+			return nil, fmt.Errorf("init %s receive key: %w", params.Encryption, err)
+		}
+		c.sealer, c.opener = sealer, opener
+	default:
+		return nil, fmt.Errorf("unsupported encryption %q", params.Encryption)
+	}
+
+	return c, nil
+}
+
+// Params returns the negotiated codec parameters.
+func (c *FrameCodec) Params() CodecParams {
+	return c.params
+}
+
+// Encode compresses and encrypts f.Payload in place and sets the matching
+// flags. The header (notably SessionID and SequenceNum) must be final before
+// Encode is called; call Seal afterwards to set the checksum.
+func (c *FrameCodec) Encode(f *Frame) error {
+	f.Header.ClearFlag(FlagCompressed | FlagEncrypted)
+	payload := f.Payload
+
+	if c.params.Compression != CompressionNone && len(payload) >= compressMinSize {
+		compressed := c.compress(payload)
+		if len(compressed) < len(payload) {
+			payload = compressed
+			f.Header.SetFlag(FlagCompressed)
+		}
+	}
+
+	if c.sealer != nil {
+		sealedLen := c.sealer.NonceSize() + len(payload) + c.sealer.Overhead()
+		if sealedLen > MaxPayloadSize {
+			return fmt.Errorf("encrypted payload too large: %d bytes (max %d)", sealedLen, MaxPayloadSize)
+		}
+		f.Header.SetFlag(FlagEncrypted)
+		f.Header.PayloadLen = uint16(sealedLen)
+
+		aad, err := headerAAD(f.Header)
+		if err != nil {
+			return err
+		}
+		nonce := make([]byte, c.sealer.NonceSize(), sealedLen)
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("generate nonce: %w", err)
+		}
+		payload = c.sealer.Seal(nonce, nonce, payload, aad)
+	}
+
+	f.Payload = payload
+	return nil
+}
+
+// Decode authenticates, decrypts and decompresses f.Payload in place, and
+// clears FlagCompressed/FlagEncrypted. Frames whose sequence number was
+// already accepted, or is older than the replay window, are rejected with
+// ErrReplayedFrame. The checksum must be verified before Decode.
+func (c *FrameCodec) Decode(f *Frame) error {
+	payload := f.Payload
+
+	if f.Header.HasFlag(FlagCompressed) && c.params.Compression == CompressionNone {
+		return fmt.Errorf("%w: compressed", ErrUnexpectedFlags)
+	}
+
+	if c.opener != nil {
+		if !f.Header.HasFlag(FlagEncrypted) {
+			return ErrFrameNotSealed
+		}
+		if err := c.checkReplay(f.Header.SequenceNum, false); err != nil {
+			return err
+		}
+		if len(payload) < c.opener.NonceSize()+c.opener.Overhead() {
+			return fmt.Errorf("encrypted payload too short: %d bytes", len(payload))
+		}
+		aad, err := headerAAD(f.Header)
+		if err != nil {
+			return err
+		}
+		nonce, sealed := payload[:c.opener.NonceSize()], payload[c.opener.NonceSize():]
+		plain, err := c.opener.Open(nil, nonce, sealed, aad)
+		if err != nil {
+			return fmt.Errorf("frame authentication failed: %w", err)
+		}
+		// Only authenticated frames may advance the window
+		if err := c.checkReplay(f.Header.SequenceNum, true); err != nil {
+			return err
+		}
+		payload = plain
+	} else {
+		if f.Header.HasFlag(FlagEncrypted) {
+			return fmt.Errorf("%w: encrypted", ErrUnexpectedFlags)
+		}
+		if err := c.checkReplay(f.Header.SequenceNum, true); err != nil {
+			return err
+		}
+	}
+
+	if f.Header.HasFlag(FlagCompressed) {
+		plain, err := c.decompress(payload)
+		if err != nil {
+			return fmt.Errorf("decompress %s: %w", c.params.Compression, err)
+		}
+		payload = plain
+	}
+
+	f.Header.ClearFlag(FlagCompressed | FlagEncrypted)
+	f.Payload = payload
+	return nil
+}
+
+func (c *FrameCodec) compress(p []byte) []byte {
+	switch c.params.Compression {
+	case CompressionZstd:
+		return zstdEncoder.EncodeAll(p, nil)
+	case CompressionSnappy:
+		return snappy.Encode(nil, p)
+	default:
+		return p
+	}
+}
+
+func (c *FrameCodec) decompress(p []byte) ([]byte, error) {
+	switch c.params.Compression {
+	case CompressionZstd:
+		return zstdDecoder.DecodeAll(p, nil)
+	case CompressionSnappy:
+		n, err := snappy.DecodedLen(p)
+		if err != nil {
+			return nil, err
+		}
+		if n > MaxDecodedPayloadSize {
+			return nil, fmt.Errorf("decoded size %d exceeds %d", n, MaxDecodedPayloadSize)
+		}
+		return snappy.Decode(nil, p)
+	default:
+		return p, nil
+	}
+}
+
+// checkReplay tests seq against the sliding window, recording it when commit
+// is true. Sequence numbers use serial-number arithmetic so the window keeps
+// working across the uint16 wrap.
+func (c *FrameCodec) checkReplay(seq uint16, commit bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.replaySeen {
+		if commit {
+			c.replaySeen = true
+			c.replayTop = seq
+			c.replayMask = 1
+		}
+		return nil
+	}
+
+	diff := int16(seq - c.replayTop)
+	switch {
+	case diff > 0:
+		if commit {
+			if diff >= replayWindowSize {
+				c.replayMask = 0
+			} else {
+				c.replayMask <<= uint(diff)
+			}
+			c.replayMask |= 1
+			c.replayTop = seq
+		}
+		return nil
+	case -int(diff) >= replayWindowSize:
+		return fmt.Errorf("%w: seq=%d highest=%d", ErrReplayedFrame, seq, c.replayTop)
+	default:
+		bit := uint64(1) << uint(-diff)
+		if c.replayMask&bit != 0 {
+			return fmt.Errorf("%w: seq=%d", ErrReplayedFrame, seq)
+		}
+		if commit {
+			c.replayMask |= bit
+		}
+		return nil
+	}
+}
+
+// headerAAD returns the header bytes covered by the AEAD tag: everything but
+// the checksum, which is recomputed after encryption.
+func headerAAD(h *FrameHeader) ([]byte, error) {
+	b, err := h.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return b[:checksumOffset], nil
+}
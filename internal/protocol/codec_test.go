@@ -0,0 +1,182 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testKey() []byte {
+	return bytes.Repeat([]byte{0x42}, 32)
+}
+
+func encodeFrame(t *testing.T, c *FrameCodec, seq uint16, payload []byte) *Frame {
+	t.Helper()
+	f := NewFrame(FrameTypeMessage, append([]byte(nil), payload...))
+	f.Header.SessionID = [16]byte{1, 2, 3}
+	f.Header.SequenceNum = seq
+	if err := c.Encode(f); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := f.Seal(); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	return f
+}
+
+// roundTrip marshals and unmarshals f so Decode sees exactly the wire bytes.
+func roundTrip(t *testing.T, f *Frame) *Frame {
+	t.Helper()
+	data, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	out := &Frame{}
+	if err := out.Unmarshal(data); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := out.VerifyChecksum(); err != nil {
+		t.Fatalf("VerifyChecksum: %v", err)
+	}
+	return out
+}
+
+func TestFrameCodecRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"tool":"search","args":{"q":"ocx"}}`), 20)
+
+	for _, comp := range []CompressionAlgo{CompressionNone, CompressionZstd, CompressionSnappy} {
+		for _, enc := range []CipherSuite{CipherNone, CipherChaCha20Poly1305} {
+			t.Run(string(comp)+"/"+string(enc), func(t *testing.T) {
+				params := CodecParams{Compression: comp, Encryption: enc}
+				tx, err := NewFrameCodec(params, testKey(), testKey())
+				if err != nil {
+					t.Fatal(err)
+				}
+				rx, _ := NewFrameCodec(params, testKey(), testKey())
+
+				f := roundTrip(t, encodeFrame(t, tx, 7, payload))
+				if got := f.Header.HasFlag(FlagCompressed); got != (comp != CompressionNone) {
+					t.Errorf("FlagCompressed = %v", got)
+				}
+				if got := f.Header.HasFlag(FlagEncrypted); got != (enc != CipherNone) {
+					t.Errorf("FlagEncrypted = %v", got)
+				}
+				if err := rx.Decode(f); err != nil {
+					t.Fatalf("Decode: %v", err)
+				}
+				if !bytes.Equal(f.Payload, payload) {
+					t.Fatal("payload mismatch after round trip")
+				}
+				if f.Header.Flags&(FlagCompressed|FlagEncrypted) != 0 {
+					t.Error("codec flags not cleared after Decode")
+				}
+			})
+		}
+	}
+}
+
+func TestFrameCodecAuthenticatesHeader(t *testing.T) {
+	params := CodecParams{Compression: CompressionNone, Encryption: CipherChaCha20Poly1305}
+	tx, _ := NewFrameCodec(params, testKey(), testKey())
+	rx, _ := NewFrameCodec(params, testKey(), testKey())
+
+	f := encodeFrame(t, tx, 1, []byte("transfer 100 to acct-9"))
+	f.Header.DestAddr = AddressDigest("ocx://hub/t1/attacker")
+	if err := f.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rx.Decode(roundTrip(t, f)); err == nil {
+		t.Fatal("expected authentication failure for modified header")
+	}
+
+	wrongKey, _ := NewFrameCodec(params, bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x01}, 32))
+	if err := wrongKey.Decode(roundTrip(t, encodeFrame(t, tx, 2, []byte("x")))); err == nil {
+		t.Fatal("expected authentication failure for wrong key")
+	}
+
+	plain, _ := NewFrameCodec(CodecParams{}, nil, nil)
+	if err := rx.Decode(roundTrip(t, encodeFrame(t, plain, 3, []byte("x")))); !errors.Is(err, ErrFrameNotSealed) {
+		t.Fatalf("expected ErrFrameNotSealed for downgrade, got %v", err)
+	}
+}
+
+func TestFrameCodecDirectionalKeys(t *testing.T) {
+	c2s, s2c, err := DeriveFrameKeys(testKey(), []byte("session-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(c2s, s2c) {
+		t.Fatal("both directions derived the same key")
+	}
+	other, _, _ := DeriveFrameKeys(testKey(), []byte("session-2"))
+	if bytes.Equal(c2s, other) {
+		t.Fatal("keys not bound to the session ID")
+	}
+
+	params := CodecParams{Compression: CompressionNone, Encryption: CipherChaCha20Poly1305}
+	client, _ := NewFrameCodec(params, c2s, s2c)
+	server, _ := NewFrameCodec(params, s2c, c2s)
+
+	if err := server.Decode(roundTrip(t, encodeFrame(t, client, 1, []byte("to hub")))); err != nil {
+		t.Fatalf("server Decode: %v", err)
+	}
+	if err := client.Decode(roundTrip(t, encodeFrame(t, server, 1, []byte("to spoke")))); err != nil {
+		t.Fatalf("client Decode: %v", err)
+	}
+
+	// A client frame reflected back at the client does not authenticate
+	if err := client.Decode(roundTrip(t, encodeFrame(t, client, 2, []byte("reflected")))); err == nil {
+		t.Fatal("expected authentication failure for reflected frame")
+	}
+}
+
+func TestFrameCodecReplayWindow(t *testing.T) {
+	params := CodecParams{Compression: CompressionNone, Encryption: CipherChaCha20Poly1305}
+	tx, _ := NewFrameCodec(params, testKey(), testKey())
+	rx, _ := NewFrameCodec(params, testKey(), testKey())
+
+	frames := map[uint16][]byte{}
+	for _, seq := range []uint16{65534, 65535, 0, 1, 2} {
+		data, _ := encodeFrame(t, tx, seq, []byte("m")).Marshal()
+		frames[seq] = data
+	}
+	decode := func(seq uint16) error {
+		f := &Frame{}
+		if err := f.Unmarshal(frames[seq]); err != nil {
+			t.Fatal(err)
+		}
+		return rx.Decode(f)
+	}
+
+	// In order across the uint16 wrap, with one frame arriving late
+	for _, seq := range []uint16{65534, 0, 65535, 2, 1} {
+		if err := decode(seq); err != nil {
+			t.Fatalf("seq %d: %v", seq, err)
+		}
+	}
+	for _, seq := range []uint16{65535, 1} {
+		if err := decode(seq); !errors.Is(err, ErrReplayedFrame) {
+			t.Fatalf("seq %d replay: expected ErrReplayedFrame, got %v", seq, err)
+		}
+	}
+}
+
+func TestNegotiateCodec(t *testing.T) {
+	offer := CodecOffer{
+		Compression: []CompressionAlgo{"lz4", CompressionSnappy, CompressionZstd},
+		Encryption:  []CipherSuite{"aes-256-gcm", CipherChaCha20Poly1305},
+	}
+
+	got := NegotiateCodec(offer, true)
+	want := CodecParams{Compression: CompressionSnappy, Encryption: CipherChaCha20Poly1305}
+	if got != want {
+		t.Errorf("NegotiateCodec = %+v, want %+v", got, want)
+	}
+
+	if got := NegotiateCodec(offer, false); got.Encryption != CipherNone {
+		t.Errorf("encryption negotiated without a key: %+v", got)
+	}
+	if got := NegotiateCodec(CodecOffer{}, true); got != (CodecParams{CompressionNone, CipherNone}) {
+		t.Errorf("empty offer = %+v", got)
+	}
+}
@@ -0,0 +1,82 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Recorded payloads live in testdata/corpus/<protocol>/<name>.payload, each
+// with the AIPayloads UniversalAIParser.ParseAll produced in
+// <name>.golden.json. After an intentional parser change, regenerate with
+//
+//	go test ./internal/protocol -run TestParserGoldenCorpus -update
+//
+// and review the golden diff like any other code change.
+var updateGolden = flag.Bool("update", false, "rewrite testdata/corpus golden files")
+
+const corpusDir = "testdata/corpus"
+
+// corpusPayloads returns every recorded payload keyed by "<protocol>/<name>".
+func corpusPayloads(tb testing.TB) map[string][]byte {
+	tb.Helper()
+	paths, err := filepath.Glob(filepath.Join(corpusDir, "*", "*.payload"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(paths) == 0 {
+		tb.Fatalf("no payloads under %s", corpusDir)
+	}
+	payloads := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		rel, _ := filepath.Rel(corpusDir, path)
+		payloads[strings.TrimSuffix(filepath.ToSlash(rel), ".payload")] = data
+	}
+	return payloads
+}
+
+// goldenJSON renders parse results without their wall-clock timestamps.
+func goldenJSON(tb testing.TB, results []*AIPayload) []byte {
+	tb.Helper()
+	for _, r := range results {
+		r.DetectedAt = time.Time{}
+	}
+	out, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return append(out, '\n')
+}
+
+func TestParserGoldenCorpus(t *testing.T) {
+	parser := NewUniversalAIParser()
+	for name, payload := range corpusPayloads(t) {
+		t.Run(name, func(t *testing.T) {
+			got := goldenJSON(t, parser.ParseAll(payload))
+			golden := filepath.Join(corpusDir, name+".golden.json")
+
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("ParseAll output differs from %s (run with -update after reviewing):\n--- got\n%s", golden, got)
+			}
+		})
+	}
+}
@@ -4,9 +4,12 @@ package protocol
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"math"
 	"time"
 )
 
@@ -154,6 +157,12 @@ type FrameHeader struct {
 // HeaderSize is the size of the AOCS frame header
 const HeaderSize = 110
 
+// checksumOffset is the byte offset of the CRC-16 field within the header
+const checksumOffset = HeaderSize - 2
+
+// MaxPayloadSize is the largest payload expressible in PayloadLen
+const MaxPayloadSize = math.MaxUint16
+
 // NewFrameHeader creates a new frame header with defaults
 func NewFrameHeader() *FrameHeader {
 	return &FrameHeader{
@@ -357,6 +366,46 @@ func (f *Frame) Unmarshal(data []byte) error {
 	return nil
 }
 
+// ComputeChecksum returns the CRC-16 over the header (excluding the checksum
+// field) followed by the payload, so that both routing metadata and body are
+// covered by the integrity check.
+func (f *Frame) ComputeChecksum() (uint16, error) {
+	headerBytes, err := f.Header.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	data := make([]byte, 0, checksumOffset+len(f.Payload))
+	data = append(data, headerBytes[:checksumOffset]...)
+	data = append(data, f.Payload...)
+	return CalculateCRC16(data), nil
+}
+
+// Seal sets PayloadLen and Checksum so the frame is ready to be written.
+func (f *Frame) Seal() error {
+	if len(f.Payload) > MaxPayloadSize {
+		return fmt.Errorf("payload too large: %d bytes (max %d)", len(f.Payload), MaxPayloadSize)
+	}
+	f.Header.PayloadLen = uint16(len(f.Payload))
+	crc, err := f.ComputeChecksum()
+	if err != nil {
+		return err
+	}
+	f.Header.Checksum = crc
+	return nil
+}
+
+// VerifyChecksum checks the frame's CRC-16 against its header and payload.
+func (f *Frame) VerifyChecksum() error {
+	crc, err := f.ComputeChecksum()
+	if err != nil {
+		return err
+	}
+	if crc != f.Header.Checksum {
+		return fmt.Errorf("checksum mismatch: header=0x%04X computed=0x%04X", f.Header.Checksum, crc)
+	}
+	return nil
+}
+
 // ReadFrame reads a frame from an io.Reader
 func ReadFrame(r io.Reader) (*Frame, error) {
 	// Read header
@@ -421,6 +470,49 @@ func CalculateCRC16(data []byte) uint16 {
 	return crc
 }
 
+// AddressDigest maps a fabric virtual address (e.g. "ocx://hub/tenant/agent",
+// "cap://finance") to the 16-byte form carried in SourceAddr/DestAddr.
+func AddressDigest(addr string) [16]byte {
+	sum := sha256.Sum256([]byte(addr))
+	var digest [16]byte
+	copy(digest[:], sum[:16])
+	return digest
+}
+
+// IdentifierHash maps a string tenant or agent ID to the 32-bit form carried
+// in the TenantID/AgentID header fields (FNV-1a).
+func IdentifierHash(id string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return h.Sum32()
+}
+
+// EncodeAddressedPayload prefixes body with a length-prefixed address string.
+// Message frames use it to carry the full virtual address, since the 16-byte
+// header address fields only hold a digest.
+func EncodeAddressedPayload(addr string, body []byte) ([]byte, error) {
+	if len(addr) > math.MaxUint16 {
+		return nil, fmt.Errorf("address too long: %d bytes", len(addr))
+	}
+	out := make([]byte, 2+len(addr)+len(body))
+	binary.BigEndian.PutUint16(out, uint16(len(addr)))
+	copy(out[2:], addr)
+	copy(out[2+len(addr):], body)
+	return out, nil
+}
+
+// DecodeAddressedPayload splits a payload built by EncodeAddressedPayload.
+func DecodeAddressedPayload(payload []byte) (string, []byte, error) {
+	if len(payload) < 2 {
+		return "", nil, fmt.Errorf("addressed payload too short: %d bytes", len(payload))
+	}
+	n := int(binary.BigEndian.Uint16(payload))
+	if len(payload) < 2+n {
+		return "", nil, fmt.Errorf("address length %d exceeds payload (%d bytes)", n, len(payload)-2)
+	}
+	return string(payload[2 : 2+n]), payload[2+n:], nil
+}
+
 // SetSessionID sets the session ID in the header
 func (h *FrameHeader) SetSessionID(id []byte) {
 	copy(h.SessionID[:], id)
@@ -0,0 +1,127 @@
+package protocol
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// Parsers and the frame decoder take untrusted bytes straight off the wire.
+// The fuzz targets check they never panic and that whatever they accept is
+// well formed. Seeds come from the recorded corpus; run one target with e.g.
+//
+//	go test ./internal/protocol -run '^$' -fuzz '^FuzzMCPParser$' -fuzztime 60s
+
+func addCorpusSeeds(f *testing.F) {
+	for _, payload := range corpusPayloads(f) {
+		f.Add(payload)
+	}
+	for _, tc := range aiParserCorpus {
+		f.Add([]byte(tc.payload))
+	}
+	f.Add([]byte{})
+	f.Add([]byte("GET / HTTP/1.1\r\n\r\n"))
+}
+
+// checkPayload fails on a parse result no caller could safely use.
+func checkPayload(t *testing.T, p *AIPayload) {
+	t.Helper()
+	if p == nil {
+		t.Fatal("nil payload without error")
+	}
+	if p.Protocol == "" {
+		t.Errorf("empty Protocol: %+v", p)
+	}
+	if math.IsNaN(p.Confidence) || p.Confidence < 0 || p.Confidence > 1 {
+		t.Errorf("Confidence %v outside [0,1]", p.Confidence)
+	}
+}
+
+func fuzzParser(f *testing.F, parser AIPayloadParser) {
+	addCorpusSeeds(f)
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		if !parser.CanParse(payload) {
+			return
+		}
+		if result, err := parser.Parse(payload); err == nil {
+			checkPayload(t, result)
+		}
+		mp, ok := parser.(MultiCallParser)
+		if !ok {
+			return
+		}
+		results, err := mp.ParseAll(payload)
+		if err != nil {
+			return
+		}
+		if len(results) == 0 {
+			t.Fatal("ParseAll returned no payloads and no error")
+		}
+		for _, r := range results {
+			checkPayload(t, r)
+		}
+	})
+}
+
+func FuzzMCPParser(f *testing.F)            { fuzzParser(f, &MCPParser{}) }
+func FuzzOpenAIParser(f *testing.F)         { fuzzParser(f, &OpenAIParser{}) }
+func FuzzAnthropicParser(f *testing.F)      { fuzzParser(f, &AnthropicParser{}) }
+func FuzzGeminiParser(f *testing.F)         { fuzzParser(f, &GeminiParser{}) }
+func FuzzA2AParser(f *testing.F)            { fuzzParser(f, &A2AParser{}) }
+func FuzzRAGParser(f *testing.F)            { fuzzParser(f, &RAGParser{}) }
+func FuzzAgentFrameworkParser(f *testing.F) { fuzzParser(f, &AgentFrameworkParser{}) }
+func FuzzGenericAIDetector(f *testing.F)    { fuzzParser(f, &GenericAIDetector{}) }
+
+func FuzzUniversalAIParser(f *testing.F) {
+	addCorpusSeeds(f)
+	parser := NewUniversalAIParser()
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		checkPayload(t, parser.Parse(payload))
+		calls := parser.ParseAll(payload)
+		if len(calls) == 0 {
+			t.Fatal("ParseAll returned no payloads")
+		}
+		for _, c := range calls {
+			checkPayload(t, c)
+			if c.CallID == "" {
+				t.Errorf("call without CallID: %+v", c)
+			}
+		}
+	})
+}
+
+func FuzzFrameUnmarshal(f *testing.F) {
+	for _, payload := range [][]byte{nil, []byte(`{"tool":"search"}`), bytes.Repeat([]byte{0xff}, 300)} {
+		frame := NewFrame(FrameTypeMessage, payload)
+		frame.Header.SequenceNum = 9
+		if err := frame.Seal(); err != nil {
+			f.Fatal(err)
+		}
+		data, err := frame.Marshal()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+		f.Add(data[:len(data)/2])
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var frame Frame
+		if err := frame.Unmarshal(data); err != nil {
+			return
+		}
+		if len(frame.Payload) != int(frame.Header.PayloadLen) {
+			t.Fatalf("payload length %d, header says %d", len(frame.Payload), frame.Header.PayloadLen)
+		}
+		// Whatever decodes must re-encode to the bytes it came from
+		out, err := frame.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal of decoded frame: %v", err)
+		}
+		if !bytes.Equal(out, data[:len(out)]) {
+			t.Fatal("decoded frame does not re-encode to its input")
+		}
+		frame.VerifyChecksum()
+		frame.Header.Validate()
+	})
+}
@@ -0,0 +1,279 @@
+package protocol
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// GeminiParser handles Google Gemini generateContent payloads (Gemini API
+// and Vertex AI):
+//   - Requests with "contents" / "parts" and "tools[].functionDeclarations"
+//   - functionCall parts in model responses ("candidates"), including
+//     several parallel calls in one candidate
+//   - functionResponse parts sent back by the client
+//
+// Both the camelCase REST form and the snake_case form emitted by some SDKs
+// (function_call, function_response, function_declarations) are accepted.
+type GeminiParser struct{}
+
+type geminiRequest struct {
+	Contents          []geminiContent  `json:"contents"`
+	Tools             []geminiTool     `json:"tools,omitempty"`
+	SystemInstruction *json.RawMessage `json:"systemInstruction,omitempty"`
+	Model             string           `json:"model,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text,omitempty"`
+
+	FunctionCall      *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionCallSnake *geminiFunctionCall     `json:"function_call,omitempty"`
+	FunctionResp      *geminiFunctionResponse `json:"functionResponse,omitempty"`
+	FunctionRespSnake *geminiFunctionResponse `json:"function_response,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations      []geminiFunctionDecl `json:"functionDeclarations,omitempty"`
+	FunctionDeclarationsSnake []geminiFunctionDecl `json:"function_declarations,omitempty"`
+}
+
+type geminiFunctionDecl struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason,omitempty"`
+	} `json:"candidates"`
+	ModelVersion string `json:"modelVersion,omitempty"`
+	ResponseID   string `json:"responseId,omitempty"`
+}
+
+// geminiModelPath extracts the model from a request line such as
+// "POST /v1beta/models/gemini-1.5-pro:generateContent".
+var geminiModelPath = regexp.MustCompile(`models/([A-Za-z0-9._-]+):(generateContent|streamGenerateContent)`)
+
+func (p *GeminiParser) Name() AIProtocolType { return ProtoGemini }
+
+func (p *GeminiParser) CanParse(payload []byte) bool {
+	s := payload
+	return containsField(s, `"functionCall"`) ||
+		containsField(s, `"functionResponse"`) ||
+		containsField(s, `"functionDeclarations"`) ||
+		containsField(s, `"function_response"`) ||
+		containsField(s, `"function_declarations"`) ||
+		(containsField(s, `"function_call"`) && containsField(s, `"parts"`)) ||
+		(containsField(s, `"candidates"`) && containsField(s, `"parts"`)) ||
+		(containsField(s, `"contents"`) && containsField(s, `"parts"`))
+}
+
+func (p *GeminiParser) Parse(payload []byte) (*AIPayload, error) {
+	jsonStart := findJSONStart(payload)
+	if jsonStart < 0 {
+		return nil, errNotJSON
+	}
+	data := payload[jsonStart:]
+
+	result := &AIPayload{
+		Protocol:   ProtoGemini,
+		Direction:  "request",
+		DetectedAt: time.Now(),
+		Metadata:   make(map[string]interface{}),
+	}
+	if m := geminiModelPath.FindSubmatch(payload[:jsonStart]); m != nil {
+		result.Model = string(m[1])
+		result.RawMethod = string(m[2])
+	}
+
+	// Response: {"candidates":[{"content":{"parts":[...]}}]}
+	var resp geminiResponse
+	if err := json.Unmarshal(data, &resp); err == nil && len(resp.Candidates) > 0 {
+		result.Direction = "response"
+		if resp.ModelVersion != "" {
+			result.Model = resp.ModelVersion
+		}
+		result.TaskID = resp.ResponseID
+		result.Metadata["finish_reason"] = resp.Candidates[0].FinishReason
+
+		for _, cand := range resp.Candidates {
+			if calls := geminiFunctionCalls(cand.Content.Parts); len(calls) > 0 {
+				setGeminiToolCall(result, calls)
+				result.Confidence = 0.96
+				return result, nil
+			}
+		}
+
+		result.ToolName = "llm_completion"
+		result.MessageType = "generation"
+		result.Confidence = 0.92
+		return result, nil
+	}
+
+	// Request: {"contents":[...],"tools":[{"functionDeclarations":[...]}]}
+	var req geminiRequest
+	if err := json.Unmarshal(data, &req); err != nil || len(req.Contents) == 0 {
+		return nil, errNotJSON
+	}
+	if result.Model == "" {
+		result.Model = strings.TrimPrefix(req.Model, "models/")
+	}
+
+	var toolNames []string
+	for _, t := range req.Tools {
+		for _, d := range append(t.FunctionDeclarations, t.FunctionDeclarationsSnake...) {
+			toolNames = append(toolNames, d.Name)
+		}
+	}
+	if len(toolNames) > 0 {
+		result.Metadata["available_tools"] = toolNames
+		result.Metadata["tool_count"] = len(toolNames)
+	}
+
+	// The latest turn decides what this request is doing
+	last := req.Contents[len(req.Contents)-1]
+	if responses := geminiFunctionResponses(last.Parts); len(responses) > 0 {
+		first := responses[0]
+		result.ToolName = first.Name
+		result.MessageType = "tool_result"
+		result.Confidence = 0.95
+		result.Metadata["total_tool_results"] = len(responses)
+		if first.ID != "" {
+			result.Metadata["tool_call_id"] = first.ID
+		}
+		if len(responses) > 1 {
+			names := make([]string, len(responses))
+			for i, r := range responses {
+				names[i] = r.Name
+			}
+			result.Metadata["tool_names"] = names
+		}
+		return result, nil
+	}
+
+	result.ToolName = "llm_completion"
+	result.MessageType = "generation"
+	result.Confidence = 0.90
+	return result, nil
+}
+
+func geminiFunctionCalls(parts []geminiPart) []*geminiFunctionCall {
+	var calls []*geminiFunctionCall
+	for _, part := range parts {
+		if part.FunctionCall != nil {
+			calls = append(calls, part.FunctionCall)
+		} else if part.FunctionCallSnake != nil {
+			calls = append(calls, part.FunctionCallSnake)
+		}
+	}
+	return calls
+}
+
+func geminiFunctionResponses(parts []geminiPart) []*geminiFunctionResponse {
+	var responses []*geminiFunctionResponse
+	for _, part := range parts {
+		if part.FunctionResp != nil {
+			responses = append(responses, part.FunctionResp)
+		} else if part.FunctionRespSnake != nil {
+			responses = append(responses, part.FunctionRespSnake)
+		}
+	}
+	return responses
+}
+
+// setGeminiToolCall fills result from one or more functionCall parts; the
+// first is the primary call.
+func setGeminiToolCall(result *AIPayload, calls []*geminiFunctionCall) {
+	primary := calls[0]
+	result.ToolName = primary.Name
+	result.MessageType = "tool_call"
+	result.Arguments = primary.Args
+	result.Metadata["total_tool_calls"] = len(calls)
+	if primary.ID != "" {
+		result.Metadata["tool_call_id"] = primary.ID
+	}
+	if len(calls) > 1 {
+		names := make([]string, len(calls))
+		for i, c := range calls {
+			names[i] = c.Name
+		}
+		result.Metadata["tool_names"] = names
+	}
+}
+
+// ParseAll returns every functionCall part across candidates, or every
+// functionResponse part of the latest request turn. Gemini call IDs are
+// optional; calls without one get a stable ID from AssignCallIDs.
+func (p *GeminiParser) ParseAll(payload []byte) ([]*AIPayload, error) {
+	primary, err := p.Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	data := payload[findJSONStart(payload):]
+
+	switch primary.MessageType {
+	case "tool_call":
+		var resp geminiResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, err
+		}
+		var calls []*AIPayload
+		for ci, cand := range resp.Candidates {
+			for _, fc := range geminiFunctionCalls(cand.Content.Parts) {
+				call := clonePayload(primary)
+				call.ToolName = fc.Name
+				call.Arguments = fc.Args
+				call.CallID = fc.ID
+				delete(call.Metadata, "tool_call_id")
+				delete(call.Metadata, "tool_names")
+				if fc.ID != "" {
+					call.Metadata["tool_call_id"] = fc.ID
+				}
+				call.Metadata["candidate_index"] = ci
+				calls = append(calls, call)
+			}
+		}
+		return calls, nil
+
+	case "tool_result":
+		var req geminiRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, err
+		}
+		responses := geminiFunctionResponses(req.Contents[len(req.Contents)-1].Parts)
+		results := make([]*AIPayload, 0, len(responses))
+		for _, fr := range responses {
+			result := clonePayload(primary)
+			result.ToolName = fr.Name
+			result.CallID = fr.ID
+			delete(result.Metadata, "tool_call_id")
+			delete(result.Metadata, "tool_names")
+			if fr.ID != "" {
+				result.Metadata["tool_call_id"] = fr.ID
+			}
+			results = append(results, result)
+		}
+		return results, nil
+	}
+	return []*AIPayload{primary}, nil
+}
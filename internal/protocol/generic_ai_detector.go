@@ -94,7 +94,7 @@ func (p *GenericAIDetector) Parse(payload []byte) (*AIPayload, error) {
 	}
 
 	result.MessageType = bestCategory
-	result.Confidence = float64(bestScore) * 0.15 // Scale: 2 matches = 0.30, 5 = 0.75
+	result.Confidence = float64(bestScore*15) / 100 // Scale: 2 matches = 0.30, 5 = 0.75
 	if result.Confidence > 0.85 {
 		result.Confidence = 0.85 // Cap — it's generic detection
 	}
@@ -32,6 +32,24 @@ type mcpToolCallParams struct {
 	Meta      map[string]interface{} `json:"_meta,omitempty"`
 }
 
+// MCPToolDefinition is one tool advertised in a tools/list result.
+type MCPToolDefinition struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+	Annotations json.RawMessage `json:"annotations,omitempty"`
+}
+
+// mcpToolListResponse is a JSON-RPC response to tools/list
+type mcpToolListResponse struct {
+	JSONRPC string `json:"jsonrpc"`
+	Result  *struct {
+		Tools      []MCPToolDefinition `json:"tools"`
+		NextCursor string              `json:"nextCursor,omitempty"`
+	} `json:"result"`
+}
+
 // mcpResourceReadParams is the params for resources/read
 type mcpResourceReadParams struct {
 	URI string `json:"uri"`
@@ -41,14 +59,15 @@ func (p *MCPParser) Name() AIProtocolType { return ProtoMCP }
 
 func (p *MCPParser) CanParse(payload []byte) bool {
 	// Quick heuristic: MCP uses JSON-RPC 2.0
-	s := string(payload)
-	return strings.Contains(s, `"jsonrpc"`) &&
-		(strings.Contains(s, `"tools/`) ||
-			strings.Contains(s, `"resources/`) ||
-			strings.Contains(s, `"prompts/`) ||
-			strings.Contains(s, `"sampling/`) ||
-			strings.Contains(s, `"completion/`) ||
-			strings.Contains(s, `"initialize"`))
+	s := payload
+	return containsField(s, `"jsonrpc"`) &&
+		(containsField(s, `"tools/`) ||
+			containsField(s, `"resources/`) ||
+			containsField(s, `"prompts/`) ||
+			containsField(s, `"sampling/`) ||
+			containsField(s, `"completion/`) ||
+			containsField(s, `"initialize"`) ||
+			(containsField(s, `"tools"`) && containsField(s, `"inputSchema"`)))
 }
 
 func (p *MCPParser) Parse(payload []byte) (*AIPayload, error) {
@@ -67,6 +86,26 @@ func (p *MCPParser) Parse(payload []byte) (*AIPayload, error) {
 		return nil, errNotJSON
 	}
 
+	// A tools/list result carries no method; the tool list identifies it
+	if req.Method == "" {
+		if tools, err := ParseMCPToolList(payload); err == nil {
+			names := make([]string, len(tools))
+			for i, t := range tools {
+				names[i] = t.Name
+			}
+			return &AIPayload{
+				Protocol:    ProtoMCP,
+				ToolName:    "_list_tools",
+				MessageType: "discovery",
+				Direction:   "response",
+				Confidence:  0.95,
+				RawMethod:   "tools/list",
+				DetectedAt:  time.Now(),
+				Metadata:    map[string]interface{}{"tool_count": len(tools), "tool_names": names},
+			}, nil
+		}
+	}
+
 	result := &AIPayload{
 		Protocol:    ProtoMCP,
 		RawMethod:   req.Method,
@@ -118,8 +157,8 @@ func (p *MCPParser) Parse(payload []byte) (*AIPayload, error) {
 	}
 
 	// Check if this is a response (has "result" or "error" field)
-	if strings.Contains(string(payload[jsonStart:]), `"result"`) ||
-		strings.Contains(string(payload[jsonStart:]), `"error"`) {
+	if containsField(payload[jsonStart:], `"result"`) ||
+		containsField(payload[jsonStart:], `"error"`) {
 		result.Direction = "response"
 	}
 
@@ -140,3 +179,74 @@ func classifyMCPMethod(method string) string {
 		return "control"
 	}
 }
+
+// ParseAll handles JSON-RPC batches (an array of requests) as well as single
+// messages; each request's JSON-RPC id becomes its CallID.
+func (p *MCPParser) ParseAll(payload []byte) ([]*AIPayload, error) {
+	jsonStart := findJSONStart(payload)
+	if jsonStart < 0 {
+		return nil, errNotJSON
+	}
+	data := payload[jsonStart:]
+
+	var batch []json.RawMessage
+	if data[0] != '[' || json.Unmarshal(data, &batch) != nil {
+		result, err := p.Parse(payload)
+		if err != nil {
+			return nil, err
+		}
+		var req mcpRequest
+		if json.Unmarshal(data, &req) == nil {
+			result.CallID = jsonRPCIDString(req.ID)
+		}
+		return []*AIPayload{result}, nil
+	}
+
+	results := make([]*AIPayload, 0, len(batch))
+	for _, msg := range batch {
+		result, err := p.Parse(msg)
+		if err != nil {
+			continue
+		}
+		var req mcpRequest
+		if json.Unmarshal(msg, &req) == nil {
+			result.CallID = jsonRPCIDString(req.ID)
+		}
+		result.Metadata["batch_size"] = len(batch)
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, errNotJSON
+	}
+	return results, nil
+}
+
+// ParseMCPToolList extracts the tools advertised in a tools/list result.
+// payload may carry HTTP headers before the JSON body.
+func ParseMCPToolList(payload []byte) ([]MCPToolDefinition, error) {
+	jsonStart := findJSONStart(payload)
+	if jsonStart < 0 {
+		return nil, errNotJSON
+	}
+	var resp mcpToolListResponse
+	if err := json.Unmarshal(payload[jsonStart:], &resp); err != nil {
+		return nil, err
+	}
+	if resp.JSONRPC != "2.0" || resp.Result == nil || resp.Result.Tools == nil {
+		return nil, errNotJSON
+	}
+	return resp.Result.Tools, nil
+}
+
+// jsonRPCIDString renders a JSON-RPC id (string, number or null) as text.
+func jsonRPCIDString(id interface{}) string {
+	switch v := id.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
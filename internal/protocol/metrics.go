@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Session lifecycle metrics, shared by all SessionManagers in the process.
+var (
+	sessionsResumed = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "aocs_sessions_resumed_total",
+			Help: "Total number of AOCS sessions reattached with a resumption token",
+		},
+	)
+
+	sessionsExpired = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aocs_sessions_expired_total",
+			Help: "Total number of AOCS sessions that expired",
+		},
+		[]string{"reason"}, // reason: ttl, idle, resume
+	)
+)
+
+// Stream reassembly metrics.
+var streamToolCalls = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "protocol_stream_tool_calls_total",
+		Help: "Total number of tool calls rebuilt from streamed (SSE/chunked) responses",
+	},
+	[]string{"protocol", "status"}, // status: complete, invalid_args, truncated
+)
+
+// Protocol arbitration metrics.
+var parseArbitrations = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "protocol_parse_arbitrations_total",
+		Help: "Total number of payloads parsed, by how the winning parser was chosen",
+	},
+	[]string{"outcome"}, // outcome: single, first_match, rescored, unwrapped
+)
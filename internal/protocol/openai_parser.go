@@ -2,7 +2,6 @@ package protocol
 
 import (
 	"encoding/json"
-	"strings"
 	"time"
 )
 
@@ -20,7 +19,7 @@ type openaiRequest struct {
 	Model       string          `json:"model,omitempty"`
 	Messages    []openaiMessage `json:"messages,omitempty"`
 	Tools       []openaiToolDef `json:"tools,omitempty"`
-	ToolChoice  interface{}     `json:"tool_choice,omitempty"`
+	ToolChoice  json.RawMessage `json:"tool_choice,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
 	Temperature float64         `json:"temperature,omitempty"`
 }
@@ -45,9 +44,9 @@ type openaiToolCall struct {
 type openaiToolDef struct {
 	Type     string `json:"type"`
 	Function struct {
-		Name        string      `json:"name"`
-		Description string      `json:"description"`
-		Parameters  interface{} `json:"parameters"`
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
 	} `json:"function"`
 }
 
@@ -66,12 +65,12 @@ type openaiResponse struct {
 func (p *OpenAIParser) Name() AIProtocolType { return ProtoOpenAI }
 
 func (p *OpenAIParser) CanParse(payload []byte) bool {
-	s := string(payload)
-	return (strings.Contains(s, `"model"`) &&
-		(strings.Contains(s, `"messages"`) || strings.Contains(s, `"tool_calls"`))) ||
-		strings.Contains(s, `"chat/completions"`) ||
-		strings.Contains(s, `"function_call"`) ||
-		(strings.Contains(s, `"choices"`) && strings.Contains(s, `"finish_reason"`))
+	s := payload
+	return (containsField(s, `"model"`) &&
+		(containsField(s, `"messages"`) || containsField(s, `"tool_calls"`))) ||
+		containsField(s, `"chat/completions"`) ||
+		containsField(s, `"function_call"`) ||
+		(containsField(s, `"choices"`) && containsField(s, `"finish_reason"`))
 }
 
 func (p *OpenAIParser) Parse(payload []byte) (*AIPayload, error) {
@@ -148,8 +147,103 @@ func (p *OpenAIParser) Parse(payload []byte) (*AIPayload, error) {
 		result.ToolName = "llm_completion"
 		result.MessageType = "generation"
 		result.Confidence = 0.90
+		if hasForeignContentBlocks(req.Messages) {
+			// Anthropic tool_use/tool_result blocks: same envelope, other API
+			result.Confidence = 0.70
+		}
 		return result, nil
 	}
 
 	return nil, errNotJSON
 }
+
+// ParseAll returns every tool call across all choices of a response, or
+// every tool result in the trailing run of "tool" messages of a request.
+func (p *OpenAIParser) ParseAll(payload []byte) ([]*AIPayload, error) {
+	primary, err := p.Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	data := payload[findJSONStart(payload):]
+
+	switch primary.MessageType {
+	case "tool_call":
+		var resp openaiResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, err
+		}
+		var calls []*AIPayload
+		for ci, choice := range resp.Choices {
+			for _, tc := range choice.Message.ToolCalls {
+				call := clonePayload(primary)
+				call.ToolName = tc.Function.Name
+				call.Arguments = nil
+				var args map[string]interface{}
+				if json.Unmarshal([]byte(tc.Function.Arguments), &args) == nil {
+					call.Arguments = args
+				}
+				call.CallID = tc.ID
+				call.Metadata["tool_call_id"] = tc.ID
+				call.Metadata["total_tool_calls"] = len(choice.Message.ToolCalls)
+				call.Metadata["choice_index"] = ci
+				calls = append(calls, call)
+			}
+		}
+		return calls, nil
+
+	case "tool_result":
+		var req openaiRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, err
+		}
+		names := make(map[string]string)
+		for _, msg := range req.Messages {
+			for _, tc := range msg.ToolCalls {
+				names[tc.ID] = tc.Function.Name
+			}
+		}
+
+		// Results answering the latest assistant turn sit at the end
+		start := len(req.Messages)
+		for start > 0 && req.Messages[start-1].Role == "tool" {
+			start--
+		}
+		var results []*AIPayload
+		for _, msg := range req.Messages[start:] {
+			if msg.ToolCallID == "" {
+				continue
+			}
+			result := clonePayload(primary)
+			result.ToolName = msg.Name
+			if result.ToolName == "" {
+				result.ToolName = names[msg.ToolCallID]
+			}
+			result.CallID = msg.ToolCallID
+			result.Metadata["tool_call_id"] = msg.ToolCallID
+			results = append(results, result)
+		}
+		if len(results) > 0 {
+			return results, nil
+		}
+	}
+	return []*AIPayload{primary}, nil
+}
+
+// hasForeignContentBlocks reports content blocks OpenAI's chat API does not
+// define but the Anthropic Messages API does.
+func hasForeignContentBlocks(messages []openaiMessage) bool {
+	for _, msg := range messages {
+		blocks, ok := msg.Content.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, b := range blocks {
+			if block, ok := b.(map[string]interface{}); ok {
+				if t := block["type"]; t == "tool_use" || t == "tool_result" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -7,6 +7,13 @@ import (
 
 var errNotJSON = errors.New("payload is not JSON")
 
+// containsField reports whether payload contains lit. CanParse runs for
+// every parser on every payload, so it matches in place rather than
+// converting the payload to a string.
+func containsField(payload []byte, lit string) bool {
+	return bytes.Contains(payload, []byte(lit))
+}
+
 // findJSONStart scans payload for the start of a JSON object or array.
 // HTTP payloads often have headers before the JSON body, so we skip past them.
 func findJSONStart(data []byte) int {
@@ -40,3 +47,14 @@ func findJSONStart(data []byte) int {
 
 	return -1
 }
+
+// clonePayload copies a parsed payload so ParseAll can derive one payload
+// per invocation from the primary parse; Metadata is copied, not shared.
+func clonePayload(base *AIPayload) *AIPayload {
+	c := *base
+	c.Metadata = make(map[string]interface{}, len(base.Metadata))
+	for k, v := range base.Metadata {
+		c.Metadata[k] = v
+	}
+	return &c
+}
@@ -16,20 +16,27 @@ import (
 //   - Vector DB queries (Pinecone, Weaviate, Chroma, Qdrant, Milvus)
 //   - Embedding API calls (OpenAI embeddings, Cohere, HuggingFace)
 //   - Reranking calls (Cohere rerank, etc.)
+//   - Retrieval results returned by those services (Pinecone matches,
+//     Chroma query results, Qdrant points, rerank results). These carry
+//     third-party content into the model's context and are marked
+//     Direction "response" so they can be inspected before use.
 type RAGParser struct{}
 
 func (p *RAGParser) Name() AIProtocolType { return ProtoRAG }
 
 func (p *RAGParser) CanParse(payload []byte) bool {
-	s := string(payload)
-	return strings.Contains(s, `"embedding"`) ||
-		strings.Contains(s, `"vector"`) ||
-		strings.Contains(s, `"query_embedding"`) ||
-		strings.Contains(s, `"top_k"`) ||
-		strings.Contains(s, `"namespace"`) ||
-		strings.Contains(s, `"collection"`) ||
-		(strings.Contains(s, `"documents"`) && strings.Contains(s, `"ids"`)) ||
-		strings.Contains(s, `"rerank"`)
+	s := payload
+	return containsField(s, `"embedding"`) ||
+		containsField(s, `"vector"`) ||
+		containsField(s, `"query_embedding"`) ||
+		containsField(s, `"top_k"`) ||
+		containsField(s, `"namespace"`) ||
+		containsField(s, `"collection"`) ||
+		(containsField(s, `"documents"`) && containsField(s, `"ids"`)) ||
+		containsField(s, `"rerank"`) ||
+		(containsField(s, `"query"`) && containsField(s, `"documents"`) && containsField(s, `"top_n"`)) ||
+		(containsField(s, `"matches"`) && containsField(s, `"score"`)) ||
+		containsField(s, `"relevance_score"`)
 }
 
 func (p *RAGParser) Parse(payload []byte) (*AIPayload, error) {
@@ -51,6 +58,10 @@ func (p *RAGParser) Parse(payload []byte) (*AIPayload, error) {
 		Metadata:   make(map[string]interface{}),
 	}
 
+	if p.parseRetrievalResult(raw, result) {
+		return result, nil
+	}
+
 	switch {
 	// Embedding API call (OpenAI /v1/embeddings or similar)
 	case hasKeys(raw, "input", "model") && strings.Contains(strings.ToLower(getString(raw, "model")), "embed"):
@@ -92,6 +103,77 @@ func (p *RAGParser) Parse(payload []byte) (*AIPayload, error) {
 	return result, nil
 }
 
+// parseRetrievalResult recognises the result side of a retrieval call and
+// records how many documents came back.
+func (p *RAGParser) parseRetrievalResult(raw map[string]interface{}, result *AIPayload) bool {
+	switch {
+	// Pinecone: {"matches":[{"id","score","metadata"}],"namespace"}
+	case hasKeys(raw, "matches"):
+		result.ToolName = "vector_search"
+		result.Confidence = 0.90
+		result.Metadata["retrieved_count"] = lenOf(raw["matches"])
+		if ns, ok := raw["namespace"].(string); ok {
+			result.Metadata["namespace"] = ns
+		}
+
+	// Chroma: {"ids":[[...]],"documents":[[...]],"distances":[[...]]}. Query
+	// results are nested per query text, unlike the flat lists of an add.
+	case hasKeys(raw, "ids", "documents") && firstIsList(raw["documents"]):
+		result.ToolName = "collection_query"
+		result.Confidence = 0.88
+		docs := 0
+		if batches, ok := raw["documents"].([]interface{}); ok {
+			for _, b := range batches {
+				docs += lenOf(b)
+			}
+		}
+		result.Metadata["retrieved_count"] = docs
+
+	// Qdrant: {"result":[{"id","score","payload"}],"status":"ok"}
+	case hasKeys(raw, "result", "status") && firstHasKeys(raw["result"], "score", "payload"):
+		result.ToolName = "vector_search"
+		result.Confidence = 0.86
+		result.Metadata["retrieved_count"] = lenOf(raw["result"])
+
+	// Rerank: {"results":[{"index","relevance_score"}]}
+	case hasKeys(raw, "results") && firstHasKeys(raw["results"], "relevance_score"):
+		result.ToolName = "rerank"
+		result.Confidence = 0.86
+		result.Metadata["retrieved_count"] = lenOf(raw["results"])
+
+	default:
+		return false
+	}
+	result.MessageType = "retrieval"
+	result.Direction = "response"
+	return true
+}
+
+func lenOf(v interface{}) int {
+	if list, ok := v.([]interface{}); ok {
+		return len(list)
+	}
+	return 0
+}
+
+func firstIsList(v interface{}) bool {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return false
+	}
+	_, ok = list[0].([]interface{})
+	return ok
+}
+
+func firstHasKeys(v interface{}, keys ...string) bool {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return false
+	}
+	first, ok := list[0].(map[string]interface{})
+	return ok && hasKeys(first, keys...)
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if v, ok := m[key].(string); ok {
 		return v
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -54,6 +55,8 @@ type Session struct {
 	Entitlements    uint64 // Bitmask
 	GovernanceHash  uint32
 	EncryptionKeyID string
+	codec           *FrameCodec // Negotiated payload codec; nil until SetCodec
+	resumeHash      [32]byte    // SHA-256 of the outstanding resumption token
 
 	// Metrics
 	MessagesIn  int64
@@ -147,6 +150,20 @@ func (s *Session) NextSequence() uint16 {
 	return seq
 }
 
+// AcceptSequence enforces in-order delivery of inbound frames. The sequence
+// number must equal AckNum (the next expected value, wrapping at 2^16);
+// on success AckNum advances. Replayed, dropped or reordered frames fail.
+func (s *Session) AcceptSequence(seq uint16) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seq != s.AckNum {
+		return fmt.Errorf("unexpected sequence number %d (expected %d)", seq, s.AckNum)
+	}
+	s.AckNum++
+	return nil
+}
+
 // IsExpired checks if the session has expired
 func (s *Session) IsExpired() bool {
 	s.mu.RLock()
@@ -240,6 +257,13 @@ func (s *Session) AdvanceTurn() int32 {
 	return s.TurnCount
 }
 
+// Turn returns the current turn counter
+func (s *Session) Turn() int32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.TurnCount
+}
+
 // SetContextHash sets the conversation context hash
 func (s *Session) SetContextHash(hash [32]byte) {
 	s.mu.Lock()
@@ -247,6 +271,22 @@ func (s *Session) SetContextHash(hash [32]byte) {
 	s.ContextHash = hash
 }
 
+// SetCodec installs the payload codec negotiated during the handshake.
+// keyID identifies the derived key (never the key itself) for auditing.
+func (s *Session) SetCodec(codec *FrameCodec, keyID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codec = codec
+	s.EncryptionKeyID = keyID
+}
+
+// Codec returns the session's payload codec, or nil if none was negotiated.
+func (s *Session) Codec() *FrameCodec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.codec
+}
+
 // ============================================================================
 // SESSION MANAGER
 // ============================================================================
@@ -264,6 +304,14 @@ type SessionManager struct {
 	// on Get (as fallback when not in memory).
 	store SessionStore
 
+	// Resumption tokens are HMAC'd with this key; it must be shared by all
+	// replicas for a session suspended on one to be resumed on another.
+	resumeSecret []byte
+
+	// Lifetime counters (also exported as Prometheus metrics)
+	resumed atomic.Int64
+	expired atomic.Int64
+
 	// Limits
 	maxSessionsPerTenant int
 	maxTotalSessions     int
@@ -279,6 +327,7 @@ type SessionManagerConfig struct {
 	MaxTotalSessions     int
 	CleanupInterval      time.Duration
 	Store                SessionStore // P1 FIX #6: Optional persistent store
+	ResumptionSecret     []byte       // HMAC key for resumption tokens; random if empty
 }
 
 // NewSessionManager creates a new session manager
@@ -293,6 +342,17 @@ func NewSessionManager(cfg SessionManagerConfig) *SessionManager {
 		stopCleanup:          make(chan struct{}),
 	}
 
+	sm.resumeSecret = cfg.ResumptionSecret
+	if len(sm.resumeSecret) == 0 {
+		sm.resumeSecret = make([]byte, 32)
+		if _, err := rand.Read(sm.resumeSecret); err != nil {
+			panic(fmt.Sprintf("generate resumption secret: %v", err))
+		}
+		if cfg.Store != nil {
+			slog.Warn("[SessionManager] No resumption secret configured; tokens will only be valid on this replica")
+		}
+	}
+
 	// Start cleanup goroutine
 	if cfg.CleanupInterval > 0 {
 		go sm.cleanupLoop()
@@ -304,35 +364,38 @@ func NewSessionManager(cfg SessionManagerConfig) *SessionManager {
 // Create creates and registers a new session
 func (sm *SessionManager) Create(ctx context.Context, cfg SessionConfig) (*Session, error) {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
 
 	// Check limits
 	if len(sm.sessions) >= sm.maxTotalSessions {
+		sm.mu.Unlock()
 		return nil, fmt.Errorf("maximum total sessions reached (%d)", sm.maxTotalSessions)
 	}
 
 	tenantSessions := sm.byTenant[cfg.TenantID]
 	if len(tenantSessions) >= sm.maxSessionsPerTenant {
+		sm.mu.Unlock()
 		return nil, fmt.Errorf("maximum sessions per tenant reached (%d)", sm.maxSessionsPerTenant)
 	}
 
 	// Create session
 	session, err := NewSession(cfg)
 	if err != nil {
+		sm.mu.Unlock()
 		return nil, err
 	}
 
 	// Register
 	sm.sessions[session.ID] = session
 	sm.byTenant[cfg.TenantID] = append(sm.byTenant[cfg.TenantID], session)
+	sm.mu.Unlock()
 
-	// P1 FIX #6: Persist to store (non-blocking, best-effort)
+	// P1 FIX #6: Persist to store (best-effort). Done synchronously, outside
+	// sm.mu, so a slower initial write can't overwrite a later save made by
+	// IssueResumptionToken or Suspend.
 	if sm.store != nil {
-		go func() {
-			if err := sm.store.Save(ctx, session); err != nil {
-				slog.Warn("[SessionManager] Failed to persist session", "i_d_string", session.IDString(), "error", err)
-			}
-		}()
+		if err := sm.saveNow(ctx, session); err != nil {
+			slog.Warn("[SessionManager] Failed to persist session", "session_id", session.IDString(), "error", err)
+		}
 	}
 
 	return session, nil
@@ -396,18 +459,37 @@ func (sm *SessionManager) Remove(id [16]byte) error {
 		return fmt.Errorf("session not found: %s", hex.EncodeToString(id[:]))
 	}
 
-	delete(sm.sessions, id)
+	sm.unindexLocked(session)
+	sm.deleteFromStore(id)
+
+	return nil
+}
+
+// unindexLocked drops a session from the in-memory maps. Caller holds sm.mu.
+func (sm *SessionManager) unindexLocked(session *Session) {
+	delete(sm.sessions, session.ID)
 
-	// Remove from tenant index
 	tenantSessions := sm.byTenant[session.TenantID]
 	for i, s := range tenantSessions {
-		if s.ID == id {
+		if s.ID == session.ID {
 			sm.byTenant[session.TenantID] = append(tenantSessions[:i], tenantSessions[i+1:]...)
 			break
 		}
 	}
+}
 
-	return nil
+// deleteFromStore removes a session from the persistent store (best-effort).
+func (sm *SessionManager) deleteFromStore(id [16]byte) {
+	if sm.store == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		if err := sm.store.Delete(ctx, id); err != nil {
+			slog.Warn("[SessionManager] Failed to delete persisted session", "session_id", hex.EncodeToString(id[:]), "error", err)
+		}
+	}()
 }
 
 // Cleanup removes expired sessions.
@@ -426,19 +508,19 @@ func (sm *SessionManager) Cleanup() int {
 		// Reading State/ExpiresAt/LastActive is safe here because Cleanup
 		// holds the exclusive sm.mu lock, and these fields are only written
 		// under session.mu which is fine for a snapshot read.
-		isExpired := now.After(session.ExpiresAt) ||
-			(session.IdleTimeout > 0 && now.Sub(session.LastActive) > session.IdleTimeout)
-
-		if isExpired || session.State == SessionStateTerminated {
-			delete(sm.sessions, id)
-
-			// Remove from tenant index
-			tenantSessions := sm.byTenant[session.TenantID]
-			for i, s := range tenantSessions {
-				if s.ID == id {
-					sm.byTenant[session.TenantID] = append(tenantSessions[:i], tenantSessions[i+1:]...)
-					break
-				}
+		ttlExpired := now.After(session.ExpiresAt)
+		idleExpired := session.IdleTimeout > 0 && now.Sub(session.LastActive) > session.IdleTimeout
+
+		if ttlExpired || idleExpired || session.State == SessionStateTerminated {
+			sm.unindexLocked(session)
+			sm.deleteFromStore(id)
+
+			switch {
+			case session.State == SessionStateTerminated:
+			case ttlExpired:
+				sm.recordExpired("ttl")
+			default:
+				sm.recordExpired("idle")
 			}
 
 			removed++
@@ -480,6 +562,8 @@ func (sm *SessionManager) Stats() SessionStats {
 		TotalSessions: len(sm.sessions),
 		TenantCount:   len(sm.byTenant),
 		ByState:       make(map[SessionState]int),
+		Resumed:       sm.resumed.Load(),
+		Expired:       sm.expired.Load(),
 	}
 
 	for _, s := range sm.sessions {
@@ -494,6 +578,8 @@ type SessionStats struct {
 	TotalSessions int
 	TenantCount   int
 	ByState       map[SessionState]int
+	Resumed       int64 // Sessions reattached via resumption token
+	Expired       int64 // Sessions that expired (TTL, idle, or on a resume attempt)
 }
 
 // ============================================================================
@@ -508,6 +594,14 @@ type SessionStore interface {
 	ListByTenant(ctx context.Context, tenantID uint32) ([]*Session, error)
 }
 
+// ResumeTokenConsumer is implemented by stores shared between replicas.
+// ConsumeResumeToken clears the session's stored resumption token only if it
+// still matches tokenHash, in one atomic step, and reports whether this call
+// cleared it. Resume uses it so exactly one of several racing replicas wins.
+type ResumeTokenConsumer interface {
+	ConsumeResumeToken(ctx context.Context, id [16]byte, tokenHash [32]byte) (bool, error)
+}
+
 // InMemorySessionStore provides in-memory session storage (for testing)
 type InMemorySessionStore struct {
 	mu       sync.RWMutex
@@ -0,0 +1,422 @@
+package protocol
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ============================================================================
+// SESSION RESUMPTION
+// ============================================================================
+//
+// A client that loses its connection can reattach to its session — on any
+// replica sharing the SessionStore and ResumptionSecret — with its sequence
+// numbers, turn counter and context hash intact:
+//
+//  1. IssueResumptionToken after the session is established; hand the token
+//     to the client.
+//  2. Suspend when the connection drops. With a store configured the session
+//     is persisted and released from this replica's memory.
+//  3. Resume(token) on reconnect. Tokens are single-use: the client must be
+//     issued a fresh one after each resume.
+//
+// Tokens are base64url(version | sessionID | expiry | random | HMAC-SHA256).
+// Only the SHA-256 of the outstanding token is stored with the session.
+// Stores that implement ResumeTokenConsumer (Redis, Postgres) consume it
+// atomically, so replicas racing on the same token resume it once.
+
+// storeTimeout bounds synchronous store calls made by the manager
+const storeTimeout = 5 * time.Second
+
+const (
+	resumeTokenVersion = 1
+	resumeTokenBody    = 1 + 16 + 8 + 16
+	resumeTokenLen     = resumeTokenBody + sha256.Size
+)
+
+// Resumption errors
+var (
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrSessionExpired         = errors.New("session expired")
+	ErrInvalidResumptionToken = errors.New("invalid resumption token")
+	ErrSessionNotSuspended    = errors.New("session is not suspended")
+
+	errResumeTokenEncoding = fmt.Errorf("%w: bad encoding", ErrInvalidResumptionToken)
+)
+
+// IssueResumptionToken creates a new single-use resumption token for a
+// session, replacing any outstanding one. The token expires with the session.
+func (sm *SessionManager) IssueResumptionToken(ctx context.Context, session *Session) (string, error) {
+	buf := make([]byte, resumeTokenBody, resumeTokenLen)
+	buf[0] = resumeTokenVersion
+	copy(buf[1:17], session.ID[:])
+
+	session.mu.RLock()
+	expiresAt := session.ExpiresAt
+	session.mu.RUnlock()
+	binary.BigEndian.PutUint64(buf[17:25], uint64(expiresAt.Unix()))
+
+	if _, err := rand.Read(buf[25:41]); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	buf = append(buf, sm.tokenMAC(buf)...)
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	session.mu.Lock()
+	session.resumeHash = sha256.Sum256([]byte(token))
+	session.mu.Unlock()
+
+	if sm.store != nil {
+		if err := sm.saveNow(ctx, session); err != nil {
+			return "", err
+		}
+	}
+	return token, nil
+}
+
+// Suspend pauses an active session so it can later be resumed. When a store
+// is configured the session is persisted and dropped from local memory, so
+// the replica that resumes it becomes its owner.
+func (sm *SessionManager) Suspend(ctx context.Context, id [16]byte) error {
+	sm.mu.RLock()
+	session, exists := sm.sessions[id]
+	sm.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, hex.EncodeToString(id[:]))
+	}
+
+	if err := session.Suspend(); err != nil {
+		return err
+	}
+	session.SetCodec(nil, "") // Codec keys are bound to the old connection
+
+	if sm.store == nil {
+		return nil
+	}
+	if err := sm.saveNow(ctx, session); err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	sm.unindexLocked(session)
+	sm.mu.Unlock()
+	return nil
+}
+
+// Resume reattaches a suspended session using a resumption token and marks
+// it active. The token is consumed; issue a new one for the client.
+func (sm *SessionManager) Resume(ctx context.Context, token string) (*Session, error) {
+	id, expiresAt, err := sm.parseResumptionToken(token)
+	if err != nil {
+		return nil, err
+	}
+	idHex := hex.EncodeToString(id[:])
+	if time.Now().After(expiresAt) {
+		sm.recordExpired("resume")
+		return nil, fmt.Errorf("%w: %s", ErrSessionExpired, idHex)
+	}
+
+	sm.mu.RLock()
+	session, local := sm.sessions[id]
+	sm.mu.RUnlock()
+
+	if !local {
+		if sm.store == nil {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, idHex)
+		}
+		loaded, err := sm.store.Load(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrSessionNotFound, idHex, err)
+		}
+		session = loaded
+	}
+
+	if session.IsExpired() {
+		sm.recordExpired("resume")
+		sm.mu.Lock()
+		sm.unindexLocked(session)
+		sm.mu.Unlock()
+		sm.deleteFromStore(id)
+		return nil, fmt.Errorf("%w: %s", ErrSessionExpired, idHex)
+	}
+
+	tokenHash := sha256.Sum256([]byte(token))
+	session.mu.RLock()
+	err = session.resumableLocked(tokenHash)
+	session.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// A shared store is the authority on whether the token is still
+	// outstanding; the copy loaded above may already be stale.
+	if consumer, ok := sm.store.(ResumeTokenConsumer); ok {
+		consumed, err := sm.consumeNow(ctx, consumer, id, tokenHash)
+		if err != nil {
+			return nil, err
+		}
+		if !consumed {
+			return nil, fmt.Errorf("%w: token already used or superseded", ErrInvalidResumptionToken)
+		}
+	}
+
+	session.mu.Lock()
+	if err := session.resumableLocked(tokenHash); err != nil {
+		session.mu.Unlock()
+		return nil, err
+	}
+	session.resumeHash = [32]byte{}
+	session.State = SessionStateActive
+	session.LastActive = time.Now()
+	session.mu.Unlock()
+
+	if !local {
+		sm.mu.Lock()
+		if len(sm.sessions) >= sm.maxTotalSessions {
+			sm.mu.Unlock()
+			return nil, fmt.Errorf("maximum total sessions reached (%d)", sm.maxTotalSessions)
+		}
+		sm.sessions[id] = session
+		sm.byTenant[session.TenantID] = append(sm.byTenant[session.TenantID], session)
+		sm.mu.Unlock()
+	}
+
+	if sm.store != nil {
+		if err := sm.saveNow(ctx, session); err != nil {
+			slog.Warn("[SessionManager] Failed to persist resumed session", "session_id", idHex, "error", err)
+		}
+	}
+
+	sm.resumed.Add(1)
+	sessionsResumed.Inc()
+	slog.Info("[SessionManager] Session resumed", "session_id", idHex, "turn", session.TurnCount, "local", local)
+	return session, nil
+}
+
+// resumableLocked reports why a suspended session cannot be resumed with the
+// token hashing to tokenHash. The caller holds s.mu.
+func (s *Session) resumableLocked(tokenHash [32]byte) error {
+	if subtle.ConstantTimeCompare(tokenHash[:], s.resumeHash[:]) != 1 {
+		return fmt.Errorf("%w: token already used or superseded", ErrInvalidResumptionToken)
+	}
+	if s.State != SessionStateSuspended {
+		return fmt.Errorf("%w: state %s", ErrSessionNotSuspended, s.State)
+	}
+	return nil
+}
+
+func (sm *SessionManager) parseResumptionToken(token string) ([16]byte, time.Time, error) {
+	var id [16]byte
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != resumeTokenLen || raw[0] != resumeTokenVersion {
+		return id, time.Time{}, errResumeTokenEncoding
+	}
+	body, mac := raw[:resumeTokenBody], raw[resumeTokenBody:]
+	if !hmac.Equal(mac, sm.tokenMAC(body)) {
+		return id, time.Time{}, fmt.Errorf("%w: bad signature", ErrInvalidResumptionToken)
+	}
+
+	copy(id[:], body[1:17])
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(body[17:25])), 0)
+	return id, expiresAt, nil
+}
+
+func (sm *SessionManager) tokenMAC(body []byte) []byte {
+	mac := hmac.New(sha256.New, sm.resumeSecret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func (sm *SessionManager) saveNow(ctx context.Context, session *Session) error {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	if err := sm.store.Save(ctx, session); err != nil {
+		return fmt.Errorf("persist session %s: %w", session.IDString(), err)
+	}
+	return nil
+}
+
+func (sm *SessionManager) consumeNow(ctx context.Context, consumer ResumeTokenConsumer, id [16]byte, tokenHash [32]byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	consumed, err := consumer.ConsumeResumeToken(ctx, id, tokenHash)
+	if err != nil {
+		return false, fmt.Errorf("consume resumption token %s: %w", hex.EncodeToString(id[:]), err)
+	}
+	return consumed, nil
+}
+
+func (sm *SessionManager) recordExpired(reason string) {
+	sm.expired.Add(1)
+	sessionsExpired.WithLabelValues(reason).Inc()
+}
+
+// ============================================================================
+// SESSION SERIALIZATION
+// ============================================================================
+
+// sessionRecord is the persisted form of a Session. Binary identifiers are
+// hex-encoded; the frame codec is not persisted because its keys are bound
+// to the connection that negotiated it.
+type sessionRecord struct {
+	ID          string        `json:"id"`
+	TenantID    uint32        `json:"tenant_id"`
+	AgentID     uint32        `json:"agent_id"`
+	State       SessionState  `json:"state"`
+	LocalAddr   string        `json:"local_addr"`
+	RemoteAddr  string        `json:"remote_addr"`
+	CreatedAt   time.Time     `json:"created_at"`
+	LastActive  time.Time     `json:"last_active"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	IdleTimeout time.Duration `json:"idle_timeout"`
+
+	SequenceNum uint16 `json:"sequence_num"`
+	AckNum      uint16 `json:"ack_num"`
+	WindowSize  uint16 `json:"window_size"`
+
+	TrustLevel      float64 `json:"trust_level"`
+	Entitlements    uint64  `json:"entitlements"`
+	GovernanceHash  uint32  `json:"governance_hash"`
+	EncryptionKeyID string  `json:"encryption_key_id,omitempty"`
+
+	MessagesIn  int64  `json:"messages_in"`
+	MessagesOut int64  `json:"messages_out"`
+	BytesIn     int64  `json:"bytes_in"`
+	BytesOut    int64  `json:"bytes_out"`
+	ErrorCount  int64  `json:"error_count"`
+	LastError   string `json:"last_error,omitempty"`
+
+	TurnCount   int32  `json:"turn_count"`
+	ContextHash string `json:"context_hash"`
+	ResumeHash  string `json:"resume_hash,omitempty"`
+}
+
+// MarshalJSON encodes a consistent snapshot of the session.
+func (s *Session) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	rec := sessionRecord{
+		ID:              hex.EncodeToString(s.ID[:]),
+		TenantID:        s.TenantID,
+		AgentID:         s.AgentID,
+		State:           s.State,
+		LocalAddr:       hex.EncodeToString(s.LocalAddr[:]),
+		RemoteAddr:      hex.EncodeToString(s.RemoteAddr[:]),
+		CreatedAt:       s.CreatedAt,
+		LastActive:      s.LastActive,
+		ExpiresAt:       s.ExpiresAt,
+		IdleTimeout:     s.IdleTimeout,
+		SequenceNum:     s.SequenceNum,
+		AckNum:          s.AckNum,
+		WindowSize:      s.WindowSize,
+		TrustLevel:      s.TrustLevel,
+		Entitlements:    s.Entitlements,
+		GovernanceHash:  s.GovernanceHash,
+		EncryptionKeyID: s.EncryptionKeyID,
+		MessagesIn:      s.MessagesIn,
+		MessagesOut:     s.MessagesOut,
+		BytesIn:         s.BytesIn,
+		BytesOut:        s.BytesOut,
+		ErrorCount:      s.ErrorCount,
+		LastError:       s.LastError,
+		TurnCount:       s.TurnCount,
+		ContextHash:     hex.EncodeToString(s.ContextHash[:]),
+	}
+	if s.resumeHash != ([32]byte{}) {
+		rec.ResumeHash = hex.EncodeToString(s.resumeHash[:])
+	}
+	s.mu.RUnlock()
+	return json.Marshal(rec)
+}
+
+// UnmarshalJSON restores a session persisted with MarshalJSON.
+func (s *Session) UnmarshalJSON(data []byte) error {
+	var rec sessionRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := []struct {
+		name string
+		src  string
+		dst  []byte
+	}{
+		{"id", rec.ID, s.ID[:]},
+		{"local_addr", rec.LocalAddr, s.LocalAddr[:]},
+		{"remote_addr", rec.RemoteAddr, s.RemoteAddr[:]},
+		{"context_hash", rec.ContextHash, s.ContextHash[:]},
+		{"resume_hash", rec.ResumeHash, s.resumeHash[:]},
+	}
+	for _, f := range fields {
+		if f.src == "" {
+			continue
+		}
+		b, err := hex.DecodeString(f.src)
+		if err != nil || len(b) != len(f.dst) {
+			return fmt.Errorf("invalid session %s", f.name)
+		}
+		copy(f.dst, b)
+	}
+
+	s.TenantID = rec.TenantID
+	s.AgentID = rec.AgentID
+	s.State = rec.State
+	s.CreatedAt = rec.CreatedAt
+	s.LastActive = rec.LastActive
+	s.ExpiresAt = rec.ExpiresAt
+	s.IdleTimeout = rec.IdleTimeout
+	s.SequenceNum = rec.SequenceNum
+	s.AckNum = rec.AckNum
+	s.WindowSize = rec.WindowSize
+	s.TrustLevel = rec.TrustLevel
+	s.Entitlements = rec.Entitlements
+	s.GovernanceHash = rec.GovernanceHash
+	s.EncryptionKeyID = rec.EncryptionKeyID
+	s.MessagesIn = rec.MessagesIn
+	s.MessagesOut = rec.MessagesOut
+	s.BytesIn = rec.BytesIn
+	s.BytesOut = rec.BytesOut
+	s.ErrorCount = rec.ErrorCount
+	s.LastError = rec.LastError
+	s.TurnCount = rec.TurnCount
+	return nil
+}
+
+// resumeHashHex returns the outstanding token hash, or "" if none.
+func (s *Session) resumeHashHex() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.resumeHash == ([32]byte{}) {
+		return ""
+	}
+	return hex.EncodeToString(s.resumeHash[:])
+}
+
+// expiryDeadline returns when the session will expire if left untouched: the
+// earlier of its TTL and idle deadlines. Stores use it as a key TTL.
+func (s *Session) expiryDeadline() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deadline := s.ExpiresAt
+	if s.IdleTimeout > 0 {
+		if idle := s.LastActive.Add(s.IdleTimeout); idle.Before(deadline) {
+			deadline = idle
+		}
+	}
+	return deadline
+}
@@ -0,0 +1,243 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jsonSessionStore serializes sessions like the Redis/Postgres stores do, so
+// tests exercise the persisted form rather than sharing *Session pointers.
+type jsonSessionStore struct {
+	mu   sync.Mutex
+	data map[[16]byte][]byte
+}
+
+func newJSONSessionStore() *jsonSessionStore {
+	return &jsonSessionStore{data: make(map[[16]byte][]byte)}
+}
+
+func (s *jsonSessionStore) Save(ctx context.Context, session *Session) error {
+	b, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[session.ID] = b
+	return nil
+}
+
+func (s *jsonSessionStore) Load(ctx context.Context, id [16]byte) (*Session, error) {
+	s.mu.Lock()
+	b, ok := s.data[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	session := &Session{}
+	return session, json.Unmarshal(b, session)
+}
+
+func (s *jsonSessionStore) Delete(ctx context.Context, id [16]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, id)
+	return nil
+}
+
+// ConsumeResumeToken compares and clears under the store lock, as the Redis
+// script and the Postgres UPDATE do.
+func (s *jsonSessionStore) ConsumeResumeToken(ctx context.Context, id [16]byte, tokenHash [32]byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.data[id]
+	if !ok {
+		return false, nil
+	}
+	session := &Session{}
+	if err := json.Unmarshal(b, session); err != nil {
+		return false, err
+	}
+	if session.resumeHash != tokenHash {
+		return false, nil
+	}
+	session.resumeHash = [32]byte{}
+	b, err := json.Marshal(session)
+	if err != nil {
+		return false, err
+	}
+	s.data[id] = b
+	return true, nil
+}
+
+func (s *jsonSessionStore) ListByTenant(ctx context.Context, tenantID uint32) ([]*Session, error) {
+	return nil, nil
+}
+
+func newReplica(store SessionStore, secret string) *SessionManager {
+	return NewSessionManager(SessionManagerConfig{
+		MaxSessionsPerTenant: 10,
+		MaxTotalSessions:     10,
+		Store:                store,
+		ResumptionSecret:     []byte(secret),
+	})
+}
+
+func TestSessionResumeAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	store := newJSONSessionStore()
+	replicaA := newReplica(store, "shared-secret")
+	replicaB := newReplica(store, "shared-secret")
+
+	session, err := replicaA.Create(ctx, SessionConfig{TenantID: 7, AgentID: 9, TTL: time.Hour, IdleTimeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Activate()
+	session.AdvanceTurn()
+	session.AdvanceTurn()
+	session.SetContextHash([32]byte{0xAB})
+	session.AcceptSequence(0)
+	session.NextSequence()
+
+	token, err := replicaA.IssueResumptionToken(ctx, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replicaA.Suspend(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if replicaA.Stats().TotalSessions != 0 {
+		t.Error("suspended session should be released from the suspending replica")
+	}
+
+	resumed, err := replicaB.Resume(ctx, token)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if resumed.ID != session.ID || resumed.State != SessionStateActive {
+		t.Fatalf("resumed %s in state %s", resumed.IDString(), resumed.State)
+	}
+	if resumed.Turn() != 2 || resumed.ContextHash != ([32]byte{0xAB}) {
+		t.Errorf("turn=%d context=%x not preserved", resumed.Turn(), resumed.ContextHash[:1])
+	}
+	if resumed.AckNum != 1 || resumed.SequenceNum != 1 {
+		t.Errorf("sequence state not preserved: seq=%d ack=%d", resumed.SequenceNum, resumed.AckNum)
+	}
+	if got := replicaB.Stats().Resumed; got != 1 {
+		t.Errorf("Resumed = %d, want 1", got)
+	}
+
+	// Tokens are single use, even on another replica
+	replicaB.Suspend(ctx, session.ID)
+	if _, err := replicaA.Resume(ctx, token); !errors.Is(err, ErrInvalidResumptionToken) {
+		t.Fatalf("token reuse: expected ErrInvalidResumptionToken, got %v", err)
+	}
+}
+
+func TestSessionResumeRejectsBadTokens(t *testing.T) {
+	ctx := context.Background()
+	store := newJSONSessionStore()
+	sm := newReplica(store, "secret-a")
+
+	session, _ := sm.Create(ctx, SessionConfig{TenantID: 1, AgentID: 1, TTL: time.Hour})
+	session.Activate()
+	token, _ := sm.IssueResumptionToken(ctx, session)
+
+	// Active sessions can't be resumed
+	if _, err := sm.Resume(ctx, token); !errors.Is(err, ErrSessionNotSuspended) {
+		t.Errorf("expected ErrSessionNotSuspended, got %v", err)
+	}
+	sm.Suspend(ctx, session.ID)
+
+	if _, err := newReplica(store, "secret-b").Resume(ctx, token); !errors.Is(err, ErrInvalidResumptionToken) {
+		t.Errorf("foreign secret: expected ErrInvalidResumptionToken, got %v", err)
+	}
+	if _, err := sm.Resume(ctx, "not-a-token"); !errors.Is(err, ErrInvalidResumptionToken) {
+		t.Errorf("garbage: expected ErrInvalidResumptionToken, got %v", err)
+	}
+}
+
+func TestSessionResumeExpired(t *testing.T) {
+	ctx := context.Background()
+	sm := newReplica(newJSONSessionStore(), "secret")
+
+	session, _ := sm.Create(ctx, SessionConfig{TenantID: 1, AgentID: 1, TTL: time.Hour, IdleTimeout: time.Minute})
+	session.Activate()
+	token, _ := sm.IssueResumptionToken(ctx, session)
+	sm.Suspend(ctx, session.ID)
+
+	// Simulate the idle timeout elapsing while suspended
+	stored, _ := sm.store.Load(ctx, session.ID)
+	stored.LastActive = time.Now().Add(-2 * time.Minute)
+	sm.store.Save(ctx, stored)
+
+	if _, err := sm.Resume(ctx, token); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired, got %v", err)
+	}
+	if got := sm.Stats().Expired; got != 1 {
+		t.Errorf("Expired = %d, want 1", got)
+	}
+}
+
+// barrierStore holds every Load until n of them are in flight, so racing
+// replicas all read the session before any of them resumes it.
+type barrierStore struct {
+	*jsonSessionStore
+	loads *sync.WaitGroup
+}
+
+func (s barrierStore) Load(ctx context.Context, id [16]byte) (*Session, error) {
+	session, err := s.jsonSessionStore.Load(ctx, id)
+	s.loads.Done()
+	s.loads.Wait()
+	return session, err
+}
+
+func TestSessionResumeRaceResumesOnce(t *testing.T) {
+	ctx := context.Background()
+	shared := newJSONSessionStore()
+	owner := newReplica(shared, "shared-secret")
+
+	session, _ := owner.Create(ctx, SessionConfig{TenantID: 1, AgentID: 1, TTL: time.Hour})
+	session.Activate()
+	token, _ := owner.IssueResumptionToken(ctx, session)
+	if err := owner.Suspend(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	const replicas = 8
+	var (
+		wg        sync.WaitGroup
+		loads     sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	loads.Add(replicas)
+	store := barrierStore{jsonSessionStore: shared, loads: &loads}
+	for i := 0; i < replicas; i++ {
+		replica := newReplica(store, "shared-secret")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := replica.Resume(ctx, token)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, ErrInvalidResumptionToken):
+				t.Errorf("losing replica: expected ErrInvalidResumptionToken, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Fatalf("%d replicas resumed the session, want exactly 1", succeeded)
+	}
+}
@@ -0,0 +1,270 @@
+package protocol
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// REDIS SESSION STORE
+// ============================================================================
+
+// SessionRedisClient is the subset of Redis operations the session store
+// needs. infra.GoRedisAdapter satisfies it.
+type SessionRedisClient interface {
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Del(ctx context.Context, keys ...string) error
+	SAdd(ctx context.Context, key string, members ...string) error
+	SRem(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	DelIfEqual(ctx context.Context, key string, value []byte) (bool, error)
+}
+
+// RedisSessionStore persists sessions as JSON with a key TTL matching the
+// session's own expiry, so abandoned sessions disappear without a sweeper.
+// The outstanding resumption token hash is also kept under its own key so it
+// can be consumed with a single compare-and-delete.
+type RedisSessionStore struct {
+	client    SessionRedisClient
+	keyPrefix string // e.g. "ocx:aocs:" to namespace keys
+}
+
+// NewRedisSessionStore creates a Redis-backed session store.
+func NewRedisSessionStore(client SessionRedisClient, keyPrefix string) *RedisSessionStore {
+	return &RedisSessionStore{client: client, keyPrefix: keyPrefix}
+}
+
+func (r *RedisSessionStore) sessionKey(id [16]byte) string {
+	return r.keyPrefix + "session:" + hex.EncodeToString(id[:])
+}
+
+func (r *RedisSessionStore) resumeKey(id [16]byte) string {
+	return r.keyPrefix + "resume:" + hex.EncodeToString(id[:])
+}
+
+func (r *RedisSessionStore) tenantKey(tenantID uint32) string {
+	return r.keyPrefix + "tenant:" + strconv.FormatUint(uint64(tenantID), 10)
+}
+
+// Save stores a session until it would expire.
+func (r *RedisSessionStore) Save(ctx context.Context, session *Session) error {
+	ttl := time.Until(session.expiryDeadline())
+	if ttl <= 0 {
+		return r.Delete(ctx, session.ID)
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+	if err := r.client.Set(ctx, r.sessionKey(session.ID), data, ttl); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	if hash := session.resumeHashHex(); hash != "" {
+		err = r.client.Set(ctx, r.resumeKey(session.ID), []byte(hash), ttl)
+	} else {
+		err = r.client.Del(ctx, r.resumeKey(session.ID))
+	}
+	if err != nil {
+		return fmt.Errorf("save resumption token: %w", err)
+	}
+	if err := r.client.SAdd(ctx, r.tenantKey(session.TenantID), session.IDString()); err != nil {
+		return fmt.Errorf("index session: %w", err)
+	}
+	return nil
+}
+
+// Load retrieves a session.
+func (r *RedisSessionStore) Load(ctx context.Context, id [16]byte) (*Session, error) {
+	data, err := r.client.Get(ctx, r.sessionKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+	return session, nil
+}
+
+// Delete removes a session and its tenant index entry.
+func (r *RedisSessionStore) Delete(ctx context.Context, id [16]byte) error {
+	// Load first to find the tenant index; a missing session is not an error
+	session, loadErr := r.Load(ctx, id)
+	if err := r.client.Del(ctx, r.sessionKey(id), r.resumeKey(id)); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	if loadErr == nil {
+		r.client.SRem(ctx, r.tenantKey(session.TenantID), hex.EncodeToString(id[:]))
+	}
+	return nil
+}
+
+// ConsumeResumeToken deletes the stored token hash if it matches tokenHash.
+func (r *RedisSessionStore) ConsumeResumeToken(ctx context.Context, id [16]byte, tokenHash [32]byte) (bool, error) {
+	return r.client.DelIfEqual(ctx, r.resumeKey(id), []byte(hex.EncodeToString(tokenHash[:])))
+}
+
+// ListByTenant returns a tenant's persisted sessions, pruning index entries
+// whose session key has expired.
+func (r *RedisSessionStore) ListByTenant(ctx context.Context, tenantID uint32) ([]*Session, error) {
+	members, err := r.client.SMembers(ctx, r.tenantKey(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	sessions := make([]*Session, 0, len(members))
+	for _, member := range members {
+		b, err := hex.DecodeString(member)
+		if err != nil || len(b) != 16 {
+			r.client.SRem(ctx, r.tenantKey(tenantID), member)
+			continue
+		}
+		var id [16]byte
+		copy(id[:], b)
+
+		session, err := r.Load(ctx, id)
+		if err != nil {
+			r.client.SRem(ctx, r.tenantKey(tenantID), member)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// ============================================================================
+// POSTGRES SESSION STORE
+// ============================================================================
+
+// PostgresSessionStore persists sessions in the aocs_sessions table
+// (db/master_schema.sql). The caller owns db and registers the driver.
+type PostgresSessionStore struct {
+	db *sql.DB
+}
+
+// NewPostgresSessionStore creates a Postgres-backed session store.
+func NewPostgresSessionStore(db *sql.DB) *PostgresSessionStore {
+	return &PostgresSessionStore{db: db}
+}
+
+// Save upserts a session.
+func (p *PostgresSessionStore) Save(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+
+	session.mu.RLock()
+	state := session.State
+	session.mu.RUnlock()
+
+	var resumeHash sql.NullString
+	if hash := session.resumeHashHex(); hash != "" {
+		resumeHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	_, err = p.db.ExecContext(ctx, `
+		INSERT INTO aocs_sessions (session_id, tenant_id, state, expires_at, data, resume_hash, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (session_id) DO UPDATE SET
+			state = EXCLUDED.state,
+			expires_at = EXCLUDED.expires_at,
+			data = EXCLUDED.data,
+			resume_hash = EXCLUDED.resume_hash,
+			updated_at = NOW()`,
+		session.IDString(), int64(session.TenantID), string(state), session.expiryDeadline(), data, resumeHash)
+	if err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	return nil
+}
+
+// Load retrieves an unexpired session.
+func (p *PostgresSessionStore) Load(ctx context.Context, id [16]byte) (*Session, error) {
+	var data []byte
+	err := p.db.QueryRowContext(ctx,
+		`SELECT data FROM aocs_sessions WHERE session_id = $1 AND expires_at > NOW()`,
+		hex.EncodeToString(id[:])).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, hex.EncodeToString(id[:]))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+	return session, nil
+}
+
+// Delete removes a session.
+func (p *PostgresSessionStore) Delete(ctx context.Context, id [16]byte) error {
+	if _, err := p.db.ExecContext(ctx,
+		`DELETE FROM aocs_sessions WHERE session_id = $1`, hex.EncodeToString(id[:])); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+// ConsumeResumeToken clears the stored token hash if it matches tokenHash.
+// Concurrent updates of the row serialize, so only one sees the match.
+func (p *PostgresSessionStore) ConsumeResumeToken(ctx context.Context, id [16]byte, tokenHash [32]byte) (bool, error) {
+	var sessionID string
+	err := p.db.QueryRowContext(ctx, `
+		UPDATE aocs_sessions
+		SET resume_hash = NULL, data = data - 'resume_hash', updated_at = NOW()
+		WHERE session_id = $1 AND resume_hash = $2 AND expires_at > NOW()
+		RETURNING session_id`,
+		hex.EncodeToString(id[:]), hex.EncodeToString(tokenHash[:])).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("consume resumption token: %w", err)
+	}
+	return true, nil
+}
+
+// ListByTenant returns a tenant's unexpired sessions.
+func (p *PostgresSessionStore) ListByTenant(ctx context.Context, tenantID uint32) ([]*Session, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT data FROM aocs_sessions WHERE tenant_id = $1 AND expires_at > NOW()`, int64(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		session := &Session{}
+		if err := json.Unmarshal(data, session); err != nil {
+			slog.Warn("[PostgresSessionStore] Skipping unreadable session", "error", err)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// PurgeExpired deletes sessions past their expiry and returns how many.
+func (p *PostgresSessionStore) PurgeExpired(ctx context.Context) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM aocs_sessions WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("purge sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
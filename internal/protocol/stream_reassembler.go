@@ -0,0 +1,968 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// STREAMING PAYLOAD REASSEMBLY
+// ============================================================================
+//
+// The protocol parsers expect one complete JSON body. Streaming APIs break
+// that assumption: OpenAI sends tool calls as chat.completion.chunk deltas
+// where the function name and argument fragments arrive across many SSE
+// events, Anthropic sends content_block_start / input_json_delta /
+// content_block_stop, and MCP over streamable HTTP answers tools/call with
+// an SSE stream of JSON-RPC messages, often inside a chunked HTTP body.
+//
+// StreamReassembler consumes the raw bytes of one connection direction,
+// undoes HTTP chunked framing, splits SSE events, rebuilds tool calls from
+// deltas and emits an AIPayload as soon as each call is complete — without
+// waiting for the end of the stream.
+
+// ErrStreamTooLarge is returned when a header block, SSE event, body or tool
+// call argument buffer exceeds the reassembly limit. The reassembler resets
+// and resynchronizes on the next HTTP message.
+var ErrStreamTooLarge = errors.New("stream exceeds reassembly limit")
+
+// maxStreamBuffer bounds every buffer a single stream can grow.
+const maxStreamBuffer = 4 << 20
+
+type streamMode int
+
+const (
+	streamSniff   streamMode = iota // waiting to see what the connection carries
+	streamHeaders                   // reading an HTTP header block
+	streamBody                      // reading a message body
+	streamDiscard                   // not HTTP/SSE/JSON — drop until the next write
+)
+
+const (
+	chunkSize    = iota // expecting a chunk-size line
+	chunkData           // inside chunk data
+	chunkCRLF           // expecting the CRLF after chunk data
+	chunkTrailer        // after the last chunk, skipping trailers
+)
+
+// StreamReassembler rebuilds AI payloads from one streaming connection
+// direction. It is not safe for concurrent use; keep one per connection.
+type StreamReassembler struct {
+	parser *UniversalAIParser
+
+	mode streamMode
+	buf  []byte // wire bytes not yet consumed
+
+	// HTTP message framing
+	header     []byte // raw header block, kept so non-SSE bodies parse like single-shot payloads
+	sse        bool
+	chunked    bool
+	chunkState int
+	chunkLeft  int64
+	remaining  int64 // Content-Length left; -1 when the body runs until close
+
+	// Body decoding
+	body      []byte // partial SSE line, or the accumulated non-SSE body
+	eventName string
+	eventData []byte
+	hasData   bool
+
+	msg      *streamMessage
+	mcpCalls map[string]string // JSON-RPC id -> tool name of tools/call requests seen
+	out      []*AIPayload
+}
+
+// maxPendingMCPCalls bounds the JSON-RPC id correlation map.
+const maxPendingMCPCalls = 256
+
+// streamMessage accumulates the deltas of one streamed model response.
+type streamMessage struct {
+	protocol   AIProtocolType
+	id         string
+	model      string
+	stopReason string
+	calls      map[string]*streamToolCall
+	order      []*streamToolCall
+	emitted    int
+	generation *AIPayload // first complete generation event from a non-delta stream
+}
+
+// streamToolCall is a tool call under construction.
+type streamToolCall struct {
+	protocol AIProtocolType
+	choice   int
+	index    int
+	id       string
+	name     string
+	args     bytes.Buffer
+	initial  map[string]interface{}
+	done     bool
+}
+
+// NewStreamReassembler creates a reassembler for raw connection bytes, such
+// as socket or TLS uprobe captures. HTTP headers are detected and parsed;
+// headerless SSE or JSON data is also accepted.
+func NewStreamReassembler(parser *UniversalAIParser) *StreamReassembler {
+	if parser == nil {
+		parser = NewUniversalAIParser()
+	}
+	return &StreamReassembler{parser: parser}
+}
+
+// NewBodyReassembler creates a reassembler for an already de-framed HTTP
+// body, e.g. an http.Response.Body, with the given Content-Type.
+func NewBodyReassembler(parser *UniversalAIParser, contentType string) *StreamReassembler {
+	s := NewStreamReassembler(parser)
+	s.beginBody(isEventStream(contentType), false, -1)
+	return s
+}
+
+// Write feeds the next bytes of the stream and returns any payloads that
+// became complete. On error the partial state is discarded; payloads
+// completed before the error are still returned.
+func (s *StreamReassembler) Write(p []byte) ([]*AIPayload, error) {
+	if s.mode == streamDiscard {
+		s.mode = streamSniff
+	}
+	s.buf = append(s.buf, p...)
+
+	for len(s.buf) > 0 {
+		progressed, err := s.step()
+		if err != nil {
+			s.reset()
+			return s.takeOutput(), err
+		}
+		if !progressed {
+			break
+		}
+	}
+	if len(s.buf) > maxStreamBuffer {
+		s.reset()
+		return s.takeOutput(), ErrStreamTooLarge
+	}
+	return s.takeOutput(), nil
+}
+
+// Flush ends the stream (connection closed or idle). Tool calls still under
+// construction are emitted with Metadata["truncated"] = true so governance
+// sees them even though the model never finished them.
+func (s *StreamReassembler) Flush() []*AIPayload {
+	if s.mode == streamBody {
+		if s.sse {
+			if s.hasData {
+				s.dispatchEvent()
+			}
+			s.finishMessage(true)
+		} else if len(s.body) > 0 {
+			s.parseBody()
+		}
+	}
+	s.reset()
+	return s.takeOutput()
+}
+
+func (s *StreamReassembler) takeOutput() []*AIPayload {
+	out := s.out
+	s.out = nil
+	return out
+}
+
+func (s *StreamReassembler) reset() {
+	s.mode = streamSniff
+	s.buf = nil
+	s.endMessageState()
+}
+
+func (s *StreamReassembler) endMessageState() {
+	s.header = nil
+	s.sse, s.chunked = false, false
+	s.body = nil
+	s.eventName, s.eventData, s.hasData = "", nil, false
+	s.msg = nil
+}
+
+// step makes one unit of progress; false means more bytes are needed.
+func (s *StreamReassembler) step() (bool, error) {
+	switch s.mode {
+	case streamSniff:
+		return s.sniff(), nil
+	case streamHeaders:
+		return s.readHeaders()
+	case streamBody:
+		return s.readBody()
+	default:
+		s.buf = nil
+		return false, nil
+	}
+}
+
+// ============================================================================
+// HTTP FRAMING
+// ============================================================================
+
+var httpMethods = []string{"GET ", "POST ", "PUT ", "PATCH ", "DELETE ", "HEAD ", "OPTIONS "}
+
+var sseFieldPrefixes = []string{"data:", "event:", "id:", "retry:", ":"}
+
+func (s *StreamReassembler) sniff() bool {
+	start := bytes.TrimLeft(s.buf, "\r\n")
+	if len(start) < 8 && !bytes.ContainsAny(start, "{[") {
+		// Too short to tell an HTTP start line from anything else
+		s.buf = start
+		return false
+	}
+	s.buf = start
+
+	str := string(start[:min(len(start), 8)])
+	if strings.HasPrefix(str, "HTTP/") {
+		s.mode = streamHeaders
+		return true
+	}
+	for _, m := range httpMethods {
+		if strings.HasPrefix(str, m) {
+			s.mode = streamHeaders
+			return true
+		}
+	}
+	for _, f := range sseFieldPrefixes {
+		if strings.HasPrefix(str, f) {
+			s.beginBody(true, false, -1)
+			return true
+		}
+	}
+	if start[0] == '{' || start[0] == '[' {
+		s.beginBody(false, false, -1)
+		return true
+	}
+
+	s.mode = streamDiscard
+	s.buf = nil
+	return false
+}
+
+func (s *StreamReassembler) readHeaders() (bool, error) {
+	end, sepLen := bytes.Index(s.buf, []byte("\r\n\r\n")), 4
+	if end < 0 {
+		end, sepLen = bytes.Index(s.buf, []byte("\n\n")), 2
+	}
+	if end < 0 {
+		if len(s.buf) > 64<<10 {
+			return false, fmt.Errorf("%w: header block", ErrStreamTooLarge)
+		}
+		return false, nil
+	}
+
+	block := s.buf[:end+sepLen]
+	s.buf = s.buf[end+sepLen:]
+
+	lines := strings.Split(strings.ReplaceAll(string(block), "\r\n", "\n"), "\n")
+	isResponse := strings.HasPrefix(lines[0], "HTTP/")
+
+	var contentType string
+	var chunked bool
+	length := int64(-1)
+	for _, line := range lines[1:] {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "content-type":
+			contentType = value
+		case "transfer-encoding":
+			chunked = strings.Contains(strings.ToLower(value), "chunked")
+		case "content-length":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+				length = n
+			}
+		}
+	}
+	if chunked {
+		length = -1
+	} else if length < 0 && !isResponse {
+		// Requests without Content-Length or chunking have no body
+		length = 0
+	}
+	if length > maxStreamBuffer && !isEventStream(contentType) {
+		return false, fmt.Errorf("%w: %d byte body", ErrStreamTooLarge, length)
+	}
+
+	s.beginBody(isEventStream(contentType), chunked, length)
+	s.header = append([]byte(nil), block...)
+	if !chunked && length == 0 {
+		s.endBody()
+	}
+	return true, nil
+}
+
+func (s *StreamReassembler) beginBody(sse, chunked bool, length int64) {
+	s.endMessageState()
+	s.mode = streamBody
+	s.sse = sse
+	s.chunked = chunked
+	s.chunkState = chunkSize
+	s.remaining = length
+}
+
+func (s *StreamReassembler) readBody() (bool, error) {
+	if !s.chunked {
+		n := int64(len(s.buf))
+		if s.remaining >= 0 && n > s.remaining {
+			n = s.remaining
+		}
+		data := s.buf[:n]
+		s.buf = s.buf[n:]
+		if err := s.consumeBody(data); err != nil {
+			return false, err
+		}
+		if s.remaining >= 0 {
+			s.remaining -= n
+			if s.remaining == 0 {
+				s.endBody()
+			}
+		}
+		return n > 0, nil
+	}
+
+	switch s.chunkState {
+	case chunkSize, chunkTrailer:
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			if len(s.buf) > 4096 {
+				return false, fmt.Errorf("malformed chunk framing")
+			}
+			return false, nil
+		}
+		line := strings.TrimSpace(string(s.buf[:i]))
+		s.buf = s.buf[i+1:]
+
+		if s.chunkState == chunkTrailer {
+			if line == "" {
+				s.endBody()
+			}
+			return true, nil
+		}
+		sizeStr, _, _ := strings.Cut(line, ";") // drop chunk extensions
+		size, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 16, 64)
+		if err != nil || size < 0 {
+			return false, fmt.Errorf("malformed chunk size %q", line)
+		}
+		if size == 0 {
+			s.chunkState = chunkTrailer
+		} else {
+			s.chunkState, s.chunkLeft = chunkData, size
+		}
+		return true, nil
+
+	case chunkData:
+		n := min(int64(len(s.buf)), s.chunkLeft)
+		data := s.buf[:n]
+		s.buf = s.buf[n:]
+		s.chunkLeft -= n
+		if s.chunkLeft == 0 {
+			s.chunkState = chunkCRLF
+		}
+		return true, s.consumeBody(data)
+
+	default: // chunkCRLF
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			return false, nil
+		}
+		s.buf = s.buf[i+1:]
+		s.chunkState = chunkSize
+		return true, nil
+	}
+}
+
+// endBody completes the current HTTP message; the connection may carry
+// another one (keep-alive), so go back to sniffing.
+func (s *StreamReassembler) endBody() {
+	if s.sse {
+		if s.hasData {
+			s.dispatchEvent()
+		}
+		s.finishMessage(false)
+	} else if len(s.body) > 0 {
+		s.parseBody()
+	}
+	s.endMessageState()
+	s.mode = streamSniff
+}
+
+// ============================================================================
+// BODY DECODING
+// ============================================================================
+
+func (s *StreamReassembler) consumeBody(data []byte) error {
+	s.body = append(s.body, data...)
+	if len(s.body) > maxStreamBuffer {
+		return fmt.Errorf("%w: body", ErrStreamTooLarge)
+	}
+
+	if !s.sse {
+		// Close-delimited or headerless JSON: parse once the document is whole
+		if s.remaining < 0 && !s.chunked && json.Valid(s.body) {
+			s.endBody()
+		}
+		return nil
+	}
+
+	for {
+		i := bytes.IndexByte(s.body, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(s.body[:i], []byte("\r"))
+		s.body = s.body[i+1:]
+		if err := s.sseLine(line); err != nil {
+			return err
+		}
+	}
+	// Keep the partial line in a fresh slice so the consumed prefix is freed
+	s.body = append([]byte(nil), s.body...)
+	return nil
+}
+
+// sseLine applies one line of the text/event-stream format.
+func (s *StreamReassembler) sseLine(line []byte) error {
+	if len(line) == 0 {
+		if s.hasData {
+			s.dispatchEvent()
+		}
+		s.eventName, s.eventData, s.hasData = "", nil, false
+		return nil
+	}
+	if line[0] == ':' {
+		return nil // comment / keep-alive
+	}
+
+	field, value, _ := bytes.Cut(line, []byte(":"))
+	value = bytes.TrimPrefix(value, []byte(" "))
+	switch string(field) {
+	case "event":
+		s.eventName = string(value)
+	case "data":
+		if s.hasData {
+			s.eventData = append(s.eventData, '\n')
+		}
+		s.eventData = append(s.eventData, value...)
+		s.hasData = true
+		if len(s.eventData) > maxStreamBuffer {
+			return fmt.Errorf("%w: event", ErrStreamTooLarge)
+		}
+	}
+	return nil
+}
+
+func (s *StreamReassembler) parseBody() {
+	if payload := s.parseMessage(append(s.header, s.body...)); payload != nil {
+		s.emit(payload)
+	}
+}
+
+// parseMessage parses one complete JSON message. Beyond the universal
+// parser, it recognizes MCP JSON-RPC responses — which carry only an id —
+// as tool results, naming the tool from a tools/call seen earlier on this
+// stream.
+func (s *StreamReassembler) parseMessage(data []byte) *AIPayload {
+	payload := s.parser.Parse(data)
+	if payload.Protocol == ProtoMCP && payload.RawMethod == "tools/call" {
+		if id := jsonRPCID(data); id != "" {
+			if s.mcpCalls == nil || len(s.mcpCalls) >= maxPendingMCPCalls {
+				s.mcpCalls = make(map[string]string)
+			}
+			s.mcpCalls[id] = payload.ToolName
+		}
+	}
+	if payload.Protocol != ProtoRaw {
+		return payload
+	}
+
+	start := findJSONStart(data)
+	if start < 0 {
+		return nil
+	}
+	var resp struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  *struct {
+			Content json.RawMessage `json:"content"`
+			IsError bool            `json:"isError"`
+		} `json:"result"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data[start:], &resp) != nil || resp.JSONRPC != "2.0" || len(resp.ID) == 0 {
+		return nil
+	}
+	id := string(resp.ID)
+	tool, known := s.mcpCalls[id]
+	if !known && (resp.Result == nil || resp.Result.Content == nil) {
+		// Not demonstrably a tools/call result (e.g. initialize, tools/list)
+		return nil
+	}
+	delete(s.mcpCalls, id)
+
+	result := &AIPayload{
+		Protocol:    ProtoMCP,
+		ToolName:    tool,
+		MessageType: "tool_result",
+		Direction:   "response",
+		Confidence:  0.95,
+		DetectedAt:  time.Now(),
+		CallID:      strings.Trim(id, `"`),
+		Metadata:    map[string]interface{}{"jsonrpc_id": id},
+	}
+	if !known {
+		result.ToolName = "unknown_tool"
+		result.Confidence = 0.85
+	}
+	if resp.Error != nil {
+		result.Metadata["is_error"] = true
+		result.Metadata["error_message"] = resp.Error.Message
+	} else {
+		result.Metadata["is_error"] = resp.Result.IsError
+	}
+	return result
+}
+
+func jsonRPCID(data []byte) string {
+	start := findJSONStart(data)
+	if start < 0 {
+		return ""
+	}
+	var msg struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(data[start:], &msg) != nil {
+		return ""
+	}
+	return string(msg.ID)
+}
+
+// ============================================================================
+// EVENT ASSEMBLY
+// ============================================================================
+
+// streamEvent covers the fields of OpenAI and Anthropic streaming events.
+type streamEvent struct {
+	Type   string          `json:"type"`
+	Object string          `json:"object"`
+	ID     json.RawMessage `json:"id"` // string for OpenAI, any JSON-RPC id for MCP
+	Model  string          `json:"model"`
+
+	// OpenAI chat.completion.chunk
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+			FunctionCall *struct {
+				Name      string `json:"name"`
+				Arguments string `json:"arguments"`
+			} `json:"function_call"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+
+	// Anthropic Messages streaming
+	Message *struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+	} `json:"message"`
+	Index        int             `json:"index"`
+	ContentBlock *anthropicBlock `json:"content_block"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+}
+
+func (s *StreamReassembler) dispatchEvent() {
+	data := bytes.TrimSpace(s.eventData)
+	s.eventName, s.eventData, s.hasData = "", nil, false
+
+	if string(data) == "[DONE]" {
+		s.finishMessage(false)
+		return
+	}
+
+	var ev streamEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return // not JSON — ignore (e.g. plain-text SSE)
+	}
+
+	switch {
+	case ev.Object == "chat.completion.chunk" || (len(ev.Choices) > 0 && ev.Type == ""):
+		s.openAIChunk(&ev)
+	case strings.HasPrefix(ev.Type, "message_") || strings.HasPrefix(ev.Type, "content_block_") || ev.Type == "ping":
+		s.anthropicEvent(&ev)
+	default:
+		// Events that are complete messages on their own: MCP JSON-RPC over
+		// streamable HTTP, Gemini alt=sse chunks, A2A task updates.
+		payload := s.parseMessage(data)
+		if payload == nil {
+			return
+		}
+		payload.Metadata = withMetadata(payload.Metadata, "streamed", true)
+		msg := s.message(payload.Protocol)
+		if payload.MessageType == "generation" {
+			// One payload per generation stream, not one per text chunk
+			if msg.generation == nil {
+				msg.generation = payload
+			}
+			return
+		}
+		msg.emitted++
+		s.emit(payload)
+	}
+}
+
+func (s *StreamReassembler) message(proto AIProtocolType) *streamMessage {
+	if s.msg == nil {
+		s.msg = &streamMessage{protocol: proto, calls: make(map[string]*streamToolCall)}
+	}
+	return s.msg
+}
+
+func (s *StreamReassembler) openAIChunk(ev *streamEvent) {
+	msg := s.message(ProtoOpenAI)
+	var id string
+	if json.Unmarshal(ev.ID, &id) == nil && id != "" {
+		msg.id = id
+	}
+	if ev.Model != "" {
+		msg.model = ev.Model
+	}
+
+	for _, ch := range ev.Choices {
+		for _, tc := range ch.Delta.ToolCalls {
+			// OpenAI streams one call at a time: a new index completes the earlier ones
+			for _, prev := range msg.order {
+				if prev.choice == ch.Index && prev.index < tc.Index {
+					s.completeCall(prev, false)
+				}
+			}
+			call := s.toolCall(msg, ProtoOpenAI, ch.Index, tc.Index)
+			if tc.ID != "" {
+				call.id = tc.ID
+			}
+			if call.name == "" {
+				call.name = tc.Function.Name
+			}
+			call.args.WriteString(tc.Function.Arguments)
+		}
+		if fc := ch.Delta.FunctionCall; fc != nil {
+			call := s.toolCall(msg, ProtoOpenAI, ch.Index, 0)
+			if call.name == "" {
+				call.name = fc.Name
+			}
+			call.args.WriteString(fc.Arguments)
+		}
+
+		if ch.FinishReason != "" {
+			msg.stopReason = ch.FinishReason
+			for _, call := range msg.order {
+				if call.choice == ch.Index {
+					s.completeCall(call, false)
+				}
+			}
+		}
+	}
+}
+
+func (s *StreamReassembler) anthropicEvent(ev *streamEvent) {
+	msg := s.message(ProtoAnthropic)
+
+	switch ev.Type {
+	case "message_start":
+		if ev.Message != nil {
+			msg.id, msg.model = ev.Message.ID, ev.Message.Model
+		}
+	case "content_block_start":
+		if b := ev.ContentBlock; b != nil && (b.Type == "tool_use" || b.Type == "server_tool_use") {
+			call := s.toolCall(msg, ProtoAnthropic, 0, ev.Index)
+			call.id, call.name, call.initial = b.ID, b.Name, b.Input
+		}
+	case "content_block_delta":
+		if ev.Delta != nil && ev.Delta.Type == "input_json_delta" {
+			if call := msg.calls[callKey(0, ev.Index)]; call != nil {
+				call.args.WriteString(ev.Delta.PartialJSON)
+			}
+		}
+	case "content_block_stop":
+		if call := msg.calls[callKey(0, ev.Index)]; call != nil {
+			s.completeCall(call, false)
+		}
+	case "message_delta":
+		if ev.Delta != nil && ev.Delta.StopReason != "" {
+			msg.stopReason = ev.Delta.StopReason
+		}
+	case "message_stop":
+		s.finishMessage(false)
+	}
+}
+
+func callKey(choice, index int) string {
+	return strconv.Itoa(choice) + "/" + strconv.Itoa(index)
+}
+
+func (s *StreamReassembler) toolCall(msg *streamMessage, proto AIProtocolType, choice, index int) *streamToolCall {
+	key := callKey(choice, index)
+	call, ok := msg.calls[key]
+	if !ok {
+		call = &streamToolCall{protocol: proto, choice: choice, index: index}
+		msg.calls[key] = call
+		msg.order = append(msg.order, call)
+	}
+	return call
+}
+
+// completeCall emits a finished tool call. truncated marks calls cut off by
+// the end of the stream rather than closed by the model.
+func (s *StreamReassembler) completeCall(call *streamToolCall, truncated bool) {
+	if call.done {
+		return
+	}
+	call.done = true
+	msg := s.msg
+
+	payload := &AIPayload{
+		Protocol:    call.protocol,
+		ToolName:    call.name,
+		TaskID:      msg.id,
+		Model:       msg.model,
+		MessageType: "tool_call",
+		Direction:   "response",
+		Confidence:  0.97,
+		DetectedAt:  time.Now(),
+		Metadata: map[string]interface{}{
+			"streamed":   true,
+			"tool_index": call.index,
+		},
+	}
+	if call.protocol == ProtoAnthropic {
+		payload.RawMethod = "messages"
+		payload.Metadata["tool_use_id"] = call.id
+	} else {
+		payload.RawMethod = "chat.completions"
+		payload.Metadata["tool_call_id"] = call.id
+	}
+	if payload.ToolName == "" {
+		payload.ToolName = "unknown_tool"
+		payload.Confidence = 0.80
+	}
+	payload.CallID = call.id
+
+	status := "complete"
+	raw := bytes.TrimSpace(call.args.Bytes())
+	if len(raw) == 0 {
+		payload.Arguments = call.initial
+	} else if err := json.Unmarshal(raw, &payload.Arguments); err != nil {
+		// Keep the fragment for inspection; governance must not see "no args"
+		status = "invalid_args"
+		payload.Metadata["arguments_raw"] = string(raw[:min(len(raw), 4096)])
+		payload.Metadata["arguments_error"] = err.Error()
+		payload.Confidence = 0.80
+	}
+	if truncated {
+		status = "truncated"
+		payload.Metadata["truncated"] = true
+		payload.Confidence = min(payload.Confidence, 0.80)
+	}
+
+	if payload.CallID == "" {
+		payload.CallID = StableCallID(payload, call.index)
+	}
+
+	streamToolCalls.WithLabelValues(string(call.protocol), status).Inc()
+	msg.emitted++
+	s.emit(payload)
+}
+
+// finishMessage flushes pending tool calls at the end of a streamed
+// response. A response that produced no tool calls yields one generation
+// payload so callers still learn the protocol and model.
+func (s *StreamReassembler) finishMessage(truncated bool) {
+	msg := s.msg
+	if msg == nil {
+		return
+	}
+	for _, call := range msg.order {
+		s.completeCall(call, truncated)
+	}
+
+	if msg.emitted == 0 {
+		payload := msg.generation
+		if payload == nil && (msg.protocol == ProtoOpenAI || msg.protocol == ProtoAnthropic) {
+			payload = &AIPayload{
+				Protocol:    msg.protocol,
+				ToolName:    "llm_completion",
+				TaskID:      msg.id,
+				Model:       msg.model,
+				MessageType: "generation",
+				Direction:   "response",
+				Confidence:  0.90,
+				DetectedAt:  time.Now(),
+				Metadata:    map[string]interface{}{"streamed": true},
+			}
+		}
+		if payload != nil {
+			if msg.stopReason != "" {
+				payload.Metadata["stop_reason"] = msg.stopReason
+			}
+			s.emit(payload)
+		}
+	}
+	s.msg = nil
+}
+
+func (s *StreamReassembler) emit(p *AIPayload) {
+	s.out = append(s.out, p)
+}
+
+func withMetadata(m map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	m[key] = value
+	return m
+}
+
+func isEventStream(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "text/event-stream")
+}
+
+// ============================================================================
+// PER-CONNECTION TRACKING
+// ============================================================================
+
+// StreamTracker keeps one StreamReassembler per connection key (4-tuple,
+// pid/tid, ...) for capture paths that see interleaved traffic from many
+// connections. Streams idle past idleTimeout are flushed and dropped.
+type StreamTracker struct {
+	parser      *UniversalAIParser
+	idleTimeout time.Duration
+	maxStreams  int
+
+	mu        sync.Mutex
+	streams   map[string]*trackedStream
+	lastSweep time.Time
+}
+
+type trackedStream struct {
+	r        *StreamReassembler
+	lastSeen time.Time
+}
+
+// NewStreamTracker creates a tracker. Zero values default to a 2 minute
+// idle timeout and 10000 concurrent streams.
+func NewStreamTracker(parser *UniversalAIParser, idleTimeout time.Duration, maxStreams int) *StreamTracker {
+	if parser == nil {
+		parser = NewUniversalAIParser()
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = 2 * time.Minute
+	}
+	if maxStreams <= 0 {
+		maxStreams = 10000
+	}
+	return &StreamTracker{
+		parser:      parser,
+		idleTimeout: idleTimeout,
+		maxStreams:  maxStreams,
+		streams:     make(map[string]*trackedStream),
+		lastSweep:   time.Now(),
+	}
+}
+
+// Feed appends chunk to the stream identified by key and returns payloads
+// completed by it. Chunks for one key must be fed in order.
+func (t *StreamTracker) Feed(key string, chunk []byte) ([]*AIPayload, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.lastSweep) > t.idleTimeout {
+		t.sweepLocked(now)
+	}
+
+	ts, ok := t.streams[key]
+	if !ok {
+		if len(t.streams) >= t.maxStreams {
+			t.evictOldestLocked()
+		}
+		ts = &trackedStream{r: NewStreamReassembler(t.parser)}
+		t.streams[key] = ts
+	}
+	ts.lastSeen = now
+	return ts.r.Write(chunk)
+}
+
+// Close flushes and forgets the stream for key, e.g. on connection close.
+func (t *StreamTracker) Close(key string) []*AIPayload {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ts, ok := t.streams[key]
+	if !ok {
+		return nil
+	}
+	delete(t.streams, key)
+	return ts.r.Flush()
+}
+
+// Len returns the number of tracked streams.
+func (t *StreamTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.streams)
+}
+
+func (t *StreamTracker) sweepLocked(now time.Time) {
+	t.lastSweep = now
+	for key, ts := range t.streams {
+		if now.Sub(ts.lastSeen) > t.idleTimeout {
+			t.dropLocked(key, ts, "idle")
+		}
+	}
+}
+
+func (t *StreamTracker) evictOldestLocked() {
+	var oldestKey string
+	var oldest *trackedStream
+	for key, ts := range t.streams {
+		if oldest == nil || ts.lastSeen.Before(oldest.lastSeen) {
+			oldestKey, oldest = key, ts
+		}
+	}
+	if oldest != nil {
+		t.dropLocked(oldestKey, oldest, "capacity")
+	}
+}
+
+func (t *StreamTracker) dropLocked(key string, ts *trackedStream, reason string) {
+	delete(t.streams, key)
+	if lost := ts.r.Flush(); len(lost) > 0 {
+		slog.Warn("[StreamTracker] Dropped stream with incomplete payloads",
+			"stream", key, "reason", reason, "payloads", len(lost))
+	}
+}
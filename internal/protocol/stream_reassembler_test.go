@@ -0,0 +1,211 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const openAIToolStream = `data: {"id":"chatcmpl-9","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_pay","type":"function","function":{"name":"execute_payment","arguments":""}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"amount\":"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":" 250, \"currency\": \"USD\"}"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_mail","type":"function","function":{"name":"send_email","arguments":"{\"to\":"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"\"cfo@example.com\"}"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+
+const anthropicToolStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_014p7","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[],"stop_reason":null}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking the weather."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_01T1x","name":"get_weather","input":{}}}
+
+event: ping
+data: {"type": "ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"location\": \"San Fra"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"ncisco, CA\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":89}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+// chunked wraps body in HTTP/1.1 chunked framing with fixed-size chunks.
+func chunked(body string, size int) string {
+	var b strings.Builder
+	for len(body) > 0 {
+		n := min(size, len(body))
+		fmt.Fprintf(&b, "%x\r\n%s\r\n", n, body[:n])
+		body = body[n:]
+	}
+	b.WriteString("0\r\n\r\n")
+	return b.String()
+}
+
+// feed writes stream in pieces of the given size and collects payloads.
+func feed(t *testing.T, r *StreamReassembler, stream string, piece int) []*AIPayload {
+	t.Helper()
+	var out []*AIPayload
+	for len(stream) > 0 {
+		n := min(piece, len(stream))
+		got, err := r.Write([]byte(stream[:n]))
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		out = append(out, got...)
+		stream = stream[n:]
+	}
+	return out
+}
+
+func TestStreamReassemblerOpenAIChunkedSSE(t *testing.T) {
+	wire := "HTTP/1.1 200 OK\r\nContent-Type: text/event-stream; charset=utf-8\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		chunked(openAIToolStream, 97)
+
+	// Every split point must give the same result
+	for _, piece := range []int{1, 7, 64, 4096} {
+		t.Run(fmt.Sprintf("piece=%d", piece), func(t *testing.T) {
+			out := feed(t, NewStreamReassembler(nil), wire, piece)
+			if len(out) != 2 {
+				t.Fatalf("got %d payloads, want 2", len(out))
+			}
+
+			pay, mail := out[0], out[1]
+			if pay.Protocol != ProtoOpenAI || pay.ToolName != "execute_payment" || pay.MessageType != "tool_call" {
+				t.Errorf("first payload = %s/%s/%s", pay.Protocol, pay.ToolName, pay.MessageType)
+			}
+			if !reflect.DeepEqual(pay.Arguments, map[string]interface{}{"amount": 250.0, "currency": "USD"}) {
+				t.Errorf("arguments = %v", pay.Arguments)
+			}
+			if pay.Metadata["tool_call_id"] != "call_pay" || pay.Model != "gpt-4o" {
+				t.Errorf("metadata = %v model = %s", pay.Metadata, pay.Model)
+			}
+			if mail.ToolName != "send_email" || mail.Arguments["to"] != "cfo@example.com" {
+				t.Errorf("second payload = %s %v", mail.ToolName, mail.Arguments)
+			}
+		})
+	}
+}
+
+func TestStreamReassemblerEmitsBeforeStreamEnds(t *testing.T) {
+	r := NewStreamReassembler(nil)
+
+	// The first call completes as soon as the second one starts
+	cut := strings.Index(openAIToolStream, `"index":1`)
+	cut += strings.Index(openAIToolStream[cut:], "\n\n") + 2
+	out := feed(t, r, openAIToolStream[:cut], 4096)
+	if len(out) != 1 || out[0].ToolName != "execute_payment" {
+		t.Fatalf("expected execute_payment before stream end, got %d payloads", len(out))
+	}
+
+	// Connection drops mid-call: the partial call is still surfaced
+	out = feed(t, r, openAIToolStream[cut:strings.Index(openAIToolStream, "finish_reason\":\"tool_calls")], 4096)
+	out = append(out, r.Flush()...)
+	if len(out) != 1 || out[0].ToolName != "send_email" || out[0].Metadata["truncated"] != true {
+		t.Fatalf("expected truncated send_email on flush, got %+v", out)
+	}
+}
+
+func TestStreamReassemblerAnthropic(t *testing.T) {
+	for _, piece := range []int{3, 4096} {
+		out := feed(t, NewBodyReassembler(nil, "text/event-stream"), anthropicToolStream, piece)
+		if len(out) != 1 {
+			t.Fatalf("got %d payloads, want 1", len(out))
+		}
+		p := out[0]
+		if p.Protocol != ProtoAnthropic || p.ToolName != "get_weather" || p.TaskID != "msg_014p7" {
+			t.Errorf("payload = %s/%s task=%s", p.Protocol, p.ToolName, p.TaskID)
+		}
+		if p.Arguments["location"] != "San Francisco, CA" || p.Metadata["tool_use_id"] != "toolu_01T1x" {
+			t.Errorf("arguments = %v metadata = %v", p.Arguments, p.Metadata)
+		}
+	}
+}
+
+func TestStreamReassemblerTextOnlyStream(t *testing.T) {
+	stream := `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o-mini","choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" +
+		`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o-mini","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}` + "\n\n" +
+		"data: [DONE]\n\n"
+
+	out := feed(t, NewStreamReassembler(nil), stream, 16)
+	if len(out) != 1 || out[0].MessageType != "generation" || out[0].Model != "gpt-4o-mini" {
+		t.Fatalf("expected one generation payload, got %+v", out)
+	}
+}
+
+func TestStreamReassemblerMCPStreamableHTTP(t *testing.T) {
+	result := `{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"42 rows"}]}}`
+	sse := ": keep-alive\n\nevent: message\nid: 1\ndata: " + result + "\n\n"
+	call := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"query_db","arguments":{}}}`
+	wire := fmt.Sprintf("POST /mcp HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(call), call) +
+		"HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nTransfer-Encoding: chunked\r\n\r\n" + chunked(sse, 20)
+
+	out := feed(t, NewStreamReassembler(nil), wire, 11)
+	if len(out) != 2 {
+		t.Fatalf("got %d payloads, want request + streamed result", len(out))
+	}
+	if out[0].Protocol != ProtoMCP || out[0].ToolName != "query_db" {
+		t.Errorf("request payload = %s/%s", out[0].Protocol, out[0].ToolName)
+	}
+	if res := out[1]; res.Protocol != ProtoMCP || res.MessageType != "tool_result" || res.ToolName != "query_db" || res.Metadata["streamed"] != true {
+		t.Errorf("streamed payload = %s/%s/%s %v", res.Protocol, res.MessageType, res.ToolName, res.Metadata)
+	}
+}
+
+func TestStreamTrackerSeparatesConnections(t *testing.T) {
+	tracker := NewStreamTracker(nil, 0, 0)
+	a, b := anthropicToolStream, openAIToolStream
+
+	var out []*AIPayload
+	for len(a) > 0 || len(b) > 0 {
+		for _, s := range []struct {
+			key string
+			rem *string
+		}{{"a", &a}, {"b", &b}} {
+			n := min(50, len(*s.rem))
+			got, err := tracker.Feed(s.key, []byte((*s.rem)[:n]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, got...)
+			*s.rem = (*s.rem)[n:]
+		}
+	}
+	if len(out) != 3 {
+		t.Fatalf("got %d payloads from interleaved streams, want 3", len(out))
+	}
+	if tracker.Close("a"); tracker.Len() != 1 {
+		t.Errorf("Len = %d after Close, want 1", tracker.Len())
+	}
+}
@@ -17,6 +17,7 @@ type AgentReputation struct {
 	FirstSeen              time.Time
 	Status                 string
 	Blacklisted            bool
+	ImportedFrom           string // issuing instance of an imported reputation
 }
 
 // ReputationStore defines the interface for any reputation backend (SQLite, Spanner)
@@ -2,6 +2,7 @@ package reputation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -87,6 +88,17 @@ type ReputationManager struct {
 
 	// Attestation freshness: "tenantID:agentID" -> attestation
 	attestations map[string]*AttestationRecord
+
+	// Reputation imported from another instance: "tenantID:agentID" -> prior
+	imported map[string]*importedPrior
+}
+
+// importedPrior is a reputation carried over from another OCX instance. It
+// counts as Weight interactions at Score until local history outweighs it.
+type importedPrior struct {
+	Score  float64
+	Weight float64
+	Source string
 }
 
 // AgentReputation represents the reputation of an agent
@@ -135,6 +147,7 @@ func NewReputationManagerWithConfig(cfg ReputationManagerConfig) *ReputationMana
 		interactions: make(map[string]*InteractionRecord),
 		auditScores:  make(map[string]*AuditScore),
 		attestations: make(map[string]*AttestationRecord),
+		imported:     make(map[string]*importedPrior),
 	}
 }
 
@@ -181,6 +194,10 @@ func (rm *ReputationManager) updateReputation(tenantID, agentID string, success
 	// Calculate reputation score
 	// Formula: (successful / total) with decay for old interactions
 	successRate := float64(rep.SuccessfulInteractions) / float64(rep.TotalInteractions)
+	if prior, ok := rm.imported[key]; ok {
+		successRate = (prior.Score*prior.Weight + float64(rep.SuccessfulInteractions)) /
+			(prior.Weight + float64(rep.TotalInteractions))
+	}
 
 	// Apply time decay (older reputations decay slightly)
 	age := time.Since(rep.FirstSeen)
@@ -195,6 +212,40 @@ func (rm *ReputationManager) updateReputation(tenantID, agentID string, success
 	rep.LastUpdated = time.Now()
 }
 
+// ErrLocalHistory is returned when importing a reputation for an agent that
+// already has local history; local history always wins.
+var ErrLocalHistory = errors.New("agent already has local reputation history")
+
+// ImportReputation seeds a new agent's reputation with a score earned on
+// another instance (tenant-scoped). The score counts as weight interactions,
+// so local interactions progressively replace it.
+func (rm *ReputationManager) ImportReputation(tenantID, agentID string, score, weight float64, source string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if tenantID == "" {
+		return fmt.Errorf("tenantID is required")
+	}
+	if score < 0 || score > 1 {
+		return fmt.Errorf("imported score %.2f outside [0, 1]", score)
+	}
+
+	key := fmt.Sprintf("%s:%s", tenantID, agentID)
+	if rep, exists := rm.reputations[key]; exists && (rep.TotalInteractions > 0 || rep.Blacklisted) {
+		return ErrLocalHistory
+	}
+
+	rm.imported[key] = &importedPrior{Score: score, Weight: weight, Source: source}
+	rm.reputations[key] = &AgentReputation{
+		AgentID:         agentID,
+		ReputationScore: score,
+		FirstSeen:       time.Now(),
+		LastUpdated:     time.Now(),
+		ImportedFrom:    source,
+	}
+	return nil
+}
+
 // GetReputationScore returns the reputation score for an agent in a specific tenant
 func (rm *ReputationManager) GetReputationScore(tenantID, agentID string) float64 {
 	rm.mu.RLock()
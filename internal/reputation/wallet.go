@@ -202,6 +202,38 @@ func (w *ReputationWallet) GetTrustScore(ctx context.Context, agentID, tenantID
 	return w.getNewAgentDefaultScore(tenantID), nil
 }
 
+// ImportScore sets the trust score of an agent this instance has no score
+// for yet, e.g. from a reputation credential issued by a federated peer. It
+// reports false and leaves the score unchanged for a known agent.
+func (w *ReputationWallet) ImportScore(ctx context.Context, agentID, tenantID string, score float64, source string) (bool, error) {
+	if score < 0 || score > 1 {
+		return false, fmt.Errorf("imported score %.2f outside [0, 1]", score)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := tenantID + ":" + agentID
+	if _, ok := w.cache[key]; ok {
+		return false, nil
+	}
+	if w.db != nil {
+		agent, err := w.db.GetAgent(ctx, tenantID, agentID)
+		if err != nil {
+			return false, fmt.Errorf("look up agent %s: %w", agentID, err)
+		}
+		if agent != nil {
+			w.cache[key] = agent.TrustScore
+			return false, nil
+		}
+	}
+
+	w.setScore(ctx, agentID, tenantID, score)
+	w.ledger.Append(tenantID, "IMPORT", fmt.Sprintf("Agent: %s, Source: %s, New: %.4f", agentID, source, score))
+	slog.Info("Imported trust score", "agent_id", agentID, "tenant_id", tenantID, "source", source, "score", score)
+	return true, nil
+}
+
 // LevyTax deducts reputation points and persists to Supabase.
 func (w *ReputationWallet) LevyTax(ctx context.Context, agentID, tenantID string, amount float64, reason string) (float64, error) {
 	w.mu.Lock()
@@ -0,0 +1,777 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/ocx/backend/internal/fabric"
+	"github.com/ocx/backend/internal/protocol"
+)
+
+// ============================================================================
+// AGENT CARDS - A2A peer discovery, card signatures and declared skills
+// ============================================================================
+//
+// An A2A peer describes itself in an agent card. The registry fetches the
+// card from the peer's well-known path, verifies its JWS signatures against
+// the peer's JWKS, and caches the result. Every tool the agent then invokes
+// is checked against the skills the card declares: an agent that advertised
+// "currency conversion" and starts calling delete_records is either
+// compromised or misrepresenting itself.
+//
+// Bindings belong to the tenant that registered them: an agent ID bound by
+// one tenant says nothing about the same ID in another. Card and JWKS URLs
+// come from tenants, so the default HTTP client only connects to public
+// addresses.
+
+// AgentCardAction is what a card finding does to the call that raised it.
+type AgentCardAction string
+
+const (
+	CardActionFlag   AgentCardAction = "FLAG"   // reported, call proceeds
+	CardActionEscrow AgentCardAction = "ESCROW" // held for review
+	CardActionBlock  AgentCardAction = "BLOCK"
+)
+
+func (a AgentCardAction) severity() int {
+	switch a {
+	case CardActionBlock:
+		return 2
+	case CardActionEscrow:
+		return 1
+	}
+	return 0
+}
+
+// ParseAgentCardAction accepts flag | escrow | block in any case and returns
+// "" for anything else.
+func ParseAgentCardAction(s string) AgentCardAction {
+	switch a := AgentCardAction(strings.ToUpper(strings.TrimSpace(s))); a {
+	case CardActionFlag, CardActionEscrow, CardActionBlock:
+		return a
+	}
+	return ""
+}
+
+// AgentCardSignalKind classifies a card finding.
+type AgentCardSignalKind string
+
+const (
+	CardSignalUnsigned         AgentCardSignalKind = "CARD_UNSIGNED"
+	CardSignalInvalidSignature AgentCardSignalKind = "CARD_INVALID_SIGNATURE"
+	CardSignalSkillMismatch    AgentCardSignalKind = "SKILL_MISMATCH"
+)
+
+// AgentCardSignal is one governance finding about an agent's invocation.
+type AgentCardSignal struct {
+	Kind     AgentCardSignalKind `json:"kind"`
+	AgentID  string              `json:"agent_id"`
+	ToolName string              `json:"tool_name,omitempty"`
+	Detail   string              `json:"detail"`
+	Action   AgentCardAction     `json:"action"`
+}
+
+// StrictestCardSignal returns the signal with the most severe action, or
+// nil when there are none.
+func StrictestCardSignal(signals []AgentCardSignal) *AgentCardSignal {
+	var worst *AgentCardSignal
+	for i := range signals {
+		if worst == nil || signals[i].Action.severity() > worst.Action.severity() {
+			worst = &signals[i]
+		}
+	}
+	return worst
+}
+
+// AgentCardConfig configures card discovery and enforcement.
+type AgentCardConfig struct {
+	CacheTTL     time.Duration
+	FetchTimeout time.Duration
+
+	UnsignedAction         AgentCardAction
+	InvalidSignatureAction AgentCardAction
+	MismatchAction         AgentCardAction
+
+	// TrustedJWKS pins the key set for a card host (host -> JWKS URL).
+	// Without a pin the signature's jku is used when it is on the card's
+	// origin, else <origin>/.well-known/jwks.json.
+	TrustedJWKS map[string]string
+
+	// AllowPrivateNetworks lets the default client fetch cards from
+	// loopback, private and link-local addresses (development only).
+	AllowPrivateNetworks bool
+
+	// HTTPClient replaces the default client, including its address checks.
+	HTTPClient *http.Client
+}
+
+// VerifiedAgentCard is a fetched card and the outcome of verifying it.
+type VerifiedAgentCard struct {
+	TenantID    string             `json:"tenant_id"`
+	AgentID     string             `json:"agent_id"`
+	CardURL     string             `json:"card_url"`
+	Card        protocol.AgentCard `json:"card"`
+	Signed      bool               `json:"signed"`
+	Verified    bool               `json:"verified"`
+	KeyID       string             `json:"key_id,omitempty"`
+	Algorithm   string             `json:"algorithm,omitempty"`
+	VerifyError string             `json:"verify_error,omitempty"`
+	FetchedAt   time.Time          `json:"fetched_at"`
+	ExpiresAt   time.Time          `json:"expires_at"`
+	Mismatches  int64              `json:"mismatches"`
+	LastSignal  *AgentCardSignal   `json:"last_signal,omitempty"`
+}
+
+// wellKnownCardPaths are tried in order when a peer is registered by base URL.
+var wellKnownCardPaths = []string{"/.well-known/agent-card.json", "/.well-known/agent.json"}
+
+// a2aProtocolTools are the names the A2A parser gives protocol operations;
+// they are not skills and never mismatch.
+var a2aProtocolTools = map[string]bool{
+	"agent_task":       true,
+	"task_status":      true,
+	"task_cancel":      true,
+	"_agent_discovery": true,
+}
+
+const maxCardBytes = 1 << 20
+
+// ErrNonPublicAddress is returned when a card or JWKS URL resolves to an
+// address the registry may not connect to.
+var ErrNonPublicAddress = errors.New("address is not publicly routable")
+
+// cardBinding identifies an agent as registered by one tenant.
+type cardBinding struct {
+	tenantID string
+	agentID  string
+}
+
+// AgentCardRegistry resolves, verifies and caches A2A peers' agent cards
+// and checks invocations against their declared skills. It implements
+// fabric.RouteInspector so hub routing sees the same signals as /govern.
+type AgentCardRegistry struct {
+	cfg    AgentCardConfig
+	client *http.Client
+	parser *protocol.UniversalAIParser
+
+	mu       sync.RWMutex
+	bindings map[cardBinding]string // tenant + agent ID -> card URL
+	cards    map[cardBinding]*VerifiedAgentCard
+	jwks     map[string]*cachedJWKS
+}
+
+type cachedJWKS struct {
+	keys      map[string]crypto.PublicKey
+	expiresAt time.Time
+}
+
+// NewAgentCardRegistry creates a registry. Zero durations and empty
+// actions take the defaults: 1h cache, 5s fetch, unsigned cards flagged,
+// bad signatures blocked and skill mismatches escrowed.
+func NewAgentCardRegistry(cfg AgentCardConfig) *AgentCardRegistry {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = time.Hour
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = 5 * time.Second
+	}
+	if cfg.UnsignedAction == "" {
+		cfg.UnsignedAction = CardActionFlag
+	}
+	if cfg.InvalidSignatureAction == "" {
+		cfg.InvalidSignatureAction = CardActionBlock
+	}
+	if cfg.MismatchAction == "" {
+		cfg.MismatchAction = CardActionEscrow
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		dialer := &net.Dialer{Timeout: cfg.FetchTimeout}
+		if !cfg.AllowPrivateNetworks {
+			dialer.Control = refuseNonPublic
+		}
+		// No proxy: the address check must see the card host itself
+		client = &http.Client{Timeout: cfg.FetchTimeout, Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: cfg.FetchTimeout,
+		}}
+	}
+	return &AgentCardRegistry{
+		cfg:      cfg,
+		client:   client,
+		parser:   protocol.NewUniversalAIParser(),
+		bindings: make(map[cardBinding]string),
+		cards:    make(map[cardBinding]*VerifiedAgentCard),
+		jwks:     make(map[string]*cachedJWKS),
+	}
+}
+
+// nonPublicNets are ranges net.IP's predicates do not cover.
+var nonPublicNets = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),     // "this network"
+	mustCIDR("100.64.0.0/10"), // carrier-grade NAT
+	mustCIDR("192.0.0.0/24"),  // IETF protocol assignments
+	mustCIDR("198.18.0.0/15"), // benchmarking
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// refuseNonPublic is a net.Dialer Control hook. It runs on the resolved
+// address of every connection, redirects included, so neither a hostname
+// nor a redirect can point the registry at the internal network or a cloud
+// metadata endpoint.
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Register binds agentID to the peer at peerURL for tenantID and resolves
+// its card. peerURL is either the card's own URL or the agent's base URL,
+// in which case the well-known paths are tried. A card whose signature
+// fails still registers; the failure is reported on every invocation.
+func (r *AgentCardRegistry) Register(ctx context.Context, tenantID, agentID, peerURL string) (*VerifiedAgentCard, error) {
+	if tenantID == "" || agentID == "" || peerURL == "" {
+		return nil, errors.New("tenant, agent_id and url are required")
+	}
+	key := cardBinding{tenantID, agentID}
+	u, err := url.Parse(peerURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid agent URL %q", peerURL)
+	}
+
+	candidates := []string{peerURL}
+	if !strings.HasSuffix(u.Path, ".json") {
+		candidates = candidates[:0]
+		for _, p := range wellKnownCardPaths {
+			candidates = append(candidates, u.Scheme+"://"+u.Host+strings.TrimSuffix(u.Path, "/")+p)
+		}
+	}
+
+	var lastErr error
+	for _, cardURL := range candidates {
+		vc, err := r.fetch(ctx, key, cardURL)
+		if err != nil {
+			lastErr = err
+			if errors.Is(err, ErrNonPublicAddress) {
+				break
+			}
+			continue
+		}
+		r.mu.Lock()
+		r.bindings[key] = cardURL
+		r.cards[key] = vc
+		r.mu.Unlock()
+		slog.Info("[AgentCards] registered A2A peer", "tenant_id", tenantID, "agent_id", agentID, "card_url", cardURL,
+			"signed", vc.Signed, "verified", vc.Verified, "skills", len(vc.Card.Skills))
+		return vc, nil
+	}
+	return nil, fmt.Errorf("resolve agent card for %s: %w", agentID, lastErr)
+}
+
+// Get returns the card tenantID registered for agentID, even when it is
+// past its TTL.
+func (r *AgentCardRegistry) Get(tenantID, agentID string) (*VerifiedAgentCard, bool) {
+	vc := r.snapshot(cardBinding{tenantID, agentID})
+	return vc, vc != nil
+}
+
+// snapshot copies the cached card so callers do not race mismatch counting.
+func (r *AgentCardRegistry) snapshot(key cardBinding) *VerifiedAgentCard {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	vc, ok := r.cards[key]
+	if !ok {
+		return nil
+	}
+	cp := *vc
+	return &cp
+}
+
+// Resolve returns the card tenantID registered for agentID, refetching it
+// once the cache has expired. A failed refetch keeps serving the previous
+// card. Agents the tenant never registered return nil, nil.
+func (r *AgentCardRegistry) Resolve(ctx context.Context, tenantID, agentID string) (*VerifiedAgentCard, error) {
+	key := cardBinding{tenantID, agentID}
+	r.mu.RLock()
+	cardURL, bound := r.bindings[key]
+	cached := r.cards[key]
+	r.mu.RUnlock()
+	if !bound {
+		return nil, nil
+	}
+	if cached != nil && time.Now().Before(cached.ExpiresAt) {
+		return r.snapshot(key), nil
+	}
+
+	vc, err := r.fetch(ctx, key, cardURL)
+	if err != nil {
+		if cached != nil {
+			slog.Warn("[AgentCards] refresh failed, serving stale card", "tenant_id", tenantID, "agent_id", agentID, "error", err)
+			return r.snapshot(key), nil
+		}
+		return nil, err
+	}
+	r.mu.Lock()
+	if cached != nil {
+		vc.Mismatches = cached.Mismatches
+		vc.LastSignal = cached.LastSignal
+	}
+	r.cards[key] = vc
+	r.mu.Unlock()
+	return r.snapshot(key), nil
+}
+
+// CheckInvocation resolves the card tenantID registered for agentID and
+// reports card and skill signals for a call to toolName. Agents without a
+// card in the tenant are not A2A peers and produce no signals.
+func (r *AgentCardRegistry) CheckInvocation(ctx context.Context, tenantID, agentID, toolName string) []AgentCardSignal {
+	vc, err := r.Resolve(ctx, tenantID, agentID)
+	if err != nil {
+		slog.Warn("[AgentCards] card unavailable", "tenant_id", tenantID, "agent_id", agentID, "error", err)
+		return nil
+	}
+	if vc == nil {
+		return nil
+	}
+	return r.record(cardBinding{tenantID, agentID}, r.evaluate(vc, toolName))
+}
+
+// InspectRoute implements fabric.RouteInspector from cached cards only;
+// routing never waits on the network. The tool is read from the message
+// payload, and only blocking signals reject delivery.
+func (r *AgentCardRegistry) InspectRoute(ctx context.Context, src *fabric.SpokeInfo, msg *fabric.Message) []fabric.RouteSignal {
+	if src == nil || src.AgentID == "" || len(msg.Payload) == 0 {
+		return nil
+	}
+	key := cardBinding{src.TenantID, src.AgentID}
+	r.mu.RLock()
+	vc := r.cards[key]
+	r.mu.RUnlock()
+	if vc == nil {
+		return nil
+	}
+
+	toolName := ""
+	if p := r.parser.Parse(msg.Payload); p != nil && p.MessageType == "tool_call" {
+		toolName = p.ToolName
+	}
+	signals := r.record(key, r.evaluate(vc, toolName))
+	out := make([]fabric.RouteSignal, len(signals))
+	for i, s := range signals {
+		out[i] = fabric.RouteSignal{Kind: string(s.Kind), Detail: s.Detail, Reject: s.Action == CardActionBlock}
+	}
+	return out
+}
+
+// evaluate produces the signals for one invocation. An empty toolName
+// checks the card alone.
+func (r *AgentCardRegistry) evaluate(vc *VerifiedAgentCard, toolName string) []AgentCardSignal {
+	var signals []AgentCardSignal
+	switch {
+	case !vc.Signed:
+		signals = append(signals, AgentCardSignal{Kind: CardSignalUnsigned, AgentID: vc.AgentID, ToolName: toolName,
+			Detail: "agent card carries no signature", Action: r.cfg.UnsignedAction})
+	case !vc.Verified:
+		signals = append(signals, AgentCardSignal{Kind: CardSignalInvalidSignature, AgentID: vc.AgentID, ToolName: toolName,
+			Detail: "agent card signature invalid: " + vc.VerifyError, Action: r.cfg.InvalidSignatureAction})
+	}
+	if toolName != "" && !a2aProtocolTools[toolName] && !skillCovers(vc.Card.Skills, toolName) {
+		signals = append(signals, AgentCardSignal{Kind: CardSignalSkillMismatch, AgentID: vc.AgentID, ToolName: toolName,
+			Detail: fmt.Sprintf("tool %s is not among the %d skills declared by %s", toolName, len(vc.Card.Skills), vc.Card.Name),
+			Action: r.cfg.MismatchAction})
+	}
+	return signals
+}
+
+func (r *AgentCardRegistry) record(key cardBinding, signals []AgentCardSignal) []AgentCardSignal {
+	var mismatch *AgentCardSignal
+	for i := range signals {
+		if signals[i].Kind == CardSignalSkillMismatch {
+			mismatch = &signals[i]
+		}
+	}
+	if mismatch == nil {
+		return signals
+	}
+	r.mu.Lock()
+	if vc := r.cards[key]; vc != nil {
+		vc.Mismatches++
+		s := *mismatch
+		vc.LastSignal = &s
+	}
+	r.mu.Unlock()
+	slog.Warn("[AgentCards] invocation outside declared skills",
+		"tenant_id", key.tenantID, "agent_id", key.agentID, "tool", mismatch.ToolName, "action", mismatch.Action)
+	return signals
+}
+
+// ============================================================================
+// FETCH AND VERIFY
+// ============================================================================
+
+func (r *AgentCardRegistry) fetch(ctx context.Context, key cardBinding, cardURL string) (*VerifiedAgentCard, error) {
+	body, err := r.get(ctx, cardURL)
+	if err != nil {
+		return nil, err
+	}
+	var card protocol.AgentCard
+	if err := json.Unmarshal(body, &card); err != nil {
+		return nil, fmt.Errorf("decode agent card %s: %w", cardURL, err)
+	}
+	if card.Name == "" {
+		return nil, fmt.Errorf("agent card %s has no name", cardURL)
+	}
+
+	now := time.Now()
+	vc := &VerifiedAgentCard{
+		TenantID:  key.tenantID,
+		AgentID:   key.agentID,
+		CardURL:   cardURL,
+		Card:      card,
+		Signed:    len(card.Signatures) > 0,
+		FetchedAt: now,
+		ExpiresAt: now.Add(r.cfg.CacheTTL),
+	}
+	if vc.Signed {
+		kid, alg, err := r.verifyCard(ctx, cardURL, body, card.Signatures)
+		vc.KeyID, vc.Algorithm = kid, alg
+		if err != nil {
+			vc.VerifyError = err.Error()
+			slog.Warn("[AgentCards] agent card signature rejected", "tenant_id", key.tenantID, "agent_id", key.agentID, "card_url", cardURL, "error", err)
+		} else {
+			vc.Verified = true
+		}
+	}
+	return vc, nil
+}
+
+func (r *AgentCardRegistry) get(ctx context.Context, rawURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.FetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: status %d", rawURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCardBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", rawURL, err)
+	}
+	if len(body) > maxCardBytes {
+		return nil, fmt.Errorf("%s exceeds %d bytes", rawURL, maxCardBytes)
+	}
+	return body, nil
+}
+
+// verifyCard accepts the card when any signature verifies and returns the
+// key and algorithm that did, or the last failure.
+func (r *AgentCardRegistry) verifyCard(ctx context.Context, cardURL string, body []byte, sigs []protocol.AgentCardSignature) (string, string, error) {
+	payload, err := canonicalCardPayload(body)
+	if err != nil {
+		return "", "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	var kid, alg string
+	lastErr := errors.New("no signatures")
+	for _, sig := range sigs {
+		var hdr struct {
+			Alg string `json:"alg"`
+			Kid string `json:"kid"`
+			Jku string `json:"jku"`
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(sig.Protected)
+		if err != nil || json.Unmarshal(raw, &hdr) != nil {
+			lastErr = errors.New("malformed protected header")
+			continue
+		}
+		kid, alg = hdr.Kid, hdr.Alg
+
+		jwksURL, err := r.jwksURL(cardURL, hdr.Jku)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		keys, err := r.keySet(ctx, jwksURL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		key, ok := keys[hdr.Kid]
+		if !ok {
+			lastErr = fmt.Errorf("key %q not in %s", hdr.Kid, jwksURL)
+			continue
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(sig.Signature)
+		if err != nil {
+			lastErr = errors.New("malformed signature")
+			continue
+		}
+		if err := verifyJWS(hdr.Alg, key, []byte(sig.Protected+"."+encoded), signature); err != nil {
+			lastErr = fmt.Errorf("key %q: %w", hdr.Kid, err)
+			continue
+		}
+		return kid, alg, nil
+	}
+	return kid, alg, lastErr
+}
+
+// jwksURL picks where a card's keys come from: a pinned JWKS for the host,
+// the signature's jku when it is on the card's own origin, or the origin's
+// well-known JWKS. A jku elsewhere would let whoever serves the card
+// choose the keys that verify it.
+func (r *AgentCardRegistry) jwksURL(cardURL, jku string) (string, error) {
+	u, err := url.Parse(cardURL)
+	if err != nil {
+		return "", err
+	}
+	if pinned, ok := r.cfg.TrustedJWKS[u.Host]; ok {
+		return pinned, nil
+	}
+	origin := u.Scheme + "://" + u.Host
+	if jku == "" {
+		return origin + "/.well-known/jwks.json", nil
+	}
+	ju, err := url.Parse(jku)
+	if err != nil || ju.Scheme+"://"+ju.Host != origin {
+		return "", fmt.Errorf("jku %q is not on the card's origin %s", jku, origin)
+	}
+	return jku, nil
+}
+
+func (r *AgentCardRegistry) keySet(ctx context.Context, jwksURL string) (map[string]crypto.PublicKey, error) {
+	r.mu.RLock()
+	cached := r.jwks[jwksURL]
+	r.mu.RUnlock()
+	if cached != nil && time.Now().Before(cached.expiresAt) {
+		return cached.keys, nil
+	}
+
+	body, err := r.get(ctx, jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", jwksURL, err)
+	}
+	r.mu.Lock()
+	r.jwks[jwksURL] = &cachedJWKS{keys: keys, expiresAt: time.Now().Add(r.cfg.CacheTTL)}
+	r.mu.Unlock()
+	return keys, nil
+}
+
+// canonicalCardPayload is the signed form of a card: its JSON without the
+// signatures member, with sorted keys and no insignificant whitespace
+// (RFC 8785 for the strings and integers cards contain).
+func canonicalCardPayload(body []byte) ([]byte, error) {
+	var card map[string]interface{}
+	if err := json.Unmarshal(body, &card); err != nil {
+		return nil, err
+	}
+	delete(card, "signatures")
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(card); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// parseJWKS reads the Ed25519, P-256 and RSA keys of a JWK set by kid.
+func parseJWKS(body []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Crv string `json:"crv"`
+			Kid string `json:"kid"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		b := func(s string) []byte {
+			v, _ := base64.RawURLEncoding.DecodeString(s)
+			return v
+		}
+		switch {
+		case k.Kty == "OKP" && k.Crv == "Ed25519" && len(b(k.X)) == ed25519.PublicKeySize:
+			keys[k.Kid] = ed25519.PublicKey(b(k.X))
+		case k.Kty == "EC" && k.Crv == "P-256":
+			pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(b(k.X)), Y: new(big.Int).SetBytes(b(k.Y))}
+			if pub.Curve.IsOnCurve(pub.X, pub.Y) {
+				keys[k.Kid] = pub
+			}
+		case k.Kty == "RSA" && len(b(k.N)) >= 256:
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(b(k.N)), E: int(new(big.Int).SetBytes(b(k.E)).Int64())}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable keys")
+	}
+	return keys, nil
+}
+
+func verifyJWS(alg string, key crypto.PublicKey, signingInput, sig []byte) error {
+	digest := sha256.Sum256(signingInput)
+	switch pub := key.(type) {
+	case ed25519.PublicKey:
+		if alg != "EdDSA" && alg != "Ed25519" {
+			break
+		}
+		if !ed25519.Verify(pub, signingInput, sig) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" {
+			break
+		}
+		if len(sig) != 64 || !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			break
+		}
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) != nil {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+	return fmt.Errorf("algorithm %q does not match key type %T", alg, key)
+}
+
+// ============================================================================
+// SKILL COVERAGE
+// ============================================================================
+
+// skillCovers reports whether a declared skill accounts for toolName: the
+// tool's name matches a skill's id or name, or every word of it appears in
+// one skill's id, name and tags. Descriptions are prose and do not count.
+func skillCovers(skills []protocol.AgentSkill, toolName string) bool {
+	toolWords := skillWords(toolName)
+	if len(toolWords) == 0 {
+		return true
+	}
+	joined := strings.Join(toolWords, "_")
+	for _, skill := range skills {
+		vocab := make(map[string]bool)
+		for _, field := range append([]string{skill.ID, skill.Name}, skill.Tags...) {
+			words := skillWords(field)
+			if strings.Join(words, "_") == joined {
+				return true
+			}
+			for _, w := range words {
+				vocab[w] = true
+			}
+		}
+		covered := true
+		for _, w := range toolWords {
+			if !vocab[w] {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// skillWords splits identifiers and labels into lowercase singular words:
+// "searchRecords", "search-records" and "Search records" all give
+// [search record].
+func skillWords(s string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) == 0 {
+			return
+		}
+		w := strings.ToLower(string(cur))
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = w[:len(w)-1]
+		}
+		words = append(words, w)
+		cur = cur[:0]
+	}
+	runes := []rune(s)
+	for i, c := range runes {
+		switch {
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			flush()
+		case unicode.IsUpper(c) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			cur = append(cur, c)
+		default:
+			cur = append(cur, c)
+		}
+	}
+	flush()
+	return words
+}
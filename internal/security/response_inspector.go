@@ -0,0 +1,527 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ocx/backend/internal/evidence"
+	"github.com/ocx/backend/internal/protocol"
+)
+
+// ============================================================================
+// RESPONSE INSPECTOR - Tool results and retrievals before they reach the model
+// ============================================================================
+
+// ResponseAction is what happens to an inspected result.
+type ResponseAction string
+
+const (
+	ResponseAllow  ResponseAction = "ALLOW"
+	ResponseRedact ResponseAction = "REDACT"
+	ResponseBlock  ResponseAction = "BLOCK"
+)
+
+func (a ResponseAction) severity() int {
+	switch a {
+	case ResponseBlock:
+		return 2
+	case ResponseRedact:
+		return 1
+	}
+	return 0
+}
+
+// ParseResponseAction accepts allow | redact | block in any case and returns
+// "" for anything else.
+func ParseResponseAction(s string) ResponseAction {
+	switch a := ResponseAction(strings.ToUpper(strings.TrimSpace(s))); a {
+	case ResponseAllow, ResponseRedact, ResponseBlock:
+		return a
+	}
+	return ""
+}
+
+// FindingKind classifies what a rule detected.
+type FindingKind string
+
+const (
+	FindingPII       FindingKind = "PII"
+	FindingSecret    FindingKind = "SECRET"
+	FindingInjection FindingKind = "PROMPT_INJECTION"
+	FindingOversize  FindingKind = "OVERSIZE"
+)
+
+// ResponseFinding is one rule matching at one location. Samples are masked
+// so evidence never holds the sensitive value itself.
+type ResponseFinding struct {
+	Kind   FindingKind    `json:"kind"`
+	Rule   string         `json:"rule"`
+	Path   string         `json:"path,omitempty"`
+	Count  int            `json:"count"`
+	Sample string         `json:"sample,omitempty"`
+	Action ResponseAction `json:"action"`
+}
+
+// ResponseInspection is the outcome of inspecting one result.
+type ResponseInspection struct {
+	TransactionID string                  `json:"transaction_id"`
+	TenantID      string                  `json:"tenant_id,omitempty"`
+	AgentID       string                  `json:"agent_id,omitempty"`
+	ToolName      string                  `json:"tool_name,omitempty"`
+	CallID        string                  `json:"call_id,omitempty"`
+	Protocol      protocol.AIProtocolType `json:"protocol,omitempty"`
+	MessageType   string                  `json:"message_type,omitempty"`
+	Bytes         int                     `json:"bytes"`
+	Action        ResponseAction          `json:"action"`
+	Reason        string                  `json:"reason,omitempty"`
+	Findings      []ResponseFinding       `json:"findings"`
+	EvidenceHash  string                  `json:"evidence_hash,omitempty"`
+
+	// Content is the body to forward: the original on ALLOW, the redacted
+	// body on REDACT and nil on BLOCK.
+	Content []byte `json:"-"`
+}
+
+// ResponseTarget identifies the transaction whose call produced the result.
+type ResponseTarget struct {
+	TransactionID string
+	TenantID      string
+	AgentID       string
+}
+
+// ResponsePolicyHook lets callers apply tenant or tool policy after the
+// built-in rules. The strictest action wins, so a hook can escalate to
+// REDACT or BLOCK but never relax a built-in decision; returning
+// ResponseAllow leaves the outcome unchanged.
+type ResponsePolicyHook func(ctx context.Context, p *protocol.AIPayload, in *ResponseInspection) (ResponseAction, string)
+
+// ResponseInspectorConfig sets the action per finding kind.
+type ResponseInspectorConfig struct {
+	MaxResultBytes           int
+	PIIAction                ResponseAction
+	SecretAction             ResponseAction
+	InjectionAction          ResponseAction
+	RetrievalInjectionAction ResponseAction // retrieved documents are untrusted third-party text
+	OversizeAction           ResponseAction // REDACT is treated as BLOCK: a result cannot be partially forwarded
+}
+
+// ResponseInspector evaluates tool_result and retrieval content before it
+// goes back to the model: PII and secret detection, prompt-injection
+// markers, size limits and policy hooks. Findings are written to the
+// evidence vault under the originating transaction.
+type ResponseInspector struct {
+	cfg   ResponseInspectorConfig
+	vault *evidence.EvidenceVault
+
+	mu    sync.RWMutex
+	hooks []ResponsePolicyHook
+}
+
+// NewResponseInspector creates an inspector. vault may be nil, in which case
+// findings are only logged.
+func NewResponseInspector(cfg ResponseInspectorConfig, vault *evidence.EvidenceVault) *ResponseInspector {
+	if cfg.MaxResultBytes <= 0 {
+		cfg.MaxResultBytes = 1 << 20
+	}
+	if cfg.PIIAction == "" {
+		cfg.PIIAction = ResponseRedact
+	}
+	if cfg.SecretAction == "" {
+		cfg.SecretAction = ResponseRedact
+	}
+	if cfg.InjectionAction == "" {
+		cfg.InjectionAction = ResponseRedact
+	}
+	if cfg.RetrievalInjectionAction == "" {
+		cfg.RetrievalInjectionAction = ResponseBlock
+	}
+	if cfg.OversizeAction == "" || cfg.OversizeAction == ResponseRedact {
+		cfg.OversizeAction = ResponseBlock
+	}
+	return &ResponseInspector{cfg: cfg, vault: vault}
+}
+
+// AddPolicyHook registers a hook run after the built-in rules.
+func (ri *ResponseInspector) AddPolicyHook(h ResponsePolicyHook) {
+	ri.mu.Lock()
+	ri.hooks = append(ri.hooks, h)
+	ri.mu.Unlock()
+}
+
+// InspectsPayload reports whether p carries content flowing toward the
+// model: tool results (including function outputs sent back in a request)
+// and retrieval results.
+func InspectsPayload(p *protocol.AIPayload) bool {
+	if p == nil {
+		return false
+	}
+	return p.MessageType == "tool_result" ||
+		(p.MessageType == "retrieval" && p.Direction == "response")
+}
+
+// Inspect evaluates body, the result message p was parsed from. For JSON
+// bodies only the result portion of the message is scanned (the trailing
+// tool messages of an OpenAI request, an MCP result, and so on); anything
+// else is scanned as text.
+func (ri *ResponseInspector) Inspect(ctx context.Context, target ResponseTarget, p *protocol.AIPayload, body []byte) *ResponseInspection {
+	if p == nil {
+		p = &protocol.AIPayload{Protocol: protocol.ProtoRaw, MessageType: "tool_result"}
+	}
+	in := &ResponseInspection{
+		TransactionID: target.TransactionID,
+		TenantID:      target.TenantID,
+		AgentID:       target.AgentID,
+		ToolName:      p.ToolName,
+		CallID:        p.CallID,
+		Protocol:      p.Protocol,
+		MessageType:   p.MessageType,
+		Bytes:         len(body),
+		Action:        ResponseAllow,
+		Findings:      []ResponseFinding{},
+	}
+
+	content := body
+	if len(body) > ri.cfg.MaxResultBytes {
+		in.Findings = append(in.Findings, ResponseFinding{
+			Kind:   FindingOversize,
+			Rule:   "max_result_bytes",
+			Count:  1,
+			Sample: fmt.Sprintf("%d > %d bytes", len(body), ri.cfg.MaxResultBytes),
+			Action: ri.cfg.OversizeAction,
+		})
+	}
+	if ri.cfg.OversizeAction != ResponseBlock || len(body) <= ri.cfg.MaxResultBytes {
+		content = ri.scan(p, body, in)
+	}
+
+	for _, f := range in.Findings {
+		if f.Action.severity() > in.Action.severity() {
+			in.Action = f.Action
+		}
+	}
+	in.Reason = summarizeFindings(in.Findings)
+
+	ri.mu.RLock()
+	hooks := ri.hooks
+	ri.mu.RUnlock()
+	for _, h := range hooks {
+		if action, reason := h(ctx, p, in); action.severity() > in.Action.severity() {
+			in.Action = action
+			in.Reason = reason
+		}
+	}
+
+	switch in.Action {
+	case ResponseAllow:
+		in.Content = body
+	case ResponseRedact:
+		in.Content = content
+	}
+
+	if in.Action != ResponseAllow {
+		slog.Warn("[ResponseInspector] result withheld from model",
+			"tx_id", in.TransactionID, "tool", in.ToolName, "action", in.Action, "reason", in.Reason)
+	}
+	if len(in.Findings) > 0 || in.Action != ResponseAllow {
+		ri.recordEvidence(ctx, in)
+	}
+	return in
+}
+
+// scan runs the content rules over the result portion of body, appending
+// findings to in, and returns body with REDACT matches replaced.
+func (ri *ResponseInspector) scan(p *protocol.AIPayload, body []byte, in *ResponseInspection) []byte {
+	actions := map[FindingKind]ResponseAction{
+		FindingPII:       ri.cfg.PIIAction,
+		FindingSecret:    ri.cfg.SecretAction,
+		FindingInjection: ri.cfg.InjectionAction,
+	}
+	if p.MessageType == "retrieval" {
+		actions[FindingInjection] = ri.cfg.RetrievalInjectionAction
+	}
+
+	found := map[string]*ResponseFinding{}
+	redacted := false
+	visit := func(path, s string) string {
+		for _, rule := range responseRules {
+			action := actions[rule.kind]
+			s = rule.re.ReplaceAllStringFunc(s, func(m string) string {
+				if rule.valid != nil && !rule.valid(m) {
+					return m
+				}
+				key := path + "|" + rule.name
+				f, ok := found[key]
+				if !ok {
+					f = &ResponseFinding{Kind: rule.kind, Rule: rule.name, Path: path, Sample: maskSample(m), Action: action}
+					found[key] = f
+				}
+				f.Count++
+				if action == ResponseRedact {
+					redacted = true
+					return "[REDACTED:" + strings.ToUpper(rule.name) + "]"
+				}
+				return m
+			})
+		}
+		return s
+	}
+
+	out := body
+	trimmed := bytes.TrimSpace(body)
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	var doc interface{}
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && dec.Decode(&doc) == nil {
+		doc = walkStrings(doc, "$", false, resultScopes(p, doc), visit)
+		if redacted {
+			if b, err := json.Marshal(doc); err == nil {
+				out = b
+			}
+		}
+	} else if s := visit("$", string(body)); redacted {
+		out = []byte(s)
+	}
+
+	for _, f := range found {
+		in.Findings = append(in.Findings, *f)
+	}
+	sort.Slice(in.Findings, func(i, j int) bool {
+		a, b := in.Findings[i], in.Findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Rule < b.Rule
+	})
+	return out
+}
+
+func (ri *ResponseInspector) recordEvidence(ctx context.Context, in *ResponseInspection) {
+	if ri.vault == nil {
+		return
+	}
+	outcome := evidence.OutcomeAllow
+	switch in.Action {
+	case ResponseRedact:
+		outcome = evidence.OutcomeRedact
+	case ResponseBlock:
+		outcome = evidence.OutcomeBlock
+	}
+
+	findings := make([]interface{}, len(in.Findings))
+	for i, f := range in.Findings {
+		findings[i] = map[string]interface{}{
+			"kind": f.Kind, "rule": f.Rule, "path": f.Path,
+			"count": f.Count, "sample": f.Sample, "action": f.Action,
+		}
+	}
+	record, err := ri.vault.RecordResponseInspection(ctx, in.TenantID, in.AgentID, in.TransactionID,
+		in.ToolName, outcome, in.Reason, map[string]interface{}{
+			"findings":     findings,
+			"call_id":      in.CallID,
+			"protocol":     in.Protocol,
+			"message_type": in.MessageType,
+			"bytes":        in.Bytes,
+		})
+	if err != nil {
+		slog.Warn("[ResponseInspector] evidence record failed", "tx_id", in.TransactionID, "error", err)
+		return
+	}
+	in.EvidenceHash = record.Hash
+}
+
+// ============================================================================
+// RESULT SCOPING
+// ============================================================================
+
+// resultScopes returns the JSON paths holding result content for p. A
+// request that returns tool output to the model also carries the whole
+// conversation, which is not what is being inspected.
+func resultScopes(p *protocol.AIPayload, doc interface{}) []string {
+	var scopes []string
+	root, _ := doc.(map[string]interface{})
+
+	switch {
+	case p.MessageType != "tool_result":
+		// retrievals and unrecognised results are inspected whole
+
+	case p.Protocol == protocol.ProtoOpenAI:
+		msgs, _ := root["messages"].([]interface{})
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if m, ok := msgs[i].(map[string]interface{}); !ok || m["role"] != "tool" {
+				break
+			}
+			scopes = append(scopes, fmt.Sprintf("$.messages[%d].content", i))
+		}
+
+	case p.Protocol == protocol.ProtoAnthropic:
+		msgs, _ := root["messages"].([]interface{})
+		if n := len(msgs); n > 0 {
+			last, _ := msgs[n-1].(map[string]interface{})
+			blocks, _ := last["content"].([]interface{})
+			for j, b := range blocks {
+				if block, ok := b.(map[string]interface{}); ok && block["type"] == "tool_result" {
+					scopes = append(scopes, fmt.Sprintf("$.messages[%d].content[%d].content", n-1, j))
+				}
+			}
+		}
+
+	case p.Protocol == protocol.ProtoGemini:
+		contents, _ := root["contents"].([]interface{})
+		if n := len(contents); n > 0 {
+			last, _ := contents[n-1].(map[string]interface{})
+			parts, _ := last["parts"].([]interface{})
+			for j := range parts {
+				prefix := fmt.Sprintf("$.contents[%d].parts[%d]", n-1, j)
+				scopes = append(scopes, prefix+".functionResponse", prefix+".function_response")
+			}
+		}
+
+	case p.Protocol == protocol.ProtoMCP:
+		if batch, ok := doc.([]interface{}); ok {
+			for i := range batch {
+				scopes = append(scopes, fmt.Sprintf("$[%d].result", i))
+			}
+		} else {
+			scopes = append(scopes, "$.result")
+		}
+	}
+
+	if len(scopes) == 0 {
+		return []string{"$"}
+	}
+	return scopes
+}
+
+// walkStrings calls visit for every string and number leaf under one of
+// scopes, replacing leaves with what visit returns.
+func walkStrings(v interface{}, path string, scoped bool, scopes []string, visit func(path, s string) string) interface{} {
+	if !scoped {
+		for _, s := range scopes {
+			if path == s {
+				scoped = true
+				break
+			}
+		}
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			t[k] = walkStrings(child, path+"."+k, scoped, scopes, visit)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = walkStrings(child, fmt.Sprintf("%s[%d]", path, i), scoped, scopes, visit)
+		}
+	case string:
+		if scoped {
+			return visit(path, t)
+		}
+	case json.Number:
+		if scoped {
+			if s := visit(path, t.String()); s != t.String() {
+				return s
+			}
+		}
+	}
+	return v
+}
+
+// ============================================================================
+// CONTENT RULES
+// ============================================================================
+
+type contentRule struct {
+	kind  FindingKind
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+var responseRules = []contentRule{
+	// Secrets first, so a token is not half-redacted as a phone number
+	{FindingSecret, "private_key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`), nil},
+	{FindingSecret, "aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), nil},
+	{FindingSecret, "github_token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), nil},
+	{FindingSecret, "slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), nil},
+	{FindingSecret, "api_key", regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`), nil},
+	{FindingSecret, "google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), nil},
+	{FindingSecret, "jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), nil},
+	{FindingSecret, "credential_assignment", regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token)\b\s*[:=]\s*["']?[^\s"']{8,}`), nil},
+
+	{FindingPII, "email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`), nil},
+	{FindingPII, "us_ssn", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), validSSN},
+	{FindingPII, "credit_card", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), luhnValid},
+	{FindingPII, "phone", regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`), nil},
+
+	{FindingInjection, "ignore_instructions", regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget)\b[^.\n]{0,24}\b(?:previous|prior|above|earlier|all)\b[^.\n]{0,24}\b(?:instructions|prompts?|rules|directions)\b`), nil},
+	{FindingInjection, "role_override", regexp.MustCompile(`(?i)\byou are now\b|\bfrom now on,? you\b|\bnew (?:system )?instructions\s*:`), nil},
+	{FindingInjection, "prompt_exfiltration", regexp.MustCompile(`(?i)\b(?:reveal|print|repeat|output|show)\b[^.\n]{0,24}\b(?:system prompt|hidden instructions|initial instructions)\b`), nil},
+	{FindingInjection, "jailbreak", regexp.MustCompile(`(?i)\b(?:do anything now|developer mode|jailbreak)\b`), nil},
+	{FindingInjection, "chat_template", regexp.MustCompile(`<\|im_start\|>|<\|im_end\|>|<\|system\|>|\[/?INST\]|<</?SYS>>`), nil},
+	{FindingInjection, "role_tag", regexp.MustCompile(`(?i)</?(?:system|assistant|tool_result|function_results|instructions)>`), nil},
+}
+
+// validSSN rejects area numbers the SSA never issues.
+func validSSN(m string) bool {
+	area := m[:3]
+	return area != "000" && area != "666" && area[0] != '9' && m[4:6] != "00" && m[7:] != "0000"
+}
+
+func luhnValid(m string) bool {
+	var digits []int
+	for _, r := range m {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-i)%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func maskSample(m string) string {
+	if len(m) <= 8 {
+		return strings.Repeat("*", len(m))
+	}
+	return m[:2] + strings.Repeat("*", min(len(m)-4, 8)) + m[len(m)-2:]
+}
+
+func summarizeFindings(findings []ResponseFinding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, f := range findings {
+		if f.Action == ResponseAllow {
+			continue
+		}
+		part := string(f.Kind) + "/" + f.Rule
+		if f.Path != "" {
+			part += " at " + f.Path
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d finding(s) flagged, none enforced", len(findings))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,561 @@
+package security
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ocx/backend/internal/fabric"
+	"github.com/ocx/backend/internal/protocol"
+)
+
+// ============================================================================
+// TOOL POISONING - MCP tool list fingerprinting and descriptor analysis
+// ============================================================================
+
+// ToolFindingKind classifies a problem found in an advertised tool.
+type ToolFindingKind string
+
+const (
+	ToolFindingInjection    ToolFindingKind = "INJECTION"      // instructions aimed at the model
+	ToolFindingExfiltration ToolFindingKind = "EXFILTRATION"   // references to secrets or outbound destinations
+	ToolFindingHidden       ToolFindingKind = "HIDDEN_UNICODE" // invisible or bidi characters
+	ToolFindingRugPull      ToolFindingKind = "RUG_PULL"       // descriptor changed since the accepted baseline
+	ToolFindingAdded        ToolFindingKind = "TOOL_ADDED"     // tool not in the accepted baseline
+	ToolFindingShadowing    ToolFindingKind = "SHADOWING"      // same tool name advertised by another server
+)
+
+// toolFindingWeight is each kind's contribution to a tool's risk score.
+var toolFindingWeight = map[ToolFindingKind]float64{
+	ToolFindingInjection:    0.6,
+	ToolFindingExfiltration: 0.5,
+	ToolFindingHidden:       0.5,
+	ToolFindingRugPull:      0.7,
+	ToolFindingAdded:        0.2,
+	ToolFindingShadowing:    0.3,
+}
+
+// ToolFinding is one problem with an advertised tool.
+type ToolFinding struct {
+	Kind   ToolFindingKind `json:"kind"`
+	Rule   string          `json:"rule"`
+	Field  string          `json:"field,omitempty"`
+	Detail string          `json:"detail,omitempty"`
+}
+
+// ToolAssessment is the analysis of one advertised tool.
+type ToolAssessment struct {
+	Name                string        `json:"name"`
+	Fingerprint         string        `json:"fingerprint"`
+	BaselineFingerprint string        `json:"baseline_fingerprint,omitempty"`
+	Risk                float64       `json:"risk"`
+	Findings            []ToolFinding `json:"findings"`
+}
+
+// ToolListReport is the analysis of one tools/list result.
+type ToolListReport struct {
+	TenantID            string           `json:"tenant_id"`
+	ServerID            string           `json:"server_id"`
+	Fingerprint         string           `json:"fingerprint"`
+	BaselineFingerprint string           `json:"baseline_fingerprint,omitempty"`
+	FirstSeen           bool             `json:"first_seen"`
+	Changed             bool             `json:"changed"`
+	Tools               []ToolAssessment `json:"tools"`
+	Removed             []string         `json:"removed,omitempty"`
+	MaxRisk             float64          `json:"max_risk"`
+	AnalyzedAt          time.Time        `json:"analyzed_at"`
+}
+
+// ServerToolBaseline is the accepted tool list of one MCP server as used by
+// one tenant. The first list seen becomes the baseline; later changes are
+// reported against it until accepted.
+type ServerToolBaseline struct {
+	TenantID    string            `json:"tenant_id"`
+	ServerID    string            `json:"server_id"`
+	Fingerprint string            `json:"fingerprint"`
+	Tools       map[string]string `json:"tools"` // name -> fingerprint
+	AcceptedAt  time.Time         `json:"accepted_at"`
+
+	// Most recent list, adopted by Accept
+	ObservedFingerprint string            `json:"observed_fingerprint"`
+	ObservedTools       map[string]string `json:"observed_tools"`
+	LastSeen            time.Time         `json:"last_seen"`
+}
+
+// ToolFingerprintStore persists baselines so rug pulls are caught across
+// sessions and restarts. Baselines are keyed by tenant and server. Load
+// returns nil, nil for an unknown server.
+type ToolFingerprintStore interface {
+	Load(ctx context.Context, tenantID, serverID string) (*ServerToolBaseline, error)
+	Save(ctx context.Context, baseline *ServerToolBaseline) error
+}
+
+// serverKey identifies one tenant's view of an MCP server. A tenant can
+// only create, change or accept baselines under its own key, so one
+// tenant's tool lists never raise or clear risk for another.
+type serverKey struct {
+	tenantID string
+	serverID string
+}
+
+// ToolListAnalyzer fingerprints each MCP server's advertised tools, detects
+// descriptor changes between sessions and flags injection-style
+// instructions and hidden Unicode. It implements escrow.ToolRiskSource so
+// the resulting risk feeds ToolClassifier.Classify.
+type ToolListAnalyzer struct {
+	store ToolFingerprintStore
+
+	mu          sync.RWMutex
+	assessments map[serverKey]map[string]*ToolAssessment // tenant+server -> tool name -> assessment
+}
+
+// NewToolListAnalyzer creates an analyzer. A nil store keeps baselines in
+// memory only.
+func NewToolListAnalyzer(store ToolFingerprintStore) *ToolListAnalyzer {
+	if store == nil {
+		store = NewMemoryToolFingerprintStore()
+	}
+	return &ToolListAnalyzer{
+		store:       store,
+		assessments: make(map[serverKey]map[string]*ToolAssessment),
+	}
+}
+
+// AnalyzePayload analyzes a raw tools/list result that tenantID received
+// from serverID.
+func (a *ToolListAnalyzer) AnalyzePayload(ctx context.Context, tenantID, serverID string, payload []byte) (*ToolListReport, error) {
+	tools, err := protocol.ParseMCPToolList(payload)
+	if err != nil {
+		return nil, fmt.Errorf("not a tools/list result: %w", err)
+	}
+	return a.Analyze(ctx, tenantID, serverID, tools)
+}
+
+// Analyze fingerprints tools, compares them with tenantID's baseline for
+// serverID and records each tool's risk.
+func (a *ToolListAnalyzer) Analyze(ctx context.Context, tenantID, serverID string, tools []protocol.MCPToolDefinition) (*ToolListReport, error) {
+	key := serverKey{tenantID, serverID}
+	baseline, err := a.store.Load(ctx, tenantID, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("load baseline for %s: %w", serverID, err)
+	}
+
+	now := time.Now()
+	report := &ToolListReport{TenantID: tenantID, ServerID: serverID, AnalyzedAt: now, FirstSeen: baseline == nil}
+	observed := make(map[string]string, len(tools))
+	assessments := make(map[string]*ToolAssessment, len(tools))
+
+	for _, tool := range tools {
+		as := &ToolAssessment{Name: tool.Name, Fingerprint: toolFingerprint(tool), Findings: scanToolDescriptor(tool)}
+		observed[tool.Name] = as.Fingerprint
+
+		if baseline != nil {
+			if prev, ok := baseline.Tools[tool.Name]; !ok {
+				as.Findings = append(as.Findings, ToolFinding{Kind: ToolFindingAdded, Rule: "new_tool",
+					Detail: "tool not present when the server was first accepted"})
+			} else if prev != as.Fingerprint {
+				as.BaselineFingerprint = prev
+				as.Findings = append(as.Findings, ToolFinding{Kind: ToolFindingRugPull, Rule: "descriptor_changed",
+					Detail: fmt.Sprintf("fingerprint %s, accepted %s", shortHash(as.Fingerprint), shortHash(prev))})
+			}
+		}
+		if other := a.otherOwner(key, tool.Name); other != "" {
+			as.Findings = append(as.Findings, ToolFinding{Kind: ToolFindingShadowing, Rule: "name_collision",
+				Detail: "also advertised by " + other})
+		}
+		as.Risk = toolRisk(as.Findings)
+		report.MaxRisk = max(report.MaxRisk, as.Risk)
+		assessments[tool.Name] = as
+		report.Tools = append(report.Tools, *as)
+	}
+	report.Fingerprint = listFingerprint(observed)
+
+	if baseline == nil {
+		baseline = &ServerToolBaseline{
+			TenantID:    tenantID,
+			ServerID:    serverID,
+			Fingerprint: report.Fingerprint,
+			Tools:       observed,
+			AcceptedAt:  now,
+		}
+	} else {
+		report.BaselineFingerprint = baseline.Fingerprint
+		report.Changed = baseline.Fingerprint != report.Fingerprint
+		for name := range baseline.Tools {
+			if _, ok := observed[name]; !ok {
+				report.Removed = append(report.Removed, name)
+			}
+		}
+		sort.Strings(report.Removed)
+	}
+	baseline.ObservedFingerprint = report.Fingerprint
+	baseline.ObservedTools = observed
+	baseline.LastSeen = now
+	if err := a.store.Save(ctx, baseline); err != nil {
+		return nil, fmt.Errorf("save baseline for %s: %w", serverID, err)
+	}
+
+	a.mu.Lock()
+	a.assessments[key] = assessments
+	a.mu.Unlock()
+
+	if report.Changed || report.MaxRisk >= 0.5 {
+		slog.Warn("[ToolListAnalyzer] suspicious MCP tool list",
+			"tenant", tenantID, "server", serverID, "changed", report.Changed, "max_risk", report.MaxRisk, "tools", len(tools))
+	}
+	return report, nil
+}
+
+// Accept adopts the tool list tenantID most recently observed from serverID
+// as its baseline, clearing rug-pull and new-tool findings.
+func (a *ToolListAnalyzer) Accept(ctx context.Context, tenantID, serverID string) error {
+	baseline, err := a.store.Load(ctx, tenantID, serverID)
+	if err != nil {
+		return fmt.Errorf("load baseline for %s: %w", serverID, err)
+	}
+	if baseline == nil {
+		return fmt.Errorf("no tool list observed for %s", serverID)
+	}
+	baseline.Fingerprint = baseline.ObservedFingerprint
+	baseline.Tools = baseline.ObservedTools
+	baseline.AcceptedAt = time.Now()
+	if err := a.store.Save(ctx, baseline); err != nil {
+		return fmt.Errorf("save baseline for %s: %w", serverID, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, as := range a.assessments[serverKey{tenantID, serverID}] {
+		kept := as.Findings[:0]
+		for _, f := range as.Findings {
+			if f.Kind != ToolFindingRugPull && f.Kind != ToolFindingAdded {
+				kept = append(kept, f)
+			}
+		}
+		as.Findings = kept
+		as.BaselineFingerprint = ""
+		as.Risk = toolRisk(kept)
+	}
+	return nil
+}
+
+// ToolRisk returns the risk recorded for toolID as advertised to tenantID
+// by serverID and the reasons behind it. With no serverID it returns the
+// highest risk across the tenant's servers; other tenants' lists never count.
+func (a *ToolListAnalyzer) ToolRisk(tenantID, serverID, toolID string) (float64, []string) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var worst *ToolAssessment
+	var worstServer string
+	for key, tools := range a.assessments {
+		if key.tenantID != tenantID || (serverID != "" && key.serverID != serverID) {
+			continue
+		}
+		if as, ok := tools[toolID]; ok && (worst == nil || as.Risk > worst.Risk) {
+			worst, worstServer = as, key.serverID
+		}
+	}
+	if worst == nil || worst.Risk == 0 {
+		return 0, nil
+	}
+	reasons := make([]string, len(worst.Findings))
+	for i, f := range worst.Findings {
+		reasons[i] = fmt.Sprintf("%s/%s on %s", f.Kind, f.Rule, worstServer)
+	}
+	return worst.Risk, reasons
+}
+
+// otherOwner returns another of the tenant's servers advertising name, if any.
+func (a *ToolListAnalyzer) otherOwner(key serverKey, name string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for other, tools := range a.assessments {
+		if _, ok := tools[name]; ok && other.tenantID == key.tenantID && other.serverID != key.serverID {
+			return other.serverID
+		}
+	}
+	return ""
+}
+
+// toolRisk combines findings as independent signals: 1 - Π(1 - w), each
+// kind counted once.
+func toolRisk(findings []ToolFinding) float64 {
+	seen := make(map[ToolFindingKind]bool)
+	clean := 1.0
+	for _, f := range findings {
+		if !seen[f.Kind] {
+			seen[f.Kind] = true
+			clean *= 1 - toolFindingWeight[f.Kind]
+		}
+	}
+	return 1 - clean
+}
+
+// ============================================================================
+// FINGERPRINTS
+// ============================================================================
+
+// toolFingerprint hashes everything the model sees about a tool. Schemas are
+// canonicalised so key order does not count as a change.
+func toolFingerprint(t protocol.MCPToolDefinition) string {
+	h := sha256.New()
+	for _, part := range []string{t.Name, t.Title, t.Description, canonicalJSON(t.InputSchema), canonicalJSON(t.Annotations)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func listFingerprint(tools map[string]string) string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\n", name, tools[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func canonicalJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v interface{}
+	if json.Unmarshal(raw, &v) != nil {
+		return string(raw)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func shortHash(h string) string {
+	return h[:min(12, len(h))]
+}
+
+// ============================================================================
+// DESCRIPTOR RULES
+// ============================================================================
+
+// toolPoisoningRules target instructions hidden in tool descriptors; the
+// prompt-injection rules shared with the response inspector also apply.
+var toolPoisoningRules = []struct {
+	kind ToolFindingKind
+	name string
+	re   *regexp.Regexp
+}{
+	{ToolFindingInjection, "important_tag", regexp.MustCompile(`(?i)<\s*/?\s*(?:important|secret|hidden|critical)\s*>`)},
+	{ToolFindingInjection, "conceal_from_user", regexp.MustCompile(`(?i)\b(?:do not|don't|never)\s+(?:tell|inform|mention|reveal|show|notify)\b[^.\n]{0,24}\buser\b`)},
+	{ToolFindingInjection, "cross_tool_instruction", regexp.MustCompile(`(?i)\b(?:before|after|instead of|when) (?:using|calling|invoking) (?:this|any|the [\w-]+|[\w-]+) tool\b`)},
+	{ToolFindingExfiltration, "sensitive_path", regexp.MustCompile(`(?i)(?:~/\.ssh|id_rsa|id_ed25519|\.aws/credentials|\.git-credentials|\.netrc|/etc/passwd|mcp\.json|\.cursor/|\.env\b)`)},
+	{ToolFindingExfiltration, "outbound_destination", regexp.MustCompile(`(?i)\b(?:send|forward|upload|post|email|exfiltrate)\b[^.\n]{0,32}\b(?:to|at)\s+(?:https?://|[\w.+-]+@[\w-]+\.)`)},
+}
+
+// scanToolDescriptor checks every model-visible text field of a tool.
+func scanToolDescriptor(t protocol.MCPToolDefinition) []ToolFinding {
+	fields := map[string]string{"name": t.Name, "title": t.Title, "description": t.Description}
+	if len(t.InputSchema) > 0 {
+		var schema interface{}
+		if json.Unmarshal(t.InputSchema, &schema) == nil {
+			walkStrings(schema, "inputSchema", true, nil, func(path, s string) string {
+				fields[path] = s
+				return s
+			})
+		}
+	}
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var findings []ToolFinding
+	for _, field := range paths {
+		text := fields[field]
+		if text == "" {
+			continue
+		}
+		if hidden := hiddenRunes(text); hidden != "" {
+			findings = append(findings, ToolFinding{Kind: ToolFindingHidden, Rule: "invisible_characters", Field: field, Detail: hidden})
+		}
+		// Matching runs on the visible text, so zero-width characters
+		// cannot be used to split a phrase
+		visible := stripHiddenRunes(text)
+		for _, rule := range responseRules {
+			if rule.kind == FindingInjection && rule.re.MatchString(visible) {
+				findings = append(findings, ToolFinding{Kind: ToolFindingInjection, Rule: rule.name, Field: field,
+					Detail: excerpt(visible, rule.re.FindStringIndex(visible))})
+			}
+		}
+		for _, rule := range toolPoisoningRules {
+			if loc := rule.re.FindStringIndex(visible); loc != nil {
+				findings = append(findings, ToolFinding{Kind: rule.kind, Rule: rule.name, Field: field, Detail: excerpt(visible, loc)})
+			}
+		}
+	}
+	return findings
+}
+
+// isHiddenRune reports zero-width, bidi-control and tag characters, which
+// render as nothing but are read by the model.
+func isHiddenRune(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F, // zero-width space/joiners, LRM/RLM
+		r >= 0x202A && r <= 0x202E,   // bidi embeddings and overrides
+		r >= 0x2060 && r <= 0x2064,   // word joiner, invisible operators
+		r >= 0x2066 && r <= 0x2069,   // bidi isolates
+		r == 0xFEFF,                  // zero-width no-break space
+		r == 0x00AD,                  // soft hyphen
+		r >= 0xE0000 && r <= 0xE007F: // Unicode tags
+		return true
+	}
+	return false
+}
+
+// hiddenRunes describes the hidden characters in s, decoding any text
+// smuggled as Unicode tag characters; it returns "" when there are none.
+func hiddenRunes(s string) string {
+	codes := make(map[rune]bool)
+	var smuggled strings.Builder
+	for _, r := range s {
+		if !isHiddenRune(r) {
+			continue
+		}
+		codes[r] = true
+		if r >= 0xE0020 && r <= 0xE007E {
+			smuggled.WriteRune(r - 0xE0000)
+		}
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	list := make([]string, 0, len(codes))
+	for r := range codes {
+		list = append(list, fmt.Sprintf("U+%04X", r))
+	}
+	sort.Strings(list)
+	detail := strings.Join(list, " ")
+	if smuggled.Len() > 0 {
+		detail += fmt.Sprintf(" (tag text %q)", truncateRunes(smuggled.String(), 80))
+	}
+	return detail
+}
+
+func stripHiddenRunes(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isHiddenRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// excerpt returns the match with a little surrounding context.
+func excerpt(s string, loc []int) string {
+	if loc == nil {
+		return ""
+	}
+	start, end := max(loc[0]-20, 0), min(loc[1]+20, len(s))
+	return truncateRunes(strings.ToValidUTF8(s[start:end], ""), 120)
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// ============================================================================
+// FINGERPRINT STORES
+// ============================================================================
+
+// MemoryToolFingerprintStore keeps baselines for the process lifetime.
+type MemoryToolFingerprintStore struct {
+	mu        sync.RWMutex
+	baselines map[serverKey]*ServerToolBaseline
+}
+
+// NewMemoryToolFingerprintStore creates an empty in-memory store.
+func NewMemoryToolFingerprintStore() *MemoryToolFingerprintStore {
+	return &MemoryToolFingerprintStore{baselines: make(map[serverKey]*ServerToolBaseline)}
+}
+
+func (m *MemoryToolFingerprintStore) Load(_ context.Context, tenantID, serverID string) (*ServerToolBaseline, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	b, ok := m.baselines[serverKey{tenantID, serverID}]
+	if !ok {
+		return nil, nil
+	}
+	copied := *b
+	return &copied, nil
+}
+
+func (m *MemoryToolFingerprintStore) Save(_ context.Context, baseline *ServerToolBaseline) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *baseline
+	m.baselines[serverKey{baseline.TenantID, baseline.ServerID}] = &copied
+	return nil
+}
+
+// FingerprintRedisClient is the subset of Redis operations the fingerprint
+// store needs. infra.GoRedisAdapter satisfies it; Get must wrap
+// fabric.ErrKeyNotFound for missing keys.
+type FingerprintRedisClient interface {
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// RedisToolFingerprintStore shares baselines across replicas and restarts.
+type RedisToolFingerprintStore struct {
+	client    FingerprintRedisClient
+	keyPrefix string
+}
+
+// NewRedisToolFingerprintStore creates a Redis-backed store.
+func NewRedisToolFingerprintStore(client FingerprintRedisClient, keyPrefix string) *RedisToolFingerprintStore {
+	return &RedisToolFingerprintStore{client: client, keyPrefix: keyPrefix}
+}
+
+func (r *RedisToolFingerprintStore) key(tenantID, serverID string) string {
+	return r.keyPrefix + "mcp-tools:" + tenantID + ":" + serverID
+}
+
+func (r *RedisToolFingerprintStore) Load(ctx context.Context, tenantID, serverID string) (*ServerToolBaseline, error) {
+	data, err := r.client.Get(ctx, r.key(tenantID, serverID))
+	if errors.Is(err, fabric.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	baseline := &ServerToolBaseline{}
+	if err := json.Unmarshal(data, baseline); err != nil {
+		return nil, fmt.Errorf("unmarshal baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// Save stores the baseline without expiry: a rug pull can come months later.
+func (r *RedisToolFingerprintStore) Save(ctx context.Context, baseline *ServerToolBaseline) error {
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		return fmt.Errorf("marshal baseline: %w", err)
+	}
+	return r.client.Set(ctx, r.key(baseline.TenantID, baseline.ServerID), data, 0)
+}
@@ -17,6 +17,8 @@ import (
 	"net/http/httputil"
 	"time"
 
+	"github.com/ocx/backend/internal/protocol"
+	"github.com/ocx/backend/internal/security"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -35,6 +37,13 @@ type SpeculativeProxy struct {
 	mockGenerator *MockGenerator
 	certGen       *CertGenerator
 	ctx           context.Context
+
+	// Streaming AI response observation (see stream_observer.go)
+	aiParser    *protocol.UniversalAIParser
+	onAIPayload AIPayloadCallback
+
+	// Tool result inspection before results reach the agent (see response_guard.go)
+	inspector *security.ResponseInspector
 }
 
 // Config for SOP
@@ -0,0 +1,121 @@
+/*
+Response Guard
+Inspects tool results returned through the SOP before they reach the agent,
+redacting or blocking them per the response inspection policy
+*/
+
+package sop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ocx/backend/internal/protocol"
+	"github.com/ocx/backend/internal/security"
+)
+
+// maxGuardedBody caps how much of a response is buffered for inspection.
+// Larger bodies are inspected on their prefix, which the inspector's own
+// size limit normally blocks first.
+const maxGuardedBody = 8 << 20
+
+// SetResponseInspector enables inspection of non-streaming responses.
+// Streaming responses are observed only, since bytes already sent to the
+// agent cannot be withdrawn.
+func (sop *SpeculativeProxy) SetResponseInspector(ri *security.ResponseInspector) {
+	sop.inspector = ri
+	if sop.aiParser == nil {
+		sop.aiParser = protocol.NewUniversalAIParser()
+	}
+	sop.realProxy.ModifyResponse = sop.observeResponse
+}
+
+// inspectResponse buffers resp's body, inspects it and replaces it with the
+// original, redacted or blocked body.
+func (sop *SpeculativeProxy) inspectResponse(resp *http.Response, txID, agentID string) error {
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		slog.Debug("SOP: encoded response not inspected", "tx_id", txID, "encoding", enc)
+		return nil
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "json") && !strings.HasPrefix(contentType, "text/") {
+		return nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, maxGuardedBody+1))
+	if err != nil {
+		return err
+	}
+	rest := resp.Body
+
+	ctx := context.Background()
+	target := security.ResponseTarget{TransactionID: txID, AgentID: agentID}
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+		target.TenantID = resp.Request.Header.Get("X-OCX-Tenant-ID")
+	}
+
+	// Every response through the SOP is an external call's result on its
+	// way back to the agent, whether or not it parses as an AI protocol
+	p := sop.aiParser.Parse(buf)
+	if !security.InspectsPayload(p) {
+		p = &protocol.AIPayload{
+			Protocol:    p.Protocol,
+			ToolName:    p.ToolName,
+			MessageType: "tool_result",
+			Direction:   "response",
+		}
+		if resp.Request != nil {
+			p.ToolName = resp.Request.URL.Host
+		}
+	}
+	res := sop.inspector.Inspect(ctx, target, p, buf)
+
+	switch res.Action {
+	case security.ResponseAllow:
+		resp.Body = &guardedBody{Reader: io.MultiReader(bytes.NewReader(buf), rest), closer: rest}
+		return nil
+
+	case security.ResponseRedact:
+		rest.Close()
+		setGuardedBody(resp, res.Content)
+		resp.Header.Set("X-OCX-Response-Redacted", "true")
+
+	default:
+		rest.Close()
+		body, _ := json.Marshal(map[string]interface{}{
+			"error":          "response blocked by governance",
+			"transaction_id": txID,
+			"reason":         res.Reason,
+			"evidence_hash":  res.EvidenceHash,
+		})
+		resp.StatusCode = http.StatusForbidden
+		resp.Status = "403 Forbidden"
+		resp.Header.Set("Content-Type", "application/json")
+		resp.Header.Set("X-OCX-Response-Blocked", "true")
+		setGuardedBody(resp, body)
+	}
+	slog.Info("SOP: response governed", "tx_id", txID, "tool", res.ToolName, "action", res.Action)
+	return nil
+}
+
+func setGuardedBody(resp *http.Response, body []byte) {
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+}
+
+// guardedBody replays the inspected prefix before the unread remainder.
+type guardedBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (g *guardedBody) Close() error { return g.closer.Close() }
@@ -0,0 +1,124 @@
+/*
+Streaming Response Observer
+Reassembles SSE / chunked AI responses flowing through the SOP so tool calls
+are surfaced as they complete, without buffering or delaying the stream
+*/
+
+package sop
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ocx/backend/internal/protocol"
+)
+
+// AIPayloadCallback receives payloads reassembled from proxied responses.
+type AIPayloadCallback func(txID, agentID string, payload *protocol.AIPayload)
+
+// SetAIPayloadCallback enables streaming response observation. Only
+// text/event-stream and chunked responses are observed; other bodies pass
+// through untouched.
+func (sop *SpeculativeProxy) SetAIPayloadCallback(cb AIPayloadCallback) {
+	sop.onAIPayload = cb
+	if sop.aiParser == nil {
+		sop.aiParser = protocol.NewUniversalAIParser()
+	}
+	sop.realProxy.ModifyResponse = sop.observeResponse
+}
+
+// observeResponse is the ReverseProxy ModifyResponse hook. Streaming bodies
+// are observed; other bodies go through the response inspector when one is
+// set (see response_guard.go).
+func (sop *SpeculativeProxy) observeResponse(resp *http.Response) error {
+	if resp.Body == nil {
+		return nil
+	}
+	contentType := resp.Header.Get("Content-Type")
+	streaming := strings.Contains(contentType, "text/event-stream")
+	for _, te := range resp.TransferEncoding {
+		if te == "chunked" {
+			streaming = true
+		}
+	}
+
+	var txID, agentID string
+	if resp.Request != nil {
+		txID = resp.Request.Header.Get("X-OCX-Transaction-ID")
+		agentID = resp.Request.Header.Get("X-OCX-Agent-ID")
+	}
+	if !streaming {
+		if sop.inspector != nil {
+			return sop.inspectResponse(resp, txID, agentID)
+		}
+		return nil
+	}
+	if sop.onAIPayload == nil {
+		return nil
+	}
+
+	// http.Response.Body is already de-chunked
+	resp.Body = &streamObserver{
+		body: resp.Body,
+		r:    protocol.NewBodyReassembler(sop.aiParser, contentType),
+		emit: func(p *protocol.AIPayload) { sop.onAIPayload(txID, agentID, p) },
+		txID: txID,
+	}
+	return nil
+}
+
+// streamObserver tees a response body into a StreamReassembler. Reassembly
+// errors disable observation but never interrupt the client's stream.
+type streamObserver struct {
+	body io.ReadCloser
+	r    *protocol.StreamReassembler
+	emit func(*protocol.AIPayload)
+	txID string
+
+	mu       sync.Mutex
+	disabled bool
+	flushed  bool
+}
+
+func (o *streamObserver) Read(p []byte) (int, error) {
+	n, err := o.body.Read(p)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if n > 0 && !o.disabled && !o.flushed {
+		payloads, werr := o.r.Write(p[:n])
+		o.emitAll(payloads)
+		if werr != nil {
+			slog.Warn("SOP: stream reassembly disabled", "tx_id", o.txID, "error", werr)
+			o.disabled = true
+		}
+	}
+	if err == io.EOF {
+		o.flushLocked()
+	}
+	return n, err
+}
+
+func (o *streamObserver) Close() error {
+	o.mu.Lock()
+	o.flushLocked()
+	o.mu.Unlock()
+	return o.body.Close()
+}
+
+func (o *streamObserver) flushLocked() {
+	if o.flushed || o.disabled {
+		return
+	}
+	o.flushed = true
+	o.emitAll(o.r.Flush())
+}
+
+func (o *streamObserver) emitAll(payloads []*protocol.AIPayload) {
+	for _, p := range payloads {
+		o.emit(p)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/perf"
+
+	"github.com/ocx/backend/internal/protocol"
 )
 
 // TLSEvent matches the eBPF struct
@@ -33,6 +35,18 @@ type TLSInterceptor struct {
 	reader    *perf.Reader
 	eventChan chan *TLSEvent
 	stopChan  chan struct{}
+
+	// Plaintext is reassembled per PID/TID/direction so streamed (SSE)
+	// tool calls are detected once complete
+	streams   *protocol.StreamTracker
+	aiPayload chan *TLSAIPayload
+}
+
+// TLSAIPayload is an AI payload reassembled from intercepted TLS plaintext
+type TLSAIPayload struct {
+	PID     uint32
+	Comm    string
+	Payload *protocol.AIPayload
 }
 
 // ebpfObjects placeholder (generated by bpf2go)
@@ -45,6 +59,8 @@ func NewTLSInterceptor() (*TLSInterceptor, error) {
 	ti := &TLSInterceptor{
 		eventChan: make(chan *TLSEvent, 1000),
 		stopChan:  make(chan struct{}),
+		streams:   protocol.NewStreamTracker(nil, 0, 0),
+		aiPayload: make(chan *TLSAIPayload, 1000),
 	}
 
 	// Load eBPF program
@@ -185,6 +201,11 @@ func (ti *TLSInterceptor) GetEventChannel() <-chan *TLSEvent {
 	return ti.eventChan
 }
 
+// GetAIPayloadChannel returns AI payloads reassembled from TLS plaintext
+func (ti *TLSInterceptor) GetAIPayloadChannel() <-chan *TLSAIPayload {
+	return ti.aiPayload
+}
+
 // ProcessEvent processes a TLS event
 func (ti *TLSInterceptor) ProcessEvent(event *TLSEvent) {
 	direction := "WRITE"
@@ -204,6 +225,23 @@ func (ti *TLSInterceptor) ProcessEvent(event *TLSEvent) {
 	plaintext := string(event.Data[:event.DataLen])
 
 	slog.Info("TLS [] PID: TID: Len:\n", "direction", direction, "library", library, "p_i_d", event.PID, "t_i_d", event.TID, "data_len", event.DataLen, "plaintext", plaintext)
+
+	// Reassemble AI protocol payloads; a tool call may span many SSL_read calls
+	if ti.streams != nil {
+		key := fmt.Sprintf("%d:%d:%d", event.PID, event.TID, event.Direction)
+		payloads, err := ti.streams.Feed(key, event.Data[:event.DataLen])
+		if err != nil {
+			slog.Warn("TLS stream reassembly reset", "stream", key, "error", err)
+		}
+		for _, p := range payloads {
+			slog.Info("AI payload reassembled from TLS", "p_i_d", event.PID, "protocol", p.Protocol, "tool_name", p.ToolName, "message_type", p.MessageType)
+			select {
+			case ti.aiPayload <- &TLSAIPayload{PID: event.PID, Comm: event.GetProcessName(), Payload: p}:
+			default:
+				slog.Warn("TLS AI payload channel full, dropping", "tool_name", p.ToolName)
+			}
+		}
+	}
 	// Send to Entropy Monitor for analysis
 	// Send to APE Engine for intent extraction
 }
@@ -0,0 +1,521 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: pb/connector_plugin.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DescribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribeRequest) Reset() {
+	*x = DescribeRequest{}
+	mi := &file_pb_connector_plugin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribeRequest) ProtoMessage() {}
+
+func (x *DescribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connector_plugin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribeRequest.ProtoReflect.Descriptor instead.
+func (*DescribeRequest) Descriptor() ([]byte, []int) {
+	return file_pb_connector_plugin_proto_rawDescGZIP(), []int{0}
+}
+
+// Static plugin metadata, fetched once when the plugin is loaded
+type DescribeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique plugin name
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Plugin version
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// AI protocols the plugin handles
+	Protocols []string `protobuf:"bytes,3,rep,name=protocols,proto3" json:"protocols,omitempty"`
+	// Parse order (lower = tried first)
+	Priority      int32 `protobuf:"varint,4,opt,name=priority,proto3" json:"priority,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribeResponse) Reset() {
+	*x = DescribeResponse{}
+	mi := &file_pb_connector_plugin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribeResponse) ProtoMessage() {}
+
+func (x *DescribeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connector_plugin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribeResponse.ProtoReflect.Descriptor instead.
+func (*DescribeResponse) Descriptor() ([]byte, []int) {
+	return file_pb_connector_plugin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DescribeResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DescribeResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *DescribeResponse) GetProtocols() []string {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *DescribeResponse) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+// Raw payload captured by the gateway
+type PayloadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       []byte                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PayloadRequest) Reset() {
+	*x = PayloadRequest{}
+	mi := &file_pb_connector_plugin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PayloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PayloadRequest) ProtoMessage() {}
+
+func (x *PayloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connector_plugin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PayloadRequest.ProtoReflect.Descriptor instead.
+func (*PayloadRequest) Descriptor() ([]byte, []int) {
+	return file_pb_connector_plugin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PayloadRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type CanHandleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CanHandle     bool                   `protobuf:"varint,1,opt,name=can_handle,json=canHandle,proto3" json:"can_handle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CanHandleResponse) Reset() {
+	*x = CanHandleResponse{}
+	mi := &file_pb_connector_plugin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CanHandleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CanHandleResponse) ProtoMessage() {}
+
+func (x *CanHandleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connector_plugin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CanHandleResponse.ProtoReflect.Descriptor instead.
+func (*CanHandleResponse) Descriptor() ([]byte, []int) {
+	return file_pb_connector_plugin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CanHandleResponse) GetCanHandle() bool {
+	if x != nil {
+		return x.CanHandle
+	}
+	return false
+}
+
+// One extracted invocation; mirrors protocol.AIPayload
+type ParsedPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Protocol      string                 `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	ToolName      string                 `protobuf:"bytes,2,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	AgentId       string                 `protobuf:"bytes,3,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,4,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	TaskId        string                 `protobuf:"bytes,5,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Arguments     *structpb.Struct       `protobuf:"bytes,6,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	Model         string                 `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	MessageType   string                 `protobuf:"bytes,8,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
+	Direction     string                 `protobuf:"bytes,9,opt,name=direction,proto3" json:"direction,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,10,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	CallId        string                 `protobuf:"bytes,11,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	RawMethod     string                 `protobuf:"bytes,12,opt,name=raw_method,json=rawMethod,proto3" json:"raw_method,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,13,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParsedPayload) Reset() {
+	*x = ParsedPayload{}
+	mi := &file_pb_connector_plugin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParsedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParsedPayload) ProtoMessage() {}
+
+func (x *ParsedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connector_plugin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParsedPayload.ProtoReflect.Descriptor instead.
+func (*ParsedPayload) Descriptor() ([]byte, []int) {
+	return file_pb_connector_plugin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ParsedPayload) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetArguments() *structpb.Struct {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+func (x *ParsedPayload) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *ParsedPayload) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetRawMethod() string {
+	if x != nil {
+		return x.RawMethod
+	}
+	return ""
+}
+
+func (x *ParsedPayload) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// Every invocation in the payload, in payload order. A non-empty error means
+// the plugin could not parse the payload.
+type ParseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payloads      []*ParsedPayload       `protobuf:"bytes,1,rep,name=payloads,proto3" json:"payloads,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseResponse) Reset() {
+	*x = ParseResponse{}
+	mi := &file_pb_connector_plugin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseResponse) ProtoMessage() {}
+
+func (x *ParseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connector_plugin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseResponse.ProtoReflect.Descriptor instead.
+func (*ParseResponse) Descriptor() ([]byte, []int) {
+	return file_pb_connector_plugin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ParseResponse) GetPayloads() []*ParsedPayload {
+	if x != nil {
+		return x.Payloads
+	}
+	return nil
+}
+
+func (x *ParseResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_pb_connector_plugin_proto protoreflect.FileDescriptor
+
+const file_pb_connector_plugin_proto_rawDesc = "" +
+	"\n" +
+	"\x19pb/connector_plugin.proto\x12\aplugins\x1a\x1cgoogle/protobuf/struct.proto\"\x11\n" +
+	"\x0fDescribeRequest\"z\n" +
+	"\x10DescribeResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x1c\n" +
+	"\tprotocols\x18\x03 \x03(\tR\tprotocols\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\x05R\bpriority\"*\n" +
+	"\x0ePayloadRequest\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\"2\n" +
+	"\x11CanHandleResponse\x12\x1d\n" +
+	"\n" +
+	"can_handle\x18\x01 \x01(\bR\tcanHandle\"\xb4\x03\n" +
+	"\rParsedPayload\x12\x1a\n" +
+	"\bprotocol\x18\x01 \x01(\tR\bprotocol\x12\x1b\n" +
+	"\ttool_name\x18\x02 \x01(\tR\btoolName\x12\x19\n" +
+	"\bagent_id\x18\x03 \x01(\tR\aagentId\x12\x1b\n" +
+	"\ttenant_id\x18\x04 \x01(\tR\btenantId\x12\x17\n" +
+	"\atask_id\x18\x05 \x01(\tR\x06taskId\x125\n" +
+	"\targuments\x18\x06 \x01(\v2\x17.google.protobuf.StructR\targuments\x12\x14\n" +
+	"\x05model\x18\a \x01(\tR\x05model\x12!\n" +
+	"\fmessage_type\x18\b \x01(\tR\vmessageType\x12\x1c\n" +
+	"\tdirection\x18\t \x01(\tR\tdirection\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\n" +
+	" \x01(\x01R\n" +
+	"confidence\x12\x17\n" +
+	"\acall_id\x18\v \x01(\tR\x06callId\x12\x1d\n" +
+	"\n" +
+	"raw_method\x18\f \x01(\tR\trawMethod\x123\n" +
+	"\bmetadata\x18\r \x01(\v2\x17.google.protobuf.StructR\bmetadata\"Y\n" +
+	"\rParseResponse\x122\n" +
+	"\bpayloads\x18\x01 \x03(\v2\x16.plugins.ParsedPayloadR\bpayloads\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error2\xd5\x01\n" +
+	"\x16ConnectorPluginService\x12?\n" +
+	"\bDescribe\x12\x18.plugins.DescribeRequest\x1a\x19.plugins.DescribeResponse\x12@\n" +
+	"\tCanHandle\x12\x17.plugins.PayloadRequest\x1a\x1a.plugins.CanHandleResponse\x128\n" +
+	"\x05Parse\x12\x17.plugins.PayloadRequest\x1a\x16.plugins.ParseResponseB\x1bZ\x19github.com/ocx/backend/pbb\x06proto3"
+
+var (
+	file_pb_connector_plugin_proto_rawDescOnce sync.Once
+	file_pb_connector_plugin_proto_rawDescData []byte
+)
+
+func file_pb_connector_plugin_proto_rawDescGZIP() []byte {
+	file_pb_connector_plugin_proto_rawDescOnce.Do(func() {
+		file_pb_connector_plugin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_connector_plugin_proto_rawDesc), len(file_pb_connector_plugin_proto_rawDesc)))
+	})
+	return file_pb_connector_plugin_proto_rawDescData
+}
+
+var file_pb_connector_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_pb_connector_plugin_proto_goTypes = []any{
+	(*DescribeRequest)(nil),   // 0: plugins.DescribeRequest
+	(*DescribeResponse)(nil),  // 1: plugins.DescribeResponse
+	(*PayloadRequest)(nil),    // 2: plugins.PayloadRequest
+	(*CanHandleResponse)(nil), // 3: plugins.CanHandleResponse
+	(*ParsedPayload)(nil),     // 4: plugins.ParsedPayload
+	(*ParseResponse)(nil),     // 5: plugins.ParseResponse
+	(*structpb.Struct)(nil),   // 6: google.protobuf.Struct
+}
+var file_pb_connector_plugin_proto_depIdxs = []int32{
+	6, // 0: plugins.ParsedPayload.arguments:type_name -> google.protobuf.Struct
+	6, // 1: plugins.ParsedPayload.metadata:type_name -> google.protobuf.Struct
+	4, // 2: plugins.ParseResponse.payloads:type_name -> plugins.ParsedPayload
+	0, // 3: plugins.ConnectorPluginService.Describe:input_type -> plugins.DescribeRequest
+	2, // 4: plugins.ConnectorPluginService.CanHandle:input_type -> plugins.PayloadRequest
+	2, // 5: plugins.ConnectorPluginService.Parse:input_type -> plugins.PayloadRequest
+	1, // 6: plugins.ConnectorPluginService.Describe:output_type -> plugins.DescribeResponse
+	3, // 7: plugins.ConnectorPluginService.CanHandle:output_type -> plugins.CanHandleResponse
+	5, // 8: plugins.ConnectorPluginService.Parse:output_type -> plugins.ParseResponse
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_pb_connector_plugin_proto_init() }
+func file_pb_connector_plugin_proto_init() {
+	if File_pb_connector_plugin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_connector_plugin_proto_rawDesc), len(file_pb_connector_plugin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pb_connector_plugin_proto_goTypes,
+		DependencyIndexes: file_pb_connector_plugin_proto_depIdxs,
+		MessageInfos:      file_pb_connector_plugin_proto_msgTypes,
+	}.Build()
+	File_pb_connector_plugin_proto = out.File
+	file_pb_connector_plugin_proto_goTypes = nil
+	file_pb_connector_plugin_proto_depIdxs = nil
+}
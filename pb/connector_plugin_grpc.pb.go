@@ -0,0 +1,204 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             v5.29.3
+// source: pb/connector_plugin.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ConnectorPluginService_Describe_FullMethodName  = "/plugins.ConnectorPluginService/Describe"
+	ConnectorPluginService_CanHandle_FullMethodName = "/plugins.ConnectorPluginService/CanHandle"
+	ConnectorPluginService_Parse_FullMethodName     = "/plugins.ConnectorPluginService/Parse"
+)
+
+// ConnectorPluginServiceClient is the client API for ConnectorPluginService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConnectorPluginServiceClient interface {
+	// Plugin name, version, protocols and priority
+	Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error)
+	// Fast check whether the plugin recognises the payload
+	CanHandle(ctx context.Context, in *PayloadRequest, opts ...grpc.CallOption) (*CanHandleResponse, error)
+	// Extract every invocation in the payload
+	Parse(ctx context.Context, in *PayloadRequest, opts ...grpc.CallOption) (*ParseResponse, error)
+}
+
+type connectorPluginServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConnectorPluginServiceClient(cc grpc.ClientConnInterface) ConnectorPluginServiceClient {
+	return &connectorPluginServiceClient{cc}
+}
+
+func (c *connectorPluginServiceClient) Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DescribeResponse)
+	err := c.cc.Invoke(ctx, ConnectorPluginService_Describe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorPluginServiceClient) CanHandle(ctx context.Context, in *PayloadRequest, opts ...grpc.CallOption) (*CanHandleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CanHandleResponse)
+	err := c.cc.Invoke(ctx, ConnectorPluginService_CanHandle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorPluginServiceClient) Parse(ctx context.Context, in *PayloadRequest, opts ...grpc.CallOption) (*ParseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ParseResponse)
+	err := c.cc.Invoke(ctx, ConnectorPluginService_Parse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConnectorPluginServiceServer is the server API for ConnectorPluginService service.
+// All implementations must embed UnimplementedConnectorPluginServiceServer
+// for forward compatibility.
+type ConnectorPluginServiceServer interface {
+	// Plugin name, version, protocols and priority
+	Describe(context.Context, *DescribeRequest) (*DescribeResponse, error)
+	// Fast check whether the plugin recognises the payload
+	CanHandle(context.Context, *PayloadRequest) (*CanHandleResponse, error)
+	// Extract every invocation in the payload
+	Parse(context.Context, *PayloadRequest) (*ParseResponse, error)
+	mustEmbedUnimplementedConnectorPluginServiceServer()
+}
+
+// UnimplementedConnectorPluginServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConnectorPluginServiceServer struct{}
+
+func (UnimplementedConnectorPluginServiceServer) Describe(context.Context, *DescribeRequest) (*DescribeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Describe not implemented")
+}
+func (UnimplementedConnectorPluginServiceServer) CanHandle(context.Context, *PayloadRequest) (*CanHandleResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CanHandle not implemented")
+}
+func (UnimplementedConnectorPluginServiceServer) Parse(context.Context, *PayloadRequest) (*ParseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Parse not implemented")
+}
+func (UnimplementedConnectorPluginServiceServer) mustEmbedUnimplementedConnectorPluginServiceServer() {
+}
+func (UnimplementedConnectorPluginServiceServer) testEmbeddedByValue() {}
+
+// UnsafeConnectorPluginServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConnectorPluginServiceServer will
+// result in compilation errors.
+type UnsafeConnectorPluginServiceServer interface {
+	mustEmbedUnimplementedConnectorPluginServiceServer()
+}
+
+func RegisterConnectorPluginServiceServer(s grpc.ServiceRegistrar, srv ConnectorPluginServiceServer) {
+	// If the following call panics, it indicates UnimplementedConnectorPluginServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConnectorPluginService_ServiceDesc, srv)
+}
+
+func _ConnectorPluginService_Describe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorPluginServiceServer).Describe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorPluginService_Describe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorPluginServiceServer).Describe(ctx, req.(*DescribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorPluginService_CanHandle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PayloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorPluginServiceServer).CanHandle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorPluginService_CanHandle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorPluginServiceServer).CanHandle(ctx, req.(*PayloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorPluginService_Parse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PayloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorPluginServiceServer).Parse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorPluginService_Parse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorPluginServiceServer).Parse(ctx, req.(*PayloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConnectorPluginService_ServiceDesc is the grpc.ServiceDesc for ConnectorPluginService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConnectorPluginService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "plugins.ConnectorPluginService",
+	HandlerType: (*ConnectorPluginServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Describe",
+			Handler:    _ConnectorPluginService_Describe_Handler,
+		},
+		{
+			MethodName: "CanHandle",
+			Handler:    _ConnectorPluginService_CanHandle_Handler,
+		},
+		{
+			MethodName: "Parse",
+			Handler:    _ConnectorPluginService_Parse_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pb/connector_plugin.proto",
+}
@@ -0,0 +1,333 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: pb/federation_transport.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// A FederatedMessage on the wire.
+type FederatedEnvelope struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceOcx    string                 `protobuf:"bytes,2,opt,name=source_ocx,json=sourceOcx,proto3" json:"source_ocx,omitempty"`
+	DestOcx      string                 `protobuf:"bytes,3,opt,name=dest_ocx,json=destOcx,proto3" json:"dest_ocx,omitempty"`
+	SourceTenant string                 `protobuf:"bytes,4,opt,name=source_tenant,json=sourceTenant,proto3" json:"source_tenant,omitempty"`
+	DestTenant   string                 `protobuf:"bytes,5,opt,name=dest_tenant,json=destTenant,proto3" json:"dest_tenant,omitempty"`
+	SourceAgent  string                 `protobuf:"bytes,6,opt,name=source_agent,json=sourceAgent,proto3" json:"source_agent,omitempty"`
+	DestAgent    string                 `protobuf:"bytes,7,opt,name=dest_agent,json=destAgent,proto3" json:"dest_agent,omitempty"`
+	MessageType  string                 `protobuf:"bytes,8,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
+	Payload      []byte                 `protobuf:"bytes,9,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Send time (Unix nanoseconds, UTC)
+	Timestamp int64 `protobuf:"varint,10,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Lifetime in seconds, counted from timestamp
+	Ttl     int32  `protobuf:"varint,11,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	TraceId string `protobuf:"bytes,12,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	// Sender's signature over the canonical message (signature excluded)
+	Signature     []byte `protobuf:"bytes,13,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FederatedEnvelope) Reset() {
+	*x = FederatedEnvelope{}
+	mi := &file_pb_federation_transport_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FederatedEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FederatedEnvelope) ProtoMessage() {}
+
+func (x *FederatedEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_federation_transport_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FederatedEnvelope.ProtoReflect.Descriptor instead.
+func (*FederatedEnvelope) Descriptor() ([]byte, []int) {
+	return file_pb_federation_transport_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FederatedEnvelope) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetSourceOcx() string {
+	if x != nil {
+		return x.SourceOcx
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetDestOcx() string {
+	if x != nil {
+		return x.DestOcx
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetSourceTenant() string {
+	if x != nil {
+		return x.SourceTenant
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetDestTenant() string {
+	if x != nil {
+		return x.DestTenant
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetSourceAgent() string {
+	if x != nil {
+		return x.SourceAgent
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetDestAgent() string {
+	if x != nil {
+		return x.DestAgent
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *FederatedEnvelope) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *FederatedEnvelope) GetTtl() int32 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+func (x *FederatedEnvelope) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *FederatedEnvelope) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// Acknowledgement that the receiver accepted and routed an envelope.
+type DeliveryReceipt struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	MessageId   string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	ReceiverOcx string                 `protobuf:"bytes,2,opt,name=receiver_ocx,json=receiverOcx,proto3" json:"receiver_ocx,omitempty"`
+	// Federation trust tax the receiver charged for this message
+	TrustTax float64 `protobuf:"fixed64,3,opt,name=trust_tax,json=trustTax,proto3" json:"trust_tax,omitempty"`
+	// Receiver-side EvidenceFederation record, when an evidence vault is wired
+	EvidenceId string `protobuf:"bytes,4,opt,name=evidence_id,json=evidenceId,proto3" json:"evidence_id,omitempty"`
+	// Receive time (Unix nanoseconds, UTC)
+	ReceivedAt    int64 `protobuf:"varint,5,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeliveryReceipt) Reset() {
+	*x = DeliveryReceipt{}
+	mi := &file_pb_federation_transport_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeliveryReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliveryReceipt) ProtoMessage() {}
+
+func (x *DeliveryReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_federation_transport_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliveryReceipt.ProtoReflect.Descriptor instead.
+func (*DeliveryReceipt) Descriptor() ([]byte, []int) {
+	return file_pb_federation_transport_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeliveryReceipt) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *DeliveryReceipt) GetReceiverOcx() string {
+	if x != nil {
+		return x.ReceiverOcx
+	}
+	return ""
+}
+
+func (x *DeliveryReceipt) GetTrustTax() float64 {
+	if x != nil {
+		return x.TrustTax
+	}
+	return 0
+}
+
+func (x *DeliveryReceipt) GetEvidenceId() string {
+	if x != nil {
+		return x.EvidenceId
+	}
+	return ""
+}
+
+func (x *DeliveryReceipt) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+var File_pb_federation_transport_proto protoreflect.FileDescriptor
+
+const file_pb_federation_transport_proto_rawDesc = "" +
+	"\n" +
+	"\x1dpb/federation_transport.proto\x12\n" +
+	"federation\"\x8b\x03\n" +
+	"\x11FederatedEnvelope\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"source_ocx\x18\x02 \x01(\tR\tsourceOcx\x12\x19\n" +
+	"\bdest_ocx\x18\x03 \x01(\tR\adestOcx\x12#\n" +
+	"\rsource_tenant\x18\x04 \x01(\tR\fsourceTenant\x12\x1f\n" +
+	"\vdest_tenant\x18\x05 \x01(\tR\n" +
+	"destTenant\x12!\n" +
+	"\fsource_agent\x18\x06 \x01(\tR\vsourceAgent\x12\x1d\n" +
+	"\n" +
+	"dest_agent\x18\a \x01(\tR\tdestAgent\x12!\n" +
+	"\fmessage_type\x18\b \x01(\tR\vmessageType\x12\x18\n" +
+	"\apayload\x18\t \x01(\fR\apayload\x12\x1c\n" +
+	"\ttimestamp\x18\n" +
+	" \x01(\x03R\ttimestamp\x12\x10\n" +
+	"\x03ttl\x18\v \x01(\x05R\x03ttl\x12\x19\n" +
+	"\btrace_id\x18\f \x01(\tR\atraceId\x12\x1c\n" +
+	"\tsignature\x18\r \x01(\fR\tsignature\"\xb2\x01\n" +
+	"\x0fDeliveryReceipt\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12!\n" +
+	"\freceiver_ocx\x18\x02 \x01(\tR\vreceiverOcx\x12\x1b\n" +
+	"\ttrust_tax\x18\x03 \x01(\x01R\btrustTax\x12\x1f\n" +
+	"\vevidence_id\x18\x04 \x01(\tR\n" +
+	"evidenceId\x12\x1f\n" +
+	"\vreceived_at\x18\x05 \x01(\x03R\n" +
+	"receivedAt2`\n" +
+	"\x17FederatedMessageService\x12E\n" +
+	"\aDeliver\x12\x1d.federation.FederatedEnvelope\x1a\x1b.federation.DeliveryReceiptB\x1bZ\x19github.com/ocx/backend/pbb\x06proto3"
+
+var (
+	file_pb_federation_transport_proto_rawDescOnce sync.Once
+	file_pb_federation_transport_proto_rawDescData []byte
+)
+
+func file_pb_federation_transport_proto_rawDescGZIP() []byte {
+	file_pb_federation_transport_proto_rawDescOnce.Do(func() {
+		file_pb_federation_transport_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_federation_transport_proto_rawDesc), len(file_pb_federation_transport_proto_rawDesc)))
+	})
+	return file_pb_federation_transport_proto_rawDescData
+}
+
+var file_pb_federation_transport_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_pb_federation_transport_proto_goTypes = []any{
+	(*FederatedEnvelope)(nil), // 0: federation.FederatedEnvelope
+	(*DeliveryReceipt)(nil),   // 1: federation.DeliveryReceipt
+}
+var file_pb_federation_transport_proto_depIdxs = []int32{
+	0, // 0: federation.FederatedMessageService.Deliver:input_type -> federation.FederatedEnvelope
+	1, // 1: federation.FederatedMessageService.Deliver:output_type -> federation.DeliveryReceipt
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pb_federation_transport_proto_init() }
+func file_pb_federation_transport_proto_init() {
+	if File_pb_federation_transport_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_federation_transport_proto_rawDesc), len(file_pb_federation_transport_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pb_federation_transport_proto_goTypes,
+		DependencyIndexes: file_pb_federation_transport_proto_depIdxs,
+		MessageInfos:      file_pb_federation_transport_proto_msgTypes,
+	}.Build()
+	File_pb_federation_transport_proto = out.File
+	file_pb_federation_transport_proto_goTypes = nil
+	file_pb_federation_transport_proto_depIdxs = nil
+}
@@ -0,0 +1,130 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             v5.29.3
+// source: pb/federation_transport.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FederatedMessageService_Deliver_FullMethodName = "/federation.FederatedMessageService/Deliver"
+)
+
+// FederatedMessageServiceClient is the client API for FederatedMessageService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FederatedMessageServiceClient interface {
+	// Deliver one signed message to this OCX instance. Rejections are
+	// returned as gRPC status errors (Unauthenticated for a bad signature,
+	// FailedPrecondition for an expired envelope, NotFound when the
+	// destination agent is not reachable).
+	Deliver(ctx context.Context, in *FederatedEnvelope, opts ...grpc.CallOption) (*DeliveryReceipt, error)
+}
+
+type federatedMessageServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFederatedMessageServiceClient(cc grpc.ClientConnInterface) FederatedMessageServiceClient {
+	return &federatedMessageServiceClient{cc}
+}
+
+func (c *federatedMessageServiceClient) Deliver(ctx context.Context, in *FederatedEnvelope, opts ...grpc.CallOption) (*DeliveryReceipt, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeliveryReceipt)
+	err := c.cc.Invoke(ctx, FederatedMessageService_Deliver_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FederatedMessageServiceServer is the server API for FederatedMessageService service.
+// All implementations must embed UnimplementedFederatedMessageServiceServer
+// for forward compatibility.
+type FederatedMessageServiceServer interface {
+	// Deliver one signed message to this OCX instance. Rejections are
+	// returned as gRPC status errors (Unauthenticated for a bad signature,
+	// FailedPrecondition for an expired envelope, NotFound when the
+	// destination agent is not reachable).
+	Deliver(context.Context, *FederatedEnvelope) (*DeliveryReceipt, error)
+	mustEmbedUnimplementedFederatedMessageServiceServer()
+}
+
+// UnimplementedFederatedMessageServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFederatedMessageServiceServer struct{}
+
+func (UnimplementedFederatedMessageServiceServer) Deliver(context.Context, *FederatedEnvelope) (*DeliveryReceipt, error) {
+	return nil, status.Error(codes.Unimplemented, "method Deliver not implemented")
+}
+func (UnimplementedFederatedMessageServiceServer) mustEmbedUnimplementedFederatedMessageServiceServer() {
+}
+func (UnimplementedFederatedMessageServiceServer) testEmbeddedByValue() {}
+
+// UnsafeFederatedMessageServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FederatedMessageServiceServer will
+// result in compilation errors.
+type UnsafeFederatedMessageServiceServer interface {
+	mustEmbedUnimplementedFederatedMessageServiceServer()
+}
+
+func RegisterFederatedMessageServiceServer(s grpc.ServiceRegistrar, srv FederatedMessageServiceServer) {
+	// If the following call panics, it indicates UnimplementedFederatedMessageServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FederatedMessageService_ServiceDesc, srv)
+}
+
+func _FederatedMessageService_Deliver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FederatedEnvelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FederatedMessageServiceServer).Deliver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FederatedMessageService_Deliver_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FederatedMessageServiceServer).Deliver(ctx, req.(*FederatedEnvelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FederatedMessageService_ServiceDesc is the grpc.ServiceDesc for FederatedMessageService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FederatedMessageService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "federation.FederatedMessageService",
+	HandlerType: (*FederatedMessageServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Deliver",
+			Handler:    _FederatedMessageService_Deliver_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pb/federation_transport.proto",
+}
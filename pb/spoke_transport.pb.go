@@ -0,0 +1,818 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: pb/spoke_transport.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Spoke → Hub: registration (must be the first frame on the stream)
+type SpokeRegister struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tenant the spoke asks to join; the hub checks the agent is registered in it
+	TenantId string `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// Agent identifier; must match the agent path of the caller's SVID
+	AgentId string `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	// Advertised capabilities used for cap:// routing
+	Capabilities []string `protobuf:"bytes,3,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// Ignored: the hub takes trust from the reputation wallet
+	TrustScore float64 `protobuf:"fixed64,4,opt,name=trust_score,json=trustScore,proto3" json:"trust_score,omitempty"`
+	// Ignored: the hub takes entitlements from active JIT grants
+	Entitlements  []string `protobuf:"bytes,5,rep,name=entitlements,proto3" json:"entitlements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpokeRegister) Reset() {
+	*x = SpokeRegister{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpokeRegister) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpokeRegister) ProtoMessage() {}
+
+func (x *SpokeRegister) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpokeRegister.ProtoReflect.Descriptor instead.
+func (*SpokeRegister) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SpokeRegister) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *SpokeRegister) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *SpokeRegister) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *SpokeRegister) GetTrustScore() float64 {
+	if x != nil {
+		return x.TrustScore
+	}
+	return 0
+}
+
+func (x *SpokeRegister) GetEntitlements() []string {
+	if x != nil {
+		return x.Entitlements
+	}
+	return nil
+}
+
+// Spoke → Hub: route a message through the fabric
+type SpokeSend struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Client-assigned message ID, echoed in SendResult
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Application message type
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Virtual address, cap://<capability> or broadcast://<tenant>
+	Destination string `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+	// Opaque message payload
+	Payload []byte `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Optional message headers
+	Headers map[string]string `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Hop limit (defaults to 5 when unset)
+	Ttl           int32 `protobuf:"varint,6,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpokeSend) Reset() {
+	*x = SpokeSend{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpokeSend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpokeSend) ProtoMessage() {}
+
+func (x *SpokeSend) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpokeSend.ProtoReflect.Descriptor instead.
+func (*SpokeSend) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SpokeSend) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SpokeSend) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SpokeSend) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *SpokeSend) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *SpokeSend) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *SpokeSend) GetTtl() int32 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+// Spoke → Hub: acknowledge a Delivery
+type SpokeAck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the delivered message
+	MessageId     string `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpokeAck) Reset() {
+	*x = SpokeAck{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpokeAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpokeAck) ProtoMessage() {}
+
+func (x *SpokeAck) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpokeAck.ProtoReflect.Descriptor instead.
+func (*SpokeAck) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SpokeAck) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+// Envelope for everything the spoke sends
+type SpokeFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*SpokeFrame_Register
+	//	*SpokeFrame_Send
+	//	*SpokeFrame_Ack
+	Frame         isSpokeFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpokeFrame) Reset() {
+	*x = SpokeFrame{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpokeFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpokeFrame) ProtoMessage() {}
+
+func (x *SpokeFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpokeFrame.ProtoReflect.Descriptor instead.
+func (*SpokeFrame) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SpokeFrame) GetFrame() isSpokeFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *SpokeFrame) GetRegister() *SpokeRegister {
+	if x != nil {
+		if x, ok := x.Frame.(*SpokeFrame_Register); ok {
+			return x.Register
+		}
+	}
+	return nil
+}
+
+func (x *SpokeFrame) GetSend() *SpokeSend {
+	if x != nil {
+		if x, ok := x.Frame.(*SpokeFrame_Send); ok {
+			return x.Send
+		}
+	}
+	return nil
+}
+
+func (x *SpokeFrame) GetAck() *SpokeAck {
+	if x != nil {
+		if x, ok := x.Frame.(*SpokeFrame_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+type isSpokeFrame_Frame interface {
+	isSpokeFrame_Frame()
+}
+
+type SpokeFrame_Register struct {
+	Register *SpokeRegister `protobuf:"bytes,1,opt,name=register,proto3,oneof"`
+}
+
+type SpokeFrame_Send struct {
+	Send *SpokeSend `protobuf:"bytes,2,opt,name=send,proto3,oneof"`
+}
+
+type SpokeFrame_Ack struct {
+	Ack *SpokeAck `protobuf:"bytes,3,opt,name=ack,proto3,oneof"`
+}
+
+func (*SpokeFrame_Register) isSpokeFrame_Frame() {}
+
+func (*SpokeFrame_Send) isSpokeFrame_Frame() {}
+
+func (*SpokeFrame_Ack) isSpokeFrame_Frame() {}
+
+// Hub → Spoke: registration accepted
+type SpokeRegistered struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpokeId       string                 `protobuf:"bytes,1,opt,name=spoke_id,json=spokeId,proto3" json:"spoke_id,omitempty"`
+	VirtualAddr   string                 `protobuf:"bytes,2,opt,name=virtual_addr,json=virtualAddr,proto3" json:"virtual_addr,omitempty"`
+	HubId         string                 `protobuf:"bytes,3,opt,name=hub_id,json=hubId,proto3" json:"hub_id,omitempty"`
+	ReplicaId     string                 `protobuf:"bytes,4,opt,name=replica_id,json=replicaId,proto3" json:"replica_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpokeRegistered) Reset() {
+	*x = SpokeRegistered{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpokeRegistered) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpokeRegistered) ProtoMessage() {}
+
+func (x *SpokeRegistered) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpokeRegistered.ProtoReflect.Descriptor instead.
+func (*SpokeRegistered) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SpokeRegistered) GetSpokeId() string {
+	if x != nil {
+		return x.SpokeId
+	}
+	return ""
+}
+
+func (x *SpokeRegistered) GetVirtualAddr() string {
+	if x != nil {
+		return x.VirtualAddr
+	}
+	return ""
+}
+
+func (x *SpokeRegistered) GetHubId() string {
+	if x != nil {
+		return x.HubId
+	}
+	return ""
+}
+
+func (x *SpokeRegistered) GetReplicaId() string {
+	if x != nil {
+		return x.ReplicaId
+	}
+	return ""
+}
+
+// Hub → Spoke: outcome of a SpokeSend
+type SendResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the SpokeSend this result answers
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// "routed" or "error"
+	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	// Addresses the message was routed to
+	Destinations []string `protobuf:"bytes,3,rep,name=destinations,proto3" json:"destinations,omitempty"`
+	// Hops used by the routing decision
+	Hops int32 `protobuf:"varint,4,opt,name=hops,proto3" json:"hops,omitempty"`
+	// Routing error, if status is "error"
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendResult) Reset() {
+	*x = SendResult{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendResult) ProtoMessage() {}
+
+func (x *SendResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendResult.ProtoReflect.Descriptor instead.
+func (*SendResult) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SendResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SendResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SendResult) GetDestinations() []string {
+	if x != nil {
+		return x.Destinations
+	}
+	return nil
+}
+
+func (x *SendResult) GetHops() int32 {
+	if x != nil {
+		return x.Hops
+	}
+	return 0
+}
+
+func (x *SendResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Hub → Spoke: a message routed to this spoke
+type Delivery struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type    string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Source  string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Payload []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	Headers map[string]string      `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Timestamp the message entered the fabric (Unix milliseconds)
+	Timestamp     int64 `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Delivery) Reset() {
+	*x = Delivery{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Delivery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Delivery) ProtoMessage() {}
+
+func (x *Delivery) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Delivery.ProtoReflect.Descriptor instead.
+func (*Delivery) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Delivery) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Delivery) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Delivery) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Delivery) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Delivery) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Delivery) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// Envelope for everything the hub sends
+type HubFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*HubFrame_Registered
+	//	*HubFrame_SendResult
+	//	*HubFrame_Delivery
+	Frame         isHubFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HubFrame) Reset() {
+	*x = HubFrame{}
+	mi := &file_pb_spoke_transport_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HubFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HubFrame) ProtoMessage() {}
+
+func (x *HubFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_spoke_transport_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HubFrame.ProtoReflect.Descriptor instead.
+func (*HubFrame) Descriptor() ([]byte, []int) {
+	return file_pb_spoke_transport_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HubFrame) GetFrame() isHubFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *HubFrame) GetRegistered() *SpokeRegistered {
+	if x != nil {
+		if x, ok := x.Frame.(*HubFrame_Registered); ok {
+			return x.Registered
+		}
+	}
+	return nil
+}
+
+func (x *HubFrame) GetSendResult() *SendResult {
+	if x != nil {
+		if x, ok := x.Frame.(*HubFrame_SendResult); ok {
+			return x.SendResult
+		}
+	}
+	return nil
+}
+
+func (x *HubFrame) GetDelivery() *Delivery {
+	if x != nil {
+		if x, ok := x.Frame.(*HubFrame_Delivery); ok {
+			return x.Delivery
+		}
+	}
+	return nil
+}
+
+type isHubFrame_Frame interface {
+	isHubFrame_Frame()
+}
+
+type HubFrame_Registered struct {
+	Registered *SpokeRegistered `protobuf:"bytes,1,opt,name=registered,proto3,oneof"`
+}
+
+type HubFrame_SendResult struct {
+	SendResult *SendResult `protobuf:"bytes,2,opt,name=send_result,json=sendResult,proto3,oneof"`
+}
+
+type HubFrame_Delivery struct {
+	Delivery *Delivery `protobuf:"bytes,3,opt,name=delivery,proto3,oneof"`
+}
+
+func (*HubFrame_Registered) isHubFrame_Frame() {}
+
+func (*HubFrame_SendResult) isHubFrame_Frame() {}
+
+func (*HubFrame_Delivery) isHubFrame_Frame() {}
+
+var File_pb_spoke_transport_proto protoreflect.FileDescriptor
+
+const file_pb_spoke_transport_proto_rawDesc = "" +
+	"\n" +
+	"\x18pb/spoke_transport.proto\x12\x06fabric\"\xb0\x01\n" +
+	"\rSpokeRegister\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x19\n" +
+	"\bagent_id\x18\x02 \x01(\tR\aagentId\x12\"\n" +
+	"\fcapabilities\x18\x03 \x03(\tR\fcapabilities\x12\x1f\n" +
+	"\vtrust_score\x18\x04 \x01(\x01R\n" +
+	"trustScore\x12\"\n" +
+	"\fentitlements\x18\x05 \x03(\tR\fentitlements\"\xf3\x01\n" +
+	"\tSpokeSend\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12 \n" +
+	"\vdestination\x18\x03 \x01(\tR\vdestination\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x128\n" +
+	"\aheaders\x18\x05 \x03(\v2\x1e.fabric.SpokeSend.HeadersEntryR\aheaders\x12\x10\n" +
+	"\x03ttl\x18\x06 \x01(\x05R\x03ttl\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\")\n" +
+	"\bSpokeAck\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\"\x99\x01\n" +
+	"\n" +
+	"SpokeFrame\x123\n" +
+	"\bregister\x18\x01 \x01(\v2\x15.fabric.SpokeRegisterH\x00R\bregister\x12'\n" +
+	"\x04send\x18\x02 \x01(\v2\x11.fabric.SpokeSendH\x00R\x04send\x12$\n" +
+	"\x03ack\x18\x03 \x01(\v2\x10.fabric.SpokeAckH\x00R\x03ackB\a\n" +
+	"\x05frame\"\x85\x01\n" +
+	"\x0fSpokeRegistered\x12\x19\n" +
+	"\bspoke_id\x18\x01 \x01(\tR\aspokeId\x12!\n" +
+	"\fvirtual_addr\x18\x02 \x01(\tR\vvirtualAddr\x12\x15\n" +
+	"\x06hub_id\x18\x03 \x01(\tR\x05hubId\x12\x1d\n" +
+	"\n" +
+	"replica_id\x18\x04 \x01(\tR\treplicaId\"\x82\x01\n" +
+	"\n" +
+	"SendResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\"\n" +
+	"\fdestinations\x18\x03 \x03(\tR\fdestinations\x12\x12\n" +
+	"\x04hops\x18\x04 \x01(\x05R\x04hops\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"\xf3\x01\n" +
+	"\bDelivery\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x127\n" +
+	"\aheaders\x18\x05 \x03(\v2\x1d.fabric.Delivery.HeadersEntryR\aheaders\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\x03R\ttimestamp\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb5\x01\n" +
+	"\bHubFrame\x129\n" +
+	"\n" +
+	"registered\x18\x01 \x01(\v2\x17.fabric.SpokeRegisteredH\x00R\n" +
+	"registered\x125\n" +
+	"\vsend_result\x18\x02 \x01(\v2\x12.fabric.SendResultH\x00R\n" +
+	"sendResult\x12.\n" +
+	"\bdelivery\x18\x03 \x01(\v2\x10.fabric.DeliveryH\x00R\bdeliveryB\a\n" +
+	"\x05frame2L\n" +
+	"\x15SpokeTransportService\x123\n" +
+	"\aConnect\x12\x12.fabric.SpokeFrame\x1a\x10.fabric.HubFrame(\x010\x01B\x1bZ\x19github.com/ocx/backend/pbb\x06proto3"
+
+var (
+	file_pb_spoke_transport_proto_rawDescOnce sync.Once
+	file_pb_spoke_transport_proto_rawDescData []byte
+)
+
+func file_pb_spoke_transport_proto_rawDescGZIP() []byte {
+	file_pb_spoke_transport_proto_rawDescOnce.Do(func() {
+		file_pb_spoke_transport_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_spoke_transport_proto_rawDesc), len(file_pb_spoke_transport_proto_rawDesc)))
+	})
+	return file_pb_spoke_transport_proto_rawDescData
+}
+
+var file_pb_spoke_transport_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_pb_spoke_transport_proto_goTypes = []any{
+	(*SpokeRegister)(nil),   // 0: fabric.SpokeRegister
+	(*SpokeSend)(nil),       // 1: fabric.SpokeSend
+	(*SpokeAck)(nil),        // 2: fabric.SpokeAck
+	(*SpokeFrame)(nil),      // 3: fabric.SpokeFrame
+	(*SpokeRegistered)(nil), // 4: fabric.SpokeRegistered
+	(*SendResult)(nil),      // 5: fabric.SendResult
+	(*Delivery)(nil),        // 6: fabric.Delivery
+	(*HubFrame)(nil),        // 7: fabric.HubFrame
+	nil,                     // 8: fabric.SpokeSend.HeadersEntry
+	nil,                     // 9: fabric.Delivery.HeadersEntry
+}
+var file_pb_spoke_transport_proto_depIdxs = []int32{
+	8, // 0: fabric.SpokeSend.headers:type_name -> fabric.SpokeSend.HeadersEntry
+	0, // 1: fabric.SpokeFrame.register:type_name -> fabric.SpokeRegister
+	1, // 2: fabric.SpokeFrame.send:type_name -> fabric.SpokeSend
+	2, // 3: fabric.SpokeFrame.ack:type_name -> fabric.SpokeAck
+	9, // 4: fabric.Delivery.headers:type_name -> fabric.Delivery.HeadersEntry
+	4, // 5: fabric.HubFrame.registered:type_name -> fabric.SpokeRegistered
+	5, // 6: fabric.HubFrame.send_result:type_name -> fabric.SendResult
+	6, // 7: fabric.HubFrame.delivery:type_name -> fabric.Delivery
+	3, // 8: fabric.SpokeTransportService.Connect:input_type -> fabric.SpokeFrame
+	7, // 9: fabric.SpokeTransportService.Connect:output_type -> fabric.HubFrame
+	9, // [9:10] is the sub-list for method output_type
+	8, // [8:9] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_pb_spoke_transport_proto_init() }
+func file_pb_spoke_transport_proto_init() {
+	if File_pb_spoke_transport_proto != nil {
+		return
+	}
+	file_pb_spoke_transport_proto_msgTypes[3].OneofWrappers = []any{
+		(*SpokeFrame_Register)(nil),
+		(*SpokeFrame_Send)(nil),
+		(*SpokeFrame_Ack)(nil),
+	}
+	file_pb_spoke_transport_proto_msgTypes[7].OneofWrappers = []any{
+		(*HubFrame_Registered)(nil),
+		(*HubFrame_SendResult)(nil),
+		(*HubFrame_Delivery)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_spoke_transport_proto_rawDesc), len(file_pb_spoke_transport_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pb_spoke_transport_proto_goTypes,
+		DependencyIndexes: file_pb_spoke_transport_proto_depIdxs,
+		MessageInfos:      file_pb_spoke_transport_proto_msgTypes,
+	}.Build()
+	File_pb_spoke_transport_proto = out.File
+	file_pb_spoke_transport_proto_goTypes = nil
+	file_pb_spoke_transport_proto_depIdxs = nil
+}
@@ -0,0 +1,117 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             v5.29.3
+// source: pb/spoke_transport.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SpokeTransportService_Connect_FullMethodName = "/fabric.SpokeTransportService/Connect"
+)
+
+// SpokeTransportServiceClient is the client API for SpokeTransportService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SpokeTransportServiceClient interface {
+	// Register, send, receive and acknowledge over a single stream
+	Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SpokeFrame, HubFrame], error)
+}
+
+type spokeTransportServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSpokeTransportServiceClient(cc grpc.ClientConnInterface) SpokeTransportServiceClient {
+	return &spokeTransportServiceClient{cc}
+}
+
+func (c *spokeTransportServiceClient) Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SpokeFrame, HubFrame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SpokeTransportService_ServiceDesc.Streams[0], SpokeTransportService_Connect_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SpokeFrame, HubFrame]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SpokeTransportService_ConnectClient = grpc.BidiStreamingClient[SpokeFrame, HubFrame]
+
+// SpokeTransportServiceServer is the server API for SpokeTransportService service.
+// All implementations must embed UnimplementedSpokeTransportServiceServer
+// for forward compatibility.
+type SpokeTransportServiceServer interface {
+	// Register, send, receive and acknowledge over a single stream
+	Connect(grpc.BidiStreamingServer[SpokeFrame, HubFrame]) error
+	mustEmbedUnimplementedSpokeTransportServiceServer()
+}
+
+// UnimplementedSpokeTransportServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSpokeTransportServiceServer struct{}
+
+func (UnimplementedSpokeTransportServiceServer) Connect(grpc.BidiStreamingServer[SpokeFrame, HubFrame]) error {
+	return status.Error(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedSpokeTransportServiceServer) mustEmbedUnimplementedSpokeTransportServiceServer() {}
+func (UnimplementedSpokeTransportServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeSpokeTransportServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SpokeTransportServiceServer will
+// result in compilation errors.
+type UnsafeSpokeTransportServiceServer interface {
+	mustEmbedUnimplementedSpokeTransportServiceServer()
+}
+
+func RegisterSpokeTransportServiceServer(s grpc.ServiceRegistrar, srv SpokeTransportServiceServer) {
+	// If the following call panics, it indicates UnimplementedSpokeTransportServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SpokeTransportService_ServiceDesc, srv)
+}
+
+func _SpokeTransportService_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SpokeTransportServiceServer).Connect(&grpc.GenericServerStream[SpokeFrame, HubFrame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SpokeTransportService_ConnectServer = grpc.BidiStreamingServer[SpokeFrame, HubFrame]
+
+// SpokeTransportService_ServiceDesc is the grpc.ServiceDesc for SpokeTransportService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SpokeTransportService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fabric.SpokeTransportService",
+	HandlerType: (*SpokeTransportServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _SpokeTransportService_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pb/spoke_transport.proto",
+}
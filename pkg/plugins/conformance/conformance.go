@@ -0,0 +1,361 @@
+// Package conformance checks a ConnectorPlugin against the contract the
+// gateway relies on: metadata is set, hostile payloads neither panic nor
+// hang, accepted payloads parse into well-formed AIPayloads deterministically
+// and safely from many goroutines, and payloads owned by the built-in
+// protocol parsers are left alone (plugins run before them, so a greedy
+// CanHandle would hijack MCP or OpenAI traffic).
+//
+// Plugin authors run it from their own tests, ideally with -race:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, &MyParser{}, conformance.Suite{
+//			Accept: []conformance.Case{{
+//				Name:     "search",
+//				Payload:  []byte(`{"agent_action":{"tool":"search_records"}}`),
+//				ToolName: "search_records",
+//			}},
+//		})
+//	}
+//
+// Out-of-process plugins are checked the same way through the
+// *plugins.ProcessPlugin or *plugins.WASMPlugin the host registered.
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ocx/backend/internal/protocol"
+	"github.com/ocx/backend/pkg/plugins"
+)
+
+// Case is a payload the plugin must recognise. Zero-valued expectations
+// are not checked.
+type Case struct {
+	Name        string
+	Payload     []byte
+	ToolName    string
+	Protocol    protocol.AIProtocolType
+	MessageType string
+	Calls       int // invocations ParseAll must return (MultiCallConnector only)
+}
+
+// Suite configures a conformance run.
+type Suite struct {
+	// Accept lists payloads in the plugin's own format. At least one is
+	// required.
+	Accept []Case
+
+	// Reject lists further payloads the plugin must not claim, in addition
+	// to the built-in protocol samples.
+	Reject [][]byte
+
+	// ClaimsBuiltinProtocols skips the built-in sample check, for plugins
+	// that deliberately replace a built-in parser.
+	ClaimsBuiltinProtocols bool
+
+	// Timeout bounds every call into the plugin (default 2s).
+	Timeout time.Duration
+
+	// Concurrency is how many goroutines parse the accepted payloads at
+	// once (default 8).
+	Concurrency int
+}
+
+// Failure is one violated requirement.
+type Failure struct {
+	Check   string // metadata, hostile, accept, determinism, multicall, reject, concurrency
+	Case    string
+	Message string
+}
+
+func (f Failure) String() string {
+	if f.Case == "" {
+		return fmt.Sprintf("[%s] %s", f.Check, f.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", f.Check, f.Case, f.Message)
+}
+
+// builtinSamples are payloads the built-in parsers own.
+var builtinSamples = map[string][]byte{
+	"mcp tools/call":        []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"query_db","arguments":{"sql":"select 1"}}}`),
+	"openai tool_calls":     []byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`),
+	"anthropic tool_use":    []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"location":"Paris"}}],"stop_reason":"tool_use"}`),
+	"gemini functionCall":   []byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"find_theaters","args":{"location":"Mountain View"}}}]},"finishReason":"STOP"}]}`),
+	"a2a tasks/send":        []byte(`{"jsonrpc":"2.0","id":"r1","method":"tasks/send","params":{"id":"task-1","message":{"role":"user","parts":[{"type":"text","text":"hello"}]}}}`),
+	"rag vector query":      []byte(`{"vector":[0.1,0.2,0.3],"topK":3,"namespace":"kb","includeMetadata":true}`),
+	"plain http":            []byte("GET /healthz HTTP/1.1\r\nHost: localhost\r\n\r\n"),
+	"unrelated json object": []byte(`{"status":"ok","uptime":1234}`),
+}
+
+// hostilePayloads are malformed inputs every plugin must survive.
+func hostilePayloads(accept []Case) map[string][]byte {
+	hostile := map[string][]byte{
+		"nil":            nil,
+		"empty":          {},
+		"null":           []byte("null"),
+		"empty object":   []byte("{}"),
+		"empty array":    []byte("[]"),
+		"invalid utf8":   {0xff, 0xfe, '{', 0xc3, 0x28, '}'},
+		"binary":         bytes.Repeat([]byte{0x00, 0x9c, 0xff}, 512),
+		"deep nesting":   append(bytes.Repeat([]byte(`{"a":`), 10000), bytes.Repeat([]byte("}"), 10000)...),
+		"unclosed array": bytes.Repeat([]byte("["), 100000),
+		"huge string":    []byte(`{"x":"` + strings.Repeat("A", 1<<20) + `"}`),
+	}
+	for _, c := range accept {
+		if len(c.Payload) > 1 {
+			hostile["truncated "+c.Name] = c.Payload[:len(c.Payload)/2]
+			hostile["wrong types "+c.Name] = []byte(strings.NewReplacer(`"`, ``, `:`, `:[`).Replace(string(c.Payload)))
+		}
+	}
+	return hostile
+}
+
+// Run reports every failure of Check as a test error.
+func Run(t testing.TB, plugin plugins.ConnectorPlugin, suite Suite) {
+	t.Helper()
+	for _, f := range Check(plugin, suite) {
+		t.Error(f.String())
+	}
+}
+
+// Check runs the suite and returns every failure found.
+func Check(plugin plugins.ConnectorPlugin, suite Suite) []Failure {
+	if suite.Timeout <= 0 {
+		suite.Timeout = 2 * time.Second
+	}
+	if suite.Concurrency <= 0 {
+		suite.Concurrency = 8
+	}
+	r := &runner{plugin: plugin, suite: suite}
+
+	r.checkMetadata()
+	if len(suite.Accept) == 0 {
+		r.fail("accept", "", "suite has no Accept cases")
+	}
+	for name, payload := range hostilePayloads(suite.Accept) {
+		r.checkHostile(name, payload)
+	}
+	for _, c := range suite.Accept {
+		r.checkAccept(c)
+	}
+	if !suite.ClaimsBuiltinProtocols {
+		for name, payload := range builtinSamples {
+			r.checkReject(name, payload)
+		}
+	}
+	for i, payload := range suite.Reject {
+		r.checkReject(fmt.Sprintf("reject[%d]", i), payload)
+	}
+	r.checkConcurrency()
+	return r.failures
+}
+
+type runner struct {
+	plugin plugins.ConnectorPlugin
+	suite  Suite
+
+	mu       sync.Mutex
+	failures []Failure
+}
+
+func (r *runner) fail(check, name, format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, Failure{Check: check, Case: name, Message: fmt.Sprintf(format, args...)})
+}
+
+// call runs fn with panic recovery and the suite timeout. A call that
+// times out is left running; the plugin is assumed to be stuck.
+func (r *runner) call(check, name, op string, fn func()) bool {
+	done := make(chan interface{}, 1)
+	go func() {
+		defer func() { done <- recover() }()
+		fn()
+	}()
+	select {
+	case p := <-done:
+		if p != nil {
+			r.fail(check, name, "%s panicked: %v", op, p)
+			return false
+		}
+		return true
+	case <-time.After(r.suite.Timeout):
+		r.fail(check, name, "%s did not return within %s", op, r.suite.Timeout)
+		return false
+	}
+}
+
+func (r *runner) checkMetadata() {
+	r.call("metadata", "", "metadata", func() {
+		name := r.plugin.Name()
+		if name == "" || strings.TrimSpace(name) != name || strings.ContainsAny(name, " \t\n/") {
+			r.fail("metadata", "", "Name %q must be non-empty without whitespace or slashes", name)
+		}
+		if r.plugin.Version() == "" {
+			r.fail("metadata", "", "Version is empty")
+		}
+		if p := r.plugin.Priority(); p < 0 {
+			r.fail("metadata", "", "Priority %d is negative", p)
+		}
+	})
+}
+
+// checkHostile requires malformed input to be rejected or parsed into a
+// well-formed payload, never to panic or hang.
+func (r *runner) checkHostile(name string, payload []byte) {
+	var result *protocol.AIPayload
+	var err error
+	if !r.call("hostile", name, "CanHandle", func() { r.plugin.CanHandle(payload) }) {
+		return
+	}
+	if !r.call("hostile", name, "Parse", func() { result, err = r.plugin.Parse(payload) }) {
+		return
+	}
+	if err == nil {
+		r.validate("hostile", name, result)
+	}
+}
+
+func (r *runner) checkAccept(c Case) {
+	var handled bool
+	if !r.call("accept", c.Name, "CanHandle", func() { handled = r.plugin.CanHandle(c.Payload) }) {
+		return
+	}
+	if !handled {
+		r.fail("accept", c.Name, "CanHandle returned false")
+	}
+
+	var first, second *protocol.AIPayload
+	var err error
+	if !r.call("accept", c.Name, "Parse", func() { first, err = r.plugin.Parse(c.Payload) }) {
+		return
+	}
+	if err != nil {
+		r.fail("accept", c.Name, "Parse: %v", err)
+		return
+	}
+	if !r.validate("accept", c.Name, first) {
+		return
+	}
+	if c.ToolName != "" && first.ToolName != c.ToolName {
+		r.fail("accept", c.Name, "ToolName = %q, want %q", first.ToolName, c.ToolName)
+	}
+	if c.Protocol != "" && first.Protocol != c.Protocol {
+		r.fail("accept", c.Name, "Protocol = %s, want %s", first.Protocol, c.Protocol)
+	}
+	if c.MessageType != "" && first.MessageType != c.MessageType {
+		r.fail("accept", c.Name, "MessageType = %q, want %q", first.MessageType, c.MessageType)
+	}
+
+	if r.call("determinism", c.Name, "Parse", func() { second, err = r.plugin.Parse(c.Payload) }) {
+		if err != nil || !samePayload(first, second) {
+			r.fail("determinism", c.Name, "second Parse differs: %+v, %v", second, err)
+		}
+	}
+
+	mc, ok := r.plugin.(plugins.MultiCallConnector)
+	if !ok {
+		if c.Calls > 1 {
+			r.fail("multicall", c.Name, "expects %d calls but the plugin does not implement ParseAll", c.Calls)
+		}
+		return
+	}
+	var all []*protocol.AIPayload
+	if !r.call("multicall", c.Name, "ParseAll", func() { all, err = mc.ParseAll(c.Payload) }) {
+		return
+	}
+	switch {
+	case err != nil:
+		r.fail("multicall", c.Name, "ParseAll: %v", err)
+	case len(all) == 0:
+		r.fail("multicall", c.Name, "ParseAll returned no payloads")
+	case c.Calls > 0 && len(all) != c.Calls:
+		r.fail("multicall", c.Name, "ParseAll returned %d calls, want %d", len(all), c.Calls)
+	default:
+		for i, p := range all {
+			r.validate("multicall", fmt.Sprintf("%s#%d", c.Name, i), p)
+		}
+		if all[0] != nil && !samePayload(first, all[0]) {
+			r.fail("multicall", c.Name, "ParseAll()[0] differs from Parse")
+		}
+	}
+}
+
+func (r *runner) checkReject(name string, payload []byte) {
+	var handled bool
+	if r.call("reject", name, "CanHandle", func() { handled = r.plugin.CanHandle(payload) }) && handled {
+		r.fail("reject", name, "CanHandle claims a payload the plugin does not own")
+	}
+}
+
+// checkConcurrency parses every accepted payload from several goroutines
+// at once; combined with -race this finds shared mutable parser state.
+func (r *runner) checkConcurrency() {
+	if len(r.suite.Accept) == 0 {
+		return
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < r.suite.Concurrency; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, c := range r.suite.Accept {
+				r.call("concurrency", c.Name, "Parse", func() {
+					if r.plugin.CanHandle(c.Payload) {
+						if _, err := r.plugin.Parse(c.Payload); err != nil {
+							r.fail("concurrency", c.Name, "concurrent Parse: %v", err)
+						}
+					}
+				})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// validate checks the fields every consumer of an AIPayload relies on.
+func (r *runner) validate(check, name string, p *protocol.AIPayload) bool {
+	var problems []string
+	switch {
+	case p == nil:
+		problems = append(problems, "nil payload without error")
+	default:
+		if p.Protocol == "" {
+			problems = append(problems, "Protocol is empty")
+		}
+		if p.ToolName == "" {
+			problems = append(problems, "ToolName is empty")
+		}
+		if p.MessageType == "" {
+			problems = append(problems, "MessageType is empty")
+		}
+		if p.Direction != "request" && p.Direction != "response" {
+			problems = append(problems, fmt.Sprintf("Direction %q is not request or response", p.Direction))
+		}
+		if math.IsNaN(p.Confidence) || p.Confidence < 0 || p.Confidence > 1 {
+			problems = append(problems, fmt.Sprintf("Confidence %v outside [0,1]", p.Confidence))
+		}
+	}
+	if len(problems) > 0 {
+		r.fail(check, name, "malformed payload: %s", strings.Join(problems, "; "))
+		return false
+	}
+	return true
+}
+
+// samePayload compares two parses, ignoring when they happened.
+func samePayload(a, b *protocol.AIPayload) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ac, bc := *a, *b
+	ac.DetectedAt, bc.DetectedAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(ac, bc)
+}
@@ -0,0 +1,188 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/ocx/backend/internal/protocol"
+	pb "github.com/ocx/backend/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ============================================================================
+// GRPC PLUGIN PROTOCOL - ConnectorPlugin across a process boundary
+// ============================================================================
+
+// Handshake is shared by the host and every connector plugin executable. A
+// binary started without the magic cookie (e.g. run by hand) exits with a
+// message instead of serving.
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "OCX_CONNECTOR_PLUGIN",
+	MagicCookieValue: "9f1c2b7e-connector",
+}
+
+// connectorPluginKey is the name the connector is dispensed under.
+const connectorPluginKey = "connector"
+
+// Serve runs impl as an out-of-process connector plugin. Call it from the
+// plugin executable's main; it blocks until the host disconnects.
+//
+//	func main() { plugins.Serve(&MyCustomParser{}) }
+func Serve(impl ConnectorPlugin) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         goplugin.PluginSet{connectorPluginKey: &grpcConnectorPlugin{impl: impl}},
+		GRPCServer:      goplugin.DefaultGRPCServer,
+	})
+}
+
+// grpcConnectorPlugin binds ConnectorPlugin to the go-plugin gRPC transport.
+// On the plugin side impl is served; on the host side GRPCClient returns the
+// raw service client.
+type grpcConnectorPlugin struct {
+	goplugin.NetRPCUnsupportedPlugin
+	impl ConnectorPlugin
+}
+
+func (p *grpcConnectorPlugin) GRPCServer(_ *goplugin.GRPCBroker, s *grpc.Server) error {
+	pb.RegisterConnectorPluginServiceServer(s, &connectorServer{impl: p.impl})
+	return nil
+}
+
+func (p *grpcConnectorPlugin) GRPCClient(_ context.Context, _ *goplugin.GRPCBroker, cc *grpc.ClientConn) (interface{}, error) {
+	return pb.NewConnectorPluginServiceClient(cc), nil
+}
+
+// connectorServer runs inside the plugin process. A panic in the connector
+// is returned to the host as a parse error instead of killing the plugin.
+type connectorServer struct {
+	pb.UnimplementedConnectorPluginServiceServer
+	impl ConnectorPlugin
+}
+
+func (s *connectorServer) Describe(context.Context, *pb.DescribeRequest) (*pb.DescribeResponse, error) {
+	return &pb.DescribeResponse{
+		Name:      s.impl.Name(),
+		Version:   s.impl.Version(),
+		Protocols: s.impl.Protocols(),
+		Priority:  int32(s.impl.Priority()),
+	}, nil
+}
+
+func (s *connectorServer) CanHandle(_ context.Context, req *pb.PayloadRequest) (resp *pb.CanHandleResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp = &pb.CanHandleResponse{}
+		}
+	}()
+	return &pb.CanHandleResponse{CanHandle: s.impl.CanHandle(req.Payload)}, nil
+}
+
+func (s *connectorServer) Parse(_ context.Context, req *pb.PayloadRequest) (resp *pb.ParseResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp = &pb.ParseResponse{Error: fmt.Sprintf("plugin panic: %v", r)}
+		}
+	}()
+
+	var results []*protocol.AIPayload
+	var parseErr error
+	if mc, ok := s.impl.(MultiCallConnector); ok {
+		results, parseErr = mc.ParseAll(req.Payload)
+	} else {
+		var result *protocol.AIPayload
+		if result, parseErr = s.impl.Parse(req.Payload); result != nil {
+			results = []*protocol.AIPayload{result}
+		}
+	}
+	if parseErr != nil {
+		return &pb.ParseResponse{Error: parseErr.Error()}, nil
+	}
+
+	resp = &pb.ParseResponse{}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		msg, err := toParsedPayload(r)
+		if err != nil {
+			return &pb.ParseResponse{Error: err.Error()}, nil
+		}
+		resp.Payloads = append(resp.Payloads, msg)
+	}
+	return resp, nil
+}
+
+func toParsedPayload(p *protocol.AIPayload) (*pb.ParsedPayload, error) {
+	args, err := toStruct(p.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("arguments: %w", err)
+	}
+	meta, err := toStruct(p.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	return &pb.ParsedPayload{
+		Protocol:    string(p.Protocol),
+		ToolName:    p.ToolName,
+		AgentId:     p.AgentID,
+		TenantId:    p.TenantID,
+		TaskId:      p.TaskID,
+		Arguments:   args,
+		Model:       p.Model,
+		MessageType: p.MessageType,
+		Direction:   p.Direction,
+		Confidence:  p.Confidence,
+		CallId:      p.CallID,
+		RawMethod:   p.RawMethod,
+		Metadata:    meta,
+	}, nil
+}
+
+func fromParsedPayload(m *pb.ParsedPayload) *protocol.AIPayload {
+	return &protocol.AIPayload{
+		Protocol:    protocol.AIProtocolType(m.Protocol),
+		ToolName:    m.ToolName,
+		AgentID:     m.AgentId,
+		TenantID:    m.TenantId,
+		TaskID:      m.TaskId,
+		Arguments:   fromStruct(m.Arguments),
+		Model:       m.Model,
+		MessageType: m.MessageType,
+		Direction:   m.Direction,
+		Confidence:  m.Confidence,
+		CallID:      m.CallId,
+		RawMethod:   m.RawMethod,
+		Metadata:    fromStruct(m.Metadata),
+		DetectedAt:  time.Now(),
+	}
+}
+
+// toStruct converts through JSON so typed slices and maps a connector puts
+// in Arguments or Metadata survive the trip.
+func toStruct(m map[string]interface{}) (*structpb.Struct, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var generic map[string]interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(generic)
+}
+
+func fromStruct(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
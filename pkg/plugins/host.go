@@ -0,0 +1,365 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/ocx/backend/internal/marketplace"
+	"github.com/ocx/backend/internal/protocol"
+	pb "github.com/ocx/backend/pb"
+)
+
+// ============================================================================
+// PLUGIN HOST - launches, verifies and supervises connector executables
+// ============================================================================
+
+// ManifestSuffix marks plugin manifests in the plugin directory.
+const ManifestSuffix = ".plugin.json"
+
+// PluginManifest sits next to a plugin executable and names its publisher
+// and signature. Signature is the hex ASN.1 ECDSA P-256 signature over the
+// SHA-256 of the executable.
+type PluginManifest struct {
+	Name        string   `json:"name"`
+	Executable  string   `json:"executable"` // relative to the manifest's directory
+	Args        []string `json:"args,omitempty"`
+	PublisherID string   `json:"publisher_id"`
+	Signature   string   `json:"signature"`
+}
+
+// HostConfig controls how plugin processes are run.
+type HostConfig struct {
+	Dir           string        // directory scanned for *.plugin.json
+	CallTimeout   time.Duration // per CanHandle/Parse call
+	StartTimeout  time.Duration // process launch and handshake
+	MaxRestarts   int           // restarts after crashes or hangs before the plugin is disabled
+	AllowUnsigned bool          // development only
+}
+
+// Host launches connector plugin executables and registers them in a
+// Registry. Each plugin runs in its own process, so a crash or hang only
+// costs that plugin's calls.
+type Host struct {
+	cfg      HostConfig
+	verifier *marketplace.SignatureVerifier
+	registry *Registry
+
+	mu     sync.Mutex
+	loaded map[string]*ProcessPlugin
+}
+
+// NewHost creates a plugin host. Plugins are rejected unless verifier
+// validates their signature or cfg.AllowUnsigned is set.
+func NewHost(cfg HostConfig, verifier *marketplace.SignatureVerifier, registry *Registry) *Host {
+	if cfg.CallTimeout <= 0 {
+		cfg.CallTimeout = 2 * time.Second
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = 10 * time.Second
+	}
+	return &Host{
+		cfg:      cfg,
+		verifier: verifier,
+		registry: registry,
+		loaded:   make(map[string]*ProcessPlugin),
+	}
+}
+
+// LoadDir loads every manifest in the plugin directory. A plugin that fails
+// verification or does not start is skipped; the others still load.
+func (h *Host) LoadDir() (int, error) {
+	manifests, err := filepath.Glob(filepath.Join(h.cfg.Dir, "*"+ManifestSuffix))
+	if err != nil {
+		return 0, fmt.Errorf("scan plugin dir: %w", err)
+	}
+
+	loaded := 0
+	var errs []error
+	for _, path := range manifests {
+		if _, err := h.Load(path); err != nil {
+			slog.Warn("[PluginHost] plugin not loaded", "manifest", path, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		loaded++
+	}
+	return loaded, errors.Join(errs...)
+}
+
+// Load verifies the executable named by a manifest, starts it and registers
+// the resulting connector.
+func (h *Host) Load(manifestPath string) (*ProcessPlugin, error) {
+	raw, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m PluginManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", manifestPath, err)
+	}
+	if m.Name == "" || m.Executable == "" {
+		return nil, fmt.Errorf("manifest %s: name and executable are required", manifestPath)
+	}
+
+	exe := m.Executable
+	if !filepath.IsAbs(exe) {
+		exe = filepath.Join(filepath.Dir(manifestPath), exe)
+	}
+	binary, err := os.ReadFile(exe)
+	if err != nil {
+		return nil, fmt.Errorf("read plugin %s: %w", m.Name, err)
+	}
+	checksum := sha256.Sum256(binary)
+
+	verified := false
+	switch {
+	case h.verifier != nil && m.Signature != "":
+		res := h.verifier.ValidatePlugin(m.Name, m.PublisherID, binary, m.Signature)
+		if !res.Valid {
+			return nil, fmt.Errorf("plugin %s rejected: %s", m.Name, res.Reason)
+		}
+		verified = true
+	case h.cfg.AllowUnsigned:
+		slog.Warn("[PluginHost] loading unsigned plugin", "plugin", m.Name, "sha256", hex.EncodeToString(checksum[:]))
+	default:
+		return nil, fmt.Errorf("plugin %s rejected: no signature provided", m.Name)
+	}
+
+	p := &ProcessPlugin{
+		manifest: m,
+		path:     exe,
+		checksum: checksum[:],
+		verified: verified,
+		cfg:      h.cfg,
+	}
+	if err := p.start(); err != nil {
+		return nil, fmt.Errorf("start plugin %s: %w", m.Name, err)
+	}
+	if p.info.Name != m.Name {
+		p.Kill()
+		return nil, fmt.Errorf("plugin %s describes itself as %q", m.Name, p.info.Name)
+	}
+
+	if err := h.registry.Register(p); err != nil {
+		p.Kill()
+		return nil, err
+	}
+	h.mu.Lock()
+	h.loaded[m.Name] = p
+	h.mu.Unlock()
+
+	slog.Info("[PluginHost] plugin loaded", "plugin", m.Name, "version", p.info.Version,
+		"publisher", m.PublisherID, "verified", verified)
+	return p, nil
+}
+
+// Shutdown unregisters and kills every plugin process.
+func (h *Host) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, p := range h.loaded {
+		h.registry.Unregister(name)
+		p.Kill()
+	}
+	h.loaded = make(map[string]*ProcessPlugin)
+}
+
+// ProcessPlugin adapts a plugin executable to ConnectorPlugin. Calls are
+// bounded by CallTimeout; a plugin that crashes or hangs is killed and
+// restarted on the next call, up to MaxRestarts, and then disabled.
+type ProcessPlugin struct {
+	manifest PluginManifest
+	path     string
+	checksum []byte
+	verified bool
+	cfg      HostConfig
+	info     *pb.DescribeResponse // fixed by the first start
+
+	mu       sync.Mutex
+	client   *goplugin.Client
+	rpc      pb.ConnectorPluginServiceClient
+	restarts int
+	disabled bool
+	lastErr  string
+}
+
+// start launches the process, pinning the executable to the verified
+// checksum, and fetches its description.
+func (p *ProcessPlugin) start() error {
+	cmd := exec.Command(p.path, p.manifest.Args...)
+	client := goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          goplugin.PluginSet{connectorPluginKey: &grpcConnectorPlugin{}},
+		Cmd:              cmd,
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+		SecureConfig:     &goplugin.SecureConfig{Checksum: p.checksum, Hash: sha256.New()},
+		StartTimeout:     p.cfg.StartTimeout,
+		Logger:           hclog.New(&hclog.LoggerOptions{Name: "plugin." + p.manifest.Name, Level: hclog.Warn}),
+	})
+
+	proto, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return err
+	}
+	raw, err := proto.Dispense(connectorPluginKey)
+	if err != nil {
+		client.Kill()
+		return err
+	}
+	rpc := raw.(pb.ConnectorPluginServiceClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.CallTimeout)
+	defer cancel()
+	info, err := rpc.Describe(ctx, &pb.DescribeRequest{})
+	if err != nil {
+		client.Kill()
+		return fmt.Errorf("describe: %w", err)
+	}
+
+	p.client, p.rpc = client, rpc
+	if p.info == nil {
+		p.info = info
+	}
+	return nil
+}
+
+// conn returns a live client, restarting a crashed process.
+func (p *ProcessPlugin) conn() (pb.ConnectorPluginServiceClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.disabled {
+		return nil, fmt.Errorf("plugin %s disabled: %s", p.manifest.Name, p.lastErr)
+	}
+	if p.client != nil {
+		if !p.client.Exited() {
+			return p.rpc, nil
+		}
+		p.lastErr = "plugin process exited"
+	}
+
+	if p.restarts >= p.cfg.MaxRestarts {
+		p.disabled = true
+		slog.Error("[PluginHost] plugin disabled after repeated failures",
+			"plugin", p.manifest.Name, "restarts", p.restarts, "last_error", p.lastErr)
+		return nil, fmt.Errorf("plugin %s disabled: %s", p.manifest.Name, p.lastErr)
+	}
+	p.restarts++
+	slog.Warn("[PluginHost] restarting plugin", "plugin", p.manifest.Name, "attempt", p.restarts)
+	if err := p.start(); err != nil {
+		p.lastErr = err.Error()
+		p.client = nil
+		return nil, err
+	}
+	return p.rpc, nil
+}
+
+// fail records a transport error and kills the process so the next call
+// starts a fresh one; a hung plugin would otherwise hold every call to its
+// timeout.
+func (p *ProcessPlugin) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = err.Error()
+	if p.client != nil {
+		p.client.Kill()
+		p.client = nil
+	}
+	slog.Warn("[PluginHost] plugin call failed", "plugin", p.manifest.Name, "error", err)
+}
+
+// Kill stops the plugin process.
+func (p *ProcessPlugin) Kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		p.client.Kill()
+		p.client = nil
+	}
+}
+
+func (p *ProcessPlugin) Name() string        { return p.info.Name }
+func (p *ProcessPlugin) Version() string     { return p.info.Version }
+func (p *ProcessPlugin) Protocols() []string { return p.info.Protocols }
+func (p *ProcessPlugin) Priority() int       { return int(p.info.Priority) }
+
+// CanHandle asks the plugin process; any failure counts as "no".
+func (p *ProcessPlugin) CanHandle(payload []byte) bool {
+	rpc, err := p.conn()
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.CallTimeout)
+	defer cancel()
+
+	resp, err := rpc.CanHandle(ctx, &pb.PayloadRequest{Payload: payload})
+	if err != nil {
+		p.fail(err)
+		return false
+	}
+	return resp.CanHandle
+}
+
+// Parse returns the primary invocation in the payload.
+func (p *ProcessPlugin) Parse(payload []byte) (*protocol.AIPayload, error) {
+	results, err := p.ParseAll(payload)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// ParseAll returns every invocation the plugin extracts from payload.
+func (p *ProcessPlugin) ParseAll(payload []byte) ([]*protocol.AIPayload, error) {
+	rpc, err := p.conn()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.CallTimeout)
+	defer cancel()
+
+	resp, err := rpc.Parse(ctx, &pb.PayloadRequest{Payload: payload})
+	if err != nil {
+		p.fail(err)
+		return nil, fmt.Errorf("plugin %s: %w", p.manifest.Name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", p.manifest.Name, resp.Error)
+	}
+	if len(resp.Payloads) == 0 {
+		return nil, fmt.Errorf("plugin %s returned no payloads", p.manifest.Name)
+	}
+
+	results := make([]*protocol.AIPayload, len(resp.Payloads))
+	for i, m := range resp.Payloads {
+		results[i] = fromParsedPayload(m)
+	}
+	return results, nil
+}
+
+// describeRuntime fills the process details shown by /api/v1/plugins.
+func (p *ProcessPlugin) describeRuntime(info *PluginInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info.Runtime = "grpc"
+	info.Publisher = p.manifest.PublisherID
+	info.Verified = p.verified
+	info.Checksum = hex.EncodeToString(p.checksum)
+	info.Restarts = p.restarts
+	info.Active = !p.disabled
+	info.LastError = strings.TrimSpace(p.lastErr)
+}
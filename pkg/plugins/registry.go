@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sort"
@@ -42,6 +43,33 @@ type ConnectorPlugin interface {
 	Parse(payload []byte) (*protocol.AIPayload, error)
 }
 
+// MultiCallConnector is optionally implemented by plugins whose protocol can
+// carry several tool invocations in one payload. Plugins that only implement
+// Parse are treated as yielding a single invocation.
+type MultiCallConnector interface {
+	ParseAll(payload []byte) ([]*protocol.AIPayload, error)
+}
+
+// Policy verdicts, in increasing strictness.
+const (
+	PolicyAllow    = "ALLOW"
+	PolicyEscalate = "ESCALATE"
+	PolicyBlock    = "BLOCK"
+)
+
+// PolicyDecision is a policy plugin's verdict on one tool call.
+type PolicyDecision struct {
+	Plugin  string `json:"plugin"`
+	Verdict string `json:"verdict"` // ALLOW, ESCALATE, BLOCK
+	Reason  string `json:"reason,omitempty"`
+}
+
+// PolicyPlugin is optionally implemented by plugins that evaluate tool
+// calls before classification, e.g. tenant logic shipped as WASM.
+type PolicyPlugin interface {
+	EvaluatePolicy(ctx context.Context, call *protocol.AIPayload) (PolicyDecision, error)
+}
+
 // PluginInfo describes a registered plugin (for API responses)
 type PluginInfo struct {
 	Name      string   `json:"name"`
@@ -49,104 +77,249 @@ type PluginInfo struct {
 	Protocols []string `json:"protocols"`
 	Priority  int      `json:"priority"`
 	Active    bool     `json:"active"`
+
+	// Set for plugins running outside the gateway process
+	Runtime   string `json:"runtime,omitempty"` // "grpc" or "wasm"
+	Publisher string `json:"publisher,omitempty"`
+	Verified  bool   `json:"verified,omitempty"`
+	Checksum  string `json:"checksum,omitempty"`
+	Restarts  int    `json:"restarts,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+	Policy    bool   `json:"policy,omitempty"` // evaluates tool calls
+	Reloads   int    `json:"reloads,omitempty"`
+
+	// Tenant owning the plugin; empty for platform plugins that run for all
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// runtimeReporter is implemented by plugins that run out of process, to
+// report their runtime state in List.
+type runtimeReporter interface {
+	describeRuntime(info *PluginInfo)
+}
+
+// scopedPlugin is a registered plugin and the tenant it belongs to ("" for
+// platform plugins).
+type scopedPlugin struct {
+	ConnectorPlugin
+	tenant string
 }
 
-// Registry manages connector plugins
+// Registry manages connector plugins. Platform plugins run for every
+// tenant; tenant plugins (e.g. a tenant's WASM policy modules) only ever see
+// that tenant's payloads and tool calls.
 type Registry struct {
 	mu      sync.RWMutex
-	plugins []ConnectorPlugin
-	byName  map[string]ConnectorPlugin
+	plugins []scopedPlugin
+	byName  map[string]scopedPlugin // keyed by scopedName
 	logger  *log.Logger
 }
 
 // NewRegistry creates a plugin registry
 func NewRegistry() *Registry {
 	return &Registry{
-		plugins: make([]ConnectorPlugin, 0),
-		byName:  make(map[string]ConnectorPlugin),
+		plugins: make([]scopedPlugin, 0),
+		byName:  make(map[string]scopedPlugin),
 		logger:  log.New(log.Writer(), "[PLUGINS] ", log.LstdFlags),
 	}
 }
 
-// Register adds a plugin to the registry
+// scopedName keys a plugin by tenant so tenants may reuse plugin names.
+func scopedName(tenantID, name string) string {
+	if tenantID == "" {
+		return name
+	}
+	return tenantID + "/" + name
+}
+
+// appliesTo reports whether the plugin may run for tenantID.
+func (p scopedPlugin) appliesTo(tenantID string) bool {
+	return p.tenant == "" || p.tenant == tenantID
+}
+
+// Register adds a platform plugin, run for every tenant
 func (r *Registry) Register(plugin ConnectorPlugin) error {
+	return r.RegisterForTenant("", plugin)
+}
+
+// RegisterForTenant adds a plugin that only runs for tenantID. An empty
+// tenantID registers a platform plugin.
+func (r *Registry) RegisterForTenant(tenantID string, plugin ConnectorPlugin) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.byName[plugin.Name()]; exists {
-		return fmt.Errorf("plugin %q already registered", plugin.Name())
+	key := scopedName(tenantID, plugin.Name())
+	if _, exists := r.byName[key]; exists {
+		return fmt.Errorf("plugin %q already registered", key)
 	}
 
-	r.plugins = append(r.plugins, plugin)
-	r.byName[plugin.Name()] = plugin
+	entry := scopedPlugin{ConnectorPlugin: plugin, tenant: tenantID}
+	r.plugins = append(r.plugins, entry)
+	r.byName[key] = entry
 
 	// Re-sort by priority (lower = first)
-	sort.Slice(r.plugins, func(i, j int) bool {
+	sort.SliceStable(r.plugins, func(i, j int) bool {
 		return r.plugins[i].Priority() < r.plugins[j].Priority()
 	})
 
 	r.logger.Printf("🔌 Registered plugin: %s v%s (protocols=%v, priority=%d)",
-		plugin.Name(), plugin.Version(), plugin.Protocols(), plugin.Priority())
+		key, plugin.Version(), plugin.Protocols(), plugin.Priority())
 	return nil
 }
 
-// Unregister removes a plugin
+// Unregister removes a platform plugin
 func (r *Registry) Unregister(name string) {
+	r.UnregisterForTenant("", name)
+}
+
+// UnregisterForTenant removes one of tenantID's plugins
+func (r *Registry) UnregisterForTenant(tenantID, name string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	delete(r.byName, name)
-	filtered := make([]ConnectorPlugin, 0)
+	delete(r.byName, scopedName(tenantID, name))
+	filtered := make([]scopedPlugin, 0)
 	for _, p := range r.plugins {
-		if p.Name() != name {
+		if p.tenant != tenantID || p.Name() != name {
 			filtered = append(filtered, p)
 		}
 	}
 	r.plugins = filtered
 }
 
-// Parse tries all registered plugins in priority order
-func (r *Registry) Parse(payload []byte) (*protocol.AIPayload, error) {
+// Parse tries the plugins that apply to tenantID in priority order and
+// returns the primary invocation. Use ParseAll to govern every call in the
+// payload.
+func (r *Registry) Parse(tenantID string, payload []byte) (*protocol.AIPayload, error) {
+	results, err := r.ParseAll(tenantID, payload)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// ParseAll tries the platform plugins and tenantID's own plugins in
+// priority order and returns every invocation the first successful plugin
+// finds, each with a CallID.
+func (r *Registry) ParseAll(tenantID string, payload []byte) ([]*protocol.AIPayload, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, plugin := range r.plugins {
-		if plugin.CanHandle(payload) {
-			result, err := plugin.Parse(payload)
-			if err == nil && result != nil {
-				r.logger.Printf("✅ Plugin %s parsed payload (protocol=%s, tool=%s)",
-					plugin.Name(), result.Protocol, result.ToolName)
-				return result, nil
+		if !plugin.appliesTo(tenantID) || !plugin.CanHandle(payload) {
+			continue
+		}
+		var results []*protocol.AIPayload
+		if mc, ok := plugin.ConnectorPlugin.(MultiCallConnector); ok {
+			all, err := mc.ParseAll(payload)
+			if err == nil {
+				for _, result := range all {
+					if result != nil { // third-party code: don't trust every slot
+						results = append(results, result)
+					}
+				}
 			}
+		} else if result, err := plugin.Parse(payload); err == nil && result != nil {
+			results = []*protocol.AIPayload{result}
+		}
+		if len(results) == 0 {
+			continue
 		}
+
+		protocol.AssignCallIDs(results)
+		r.logger.Printf("✅ Plugin %s parsed payload (protocol=%s, tool=%s, calls=%d)",
+			plugin.Name(), results[0].Protocol, results[0].ToolName, len(results))
+		return results, nil
 	}
 	return nil, fmt.Errorf("no plugin could parse the payload")
 }
 
+// EvaluatePolicies runs the platform policy plugins and tenantID's own on
+// call and returns the strictest non-ALLOW decision, or nil when all allow.
+// A plugin that fails escalates rather than silently allowing.
+func (r *Registry) EvaluatePolicies(ctx context.Context, tenantID string, call *protocol.AIPayload) *PolicyDecision {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var worst *PolicyDecision
+	for _, plugin := range r.plugins {
+		pp, ok := plugin.ConnectorPlugin.(PolicyPlugin)
+		if !ok || !plugin.appliesTo(tenantID) {
+			continue
+		}
+		d, err := pp.EvaluatePolicy(ctx, call)
+		if err != nil {
+			d = PolicyDecision{Verdict: PolicyEscalate, Reason: "policy evaluation failed: " + err.Error()}
+		}
+		d.Plugin = plugin.Name()
+		if policyRank(d.Verdict) > 0 && (worst == nil || policyRank(d.Verdict) > policyRank(worst.Verdict)) {
+			worst = &d
+		}
+	}
+	if worst != nil {
+		r.logger.Printf("⚖️ Policy plugin %s: %s %s (%s)", worst.Plugin, worst.Verdict, call.ToolName, worst.Reason)
+	}
+	return worst
+}
+
+func policyRank(verdict string) int {
+	switch verdict {
+	case PolicyBlock:
+		return 2
+	case PolicyEscalate:
+		return 1
+	case PolicyAllow:
+		return 0
+	}
+	return 1 // unknown verdicts escalate
+}
+
 // List returns info about all registered plugins
 func (r *Registry) List() []PluginInfo {
+	return r.list(func(scopedPlugin) bool { return true })
+}
+
+// ListForTenant returns info about the plugins that run for tenantID
+func (r *Registry) ListForTenant(tenantID string) []PluginInfo {
+	return r.list(func(p scopedPlugin) bool { return p.appliesTo(tenantID) })
+}
+
+func (r *Registry) list(include func(scopedPlugin) bool) []PluginInfo {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	infos := make([]PluginInfo, 0, len(r.plugins))
 	for _, p := range r.plugins {
-		infos = append(infos, PluginInfo{
+		if !include(p) {
+			continue
+		}
+		info := PluginInfo{
 			Name:      p.Name(),
 			Version:   p.Version(),
 			Protocols: p.Protocols(),
 			Priority:  p.Priority(),
 			Active:    true,
-		})
+			Tenant:    p.tenant,
+		}
+		if rr, ok := p.ConnectorPlugin.(runtimeReporter); ok {
+			rr.describeRuntime(&info)
+		}
+		infos = append(infos, info)
 	}
 	return infos
 }
 
-// Get returns a specific plugin by name
+// Get returns a platform plugin by name
 func (r *Registry) Get(name string) (ConnectorPlugin, bool) {
+	return r.GetForTenant("", name)
+}
+
+// GetForTenant returns one of tenantID's plugins by name
+func (r *Registry) GetForTenant(tenantID, name string) (ConnectorPlugin, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	p, ok := r.byName[name]
-	return p, ok
+	p, ok := r.byName[scopedName(tenantID, name)]
+	return p.ConnectorPlugin, ok
 }
 
 // Count returns the number of registered plugins
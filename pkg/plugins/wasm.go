@@ -0,0 +1,592 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ocx/backend/internal/protocol"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// ============================================================================
+// WASM PLUGINS - sandboxed parser and policy modules
+// ============================================================================
+//
+// Guest ABI (implemented by pkg/plugins/wasmguest for Go guests):
+//
+//	memory                          exported linear memory
+//	ocx_alloc(size i32) i32         buffer for host input, valid until the next call
+//	ocx_describe() i64              JSON {name, version, protocols, priority}
+//	ocx_can_handle(ptr, len) i32    1 if the payload is recognised
+//	ocx_parse(ptr, len) i64         JSON {payloads: [AIPayload...], error}
+//	ocx_evaluate(ptr, len) i64      optional; AIPayload JSON in, {verdict, reason} out
+//
+// i64 results pack a guest pointer and length as ptr<<32 | len. Guests may
+// import ocx.log(ptr, len) and WASI (no filesystem, network or environment
+// is granted). A reactor's _initialize runs when an instance is created.
+//
+// Modules directly in the plugin directory are platform plugins and run for
+// every tenant. Modules in a subdirectory belong to the tenant it is named
+// after (<dir>/<tenant-id>/*.wasm) and only see that tenant's traffic.
+
+// WASMConfig limits every module the loader runs.
+type WASMConfig struct {
+	Dir             string        // directory scanned for *.wasm and <tenant-id>/*.wasm
+	CallTimeout     time.Duration // CPU budget per call; the guest is interrupted when it expires
+	MaxMemoryPages  uint32        // 64 KiB pages per instance
+	MaxPayloadBytes int           // cap on input and output of one call
+	PoolSize        int           // idle instances kept per module
+}
+
+// WASMLoader compiles the modules in a directory, registers them as
+// connector plugins and reloads them in place when the files change.
+type WASMLoader struct {
+	cfg      WASMConfig
+	registry *Registry
+	runtime  wazero.Runtime
+
+	mu     sync.Mutex
+	byPath map[string]*WASMPlugin
+}
+
+// wasmPluginKey carries the calling plugin's name to host functions.
+type wasmPluginKey struct{}
+
+const (
+	guestLogMaxBytes  = 512 // longer ocx.log messages are truncated
+	guestLogPerSecond = 10  // lines per plugin per second; the rest are counted and dropped
+)
+
+// guestLogLimiter rate-limits ocx.log per plugin so a guest cannot flood
+// the host's logs.
+type guestLogLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*guestLogWindow
+}
+
+type guestLogWindow struct {
+	start   time.Time
+	lines   int
+	dropped int
+}
+
+// allow reports whether plugin may log a line now, and how many lines it
+// dropped in the window that just ended (reported once).
+func (g *guestLogLimiter) allow(plugin string, now time.Time) (bool, int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	w, ok := g.windows[plugin]
+	if !ok {
+		w = &guestLogWindow{start: now}
+		g.windows[plugin] = w
+	}
+	dropped := 0
+	if now.Sub(w.start) >= time.Second {
+		dropped = w.dropped
+		*w = guestLogWindow{start: now}
+	}
+	if w.lines >= guestLogPerSecond {
+		w.dropped++
+		return false, dropped
+	}
+	w.lines++
+	return true, dropped
+}
+
+// NewWASMLoader creates the shared runtime. wazero has no instruction
+// metering, so the CPU budget is enforced by cancelling the call's context,
+// which interrupts the guest at its next call or loop back-edge.
+func NewWASMLoader(ctx context.Context, cfg WASMConfig, registry *Registry) (*WASMLoader, error) {
+	if cfg.CallTimeout <= 0 {
+		cfg.CallTimeout = 50 * time.Millisecond
+	}
+	if cfg.MaxMemoryPages == 0 {
+		cfg.MaxMemoryPages = 1024 // 64 MiB
+	}
+	if cfg.MaxPayloadBytes <= 0 {
+		cfg.MaxPayloadBytes = 1 << 20
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 4
+	}
+
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(cfg.MaxMemoryPages))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("instantiate WASI: %w", err)
+	}
+	logs := &guestLogLimiter{windows: make(map[string]*guestLogWindow)}
+	_, err := rt.NewHostModuleBuilder("ocx").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+			name, _ := ctx.Value(wasmPluginKey{}).(string)
+			ok, dropped := logs.allow(name, time.Now())
+			if dropped > 0 {
+				slog.Debug("[WASM] guest log lines dropped", "plugin", name, "dropped", dropped)
+			}
+			if !ok {
+				return
+			}
+			if msg, ok := m.Memory().Read(ptr, min(size, guestLogMaxBytes)); ok {
+				slog.Debug("[WASM] guest log", "plugin", name, "msg", string(msg), "truncated", size > guestLogMaxBytes)
+			}
+		}).
+		Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("instantiate host module: %w", err)
+	}
+
+	return &WASMLoader{
+		cfg:      cfg,
+		registry: registry,
+		runtime:  rt,
+		byPath:   make(map[string]*WASMPlugin),
+	}, nil
+}
+
+// Reload compiles new and changed modules, swaps them into their registered
+// plugins and unregisters modules whose file is gone. It returns how many
+// modules were loaded or replaced.
+func (l *WASMLoader) Reload(ctx context.Context) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(l.cfg.Dir, "*.wasm"))
+	if err != nil {
+		return 0, fmt.Errorf("scan wasm dir: %w", err)
+	}
+	tenantPaths, err := filepath.Glob(filepath.Join(l.cfg.Dir, "*", "*.wasm"))
+	if err != nil {
+		return 0, fmt.Errorf("scan wasm dir: %w", err)
+	}
+	paths = append(paths, tenantPaths...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	changed := 0
+	var errs []error
+	present := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		present[path] = true
+		ok, err := l.loadLocked(ctx, path)
+		if err != nil {
+			slog.Warn("[WASM] module not loaded", "path", path, "error", err)
+			errs = append(errs, err)
+		}
+		if ok {
+			changed++
+		}
+	}
+
+	for path, p := range l.byPath {
+		if present[path] {
+			continue
+		}
+		l.registry.UnregisterForTenant(p.tenant, p.Name())
+		p.close(ctx)
+		delete(l.byPath, path)
+		slog.Info("[WASM] module removed", "plugin", p.Name(), "tenant", p.tenant, "path", path)
+	}
+	return changed, errors.Join(errs...)
+}
+
+// loadLocked (re)loads one module file. A module that fails to compile
+// leaves the previous version serving.
+func (l *WASMLoader) loadLocked(ctx context.Context, path string) (bool, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(code)
+	checksum := hex.EncodeToString(sum[:])
+
+	existing := l.byPath[path]
+	if existing != nil && existing.checksum() == checksum {
+		return false, nil
+	}
+
+	mod, err := l.compile(ctx, code, checksum)
+	if err != nil {
+		if existing != nil {
+			existing.setError(err)
+		}
+		return false, err
+	}
+
+	if existing != nil && existing.Name() == mod.info.Name {
+		old, repositioned := existing.swap(mod)
+		if repositioned {
+			// Priority changed: re-register to restore parse order
+			l.registry.UnregisterForTenant(existing.tenant, existing.Name())
+			if err := l.registry.RegisterForTenant(existing.tenant, existing); err != nil {
+				return false, err
+			}
+		}
+		old.close(ctx)
+		slog.Info("[WASM] module reloaded", "plugin", mod.info.Name, "version", mod.info.Version, "sha256", checksum[:12])
+		return true, nil
+	}
+
+	p := &WASMPlugin{loader: l, path: path, tenant: l.tenantOf(path), mod: mod}
+	if existing != nil {
+		l.registry.UnregisterForTenant(existing.tenant, existing.Name())
+		existing.close(ctx)
+	}
+	if err := l.registry.RegisterForTenant(p.tenant, p); err != nil {
+		delete(l.byPath, path)
+		mod.close(ctx)
+		return false, err
+	}
+	l.byPath[path] = p
+	slog.Info("[WASM] module loaded", "plugin", mod.info.Name, "version", mod.info.Version,
+		"tenant", p.tenant, "policy", mod.policy, "sha256", checksum[:12])
+	return true, nil
+}
+
+// tenantOf returns the tenant owning the module at path: the name of its
+// subdirectory, or "" for a platform module.
+func (l *WASMLoader) tenantOf(path string) string {
+	dir := filepath.Dir(path)
+	if dir == filepath.Clean(l.cfg.Dir) {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+// Watch polls the directory every interval and reloads changed modules
+// until ctx is cancelled.
+func (l *WASMLoader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.Reload(ctx)
+		}
+	}
+}
+
+// Close unregisters every module and releases the runtime.
+func (l *WASMLoader) Close(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for path, p := range l.byPath {
+		l.registry.UnregisterForTenant(p.tenant, p.Name())
+		p.close(ctx)
+		delete(l.byPath, path)
+	}
+	return l.runtime.Close(ctx)
+}
+
+// wasmModuleInfo is the guest's ocx_describe result.
+type wasmModuleInfo struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Protocols []string `json:"protocols"`
+	Priority  int      `json:"priority"`
+}
+
+// wasmModule is one compiled version of a plugin with its idle instances.
+type wasmModule struct {
+	loader   *WASMLoader
+	compiled wazero.CompiledModule
+	info     wasmModuleInfo
+	checksum string
+	policy   bool
+	idle     chan api.Module
+
+	// poolMu makes close and release mutually exclusive, so no instance is
+	// pooled after close has drained the pool.
+	poolMu sync.Mutex
+	closed bool
+}
+
+var requiredWASMExports = []string{"ocx_alloc", "ocx_describe", "ocx_can_handle", "ocx_parse"}
+
+func (l *WASMLoader) compile(ctx context.Context, code []byte, checksum string) (*wasmModule, error) {
+	compiled, err := l.runtime.CompileModule(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w", err)
+	}
+	exports := compiled.ExportedFunctions()
+	for _, name := range requiredWASMExports {
+		if _, ok := exports[name]; !ok {
+			compiled.Close(ctx)
+			return nil, fmt.Errorf("module does not export %s", name)
+		}
+	}
+	_, policy := exports["ocx_evaluate"]
+
+	mod := &wasmModule{
+		loader:   l,
+		compiled: compiled,
+		checksum: checksum,
+		policy:   policy,
+		idle:     make(chan api.Module, l.cfg.PoolSize),
+	}
+	out, err := mod.call(ctx, "", "ocx_describe", nil)
+	if err != nil {
+		mod.close(ctx)
+		return nil, fmt.Errorf("describe: %w", err)
+	}
+	if err := json.Unmarshal(out, &mod.info); err != nil || mod.info.Name == "" {
+		mod.close(ctx)
+		return nil, fmt.Errorf("describe: invalid result %q", out)
+	}
+	return mod, nil
+}
+
+// instance takes an idle instance or creates one. Each instance has its own
+// memory, so concurrent calls never share guest state.
+func (m *wasmModule) instance(ctx context.Context) (api.Module, error) {
+	select {
+	case inst := <-m.idle:
+		return inst, nil
+	default:
+	}
+	return m.loader.runtime.InstantiateModule(ctx, m.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+}
+
+// release returns a healthy instance to the pool, or closes it when the
+// module has been replaced meanwhile.
+func (m *wasmModule) release(inst api.Module) {
+	m.poolMu.Lock()
+	if !m.closed {
+		select {
+		case m.idle <- inst:
+			m.poolMu.Unlock()
+			return
+		default:
+		}
+	}
+	m.poolMu.Unlock()
+	inst.Close(context.Background())
+}
+
+// call runs one export within the CPU budget. input is copied into guest
+// memory via ocx_alloc; for a nil input the export takes no arguments. An
+// instance that traps or times out is discarded.
+func (m *wasmModule) call(ctx context.Context, plugin, export string, input []byte) ([]byte, error) {
+	if len(input) > m.loader.cfg.MaxPayloadBytes {
+		return nil, fmt.Errorf("payload of %d bytes exceeds %d", len(input), m.loader.cfg.MaxPayloadBytes)
+	}
+	// Instantiation runs the guest's _initialize and is not charged to the
+	// call's budget
+	inst, err := m.instance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("instantiate: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, wasmPluginKey{}, plugin), m.loader.cfg.CallTimeout)
+	defer cancel()
+	out, err := invoke(ctx, inst, export, input, m.loader.cfg.MaxPayloadBytes)
+	if err != nil {
+		inst.Close(context.Background())
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s exceeded its %s budget", export, m.loader.cfg.CallTimeout)
+		}
+		return nil, err
+	}
+	m.release(inst)
+	return out, nil
+}
+
+func invoke(ctx context.Context, inst api.Module, export string, input []byte, maxOut int) ([]byte, error) {
+	var args []uint64
+	if input != nil {
+		res, err := inst.ExportedFunction("ocx_alloc").Call(ctx, uint64(len(input)))
+		if err != nil {
+			return nil, fmt.Errorf("ocx_alloc: %w", err)
+		}
+		ptr := uint32(res[0])
+		if !inst.Memory().Write(ptr, input) {
+			return nil, fmt.Errorf("ocx_alloc returned out-of-range buffer")
+		}
+		args = []uint64{uint64(ptr), uint64(len(input))}
+	}
+
+	res, err := inst.ExportedFunction(export).Call(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", export, err)
+	}
+	if export == "ocx_can_handle" {
+		if res[0] != 0 {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	}
+
+	ptr, size := uint32(res[0]>>32), uint32(res[0])
+	if int(size) > maxOut {
+		return nil, fmt.Errorf("%s returned %d bytes, limit %d", export, size, maxOut)
+	}
+	view, ok := inst.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("%s returned out-of-range result", export)
+	}
+	return append([]byte(nil), view...), nil
+}
+
+func (m *wasmModule) close(ctx context.Context) {
+	m.poolMu.Lock()
+	defer m.poolMu.Unlock()
+	m.closed = true
+	for {
+		select {
+		case inst := <-m.idle:
+			inst.Close(ctx)
+		default:
+			m.compiled.Close(ctx)
+			return
+		}
+	}
+}
+
+// WASMPlugin adapts a WASM module to ConnectorPlugin and PolicyPlugin. A
+// reload swaps the module underneath; calls in flight finish on the old one.
+type WASMPlugin struct {
+	loader *WASMLoader
+	path   string
+	tenant string // owning tenant, "" for platform modules
+
+	mu      sync.RWMutex
+	mod     *wasmModule
+	reloads int
+	lastErr string
+}
+
+func (p *WASMPlugin) current() *wasmModule {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.mod
+}
+
+func (p *WASMPlugin) checksum() string { return p.current().checksum }
+
+// swap installs a new module version, returning the old one for closing
+// and whether the parse priority changed.
+func (p *WASMPlugin) swap(mod *wasmModule) (*wasmModule, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := p.mod
+	p.mod = mod
+	p.reloads++
+	p.lastErr = ""
+	return old, old.info.Priority != mod.info.Priority
+}
+
+func (p *WASMPlugin) setError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = err.Error()
+}
+
+// close releases the current module; instances still serving a call are
+// closed when they finish.
+func (p *WASMPlugin) close(ctx context.Context) {
+	p.current().close(ctx)
+}
+
+func (p *WASMPlugin) Name() string        { return p.current().info.Name }
+func (p *WASMPlugin) Version() string     { return p.current().info.Version }
+func (p *WASMPlugin) Protocols() []string { return p.current().info.Protocols }
+func (p *WASMPlugin) Priority() int       { return p.current().info.Priority }
+
+// CanHandle runs the guest's check; a trap or timeout counts as "no".
+func (p *WASMPlugin) CanHandle(payload []byte) bool {
+	mod := p.current()
+	out, err := mod.call(context.Background(), mod.info.Name, "ocx_can_handle", payload)
+	if err != nil {
+		p.setError(err)
+		return false
+	}
+	return out[0] == 1
+}
+
+// Parse returns the primary invocation in the payload.
+func (p *WASMPlugin) Parse(payload []byte) (*protocol.AIPayload, error) {
+	results, err := p.ParseAll(payload)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// ParseAll returns every invocation the module extracts from payload.
+func (p *WASMPlugin) ParseAll(payload []byte) ([]*protocol.AIPayload, error) {
+	mod := p.current()
+	out, err := mod.call(context.Background(), mod.info.Name, "ocx_parse", payload)
+	if err != nil {
+		p.setError(err)
+		return nil, fmt.Errorf("wasm plugin %s: %w", mod.info.Name, err)
+	}
+
+	var resp struct {
+		Payloads []*protocol.AIPayload `json:"payloads"`
+		Error    string                `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("wasm plugin %s: invalid parse result: %w", mod.info.Name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("wasm plugin %s: %s", mod.info.Name, resp.Error)
+	}
+	if len(resp.Payloads) == 0 {
+		return nil, fmt.Errorf("wasm plugin %s returned no payloads", mod.info.Name)
+	}
+	now := time.Now()
+	for _, r := range resp.Payloads {
+		r.DetectedAt = now
+	}
+	return resp.Payloads, nil
+}
+
+// EvaluatePolicy runs the module's ocx_evaluate on a tool call. Modules
+// without a policy allow everything.
+func (p *WASMPlugin) EvaluatePolicy(ctx context.Context, call *protocol.AIPayload) (PolicyDecision, error) {
+	mod := p.current()
+	if !mod.policy {
+		return PolicyDecision{Verdict: PolicyAllow}, nil
+	}
+	in, err := json.Marshal(call)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	out, err := mod.call(ctx, mod.info.Name, "ocx_evaluate", in)
+	if err != nil {
+		p.setError(err)
+		return PolicyDecision{}, err
+	}
+	var d PolicyDecision
+	if err := json.Unmarshal(out, &d); err != nil {
+		return PolicyDecision{}, fmt.Errorf("invalid policy result: %w", err)
+	}
+	return d, nil
+}
+
+// describeRuntime fills the module details shown by /api/v1/plugins.
+func (p *WASMPlugin) describeRuntime(info *PluginInfo) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	info.Runtime = "wasm"
+	info.Checksum = p.mod.checksum
+	info.Policy = p.mod.policy
+	info.Reloads = p.reloads
+	info.LastError = p.lastErr
+}
@@ -0,0 +1,78 @@
+//go:build wasip1
+
+// Command example is a WebAssembly plugin built with the Go guest SDK. It
+// parses the same in-house action envelope as cmd/connector-plugin-example,
+// blocks destructive tools and escalates large amounts.
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o inhouse.wasm ./pkg/plugins/wasmguest/example
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/ocx/backend/pkg/plugins/wasmguest"
+)
+
+type envelope struct {
+	Action *struct {
+		Tool   string                 `json:"tool"`
+		Params map[string]interface{} `json:"params"`
+		Agent  string                 `json:"agent"`
+		ID     string                 `json:"id"`
+	} `json:"agent_action"`
+}
+
+type parser struct{}
+
+func (parser) CanHandle(payload []byte) bool {
+	var env envelope
+	return json.Unmarshal(payload, &env) == nil && env.Action != nil && env.Action.Tool != ""
+}
+
+func (parser) Parse(payload []byte) ([]wasmguest.Payload, error) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil, err
+	}
+	if env.Action == nil || env.Action.Tool == "" {
+		return nil, errors.New("no agent_action.tool")
+	}
+	return []wasmguest.Payload{{
+		Protocol:    "CUSTOM_AGENT",
+		ToolName:    env.Action.Tool,
+		AgentID:     env.Action.Agent,
+		Arguments:   env.Action.Params,
+		MessageType: "tool_call",
+		Direction:   "request",
+		Confidence:  0.9,
+		CallID:      env.Action.ID,
+		Metadata:    map[string]interface{}{"framework": "inhouse", "runtime": "wasm"},
+	}}, nil
+}
+
+type policy struct{}
+
+func (policy) Evaluate(call wasmguest.Payload) wasmguest.Decision {
+	if strings.HasPrefix(call.ToolName, "delete_") || strings.HasPrefix(call.ToolName, "drop_") {
+		wasmguest.Log("blocking destructive tool " + call.ToolName)
+		return wasmguest.Decision{Verdict: wasmguest.Block, Reason: "destructive tools are disabled for this tenant"}
+	}
+	if amount, ok := call.Arguments["amount"].(float64); ok && amount > 10000 {
+		return wasmguest.Decision{Verdict: wasmguest.Escalate, Reason: "amount above tenant limit"}
+	}
+	return wasmguest.Decision{Verdict: wasmguest.Allow}
+}
+
+// A reactor's main never runs; registration happens during _initialize.
+func init() {
+	wasmguest.Register(wasmguest.Info{
+		Name:      "inhouse-wasm",
+		Version:   "1.0.0",
+		Protocols: []string{"inhouse"},
+		Priority:  45,
+	}, parser{}, policy{})
+}
+
+func main() {}
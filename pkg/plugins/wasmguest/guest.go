@@ -0,0 +1,180 @@
+//go:build wasip1
+
+// Package wasmguest is the Go guest SDK for OCX WebAssembly plugins. A
+// plugin registers its parser and, optionally, a policy from init (a
+// reactor's main is never called) and is built as a WASI reactor:
+//
+//	func init() {
+//		wasmguest.Register(wasmguest.Info{Name: "acme-actions", Version: "1.0.0"}, &parser{}, nil)
+//	}
+//	func main() {}
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o acme.wasm .
+//
+// The SDK implements the host ABI (see plugins.WASMLoader): payloads and
+// results cross the boundary as JSON in guest memory.
+package wasmguest
+
+import (
+	"encoding/json"
+	"unsafe"
+)
+
+// Info describes the plugin to the host.
+type Info struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Protocols []string `json:"protocols,omitempty"`
+	Priority  int      `json:"priority"`
+}
+
+// Payload mirrors the host's protocol.AIPayload JSON.
+type Payload struct {
+	Protocol    string                 `json:"protocol"`
+	ToolName    string                 `json:"tool_name"`
+	AgentID     string                 `json:"agent_id,omitempty"`
+	TenantID    string                 `json:"tenant_id,omitempty"`
+	TaskID      string                 `json:"task_id,omitempty"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	Model       string                 `json:"model,omitempty"`
+	MessageType string                 `json:"message_type"`
+	Direction   string                 `json:"direction"`
+	Confidence  float64                `json:"confidence"`
+	CallID      string                 `json:"call_id,omitempty"`
+	RawMethod   string                 `json:"raw_method,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Verdicts a policy may return.
+const (
+	Allow    = "ALLOW"
+	Block    = "BLOCK"
+	Escalate = "ESCALATE"
+)
+
+// Decision is a policy's verdict on one tool call.
+type Decision struct {
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Parser recognises and parses a payload format.
+type Parser interface {
+	CanHandle(payload []byte) bool
+	Parse(payload []byte) ([]Payload, error)
+}
+
+// Policy evaluates tool calls the gateway is about to govern.
+type Policy interface {
+	Evaluate(call Payload) Decision
+}
+
+var (
+	info   Info
+	parser Parser
+	policy Policy
+
+	// Buffers handed to the host stay referenced until the next call
+	pinned = map[uintptr][]byte{}
+)
+
+// Register installs the plugin. Either p or pol may be nil.
+func Register(i Info, p Parser, pol Policy) {
+	info, parser, policy = i, p, pol
+}
+
+// Log writes a message to the host's log.
+func Log(msg string) {
+	if msg == "" {
+		return
+	}
+	b := []byte(msg)
+	hostLog(uint32(uintptr(unsafe.Pointer(&b[0]))), uint32(len(b)))
+}
+
+//go:wasmimport ocx log
+func hostLog(ptr, size uint32)
+
+//go:wasmexport ocx_alloc
+func ocxAlloc(size uint32) uint32 {
+	if size == 0 {
+		size = 1
+	}
+	buf := make([]byte, size)
+	ptr := uintptr(unsafe.Pointer(&buf[0]))
+	pinned[ptr] = buf
+	return uint32(ptr)
+}
+
+//go:wasmexport ocx_describe
+func ocxDescribe() uint64 {
+	clear(pinned)
+	out, _ := json.Marshal(info)
+	return hostBytes(out)
+}
+
+//go:wasmexport ocx_can_handle
+func ocxCanHandle(ptr, size uint32) uint32 {
+	defer clear(pinned)
+	if parser == nil || !parser.CanHandle(guestBytes(ptr, size)) {
+		return 0
+	}
+	return 1
+}
+
+//go:wasmexport ocx_parse
+func ocxParse(ptr, size uint32) uint64 {
+	payload := guestBytes(ptr, size)
+	clear(pinned)
+
+	var resp struct {
+		Payloads []Payload `json:"payloads,omitempty"`
+		Error    string    `json:"error,omitempty"`
+	}
+	if parser == nil {
+		resp.Error = "plugin has no parser"
+	} else if payloads, err := parser.Parse(payload); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Payloads = payloads
+	}
+	out, _ := json.Marshal(resp)
+	return hostBytes(out)
+}
+
+//go:wasmexport ocx_evaluate
+func ocxEvaluate(ptr, size uint32) uint64 {
+	payload := guestBytes(ptr, size)
+	clear(pinned)
+
+	d := Decision{Verdict: Allow}
+	if policy != nil {
+		var call Payload
+		if err := json.Unmarshal(payload, &call); err != nil {
+			d = Decision{Verdict: Escalate, Reason: "malformed call: " + err.Error()}
+		} else {
+			d = policy.Evaluate(call)
+		}
+	}
+	out, _ := json.Marshal(d)
+	return hostBytes(out)
+}
+
+// guestBytes copies the host-written input out of its pinned buffer.
+func guestBytes(ptr, size uint32) []byte {
+	buf := pinned[uintptr(ptr)]
+	if uint32(len(buf)) < size {
+		return nil
+	}
+	return append([]byte(nil), buf[:size]...)
+}
+
+// hostBytes pins out and packs its address and length for the host.
+func hostBytes(out []byte) uint64 {
+	if len(out) == 0 {
+		return 0
+	}
+	ptr := uintptr(unsafe.Pointer(&out[0]))
+	pinned[ptr] = out
+	return uint64(ptr)<<32 | uint64(len(out))
+}
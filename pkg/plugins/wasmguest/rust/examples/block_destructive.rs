@@ -0,0 +1,28 @@
+//! Policy-only plugin: blocks destructive tools, parses nothing.
+use ocx_wasm_guest::{log, register_plugin, Decision, Info, Payload, Plugin};
+
+struct BlockDestructive;
+
+impl Plugin for BlockDestructive {
+    fn info(&self) -> Info {
+        Info { name: "block-destructive".into(), version: "0.1.0".into(), priority: 90, ..Default::default() }
+    }
+
+    fn can_handle(&self, _payload: &[u8]) -> bool {
+        false
+    }
+
+    fn parse(&self, _payload: &[u8]) -> Result<Vec<Payload>, String> {
+        Err("policy-only plugin".into())
+    }
+
+    fn evaluate(&self, call: &Payload) -> Decision {
+        if call.tool_name.starts_with("delete_") || call.tool_name.starts_with("drop_") {
+            log(&format!("blocking {}", call.tool_name));
+            return Decision::block("destructive tools are disabled for this tenant");
+        }
+        Decision::allow()
+    }
+}
+
+register_plugin!(BlockDestructive);
@@ -0,0 +1,195 @@
+//! Guest SDK for OCX WebAssembly plugins, the Rust counterpart of
+//! `pkg/plugins/wasmguest`. Implement [`Plugin`] and export it with
+//! [`register_plugin!`]; build for `wasm32-unknown-unknown` (or
+//! `wasm32-wasip1`) as a `cdylib`.
+//!
+//! ```ignore
+//! struct Acme;
+//! impl ocx_wasm_guest::Plugin for Acme { /* ... */ }
+//! ocx_wasm_guest::register_plugin!(Acme);
+//! ```
+//!
+//! Payloads and results cross the boundary as JSON in guest memory; see
+//! the ABI comment in `pkg/plugins/wasm.go`.
+
+use serde::{Deserialize, Serialize};
+use std::cell::RefCell;
+
+pub use serde_json::{Map, Value};
+
+#[doc(hidden)]
+pub use serde_json as __serde_json;
+
+/// Describes the plugin to the host.
+#[derive(Serialize, Clone, Debug, Default)]
+pub struct Info {
+    pub name: String,
+    pub version: String,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub protocols: Vec<String>,
+    pub priority: i32,
+}
+
+/// Mirrors the host's `protocol.AIPayload` JSON.
+#[derive(Serialize, Deserialize, Clone, Debug, Default)]
+#[serde(default)]
+pub struct Payload {
+    pub protocol: String,
+    pub tool_name: String,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub agent_id: String,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub tenant_id: String,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub task_id: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub arguments: Option<Map<String, Value>>,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub model: String,
+    pub message_type: String,
+    pub direction: String,
+    pub confidence: f64,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub call_id: String,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub raw_method: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub metadata: Option<Map<String, Value>>,
+}
+
+/// A policy's verdict on one tool call.
+#[derive(Serialize, Clone, Debug)]
+pub struct Decision {
+    pub verdict: &'static str,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub reason: String,
+}
+
+impl Decision {
+    pub fn allow() -> Self {
+        Decision { verdict: "ALLOW", reason: String::new() }
+    }
+    pub fn block(reason: impl Into<String>) -> Self {
+        Decision { verdict: "BLOCK", reason: reason.into() }
+    }
+    pub fn escalate(reason: impl Into<String>) -> Self {
+        Decision { verdict: "ESCALATE", reason: reason.into() }
+    }
+}
+
+/// A parser plugin, optionally with a policy.
+pub trait Plugin {
+    fn info(&self) -> Info;
+    fn can_handle(&self, payload: &[u8]) -> bool;
+    fn parse(&self, payload: &[u8]) -> Result<Vec<Payload>, String>;
+
+    /// Evaluates a tool call before classification. Allows by default.
+    fn evaluate(&self, _call: &Payload) -> Decision {
+        Decision::allow()
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+#[link(wasm_import_module = "ocx")]
+extern "C" {
+    #[link_name = "log"]
+    fn host_log(ptr: u32, len: u32);
+}
+
+/// Writes a message to the host's log.
+pub fn log(msg: &str) {
+    #[cfg(target_arch = "wasm32")]
+    unsafe {
+        host_log(msg.as_ptr() as u32, msg.len() as u32)
+    }
+    #[cfg(not(target_arch = "wasm32"))]
+    let _ = msg;
+}
+
+thread_local! {
+    // Buffers handed to the host stay alive until the next call
+    static PINNED: RefCell<Vec<Vec<u8>>> = RefCell::new(Vec::new());
+}
+
+#[doc(hidden)]
+pub fn __alloc(size: u32) -> u32 {
+    let mut buf = vec![0u8; size.max(1) as usize];
+    let ptr = buf.as_mut_ptr() as u32;
+    PINNED.with(|p| p.borrow_mut().push(buf));
+    ptr
+}
+
+#[doc(hidden)]
+pub fn __input(ptr: u32, len: u32) -> Vec<u8> {
+    let input = PINNED.with(|p| {
+        p.borrow()
+            .iter()
+            .find(|b| b.as_ptr() as u32 == ptr && b.len() >= len as usize)
+            .map(|b| b[..len as usize].to_vec())
+            .unwrap_or_default()
+    });
+    PINNED.with(|p| p.borrow_mut().clear());
+    input
+}
+
+#[doc(hidden)]
+pub fn __output<T: Serialize>(value: &T) -> u64 {
+    let out = serde_json::to_vec(value).unwrap_or_default();
+    if out.is_empty() {
+        return 0;
+    }
+    let packed = ((out.as_ptr() as u32 as u64) << 32) | out.len() as u64;
+    PINNED.with(|p| p.borrow_mut().push(out));
+    packed
+}
+
+#[doc(hidden)]
+#[derive(Serialize)]
+pub struct __ParseResponse {
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub payloads: Vec<Payload>,
+    #[serde(skip_serializing_if = "String::is_empty")]
+    pub error: String,
+}
+
+/// Exports the host ABI for a [`Plugin`] value.
+#[macro_export]
+macro_rules! register_plugin {
+    ($plugin:expr) => {
+        #[no_mangle]
+        pub extern "C" fn ocx_alloc(size: u32) -> u32 {
+            $crate::__alloc(size)
+        }
+
+        #[no_mangle]
+        pub extern "C" fn ocx_describe() -> u64 {
+            $crate::__output(&$crate::Plugin::info(&$plugin))
+        }
+
+        #[no_mangle]
+        pub extern "C" fn ocx_can_handle(ptr: u32, len: u32) -> u32 {
+            let input = $crate::__input(ptr, len);
+            $crate::Plugin::can_handle(&$plugin, &input) as u32
+        }
+
+        #[no_mangle]
+        pub extern "C" fn ocx_parse(ptr: u32, len: u32) -> u64 {
+            let input = $crate::__input(ptr, len);
+            let resp = match $crate::Plugin::parse(&$plugin, &input) {
+                Ok(payloads) => $crate::__ParseResponse { payloads, error: String::new() },
+                Err(error) => $crate::__ParseResponse { payloads: Vec::new(), error },
+            };
+            $crate::__output(&resp)
+        }
+
+        #[no_mangle]
+        pub extern "C" fn ocx_evaluate(ptr: u32, len: u32) -> u64 {
+            let input = $crate::__input(ptr, len);
+            let decision = match $crate::__serde_json::from_slice::<$crate::Payload>(&input) {
+                Ok(call) => $crate::Plugin::evaluate(&$plugin, &call),
+                Err(e) => $crate::Decision::escalate(format!("malformed call: {e}")),
+            };
+            $crate::__output(&decision)
+        }
+    };
+}